@@ -63,3 +63,19 @@ func NewKeyedTransactor(key *ecdsa.PrivateKey) *TransactOpts {
 		GasPrice: new(big.Int),
 	}
 }
+
+// NewKeyedEIP155Transactor is like NewKeyedTransactor, but the transactions
+// it signs are replay protected for the given chain (EIP-155).
+func NewKeyedEIP155Transactor(key *ecdsa.PrivateKey, chainId *big.Int) *TransactOpts {
+	keyAddr := crypto.PubkeyToAddress(key.PublicKey)
+	return &TransactOpts{
+		From: keyAddr,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != keyAddr {
+				return nil, errors.New("not authorized to sign this account")
+			}
+			return tx.SignECDSAEIP155(key, chainId)
+		},
+		GasPrice: new(big.Int),
+	}
+}
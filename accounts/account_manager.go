@@ -68,6 +68,8 @@ type Manager struct {
 	keyStore keyStore
 	mu       sync.RWMutex
 	unlocked map[common.Address]*unlocked
+
+	unlockHooks []func(common.Address) // called after every successful unlock
 }
 
 type unlocked struct {
@@ -77,9 +79,24 @@ type unlocked struct {
 
 // NewManager creates a manager for the given directory.
 func NewManager(keydir string, scryptN, scryptP int) *Manager {
+	return NewManagerWithCache(keydir, scryptN, scryptP, CacheConfig{})
+}
+
+// NewManagerWithCache creates a manager for the given directory, whose account
+// cache is refreshed and persisted as configured.
+func NewManagerWithCache(keydir string, scryptN, scryptP int, cache CacheConfig) *Manager {
 	keydir, _ = filepath.Abs(keydir)
 	am := &Manager{keyStore: &keyStorePassphrase{keydir, scryptN, scryptP}}
-	am.init(keydir)
+	am.init(newAddrCache(keydir, cache))
+	return am
+}
+
+// NewBackendManager creates a manager whose key files are kept in the given
+// backend instead of a directory. The paths of the key files of its accounts
+// are their names joined to the name of the backend.
+func NewBackendManager(backend KeyBackend, scryptN, scryptP int, cache CacheConfig) *Manager {
+	am := &Manager{keyStore: &keyStoreBackend{backend, scryptN, scryptP}}
+	am.init(newBackendAddrCache(backend, cache))
 	return am
 }
 
@@ -88,13 +105,13 @@ func NewManager(keydir string, scryptN, scryptP int) *Manager {
 func NewPlaintextManager(keydir string) *Manager {
 	keydir, _ = filepath.Abs(keydir)
 	am := &Manager{keyStore: &keyStorePlain{keydir}}
-	am.init(keydir)
+	am.init(newAddrCache(keydir, CacheConfig{}))
 	return am
 }
 
-func (am *Manager) init(keydir string) {
+func (am *Manager) init(cache *addrCache) {
 	am.unlocked = make(map[common.Address]*unlocked)
-	am.cache = newAddrCache(keydir)
+	am.cache = cache
 	// TODO: In order for this finalizer to work, there must be no references
 	// to am. addrCache doesn't keep a reference but unlocked keys do,
 	// so the finalizer will not trigger until all timed unlocks have expired.
@@ -140,7 +157,11 @@ func (am *Manager) DeleteAccount(a Account, passphrase string) error {
 	// The order is crucial here. The key is dropped from the
 	// cache after the file is gone so that a reload happening in
 	// between won't insert it into the cache again.
-	err = os.Remove(a.File)
+	if store, ok := am.keyStore.(*keyStoreBackend); ok {
+		err = store.backend.Delete(filepath.Base(a.File))
+	} else {
+		err = os.Remove(a.File)
+	}
 	if err == nil {
 		am.cache.delete(a)
 	}
@@ -214,7 +235,23 @@ func (am *Manager) TimedUnlock(a Account, passphrase string, timeout time.Durati
 	if err != nil {
 		return err
 	}
+	for _, hook := range am.storeUnlocked(a, key, timeout) {
+		hook(a.Address)
+	}
+	return nil
+}
 
+// OnUnlock registers a function which is called with the address of every
+// account successfully unlocked.
+func (am *Manager) OnUnlock(hook func(common.Address)) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.unlockHooks = append(am.unlockHooks, hook)
+}
+
+// storeUnlocked keeps the decrypted key of an unlocked account and returns the
+// unlock hooks to notify.
+func (am *Manager) storeUnlocked(a Account, key *Key, timeout time.Duration) []func(common.Address) {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 	u, found := am.unlocked[a.Address]
@@ -223,7 +260,7 @@ func (am *Manager) TimedUnlock(a Account, passphrase string, timeout time.Durati
 			// The address was unlocked indefinitely, so unlocking
 			// it with a timeout would be confusing.
 			zeroKey(key.PrivateKey)
-			return nil
+			return am.unlockHooks
 		} else {
 			// Terminate the expire goroutine and replace it below.
 			close(u.abort)
@@ -236,7 +273,7 @@ func (am *Manager) TimedUnlock(a Account, passphrase string, timeout time.Durati
 		u = &unlocked{Key: key}
 	}
 	am.unlocked[a.Address] = u
-	return nil
+	return am.unlockHooks
 }
 
 func (am *Manager) getDecryptedKey(a Account, auth string) (Account, *Key, error) {
@@ -300,9 +337,12 @@ func (am *Manager) Export(a Account, passphrase, newPassphrase string) (keyJSON
 		return nil, err
 	}
 	var N, P int
-	if store, ok := am.keyStore.(*keyStorePassphrase); ok {
+	switch store := am.keyStore.(type) {
+	case *keyStorePassphrase:
 		N, P = store.scryptN, store.scryptP
-	} else {
+	case *keyStoreBackend:
+		N, P = store.scryptN, store.scryptP
+	default:
 		N, P = StandardScryptN, StandardScryptP
 	}
 	return EncryptKey(key, newPassphrase, N, P)
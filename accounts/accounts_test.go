@@ -179,6 +179,70 @@ func TestOverrideUnlock(t *testing.T) {
 }
 
 // This test should fail under -race if signing races the expiration goroutine.
+func TestUnlockHook(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	var unlocked []common.Address
+	am.OnUnlock(func(addr common.Address) { unlocked = append(unlocked, addr) })
+
+	a1, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.Unlock(a1, "bar"); err == nil {
+		t.Fatal("Unlock with wrong passphrase succeeded")
+	}
+	if len(unlocked) != 0 {
+		t.Fatalf("hook called for failed unlock: %x", unlocked)
+	}
+	if err := am.TimedUnlock(a1, "foo", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if len(unlocked) != 1 || unlocked[0] != a1.Address {
+		t.Fatalf("hook not called for unlock: have %x, want %x", unlocked, a1.Address)
+	}
+}
+
+func TestInspect(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	before := time.Now().Add(-time.Second)
+	a1, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := am.Inspect(a1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Address != a1.Address || info.File != a1.File {
+		t.Errorf("wrong account: have %x (%s), want %x (%s)", info.Address, info.File, a1.Address, a1.File)
+	}
+	if info.KDF != "scrypt" {
+		t.Errorf("KDF mismatch: have %q, want scrypt", info.KDF)
+	}
+	if n, ok := info.KDFParams["n"].(float64); !ok || int(n) != veryLightScryptN {
+		t.Errorf("scrypt N mismatch: have %v, want %d", info.KDFParams["n"], veryLightScryptN)
+	}
+	if _, ok := info.KDFParams["salt"]; ok {
+		t.Error("salt included in KDF params")
+	}
+	if info.Created.Before(before) || info.Created.After(time.Now()) {
+		t.Errorf("creation time %v not within test run", info.Created)
+	}
+	if info.Unlocked {
+		t.Error("new account reported unlocked")
+	}
+	if err := am.Unlock(a1, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ = am.Inspect(a1); !info.Unlocked {
+		t.Error("unlocked account reported locked")
+	}
+}
+
 func TestSignRace(t *testing.T) {
 	dir, am := tmpManager(t, false)
 	defer os.RemoveAll(dir)
@@ -38,6 +38,38 @@ import (
 // exist yet, the code will attempt to create a watcher at most this often.
 const minReloadInterval = 2 * time.Second
 
+// cacheIndexVersion is the version of the persisted cache index, indexes of
+// other versions are discarded.
+const cacheIndexVersion = 1
+
+// CacheConfig configures the account cache of a Manager.
+type CacheConfig struct {
+	// Refresh is the minimum interval between rescans of the key directory
+	// when it can't be watched for changes, 2 seconds if zero.
+	Refresh time.Duration
+
+	// Index is the file the cache is persisted to between runs, so that only
+	// the key files changed since are parsed again. Not persisted if empty, nor
+	// for key backends.
+	Index string
+}
+
+// cachedFile is the address parsed from a key file, reused as long as the size
+// and modification time of the file don't change. The address of files which
+// aren't keys is zero.
+type cachedFile struct {
+	Size    int64          `json:"size"`
+	ModTime int64          `json:"mtime"` // Unix nanoseconds
+	Address common.Address `json:"address"`
+}
+
+// cacheIndex is the persisted form of the cache.
+type cacheIndex struct {
+	Version int                    `json:"version"`
+	KeyDir  string                 `json:"keydir"`
+	Files   map[string]*cachedFile `json:"files"` // By file name
+}
+
 type accountsByFile []Account
 
 func (s accountsByFile) Len() int           { return len(s) }
@@ -62,20 +94,51 @@ func (err *AmbiguousAddrError) Error() string {
 	return fmt.Sprintf("multiple keys match address (%s)", files)
 }
 
-// addrCache is a live index of all accounts in the keystore.
+// addrCache is a live index of all accounts in the keystore. Rescans of the
+// key directory only parse the files which are new or changed.
 type addrCache struct {
 	keydir   string
+	backend  KeyBackend // Holds the key files instead of keydir if set
+	config   CacheConfig
 	watcher  *watcher
 	mu       sync.Mutex
 	all      accountsByFile
 	byAddr   map[common.Address][]Account
+	files    map[string]*cachedFile // Key files by name, as of the last scan
+	dirty    bool                   // Whether files changed since the index was saved
 	throttle *time.Timer
 }
 
-func newAddrCache(keydir string) *addrCache {
+func newAddrCache(keydir string, config CacheConfig) *addrCache {
+	if config.Refresh == 0 {
+		config.Refresh = minReloadInterval
+	}
 	ac := &addrCache{
 		keydir: keydir,
+		config: config,
 		byAddr: make(map[common.Address][]Account),
+		files:  make(map[string]*cachedFile),
+	}
+	ac.watcher = newWatcher(ac)
+	if config.Index != "" {
+		ac.loadIndex()
+	}
+	return ac
+}
+
+// newBackendAddrCache creates a cache of the accounts in a key backend. As it
+// can't be watched for changes, it's listed again at most every refresh
+// interval, a minute by default.
+func newBackendAddrCache(backend KeyBackend, config CacheConfig) *addrCache {
+	if config.Refresh == 0 {
+		config.Refresh = backendReloadInterval
+	}
+	ac := &addrCache{
+		keydir:  backend.String(),
+		backend: backend,
+		config:  config,
+		byAddr:  make(map[common.Address][]Account),
+		files:   make(map[string]*cachedFile),
 	}
 	ac.watcher = newWatcher(ac)
 	return ac
@@ -183,9 +246,11 @@ func (ac *addrCache) maybeReload() {
 			return // The cache was reloaded recently.
 		}
 	}
-	ac.watcher.start()
+	if ac.backend == nil {
+		ac.watcher.start()
+	}
 	ac.reload()
-	ac.throttle.Reset(minReloadInterval)
+	ac.throttle.Reset(ac.config.Refresh)
 }
 
 func (ac *addrCache) close() {
@@ -200,8 +265,18 @@ func (ac *addrCache) close() {
 // reload caches addresses of existing accounts.
 // Callers must hold ac.mu.
 func (ac *addrCache) reload() {
-	accounts, err := ac.scan()
-	if err != nil && glog.V(logger.Debug) {
+	var (
+		accounts []Account
+		parsed   int
+		err      error
+	)
+	if ac.backend != nil {
+		// Keep the accounts known so far through outages of the backend
+		if accounts, parsed, err = ac.scanBackend(); err != nil {
+			glog.V(logger.Warn).Infof("can't list keys in %s: %v", ac.keydir, err)
+			return
+		}
+	} else if accounts, parsed, err = ac.scan(); err != nil && glog.V(logger.Debug) {
 		glog.Errorf("can't load keys: %v", err)
 	}
 	ac.all = accounts
@@ -212,18 +287,29 @@ func (ac *addrCache) reload() {
 	for _, a := range accounts {
 		ac.byAddr[a.Address] = append(ac.byAddr[a.Address], a)
 	}
-	glog.V(logger.Debug).Infof("reloaded keys, cache has %d accounts", len(ac.all))
+	glog.V(logger.Debug).Infof("reloaded keys, cache has %d accounts, parsed %d key files", len(ac.all), parsed)
+
+	if ac.dirty && ac.config.Index != "" {
+		if err := ac.saveIndex(); err != nil {
+			glog.V(logger.Warn).Infof("can't save keystore cache index %s: %v", ac.config.Index, err)
+		}
+	}
 }
 
-func (ac *addrCache) scan() ([]Account, error) {
+// scan lists the accounts in the key directory, parsing the files which
+// aren't cached or changed since and returning how many were.
+// Callers must hold ac.mu.
+func (ac *addrCache) scan() ([]Account, int, error) {
 	files, err := ioutil.ReadDir(ac.keydir)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var (
 		buf     = new(bufio.Reader)
 		addrs   []Account
+		parsed  int
+		seen    = make(map[string]bool, len(files))
 		keyJSON struct {
 			Address common.Address `json:"address"`
 		}
@@ -234,6 +320,13 @@ func (ac *addrCache) scan() ([]Account, error) {
 			glog.V(logger.Detail).Infof("ignoring file %s", path)
 			continue
 		}
+		seen[fi.Name()] = true
+		if cached := ac.files[fi.Name()]; cached != nil && cached.Size == fi.Size() && cached.ModTime == fi.ModTime().UnixNano() {
+			if (cached.Address != common.Address{}) {
+				addrs = append(addrs, Account{Address: cached.Address, File: path})
+			}
+			continue
+		}
 		fd, err := os.Open(path)
 		if err != nil {
 			glog.V(logger.Detail).Infoln(err)
@@ -246,14 +339,127 @@ func (ac *addrCache) scan() ([]Account, error) {
 		switch {
 		case err != nil:
 			glog.V(logger.Debug).Infof("can't decode key %s: %v", path, err)
+			keyJSON.Address = common.Address{}
 		case (keyJSON.Address == common.Address{}):
 			glog.V(logger.Debug).Infof("can't decode key %s: missing or zero address", path)
 		default:
 			addrs = append(addrs, Account{Address: keyJSON.Address, File: path})
 		}
 		fd.Close()
+
+		ac.files[fi.Name()] = &cachedFile{Size: fi.Size(), ModTime: fi.ModTime().UnixNano(), Address: keyJSON.Address}
+		ac.dirty = true
+		parsed++
+	}
+	for name := range ac.files {
+		if !seen[name] {
+			delete(ac.files, name)
+			ac.dirty = true
+		}
+	}
+	return addrs, parsed, nil
+}
+
+// scanBackend lists the accounts in the key backend. Key files are only read
+// when first seen, their names don't change with their contents like the
+// modification times of files do, but they only ever hold the key of the same
+// address.
+// Callers must hold ac.mu.
+func (ac *addrCache) scanBackend() ([]Account, int, error) {
+	names, err := ac.backend.List()
+	if err != nil {
+		return nil, 0, err
+	}
+	var (
+		addrs   []Account
+		parsed  int
+		seen    = make(map[string]bool, len(names))
+		keyJSON struct {
+			Address common.Address `json:"address"`
+		}
+	)
+	for _, name := range names {
+		path := filepath.Join(ac.keydir, name)
+		if strings.HasSuffix(name, "/") || strings.HasPrefix(name, ".") {
+			glog.V(logger.Detail).Infof("ignoring key %s", path)
+			continue
+		}
+		seen[name] = true
+		cached := ac.files[name]
+		if cached == nil {
+			blob, err := ac.backend.Read(name)
+			if err != nil {
+				glog.V(logger.Debug).Infof("can't read key %s: %v", path, err)
+				continue
+			}
+			keyJSON.Address = common.Address{}
+			if err := json.Unmarshal(blob, &keyJSON); err != nil {
+				glog.V(logger.Debug).Infof("can't decode key %s: %v", path, err)
+				keyJSON.Address = common.Address{}
+			}
+			cached = &cachedFile{Address: keyJSON.Address}
+			ac.files[name] = cached
+			parsed++
+		}
+		if (cached.Address != common.Address{}) {
+			addrs = append(addrs, Account{Address: cached.Address, File: path})
+		}
+	}
+	for name := range ac.files {
+		if !seen[name] {
+			delete(ac.files, name)
+		}
+	}
+	return addrs, parsed, nil
+}
+
+// loadIndex fills the cache of key files from the persisted index, which is
+// ignored if it's missing, invalid or of another key directory.
+func (ac *addrCache) loadIndex() {
+	blob, err := ioutil.ReadFile(ac.config.Index)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(logger.Warn).Infof("can't read keystore cache index %s: %v", ac.config.Index, err)
+		}
+		return
+	}
+	var index cacheIndex
+	if err := json.Unmarshal(blob, &index); err != nil {
+		glog.V(logger.Warn).Infof("ignoring invalid keystore cache index %s: %v", ac.config.Index, err)
+		return
+	}
+	if index.Version != cacheIndexVersion || index.KeyDir != ac.keydir {
+		glog.V(logger.Debug).Infof("ignoring keystore cache index %s of version %d for %s", ac.config.Index, index.Version, index.KeyDir)
+		return
+	}
+	for name, cached := range index.Files {
+		if cached != nil {
+			ac.files[name] = cached
+		}
+	}
+	glog.V(logger.Debug).Infof("loaded keystore cache index of %d files", len(ac.files))
+}
+
+// saveIndex persists the cache of key files, replacing the index atomically.
+// Callers must hold ac.mu.
+func (ac *addrCache) saveIndex() error {
+	blob, err := json.Marshal(&cacheIndex{Version: cacheIndexVersion, KeyDir: ac.keydir, Files: ac.files})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(ac.config.Index), 0700); err != nil {
+		return err
+	}
+	tmp := ac.config.Index + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ac.config.Index); err != nil {
+		os.Remove(tmp)
+		return err
 	}
-	return addrs, err
+	ac.dirty = false
+	return nil
 }
 
 func skipKeyFile(fi os.FileInfo) bool {
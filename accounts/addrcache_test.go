@@ -18,6 +18,7 @@ package accounts
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -118,15 +119,79 @@ func TestWatchNoDir(t *testing.T) {
 }
 
 func TestCacheInitialReload(t *testing.T) {
-	cache := newAddrCache(cachetestDir)
+	cache := newAddrCache(cachetestDir, CacheConfig{})
 	accounts := cache.accounts()
 	if !reflect.DeepEqual(accounts, cachetestAccounts) {
 		t.Fatalf("got initial accounts: %swant %s", spew.Sdump(accounts), spew.Sdump(cachetestAccounts))
 	}
 }
 
+// Tests that the persisted cache index spares parsing unchanged key files on
+// restart, while new, changed and removed ones are picked up.
+func TestCacheIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-keystore-index-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keydir := filepath.Join(dir, "keystore")
+	os.MkdirAll(keydir, 0700)
+	wantAccounts := make([]Account, len(cachetestAccounts))
+	for i, a := range cachetestAccounts {
+		wantAccounts[i] = Account{Address: a.Address, File: filepath.Join(keydir, filepath.Base(a.File))}
+		if err := cp.CopyFile(wantAccounts[i].File, a.File); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := CacheConfig{Index: filepath.Join(dir, "keystore.index")}
+	cache := newAddrCache(keydir, config)
+	cache.watcher.running = true // prevent unexpected reloads
+	cache.mu.Lock()
+	cache.reload()
+	cache.mu.Unlock()
+
+	// Rewrite a key file in place with another address but its size and
+	// modification time: it's not parsed again by a restarted cache
+	replaced := wantAccounts[1].File
+	fi, _ := os.Stat(replaced)
+	blob, _ := ioutil.ReadFile(wantAccounts[2].File)
+	if err := ioutil.WriteFile(replaced, blob, 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(replaced, fi.ModTime(), fi.ModTime())
+	os.Remove(wantAccounts[0].File)
+
+	cache = newAddrCache(keydir, config)
+	cache.watcher.running = true
+	cache.mu.Lock()
+	accounts, parsed, err := cache.scan()
+	cache.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(accounts, wantAccounts[1:]) {
+		t.Errorf("got accounts: %swant %s", spew.Sdump(accounts), spew.Sdump(wantAccounts[1:]))
+	}
+	if parsed != 0 {
+		t.Errorf("parsed %d unchanged key files", parsed)
+	}
+	if _, ok := cache.files[filepath.Base(wantAccounts[0].File)]; ok || !cache.dirty {
+		t.Error("removed key file still cached")
+	}
+
+	// Changed files are parsed, while an index of another directory is ignored
+	os.Chtimes(replaced, fi.ModTime(), fi.ModTime().Add(time.Second))
+	if _, parsed, _ := cache.scan(); parsed != 1 {
+		t.Errorf("parsed %d changed key files, want 1", parsed)
+	}
+	if cache := newAddrCache(dir, config); len(cache.files) != 0 {
+		t.Errorf("loaded the index of another directory: %d files", len(cache.files))
+	}
+}
+
 func TestCacheAddDeleteOrder(t *testing.T) {
-	cache := newAddrCache("testdata/no-such-dir")
+	cache := newAddrCache("testdata/no-such-dir", CacheConfig{})
 	cache.watcher.running = true // prevent unexpected reloads
 
 	accounts := []Account{
@@ -211,7 +276,7 @@ func TestCacheAddDeleteOrder(t *testing.T) {
 
 func TestCacheFind(t *testing.T) {
 	dir := filepath.Join("testdata", "dir")
-	cache := newAddrCache(dir)
+	cache := newAddrCache(dir, CacheConfig{})
 	cache.watcher.running = true // prevent unexpected reloads
 
 	accounts := []Account{
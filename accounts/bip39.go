@@ -0,0 +1,115 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto/randentropy"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultMnemonicBits is the entropy of generated mnemonics, giving 24 words.
+const DefaultMnemonicBits = 256
+
+var (
+	ErrMnemonicChecksum = errors.New("invalid mnemonic checksum")
+
+	bip39Index = make(map[string]int, len(bip39English))
+)
+
+func init() {
+	for i, word := range bip39English {
+		bip39Index[word] = i
+	}
+}
+
+// NewMnemonic generates a BIP-39 mnemonic encoding the given number of random
+// bits, which must be a multiple of 32 between 128 and 256.
+func NewMnemonic(bits int) (string, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", fmt.Errorf("invalid mnemonic entropy size %d", bits)
+	}
+	return entropyToMnemonic(randentropy.GetEntropyCSPRNG(bits / 8)), nil
+}
+
+// entropyToMnemonic encodes the entropy, followed by the first len/32 bits of
+// its SHA-256 hash as checksum, into words of 11 bits each.
+func entropyToMnemonic(entropy []byte) string {
+	var (
+		bits     = len(entropy) * 8
+		checksum = sha256.Sum256(entropy)
+		cs       = uint(bits / 32)
+	)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, cs)
+	data.Or(data, big.NewInt(int64(checksum[0]>>(8-cs))))
+
+	words := make([]string, (bits+int(cs))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39English[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " ")
+}
+
+// ValidateMnemonic checks that the mnemonic consists of words of the BIP-39
+// English wordlist and that its checksum is correct.
+func ValidateMnemonic(mnemonic string) error {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return fmt.Errorf("invalid mnemonic length %d, expected 12, 15, 18, 21 or 24 words", len(words))
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := bip39Index[word]
+		if !ok {
+			return fmt.Errorf("invalid mnemonic word %q", word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	var (
+		cs       = uint(len(words) / 3)
+		checksum = new(big.Int).And(data, big.NewInt(int64(1<<cs-1)))
+		entropy  = make([]byte, int(uint(len(words)*11)-cs)/8)
+	)
+	data.Rsh(data, cs)
+	b := data.Bytes()
+	copy(entropy[len(entropy)-len(b):], b)
+
+	hash := sha256.Sum256(entropy)
+	if checksum.Int64() != int64(hash[0]>>(8-cs)) {
+		return ErrMnemonicChecksum
+	}
+	return nil
+}
+
+// MnemonicToSeed derives the BIP-39 seed of a mnemonic, protected by an
+// optional password. The mnemonic is not validated.
+func MnemonicToSeed(mnemonic, password string) []byte {
+	mnemonic = norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String("mnemonic" + password)
+	return pbkdf2.Key([]byte(mnemonic), []byte(salt), 2048, 64, sha512.New)
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+)
+
+// HardenedKeyStart is the index of the first hardened BIP-32 child key.
+const HardenedKeyStart = 0x80000000
+
+// DefaultHDPath is the BIP-44 path of the first Ethereum account, m/44'/60'/0'/0/0.
+var DefaultHDPath = DerivationPath{HardenedKeyStart + 44, HardenedKeyStart + 60, HardenedKeyStart, 0, 0}
+
+var errInvalidChildKey = errors.New("derived child key is invalid, use the next index")
+
+// DerivationPath is a BIP-32 path of child key indexes, starting at the master
+// key. Hardened indexes are offset by HardenedKeyStart.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path like m/44'/60'/0'/0/0. Hardened indexes
+// are marked with ' or h. The leading m/ is optional.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	components := strings.Split(strings.TrimSpace(path), "/")
+	if components[0] == "m" {
+		components = components[1:]
+	}
+	if len(components) == 0 || components[0] == "" {
+		return nil, fmt.Errorf("empty derivation path %q", path)
+	}
+	result := make(DerivationPath, len(components))
+	for i, component := range components {
+		offset := uint64(0)
+		if strings.HasSuffix(component, "'") || strings.HasSuffix(component, "h") {
+			offset = HardenedKeyStart
+			component = component[:len(component)-1]
+		}
+		index, err := strconv.ParseUint(component, 10, 32)
+		if err != nil || index >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid component %q in derivation path %q", components[i], path)
+		}
+		result[i] = uint32(index + offset)
+	}
+	return result, nil
+}
+
+// String implements fmt.Stringer, formatting hardened indexes with a '.
+func (path DerivationPath) String() string {
+	result := "m"
+	for _, index := range path {
+		if index >= HardenedKeyStart {
+			result += fmt.Sprintf("/%d'", index-HardenedKeyStart)
+		} else {
+			result += fmt.Sprintf("/%d", index)
+		}
+	}
+	return result
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (path DerivationPath) MarshalText() ([]byte, error) {
+	return []byte(path.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (path *DerivationPath) UnmarshalText(text []byte) error {
+	parsed, err := ParseDerivationPath(string(text))
+	if err != nil {
+		return err
+	}
+	*path = parsed
+	return nil
+}
+
+// extendedKey is a BIP-32 private key together with its chain code.
+type extendedKey struct {
+	key       []byte // 32 byte private key
+	chainCode []byte // 32 byte chain code
+}
+
+// newMasterKey derives the BIP-32 master key of a seed.
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	key := new(big.Int).SetBytes(sum[:32])
+	if key.Sign() == 0 || key.Cmp(secp256k1.S256().N) >= 0 {
+		return nil, errors.New("seed yields an invalid master key")
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// child derives the private child key with the given index.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	var data []byte
+	if index >= HardenedKeyStart {
+		data = append([]byte{0x00}, k.key...)
+	} else {
+		data = compressedPubkey(k.key)
+	}
+	data = append(data, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(data[len(data)-4:], index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curveN := secp256k1.S256().N
+	tweak := new(big.Int).SetBytes(sum[:32])
+	if tweak.Cmp(curveN) >= 0 {
+		return nil, errInvalidChildKey
+	}
+	key := tweak.Add(tweak, new(big.Int).SetBytes(k.key))
+	key.Mod(key, curveN)
+	if key.Sign() == 0 {
+		return nil, errInvalidChildKey
+	}
+	return &extendedKey{key: common.LeftPadBytes(key.Bytes(), 32), chainCode: sum[32:]}, nil
+}
+
+// derive derives the private key at the given path from the master key.
+func (k *extendedKey) derive(path DerivationPath) (*ecdsa.PrivateKey, error) {
+	var err error
+	for i, index := range path {
+		if k, err = k.child(index); err != nil {
+			return nil, fmt.Errorf("%v: %v", DerivationPath(path[:i+1]), err)
+		}
+	}
+	return crypto.ToECDSA(k.key), nil
+}
+
+// compressedPubkey returns the SEC1 compressed public key of a private key.
+func compressedPubkey(key []byte) []byte {
+	x, y := secp256k1.S256().ScalarBaseMult(key)
+	pub := make([]byte, 33)
+	pub[0] = 0x02 | byte(y.Bit(0))
+	copy(pub[1:], common.LeftPadBytes(x.Bytes(), 32))
+	return pub
+}
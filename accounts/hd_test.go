@@ -0,0 +1,210 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// Tests mnemonic encoding and seed derivation against the BIP-39 test vectors.
+func TestMnemonic(t *testing.T) {
+	tests := []struct {
+		entropy, mnemonic, seed string
+	}{
+		{
+			"00000000000000000000000000000000",
+			testMnemonic,
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+	for i, test := range tests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		if mnemonic := entropyToMnemonic(entropy); mnemonic != test.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, test.mnemonic)
+		}
+		if err := ValidateMnemonic(test.mnemonic); err != nil {
+			t.Errorf("test %d: valid mnemonic rejected: %v", i, err)
+		}
+		if seed := hex.EncodeToString(MnemonicToSeed(test.mnemonic, "TREZOR")); seed != test.seed {
+			t.Errorf("test %d: seed mismatch: have %s, want %s", i, seed, test.seed)
+		}
+	}
+	if err := ValidateMnemonic(strings.Replace(testMnemonic, "about", "abandon", 1)); err != ErrMnemonicChecksum {
+		t.Errorf("bad checksum: have %v, want %v", err, ErrMnemonicChecksum)
+	}
+	if err := ValidateMnemonic(strings.Replace(testMnemonic, "about", "aboot", 1)); err == nil {
+		t.Error("unknown word accepted")
+	}
+	mnemonic, err := NewMnemonic(DefaultMnemonicBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words := strings.Fields(mnemonic); len(words) != 24 {
+		t.Errorf("generated mnemonic has %d words, want 24", len(words))
+	}
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		t.Errorf("generated mnemonic invalid: %v", err)
+	}
+}
+
+// Tests key derivation against BIP-32 test vector 1.
+func TestHDDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key := hex.EncodeToString(master.key); key != "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35" {
+		t.Errorf("master key mismatch: have %s", key)
+	}
+	if chainCode := hex.EncodeToString(master.chainCode); chainCode != "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508" {
+		t.Errorf("master chain code mismatch: have %s", chainCode)
+	}
+	tests := []struct {
+		path, key string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'/2/1000000000", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		key, err := master.derive(path)
+		if err != nil {
+			t.Fatalf("%s: %v", test.path, err)
+		}
+		if have := hex.EncodeToString(crypto.FromECDSA(key)); have != test.key {
+			t.Errorf("%s: key mismatch: have %s, want %s", test.path, have, test.key)
+		}
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		input  string
+		output DerivationPath
+	}{
+		{"m/44'/60'/0'/0/0", DefaultHDPath},
+		{"44h/60h/0h/0/0", DefaultHDPath},
+		{"m/0/2147483647'", DerivationPath{0, 0xffffffff}},
+		{"m", nil},
+		{"m/", nil},
+		{"m/2147483648", nil},
+		{"m/-1", nil},
+		{"m/44''", nil},
+	}
+	for _, test := range tests {
+		path, err := ParseDerivationPath(test.input)
+		if test.output == nil {
+			if err == nil {
+				t.Errorf("%q: expected error, got %v", test.input, path)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(path, test.output) {
+			t.Errorf("%q: have %v (%v), want %v", test.input, path, err, test.output)
+		}
+	}
+	if s := DefaultHDPath.String(); s != "m/44'/60'/0'/0/0" {
+		t.Errorf("path string mismatch: have %s", s)
+	}
+}
+
+func TestHDWallet(t *testing.T) {
+	dir, am := tmpManager(t, true)
+	defer os.RemoveAll(dir)
+
+	if _, err := am.DeriveHDAccount(DefaultHDPath, "foo"); err != ErrNoHDWallet {
+		t.Fatalf("derivation without wallet: have %v, want %v", err, ErrNoHDWallet)
+	}
+	if _, err := am.NewHDWallet(testMnemonic, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := am.NewHDWallet("", "foo"); err != ErrHDWalletExists {
+		t.Fatalf("second wallet: have %v, want %v", err, ErrHDWalletExists)
+	}
+	if len(am.Accounts()) != 0 {
+		t.Fatalf("wallet seed listed as account: %v", am.Accounts())
+	}
+	if _, err := am.DeriveHDAccount(DefaultHDPath, "bar"); err != ErrDecrypt {
+		t.Fatalf("wrong passphrase: have %v, want %v", err, ErrDecrypt)
+	}
+	// The first account of the test mnemonic, as derived by other wallets
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	addr, err := am.DeriveHDAccount(DefaultHDPath, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != want {
+		t.Fatalf("address mismatch: have %x, want %x", addr, want)
+	}
+
+	sig, err := am.SignHDWithPassphrase(DefaultHDPath, "foo", testSigData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer := recoverSigner(t, sig); signer != want {
+		t.Errorf("signer mismatch: have %x, want %x", signer, want)
+	}
+
+	if _, err := am.UnlockHDAccount(DefaultHDPath, "foo", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	sig, err = am.SignEthereum(want, testSigData)
+	if err != nil {
+		t.Fatal("signing with unlocked HD account failed:", err)
+	}
+	if signer := recoverSigner(t, sig); signer != want {
+		t.Errorf("unlocked signer mismatch: have %x, want %x", signer, want)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if _, err := am.SignEthereum(want, testSigData); err != ErrLocked {
+		t.Errorf("signing after timeout: have %v, want %v", err, ErrLocked)
+	}
+}
+
+// recoverSigner returns the address which created a signature in Ethereum format.
+func recoverSigner(t *testing.T, sig []byte) common.Address {
+	pub, err := crypto.Ecrecover(testSigData, append(sig[:64:64], sig[64]-27))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return common.BytesToAddress(crypto.Keccak256(pub[1:])[12:])
+}
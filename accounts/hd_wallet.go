@@ -0,0 +1,194 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pborman/uuid"
+)
+
+// hdWalletFile is the location of the encrypted HD wallet seed within the key
+// directory. It lives in a subdirectory so that it isn't taken for a key file.
+var hdWalletFile = filepath.Join("hd", "seed.json")
+
+var (
+	ErrNoHDWallet     = errors.New("keystore has no HD wallet")
+	ErrHDWalletExists = errors.New("keystore already has an HD wallet")
+	ErrHDUnsupported  = errors.New("HD wallets require an encrypted keystore directory")
+)
+
+// hdWalletJSON is the on-disk format of the HD wallet seed, encrypted like a
+// key file. The address is the one of the master key and identifies the wallet.
+type hdWalletJSON struct {
+	Address string     `json:"address"`
+	Crypto  cryptoJSON `json:"crypto"`
+	Id      string     `json:"id"`
+	Version int        `json:"version"`
+}
+
+// NewHDWallet stores the BIP-39 seed of the given mnemonic in the key directory,
+// encrypted with the passphrase. If mnemonic is empty a new one is generated.
+// The mnemonic is returned, it is the only backup of the wallet.
+//
+// Accounts of the wallet aren't stored as key files. They are addressed by
+// their BIP-32 derivation path instead, see DeriveHDAccount.
+func (am *Manager) NewHDWallet(mnemonic, passphrase string) (string, error) {
+	ks, ok := am.keyStore.(*keyStorePassphrase)
+	if !ok {
+		return "", ErrHDUnsupported
+	}
+	if mnemonic == "" {
+		var err error
+		if mnemonic, err = NewMnemonic(DefaultMnemonicBits); err != nil {
+			return "", err
+		}
+	} else if err := ValidateMnemonic(mnemonic); err != nil {
+		return "", err
+	}
+	seed := MnemonicToSeed(mnemonic, "")
+	defer zeroBytes(seed)
+
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return "", err
+	}
+	cryptoStruct, err := encryptData(seed, passphrase, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return "", err
+	}
+	masterKey := crypto.ToECDSA(master.key)
+	defer zeroKey(masterKey)
+
+	walletjson, err := json.Marshal(hdWalletJSON{
+		Address: hex.EncodeToString(crypto.PubkeyToAddress(masterKey.PublicKey).Bytes()),
+		Crypto:  cryptoStruct,
+		Id:      uuid.NewRandom().String(),
+		Version: version,
+	})
+	if err != nil {
+		return "", err
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	file := am.keyStore.JoinPath(hdWalletFile)
+	if _, err := os.Stat(file); err == nil {
+		return "", ErrHDWalletExists
+	}
+	if err := writeKeyFile(file, walletjson); err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// HDWallet returns the address of the master key of the HD wallet, which
+// identifies it.
+func (am *Manager) HDWallet() (common.Address, error) {
+	wallet, err := am.readHDWallet()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.HexToAddress(wallet.Address), nil
+}
+
+// DeriveHDAccount returns the address of the HD wallet account at the given
+// derivation path. The passphrase is needed to decrypt the wallet seed.
+func (am *Manager) DeriveHDAccount(path DerivationPath, passphrase string) (common.Address, error) {
+	key, err := am.getHDKey(path, passphrase)
+	if err != nil {
+		return common.Address{}, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return key.Address, nil
+}
+
+// UnlockHDAccount derives the HD wallet account at the given path and unlocks
+// it for the duration of timeout, like TimedUnlock does for key files. While
+// unlocked, the account can be used with Sign and SignEthereum.
+func (am *Manager) UnlockHDAccount(path DerivationPath, passphrase string, timeout time.Duration) (common.Address, error) {
+	key, err := am.getHDKey(path, passphrase)
+	if err != nil {
+		return common.Address{}, err
+	}
+	for _, hook := range am.storeUnlocked(Account{Address: key.Address}, key, timeout) {
+		hook(key.Address)
+	}
+	return key.Address, nil
+}
+
+// SignHDWithPassphrase signs hash with the HD wallet account at the given path
+// if the wallet seed can be decrypted with the given passphrase.
+func (am *Manager) SignHDWithPassphrase(path DerivationPath, passphrase string, hash []byte) ([]byte, error) {
+	key, err := am.getHDKey(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+	return crypto.SignEthereum(hash, key.PrivateKey)
+}
+
+func (am *Manager) readHDWallet() (*hdWalletJSON, error) {
+	walletjson, err := ioutil.ReadFile(am.keyStore.JoinPath(hdWalletFile))
+	if os.IsNotExist(err) {
+		return nil, ErrNoHDWallet
+	} else if err != nil {
+		return nil, err
+	}
+	wallet := new(hdWalletJSON)
+	if err := json.Unmarshal(walletjson, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// getHDKey decrypts the HD wallet seed and derives the key at the given path.
+func (am *Manager) getHDKey(path DerivationPath, passphrase string) (*Key, error) {
+	wallet, err := am.readHDWallet()
+	if err != nil {
+		return nil, err
+	}
+	seed, err := decryptData(wallet.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := master.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return newKeyFromECDSA(privateKey), nil
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// KeyInfo describes the provenance of the key file of an account.
+type KeyInfo struct {
+	Address   common.Address         `json:"address"`
+	File      string                 `json:"file"`
+	Version   interface{}            `json:"version"`
+	Created   time.Time              `json:"created"`
+	Cipher    string                 `json:"cipher,omitempty"`
+	KDF       string                 `json:"kdf,omitempty"`
+	KDFParams map[string]interface{} `json:"kdfparams,omitempty"`
+	Unlocked  bool                   `json:"unlocked"`
+}
+
+// keyFileTimeLayout is the layout of the creation time in key file names
+// written by keyFileName.
+const keyFileTimeLayout = "2006-01-02T15-04-05.999999999Z"
+
+// Inspect reads the metadata of the key file of the given account without
+// decrypting it. The salt is left out of the KDF parameters.
+func (am *Manager) Inspect(a Account) (*KeyInfo, error) {
+	a, err := am.cache.find(a)
+	if err != nil {
+		return nil, err
+	}
+	var raw []byte
+	store, backed := am.keyStore.(*keyStoreBackend)
+	if backed {
+		raw, err = store.backend.Read(filepath.Base(a.File))
+	} else {
+		raw, err = ioutil.ReadFile(a.File)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keyJSON struct {
+		Version interface{} `json:"version"`
+		Crypto  struct {
+			Cipher    string                 `json:"cipher"`
+			KDF       string                 `json:"kdf"`
+			KDFParams map[string]interface{} `json:"kdfparams"`
+		} `json:"crypto"`
+	}
+	if err := json.Unmarshal(raw, &keyJSON); err != nil {
+		return nil, err
+	}
+	info := &KeyInfo{
+		Address:   a.Address,
+		File:      a.File,
+		Version:   keyJSON.Version,
+		Cipher:    keyJSON.Crypto.Cipher,
+		KDF:       keyJSON.Crypto.KDF,
+		KDFParams: keyJSON.Crypto.KDFParams,
+	}
+	delete(info.KDFParams, "salt")
+
+	// Key files in a backend have no modification time to fall back to
+	if info.Created, err = keyFileCreated(a.File); err != nil && !backed {
+		return nil, err
+	}
+	am.mu.RLock()
+	_, info.Unlocked = am.unlocked[a.Address]
+	am.mu.RUnlock()
+	return info, nil
+}
+
+// keyFileCreated returns the creation time encoded in the name of key files
+// created by geth, falling back to the modification time of the file.
+func keyFileCreated(path string) (time.Time, error) {
+	if parts := strings.Split(filepath.Base(path), "--"); len(parts) == 3 && parts[0] == "UTC" {
+		if t, err := time.Parse(keyFileTimeLayout, parts[1]); err == nil {
+			return t, nil
+		}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime().UTC(), nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// backendReloadInterval is the default minimum interval between listings of a
+// key backend, which can't be watched for changes.
+const backendReloadInterval = time.Minute
+
+// KeyBackend stores the encrypted key files of a Manager outside of the file
+// system, e.g. in Hashicorp Vault, so that no key material is kept on disk.
+// Key files are identified by the base name they would have in a key directory.
+type KeyBackend interface {
+	fmt.Stringer // Names the backend in the paths of its key files
+
+	// List returns the names of the stored key files.
+	List() ([]string, error)
+	// Read returns the contents of a key file.
+	Read(name string) ([]byte, error)
+	// Write stores a key file, replacing the one of the same name.
+	Write(name string, content []byte) error
+	// Delete removes a key file.
+	Delete(name string) error
+}
+
+// keyStoreBackend is like keyStorePassphrase, but keeps the encrypted key files
+// in a KeyBackend. Their paths are their names joined to the backend name.
+type keyStoreBackend struct {
+	backend KeyBackend
+	scryptN int
+	scryptP int
+}
+
+func (ks *keyStoreBackend) GetKey(addr common.Address, filename, auth string) (*Key, error) {
+	keyjson, err := ks.backend.Read(filepath.Base(filename))
+	if err != nil {
+		return nil, err
+	}
+	key, err := DecryptKey(keyjson, auth)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure we're really operating on the requested key (no swap attacks)
+	if key.Address != addr {
+		return nil, fmt.Errorf("key content mismatch: have account %x, want %x", key.Address, addr)
+	}
+	return key, nil
+}
+
+func (ks *keyStoreBackend) StoreKey(filename string, key *Key, auth string) error {
+	keyjson, err := EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+	if err != nil {
+		return err
+	}
+	return ks.backend.Write(filepath.Base(filename), keyjson)
+}
+
+func (ks *keyStoreBackend) JoinPath(filename string) string {
+	return filepath.Join(ks.backend.String(), filepath.Base(filename))
+}
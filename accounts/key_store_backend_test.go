@@ -0,0 +1,110 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// memKeyBackend is a KeyBackend keeping the key files in memory.
+type memKeyBackend struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemKeyBackend() *memKeyBackend {
+	return &memKeyBackend{files: make(map[string][]byte)}
+}
+
+func (b *memKeyBackend) String() string { return "mem:" }
+
+func (b *memKeyBackend) List() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name := range b.files {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (b *memKeyBackend) Read(name string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	content, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("no key file %s", name)
+	}
+	return content, nil
+}
+
+func (b *memKeyBackend) Write(name string, content []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.files[name] = content
+	return nil
+}
+
+func (b *memKeyBackend) Delete(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.files, name)
+	return nil
+}
+
+func TestBackendManager(t *testing.T) {
+	backend := newMemKeyBackend()
+	am := NewBackendManager(backend, LightScryptN, LightScryptP, CacheConfig{})
+
+	a, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backend.files) != 1 {
+		t.Fatalf("backend holds %d key files, want 1", len(backend.files))
+	}
+	if err := am.Unlock(a, "foo"); err != nil {
+		t.Fatalf("Unlock error: %v", err)
+	}
+	if _, err := am.Sign(a.Address, testSigData); err != nil {
+		t.Fatalf("Sign error: %v", err)
+	}
+	if _, err := am.Inspect(a); err != nil {
+		t.Fatalf("Inspect error: %v", err)
+	}
+
+	// Another manager on the same backend finds the key when listing it
+	other := NewBackendManager(backend, LightScryptN, LightScryptP, CacheConfig{})
+	if accs := other.Accounts(); len(accs) != 1 || accs[0] != a {
+		t.Fatalf("other manager accounts mismatch: have %v, want [%v]", accs, a)
+	}
+	if err := other.Unlock(a, "foo"); err != nil {
+		t.Fatalf("Unlock error on other manager: %v", err)
+	}
+
+	if err := am.DeleteAccount(a, "foo"); err != nil {
+		t.Fatalf("DeleteAccount error: %v", err)
+	}
+	if len(backend.files) != 0 {
+		t.Errorf("backend holds %d key files after DeleteAccount, want 0", len(backend.files))
+	}
+	if am.HasAddress(a.Address) {
+		t.Errorf("HasAddress(%x) should've returned false after DeleteAccount", a.Address)
+	}
+}
@@ -251,10 +251,16 @@ func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int, cfg
 	return env
 }
 
-// ruleSet implements vm.RuleSet and will always default to the homestead rule set.
+// ruleSet implements vm.RuleSet and will always default to the latest rule set.
 type ruleSet struct{}
 
 func (ruleSet) IsHomestead(*big.Int) bool { return true }
+func (ruleSet) IsByzantium(*big.Int) bool { return true }
+func (ruleSet) Precompile(common.Address, *big.Int) vm.PrecompiledContract {
+	return nil
+}
+func (ruleSet) MaxCodeSize(*big.Int) int { return 0 }
+func (ruleSet) Limits(*big.Int) vm.Limits { return vm.DefaultLimits }
 func (ruleSet) GasTable(*big.Int) params.GasTable {
 	return params.GasTableHomesteadGasRepriceFork
 }
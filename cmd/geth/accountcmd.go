@@ -19,17 +19,25 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/console"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"gopkg.in/urfave/cli.v1"
 )
 
 var (
+	hdImportMnemonicFlag = cli.BoolFlag{
+		Name:  "importmnemonic",
+		Usage: "Prompt for an existing mnemonic instead of generating one",
+	}
 	walletCommand = cli.Command{
 		Name:  "wallet",
 		Usage: "ethereum presale wallet",
@@ -163,6 +171,53 @@ this import mechanism is not needed when you transfer an account between
 nodes.
 					`,
 			},
+			{
+				Action: accountNewHD,
+				Name:   "newhd",
+				Usage:  "create an HD wallet from a BIP-39 mnemonic",
+				Flags:  []cli.Flag{hdImportMnemonicFlag},
+				Description: `
+
+    ethereum account newhd [--importmnemonic]
+
+Creates the HD wallet of the keystore from a new 24 word BIP-39 mnemonic, which
+is printed, or with --importmnemonic from an existing mnemonic you are prompted
+for. The wallet seed is saved in encrypted format in the keystore, you are
+prompted for a passphrase.
+
+Write down the mnemonic, it is the only backup of the wallet. Accounts of the
+wallet aren't stored as key files, they are derived from the seed by their
+BIP-32 derivation path (see account derive).
+					`,
+			},
+			{
+				Action: accountDeriveHD,
+				Name:   "derive",
+				Usage:  "print the addresses of HD wallet accounts",
+				Description: `
+
+    ethereum account derive <path> [<path>...]
+
+Prints the addresses of the HD wallet accounts at the given derivation paths,
+e.g. m/44'/60'/0'/0/0. You are prompted for the wallet passphrase.
+					`,
+			},
+			{
+				Action: accountInspect,
+				Name:   "inspect",
+				Usage:  "print key provenance and usage of accounts",
+				Description: `
+
+    ethereum account inspect <address> [<address>...]
+
+Prints, for each of the given accounts (addresses or indexes), its key file,
+KDF parameters and creation time, together with when the node last unlocked it
+and last signed or voted for a block with it.
+
+Usage is read from the chain database, which can't be opened while the node is
+running. Use personal.inspectAccount on the console of a running node instead.
+					`,
+			},
 		},
 	}
 )
@@ -175,6 +230,71 @@ func accountList(ctx *cli.Context) error {
 	return nil
 }
 
+// accountInspect prints the key file metadata and usage of the given accounts.
+func accountInspect(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No accounts specified to inspect")
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	accman := stack.AccountManager()
+
+	// Usage is only available if the node isn't holding the database
+	chainDb, err := stack.OpenDatabase("chaindata", ctx.GlobalInt(utils.CacheFlag.Name), utils.MakeDatabaseHandles(ctx))
+	if err != nil {
+		fmt.Printf("Usage not available, could not open database: %v\n\n", err)
+		chainDb = nil
+	} else {
+		defer utils.CloseOnFatal("chaindata", chainDb)()
+	}
+	for _, addr := range ctx.Args() {
+		account, err := utils.MakeAddress(accman, addr)
+		if err != nil {
+			utils.Fatalf("Could not inspect account: %v", err)
+		}
+		inspection, err := ethapi.InspectAccount(accman, chainDb, account.Address)
+		if err != nil {
+			utils.Fatalf("Could not inspect account %s: %v", addr, err)
+		}
+		printAccountInspection(inspection)
+	}
+	return nil
+}
+
+func printAccountInspection(inspection *ethapi.AccountInspection) {
+	fmt.Printf("Address:     {%x}\n", inspection.Address)
+	fmt.Printf("File:        %s\n", inspection.File)
+	fmt.Printf("Version:     %v\n", inspection.Version)
+	fmt.Printf("Created:     %v\n", inspection.Created)
+	if inspection.KDF != "" {
+		params := make([]string, 0, len(inspection.KDFParams))
+		for name, value := range inspection.KDFParams {
+			params = append(params, fmt.Sprintf("%s=%v", name, value))
+		}
+		sort.Strings(params)
+		fmt.Printf("KDF:         %s (%s)\n", inspection.KDF, strings.Join(params, " "))
+	} else {
+		fmt.Printf("KDF:         none (plaintext key)\n")
+	}
+	if usage := inspection.AccountUsage; usage != nil {
+		if usage.LastUnlock != nil {
+			fmt.Printf("Last unlock: %v\n", *usage.LastUnlock)
+		} else {
+			fmt.Printf("Last unlock: never\n")
+		}
+		printAccountBlock("Last signed: ", usage.LastSignedBlock)
+		printAccountBlock("Last voted:  ", usage.LastVotedBlock)
+	}
+	fmt.Println()
+}
+
+func printAccountBlock(label string, block *core.AccountBlock) {
+	if block == nil {
+		fmt.Printf("%snever\n", label)
+		return
+	}
+	fmt.Printf("%sblock #%d [%x…] at %v\n", label, block.Number, block.Hash[:4], block.Time)
+}
+
 // tries unlocking the specified account a few times.
 func unlockAccount(ctx *cli.Context, accman *accounts.Manager, address string, i int, passwords []string) (accounts.Account, string) {
 	account, err := utils.MakeAddress(accman, address)
@@ -200,7 +320,7 @@ func unlockAccount(ctx *cli.Context, accman *accounts.Manager, address string, i
 		}
 	}
 	// All trials expended to unlock account, bail out
-	utils.Fatalf("Failed to unlock account %s (%v)", address, err)
+	utils.Fatal(utils.ExitAccount, "Failed to unlock account %s (%v)", address, err)
 	return accounts.Account{}, ""
 }
 
@@ -273,6 +393,64 @@ func accountCreate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountNewHD creates the HD wallet of the keystore.
+func accountNewHD(ctx *cli.Context) error {
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+
+	var mnemonic string
+	if ctx.Bool(hdImportMnemonicFlag.Name) {
+		var err error
+		if mnemonic, err = console.Stdin.PromptPassword("Mnemonic: "); err != nil {
+			utils.Fatalf("Failed to read mnemonic: %v", err)
+		}
+		if err := accounts.ValidateMnemonic(mnemonic); err != nil {
+			utils.Fatalf("Invalid mnemonic: %v", err)
+		}
+	}
+	password := getPassPhrase("Your new HD wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	mnemonic, err := stack.AccountManager().NewHDWallet(mnemonic, password)
+	if err != nil {
+		utils.Fatalf("Failed to create HD wallet: %v", err)
+	}
+	wallet, _ := stack.AccountManager().HDWallet()
+	fmt.Printf("Wallet: {%x}\n", wallet)
+	if !ctx.Bool(hdImportMnemonicFlag.Name) {
+		fmt.Printf("Mnemonic: %s\n", mnemonic)
+		fmt.Println("Write down the mnemonic and keep it safe, it is the only backup of the wallet.")
+	}
+	return nil
+}
+
+// accountDeriveHD prints the addresses of HD wallet accounts.
+func accountDeriveHD(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No derivation paths specified")
+	}
+	paths := make([]accounts.DerivationPath, len(ctx.Args()))
+	for i, arg := range ctx.Args() {
+		path, err := accounts.ParseDerivationPath(arg)
+		if err != nil {
+			utils.Fatalf("%v", err)
+		}
+		paths[i] = path
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	accman := stack.AccountManager()
+	if _, err := accman.HDWallet(); err != nil {
+		utils.Fatalf("Could not derive accounts: %v", err)
+	}
+	password := getPassPhrase("Unlocking HD wallet", false, 0, utils.MakePasswordList(ctx))
+	for _, path := range paths {
+		addr, err := accman.DeriveHDAccount(path, password)
+		if err != nil {
+			utils.Fatalf("Could not derive %v: %v", path, err)
+		}
+		fmt.Printf("%v: {%x}\n", path, addr)
+	}
+	return nil
+}
+
 // accountUpdate transitions an account from a previous format to the current
 // one, also providing the possibility to change the pass-phrase.
 func accountUpdate(ctx *cli.Context) error {
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/parquet"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	analyticsFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format (parquet or csv)",
+		Value: "parquet",
+	}
+	exportAnalyticsCommand = cli.Command{
+		Action: exportAnalytics,
+		Name:   "export-analytics",
+		Usage:  "export blocks, transactions, receipts and logs as tables",
+		Flags:  []cli.Flag{analyticsFormatFlag},
+		Description: `
+Requires a first argument of the directory to write to, and the first and last
+block of the range to export as second and third argument. The blocks,
+transactions, receipts and logs of the range are written to one file per table,
+named after the table and the format (e.g. blocks.parquet).
+
+With --format parquet (the default) uncompressed Parquet files are written, with
+--format csv CSV files with a header row. The columns of every table are fixed,
+hashes, addresses and binary data are 0x prefixed hex strings, and amounts of
+wei are decimal strings.
+
+Private transactions are exported with the receipts seen by this node, so their
+outcome is only reported in full on nodes which are a party to them.
+`,
+	}
+)
+
+// analyticsTables are the exported tables and their columns. The columns must
+// only ever be appended to, so the schema stays stable for existing consumers.
+var analyticsTables = []struct {
+	name    string
+	columns []parquet.Column
+}{
+	{"blocks", []parquet.Column{
+		{Name: "number", Type: parquet.Int64},
+		{Name: "hash", Type: parquet.String},
+		{Name: "parent_hash", Type: parquet.String},
+		{Name: "timestamp", Type: parquet.Int64},
+		{Name: "coinbase", Type: parquet.String},
+		{Name: "gas_limit", Type: parquet.Int64},
+		{Name: "gas_used", Type: parquet.Int64},
+		{Name: "transaction_count", Type: parquet.Int64},
+	}},
+	{"transactions", []parquet.Column{
+		{Name: "block_number", Type: parquet.Int64},
+		{Name: "block_hash", Type: parquet.String},
+		{Name: "transaction_index", Type: parquet.Int64},
+		{Name: "hash", Type: parquet.String},
+		{Name: "from", Type: parquet.String},
+		{Name: "to", Type: parquet.String, Optional: true},
+		{Name: "value", Type: parquet.String},
+		{Name: "gas", Type: parquet.Int64},
+		{Name: "gas_price", Type: parquet.String},
+		{Name: "nonce", Type: parquet.Int64},
+		{Name: "input", Type: parquet.String},
+		{Name: "private", Type: parquet.Bool},
+	}},
+	{"receipts", []parquet.Column{
+		{Name: "block_number", Type: parquet.Int64},
+		{Name: "transaction_hash", Type: parquet.String},
+		{Name: "transaction_index", Type: parquet.Int64},
+		{Name: "status", Type: parquet.Int64},
+		{Name: "cumulative_gas_used", Type: parquet.Int64},
+		{Name: "gas_used", Type: parquet.Int64},
+		{Name: "contract_address", Type: parquet.String, Optional: true},
+		{Name: "log_count", Type: parquet.Int64},
+	}},
+	{"logs", []parquet.Column{
+		{Name: "block_number", Type: parquet.Int64},
+		{Name: "transaction_hash", Type: parquet.String},
+		{Name: "transaction_index", Type: parquet.Int64},
+		{Name: "log_index", Type: parquet.Int64},
+		{Name: "address", Type: parquet.String},
+		{Name: "topic0", Type: parquet.String, Optional: true},
+		{Name: "topic1", Type: parquet.String, Optional: true},
+		{Name: "topic2", Type: parquet.String, Optional: true},
+		{Name: "topic3", Type: parquet.String, Optional: true},
+		{Name: "data", Type: parquet.String},
+	}},
+}
+
+func exportAnalytics(ctx *cli.Context) error {
+	if len(ctx.Args()) < 3 {
+		utils.Fatalf("This command requires a directory and the first and last block to export.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if first > last {
+		utils.Fatalf("Export error: first block %d is after last block %d\n", first, last)
+	}
+	var writer func(io.Writer, []parquet.Column) analyticsWriter
+	format := ctx.String(analyticsFormatFlag.Name)
+	switch format {
+	case "parquet":
+		writer = newAnalyticsParquetWriter
+	case "csv":
+		writer = newAnalyticsCSVWriter
+	default:
+		utils.Fatalf("Unknown export format %q, expected parquet or csv", format)
+	}
+
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	dir := ctx.Args().First()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	var (
+		files   []*os.File
+		buffers []*bufio.Writer
+		tables  []analyticsWriter
+	)
+	for _, table := range analyticsTables {
+		fh, err := os.OpenFile(filepath.Join(dir, table.name+"."+format), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		defer fh.Close()
+		buf := bufio.NewWriter(fh)
+
+		files = append(files, fh)
+		buffers = append(buffers, buf)
+		tables = append(tables, writer(buf, table.columns))
+	}
+
+	start := time.Now()
+	for number := first; number <= last; number++ {
+		block := chain.GetBlockByNumber(number)
+		if block == nil {
+			utils.Fatalf("Export error in block %d: block not found\n", number)
+		}
+		if err := exportAnalyticsBlock(chainDb, block, tables[0], tables[1], tables[2], tables[3]); err != nil {
+			utils.Fatalf("Export error in block %d: %v\n", number, err)
+		}
+	}
+	for i, table := range tables {
+		if err := table.close(); err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		if err := buffers[i].Flush(); err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+		if err := files[i].Close(); err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// exportAnalyticsBlock writes the rows of a block, its transactions, their
+// receipts and logs.
+func exportAnalyticsBlock(db ethdb.Database, block *types.Block, blocks, txs, receipts, logs analyticsWriter) error {
+	var (
+		number = int64(block.NumberU64())
+		hash   = block.Hash().Hex()
+	)
+	err := blocks.write(number, hash, block.ParentHash().Hex(), block.Time().Int64(), block.Coinbase().Hex(),
+		block.GasLimit().Int64(), block.GasUsed().Int64(), int64(len(block.Transactions())))
+	if err != nil {
+		return err
+	}
+	blockReceipts := core.GetBlockReceipts(db, block.Hash(), block.NumberU64())
+	for i, tx := range block.Transactions() {
+		from, err := tx.From()
+		if err != nil {
+			return fmt.Errorf("transaction %x: %v", tx.Hash(), err)
+		}
+		var to interface{}
+		if tx.To() != nil {
+			to = tx.To().Hex()
+		}
+		err = txs.write(number, hash, int64(i), tx.Hash().Hex(), from.Hex(), to, tx.Value().String(),
+			tx.Gas().Int64(), tx.GasPrice().String(), int64(tx.Nonce()), common.ToHex(tx.Data()), tx.IsPrivate())
+		if err != nil {
+			return err
+		}
+		// Prefer the receipt stored by transaction, which is the private receipt
+		// on parties to a private transaction
+		receipt := core.GetReceipt(db, tx.Hash())
+		if receipt == nil && i < len(blockReceipts) {
+			receipt = blockReceipts[i]
+		}
+		if receipt == nil {
+			return fmt.Errorf("receipt of transaction %x not found", tx.Hash())
+		}
+		var contract interface{}
+		if tx.To() == nil {
+			contract = receipt.ContractAddress.Hex()
+		}
+		err = receipts.write(number, tx.Hash().Hex(), int64(i), int64(receipt.Status),
+			receipt.CumulativeGasUsed.Int64(), receipt.GasUsed.Int64(), contract, int64(len(receipt.Logs)))
+		if err != nil {
+			return err
+		}
+		for _, log := range receipt.Logs {
+			topics := make([]interface{}, 4)
+			for j := 0; j < len(log.Topics) && j < len(topics); j++ {
+				topics[j] = log.Topics[j].Hex()
+			}
+			err := logs.write(number, tx.Hash().Hex(), int64(i), int64(log.Index), log.Address.Hex(),
+				topics[0], topics[1], topics[2], topics[3], common.ToHex(log.Data))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// analyticsWriter writes the rows of a table in a specific format.
+type analyticsWriter interface {
+	write(row ...interface{}) error
+	close() error
+}
+
+// analyticsParquetWriter writes a table as a Parquet file.
+type analyticsParquetWriter struct {
+	w *parquet.Writer
+}
+
+func newAnalyticsParquetWriter(w io.Writer, columns []parquet.Column) analyticsWriter {
+	return &analyticsParquetWriter{w: parquet.NewWriter(w, columns)}
+}
+
+func (w *analyticsParquetWriter) write(row ...interface{}) error { return w.w.Write(row...) }
+func (w *analyticsParquetWriter) close() error                   { return w.w.Close() }
+
+// analyticsCSVWriter writes a table as a CSV file with a header row. Nulls are
+// written as empty fields.
+type analyticsCSVWriter struct {
+	w *csv.Writer
+}
+
+func newAnalyticsCSVWriter(w io.Writer, columns []parquet.Column) analyticsWriter {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	out := &analyticsCSVWriter{w: csv.NewWriter(w)}
+	out.w.Write(header)
+	return out
+}
+
+func (w *analyticsCSVWriter) write(row ...interface{}) error {
+	fields := make([]string, len(row))
+	for i, value := range row {
+		switch v := value.(type) {
+		case int64:
+			fields[i] = strconv.FormatInt(v, 10)
+		case string:
+			fields[i] = v
+		case bool:
+			fields[i] = strconv.FormatBool(v)
+		}
+	}
+	return w.w.Write(fields)
+}
+
+func (w *analyticsCSVWriter) close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
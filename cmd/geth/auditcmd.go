@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	auditFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "Output format (json or csv)",
+		Value: "json",
+	}
+	exportAuditCommand = cli.Command{
+		Action: exportAudit,
+		Name:   "exportaudit",
+		Usage:  "export the accounts, storage slots and events touched by transactions",
+		Flags:  []cli.Flag{auditFormatFlag},
+		Description: `
+Requires a first argument of the file to write to, and the first and last block
+of the range to export as second and third argument. The blocks are replayed
+and, for every transaction, the accounts and storage slots it touched and the
+events it emitted are written to the file.
+
+With --format json (the default) one JSON object is written per transaction and
+line. With --format csv one row is written per touched account, storage slot
+and event.
+
+Private transactions are replayed against the private state, so they are only
+reported in full on nodes which are a party to them.
+`,
+	}
+)
+
+// auditRecord is the audit export of a single transaction.
+type auditRecord struct {
+	BlockNumber     uint64          `json:"blockNumber"`
+	BlockHash       common.Hash     `json:"blockHash"`
+	TxHash          common.Hash     `json:"transactionHash"`
+	TxIndex         int             `json:"transactionIndex"`
+	From            common.Address  `json:"from"`
+	To              *common.Address `json:"to"`
+	ContractAddress *common.Address `json:"contractAddress,omitempty"`
+	Private         bool            `json:"private"`
+	Status          uint            `json:"status"`
+	Accounts        []auditAccount  `json:"accounts"`
+	Events          []auditEvent    `json:"events"`
+}
+
+// auditAccount is an account touched by a transaction together with the
+// storage slots read or written.
+type auditAccount struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// auditEvent is an event emitted by a transaction.
+type auditEvent struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    string         `json:"data"`
+}
+
+func exportAudit(ctx *cli.Context) error {
+	if len(ctx.Args()) < 3 {
+		utils.Fatalf("This command requires a file and the first and last block to export.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	if first > last {
+		utils.Fatalf("Export error: first block %d is after last block %d\n", first, last)
+	}
+	var writer func(io.Writer) auditWriter
+	switch format := ctx.String(auditFormatFlag.Name); format {
+	case "json":
+		writer = newAuditJSONWriter
+	case "csv":
+		writer = newAuditCSVWriter
+	default:
+		utils.Fatalf("Unknown export format %q, expected json or csv", format)
+	}
+
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	fh, err := os.OpenFile(ctx.Args().First(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	defer fh.Close()
+	buf := bufio.NewWriter(fh)
+	out := writer(buf)
+
+	start := time.Now()
+	for number := first; number <= last; number++ {
+		records, err := auditBlock(chain, number)
+		if err != nil {
+			utils.Fatalf("Export error in block %d: %v\n", number, err)
+		}
+		for _, record := range records {
+			if err := out.write(record); err != nil {
+				utils.Fatalf("Export error: %v\n", err)
+			}
+		}
+	}
+	if err := out.flush(); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	if err := buf.Flush(); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// auditBlock replays the transactions of the given block on top of its parent
+// state and returns their audit records.
+func auditBlock(chain *core.BlockChain, number uint64) ([]*auditRecord, error) {
+	block := chain.GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block not found")
+	}
+	if number == 0 || len(block.Transactions()) == 0 {
+		return nil, nil
+	}
+	parent := chain.GetBlock(block.ParentHash(), number-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	publicState, privateState, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records []*auditRecord
+		tracer  = newAccessTracer()
+		gp      = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas = new(big.Int)
+		config  = vm.Config{Debug: true, Tracer: tracer}
+	)
+	for i, tx := range block.Transactions() {
+		publicState.StartRecord(tx.Hash(), block.Hash(), i)
+		privateState.StartRecord(tx.Hash(), block.Hash(), i)
+
+		tracer.reset()
+		publicReceipt, privateReceipt, _, err := core.ApplyTransaction(chain.Config(), chain, gp, publicState, privateState, block.Header(), tx, usedGas, config)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %x: %v", tx.Hash(), err)
+		}
+		from, _ := tx.From()
+		receipt := publicReceipt
+		if privateReceipt != nil {
+			receipt = privateReceipt
+		}
+		// Value transfers don't run any code, so add the parties explicitly
+		tracer.touch(from)
+		if tx.To() != nil {
+			tracer.touch(*tx.To())
+		}
+		record := &auditRecord{
+			BlockNumber: number,
+			BlockHash:   block.Hash(),
+			TxHash:      tx.Hash(),
+			TxIndex:     i,
+			From:        from,
+			To:          tx.To(),
+			Private:     tx.IsPrivate(),
+			Status:      receipt.Status,
+			Accounts:    tracer.accounts(),
+			Events:      []auditEvent{},
+		}
+		if tx.To() == nil {
+			addr := receipt.ContractAddress
+			record.ContractAddress = &addr
+		}
+		for _, log := range receipt.Logs {
+			record.Events = append(record.Events, auditEvent{
+				Address: log.Address,
+				Topics:  log.Topics,
+				Data:    common.ToHex(log.Data),
+			})
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// accessTracer is a vm.Tracer recording the accounts and storage slots touched
+// during execution.
+type accessTracer struct {
+	touched map[common.Address]map[common.Hash]struct{}
+}
+
+func newAccessTracer() *accessTracer {
+	return &accessTracer{touched: make(map[common.Address]map[common.Hash]struct{})}
+}
+
+func (t *accessTracer) reset() {
+	t.touched = make(map[common.Address]map[common.Hash]struct{})
+}
+
+func (t *accessTracer) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := t.touched[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		t.touched[addr] = slots
+	}
+	return slots
+}
+
+// CaptureState implements vm.Tracer. It is called before every instruction,
+// while the operands are still on the stack.
+func (t *accessTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	slots := t.touch(contract.Address())
+
+	// The operand at position n from the top of the stack
+	data := stack.Data()
+	operand := func(n int) *big.Int {
+		if len(data) <= n {
+			return nil
+		}
+		return data[len(data)-1-n]
+	}
+	switch op {
+	case vm.SLOAD, vm.SSTORE:
+		if key := operand(0); key != nil {
+			slots[common.BigToHash(key)] = struct{}{}
+		}
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY, vm.SUICIDE:
+		if addr := operand(0); addr != nil {
+			t.touch(common.BigToAddress(addr))
+		}
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL:
+		if addr := operand(1); addr != nil {
+			t.touch(common.BigToAddress(addr))
+		}
+	}
+}
+
+// accounts returns the touched accounts and their storage slots in a
+// deterministic order.
+func (t *accessTracer) accounts() []auditAccount {
+	accounts := make([]auditAccount, 0, len(t.touched))
+	for addr, slots := range t.touched {
+		account := auditAccount{Address: addr, StorageKeys: make([]common.Hash, 0, len(slots))}
+		for key := range slots {
+			account.StorageKeys = append(account.StorageKeys, key)
+		}
+		sort.Sort(hashes(account.StorageKeys))
+		accounts = append(accounts, account)
+	}
+	sort.Sort(auditAccounts(accounts))
+	return accounts
+}
+
+type hashes []common.Hash
+
+func (h hashes) Len() int           { return len(h) }
+func (h hashes) Less(i, j int) bool { return h[i].Hex() < h[j].Hex() }
+func (h hashes) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+type auditAccounts []auditAccount
+
+func (a auditAccounts) Len() int           { return len(a) }
+func (a auditAccounts) Less(i, j int) bool { return a[i].Address.Hex() < a[j].Address.Hex() }
+func (a auditAccounts) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// auditWriter writes audit records in a specific format.
+type auditWriter interface {
+	write(*auditRecord) error
+	flush() error
+}
+
+// auditJSONWriter writes one JSON object per record and line.
+type auditJSONWriter struct {
+	enc *json.Encoder
+}
+
+func newAuditJSONWriter(w io.Writer) auditWriter {
+	return &auditJSONWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *auditJSONWriter) write(record *auditRecord) error { return w.enc.Encode(record) }
+func (w *auditJSONWriter) flush() error                    { return nil }
+
+// auditCSVWriter writes one row per touched account, storage slot and event.
+type auditCSVWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+var auditCSVHeader = []string{"blockNumber", "transactionHash", "transactionIndex", "from", "to", "private", "status", "kind", "address", "storageKey", "topics", "data"}
+
+func newAuditCSVWriter(w io.Writer) auditWriter {
+	return &auditCSVWriter{w: csv.NewWriter(w)}
+}
+
+func (w *auditCSVWriter) write(record *auditRecord) error {
+	if !w.wroteHeader {
+		if err := w.w.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	to := ""
+	if record.To != nil {
+		to = record.To.Hex()
+	} else if record.ContractAddress != nil {
+		to = record.ContractAddress.Hex()
+	}
+	prefix := []string{
+		strconv.FormatUint(record.BlockNumber, 10),
+		record.TxHash.Hex(),
+		strconv.Itoa(record.TxIndex),
+		record.From.Hex(),
+		to,
+		strconv.FormatBool(record.Private),
+		strconv.FormatUint(uint64(record.Status), 10),
+	}
+	row := func(fields ...string) error {
+		return w.w.Write(append(append([]string{}, prefix...), fields...))
+	}
+	for _, account := range record.Accounts {
+		if err := row("account", account.Address.Hex(), "", "", ""); err != nil {
+			return err
+		}
+		for _, key := range account.StorageKeys {
+			if err := row("storage", account.Address.Hex(), key.Hex(), "", ""); err != nil {
+				return err
+			}
+		}
+	}
+	for _, event := range record.Events {
+		topics := make([]string, len(event.Topics))
+		for i, topic := range event.Topics {
+			topics[i] = topic.Hex()
+		}
+		if err := row("event", event.Address.Hex(), "", strings.Join(topics, " "), event.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *auditCSVWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
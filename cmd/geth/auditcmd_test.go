@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	auditTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	auditTestSender  = crypto.PubkeyToAddress(auditTestKey.PublicKey)
+	auditTestProbed  = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	auditTestPayee   = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+	auditTestAccount = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	// auditTestCode loads slot 1, stores 0x2a in slot 2, reads the balance of
+	// auditTestProbed and emits an event with topic 0x99.
+	auditTestCode = "6001545060" + "2a600255" + "73" + common.Bytes2Hex(auditTestProbed[:]) + "3150" + "609960006000a100"
+)
+
+// newAuditTestChain returns a chain whose first block calls the test contract
+// and sends a value transfer.
+func newAuditTestChain(t *testing.T) *core.BlockChain {
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := core.WriteGenesisBlock(db, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x%x",
+	"difficulty": "0x%x",
+	"alloc": {
+		"%x": {"balance": "1000000000"},
+		"%x": {"balance": "0", "code": "%s"}
+	}
+}`, params.GenesisGasLimit, params.GenesisDifficulty, auditTestSender, auditTestAccount, auditTestCode)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err := core.NewBlockChain(db, &core.ChainConfig{HomesteadBlock: big.NewInt(0)}, new(core.FakePow), new(event.TypeMux), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, _ := core.GenerateChain(nil, genesis, db, 2, func(i int, gen *core.BlockGen) {
+		if i > 0 {
+			return
+		}
+		call, _ := types.NewTransaction(gen.TxNonce(auditTestSender), auditTestAccount, big.NewInt(0), big.NewInt(100000), nil, nil).SignECDSA(auditTestKey)
+		gen.AddTx(call)
+		transfer, _ := types.NewTransaction(gen.TxNonce(auditTestSender), auditTestPayee, big.NewInt(1), params.TxGas, nil, nil).SignECDSA(auditTestKey)
+		gen.AddTx(transfer)
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatal(err)
+	}
+	return chain
+}
+
+// Tests that replayed transactions report the accounts and slots touched by
+// their code, the parties of value transfers and the emitted events.
+func TestAuditBlock(t *testing.T) {
+	chain := newAuditTestChain(t)
+
+	records, err := auditBlock(chain, 1)
+	if err != nil {
+		t.Fatalf("failed to audit block: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("audited %d transactions, want 2", len(records))
+	}
+	call, transfer := records[0], records[1]
+	if call.From != auditTestSender || call.To == nil || *call.To != auditTestAccount || call.TxIndex != 0 || call.BlockNumber != 1 {
+		t.Errorf("call record mismatch: %+v", call)
+	}
+	want := []auditAccount{
+		{Address: auditTestAccount, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2))}},
+		{Address: auditTestProbed, StorageKeys: []common.Hash{}},
+		{Address: auditTestSender, StorageKeys: []common.Hash{}},
+	}
+	if !reflect.DeepEqual(call.Accounts, want) {
+		t.Errorf("call accounts mismatch:\nhave %+v\nwant %+v", call.Accounts, want)
+	}
+	if len(call.Events) != 1 || call.Events[0].Address != auditTestAccount || call.Events[0].Topics[0] != common.BigToHash(big.NewInt(0x99)) {
+		t.Errorf("call events mismatch: %+v", call.Events)
+	}
+
+	want = []auditAccount{
+		{Address: auditTestPayee, StorageKeys: []common.Hash{}},
+		{Address: auditTestSender, StorageKeys: []common.Hash{}},
+	}
+	if !reflect.DeepEqual(transfer.Accounts, want) {
+		t.Errorf("transfer accounts mismatch:\nhave %+v\nwant %+v", transfer.Accounts, want)
+	}
+	if len(transfer.Events) != 0 {
+		t.Errorf("transfer emitted events: %+v", transfer.Events)
+	}
+
+	if records, err := auditBlock(chain, 2); err != nil || len(records) != 0 {
+		t.Errorf("empty block audited as %v, %v", records, err)
+	}
+	if _, err := auditBlock(chain, 3); err == nil {
+		t.Errorf("missing block audited")
+	}
+}
+
+// Tests that the CSV export writes a header once and a row per account, slot
+// and event.
+func TestAuditCSVWriter(t *testing.T) {
+	var (
+		to     = common.Address{2}
+		record = &auditRecord{
+			BlockNumber: 7,
+			TxHash:      common.Hash{7},
+			TxIndex:     3,
+			From:        common.Address{1},
+			To:          &to,
+			Status:      1,
+			Accounts: []auditAccount{
+				{Address: to, StorageKeys: []common.Hash{{1}}},
+			},
+			Events: []auditEvent{
+				{Address: to, Topics: []common.Hash{{0xa}, {0xb}}, Data: "0x01"},
+			},
+		}
+		created = common.Address{3}
+		deploy  = &auditRecord{BlockNumber: 8, From: common.Address{1}, ContractAddress: &created, Accounts: []auditAccount{{Address: created}}}
+	)
+	var buf bytes.Buffer
+	w := newAuditCSVWriter(&buf)
+	for _, r := range []*auditRecord{record, deploy} {
+		if err := w.write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.flush(); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	prefix := []string{"7", record.TxHash.Hex(), "3", record.From.Hex(), to.Hex(), "false", "1"}
+	row := func(prefix []string, fields ...string) []string {
+		return append(append([]string{}, prefix...), fields...)
+	}
+	want := [][]string{
+		auditCSVHeader,
+		row(prefix, "account", to.Hex(), "", "", ""),
+		row(prefix, "storage", to.Hex(), common.Hash{1}.Hex(), "", ""),
+		row(prefix, "event", to.Hex(), "", common.Hash{0xa}.Hex()+" "+common.Hash{0xb}.Hex(), "0x01"),
+		row([]string{"8", common.Hash{}.Hex(), "0", record.From.Hex(), created.Hex(), "false", "0"}, "account", created.Hex(), "", "", ""),
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows mismatch:\nhave %q\nwant %q", rows, want)
+	}
+}
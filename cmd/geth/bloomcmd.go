@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var bloomsCommand = cli.Command{
+	Name:  "blooms",
+	Usage: "verify and rebuild the log blooms of the chain",
+	Description: `
+Log filters skip blocks using the blooms of the receipts, the private block
+blooms and the bloom index. If these don't match the stored receipts after a
+crash, filters miss events.
+
+The optional arguments are the first and last block to check, the whole chain
+by default.
+`,
+	Subcommands: []cli.Command{
+		{
+			Action: verifyBlooms,
+			Name:   "verify",
+			Usage:  "report blooms inconsistent with the stored receipts",
+		},
+		{
+			Action: rebuildBlooms,
+			Name:   "rebuild",
+			Usage:  "rebuild blooms inconsistent with the stored receipts",
+			Description: `
+Rewrites the receipt and private block blooms and adds missing addresses to the
+bloom index. Receipts which don't match the header bloom are only reported, the
+blocks have to be imported again.
+`,
+		},
+	},
+}
+
+func verifyBlooms(ctx *cli.Context) error {
+	return checkBlooms(ctx, false)
+}
+
+func rebuildBlooms(ctx *cli.Context) error {
+	return checkBlooms(ctx, true)
+}
+
+func checkBlooms(ctx *cli.Context, repair bool) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	first, last := uint64(0), chain.CurrentBlock().NumberU64()
+	if len(ctx.Args()) > 0 {
+		var ferr, lerr error
+		first, ferr = strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+		if len(ctx.Args()) > 1 {
+			last, lerr = strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+		}
+		if ferr != nil || lerr != nil {
+			utils.Fatalf("Error in parsing parameters: block number not an integer")
+		}
+	}
+	if first > last {
+		utils.Fatalf("First block %d is after last block %d", first, last)
+	}
+	start := time.Now()
+	faults, err := core.CheckBlooms(chainDb, first, last, repair)
+	for _, fault := range faults {
+		fmt.Println(fault)
+	}
+	if err != nil {
+		utils.Fatalf("Bloom check error: %v", err)
+	}
+	unrepaired := 0
+	for _, fault := range faults {
+		if !fault.Repaired {
+			unrepaired++
+		}
+	}
+	fmt.Printf("Checked blocks #%d to #%d in %v: %d inconsistent, %d repaired\n", first, last, time.Since(start), len(faults), len(faults)-unrepaired)
+	if unrepaired > 0 {
+		utils.Fatalf("%d inconsistent blooms not repaired", unrepaired)
+	}
+	return nil
+}
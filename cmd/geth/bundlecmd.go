@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const datadirConfigBundle = "config-bundle.json" // Path within the instance directory to the last applied bundle
+
+var (
+	initBundleFlag = cli.StringFlag{
+		Name:  "bundle",
+		Usage: "Signed config bundle to initialise the node from instead of a genesis file",
+	}
+	initBundleSignersFlag = cli.StringFlag{
+		Name:  "bundle.signers",
+		Usage: "Comma separated addresses of the consortium keys trusted to sign config bundles",
+	}
+	bundleStaticNodesFlag = cli.StringFlag{
+		Name:  "staticnodes",
+		Usage: "static-nodes.json to include in the bundle",
+	}
+	bundlePermissionedNodesFlag = cli.StringFlag{
+		Name:  "permissionednodes",
+		Usage: "permissioned-nodes.json to include in the bundle",
+	}
+	bundleChainConfigFlag = cli.StringFlag{
+		Name:  "chainconfig",
+		Usage: "Chain config JSON replacing the config of the genesis",
+	}
+	bundleVersionFlag = cli.Uint64Flag{
+		Name:  "version",
+		Usage: "Version of the bundle, higher than any published before (default = current unix time)",
+	}
+	signBundleCommand = cli.Command{
+		Action: signBundle,
+		Name:   "signbundle",
+		Usage:  "sign a config bundle for onboarding members",
+		Flags:  []cli.Flag{nodeCertCAKeyFlag, bundleVersionFlag, bundleStaticNodesFlag, bundlePermissionedNodesFlag, bundleChainConfigFlag},
+		Description: `
+
+    geth signbundle --cakey <file> [--version <n>] [--staticnodes <file>]
+        [--permissionednodes <file>] [--chainconfig <file>] <genesis.json>
+
+Bundles the genesis with the given static and permissioned node lists and
+chain config, signs the bundle with the key of a consortium CA and prints it in
+JSON. New members initialise their node with it:
+
+    geth --datadir <dir> init --bundle <bundle.json> --bundle.signers <address>
+
+The genesis is built and every enode URL is parsed before signing. The genesis
+hash and the address of the CA are printed to stderr.
+`,
+	}
+)
+
+func signBundle(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("The genesis file to bundle is required")
+	}
+	genesis, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read genesis: %v", err)
+	}
+	bundle := &core.ConfigBundle{Version: ctx.Uint64(bundleVersionFlag.Name), Genesis: genesis}
+	if bundle.Version == 0 {
+		bundle.Version = uint64(time.Now().Unix())
+	}
+	if path := ctx.String(bundleStaticNodesFlag.Name); path != "" {
+		if err := common.LoadJSON(path, &bundle.StaticNodes); err != nil {
+			utils.Fatalf("Failed to read static nodes: %v", err)
+		}
+	}
+	if path := ctx.String(bundlePermissionedNodesFlag.Name); path != "" {
+		if err := common.LoadJSON(path, &bundle.PermissionedNodes); err != nil {
+			utils.Fatalf("Failed to read permissioned nodes: %v", err)
+		}
+	}
+	if path := ctx.String(bundleChainConfigFlag.Name); path != "" {
+		if err := common.LoadJSON(path, &bundle.ChainConfig); err != nil {
+			utils.Fatalf("Failed to read chain config: %v", err)
+		}
+	}
+	block, err := bundle.Check()
+	if err != nil {
+		utils.Fatalf("Invalid bundle: %v", err)
+	}
+	keyfile := ctx.String(nodeCertCAKeyFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("The CA key is required (--%s)", nodeCertCAKeyFlag.Name)
+	}
+	ca, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to load CA key: %v", err)
+	}
+	if err := bundle.Sign(ca); err != nil {
+		utils.Fatalf("Failed to sign bundle: %v", err)
+	}
+	out, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode bundle: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Bundle version %d of genesis %x, signed by CA %x\n", bundle.Version, block.Hash(), crypto.PubkeyToAddress(ca.PublicKey))
+	fmt.Println(string(out))
+	return nil
+}
+
+// initBundle initialises the node from a config bundle. The bundle is only
+// applied if it's signed by one of the trusted keys, its contents are valid and
+// it's not older than the last bundle applied.
+func initBundle(ctx *cli.Context) error {
+	var signers []common.Address
+	for _, addr := range strings.Split(ctx.String(initBundleSignersFlag.Name), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			utils.Fatal(utils.ExitConfig, "Invalid --%s address %q", initBundleSignersFlag.Name, addr)
+		}
+		signers = append(signers, common.HexToAddress(addr))
+	}
+	if len(signers) == 0 {
+		utils.Fatal(utils.ExitConfig, "The bundle signers are required (--%s)", initBundleSignersFlag.Name)
+	}
+	bundle := new(core.ConfigBundle)
+	if err := common.LoadJSON(ctx.String(initBundleFlag.Name), bundle); err != nil {
+		utils.Fatal(utils.ExitConfig, "Failed to read bundle: %v", err)
+	}
+	if err := bundle.Verify(signers); err != nil {
+		utils.Fatal(utils.ExitConfig, "Rejected bundle: %v", err)
+	}
+	if _, err := bundle.Check(); err != nil {
+		utils.Fatal(utils.ExitConfig, "Invalid bundle: %v", err)
+	}
+
+	stack := makeFullNode(ctx)
+	bundlePath := stack.ResolvePath(datadirConfigBundle)
+	applied := new(core.ConfigBundle)
+	if err := common.LoadJSON(bundlePath, applied); err == nil {
+		if bundle.Version < applied.Version {
+			utils.Fatal(utils.ExitConfig, "Bundle version %d is older than the applied version %d", bundle.Version, applied.Version)
+		}
+	} else if !os.IsNotExist(err) {
+		utils.Fatal(utils.ExitConfig, "Failed to read applied bundle: %v", err)
+	}
+
+	chaindb := utils.MakeChainDatabase(ctx, stack)
+	block, err := bundle.WriteGenesis(chaindb)
+	if err != nil {
+		utils.Fatal(utils.ExitDatabase, "failed to write genesis block: %v", err)
+	}
+	if bundle.StaticNodes != nil {
+		if err := writeBundleFile(stack.ResolvePath("static-nodes.json"), bundle.StaticNodes); err != nil {
+			utils.Fatal(utils.ExitStartup, "Failed to write static nodes: %v", err)
+		}
+	}
+	if bundle.PermissionedNodes != nil {
+		if err := writeBundleFile(filepath.Join(stack.DataDir(), p2p.PERMISSIONED_CONFIG), bundle.PermissionedNodes); err != nil {
+			utils.Fatal(utils.ExitStartup, "Failed to write permissioned nodes: %v", err)
+		}
+	}
+	if err := writeBundleFile(bundlePath, bundle); err != nil {
+		utils.Fatal(utils.ExitStartup, "Failed to record applied bundle: %v", err)
+	}
+	glog.V(logger.Info).Infof("successfully applied config bundle version %d with genesis block %x", bundle.Version, block.Hash())
+	return nil
+}
+
+func writeBundleFile(path string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, blob, 0644)
+}
@@ -85,7 +85,7 @@ func importChain(ctx *cli.Context) error {
 	}
 	stack := makeFullNode(ctx)
 	chain, chainDb := utils.MakeChain(ctx, stack)
-	defer chainDb.Close()
+	defer utils.CloseOnFatal("chaindata", chainDb)()
 
 	// Start periodically gathering memory profiles
 	var peakMemAlloc, peakMemSys uint64
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dumpConfigAllFlag = cli.BoolFlag{
+		Name:  "all",
+		Usage: "Include the flags left at their defaults",
+	}
+	dumpConfigCommand = cli.Command{
+		Action: dumpConfig,
+		Name:   "dumpconfig",
+		Usage:  "print the flag values in effect as a TOML config file",
+		Flags:  []cli.Flag{dumpConfigAllFlag},
+		Description: `
+Prints the flags set on the command line and in the --config file, with the
+command line taking precedence, as a TOML file for --config. With --all the
+flags left at their defaults are included too, pinning them against changes of
+the defaults in later releases. The hex node key is left out.
+
+    geth --config base.toml --raft --rpcport 22001 dumpconfig > node1.toml
+`,
+	}
+)
+
+func dumpConfig(ctx *cli.Context) error {
+	return utils.DumpConfig(ctx, os.Stdout, ctx.Bool(dumpConfigAllFlag.Name))
+}
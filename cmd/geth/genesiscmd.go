@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/quorum"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	genesisChainIdFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain ID for replay protection from genesis (0 = no replay protection)",
+	}
+	genesisVoteThresholdFlag = cli.IntFlag{
+		Name:  "votethreshold",
+		Usage: "Votes needed for a block to become canonical (0 = majority of the voters)",
+	}
+	genesisGasLimitFlag = cli.Uint64Flag{
+		Name:  "gaslimit",
+		Usage: "Gas limit of the genesis block",
+		Value: 0xe0000000,
+	}
+	genesisCommand = cli.Command{
+		Name:  "genesis",
+		Usage: "build Quorum genesis files",
+		Subcommands: []cli.Command{
+			{
+				Action: buildGenesis,
+				Name:   "build",
+				Usage:  "build a genesis file from a member registry",
+				Flags:  []cli.Flag{genesisChainIdFlag, genesisVoteThresholdFlag, genesisGasLimitFlag},
+				Description: `
+
+    geth [--raft] genesis build [--chainid <id>] [--votethreshold <n>] <registry.csv|registry.json>
+
+Writes the genesis JSON of the members in the registry to stdout. The registry
+is a CSV file with a header row of the columns address, balance, roles and
+optionally name, or a JSON array of objects with the same fields:
+
+    name,address,balance,roles
+    bank a,0xed9d02e382b34818e88b88a309c7fe71e65f419d,1000000000000000000000000000,voter
+    bank b,0x9186eb3d20cbd1f5f992a950d808c4495153abd5,0,voter;blockmaker
+
+    [{"name": "bank b", "address": "0x9186...", "balance": "0", "roles": ["voter", "blockmaker"]}]
+
+Balances are in wei, decimal or 0x-prefixed hex. Roles are voter and
+blockmaker, separated by semicolons in CSV. The voters and block makers are
+written to the storage of the voting contract at 0x20, and voters without a
+balance are given 1 wei, as only accounts which exist may send votes. With
+--raft, the voting contract is left out and members can't have roles.
+
+The registry is checked for invalid and duplicate addresses, invalid balances
+and unknown roles, and the genesis block is built in memory before it's
+written, so the file can be passed to geth init as is. Its hash is printed to
+stderr to compare with the other members.
+`,
+			},
+		},
+	}
+)
+
+const (
+	memberRoleVoter      = "voter"
+	memberRoleBlockMaker = "blockmaker"
+)
+
+// genesisMember is a member of the consortium in the genesis registry.
+type genesisMember struct {
+	Name    string   `json:"name"`
+	Address string   `json:"address"`
+	Balance string   `json:"balance"`
+	Roles   []string `json:"roles"`
+}
+
+// genesisAccount is an account allocated in the genesis file.
+type genesisAccount struct {
+	Code    string            `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Balance string            `json:"balance,omitempty"`
+}
+
+// genesisFile is the genesis file read by geth init.
+type genesisFile struct {
+	Alloc      map[string]genesisAccount `json:"alloc"`
+	Coinbase   string                    `json:"coinbase"`
+	Config     map[string]interface{}    `json:"config"`
+	Difficulty string                    `json:"difficulty"`
+	ExtraData  string                    `json:"extraData"`
+	GasLimit   string                    `json:"gasLimit"`
+	Mixhash    string                    `json:"mixhash"`
+	Nonce      string                    `json:"nonce"`
+	ParentHash string                    `json:"parentHash"`
+	Timestamp  string                    `json:"timestamp"`
+}
+
+func buildGenesis(ctx *cli.Context) error {
+	path := ctx.Args().First()
+	if path == "" {
+		utils.Fatal(utils.ExitConfig, "must supply the path to the member registry")
+	}
+	members, err := loadGenesisRegistry(path)
+	if err != nil {
+		utils.Fatal(utils.ExitConfig, "failed to read member registry: %v", err)
+	}
+	raftMode := ctx.GlobalBool(utils.RaftModeFlag.Name)
+
+	genesis := &genesisFile{
+		Alloc:      make(map[string]genesisAccount),
+		Coinbase:   common.Address{}.Hex(),
+		Config:     map[string]interface{}{"homesteadBlock": 0},
+		Difficulty: "0x0",
+		ExtraData:  "0x",
+		GasLimit:   fmt.Sprintf("%#x", ctx.Uint64(genesisGasLimitFlag.Name)),
+		Mixhash:    common.Hash{}.Hex(),
+		Nonce:      "0x0",
+		ParentHash: common.Hash{}.Hex(),
+		Timestamp:  "0x0",
+	}
+	if id := ctx.Uint64(genesisChainIdFlag.Name); id != 0 {
+		config := &core.ChainConfig{ChainId: new(big.Int).SetUint64(id), EIP155Block: new(big.Int)}
+		if err := config.CheckChainId(); err != nil {
+			utils.Fatal(utils.ExitConfig, "%v", err)
+		}
+		genesis.Config["chainId"] = id
+		genesis.Config["eip155Block"] = 0
+	}
+
+	// Allocate the balances and collect the roles of the members
+	var voters, blockMakers []common.Address
+	problems := []string{}
+	for i, m := range members {
+		name := fmt.Sprintf("member %d", i+1)
+		if m.Name != "" {
+			name = fmt.Sprintf("member %d (%s)", i+1, m.Name)
+		}
+		if !common.IsHexAddress(m.Address) {
+			problems = append(problems, fmt.Sprintf("%s: invalid address %q", name, m.Address))
+			continue
+		}
+		addr := common.HexToAddress(m.Address)
+		key := strings.ToLower(addr.Hex())
+		if _, ok := genesis.Alloc[key]; ok || addr == params.QuorumVotingContractAddr {
+			problems = append(problems, fmt.Sprintf("%s: duplicate address %s", name, addr.Hex()))
+			continue
+		}
+		balance, ok := parseGenesisBalance(m.Balance)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: invalid balance %q", name, m.Balance))
+			continue
+		}
+		for _, role := range m.Roles {
+			switch {
+			case raftMode:
+				problems = append(problems, fmt.Sprintf("%s: role %q, raft has no voters or block makers", name, role))
+			case role == memberRoleVoter:
+				voters = append(voters, addr)
+				// Votes are free, but only accounts which exist may send transactions
+				if balance.Sign() == 0 {
+					balance.SetInt64(1)
+				}
+			case role == memberRoleBlockMaker:
+				blockMakers = append(blockMakers, addr)
+			default:
+				problems = append(problems, fmt.Sprintf("%s: unknown role %q", name, role))
+			}
+		}
+		genesis.Alloc[key] = genesisAccount{Balance: balance.String()}
+	}
+	if !raftMode && len(problems) == 0 {
+		threshold := ctx.Int(genesisVoteThresholdFlag.Name)
+		if threshold == 0 {
+			threshold = len(voters)/2 + 1
+		}
+		storage, err := quorum.GenesisStorage(voters, blockMakers, threshold)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			account := genesisAccount{Code: quorum.RuntimeCode, Storage: make(map[string]string)}
+			for k, v := range storage {
+				account.Storage[k.Hex()] = v.Hex()
+			}
+			genesis.Alloc[strings.ToLower(params.QuorumVotingContractAddr.Hex())] = account
+		}
+	}
+	if len(problems) > 0 {
+		utils.Fatal(utils.ExitConfig, "invalid member registry %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	// Build the genesis block to make sure geth init accepts the file
+	blob, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	db, _ := ethdb.NewMemDatabase()
+	block, err := core.WriteGenesisBlock(db, bytes.NewReader(blob))
+	if err != nil {
+		utils.Fatal(utils.ExitConfig, "invalid genesis: %v", err)
+	}
+	fmt.Printf("%s\n", blob)
+	fmt.Fprintf(os.Stderr, "Genesis of %d members, %d voters and %d block makers: %x\n", len(members), len(voters), len(blockMakers), block.Hash())
+	return nil
+}
+
+// loadGenesisRegistry reads the members of a JSON or CSV registry, told apart
+// by the file extension.
+func loadGenesisRegistry(path string) ([]genesisMember, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		var members []genesisMember
+		if err := json.Unmarshal(blob, &members); err != nil {
+			return nil, err
+		}
+		return members, nil
+	}
+	r := csv.NewReader(bytes.NewReader(blob))
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty registry")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"address", "balance", "roles"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %q", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	var members []genesisMember
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return members, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		m := genesisMember{
+			Name:    field(record, "name"),
+			Address: field(record, "address"),
+			Balance: field(record, "balance"),
+		}
+		for _, role := range strings.Split(field(record, "roles"), ";") {
+			if role = strings.TrimSpace(role); role != "" {
+				m.Roles = append(m.Roles, role)
+			}
+		}
+		members = append(members, m)
+	}
+}
+
+// parseGenesisBalance parses a decimal or 0x-prefixed hex balance, an empty
+// balance is zero.
+func parseGenesisBalance(s string) (*big.Int, bool) {
+	if s == "" {
+		return new(big.Int), true
+	}
+	base := 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s, base = s[2:], 16
+	}
+	balance, ok := new(big.Int).SetString(s, base)
+	if !ok || balance.Sign() < 0 {
+		return nil, false
+	}
+	return balance, true
+}
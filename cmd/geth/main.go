@@ -31,12 +31,15 @@ import (
 	"crypto/ecdsa"
 
 	"github.com/ethereum/ethash"
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/console"
 	"github.com/ethereum/go-ethereum/contracts/release"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/quorum"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/internal/debug"
 	"github.com/ethereum/go-ethereum/logger"
@@ -67,12 +70,25 @@ func init() {
 	app.Commands = []cli.Command{
 		importCommand,
 		exportCommand,
+		exportAuditCommand,
+		exportPreimagesCommand,
+		exportAnalyticsCommand,
 		upgradedbCommand,
 		removedbCommand,
 		dumpCommand,
+		snapshotCommand,
+		bloomsCommand,
+		replayCommand,
 		monitorCommand,
 		accountCommand,
 		walletCommand,
+		signTxCommand,
+		signNodeCertCommand,
+		signNodeManifestCommand,
+		signBundleCommand,
+		checkPeersConfigCommand,
+		genesisCommand,
+		dumpConfigCommand,
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
@@ -88,10 +104,17 @@ The output of this command is supposed to be machine-readable.
 			Action: initGenesis,
 			Name:   "init",
 			Usage:  "bootstraps and initialises a new genesis block (JSON)",
+			Flags:  []cli.Flag{initBundleFlag, initBundleSignersFlag},
 			Description: `
 The init command initialises a new genesis block and definition for the network.
 This is a destructive action and changes the network in which you will be
 participating.
+
+With --bundle, the node is initialised from a config bundle signed with geth
+signbundle instead: the genesis block, chain config, static-nodes.json and
+permissioned-nodes.json of the bundle are applied only if it's signed by one of
+the --bundle.signers, its contents are valid and it's not older than the last
+bundle applied.
 `,
 		},
 		{
@@ -105,17 +128,28 @@ participating.
 		utils.IdentityFlag,
 		utils.UnlockedAccountFlag,
 		utils.PasswordFileFlag,
+		utils.AllowInsecureUnlockFlag,
+		utils.UnlockMaxFailuresFlag,
 		utils.BootnodesFlag,
+		utils.ConfigFileFlag,
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
+		utils.KeyStoreRefreshFlag,
+		utils.ChainDataDirFlag,
+		utils.CrashRecordFlag,
 		utils.OlympicFlag,
 		utils.CacheFlag,
+		utils.DatabaseMaxHandlesFlag,
 		utils.LightKDFFlag,
 		utils.TrieCacheGenFlag,
+		utils.CachePreimagesFlag,
 		utils.JSpathFlag,
 		utils.ListenPortFlag,
+		utils.AdvertiseAddrFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.MaxIngressFlag,
+		utils.MaxEgressFlag,
 		utils.EtherbaseFlag,
 		utils.AutoDAGFlag,
 		utils.TargetGasLimitFlag,
@@ -128,14 +162,31 @@ participating.
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.RPCEVMTimeoutFlag,
+		utils.RPCMethodTimeoutsFlag,
+		utils.RPCCallCacheFlag,
+		utils.RPCCallCacheSizeFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.WSCompressionFlag,
+		utils.WSPingIntervalFlag,
+		utils.WSPongTimeoutFlag,
+		utils.GRPCEnabledFlag,
+		utils.GRPCListenAddrFlag,
+		utils.GRPCPortFlag,
+		utils.GRPCApiFlag,
+		utils.GRPCTLSCertFlag,
+		utils.GRPCTLSKeyFlag,
+		utils.RPCAccessLogFlag,
+		utils.RPCAccessLogSampleFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCApiFlag,
 		utils.IPCPathFlag,
+		utils.IPCSecurityDescriptorFlag,
+		utils.IPCGroupsFlag,
 		utils.ExecFlag,
 		utils.PreloadJSFlag,
 		utils.WhisperEnabledFlag,
@@ -154,26 +205,92 @@ participating.
 		utils.VoteAccountPasswordFlag,
 		utils.VoteBlockMakerAccountFlag,
 		utils.VoteBlockMakerAccountPasswordFlag,
+		utils.NextBlockMakerAccountFlag,
+		utils.NextBlockMakerActivationFlag,
+		utils.BlockMakerSignerFlag,
+		utils.BlockMakerSignerAccountFlag,
+		utils.BlockMakerSignerCACertFlag,
+		utils.BlockMakerSignerTimeoutFlag,
+		utils.BlockMakerSignerBreakGlassFlag,
 		utils.MinBlockTimeFlag,
 		utils.MaxBlockTimeFlag,
 		utils.MinVoteTimeFlag,
 		utils.MaxVoteTimeFlag,
+		utils.VoteRateLimitFlag,
+		utils.TxRateLimitFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.RPCTxRateLimitFlag,
+		utils.TxRateLimitBanFlag,
+		utils.StandbyWindowsFlag,
+		utils.PauseOnDoubleProductionFlag,
+		utils.VoteBatchFlag,
+		utils.RequireProtectedTxFlag,
+		utils.ConfigCheckFlag,
+		utils.MinProtocolVersionFlag,
+		utils.SyncFromFlag,
+		utils.LogIndexFlag,
+		utils.LogIndexRetentionFlag,
+		utils.StatusFileFlag,
+		utils.StatusFileIntervalFlag,
+		utils.StartupSummaryFlag,
+		utils.MaintenanceFlag,
+		utils.WatchdogFlag,
+		utils.WatchdogActionsFlag,
+		utils.FinalityHookFlag,
+		utils.FinalityHookCACertFlag,
+		utils.FinalityHookTimeoutFlag,
+		utils.SlowTxThresholdFlag,
+		utils.FlatStateFlag,
+		utils.InclusionSLAFlag,
+		utils.InclusionSLAHookFlag,
 		utils.SingleBlockMakerFlag,
 		utils.EnableNodePermissionFlag,
+		utils.NodeCertFlag,
+		utils.NodeCertRootsFlag,
+		utils.NodeManifestFlag,
+		utils.NodeManifestSignersFlag,
+		utils.NodeManifestIntervalFlag,
 		utils.VaultAddrFlag,
 		utils.VaultPrefixFlag,
+		utils.VaultAuthMethodFlag,
+		utils.VaultAuthMountFlag,
+		utils.VaultRoleFlag,
+		utils.VaultRoleIDFlag,
+		utils.VaultRoleIDFileFlag,
+		utils.VaultSecretIDFlag,
+		utils.VaultSecretIDFileFlag,
+		utils.VaultJWTFileFlag,
 		utils.VaultPasswordPathFlag,
 		utils.VaultPasswordNameFlag,
+		utils.NodeKeyVaultPathFlag,
+		utils.UnlockVaultPathFlag,
+		utils.KeyStoreVaultPathFlag,
+		utils.DataKeyVaultKeyFlag,
+		utils.DataKeyVaultMountFlag,
 		utils.PrivateConfigPathFlag,
 		utils.RaftModeFlag,
 		utils.RaftBlockTimeFlag,
+		utils.RaftMinTimeIncrementFlag,
+		utils.RaftMaxSpeculativeDepthFlag,
 		utils.RaftJoinExistingFlag,
 		utils.RaftPortFlag,
+		utils.RaftAdvertiseAddrFlag,
+		utils.RaftCompressionFlag,
+		utils.RaftSnapshotRateFlag,
+		utils.RaftDirFlag,
+		utils.RaftBackpressureFlag,
+		utils.RaftBackpressureResumeFlag,
+		utils.RaftFollowerWritesFlag,
+		utils.RaftRPCEndpointFlag,
 	}
 	app.Flags = append(app.Flags, debug.Flags...)
 
 	app.Before = func(ctx *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
+		if err := utils.LoadConfigFile(ctx); err != nil {
+			return err
+		}
+		utils.SetCrashRecord(utils.MakeCrashRecordPath(ctx))
 		if err := debug.Setup(ctx); err != nil {
 			return err
 		}
@@ -212,32 +329,35 @@ func geth(ctx *cli.Context) error {
 	node := makeFullNode(ctx)
 	startNode(ctx, node)
 	node.Wait()
+	utils.CloseVaultClients()
 	return nil
 }
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
 // the zero'd block (i.e. genesis) or will fail hard if it can't succeed.
 func initGenesis(ctx *cli.Context) error {
-	genesisPath := ctx.Args().First()
-	if len(genesisPath) == 0 {
-		utils.Fatalf("must supply path to genesis JSON file")
-	}
-
 	if ctx.GlobalBool(utils.TestNetFlag.Name) {
 		state.StartingNonce = 1048576 // (2**20)
 	}
+	if ctx.String(initBundleFlag.Name) != "" {
+		return initBundle(ctx)
+	}
+	genesisPath := ctx.Args().First()
+	if len(genesisPath) == 0 {
+		utils.Fatal(utils.ExitConfig, "must supply path to genesis JSON file")
+	}
 
 	stack := makeFullNode(ctx)
 	chaindb := utils.MakeChainDatabase(ctx, stack)
 
 	genesisFile, err := os.Open(genesisPath)
 	if err != nil {
-		utils.Fatalf("failed to read genesis file: %v", err)
+		utils.Fatal(utils.ExitConfig, "failed to read genesis file: %v", err)
 	}
 
 	block, err := core.WriteGenesisBlock(chaindb, genesisFile)
 	if err != nil {
-		utils.Fatalf("failed to write genesis block: %v", err)
+		utils.Fatal(utils.ExitDatabase, "failed to write genesis block: %v", err)
 	}
 	glog.V(logger.Info).Infof("successfully wrote genesis block and/or chain rule set: %x", block.Hash())
 	return nil
@@ -266,7 +386,7 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		copy(config.Commit[:], commit)
 		return release.NewReleaseService(ctx, config)
 	}); err != nil {
-		utils.Fatalf("Failed to register the Geth release oracle service: %v", err)
+		utils.Fatal(utils.ExitStartup, "Failed to register the Geth release oracle service: %v", err)
 	}
 
 	return stack
@@ -278,6 +398,8 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 func startNode(ctx *cli.Context, stack *node.Node) {
 	// Start up the node itself
 	utils.StartNode(stack)
+	utils.SetupConfigReload(ctx, stack)
+	debug.StartWatch(ctx, filepath.Join(stack.DataDir(), "profiles"))
 
 	// Fetch password either from (1) plaintext pass args, (2) password file arg,
 	// or (3) Vault cred args.
@@ -287,7 +409,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	} else {
 		passwordResult, err := fetchPassword(ctx)
 		if err != nil {
-			utils.Fatalf("Failed to fetch password: %v", err)
+			utils.Fatal(utils.ExitAccount, "Failed to fetch password: %v", err)
 		}
 		passwords = append(passwords, passwordResult)
 	}
@@ -301,7 +423,11 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 	}
 
-	if ctx.GlobalBool(utils.RaftModeFlag.Name) {
+	if ctx.GlobalBool(utils.DevModeFlag.Name) {
+		unlockDevAccounts(accman)
+	}
+
+	if ctx.GlobalBool(utils.RaftModeFlag.Name) || ctx.GlobalBool(utils.DevModeFlag.Name) {
 		return
 	}
 
@@ -325,8 +451,9 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 	)
 	usingVoterAcct := ctx.GlobalIsSet(utils.VoteAccountFlag.Name)
 	usingBlockMakerAcct := ctx.GlobalIsSet(utils.VoteBlockMakerAccountFlag.Name)
-	if len(accounts) == 0 && !usingVoterAcct && !usingBlockMakerAcct {
-		utils.Fatalf("Was not provided an `unlock`, `voteaccount`, or `blockmakeraccount` flag, cannot launch.")
+	usingBlockMakerSigner := ctx.GlobalIsSet(utils.BlockMakerSignerFlag.Name)
+	if len(accounts) == 0 && !usingVoterAcct && !usingBlockMakerAcct && !usingBlockMakerSigner {
+		utils.Fatal(utils.ExitConfig, "Was not provided an `unlock`, `voteaccount`, `blockmakeraccount` or `blockmakersigner` flag, cannot launch.")
 	}
 	var addr string
 	if usingVoterAcct {
@@ -342,7 +469,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			voteKey, err = accman.Key(common.HexToAddress(addr))
 		}
 		if err != nil {
-			utils.Fatalf("Unable to unlock vote or block maker key: %v", err)
+			utils.Fatal(utils.ExitAccount, "Unable to unlock vote or block maker key: %v", err)
 		}
 	}
 
@@ -351,8 +478,57 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		private.RegeneratePrivateConfig()
 	}
 
-	if err := ethereum.StartBlockVoting(client, voteKey, blockVoteKey); err != nil {
-		utils.Fatalf("Failed to start block voting: %v", err)
+	var blockSigner quorum.BlockSigner
+	if blockVoteKey != nil {
+		blockSigner = quorum.NewLocalSigner(blockVoteKey)
+	}
+	if remote := utils.MakeRemoteBlockSigner(ctx); remote != nil {
+		breakGlass := ctx.GlobalBool(utils.BlockMakerSignerBreakGlassFlag.Name)
+		switch {
+		case breakGlass && blockSigner != nil:
+			blockSigner = quorum.NewFailoverSigner(remote, blockSigner.(*quorum.LocalSigner))
+		case breakGlass:
+			utils.Fatal(utils.ExitConfig, "--%s requires a --%s to fail over to", utils.BlockMakerSignerBreakGlassFlag.Name, utils.VoteBlockMakerAccountFlag.Name)
+		case blockSigner != nil:
+			utils.Fatal(utils.ExitConfig, "Both --%s and --%s given, use --%s to fail over to the local key", utils.BlockMakerSignerFlag.Name, utils.VoteBlockMakerAccountFlag.Name, utils.BlockMakerSignerBreakGlassFlag.Name)
+		default:
+			blockSigner = remote
+		}
+	}
+	if next := strings.TrimSpace(ctx.GlobalString(utils.NextBlockMakerAccountFlag.Name)); next != "" {
+		if blockSigner == nil {
+			utils.Fatal(utils.ExitConfig, "--%s requires a block maker account or signer to rotate from", utils.NextBlockMakerAccountFlag.Name)
+		}
+		if !ctx.GlobalIsSet(utils.NextBlockMakerActivationFlag.Name) {
+			utils.Fatal(utils.ExitConfig, "--%s requires the block to activate it at (--%s)", utils.NextBlockMakerAccountFlag.Name, utils.NextBlockMakerActivationFlag.Name)
+		}
+		account, _ := unlockAccount(ctx, accman, next, 1, passwords)
+		nextKey, err := accman.Key(account.Address)
+		if err != nil {
+			utils.Fatal(utils.ExitAccount, "Unable to unlock next block maker key: %v", err)
+		}
+		blockSigner = quorum.NewRotatingSigner(blockSigner, nextKey, ctx.GlobalUint64(utils.NextBlockMakerActivationFlag.Name))
+	}
+
+	if err := ethereum.StartBlockVoting(client, voteKey, blockSigner); err != nil {
+		utils.Fatal(utils.ExitStartup, "Failed to start block voting: %v", err)
+	}
+}
+
+// unlockDevAccounts imports the prefunded accounts of --dev mode into the
+// keystore, and unlocks them until the node exits.
+func unlockDevAccounts(accman *accounts.Manager) {
+	for _, key := range utils.DevAccountKeys() {
+		account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+		if !accman.HasAddress(account.Address) {
+			if _, err := accman.ImportECDSA(key, ""); err != nil {
+				utils.Fatalf("Failed to import dev account %x: %v", account.Address, err)
+			}
+		}
+		if err := accman.Unlock(account, ""); err != nil {
+			utils.Fatal(utils.ExitAccount, "Failed to unlock dev account %x: %v", account.Address, err)
+		}
+		glog.V(logger.Info).Infof("Unlocked dev account %x", account.Address)
 	}
 }
 
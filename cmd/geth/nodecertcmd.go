@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	nodeCertCAKeyFlag = cli.StringFlag{
+		Name:  "cakey",
+		Usage: "File of the hex encoded private key of the consortium CA, like a node key",
+	}
+	nodeCertNameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "Name of the member operating the node, for information only",
+	}
+	nodeCertValidityFlag = cli.DurationFlag{
+		Name:  "validity",
+		Usage: "Time the certificate is valid for (0 = no expiry)",
+		Value: 365 * 24 * time.Hour,
+	}
+	signNodeCertCommand = cli.Command{
+		Action: signNodeCert,
+		Name:   "signnodecert",
+		Usage:  "issue a certificate admitting a node to the network",
+		Flags:  []cli.Flag{nodeCertCAKeyFlag, nodeCertNameFlag, nodeCertValidityFlag},
+		Description: `
+
+    geth signnodecert --cakey <file> [--name <member>] [--validity <duration>] <enode>
+
+Signs a certificate for the node with the given enode URL or node ID with the
+key of a consortium CA and prints it in JSON. The operator of the node passes
+the file to --nodecert. Nodes trusting the CA, given by its address in
+--nodecert.roots, admit the node without listing its enode in
+permissioned-nodes.json.
+
+A CA key is created like a node key, e.g. with bootnode -genkey. The address of
+the CA is printed to stderr.
+`,
+	}
+	nodeManifestVersionFlag = cli.Uint64Flag{
+		Name:  "version",
+		Usage: "Version of the manifest, higher than any published before (default = current unix time)",
+	}
+	signNodeManifestCommand = cli.Command{
+		Action: signNodeManifest,
+		Name:   "signnodemanifest",
+		Usage:  "sign a node manifest listing the static and permissioned nodes",
+		Flags:  []cli.Flag{nodeCertCAKeyFlag, nodeManifestVersionFlag},
+		Description: `
+
+    geth signnodemanifest --cakey <file> [--version <n>] <manifest.json>
+
+Signs the node manifest in the given file with the key of a consortium CA and
+prints it in JSON. The manifest lists the enode URLs of the static and
+permissioned nodes:
+
+    {"staticNodes": ["enode://..."], "permissionedNodes": ["enode://..."]}
+
+Nodes polling the manifest at its --nodemanifest URL apply it if it's signed by
+one of the --nodemanifest.signers and newer than the last one they applied. A
+list left out of the manifest is left unchanged on the nodes.
+`,
+	}
+)
+
+func signNodeCert(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("The enode or node ID of the node to certify is required")
+	}
+	node, err := discover.ParseNode(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Invalid enode: %v", err)
+	}
+	keyfile := ctx.String(nodeCertCAKeyFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("The CA key is required (--%s)", nodeCertCAKeyFlag.Name)
+	}
+	ca, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to load CA key: %v", err)
+	}
+
+	cert := &p2p.NodeCert{ID: node.ID, Name: ctx.String(nodeCertNameFlag.Name)}
+	if validity := ctx.Duration(nodeCertValidityFlag.Name); validity > 0 {
+		cert.Expires = uint64(time.Now().Add(validity).Unix())
+	}
+	if err := cert.Sign(ca); err != nil {
+		utils.Fatalf("Failed to sign certificate: %v", err)
+	}
+	out, err := json.MarshalIndent(cert, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode certificate: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Certificate of CA %x\n", crypto.PubkeyToAddress(ca.PublicKey))
+	fmt.Println(string(out))
+	return nil
+}
+
+func signNodeManifest(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("The manifest file to sign is required")
+	}
+	blob, err := ioutil.ReadFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read manifest: %v", err)
+	}
+	manifest := new(p2p.NodeManifest)
+	if err := json.Unmarshal(blob, manifest); err != nil {
+		utils.Fatalf("Invalid manifest: %v", err)
+	}
+	if _, _, err := manifest.Nodes(); err != nil {
+		utils.Fatalf("Invalid manifest: %v", err)
+	}
+	keyfile := ctx.String(nodeCertCAKeyFlag.Name)
+	if keyfile == "" {
+		utils.Fatalf("The CA key is required (--%s)", nodeCertCAKeyFlag.Name)
+	}
+	ca, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to load CA key: %v", err)
+	}
+
+	manifest.Version = ctx.Uint64(nodeManifestVersionFlag.Name)
+	if manifest.Version == 0 {
+		manifest.Version = uint64(time.Now().Unix())
+	}
+	if err := manifest.Sign(ca); err != nil {
+		utils.Fatalf("Failed to sign manifest: %v", err)
+	}
+	out, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode manifest: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Manifest version %d of CA %x\n", manifest.Version, crypto.PubkeyToAddress(ca.PublicKey))
+	fmt.Println(string(out))
+	return nil
+}
@@ -1,17 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	cli "gopkg.in/urfave/cli.v1"
-
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
-	"github.com/aws/aws-sdk-go/aws/session"
-	vaultAPI "github.com/hashicorp/vault/api"
-	awsauth "github.com/hashicorp/vault/builtin/credential/aws"
 )
 
 func fetchPassword(ctx *cli.Context) (string, error) {
@@ -37,41 +31,26 @@ func fetchPasswordFromCLI(ctx *cli.Context) (string, error) {
 
 func fetchPasswordFromVault(ctx *cli.Context) (string, error) {
 	if usingVaultPassword(ctx) {
-		// Authenticate to Vault via the AWS method
-		vaultConfig := vaultAPI.DefaultConfig()
-		vaultConfig.Address = ctx.GlobalString(utils.VaultAddrFlag.Name)
-		vaultClient, err := vaultAPI.NewClient(vaultConfig)
-		token, err := loginAws(vaultClient)
+		vaultClient, err := utils.MakeVaultClient(ctx)
 		if err != nil {
 			log.Fatal(err)
 			return "", err
 		}
-		vaultClient.SetToken(token)
+		defer vaultClient.Close()
 
 		// Perform the query to retrieve the password value
-		vault := vaultClient.Logical()
-		fullSecretPath := "/" + ctx.GlobalString(utils.VaultPrefixFlag.Name) +
-			"/" + ctx.GlobalString(utils.VaultPasswordPathFlag.Name)
-		secret, err := vault.Read(fullSecretPath)
-		if err != nil {
-			log.Fatal(err)
-			return "", err
-		}
-
-		// Extract from response & return to caller
+		path := ctx.GlobalString(utils.VaultPasswordPathFlag.Name)
 		keyname := ctx.GlobalString(utils.VaultPasswordNameFlag.Name)
-		password, present := secret.Data[keyname]
-		if !present {
-			utils.Fatalf("fetchPasswordFromVault found a secret at specified path (%v), but secret did not contain specified key name (%v). Secret was : %v", fullSecretPath, keyname, secret.Data)
+		password, err := vaultClient.Read(path, keyname)
+		if err != nil {
+			utils.Fatalf("fetchPasswordFromVault could not retrieve password: %v", err)
 		}
-		return password.(string), nil
+		return password, nil
 	}
 	utils.Fatalf("fetchPasswordFromVault called even though CLI got a password argument.")
 	return "", nil
 }
 
-func cliVal(ctx *cli.Context)
-
 func usingVaultPassword(ctx *cli.Context) bool {
 	passwordFlags := map[cli.StringFlag]string{
 		utils.VoteAccountPasswordFlag:           strings.TrimSpace(ctx.GlobalString(utils.VoteAccountPasswordFlag.Name)),
@@ -109,52 +88,3 @@ func usingVaultPassword(ctx *cli.Context) bool {
 		return true
 	}
 }
-
-// Expects to be running in EC2
-func getIAMRole() (string, error) {
-	svc := ec2metadata.New(session.New())
-	iam, err := svc.IAMInfo()
-	if err != nil {
-		return "", err
-	}
-	// Our instance profile conveniently has the same name as the role
-	profile := iam.InstanceProfileArn
-	splitArn := strings.Split(profile, "/")
-	if len(splitArn) < 2 {
-		return "", fmt.Errorf("no / character found in instance profile ARN")
-	}
-	role := splitArn[1]
-	return role, nil
-}
-
-func loginAws(v *vaultAPI.Client) (string, error) {
-	loginData, err := awsauth.GenerateLoginData("", "", "", "")
-	if err != nil {
-		return "", err
-	}
-	if loginData == nil {
-		return "", fmt.Errorf("got nil response from GenerateLoginData")
-	}
-
-	role, err := getIAMRole()
-	if err != nil {
-		return "", err
-	}
-	loginData["role"] = role
-
-	path := "auth/aws/login"
-
-	secret, err := v.Logical().Write(path, loginData)
-	if err != nil {
-		return "", err
-	}
-	if secret == nil {
-		return "", fmt.Errorf("empty response from credential provider")
-	}
-	if secret.Auth == nil {
-		return "", fmt.Errorf("auth secret has no auth data")
-	}
-
-	token := secret.Auth.ClientToken
-	return token, nil
-}
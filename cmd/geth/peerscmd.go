@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var checkPeersConfigCommand = cli.Command{
+	Action: checkPeersConfig,
+	Name:   "check-peers-config",
+	Usage:  "check static-nodes.json, trusted-nodes.json and permissioned-nodes.json",
+	Description: `
+
+    geth [--datadir <dir>] [--raft [--raftjoinexisting <id>]] [--permissioned] check-peers-config
+
+Checks the node lists in the data directory the way a node started with the
+same flags loads them, and reports every invalid entry instead of stopping at
+the first. Entries are checked for invalid enode URLs and node IDs, duplicate
+node IDs and addresses, and addresses which can't be dialed. With --raft, the
+static nodes are the initial cluster and need an address and a raftport, and
+the local node has to be among them unless it joins an existing cluster. With
+--permissioned, static nodes missing from the permissioned nodes are reported.
+
+The lists are JSON arrays of enode URLs, or objects of a schema version:
+
+    {"version": 1, "nodes": ["enode://...", ...]}
+
+The command fails if any list has errors, so it can run before deployments.
+`,
+}
+
+func checkPeersConfig(ctx *cli.Context) error {
+	config := &node.Config{DataDir: utils.MakeDataDir(ctx), Name: clientIdentifier}
+	raftInit := ctx.GlobalBool(utils.RaftModeFlag.Name) && ctx.GlobalInt(utils.RaftJoinExistingFlag.Name) == 0
+	permissioned := ctx.GlobalBool(utils.EnableNodePermissionFlag.Name)
+
+	errors := 0
+	load := func(name, path string, rules p2p.NodeListRules, required bool) *p2p.NodeList {
+		list, err := p2p.LoadNodeList(path, rules)
+		switch {
+		case os.IsNotExist(err) && !required:
+			fmt.Printf("%s: not found\n", name)
+			return nil
+		case err != nil:
+			fmt.Printf("%s: error: %v\n", name, err)
+			errors++
+			return nil
+		}
+		fmt.Printf("%s: version %d, %d nodes\n", path, list.Version, len(list.Nodes))
+		for _, issue := range list.Issues {
+			fmt.Printf("  %v\n", issue)
+		}
+		errors += list.Errors()
+		return list
+	}
+	staticRules := p2p.NodeListRules{Dialed: true}
+	if raftInit {
+		staticRules = p2p.NodeListRules{Complete: true, RaftPort: true}
+	}
+	static := load("static-nodes.json", config.ResolvePath("static-nodes.json"), staticRules, raftInit)
+	load("trusted-nodes.json", config.ResolvePath("trusted-nodes.json"), p2p.NodeListRules{}, false)
+	allowed := load(p2p.PERMISSIONED_CONFIG, filepath.Join(config.DataDir, p2p.PERMISSIONED_CONFIG), p2p.NodeListRules{}, permissioned)
+
+	// Check the lists against each other and the local node
+	if raftInit && static != nil {
+		key := utils.MakeNodeKey(ctx)
+		if key == nil {
+			key, _ = crypto.LoadECDSA(config.ResolvePath("nodekey"))
+		}
+		if key == nil {
+			fmt.Println("  warning: no node key, can't check that the local node is an initial raft peer")
+		} else if id := discover.PubkeyID(&key.PublicKey); !containsNode(static.Nodes, id) {
+			fmt.Printf("  error: local node %x is not an initial raft peer\n", id[:8])
+			errors++
+		}
+	}
+	if permissioned && static != nil && allowed != nil {
+		for _, n := range static.Nodes {
+			if !containsNode(allowed.Nodes, n.ID) {
+				fmt.Printf("  warning: static node %x is not permissioned\n", n.ID[:8])
+			}
+		}
+	}
+	if errors > 0 {
+		utils.Fatal(utils.ExitConfig, "%d errors in the node lists", errors)
+	}
+	fmt.Println("No errors")
+	return nil
+}
+
+func containsNode(nodes []*discover.Node, id discover.NodeID) bool {
+	for _, n := range nodes {
+		if n.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	preimagesBlockFlag = cli.StringFlag{
+		Name:  "block",
+		Usage: "Number or hash of the block to export the storage preimages at (default: head block)",
+	}
+	exportPreimagesCommand = cli.Command{
+		Action: exportPreimages,
+		Name:   "exportpreimages",
+		Usage:  "export the preimages of the hashed storage keys of contracts",
+		Flags:  []cli.Flag{preimagesBlockFlag},
+		Description: `
+Requires a first argument of the file to write to, followed by the addresses of
+the contracts to export. For every contract the hash of its address and the
+preimages of its hashed storage keys are written to the file as JSON, for
+interpreting storage dumps which are keyed by the hashes.
+
+Preimages are only known for keys written while the node ran with
+--cache.preimages, the hashes of the other keys are listed as missing. Private
+contracts are read from the private state if the node is a party to them.
+`,
+	}
+)
+
+func exportPreimages(ctx *cli.Context) error {
+	if len(ctx.Args()) < 2 {
+		utils.Fatalf("This command requires a file and at least one contract address.")
+	}
+	var addresses []common.Address
+	for _, arg := range ctx.Args()[1:] {
+		if !common.IsHexAddress(arg) {
+			utils.Fatalf("Invalid address %q", arg)
+		}
+		addresses = append(addresses, common.HexToAddress(arg))
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	block := chain.CurrentBlock()
+	if arg := ctx.String(preimagesBlockFlag.Name); arg != "" {
+		if hashish(arg) {
+			block = chain.GetBlockByHash(common.HexToHash(arg))
+		} else if num, err := strconv.ParseUint(arg, 10, 64); err == nil {
+			block = chain.GetBlockByNumber(num)
+		} else {
+			utils.Fatalf("Invalid block %q", arg)
+		}
+		if block == nil {
+			utils.Fatalf("Block %s not found", arg)
+		}
+	}
+	publicState, privateState, err := chain.StateAt(block.Root())
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	results := make([]state.Preimages, 0, len(addresses))
+	missing := 0
+	for _, address := range addresses {
+		statedb := publicState
+		if privateState.Exist(address) {
+			statedb = privateState
+		}
+		result, err := statedb.StoragePreimages(address)
+		if err != nil {
+			utils.Fatalf("Export error: %v", err)
+		}
+		results = append(results, result)
+		missing += len(result.Missing)
+	}
+
+	fh, err := os.OpenFile(ctx.Args().First(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	defer fh.Close()
+	buf := bufio.NewWriter(fh)
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	if err := buf.Flush(); err != nil {
+		utils.Fatalf("Export error: %v", err)
+	}
+	fmt.Printf("Exported the storage preimages of %d contracts at block #%d, %d missing\n", len(results), block.NumberU64(), missing)
+	return nil
+}
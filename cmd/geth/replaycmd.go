@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	replayFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "First block to re-execute",
+		Value: 1,
+	}
+	replayToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "Last block to re-execute (default: head block)",
+	}
+	replayTraceFlag = cli.StringFlag{
+		Name:  "trace",
+		Usage: "File to write the EVM traces of the transactions of the diverging block to",
+	}
+	replayCommand = cli.Command{
+		Action: replayChain,
+		Name:   "replay",
+		Usage:  "re-execute blocks and report the first state divergence",
+		Flags:  []cli.Flag{replayFromFlag, replayToFlag, replayTraceFlag},
+		Description: `
+Re-executes the canonical blocks --from to --to on top of the stored state of
+their parents, and compares the resulting public and private state roots and
+receipts with the stored ones. The replay stops at the first block which
+doesn't reproduce them, printing the transaction whose receipt differs and
+every account field which differs between the stored and recomputed states.
+With --trace the transactions of that block are executed again with the EVM
+struct logger, and the traces are written to the given file as JSON.
+
+Private transactions are executed with the payloads of the privacy manager
+configured through PRIVATE_CONFIG, which has to be the one the node used when
+importing the blocks. The chain database isn't modified, but the node must not
+be running.
+`,
+	}
+)
+
+// replayTrace is the trace of a transaction of a diverging block.
+type replayTrace struct {
+	TxHash     string                `json:"transactionHash"`
+	StructLogs []ethapi.StructLogRes `json:"structLogs"`
+}
+
+func replayChain(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	first, last := ctx.Uint64(replayFromFlag.Name), chain.CurrentBlock().NumberU64()
+	if ctx.IsSet(replayToFlag.Name) {
+		last = ctx.Uint64(replayToFlag.Name)
+	}
+	if first > last {
+		utils.Fatalf("First block %d is after last block %d", first, last)
+	}
+	start, reported := time.Now(), time.Now()
+	d, err := core.ReplayChain(chain, first, last, func(block *types.Block) {
+		if time.Since(reported) > 8*time.Second {
+			fmt.Printf("Replayed up to block #%d\n", block.NumberU64())
+			reported = time.Now()
+		}
+	})
+	if err != nil {
+		utils.Fatalf("Replay error: %v", err)
+	}
+	if d == nil {
+		fmt.Printf("Replayed blocks #%d to #%d in %v, no divergence\n", first, last, time.Since(start))
+		return nil
+	}
+	fmt.Printf("Divergence at %v\n", d)
+	for _, diff := range []struct {
+		name  string
+		state *core.StateDiff
+	}{{"public", d.Public}, {"private", d.Private}} {
+		if diff.state == nil {
+			continue
+		}
+		fmt.Printf("\n%d differences in the %s state (recomputed %x, stored %x):\n", len(diff.state.Accounts), diff.name, diff.state.Have, diff.state.Want)
+		for _, account := range diff.state.Accounts {
+			fmt.Println(" ", account)
+		}
+	}
+	if path := ctx.String(replayTraceFlag.Name); path != "" {
+		if err := writeReplayTraces(chain, d, path); err != nil {
+			utils.Fatalf("Trace error: %v", err)
+		}
+		fmt.Printf("\nWrote the traces of block #%d to %s\n", d.Number, path)
+	}
+	utils.Fatalf("Block #%d diverges from the stored chain", d.Number)
+	return nil
+}
+
+// writeReplayTraces re-executes a diverging block with tracing and writes the
+// traces of its transactions to a file.
+func writeReplayTraces(chain *core.BlockChain, d *core.Divergence, path string) error {
+	block := chain.GetBlock(d.Hash, d.Number)
+	traced, err := core.ReplayBlock(chain, block, true)
+	if err != nil {
+		return err
+	}
+	if traced == nil {
+		return fmt.Errorf("block #%d didn't diverge when traced", d.Number)
+	}
+	traces := make([]replayTrace, len(traced.Traces))
+	for i, logs := range traced.Traces {
+		traces[i] = replayTrace{TxHash: block.Transactions()[i].Hash().Hex(), StructLogs: ethapi.FormatLogs(logs)}
+	}
+	out, err := json.MarshalIndent(traces, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// defaultSignTxGas is the gas limit of transactions which don't specify one,
+// the same default eth_sendTransaction uses.
+const defaultSignTxGas = 90000
+
+var (
+	signTxVaultKeyPathFlag = cli.StringFlag{
+		Name:  "vaultkeypath",
+		Usage: "Vault path within the KV engine of the hex encoded private key to sign with, instead of the keystore",
+	}
+	signTxVaultKeyNameFlag = cli.StringFlag{
+		Name:  "vaultkeyname",
+		Usage: "Key name within the Vault secret holding the private key",
+		Value: "privatekey",
+	}
+	signTxCommand = cli.Command{
+		Action: signTx,
+		Name:   "signtx",
+		Usage:  "sign a transaction offline and print it RLP encoded",
+		Flags:  []cli.Flag{signTxVaultKeyPathFlag, signTxVaultKeyNameFlag},
+		Description: `
+
+    geth signtx [<file>]
+
+Reads an unsigned transaction in JSON from the given file, or from stdin if no
+file is given, signs it without starting the node and prints the raw signed
+transaction in hex, ready for eth_sendRawTransaction.
+
+The transaction has the fields of eth_sendTransaction: from, to, gas, value,
+data, nonce, privateFrom and privateFor. The nonce is required, as it can't be
+looked up offline. Public transactions with a chainId are replay protected.
+
+Private transactions with privateFor send their payload to the transaction
+manager configured in PRIVATE_CONFIG, like eth_sendTransaction does. On
+machines without access to it, store the payload first and pass its hash as
+data together with "private": true instead.
+
+The key of the from account is taken from the keystore and decrypted with the
+password from --password, from Vault (--vaultpasswordpath), or prompted for.
+With --vaultkeypath the key is read from Vault instead.
+`,
+	}
+)
+
+// signTxArgs is an unsigned transaction as read by signtx.
+type signTxArgs struct {
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Gas         *rpc.HexNumber  `json:"gas"`
+	Value       *rpc.HexNumber  `json:"value"`
+	Data        string          `json:"data"`
+	Nonce       *rpc.HexNumber  `json:"nonce"`
+	ChainId     *rpc.HexNumber  `json:"chainId"`
+	PrivateFrom string          `json:"privateFrom"`
+	PrivateFor  []string        `json:"privateFor"`
+	Private     bool            `json:"private"` // data already is the hash of a stored private payload
+}
+
+func signTx(ctx *cli.Context) error {
+	var (
+		input []byte
+		err   error
+	)
+	if file := ctx.Args().First(); file != "" && file != "-" {
+		input, err = ioutil.ReadFile(file)
+	} else {
+		input, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		utils.Fatalf("Failed to read transaction: %v", err)
+	}
+	var args signTxArgs
+	if err := json.Unmarshal(input, &args); err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+	tx, chainId, isPrivate, err := args.toTransaction()
+	if err != nil {
+		utils.Fatalf("Invalid transaction: %v", err)
+	}
+
+	var sign func(hash []byte) ([]byte, error)
+	if path := ctx.String(signTxVaultKeyPathFlag.Name); path != "" {
+		key := loadVaultSigningKey(ctx, path)
+		if args.From != (common.Address{}) && args.From != crypto.PubkeyToAddress(key.PublicKey) {
+			utils.Fatalf("Key in Vault belongs to %x, not to the sender %x", crypto.PubkeyToAddress(key.PublicKey), args.From)
+		}
+		sign = func(hash []byte) ([]byte, error) {
+			return crypto.SignEthereum(hash, key)
+		}
+	} else {
+		if args.From == (common.Address{}) {
+			utils.Fatalf("The sender (from) is required to sign with the keystore")
+		}
+		stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+		password := signTxPassword(ctx, args.From)
+		sign = func(hash []byte) ([]byte, error) {
+			return stack.AccountManager().SignWithPassphrase(args.From, password, hash)
+		}
+	}
+	tx, raw, err := signTransaction(tx, chainId, isPrivate, sign)
+	if err != nil {
+		utils.Fatalf("Failed to sign transaction: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Signed transaction %x\n", tx.Hash())
+	fmt.Println(common.ToHex(raw))
+	return nil
+}
+
+// signTransaction signs the transaction with the given signer, replay protected
+// if a chain ID is given, and returns it together with its RLP encoding.
+func signTransaction(tx *types.Transaction, chainId *big.Int, isPrivate bool, sign func(hash []byte) ([]byte, error)) (*types.Transaction, []byte, error) {
+	hash := tx.SigHash()
+	if chainId != nil {
+		hash = tx.EIP155SigHash(chainId)
+	}
+	signature, err := sign(hash.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	if chainId != nil {
+		tx, err = tx.WithEIP155Signature(signature, chainId)
+	} else {
+		tx, err = tx.WithSignature(signature)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// mark private after creating signed copy
+	if isPrivate {
+		tx.SetPrivate()
+	}
+	raw, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tx, raw, nil
+}
+
+// toTransaction creates the unsigned transaction, storing the private payload
+// with the transaction manager if needed. It also returns the chain ID to sign
+// for, if any, and whether the transaction is private.
+func (args *signTxArgs) toTransaction() (*types.Transaction, *big.Int, bool, error) {
+	if args.Nonce == nil {
+		return nil, nil, false, fmt.Errorf("nonce is required")
+	}
+	gas := big.NewInt(defaultSignTxGas)
+	if args.Gas != nil {
+		gas = args.Gas.BigInt()
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.BigInt()
+	}
+	data := common.FromHex(args.Data)
+	isPrivate := args.Private || args.PrivateFor != nil
+	if args.Private && args.PrivateFor != nil {
+		return nil, nil, false, fmt.Errorf("private and privateFor are mutually exclusive")
+	}
+	if args.PrivateFor != nil {
+		var err error
+		if data, err = private.Send(data, args.PrivateFrom, args.PrivateFor); err != nil {
+			return nil, nil, false, err
+		}
+	}
+	var chainId *big.Int
+	if args.ChainId != nil {
+		if isPrivate {
+			return nil, nil, false, fmt.Errorf("private transactions can't be replay protected")
+		}
+		chainId = args.ChainId.BigInt()
+	}
+
+	if args.To == nil {
+		return types.NewContractCreation(args.Nonce.Uint64(), value, gas, nil, data), chainId, isPrivate, nil
+	}
+	return types.NewTransaction(args.Nonce.Uint64(), *args.To, value, gas, nil, data), chainId, isPrivate, nil
+}
+
+// signTxPassword retrieves the password of the keystore account from the
+// password file, Vault or the user.
+func signTxPassword(ctx *cli.Context, from common.Address) string {
+	if ctx.GlobalString(utils.PasswordFileFlag.Name) == "" && ctx.GlobalString(utils.VaultPasswordPathFlag.Name) != "" {
+		password, err := fetchPasswordFromVault(ctx)
+		if err != nil {
+			utils.Fatalf("Failed to retrieve password from Vault: %v", err)
+		}
+		return password
+	}
+	passwords := utils.MakePasswordList(ctx)
+	if len(passwords) == 0 {
+		// Keep stdout for the signed transaction
+		fmt.Fprintf(os.Stderr, "Signing transaction from %x\n", from)
+	}
+	return getPassPhrase("", false, 0, passwords)
+}
+
+// loadVaultSigningKey retrieves the hex encoded private key stored in Vault.
+func loadVaultSigningKey(ctx *cli.Context, path string) *ecdsa.PrivateKey {
+	client, err := utils.MakeVaultClient(ctx)
+	if err != nil {
+		utils.Fatalf("Failed to access Vault: %v", err)
+	}
+	defer client.Close()
+
+	hexKey, err := client.Read(path, ctx.String(signTxVaultKeyNameFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to read signing key from Vault: %v", err)
+	}
+	key, err := crypto.HexToECDSA(hexKey)
+	if err != nil {
+		utils.Fatalf("Invalid signing key in Vault: %v", err)
+	}
+	return key
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that offline signed transactions decode from their RLP encoding with
+// the fields given and the signing key's address as sender.
+func TestSignTransaction(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	sign := func(hash []byte) ([]byte, error) {
+		return crypto.SignEthereum(hash, key)
+	}
+	to := common.HexToAddress("0x00000000000000000000000000000000000000dd")
+
+	tests := []struct {
+		input     string
+		chainId   *big.Int
+		isPrivate bool
+	}{
+		{input: `{"from": "` + sender.Hex() + `", "to": "` + to.Hex() + `", "value": "0x2a", "gas": "0x5208", "nonce": "0x3"}`},
+		{input: `{"to": "` + to.Hex() + `", "value": "0x2a", "gas": "0x5208", "nonce": "0x3", "chainId": "0x7"}`, chainId: big.NewInt(7)},
+		{input: `{"to": "` + to.Hex() + `", "value": "0x2a", "gas": "0x5208", "nonce": "0x3", "data": "0x` + strings.Repeat("ab", 64) + `", "private": true}`, isPrivate: true},
+	}
+	for i, test := range tests {
+		var args signTxArgs
+		if err := json.Unmarshal([]byte(test.input), &args); err != nil {
+			t.Fatalf("test %d: invalid input: %v", i, err)
+		}
+		tx, chainId, isPrivate, err := args.toTransaction()
+		if err != nil {
+			t.Fatalf("test %d: failed to create transaction: %v", i, err)
+		}
+		if (chainId == nil) != (test.chainId == nil) || (chainId != nil && chainId.Cmp(test.chainId) != 0) || isPrivate != test.isPrivate {
+			t.Errorf("test %d: chain ID %v, private %v, want %v, %v", i, chainId, isPrivate, test.chainId, test.isPrivate)
+		}
+		signed, raw, err := signTransaction(tx, chainId, isPrivate, sign)
+		if err != nil {
+			t.Fatalf("test %d: failed to sign: %v", i, err)
+		}
+		decoded := new(types.Transaction)
+		if err := rlp.DecodeBytes(raw, decoded); err != nil {
+			t.Fatalf("test %d: signed transaction doesn't decode: %v", i, err)
+		}
+		if decoded.Hash() != signed.Hash() {
+			t.Errorf("test %d: hash mismatch after decoding: have %x, want %x", i, decoded.Hash(), signed.Hash())
+		}
+		if from, err := decoded.From(); err != nil || from != sender {
+			t.Errorf("test %d: recovered sender %x (%v), want %x", i, from, err, sender)
+		}
+		if decoded.Nonce() != 3 || decoded.Value().Cmp(big.NewInt(0x2a)) != 0 || decoded.Gas().Cmp(big.NewInt(0x5208)) != 0 || *decoded.To() != to {
+			t.Errorf("test %d: fields mismatch: %v", i, decoded)
+		}
+		if decoded.Protected() != (test.chainId != nil) || (test.chainId != nil && decoded.ChainId().Cmp(test.chainId) != 0) {
+			t.Errorf("test %d: replay protection mismatch: protected %v, chain ID %v", i, decoded.Protected(), decoded.ChainId())
+		}
+		if decoded.IsPrivate() != test.isPrivate {
+			t.Errorf("test %d: private %v, want %v", i, decoded.IsPrivate(), test.isPrivate)
+		}
+	}
+}
+
+func TestSignTxArgsErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{`{"to": "0x00000000000000000000000000000000000000dd"}`, "nonce is required"},
+		{`{"nonce": "0x0", "private": true, "privateFor": ["key"]}`, "mutually exclusive"},
+		{`{"nonce": "0x0", "private": true, "chainId": "0x1"}`, "can't be replay protected"},
+	}
+	for i, test := range tests {
+		var args signTxArgs
+		if err := json.Unmarshal([]byte(test.input), &args); err != nil {
+			t.Fatalf("test %d: invalid input: %v", i, err)
+		}
+		if _, _, _, err := args.toTransaction(); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotPrivateFlag = cli.BoolFlag{
+		Name:  "private",
+		Usage: "Include the private state of the node",
+	}
+	snapshotCommand = cli.Command{
+		Name:  "snapshot",
+		Usage: "export and restore the state at a block",
+		Description: `
+Snapshots hold the state at a block, without the trie nodes and the chain
+before it, as an alternative to importing or syncing the chain from genesis.
+`,
+		Subcommands: []cli.Command{
+			{
+				Action: snapshotDump,
+				Name:   "dump",
+				Usage:  "write the state at a block to a snapshot file",
+				Flags:  []cli.Flag{snapshotPrivateFlag},
+				Description: `
+Requires a first argument of the file to write to. The optional second argument
+is the number or hash of the block to export, the head block by default.
+
+The private state is only included with --private. It's meant for replacing
+the node of the same member, other members aren't a party to its contents.
+`,
+			},
+			{
+				Action: snapshotRestore,
+				Name:   "restore",
+				Usage:  "initialize a chain from a snapshot file",
+				Description: `
+Requires an argument of the snapshot file to restore. The data directory must
+have been initialized with the genesis of the chain and have no other blocks.
+The state is checked against the roots of the snapshot block, which becomes the
+head of the chain. Blocks after it can be imported or synced from the peers.
+`,
+			},
+		},
+	}
+)
+
+func snapshotDump(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	block := chain.CurrentBlock()
+	if len(ctx.Args()) > 1 {
+		if arg := ctx.Args().Get(1); hashish(arg) {
+			block = chain.GetBlockByHash(common.HexToHash(arg))
+		} else if num, err := strconv.ParseUint(arg, 10, 64); err == nil {
+			block = chain.GetBlockByNumber(num)
+		} else {
+			utils.Fatalf("Invalid block %q", arg)
+		}
+		if block == nil {
+			utils.Fatalf("Block %s not found", ctx.Args().Get(1))
+		}
+	}
+	fh, err := os.OpenFile(ctx.Args().First(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		utils.Fatalf("Snapshot error: %v", err)
+	}
+	defer fh.Close()
+	buf := bufio.NewWriter(fh)
+
+	start := time.Now()
+	if err := core.ExportSnapshot(chainDb, block, ctx.Bool(snapshotPrivateFlag.Name), buf); err != nil {
+		utils.Fatalf("Snapshot error: %v", err)
+	}
+	if err := buf.Flush(); err != nil {
+		utils.Fatalf("Snapshot error: %v", err)
+	}
+	fmt.Printf("Snapshot of block #%d [%x] written in %v\n", block.NumberU64(), block.Hash().Bytes()[:4], time.Since(start))
+	return nil
+}
+
+func snapshotRestore(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack := utils.MakeNode(ctx, clientIdentifier, gitCommit)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer utils.CloseOnFatal("chaindata", chainDb)()
+
+	fh, err := os.Open(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Restore error: %v", err)
+	}
+	defer fh.Close()
+
+	start := time.Now()
+	block, err := core.RestoreSnapshot(chainDb, bufio.NewReader(fh))
+	if err != nil {
+		utils.Fatalf("Restore error: %v", err)
+	}
+	fmt.Printf("Restored block #%d [%x] in %v\n", block.NumberU64(), block.Hash().Bytes()[:4], time.Since(start))
+	return nil
+}
@@ -64,8 +64,12 @@ var AppHelpFlagGroups = []flagGroup{
 	{
 		Name: "ETHEREUM",
 		Flags: []cli.Flag{
+			utils.ConfigFileFlag,
 			utils.DataDirFlag,
 			utils.KeyStoreDirFlag,
+			utils.KeyStoreRefreshFlag,
+			utils.ChainDataDirFlag,
+			utils.CrashRecordFlag,
 			utils.NetworkIdFlag,
 			utils.OlympicFlag,
 			utils.TestNetFlag,
@@ -78,7 +82,9 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "PERFORMANCE TUNING",
 		Flags: []cli.Flag{
 			utils.CacheFlag,
+			utils.DatabaseMaxHandlesFlag,
 			utils.TrieCacheGenFlag,
+			utils.CachePreimagesFlag,
 		},
 	},
 	{
@@ -88,11 +94,45 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.VoteAccountPasswordFlag,
 			utils.VoteBlockMakerAccountFlag,
 			utils.VoteBlockMakerAccountPasswordFlag,
+			utils.NextBlockMakerAccountFlag,
+			utils.NextBlockMakerActivationFlag,
+			utils.BlockMakerSignerFlag,
+			utils.BlockMakerSignerAccountFlag,
+			utils.BlockMakerSignerCACertFlag,
+			utils.BlockMakerSignerTimeoutFlag,
+			utils.BlockMakerSignerBreakGlassFlag,
 			utils.SingleBlockMakerFlag,
 			utils.MinBlockTimeFlag,
 			utils.MaxBlockTimeFlag,
 			utils.MinVoteTimeFlag,
 			utils.MaxVoteTimeFlag,
+			utils.VoteRateLimitFlag,
+			utils.TxRateLimitFlag,
+			utils.TxPoolSnapshotFlag,
+			utils.RPCTxRateLimitFlag,
+			utils.TxRateLimitBanFlag,
+			utils.StandbyWindowsFlag,
+			utils.PauseOnDoubleProductionFlag,
+			utils.VoteBatchFlag,
+			utils.RequireProtectedTxFlag,
+			utils.ConfigCheckFlag,
+			utils.MinProtocolVersionFlag,
+			utils.SyncFromFlag,
+			utils.LogIndexFlag,
+			utils.LogIndexRetentionFlag,
+			utils.StatusFileFlag,
+			utils.StatusFileIntervalFlag,
+			utils.StartupSummaryFlag,
+			utils.MaintenanceFlag,
+			utils.WatchdogFlag,
+			utils.WatchdogActionsFlag,
+			utils.FinalityHookFlag,
+			utils.FinalityHookCACertFlag,
+			utils.FinalityHookTimeoutFlag,
+			utils.SlowTxThresholdFlag,
+			utils.FlatStateFlag,
+			utils.InclusionSLAFlag,
+			utils.InclusionSLAHookFlag,
 			utils.PrivateConfigPathFlag,
 		},
 	},
@@ -101,8 +141,21 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.VaultAddrFlag,
 			utils.VaultPrefixFlag,
+			utils.VaultAuthMethodFlag,
+			utils.VaultAuthMountFlag,
+			utils.VaultRoleFlag,
+			utils.VaultRoleIDFlag,
+			utils.VaultRoleIDFileFlag,
+			utils.VaultSecretIDFlag,
+			utils.VaultSecretIDFileFlag,
+			utils.VaultJWTFileFlag,
 			utils.VaultPasswordPathFlag,
 			utils.VaultPasswordNameFlag,
+			utils.NodeKeyVaultPathFlag,
+			utils.UnlockVaultPathFlag,
+			utils.KeyStoreVaultPathFlag,
+			utils.DataKeyVaultKeyFlag,
+			utils.DataKeyVaultMountFlag,
 		},
 	},
 	{
@@ -110,8 +163,18 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.RaftModeFlag,
 			utils.RaftBlockTimeFlag,
+			utils.RaftMinTimeIncrementFlag,
+			utils.RaftMaxSpeculativeDepthFlag,
 			utils.RaftJoinExistingFlag,
 			utils.RaftPortFlag,
+			utils.RaftAdvertiseAddrFlag,
+			utils.RaftCompressionFlag,
+			utils.RaftSnapshotRateFlag,
+			utils.RaftDirFlag,
+			utils.RaftBackpressureFlag,
+			utils.RaftBackpressureResumeFlag,
+			utils.RaftFollowerWritesFlag,
+			utils.RaftRPCEndpointFlag,
 		},
 	},
 	{
@@ -119,6 +182,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
+			utils.AllowInsecureUnlockFlag,
+			utils.UnlockMaxFailuresFlag,
 		},
 	},
 	{
@@ -128,14 +193,31 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.RPCListenAddrFlag,
 			utils.RPCPortFlag,
 			utils.RPCApiFlag,
+			utils.RPCEVMTimeoutFlag,
+			utils.RPCMethodTimeoutsFlag,
+			utils.RPCCallCacheFlag,
+			utils.RPCCallCacheSizeFlag,
 			utils.WSEnabledFlag,
 			utils.WSListenAddrFlag,
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.WSCompressionFlag,
+			utils.WSPingIntervalFlag,
+			utils.WSPongTimeoutFlag,
+			utils.GRPCEnabledFlag,
+			utils.GRPCListenAddrFlag,
+			utils.GRPCPortFlag,
+			utils.GRPCApiFlag,
+			utils.GRPCTLSCertFlag,
+			utils.GRPCTLSKeyFlag,
+			utils.RPCAccessLogFlag,
+			utils.RPCAccessLogSampleFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCApiFlag,
 			utils.IPCPathFlag,
+			utils.IPCSecurityDescriptorFlag,
+			utils.IPCGroupsFlag,
 			utils.RPCCORSDomainFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
@@ -147,12 +229,20 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.BootnodesFlag,
 			utils.ListenPortFlag,
+			utils.AdvertiseAddrFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.MaxIngressFlag,
+			utils.MaxEgressFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
+			utils.NodeCertFlag,
+			utils.NodeCertRootsFlag,
+			utils.NodeManifestFlag,
+			utils.NodeManifestSignersFlag,
+			utils.NodeManifestIntervalFlag,
 		},
 	},
 	{
@@ -0,0 +1,341 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// configFile holds the flag values loaded from the --config file.
+type configFile struct {
+	path     string
+	values   map[string]string // Flag values by name as last applied
+	defaults map[string]string // Flag values before the file was applied
+	cmdline  map[string]bool   // Flags set on the command line, which win over the file
+}
+
+// loadedConfig is the config file loaded on startup, nil if none was given.
+var loadedConfig *configFile
+
+// readConfigFile reads the flag values keyed by flag name from a JSON object,
+// or from a TOML document if the file name ends in .toml. Values may be
+// strings, numbers or booleans.
+func readConfigFile(path string, known map[string]bool) (map[string]string, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := make(map[string]interface{})
+	if strings.HasSuffix(path, ".toml") {
+		var doc map[string]interface{}
+		if _, err := toml.Decode(string(blob), &doc); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+		if err := flattenTOML("", doc, raw); err != nil {
+			return nil, fmt.Errorf("config file %s: %v", path, err)
+		}
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(blob))
+		dec.UseNumber()
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %v", path, err)
+		}
+	}
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if !known[name] {
+			return nil, fmt.Errorf("config file %s: unknown flag %q", path, name)
+		}
+		switch value := value.(type) {
+		case string:
+			values[name] = value
+		case json.Number:
+			values[name] = value.String()
+		case int64:
+			values[name] = strconv.FormatInt(value, 10)
+		case float64:
+			values[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			values[name] = fmt.Sprint(value)
+		default:
+			return nil, fmt.Errorf("config file %s: flag %q must be a string, number or boolean", path, name)
+		}
+	}
+	return values, nil
+}
+
+// flattenTOML collects the keys of a TOML document, prefixing the keys of a
+// table with its name, so that txpool.snapshot can also be set as snapshot in
+// a [txpool] table.
+func flattenTOML(prefix string, doc, flat map[string]interface{}) error {
+	for key, value := range doc {
+		name := key
+		if prefix != "" {
+			name = prefix + "." + key
+		}
+		if table, ok := value.(map[string]interface{}); ok {
+			if err := flattenTOML(name, table, flat); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, dup := flat[name]; dup {
+			return fmt.Errorf("flag %q set twice", name)
+		}
+		flat[name] = value
+	}
+	return nil
+}
+
+// DumpConfig writes the flag values in effect, after applying the config file,
+// as a TOML config file. Only flags set on the command line or in the config
+// file are written, unless all is set. The hex node key and the AppRole secret
+// ID are never written, use --nodekey or --nodekeyvaultpath and
+// --vaultsecretidfile instead.
+func DumpConfig(ctx *cli.Context, w io.Writer, all bool) error {
+	values := make(map[string]interface{})
+	for _, flag := range ctx.App.Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		switch {
+		case name == "help" || name == "version" || name == ConfigFileFlag.Name || name == NodeKeyHexFlag.Name || name == VaultSecretIDFlag.Name:
+			continue
+		case !all && !ctx.GlobalIsSet(name):
+			continue
+		}
+		switch flag.(type) {
+		case cli.BoolFlag, cli.BoolTFlag:
+			values[name] = ctx.GlobalBool(name)
+		case cli.IntFlag:
+			values[name] = ctx.GlobalInt(name)
+		case cli.Int64Flag:
+			values[name] = ctx.GlobalInt64(name)
+		case cli.UintFlag:
+			values[name] = ctx.GlobalUint(name)
+		case cli.Uint64Flag:
+			values[name] = ctx.GlobalUint64(name)
+		case cli.Float64Flag:
+			values[name] = ctx.GlobalFloat64(name)
+		default:
+			values[name] = ctx.GlobalString(name)
+		}
+	}
+	return toml.NewEncoder(w).Encode(values)
+}
+
+// LoadConfigFile applies the flag values of the --config file to the flags
+// not set on the command line. It has to run before any flag is read.
+func LoadConfigFile(ctx *cli.Context) error {
+	path := ctx.GlobalString(ConfigFileFlag.Name)
+	if path == "" {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, name := range ctx.GlobalFlagNames() {
+		known[name] = true
+	}
+	delete(known, ConfigFileFlag.Name)
+
+	values, err := readConfigFile(path, known)
+	if err != nil {
+		return err
+	}
+	// Check the command line through a child context, as contexts cache which
+	// flags are set on first use and setting them below would go unnoticed.
+	check := cli.NewContext(ctx.App, nil, ctx)
+	config := &configFile{
+		path:     path,
+		values:   values,
+		defaults: make(map[string]string),
+		cmdline:  make(map[string]bool),
+	}
+	for name := range known {
+		config.defaults[name] = ctx.GlobalString(name)
+		config.cmdline[name] = check.GlobalIsSet(name)
+	}
+	for name, value := range values {
+		if config.cmdline[name] {
+			continue
+		}
+		if err := ctx.GlobalSet(name, value); err != nil {
+			return fmt.Errorf("config file %s: flag %q: %v", path, name, err)
+		}
+	}
+	loadedConfig = config
+	return nil
+}
+
+// configApplier applies the value of a flag, already set in the context, to
+// the running node.
+type configApplier func(ctx *cli.Context, stack *node.Node) error
+
+// configAppliers are the flags which can be changed without a restart. Flags
+// bound to the logger directly don't need to do anything after being set.
+var configAppliers = map[string]configApplier{
+	"verbosity": func(*cli.Context, *node.Node) error { return nil },
+	"vmodule":   func(*cli.Context, *node.Node) error { return nil },
+
+	RPCCORSDomainFlag.Name: func(ctx *cli.Context, stack *node.Node) error {
+		stack.SetHTTPCors(ctx.GlobalString(RPCCORSDomainFlag.Name))
+		return nil
+	},
+	VoteRateLimitFlag.Name: func(ctx *cli.Context, stack *node.Node) error {
+		if ctx.GlobalBool(RaftModeFlag.Name) {
+			return errors.New("votes aren't used in raft mode")
+		}
+		var ethereum *eth.Ethereum
+		if err := stack.Service(&ethereum); err != nil {
+			return err
+		}
+		filter := core.DefaultVoteFilterConfig
+		filter.RateLimit = ctx.GlobalInt(VoteRateLimitFlag.Name)
+		ethereum.TxPool().SetVoteFilter(filter, ethereum.BlockChain().CurrentBlock)
+		return nil
+	},
+	TxRateLimitFlag.Name:    applyTxRateLimit,
+	RPCTxRateLimitFlag.Name: applyTxRateLimit,
+	TxRateLimitBanFlag.Name: applyTxRateLimit,
+	UnlockVaultPathFlag.Name: func(ctx *cli.Context, stack *node.Node) error {
+		path := ctx.GlobalString(UnlockVaultPathFlag.Name)
+		switch {
+		case unlockVerifier == nil:
+			return errors.New("unlock tokens can only be enabled on startup")
+		case path == "":
+			return errors.New("unlock tokens can only be disabled on startup")
+		}
+		unlockVerifier.setPath(path)
+		return nil
+	},
+}
+
+// applyTxRateLimit replaces the transaction rate limits, lifting the throttling
+// and bans of the previous ones.
+func applyTxRateLimit(ctx *cli.Context, stack *node.Node) error {
+	var ethereum *eth.Ethereum
+	if err := stack.Service(&ethereum); err != nil {
+		return err
+	}
+	ethereum.SetTxRateLimit(MakeTxRateLimitConfig(ctx))
+	return nil
+}
+
+// SetupConfigReload makes the node re-read the config file on SIGHUP and
+// admin_reloadConfig, applying the changes which are safe while running.
+func SetupConfigReload(ctx *cli.Context, stack *node.Node) {
+	stack.SetConfigReloader(func() (*node.ConfigReload, error) {
+		return reloadConfigFile(ctx, stack)
+	})
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGHUP)
+		for range sigc {
+			report, err := stack.ReloadConfig()
+			if err != nil {
+				glog.V(logger.Error).Infof("Failed to reload config: %v", err)
+				continue
+			}
+			glog.V(logger.Info).Infof("Reloaded config: applied %v, restart required for %v, overridden on the command line %v", report.Applied, report.Restart, report.Ignored)
+			for name, reason := range report.Failed {
+				glog.V(logger.Error).Infof("Failed to apply %s: %v", name, reason)
+			}
+		}
+	}()
+}
+
+// reloadConfigFile re-reads the config file and applies the changed flags. A
+// file which can't be read or has unknown flags is rejected as a whole. Flags
+// removed from the file revert to their defaults. Changes which can't be
+// applied are reported again on the next reload, until the node restarts.
+func reloadConfigFile(ctx *cli.Context, stack *node.Node) (*node.ConfigReload, error) {
+	report := &node.ConfigReload{Applied: []string{}, Restart: []string{}, Ignored: []string{}}
+	config := loadedConfig
+	if config == nil {
+		return report, nil
+	}
+	known := make(map[string]bool, len(config.defaults))
+	for name := range config.defaults {
+		known[name] = true
+	}
+	values, err := readConfigFile(config.path, known)
+	if err != nil {
+		return nil, err
+	}
+	for name := range known {
+		value, inFile := values[name]
+		if !inFile {
+			value = config.defaults[name]
+		}
+		prev, ok := config.values[name]
+		if !ok {
+			prev = config.defaults[name]
+		}
+		if value == prev {
+			continue
+		}
+		apply, reloadable := configAppliers[name]
+		switch {
+		case config.cmdline[name]:
+			report.Ignored = append(report.Ignored, name)
+			continue
+		case !reloadable:
+			report.Restart = append(report.Restart, name)
+			continue
+		}
+		if err := ctx.GlobalSet(name, value); err != nil {
+			report.Failed = setFailed(report.Failed, name, err)
+			continue
+		}
+		if err := apply(ctx, stack); err != nil {
+			ctx.GlobalSet(name, prev)
+			report.Failed = setFailed(report.Failed, name, err)
+			continue
+		}
+		if inFile {
+			config.values[name] = value
+		} else {
+			delete(config.values, name)
+		}
+		report.Applied = append(report.Applied, name)
+	}
+	report.Sort()
+	return report, nil
+}
+
+func setFailed(failed map[string]string, name string, err error) map[string]string {
+	if failed == nil {
+		failed = make(map[string]string)
+	}
+	failed[name] = err.Error()
+	return failed
+}
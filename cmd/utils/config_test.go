@@ -0,0 +1,152 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/urfave/cli.v1"
+)
+
+var configTestFlags = []cli.Flag{
+	ConfigFileFlag,
+	RaftModeFlag,
+	RPCPortFlag,
+	RPCApiFlag,
+	RPCCallCacheFlag,
+	DatabaseMaxHandlesFlag,
+	NodeKeyFileFlag,
+	NodeKeyHexFlag,
+	VaultSecretIDFlag,
+	VaultSecretIDFileFlag,
+}
+
+// runConfigTestApp runs an app with the test flags, loading the config file
+// given on the command line, and returns the output of dumpconfig.
+func runConfigTestApp(t *testing.T, args ...string) string {
+	var out bytes.Buffer
+	app := cli.NewApp()
+	app.Flags = configTestFlags
+	app.Action = func(ctx *cli.Context) error {
+		if err := LoadConfigFile(ctx); err != nil {
+			return err
+		}
+		return DumpConfig(ctx, &out, false)
+	}
+	if err := app.Run(append([]string{"geth"}, args...)); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestDumpConfigRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { loadedConfig = nil }()
+
+	dump := runConfigTestApp(t,
+		"--raft", "--rpcport", "22001", "--rpcapi", "eth,raft", "--rpc.callcache", "2s", "--db.maxhandles", "512",
+		"--nodekeyhex", "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291",
+		"--vaultsecretid", "secret-id", "--vaultsecretidfile", "/run/secrets/secret-id",
+	)
+	for _, secret := range []string{NodeKeyHexFlag.Name, VaultSecretIDFlag.Name + " ", "b71c71a6", `"secret-id"`} {
+		if strings.Contains(dump, secret) {
+			t.Errorf("dump contains %q:\n%s", secret, dump)
+		}
+	}
+
+	// The dump loads back to the same flag values
+	path := filepath.Join(dir, "node.toml")
+	if err := ioutil.WriteFile(path, []byte(dump), 0600); err != nil {
+		t.Fatal(err)
+	}
+	known := make(map[string]bool)
+	for _, flag := range configTestFlags {
+		known[flag.GetName()] = true
+	}
+	values, err := readConfigFile(path, known)
+	if err != nil {
+		t.Fatalf("dump not loadable: %v\n%s", err, dump)
+	}
+	want := map[string]string{
+		RaftModeFlag.Name:           "true",
+		RPCPortFlag.Name:            "22001",
+		RPCApiFlag.Name:             "eth,raft",
+		RPCCallCacheFlag.Name:       "2s",
+		DatabaseMaxHandlesFlag.Name: "512",
+		VaultSecretIDFileFlag.Name:  "/run/secrets/secret-id",
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("loaded values mismatch:\nhave %v\nwant %v", values, want)
+	}
+	if reloaded := runConfigTestApp(t, "--config", path); reloaded != dump {
+		t.Errorf("dump of the loaded config differs:\nhave\n%s\nwant\n%s", reloaded, dump)
+	}
+}
+
+func TestReadConfigFileTables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "geth-config-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	known := map[string]bool{"raft": true, "rpc.callcache": true, "rpc.callcachesize": true, "db.maxhandles": true}
+	tests := []struct {
+		doc    string
+		values map[string]string
+		err    string
+	}{
+		{
+			doc:    "raft = true\n\n[rpc]\ncallcache = \"2s\"\ncallcachesize = 100\n\n[db]\nmaxhandles = 512\n",
+			values: map[string]string{"raft": "true", "rpc.callcache": "2s", "rpc.callcachesize": "100", "db.maxhandles": "512"},
+		},
+		{
+			doc:    "\"rpc.callcache\" = \"2s\"\n",
+			values: map[string]string{"rpc.callcache": "2s"},
+		},
+		{doc: "\"rpc.callcache\" = \"2s\"\n\n[rpc]\ncallcache = \"3s\"\n", err: `flag "rpc.callcache" set twice`},
+		{doc: "[rpc]\ncallcachettl = \"2s\"\n", err: `unknown flag "rpc.callcachettl"`},
+		{doc: "[raft]\nenabled = true\n", err: `unknown flag "raft.enabled"`},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, "config.toml")
+		if err := ioutil.WriteFile(path, []byte(test.doc), 0600); err != nil {
+			t.Fatal(err)
+		}
+		values, err := readConfigFile(path, known)
+		switch {
+		case test.err != "":
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("test %d: error mismatch: have %v, want %q", i, err, test.err)
+			}
+		case err != nil:
+			t.Errorf("test %d: failed to read: %v", i, err)
+		case !reflect.DeepEqual(values, test.values):
+			t.Errorf("test %d: values mismatch:\nhave %v\nwant %v", i, values, test.values)
+		}
+	}
+}
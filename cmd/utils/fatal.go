@@ -0,0 +1,217 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+)
+
+// ExitCode is the process exit status of a fatal error, telling supervisors
+// whether restarting can help.
+type ExitCode int
+
+const (
+	ExitError    ExitCode = 1 // Unclassified error, restarting may help
+	ExitConfig   ExitCode = 2 // Invalid flags, config or genesis, fix before restarting
+	ExitDatabase ExitCode = 3 // Chain database can't be opened or is corrupt
+	ExitStartup  ExitCode = 4 // Node services failed to start
+	ExitAccount  ExitCode = 5 // Accounts or keys can't be unlocked
+)
+
+var exitCodeNames = map[ExitCode]string{
+	ExitError:    "error",
+	ExitConfig:   "config",
+	ExitDatabase: "database",
+	ExitStartup:  "startup",
+	ExitAccount:  "account",
+}
+
+func (c ExitCode) String() string {
+	if name, ok := exitCodeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("exit%d", int(c))
+}
+
+// shutdownHookTimeout bounds the time each shutdown hook may take, so a hook
+// waiting on the failed component doesn't keep the process alive.
+const shutdownHookTimeout = 10 * time.Second
+
+// CrashRecord is written as JSON to the crash record file on fatal errors.
+type CrashRecord struct {
+	Time    time.Time         `json:"time"`
+	Pid     int               `json:"pid"`
+	Version string            `json:"version"`
+	Code    int               `json:"code"`
+	Reason  string            `json:"reason"` // Name of the exit code
+	Message string            `json:"message"`
+	Hooks   map[string]string `json:"hooks,omitempty"` // Errors of the shutdown hooks which failed
+}
+
+type shutdownHook struct {
+	name string
+	fn   func() error
+}
+
+var (
+	fatalLock   sync.Mutex
+	fatalHooks  []*shutdownHook
+	crashRecord string // Path of the crash record, none written if empty
+	exiting     bool
+)
+
+// RegisterShutdownHook registers a function run on fatal errors before the
+// process exits, e.g. to close databases. Hooks run in the reverse order of
+// registration. The returned function removes the hook again, for callers
+// cleaning up by themselves once done.
+func RegisterShutdownHook(name string, fn func() error) (remove func()) {
+	fatalLock.Lock()
+	defer fatalLock.Unlock()
+
+	hook := &shutdownHook{name: name, fn: fn}
+	fatalHooks = append(fatalHooks, hook)
+	return func() {
+		fatalLock.Lock()
+		defer fatalLock.Unlock()
+
+		for i, h := range fatalHooks {
+			if h == hook {
+				fatalHooks = append(fatalHooks[:i], fatalHooks[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// CloseOnFatal registers a shutdown hook closing a database, so fatal errors
+// don't leave it unflushed. The returned function removes the hook and closes
+// the database, for commands to defer.
+func CloseOnFatal(name string, db ethdb.Database) func() {
+	remove := RegisterShutdownHook(name, func() error {
+		db.Close()
+		return nil
+	})
+	return func() {
+		remove()
+		db.Close()
+	}
+}
+
+// SetCrashRecord sets the file the crash record is written to on fatal errors.
+func SetCrashRecord(path string) {
+	fatalLock.Lock()
+	defer fatalLock.Unlock()
+
+	crashRecord = path
+}
+
+// Fatalf formats a message to standard error and exits the program with
+// ExitError, see Fatal.
+func Fatalf(format string, args ...interface{}) {
+	Fatal(ExitError, format, args...)
+}
+
+// Fatal formats a message to standard error, runs the shutdown hooks, writes
+// the crash record and exits the program with the given code. The message is
+// also printed to standard output if standard error is redirected to a
+// different file. Fatal errors raised while exiting, e.g. by a hook, are
+// printed but otherwise wait for the first one to exit.
+func Fatal(code ExitCode, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Fprintln(fatalWriter(), "Fatal: "+msg)
+
+	fatalLock.Lock()
+	if exiting {
+		fatalLock.Unlock()
+		select {}
+	}
+	exiting = true
+	hooks, path := fatalHooks, crashRecord
+	fatalLock.Unlock()
+
+	failed := runShutdownHooks(hooks, shutdownHookTimeout)
+	if path != "" {
+		if err := writeCrashRecord(path, code, msg, failed); err != nil {
+			fmt.Fprintf(fatalWriter(), "Failed to write crash record: %v\n", err)
+		}
+	}
+	logger.Flush()
+	os.Exit(int(code))
+}
+
+func fatalWriter() io.Writer {
+	if runtime.GOOS == "windows" {
+		// The SameFile check below doesn't work on Windows.
+		// stdout is unlikely to get redirected though, so just print there.
+		return os.Stdout
+	}
+	outf, _ := os.Stdout.Stat()
+	errf, _ := os.Stderr.Stat()
+	if outf != nil && errf != nil && os.SameFile(outf, errf) {
+		return os.Stderr
+	}
+	return io.MultiWriter(os.Stdout, os.Stderr)
+}
+
+// runShutdownHooks runs the hooks latest first and returns the errors of those
+// which failed or timed out.
+func runShutdownHooks(hooks []*shutdownHook, timeout time.Duration) map[string]string {
+	failed := make(map[string]string)
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook, errc := hooks[i], make(chan error, 1)
+		go func() { errc <- hook.fn() }()
+
+		select {
+		case err := <-errc:
+			if err != nil {
+				failed[hook.name] = err.Error()
+			}
+		case <-time.After(timeout):
+			failed[hook.name] = fmt.Sprintf("timed out after %v", timeout)
+		}
+	}
+	return failed
+}
+
+func writeCrashRecord(path string, code ExitCode, msg string, failed map[string]string) error {
+	record := CrashRecord{
+		Time:    time.Now().UTC(),
+		Pid:     os.Getpid(),
+		Version: Version,
+		Code:    int(code),
+		Reason:  code.String(),
+		Message: msg,
+	}
+	if len(failed) > 0 {
+		record.Hooks = failed
+	}
+	blob, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(blob, '\n'), 0600)
+}
@@ -52,3 +52,13 @@ func getFdLimit() (int, error) {
 	}
 	return int(limit.Cur), nil
 }
+
+// getFdMaxLimit retrieves the hard limit of file descriptors, which the allowance
+// of this process can be raised to.
+func getFdMaxLimit() (int, error) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0, err
+	}
+	return int(limit.Max), nil
+}
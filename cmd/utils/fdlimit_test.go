@@ -26,6 +26,9 @@ func TestFileDescriptorLimits(t *testing.T) {
 	if limit, err := getFdLimit(); err != nil || limit <= 0 {
 		t.Fatalf("failed to retrieve file descriptor limit (%d): %v", limit, err)
 	}
+	if max, err := getFdMaxLimit(); err != nil || max < target {
+		t.Skipf("hard file descriptor limit too low to raise (%d): %v", max, err)
+	}
 	if err := raiseFdLimit(uint64(target)); err != nil {
 		t.Fatalf("failed to raise file allowance")
 	}
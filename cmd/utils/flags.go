@@ -18,36 +18,44 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"math/big"
 	"math/rand"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/ethash"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/quorum"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/pow"
 	"github.com/ethereum/go-ethereum/raft"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/vault"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv2"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -111,6 +119,23 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	KeyStoreRefreshFlag = cli.DurationFlag{
+		Name:  "keystore.refresh",
+		Usage: "Minimum interval between rescans of the keystore for added or removed keys, where it can't be watched for changes",
+		Value: 2 * time.Second,
+	}
+	ChainDataDirFlag = DirectoryFlag{
+		Name:  "datadir.chaindata",
+		Usage: "Directory for the chain database (default = inside the datadir)",
+	}
+	ConfigFileFlag = cli.StringFlag{
+		Name:  "config",
+		Usage: "JSON or TOML (.toml) file of flag values keyed by flag name, re-read on SIGHUP and admin.reloadConfig",
+	}
+	CrashRecordFlag = cli.StringFlag{
+		Name:  "crashrecord",
+		Usage: "File a JSON record of fatal errors is written to (default = crash.json inside the datadir)",
+	}
 	NetworkIdFlag = cli.IntFlag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 0=Olympic, 1=Frontier, 2=Morden)",
@@ -126,7 +151,7 @@ var (
 	}
 	DevModeFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Developer mode: pre-configured private network with several debugging flags",
+		Usage: "Developer mode: single node raft chain with prefunded, unlocked accounts",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -151,11 +176,20 @@ var (
 		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
 		Value: 128,
 	}
+	DatabaseMaxHandlesFlag = cli.IntFlag{
+		Name:  "db.maxhandles",
+		Usage: "Maximum number of file handles allotted to the databases, twice as many are requested from the OS",
+		Value: 1024,
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	CachePreimagesFlag = cli.BoolFlag{
+		Name:  "cache.preimages",
+		Usage: "Record the preimages of hashed trie keys, needed to resolve addresses and storage keys in state dumps",
+	}
 	TargetGasLimitFlag = cli.StringFlag{
 		Name:  "targetgaslimit",
 		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
@@ -185,6 +219,15 @@ var (
 		Usage: "Password file to use for non-inteactive password input",
 		Value: "",
 	}
+	AllowInsecureUnlockFlag = cli.BoolTFlag{
+		Name:  "allow-insecure-unlock",
+		Usage: "Allow unlocking accounts over RPC with only their password (disable with --allow-insecure-unlock=false)",
+	}
+	UnlockMaxFailuresFlag = cli.IntFlag{
+		Name:  "unlockmaxfailures",
+		Usage: "Failed RPC unlock attempts allowed per account and minute (0 = unlimited)",
+		Value: 5,
+	}
 
 	VMForceJitFlag = cli.BoolFlag{
 		Name:  "forcejit",
@@ -235,6 +278,25 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: rpc.DefaultHTTPApis,
 	}
+	RPCEVMTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.evmtimeout",
+		Usage: "Timeout of EVM executions by RPC methods like eth_call (0 = unlimited)",
+		Value: 5 * time.Second,
+	}
+	RPCMethodTimeoutsFlag = cli.StringFlag{
+		Name:  "rpc.methodtimeouts",
+		Usage: "Comma separated per method EVM timeouts overriding --rpc.evmtimeout, e.g. eth_call=10s,eth_estimateGas=2s",
+		Value: "",
+	}
+	RPCCallCacheFlag = cli.DurationFlag{
+		Name:  "rpc.callcache",
+		Usage: "Time eth_call results are cached for, keyed on block and call (0 = disabled)",
+	}
+	RPCCallCacheSizeFlag = cli.IntFlag{
+		Name:  "rpc.callcachesize",
+		Usage: "Maximum number of cached eth_call results",
+		Value: 10000,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -249,6 +311,14 @@ var (
 		Usage: "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
 		Value: DirectoryString{"geth.ipc"},
 	}
+	IPCSecurityDescriptorFlag = cli.StringFlag{
+		Name:  "ipcsddl",
+		Usage: "Security descriptor in SDDL restricting who can connect to the IPC pipe (Windows only)",
+	}
+	IPCGroupsFlag = cli.StringFlag{
+		Name:  "ipcgroups",
+		Usage: "Comma separated local groups allowed to connect to the IPC pipe besides administrators (Windows only)",
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -273,6 +343,55 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	WSCompressionFlag = cli.BoolFlag{
+		Name:  "wscompression",
+		Usage: "Enable per message compression on the WS-RPC server",
+	}
+	WSPingIntervalFlag = cli.DurationFlag{
+		Name:  "wspinginterval",
+		Usage: "Interval of WS-RPC keepalive pings (0 = disabled)",
+	}
+	WSPongTimeoutFlag = cli.DurationFlag{
+		Name:  "wspongtimeout",
+		Usage: "Time to wait for a WS-RPC pong before dropping the connection",
+		Value: 30 * time.Second,
+	}
+	GRPCEnabledFlag = cli.BoolFlag{
+		Name:  "grpc",
+		Usage: "Enable the gRPC server",
+	}
+	GRPCListenAddrFlag = cli.StringFlag{
+		Name:  "grpcaddr",
+		Usage: "gRPC server listening interface",
+		Value: node.DefaultGRPCHost,
+	}
+	GRPCPortFlag = cli.IntFlag{
+		Name:  "grpcport",
+		Usage: "gRPC server listening port",
+		Value: node.DefaultGRPCPort,
+	}
+	GRPCApiFlag = cli.StringFlag{
+		Name:  "grpcapi",
+		Usage: "API's the gRPC methods may call",
+		Value: rpc.DefaultHTTPApis,
+	}
+	GRPCTLSCertFlag = cli.StringFlag{
+		Name:  "grpc.tlscert",
+		Usage: "PEM certificate file to serve gRPC over TLS with",
+	}
+	GRPCTLSKeyFlag = cli.StringFlag{
+		Name:  "grpc.tlskey",
+		Usage: "PEM key file of the gRPC TLS certificate",
+	}
+	RPCAccessLogFlag = cli.StringFlag{
+		Name:  "rpcaccesslog",
+		Usage: "File to log the requests served by the IPC, HTTP-RPC, WS-RPC and gRPC servers to",
+	}
+	RPCAccessLogSampleFlag = cli.Float64Flag{
+		Name:  "rpcaccesslog.sample",
+		Usage: "Fraction of successful requests to log, failed requests are always logged",
+		Value: 1,
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement (only in combination with console/attach)",
@@ -293,6 +412,18 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	AdvertiseAddrFlag = cli.StringFlag{
+		Name:  "advertiseaddr",
+		Usage: "Host or host:port peers dial to reach this node, if not the listening address (e.g. a load balancer)",
+	}
+	MaxIngressFlag = cli.Uint64Flag{
+		Name:  "p2p.maxingress",
+		Usage: "Maximum KB/s received from each peer (0 = unlimited)",
+	}
+	MaxEgressFlag = cli.Uint64Flag{
+		Name:  "p2p.maxegress",
+		Usage: "Maximum KB/s sent to each peer (0 = unlimited)",
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -313,7 +444,7 @@ var (
 	}
 	NATFlag = cli.StringFlag{
 		Name:  "nat",
-		Usage: "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>)",
+		Usage: "NAT port mapping mechanism (any|none|upnp|pmp|extip:<IP>), none unless set with --permissioned or --raft",
 		Value: "any",
 	}
 	NoDiscoverFlag = cli.BoolFlag{
@@ -356,6 +487,35 @@ var (
 		Usage: "Password to unlock the block maker address",
 		Value: "",
 	}
+	NextBlockMakerAccountFlag = cli.StringFlag{
+		Name:  "blockmakeraccount.next",
+		Usage: "Address the block maker key is rotated to, registered with the voting contract ahead of the activation block",
+	}
+	NextBlockMakerActivationFlag = cli.Uint64Flag{
+		Name:  "blockmakeraccount.activation",
+		Usage: "Number of the first block signed with the --blockmakeraccount.next key",
+	}
+	BlockMakerSignerFlag = cli.StringFlag{
+		Name:  "blockmakersigner",
+		Usage: "Endpoint (host:port) of an external gRPC signer creating block signatures, e.g. a threshold signing service",
+	}
+	BlockMakerSignerAccountFlag = cli.StringFlag{
+		Name:  "blockmakersigner.account",
+		Usage: "Block maker address the external signer holds the key of",
+	}
+	BlockMakerSignerCACertFlag = cli.StringFlag{
+		Name:  "blockmakersigner.cacert",
+		Usage: "CA certificate to verify the external signer's TLS certificate with (plaintext if not set)",
+	}
+	BlockMakerSignerTimeoutFlag = cli.DurationFlag{
+		Name:  "blockmakersigner.timeout",
+		Usage: "Timeout of requests to the external signer",
+		Value: 2 * time.Second,
+	}
+	BlockMakerSignerBreakGlassFlag = cli.BoolFlag{
+		Name:  "blockmakersigner.breakglass",
+		Usage: "Sign blocks with the --blockmakeraccount key while the external signer is unhealthy",
+	}
 	MinBlockTimeFlag = cli.IntFlag{
 		Name:  "minblocktime",
 		Usage: "Set min block time",
@@ -376,6 +536,121 @@ var (
 		Usage: "Set max vote time",
 		Value: 10,
 	}
+	VoteRateLimitFlag = cli.IntFlag{
+		Name:  "voteratelimit",
+		Usage: "Maximum number of vote transactions per voter and minute admitted to the transaction pool (0 = unlimited)",
+		Value: 0,
+	}
+	TxRateLimitFlag = cli.IntFlag{
+		Name:  "txratelimit",
+		Usage: "Maximum number of transactions per sender and minute admitted to the transaction pool (0 = unlimited)",
+	}
+	TxPoolSnapshotFlag = cli.BoolFlag{
+		Name:  "txpool.snapshot",
+		Usage: "Save all pending and queued transactions of the pool on shutdown and restore them on startup",
+	}
+	RPCTxRateLimitFlag = cli.IntFlag{
+		Name:  "rpc.txratelimit",
+		Usage: "Maximum number of transactions per caller IP and minute submitted over HTTP and WebSocket RPC (0 = unlimited)",
+	}
+	TxRateLimitBanFlag = cli.DurationFlag{
+		Name:  "txratelimit.ban",
+		Usage: "Time senders and caller IPs exceeding a transaction rate limit are rejected for (0 = throttle only)",
+	}
+	StandbyWindowsFlag = cli.IntFlag{
+		Name:  "blockmakerstandby",
+		Usage: "Run the block maker as a hot standby that takes over after the primary misses this many consecutive block windows (0 = not a standby)",
+		Value: 0,
+	}
+	PauseOnDoubleProductionFlag = cli.BoolFlag{
+		Name:  "pauseondoubleproduction",
+		Usage: "Pause block creation when another node is found creating blocks with this node's block maker key",
+	}
+	VoteBatchFlag = cli.IntFlag{
+		Name:  "votebatch",
+		Usage: "Send votes for heights which already have enough votes this many at a time in one transaction (0 = vote individually)",
+		Value: 0,
+	}
+	RequireProtectedTxFlag = cli.BoolFlag{
+		Name:  "requireprotectedtx",
+		Usage: "Reject public transactions that aren't replay protected (EIP-155) from the transaction pool",
+	}
+	ConfigCheckFlag = cli.StringFlag{
+		Name:  "configcheck",
+		Usage: "Exchange chain config fingerprints with peers and warn about (warn) or disconnect (strict) peers with a different config. Only enable once all nodes support it",
+		Value: "off",
+	}
+	MinProtocolVersionFlag = cli.UintFlag{
+		Name:  "minprotocolversion",
+		Usage: "Disconnect peers speaking an eth protocol version below this one (0 = any)",
+	}
+	SyncFromFlag = cli.StringFlag{
+		Name:  "syncfrom",
+		Usage: "Hash of a trusted block a new node fast syncs to, only fully validating the blocks after it",
+	}
+	LogIndexFlag = cli.BoolFlag{
+		Name:  "logindex",
+		Usage: "Index the logs of every contract to speed up eth_getLogs queries filtering by address",
+	}
+	LogIndexRetentionFlag = cli.Uint64Flag{
+		Name:  "logindex.retention",
+		Usage: "Number of blocks the logs of contracts not registered with admin.registerLogContract are kept for",
+		Value: 100000,
+	}
+	StatusFileFlag = cli.StringFlag{
+		Name:  "statusfile",
+		Usage: "File the node status is periodically written to for external supervisors, relative to the data directory",
+	}
+	StatusFileIntervalFlag = cli.DurationFlag{
+		Name:  "statusfile.interval",
+		Usage: "Interval the status file is rewritten at",
+		Value: node.DefaultStatusFileInterval,
+	}
+	StartupSummaryFlag = cli.BoolTFlag{
+		Name:  "startupsummary",
+		Usage: "Log a summary of the effective configuration on startup (default = true)",
+	}
+	MaintenanceFlag = cli.BoolFlag{
+		Name:  "maintenance",
+		Usage: "Start in maintenance: reject transactions and don't produce blocks or vote until admin.exitMaintenance()",
+	}
+	WatchdogFlag = cli.IntFlag{
+		Name:  "watchdog",
+		Usage: "Alert once no block was imported for this many block times (--maxblocktime, or --raftblocktime in raft mode) while the network is ahead (0 = disabled)",
+	}
+	WatchdogActionsFlag = cli.StringFlag{
+		Name:  "watchdog.actions",
+		Usage: "Comma separated recovery actions taken in order on a stalled chain: redial, stepdown (raft only), exit",
+	}
+	FinalityHookFlag = cli.StringFlag{
+		Name:  "finalityhook",
+		Usage: "URL finalized blocks are delivered to until acknowledged: http(s):// to POST them as JSON, grpc://host:port of a FinalityHook service",
+	}
+	FinalityHookCACertFlag = cli.StringFlag{
+		Name:  "finalityhook.cacert",
+		Usage: "CA certificate to verify the finality hook's TLS certificate with (gRPC is plaintext if not set)",
+	}
+	FinalityHookTimeoutFlag = cli.DurationFlag{
+		Name:  "finalityhook.timeout",
+		Usage: "Timeout of a delivery to the finality hook",
+		Value: 10 * time.Second,
+	}
+	SlowTxThresholdFlag = cli.DurationFlag{
+		Name:  "slowtxthreshold",
+		Usage: "Trace transactions executing longer than this and log the contracts their time was spent in (0 = disabled)",
+	}
+	FlatStateFlag = cli.BoolFlag{
+		Name:  "flatstate",
+		Usage: "Keep a flat copy of the public state at the head of the chain to accelerate account and storage reads",
+	}
+	InclusionSLAFlag = cli.DurationFlag{
+		Name:  "inclusionsla",
+		Usage: "Alert when transactions submitted through this node take longer to be included in a block (0 = disabled)",
+	}
+	InclusionSLAHookFlag = cli.StringFlag{
+		Name:  "inclusionsla.hook",
+		Usage: "http(s):// endpoint inclusion SLA alerts are posted to as JSON, in addition to the log",
+	}
 	SingleBlockMakerFlag = cli.BoolFlag{
 		Name:  "singleblockmaker",
 		Usage: "Indicate this node is the only node that can create blocks",
@@ -384,6 +659,27 @@ var (
 		Name:  "permissioned",
 		Usage: "If enabled, the node will allow only a defined list of nodes to connect",
 	}
+	NodeCertFlag = cli.StringFlag{
+		Name:  "nodecert",
+		Usage: "File of the certificate of this node signed by a consortium CA (geth signnodecert), presented to peers",
+	}
+	NodeCertRootsFlag = cli.StringFlag{
+		Name:  "nodecert.roots",
+		Usage: "Comma separated addresses of the consortium CAs whose node certificates admit peers",
+	}
+	NodeManifestFlag = cli.StringFlag{
+		Name:  "nodemanifest",
+		Usage: "URL of the signed node manifest (geth signnodemanifest) the static and permissioned nodes are updated from",
+	}
+	NodeManifestSignersFlag = cli.StringFlag{
+		Name:  "nodemanifest.signers",
+		Usage: "Comma separated addresses of the consortium keys trusted to sign the node manifest",
+	}
+	NodeManifestIntervalFlag = cli.DurationFlag{
+		Name:  "nodemanifest.interval",
+		Usage: "Interval the node manifest is fetched at",
+		Value: node.DefaultNodeManifestInterval,
+	}
 	PrivateConfigPathFlag = cli.StringFlag{
 		Name:  "privateconfigpath",
 		Usage: "Path of thr constellation private config",
@@ -400,6 +696,46 @@ var (
 		Usage: "Prefix where the Vault KV engine is mounted, no outer slashes. Canonically set to `quorum` in Eximchain",
 		Value: "quorum",
 	}
+	VaultAuthMethodFlag = cli.StringFlag{
+		Name:  "vaultauthmethod",
+		Usage: "Method to log in to Vault with: `aws` (EC2 instance IAM role), `token` (VAULT_TOKEN environment variable), `approle` or `kubernetes`",
+		Value: vault.AuthAWS,
+	}
+	VaultAuthMountFlag = cli.StringFlag{
+		Name:  "vaultauthmount",
+		Usage: "Path the Vault auth method is mounted at, no outer slashes. Defaults to the method name",
+		Value: "",
+	}
+	VaultRoleFlag = cli.StringFlag{
+		Name:  "vaultrole",
+		Usage: "Vault role to log in as with the `kubernetes` method, or with `aws` instead of the role named like the instance profile",
+		Value: "",
+	}
+	VaultRoleIDFlag = cli.StringFlag{
+		Name:  "vaultroleid",
+		Usage: "AppRole role ID to log in to Vault with",
+		Value: "",
+	}
+	VaultRoleIDFileFlag = cli.StringFlag{
+		Name:  "vaultroleidfile",
+		Usage: "File holding the AppRole role ID to log in to Vault with",
+		Value: "",
+	}
+	VaultSecretIDFlag = cli.StringFlag{
+		Name:  "vaultsecretid",
+		Usage: "AppRole secret ID to log in to Vault with.  Visible to other users of the host, prefer --vaultsecretidfile",
+		Value: "",
+	}
+	VaultSecretIDFileFlag = cli.StringFlag{
+		Name:  "vaultsecretidfile",
+		Usage: "File holding the AppRole secret ID to log in to Vault with",
+		Value: "",
+	}
+	VaultJWTFileFlag = cli.StringFlag{
+		Name:  "vaultjwtfile",
+		Usage: "File holding the Kubernetes service account token to log in to Vault with",
+		Value: vault.DefaultKubernetesTokenFile,
+	}
 	VaultPasswordPathFlag = cli.StringFlag{
 		Name:  "vaultpasswordpath",
 		Usage: "Vault path to where password is kept within KV engine.  No leading slash, does not include the engine's mount prefix",
@@ -410,6 +746,31 @@ var (
 		Usage: "Key name within KV store where password is kept. Canonically set to `geth_pw` in Eximchain",
 		Value: "geth_pw",
 	}
+	NodeKeyVaultPathFlag = cli.StringFlag{
+		Name:  "nodekeyvaultpath",
+		Usage: "Vault path where the P2P node key is kept (under key name `nodekey`) within KV engine.  A new key is generated and stored if none exists",
+		Value: "",
+	}
+	UnlockVaultPathFlag = cli.StringFlag{
+		Name:  "unlockvaultpath",
+		Usage: "Vault path within KV engine under which one-time unlock tokens must be able to read the secret named after the account (e.g. <path>/0xca84...).  If set, personal_unlockAccount requires such a token",
+		Value: "",
+	}
+	KeyStoreVaultPathFlag = cli.StringFlag{
+		Name:  "keystorevaultpath",
+		Usage: "Vault path within KV engine under which the encrypted account keys are kept instead of the keystore directory, one secret per key",
+		Value: "",
+	}
+	DataKeyVaultKeyFlag = cli.StringFlag{
+		Name:  "datakeyvaultkey",
+		Usage: "Name of a Vault transit key wrapping the key the databases and raft log are encrypted with.  If set, a data key is generated on first start",
+		Value: "",
+	}
+	DataKeyVaultMountFlag = cli.StringFlag{
+		Name:  "datakeyvaultmount",
+		Usage: "Prefix where the Vault transit engine is mounted, no outer slashes",
+		Value: "transit",
+	}
 	// Raft flags
 	RaftModeFlag = cli.BoolFlag{
 		Name:  "raft",
@@ -420,6 +781,16 @@ var (
 		Usage: "Amount of time between raft block creations in milliseconds",
 		Value: 50,
 	}
+	RaftMinTimeIncrementFlag = cli.IntFlag{
+		Name:  "raftmintimeincrement",
+		Usage: "Minimum difference in nanoseconds between the timestamps of a raft block and its parent",
+		Value: 1,
+	}
+	RaftMaxSpeculativeDepthFlag = cli.IntFlag{
+		Name:  "raftmaxspeculativedepth",
+		Usage: "Maximum number of minted blocks awaiting acceptance through raft before the minter pauses (0 = unlimited)",
+		Value: 0,
+	}
 	RaftJoinExistingFlag = cli.IntFlag{
 		Name:  "raftjoinexisting",
 		Usage: "The raft ID to assume when joining an pre-existing cluster",
@@ -430,6 +801,42 @@ var (
 		Usage: "The port to bind for the raft transport",
 		Value: 50400,
 	}
+	RaftAdvertiseAddrFlag = cli.StringFlag{
+		Name:  "raftadvertiseaddr",
+		Usage: "Host:port raft peers dial to reach this node, if not the raft port (e.g. a load balancer). The host has to match --advertiseaddr",
+	}
+	RaftCompressionFlag = cli.BoolFlag{
+		Name:  "raftcompression",
+		Usage: "Compress blocks replicated over raft with snappy, once all cluster members support it",
+	}
+	RaftSnapshotRateFlag = cli.IntFlag{
+		Name:  "raftsnapshotrate",
+		Usage: "Maximum rate in KiB/s at which raft snapshots are streamed to lagging followers (0 = unlimited)",
+		Value: 0,
+	}
+	RaftDirFlag = DirectoryFlag{
+		Name:  "raftdir",
+		Usage: "Directory for the raft log, snapshots and state (default = the datadir)",
+	}
+	RaftBackpressureFlag = cli.IntFlag{
+		Name:  "raftbackpressure",
+		Usage: "Number of unapplied raft log entries at which new transactions are rejected with a retryable \"node busy\" error (0 = disabled)",
+		Value: 0,
+	}
+	RaftBackpressureResumeFlag = cli.IntFlag{
+		Name:  "raftbackpressureresume",
+		Usage: "Number of unapplied raft log entries below which transactions are accepted again (0 = half of --raftbackpressure)",
+		Value: 0,
+	}
+	RaftFollowerWritesFlag = cli.StringFlag{
+		Name:  "raftfollowerwrites",
+		Usage: "How followers handle transactions submitted over RPC: local (gossip them to the minter), forward (submit them to the leader) or redirect (reject them with the endpoint of the leader)",
+		Value: raft.FollowerWritesLocal,
+	}
+	RaftRPCEndpointFlag = cli.StringFlag{
+		Name:  "raftrpcendpoint",
+		Usage: "RPC endpoint of this node advertised to the raft cluster, which followers forward or redirect transactions to while it leads",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -443,7 +850,7 @@ func MakeDataDir(ctx *cli.Context) string {
 		}
 		return path
 	}
-	Fatalf("Cannot determine default data directory, please set manually (--datadir)")
+	Fatal(ExitConfig, "Cannot determine default data directory, please set manually (--datadir)")
 	return ""
 }
 
@@ -457,33 +864,421 @@ func MakeIPCPath(ctx *cli.Context) string {
 }
 
 // MakeNodeKey creates a node key from set command line flags, either loading it
-// from a file or as a specified hex value. If neither flags were provided, this
-// method returns nil and an emphemeral key is to be generated.
+// from a file, from Vault or as a specified hex value. If no flags were provided,
+// this method returns nil and an emphemeral key is to be generated.
 func MakeNodeKey(ctx *cli.Context) *ecdsa.PrivateKey {
 	var (
-		hex  = ctx.GlobalString(NodeKeyHexFlag.Name)
-		file = ctx.GlobalString(NodeKeyFileFlag.Name)
+		hex       = ctx.GlobalString(NodeKeyHexFlag.Name)
+		file      = ctx.GlobalString(NodeKeyFileFlag.Name)
+		vaultPath = ctx.GlobalString(NodeKeyVaultPathFlag.Name)
 
 		key *ecdsa.PrivateKey
 		err error
 	)
+	var set []string
+	for _, flag := range []cli.StringFlag{NodeKeyFileFlag, NodeKeyHexFlag, NodeKeyVaultPathFlag} {
+		if ctx.GlobalString(flag.Name) != "" {
+			set = append(set, flag.Name)
+		}
+	}
 	switch {
-	case file != "" && hex != "":
-		Fatalf("Options %q and %q are mutually exclusive", NodeKeyFileFlag.Name, NodeKeyHexFlag.Name)
+	case len(set) > 1:
+		Fatal(ExitConfig, "Options %q are mutually exclusive", set)
 
 	case file != "":
 		if key, err = crypto.LoadECDSA(file); err != nil {
-			Fatalf("Option %q: %v", NodeKeyFileFlag.Name, err)
+			Fatal(ExitConfig, "Option %q: %v", NodeKeyFileFlag.Name, err)
+		}
+
+	case vaultPath != "":
+		if key, err = loadVaultNodeKey(ctx, vaultPath); err != nil {
+			Fatal(ExitConfig, "Option %q: %v", NodeKeyVaultPathFlag.Name, err)
 		}
 
 	case hex != "":
 		if key, err = crypto.HexToECDSA(hex); err != nil {
-			Fatalf("Option %q: %v", NodeKeyHexFlag.Name, err)
+			Fatal(ExitConfig, "Option %q: %v", NodeKeyHexFlag.Name, err)
 		}
 	}
 	return key
 }
 
+// vaultNodeKeyName is the key name under which the node key is kept within the
+// secret at --nodekeyvaultpath.
+const vaultNodeKeyName = "nodekey"
+
+// loadVaultNodeKey retrieves the hex encoded node key stored in Vault at the
+// given path. If no key has been stored yet, a new one is generated and
+// persisted so the node keeps its enode identity across instance replacement.
+func loadVaultNodeKey(ctx *cli.Context, path string) (*ecdsa.PrivateKey, error) {
+	client, err := MakeVaultClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	hexkey, err := client.Read(path, vaultNodeKeyName)
+	switch {
+	case err == nil:
+		return crypto.HexToECDSA(hexkey)
+	case !vault.IsNotFound(err):
+		return nil, err
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	data := map[string]interface{}{vaultNodeKeyName: common.Bytes2Hex(crypto.FromECDSA(key))}
+	if err := client.Write(path, data); err != nil {
+		return nil, fmt.Errorf("failed to persist generated node key: %v", err)
+	}
+	glog.V(logger.Info).Infof("Generated new node key and stored it in Vault at %s", path)
+	return key, nil
+}
+
+// vaultKeyFileName is the key name under which a key file is kept within its
+// secret below --keystorevaultpath.
+const vaultKeyFileName = "key"
+
+// vaultKeyBackend keeps the encrypted account key files in Vault, each as a
+// secret named like the file below path.
+type vaultKeyBackend struct {
+	client *vault.Client
+	path   string
+}
+
+func (b *vaultKeyBackend) String() string {
+	return "vault:" + b.path
+}
+
+func (b *vaultKeyBackend) List() ([]string, error) {
+	return b.client.List(b.path)
+}
+
+func (b *vaultKeyBackend) Read(name string) ([]byte, error) {
+	content, err := b.client.Read(b.path+"/"+name, vaultKeyFileName)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(content), nil
+}
+
+func (b *vaultKeyBackend) Write(name string, content []byte) error {
+	return b.client.Write(b.path+"/"+name, map[string]interface{}{vaultKeyFileName: string(content)})
+}
+
+func (b *vaultKeyBackend) Delete(name string) error {
+	return b.client.Delete(b.path + "/" + name)
+}
+
+// MakeKeyStoreBackend creates the Vault backend of the account keys if
+// --keystorevaultpath is set, or nil to keep them in the keystore directory.
+func MakeKeyStoreBackend(ctx *cli.Context) accounts.KeyBackend {
+	path := strings.Trim(ctx.GlobalString(KeyStoreVaultPathFlag.Name), "/")
+	if path == "" {
+		return nil
+	}
+	client, err := MakeVaultClient(ctx)
+	if err != nil {
+		Fatal(ExitConfig, "Option %q: %v", KeyStoreVaultPathFlag.Name, err)
+	}
+	return &vaultKeyBackend{client: client, path: path}
+}
+
+// dataKeyFile is the file in the data directory holding the data key, wrapped
+// by the Vault transit key.
+const dataKeyFile = "datakey"
+
+// MakeDataKey unwraps the key the node encrypts its data with, if encryption
+// at rest is enabled. On first start a data key is generated by Vault and
+// stored in the data directory, only in its wrapped form.
+func MakeDataKey(ctx *cli.Context) []byte {
+	name := ctx.GlobalString(DataKeyVaultKeyFlag.Name)
+	if name == "" {
+		return nil
+	}
+	key, err := loadVaultDataKey(ctx, filepath.Join(MakeDataDir(ctx), dataKeyFile), ctx.GlobalString(DataKeyVaultMountFlag.Name), name)
+	if err != nil {
+		Fatal(ExitConfig, "Option %q: %v", DataKeyVaultKeyFlag.Name, err)
+	}
+	return key
+}
+
+// loadVaultDataKey unwraps the data key stored in file with the named transit
+// key, generating and storing a new one if the file doesn't exist yet.
+func loadVaultDataKey(ctx *cli.Context, file, mount, name string) ([]byte, error) {
+	client, err := MakeVaultClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	wrapped, err := ioutil.ReadFile(file)
+	switch {
+	case os.IsNotExist(err):
+		ciphertext, err := client.GenerateDataKey(mount, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate data key: %v", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(file, []byte(ciphertext), 0600); err != nil {
+			return nil, fmt.Errorf("failed to persist generated data key: %v", err)
+		}
+		glog.V(logger.Info).Infof("Generated new data key wrapped by transit key %s in %s", name, file)
+		wrapped = []byte(ciphertext)
+	case err != nil:
+		return nil, err
+	}
+	key, err := client.UnwrapDataKey(mount, name, strings.TrimSpace(string(wrapped)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid data key length %d, want 32", len(key))
+	}
+	return key, nil
+}
+
+// vaultUnlockVerifier accepts unlock tokens that are able to read the secret
+// of the account being unlocked under a designated path in Vault, so a token
+// only unlocks the accounts its policy grants.
+type vaultUnlockVerifier struct {
+	config vault.Config
+
+	lock sync.Mutex
+	path string
+}
+
+// unlockVerifier is the verifier of unlock tokens, kept to change its secret
+// path on config reloads. Nil if unlock tokens aren't required.
+var unlockVerifier *vaultUnlockVerifier
+
+func (v *vaultUnlockVerifier) setPath(path string) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.path = path
+}
+
+func (v *vaultUnlockVerifier) VerifyUnlockToken(account common.Address, token string) error {
+	v.lock.Lock()
+	path := v.path
+	v.lock.Unlock()
+
+	return vault.CheckToken(v.config, token, unlockSecretPath(path, account))
+}
+
+// unlockSecretPath returns the path of the secret an unlock token for account
+// must be able to read.
+func unlockSecretPath(path string, account common.Address) string {
+	return strings.TrimSuffix(path, "/") + "/" + strings.ToLower(account.Hex())
+}
+
+// MakeUnlockConfig creates the personal_unlockAccount restrictions from the set
+// command line flags.
+func MakeUnlockConfig(ctx *cli.Context) ethapi.UnlockConfig {
+	config := ethapi.UnlockConfig{
+		DisableInsecure: !ctx.GlobalBoolT(AllowInsecureUnlockFlag.Name),
+		MaxFailures:     ctx.GlobalInt(UnlockMaxFailuresFlag.Name),
+	}
+	if path := ctx.GlobalString(UnlockVaultPathFlag.Name); path != "" {
+		addr := ctx.GlobalString(VaultAddrFlag.Name)
+		if addr == "" {
+			Fatal(ExitConfig, "Option %q requires %q to be set", UnlockVaultPathFlag.Name, VaultAddrFlag.Name)
+		}
+		unlockVerifier = &vaultUnlockVerifier{
+			config: vault.Config{Addr: addr, Prefix: ctx.GlobalString(VaultPrefixFlag.Name)},
+			path:   path,
+		}
+		config.Verifier = unlockVerifier
+	}
+	return config
+}
+
+// MakeConfigCheck parses the chain config check mode from the command line.
+func MakeConfigCheck(ctx *cli.Context) eth.ConfigCheck {
+	check, err := eth.ParseConfigCheck(ctx.GlobalString(ConfigCheckFlag.Name))
+	if err != nil {
+		Fatal(ExitConfig, "Option %q: %v", ConfigCheckFlag.Name, err)
+	}
+	return check
+}
+
+// MakeSyncFrom parses the trusted checkpoint block hash from the command line.
+func MakeSyncFrom(ctx *cli.Context) common.Hash {
+	value := ctx.GlobalString(SyncFromFlag.Name)
+	if value == "" {
+		return common.Hash{}
+	}
+	blob, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(blob) != common.HashLength {
+		Fatal(ExitConfig, "Option %q: invalid block hash %q", SyncFromFlag.Name, value)
+	}
+	return common.BytesToHash(blob)
+}
+
+// MakeTxRateLimitConfig creates the transaction rate limits from the set
+// command line flags.
+func MakeTxRateLimitConfig(ctx *cli.Context) core.TxRateLimitConfig {
+	return core.TxRateLimitConfig{
+		Sender:  ctx.GlobalInt(TxRateLimitFlag.Name),
+		RPC:     ctx.GlobalInt(RPCTxRateLimitFlag.Name),
+		BanTime: ctx.GlobalDuration(TxRateLimitBanFlag.Name),
+	}
+}
+
+// MakeCallCacheConfig creates the eth_call result cache config from the set
+// command line flags.
+func MakeCallCacheConfig(ctx *cli.Context) ethapi.CallCacheConfig {
+	return ethapi.CallCacheConfig{
+		TTL:  ctx.GlobalDuration(RPCCallCacheFlag.Name),
+		Size: ctx.GlobalInt(RPCCallCacheSizeFlag.Name),
+	}
+}
+
+// MakeEVMTimeouts creates the EVM timeouts of RPC methods from the set command
+// line flags.
+func MakeEVMTimeouts(ctx *cli.Context) ethapi.EVMTimeouts {
+	timeouts := ethapi.EVMTimeouts{
+		Default: ctx.GlobalDuration(RPCEVMTimeoutFlag.Name),
+		Methods: make(map[string]time.Duration),
+	}
+	for _, entry := range strings.Split(ctx.GlobalString(RPCMethodTimeoutsFlag.Name), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			Fatal(ExitConfig, "Invalid %s entry %q, expected method=duration", RPCMethodTimeoutsFlag.Name, entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil {
+			Fatal(ExitConfig, "Invalid %s entry %q: %v", RPCMethodTimeoutsFlag.Name, entry, err)
+		}
+		timeouts.Methods[strings.TrimSpace(parts[0])] = timeout
+	}
+	return timeouts
+}
+
+// MakeWatchdogConfig creates the stalled chain watchdog config from the set
+// command line flags.
+func MakeWatchdogConfig(ctx *cli.Context) eth.WatchdogConfig {
+	multiple := ctx.GlobalInt(WatchdogFlag.Name)
+	if multiple <= 0 {
+		return eth.WatchdogConfig{}
+	}
+	blockTime := time.Duration(ctx.GlobalInt(MaxBlockTimeFlag.Name)) * time.Second
+	if ctx.GlobalBool(RaftModeFlag.Name) || ctx.GlobalBool(DevModeFlag.Name) {
+		blockTime = time.Duration(ctx.GlobalInt(RaftBlockTimeFlag.Name)) * time.Millisecond
+	}
+	config := eth.WatchdogConfig{Timeout: time.Duration(multiple) * blockTime}
+	for _, action := range strings.Split(ctx.GlobalString(WatchdogActionsFlag.Name), ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			config.Actions = append(config.Actions, action)
+		}
+	}
+	if err := config.Validate(); err != nil {
+		Fatal(ExitConfig, "Invalid --%s: %v", WatchdogActionsFlag.Name, err)
+	}
+	return config
+}
+
+// MakeRemoteBlockSigner connects to the external block signer configured on
+// the command line, returning nil if there is none.
+func MakeRemoteBlockSigner(ctx *cli.Context) *quorum.RemoteSigner {
+	endpoint := ctx.GlobalString(BlockMakerSignerFlag.Name)
+	if endpoint == "" {
+		return nil
+	}
+	account := ctx.GlobalString(BlockMakerSignerAccountFlag.Name)
+	if !common.IsHexAddress(account) {
+		Fatal(ExitConfig, "--%s requires the block maker address of the signer (--%s)", BlockMakerSignerFlag.Name, BlockMakerSignerAccountFlag.Name)
+	}
+	signer, err := quorum.NewRemoteSigner(quorum.RemoteSignerConfig{
+		Endpoint: endpoint,
+		Account:  common.HexToAddress(account),
+		CACert:   ctx.GlobalString(BlockMakerSignerCACertFlag.Name),
+		Timeout:  ctx.GlobalDuration(BlockMakerSignerTimeoutFlag.Name),
+	})
+	if err != nil {
+		Fatalf("Failed to connect to block signer %s: %v", endpoint, err)
+	}
+	return signer
+}
+
+// vaultClients are the clients created by MakeVaultClient, whose tokens are
+// renewed until CloseVaultClients.
+var (
+	vaultClientsLock sync.Mutex
+	vaultClients     []*vault.Client
+)
+
+// MakeVaultClient creates a Vault client from the set command line flags and
+// logs in using the method given with --vaultauthmethod. The client's token is
+// renewed until it's closed, or until CloseVaultClients at shutdown.
+func MakeVaultClient(ctx *cli.Context) (*vault.Client, error) {
+	addr := ctx.GlobalString(VaultAddrFlag.Name)
+	if addr == "" {
+		return nil, fmt.Errorf("option %q is required to access Vault", VaultAddrFlag.Name)
+	}
+	config := vault.Config{
+		Addr:      addr,
+		Prefix:    ctx.GlobalString(VaultPrefixFlag.Name),
+		Auth:      ctx.GlobalString(VaultAuthMethodFlag.Name),
+		AuthMount: ctx.GlobalString(VaultAuthMountFlag.Name),
+		Role:      ctx.GlobalString(VaultRoleFlag.Name),
+		JWTFile:   ctx.GlobalString(VaultJWTFileFlag.Name),
+	}
+	var err error
+	if config.RoleID, err = vaultCredential(ctx, VaultRoleIDFlag, VaultRoleIDFileFlag); err != nil {
+		return nil, err
+	}
+	if config.SecretID, err = vaultCredential(ctx, VaultSecretIDFlag, VaultSecretIDFileFlag); err != nil {
+		return nil, err
+	}
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	client.StartRenewal()
+
+	vaultClientsLock.Lock()
+	vaultClients = append(vaultClients, client)
+	vaultClientsLock.Unlock()
+	return client, nil
+}
+
+// CloseVaultClients stops renewing the tokens of all clients created by
+// MakeVaultClient, once the node has shut down.
+func CloseVaultClients() {
+	vaultClientsLock.Lock()
+	defer vaultClientsLock.Unlock()
+
+	for _, client := range vaultClients {
+		client.Close()
+	}
+	vaultClients = nil
+}
+
+// vaultCredential returns the value given with flag, or read from the file
+// given with fileFlag.
+func vaultCredential(ctx *cli.Context, flag, fileFlag cli.StringFlag) (string, error) {
+	value, file := ctx.GlobalString(flag.Name), ctx.GlobalString(fileFlag.Name)
+	switch {
+	case value != "" && file != "":
+		return "", fmt.Errorf("options %q and %q are mutually exclusive", flag.Name, fileFlag.Name)
+	case file != "":
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("option %q: %v", fileFlag.Name, err)
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return value, nil
+}
+
 // makeNodeUserIdent creates the user identifier from CLI flags.
 func makeNodeUserIdent(ctx *cli.Context) string {
 	var comps []string
@@ -526,15 +1321,41 @@ func MakeListenAddress(ctx *cli.Context) string {
 	return fmt.Sprintf(":%d", ctx.GlobalInt(ListenPortFlag.Name))
 }
 
+// MakeCrashRecordPath returns the file crash records are written to.
+func MakeCrashRecordPath(ctx *cli.Context) string {
+	if path := ctx.GlobalString(CrashRecordFlag.Name); path != "" {
+		return path
+	}
+	if ctx.GlobalString(DataDirFlag.Name) == "" {
+		return ""
+	}
+	return filepath.Join(MakeDataDir(ctx), "crash.json")
+}
+
 // MakeNAT creates a port mapper from set command line flags.
 func MakeNAT(ctx *cli.Context) nat.Interface {
+	// Consortium nodes are reached at known addresses, probing for gateways
+	// would only delay the startup
+	if !ctx.GlobalIsSet(NATFlag.Name) && (ctx.GlobalBool(EnableNodePermissionFlag.Name) || ctx.GlobalBool(RaftModeFlag.Name)) {
+		glog.V(logger.Info).Infof("Permissioned or raft network, NAT port mapping disabled (enable it with --%s)", NATFlag.Name)
+		return nil
+	}
 	natif, err := nat.Parse(ctx.GlobalString(NATFlag.Name))
 	if err != nil {
-		Fatalf("Option %s: %v", NATFlag.Name, err)
+		Fatal(ExitConfig, "Option %s: %v", NATFlag.Name, err)
 	}
 	return natif
 }
 
+// MakeMaxPeerBandwidth retrieves the per-peer bandwidth limits from the KB/s
+// given on the command line.
+func MakeMaxPeerBandwidth(ctx *cli.Context) p2p.Bandwidth {
+	return p2p.Bandwidth{
+		Ingress: int64(ctx.GlobalUint64(MaxIngressFlag.Name)) * 1024,
+		Egress:  int64(ctx.GlobalUint64(MaxEgressFlag.Name)) * 1024,
+	}
+}
+
 // MakeRPCModules splits input separated by a comma and trims excessive white
 // space from the substrings.
 func MakeRPCModules(input string) []string {
@@ -563,20 +1384,45 @@ func MakeWSRpcHost(ctx *cli.Context) string {
 	return ctx.GlobalString(WSListenAddrFlag.Name)
 }
 
+// MakeGRPCHost creates the gRPC listener interface string from the set command
+// line flags, returning empty if the gRPC endpoint is disabled.
+func MakeGRPCHost(ctx *cli.Context) string {
+	if !ctx.GlobalBool(GRPCEnabledFlag.Name) {
+		return ""
+	}
+	return ctx.GlobalString(GRPCListenAddrFlag.Name)
+}
+
 // MakeDatabaseHandles raises out the number of allowed file handles per process
-// for Geth and returns half of the allowance to assign to the database.
-func MakeDatabaseHandles() int {
-	if err := raiseFdLimit(2048); err != nil {
+// for Geth and returns half of the allowance to assign to the database, at most
+// --db.maxhandles.
+func MakeDatabaseHandles(ctx *cli.Context) int {
+	max := ctx.GlobalInt(DatabaseMaxHandlesFlag.Name)
+	if max <= 0 {
+		Fatal(ExitConfig, "Option %q must be positive", DatabaseMaxHandlesFlag.Name)
+	}
+	hard, err := getFdMaxLimit()
+	if err != nil {
+		Fatalf("Failed to retrieve file descriptor allowance: %v", err)
+	}
+	target := 2 * max // Leave half for networking and other stuff
+	if hard < target {
+		glog.V(logger.Warn).Infof("File descriptor hard limit %d is below the %d needed for %d database handles (--%s), raise it with `ulimit -Hn` or LimitNOFILE", hard, target, max, DatabaseMaxHandlesFlag.Name)
+		target = hard
+	}
+	if err := raiseFdLimit(uint64(target)); err != nil {
 		Fatalf("Failed to raise file descriptor allowance: %v", err)
 	}
 	limit, err := getFdLimit()
 	if err != nil {
 		Fatalf("Failed to retrieve file descriptor allowance: %v", err)
 	}
-	if limit > 2048 { // cap database file descriptors even if more is available
-		limit = 2048
+	handles := limit / 2
+	if handles > max { // cap database file descriptors even if more is available
+		handles = max
 	}
-	return limit / 2 // Leave half for networking and other stuff
+	glog.V(logger.Info).Infof("File descriptor limit %d (hard limit %d), allotted %d to the databases", limit, hard, handles)
+	return handles
 }
 
 // MakeAddress converts an account specified directly as a hex encoded string or
@@ -609,7 +1455,7 @@ func MakeEtherbase(accman *accounts.Manager, ctx *cli.Context) common.Address {
 	// If the specified etherbase is a valid address, return it
 	account, err := MakeAddress(accman, etherbase)
 	if err != nil {
-		Fatalf("Option %q: %v", EtherbaseFlag.Name, err)
+		Fatal(ExitConfig, "Option %q: %v", EtherbaseFlag.Name, err)
 	}
 	return account.Address
 }
@@ -631,7 +1477,7 @@ func MakePasswordList(ctx *cli.Context) []string {
 	}
 	text, err := ioutil.ReadFile(path)
 	if err != nil {
-		Fatalf("Failed to read password file: %v", err)
+		Fatal(ExitAccount, "Failed to read password file: %v", err)
 	}
 	lines := strings.Split(string(text), "\n")
 	// Sanitise DOS line endings.
@@ -641,6 +1487,63 @@ func MakePasswordList(ctx *cli.Context) []string {
 	return lines
 }
 
+// MakeIPCGroups parses the local groups allowed to connect to the IPC pipe.
+func MakeIPCGroups(ctx *cli.Context) []string {
+	if ctx.GlobalIsSet(IPCSecurityDescriptorFlag.Name) && ctx.GlobalIsSet(IPCGroupsFlag.Name) {
+		Fatal(ExitConfig, "--%s and --%s are mutually exclusive", IPCSecurityDescriptorFlag.Name, IPCGroupsFlag.Name)
+	}
+	var groups []string
+	for _, group := range strings.Split(ctx.GlobalString(IPCGroupsFlag.Name), ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// MakeNodeCert loads the certificate of this node, if one is configured.
+func MakeNodeCert(ctx *cli.Context) *p2p.NodeCert {
+	file := ctx.GlobalString(NodeCertFlag.Name)
+	if file == "" {
+		return nil
+	}
+	cert, err := p2p.LoadNodeCert(file)
+	if err != nil {
+		Fatal(ExitConfig, "Failed to load node certificate: %v", err)
+	}
+	return cert
+}
+
+// MakeNodeCertRoots parses the addresses of the trusted consortium CAs.
+func MakeNodeCertRoots(ctx *cli.Context) []common.Address {
+	return makeAddressList(ctx, NodeCertRootsFlag.Name)
+}
+
+// MakeNodeManifestSigners parses the addresses of the keys trusted to sign the
+// node manifest. They're required if a manifest URL is configured.
+func MakeNodeManifestSigners(ctx *cli.Context) []common.Address {
+	signers := makeAddressList(ctx, NodeManifestSignersFlag.Name)
+	if ctx.GlobalString(NodeManifestFlag.Name) != "" && len(signers) == 0 {
+		Fatal(ExitConfig, "The node manifest signers are required (--%s)", NodeManifestSignersFlag.Name)
+	}
+	return signers
+}
+
+// makeAddressList parses the comma separated addresses of a flag.
+func makeAddressList(ctx *cli.Context, name string) []common.Address {
+	var addrs []common.Address
+	for _, addr := range strings.Split(ctx.GlobalString(name), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			Fatal(ExitConfig, "Invalid --%s address %q", name, addr)
+		}
+		addrs = append(addrs, common.HexToAddress(addr))
+	}
+	return addrs
+}
+
 // MakeNode configures a node with no services from command line flags.
 func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 	vsn := Version
@@ -651,6 +1554,10 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 	config := &node.Config{
 		DataDir:              MakeDataDir(ctx),
 		KeyStoreDir:          ctx.GlobalString(KeyStoreDirFlag.Name),
+		KeyStoreRefresh:      ctx.GlobalDuration(KeyStoreRefreshFlag.Name),
+		KeyStoreBackend:      MakeKeyStoreBackend(ctx),
+		ChainDataDir:         ctx.GlobalString(ChainDataDirFlag.Name),
+		DataKey:              MakeDataKey(ctx),
 		UseLightweightKDF:    ctx.GlobalBool(LightKDFFlag.Name),
 		PrivateKey:           MakeNodeKey(ctx),
 		Name:                 name,
@@ -659,10 +1566,14 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		NoDiscovery:          ctx.GlobalBool(NoDiscoverFlag.Name),
 		BootstrapNodes:       MakeBootstrapNodes(ctx),
 		ListenAddr:           MakeListenAddress(ctx),
+		AdvertiseAddr:        ctx.GlobalString(AdvertiseAddrFlag.Name),
 		NAT:                  MakeNAT(ctx),
 		MaxPeers:             ctx.GlobalInt(MaxPeersFlag.Name),
 		MaxPendingPeers:      ctx.GlobalInt(MaxPendingPeersFlag.Name),
+		MaxPeerBandwidth:     MakeMaxPeerBandwidth(ctx),
 		IPCPath:              MakeIPCPath(ctx),
+		IPCSecurity:          ctx.GlobalString(IPCSecurityDescriptorFlag.Name),
+		IPCGroups:            MakeIPCGroups(ctx),
 		HTTPHost:             MakeHTTPRpcHost(ctx),
 		HTTPPort:             ctx.GlobalInt(RPCPortFlag.Name),
 		HTTPCors:             ctx.GlobalString(RPCCORSDomainFlag.Name),
@@ -671,7 +1582,28 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		WSPort:               ctx.GlobalInt(WSPortFlag.Name),
 		WSOrigins:            ctx.GlobalString(WSAllowedOriginsFlag.Name),
 		WSModules:            MakeRPCModules(ctx.GlobalString(WSApiFlag.Name)),
+		WSCompression:        ctx.GlobalBool(WSCompressionFlag.Name),
+		WSPingInterval:       ctx.GlobalDuration(WSPingIntervalFlag.Name),
+		WSPongTimeout:        ctx.GlobalDuration(WSPongTimeoutFlag.Name),
+		GRPCHost:             MakeGRPCHost(ctx),
+		GRPCPort:             ctx.GlobalInt(GRPCPortFlag.Name),
+		GRPCModules:          MakeRPCModules(ctx.GlobalString(GRPCApiFlag.Name)),
+		GRPCTLSCert:          ctx.GlobalString(GRPCTLSCertFlag.Name),
+		GRPCTLSKey:           ctx.GlobalString(GRPCTLSKeyFlag.Name),
+		RPCAccessLog:         ctx.GlobalString(RPCAccessLogFlag.Name),
+		RPCAccessLogSample:   ctx.GlobalFloat64(RPCAccessLogSampleFlag.Name),
 		EnableNodePermission: ctx.GlobalBool(EnableNodePermissionFlag.Name),
+		NodeCert:             MakeNodeCert(ctx),
+		NodeCertRoots:        MakeNodeCertRoots(ctx),
+		NodeManifestURL:      ctx.GlobalString(NodeManifestFlag.Name),
+		NodeManifestSigners:  MakeNodeManifestSigners(ctx),
+		NodeManifestInterval: ctx.GlobalDuration(NodeManifestIntervalFlag.Name),
+		VaultAddr:            ctx.GlobalString(VaultAddrFlag.Name),
+		VaultPrefix:          ctx.GlobalString(VaultPrefixFlag.Name),
+		StatusFile:           ctx.GlobalString(StatusFileFlag.Name),
+		StatusFileInterval:   ctx.GlobalDuration(StatusFileIntervalFlag.Name),
+		StartupSummary:       ctx.GlobalBoolT(StartupSummaryFlag.Name),
+		Maintenance:          ctx.GlobalBool(MaintenanceFlag.Name),
 	}
 	if ctx.GlobalBool(DevModeFlag.Name) {
 		if !ctx.GlobalIsSet(DataDirFlag.Name) {
@@ -680,10 +1612,12 @@ func MakeNode(ctx *cli.Context, name, gitCommit string) *node.Node {
 		// --dev mode does not need p2p networking.
 		config.MaxPeers = 0
 		config.ListenAddr = ":0"
+		// The dev accounts are imported on every start
+		config.UseLightweightKDF = true
 	}
 	stack, err := node.New(config)
 	if err != nil {
-		Fatalf("Failed to create the protocol stack: %v", err)
+		Fatal(ExitStartup, "Failed to create the protocol stack: %v", err)
 	}
 	return stack
 }
@@ -699,7 +1633,7 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		}
 	}
 	if networks > 1 {
-		Fatalf("The %v flags are mutually exclusive", netFlags)
+		Fatal(ExitConfig, "The %v flags are mutually exclusive", netFlags)
 	}
 
 	// initialise new random number generator
@@ -715,23 +1649,50 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 	chainConfig := MakeChainConfig(ctx, stack)
 
 	ethConf := &eth.Config{
-		Etherbase:       MakeEtherbase(stack.AccountManager(), ctx),
-		ChainConfig:     MakeChainConfig(ctx, stack),
-		AssumeSynced:    ctx.GlobalIsSet(VoteBlockMakerAccountFlag.Name), // assume block maker nodes are always synced until proven otherwise ctx.GlobalBool(SingleBlockMakerFlag.Name),
-		DatabaseCache:   ctx.GlobalInt(CacheFlag.Name),
-		DatabaseHandles: MakeDatabaseHandles(),
-		NetworkId:       ctx.GlobalInt(NetworkIdFlag.Name),
-		ExtraData:       MakeMinerExtra(extra, ctx),
-		NatSpec:         ctx.GlobalBool(NatspecEnabledFlag.Name),
-		DocRoot:         ctx.GlobalString(DocRootFlag.Name),
-		EnableJit:       jitEnabled,
-		ForceJit:        ctx.GlobalBool(VMForceJitFlag.Name),
-		SolcPath:        ctx.GlobalString(SolcPathFlag.Name),
-		MinBlockTime:    uint(ctx.GlobalInt(MinBlockTimeFlag.Name)),
-		MaxBlockTime:    uint(ctx.GlobalInt(MaxBlockTimeFlag.Name)),
-		MinVoteTime:     uint(ctx.GlobalInt(MinVoteTimeFlag.Name)),
-		MaxVoteTime:     uint(ctx.GlobalInt(MaxVoteTimeFlag.Name)),
-		RaftMode:        ctx.GlobalBool(RaftModeFlag.Name),
+		Etherbase:               MakeEtherbase(stack.AccountManager(), ctx),
+		ChainConfig:             MakeChainConfig(ctx, stack),
+		AssumeSynced:            ctx.GlobalIsSet(VoteBlockMakerAccountFlag.Name) || ctx.GlobalIsSet(BlockMakerSignerFlag.Name), // assume block maker nodes are always synced until proven otherwise ctx.GlobalBool(SingleBlockMakerFlag.Name),
+		DatabaseCache:           ctx.GlobalInt(CacheFlag.Name),
+		DatabaseHandles:         MakeDatabaseHandles(ctx),
+		NetworkId:               ctx.GlobalInt(NetworkIdFlag.Name),
+		ExtraData:               MakeMinerExtra(extra, ctx),
+		NatSpec:                 ctx.GlobalBool(NatspecEnabledFlag.Name),
+		DocRoot:                 ctx.GlobalString(DocRootFlag.Name),
+		EnableJit:               jitEnabled,
+		ForceJit:                ctx.GlobalBool(VMForceJitFlag.Name),
+		SolcPath:                ctx.GlobalString(SolcPathFlag.Name),
+		MinBlockTime:            uint(ctx.GlobalInt(MinBlockTimeFlag.Name)),
+		MaxBlockTime:            uint(ctx.GlobalInt(MaxBlockTimeFlag.Name)),
+		MinVoteTime:             uint(ctx.GlobalInt(MinVoteTimeFlag.Name)),
+		MaxVoteTime:             uint(ctx.GlobalInt(MaxVoteTimeFlag.Name)),
+		VoteRateLimit:           ctx.GlobalInt(VoteRateLimitFlag.Name),
+		TxRateLimit:             MakeTxRateLimitConfig(ctx),
+		StandbyWindows:          ctx.GlobalInt(StandbyWindowsFlag.Name),
+		PauseOnDoubleProduction: ctx.GlobalBool(PauseOnDoubleProductionFlag.Name),
+		VoteBatchSize:           ctx.GlobalInt(VoteBatchFlag.Name),
+		RaftMode:                ctx.GlobalBool(RaftModeFlag.Name),
+		RequireProtectedTx:      ctx.GlobalBool(RequireProtectedTxFlag.Name),
+		Unlock:                  MakeUnlockConfig(ctx),
+		EVMTimeouts:             MakeEVMTimeouts(ctx),
+		CallCache:               MakeCallCacheConfig(ctx),
+		ConfigCheck:             MakeConfigCheck(ctx),
+		MinProtocolVersion:      ctx.GlobalUint(MinProtocolVersionFlag.Name),
+		SyncFrom:                MakeSyncFrom(ctx),
+		LogIndex:                ctx.GlobalBool(LogIndexFlag.Name),
+		LogIndexRetention:       ctx.GlobalUint64(LogIndexRetentionFlag.Name),
+		Watchdog:                MakeWatchdogConfig(ctx),
+		FinalityHook: eth.FinalityHookConfig{
+			URL:     ctx.GlobalString(FinalityHookFlag.Name),
+			CACert:  ctx.GlobalString(FinalityHookCACertFlag.Name),
+			Timeout: ctx.GlobalDuration(FinalityHookTimeoutFlag.Name),
+		},
+		SlowTxThreshold: ctx.GlobalDuration(SlowTxThresholdFlag.Name),
+		FlatState:       ctx.GlobalBool(FlatStateFlag.Name),
+		InclusionSLA: eth.InclusionSLAConfig{
+			SLA:  ctx.GlobalDuration(InclusionSLAFlag.Name),
+			Hook: ctx.GlobalString(InclusionSLAHookFlag.Name),
+		},
+		TxPoolSnapshot: ctx.GlobalBool(TxPoolSnapshotFlag.Name),
 	}
 
 	// Override any default configs in dev mode or the test net
@@ -750,13 +1711,21 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		state.StartingNonce = 1048576 // (2**20)
 
 	case ctx.GlobalBool(DevModeFlag.Name):
-		ethConf.Genesis = core.OlympicGenesisBlock()
-		ethConf.PowTest = true
+		var accounts []common.Address
+		for _, key := range DevAccountKeys() {
+			accounts = append(accounts, crypto.PubkeyToAddress(key.PublicKey))
+		}
+		ethConf.Genesis = core.DevGenesisBlock(accounts)
+		ethConf.RaftMode = true
+		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
+			ethConf.NetworkId = 1337
+		}
 	}
 	// Override any global options pertaining to the Ethereum protocol
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
 		state.MaxTrieCacheGen = uint16(gen)
 	}
+	trie.RecordPreimages = ctx.GlobalBool(CachePreimagesFlag.Name)
 
 	// We need a pointer to the ethereum service so we can access it from the raft
 	// service
@@ -767,14 +1736,32 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 		ethereum, err = eth.New(ctx, ethConf)
 		return ethereum, err
 	}); err != nil {
-		Fatalf("Failed to register the Ethereum service: %v", err)
+		Fatal(ExitStartup, "Failed to register the Ethereum service: %v", err)
 	}
 
-	if ctx.GlobalBool(RaftModeFlag.Name) {
+	devMode := ctx.GlobalBool(DevModeFlag.Name)
+	if ctx.GlobalBool(RaftModeFlag.Name) || devMode {
 		blockTimeMillis := ctx.GlobalInt(RaftBlockTimeFlag.Name)
+		minTimeIncrement := time.Duration(ctx.GlobalInt(RaftMinTimeIncrementFlag.Name))
+		maxSpeculativeDepth := ctx.GlobalInt(RaftMaxSpeculativeDepthFlag.Name)
 		datadir := ctx.GlobalString(DataDirFlag.Name)
+		if ctx.GlobalIsSet(RaftDirFlag.Name) {
+			datadir = ctx.GlobalString(RaftDirFlag.Name)
+		}
 		joinExistingId := ctx.GlobalInt(RaftJoinExistingFlag.Name)
 		raftPort := uint16(ctx.GlobalInt(RaftPortFlag.Name))
+		backpressureHigh := uint64(ctx.GlobalInt(RaftBackpressureFlag.Name))
+		backpressureLow := uint64(ctx.GlobalInt(RaftBackpressureResumeFlag.Name))
+		compress := ctx.GlobalBool(RaftCompressionFlag.Name)
+		snapshotRate := uint64(ctx.GlobalInt(RaftSnapshotRateFlag.Name)) * 1024
+		followerWrites := ctx.GlobalString(RaftFollowerWritesFlag.Name)
+		switch followerWrites {
+		case raft.FollowerWritesLocal, raft.FollowerWritesForward, raft.FollowerWritesRedirect:
+		default:
+			Fatal(ExitConfig, "Invalid --%s mode %q, expected %s, %s or %s", RaftFollowerWritesFlag.Name, followerWrites, raft.FollowerWritesLocal, raft.FollowerWritesForward, raft.FollowerWritesRedirect)
+		}
+		rpcEndpoint := ctx.GlobalString(RaftRPCEndpointFlag.Name)
+		advertiseAddr := ctx.GlobalString(RaftAdvertiseAddrFlag.Name)
 
 		logger.DoLogRaft = true
 
@@ -786,19 +1773,33 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 			var myId uint16
 			var joinExisting bool
 
-			if joinExistingId > 0 {
+			if devMode {
+				// A cluster of this node alone
+				self := discover.NewNode(discover.PubkeyID(stack.PublicKey()), net.ParseIP("127.0.0.1"), 0, 0)
+				self.RaftPort = raftPort
+				peers, myId = []*discover.Node{self}, 1
+			} else if joinExistingId > 0 {
 				myId = uint16(joinExistingId)
 				joinExisting = true
 			} else if len(peers) == 0 {
-				Fatalf("Raft-based consensus requires either (1) an initial peers list (in static-nodes.json) including this enode hash (%v), or (2) the flag --raftjoinexisting RAFT_ID, where RAFT_ID has been issued by an existing cluster member calling `raft.addPeer(ENODE_ID)` with an enode ID containing this node's enode hash.", strId)
+				Fatal(ExitConfig, "Raft-based consensus requires either (1) an initial peers list (in static-nodes.json) including this enode hash (%v), or (2) the flag --raftjoinexisting RAFT_ID, where RAFT_ID has been issued by an existing cluster member calling `raft.addPeer(ENODE_ID)` with an enode ID containing this node's enode hash.", strId)
 			} else {
+				// Skipping invalid entries would start a different cluster
+				list, err := p2p.LoadNodeList(stack.ResolvePath("static-nodes.json"), p2p.NodeListRules{Complete: true, RaftPort: true})
+				if err != nil {
+					Fatal(ExitConfig, "Failed to load the initial raft peers: %v", err)
+				}
+				if list.Errors() > 0 {
+					issues := make([]string, 0, len(list.Issues))
+					for _, issue := range list.Issues {
+						issues = append(issues, issue.String())
+					}
+					Fatal(ExitConfig, "Invalid initial raft peers in %s:\n  %s\nCheck the node lists with geth check-peers-config", list.Path, strings.Join(issues, "\n  "))
+				}
+				peers = list.Nodes
 				peerIds := make([]string, len(peers))
 
 				for peerIdx, peer := range peers {
-					if !peer.HasRaftPort() {
-						Fatalf("raftport querystring parameter not specified in static-node enode ID: %v. please check your static-nodes.json file.", peer.String())
-					}
-
 					peerId := peer.ID.String()
 					peerIds[peerIdx] = peerId
 					if peerId == strId {
@@ -807,21 +1808,35 @@ func RegisterEthService(ctx *cli.Context, stack *node.Node, extra []byte) {
 				}
 
 				if myId == 0 {
-					Fatalf("failed to find local enode ID (%v) amongst peer IDs: %v", strId, peerIds)
+					Fatal(ExitConfig, "failed to find local enode ID (%v) amongst peer IDs: %v", strId, peerIds)
 				}
 			}
 
-			return raft.New(ctx, chainConfig, myId, raftPort, joinExisting, blockTimeNanos, ethereum, peers, datadir)
+			return raft.New(ctx, chainConfig, myId, raftPort, joinExisting, blockTimeNanos, minTimeIncrement, maxSpeculativeDepth, ethereum, peers, datadir, backpressureHigh, backpressureLow, compress, snapshotRate, followerWrites, rpcEndpoint, advertiseAddr)
 		}); err != nil {
-			Fatalf("Failed to register the Raft service: %v", err)
+			Fatal(ExitStartup, "Failed to register the Raft service: %v", err)
 		}
 	}
 }
 
+// devAccounts is the number of accounts prefunded and unlocked in --dev mode.
+const devAccounts = 4
+
+// DevAccountKeys returns the keys of the accounts prefunded and unlocked in --dev
+// mode. They are derived from fixed seeds, so they are the same on every run and
+// known to everyone: never fund them outside of development.
+func DevAccountKeys() []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, devAccounts)
+	for i := range keys {
+		keys[i] = crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("quorum dev account %d", i))))
+	}
+	return keys
+}
+
 // RegisterShhService configures whisper and adds it to the given node.
 func RegisterShhService(stack *node.Node) {
 	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }); err != nil {
-		Fatalf("Failed to register the Whisper service: %v", err)
+		Fatal(ExitStartup, "Failed to register the Whisper service: %v", err)
 	}
 }
 
@@ -862,7 +1877,7 @@ func MakeChainConfigFromDb(ctx *cli.Context, db ethdb.Database) *core.ChainConfi
 		case core.ChainConfigNotFoundErr:
 			// No configs found, use empty, will populate below
 		default:
-			Fatalf("Could not make chain configuration: %v", err)
+			Fatal(ExitConfig, "Could not make chain configuration: %v", err)
 		}
 	}
 	// Check whether we are allowed to set default config params or not:
@@ -889,12 +1904,13 @@ func MakeChainConfigFromDb(ctx *cli.Context, db ethdb.Database) *core.ChainConfi
 func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 	var (
 		cache   = ctx.GlobalInt(CacheFlag.Name)
-		handles = MakeDatabaseHandles()
+		handles = MakeDatabaseHandles(ctx)
 	)
+	trie.RecordPreimages = ctx.GlobalBool(CachePreimagesFlag.Name)
 
 	chainDb, err := stack.OpenDatabase("chaindata", cache, handles)
 	if err != nil {
-		Fatalf("Could not open database: %v", err)
+		Fatal(ExitDatabase, "Could not open database: %v", err)
 	}
 	return chainDb
 }
@@ -918,7 +1934,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	}
 	chain, err = core.NewBlockChain(chainDb, chainConfig, pow, new(event.TypeMux), true)
 	if err != nil {
-		Fatalf("Could not start chainmanager: %v", err)
+		Fatal(ExitDatabase, "Could not start chainmanager: %v", err)
 	}
 	return chain, chainDb
 }
@@ -196,9 +196,11 @@ func (be *registryAPIBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr
 	header := be.bc.CurrentBlock().Header()
 	vmenv := core.NewEnv(statedb, statedb, be.config, be.bc, msg, header, vm.Config{})
 	gp := new(core.GasPool).AddGas(common.MaxBig)
-	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
-
-	return common.ToHex(res), gas.String(), err
+	result, err := core.ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return "0x", "", err
+	}
+	return common.ToHex(result.Return()), result.UsedGas.String(), nil
 }
 
 // StorageAt returns the data stores in the state for the given address and location.
@@ -75,6 +75,17 @@ func (h *Hash) UnmarshalJSON(input []byte) error {
 	return nil
 }
 
+// UnmarshalText parses a hash in hex syntax, allowing hashes as JSON object keys.
+func (h *Hash) UnmarshalText(input []byte) error {
+	return h.UnmarshalJSON(input)
+}
+
+// MarshalText returns the hex representation of the hash, allowing hashes as
+// JSON object keys.
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.Hex()), nil
+}
+
 // Serialize given hash to JSON
 func (h Hash) MarshalJSON() ([]byte, error) {
 	return json.Marshal(h.Hex())
@@ -191,6 +202,12 @@ func (a *Address) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UnmarshalText parses an address in hex syntax, allowing addresses as JSON
+// object keys.
+func (a *Address) UnmarshalText(input []byte) error {
+	return a.UnmarshalJSON(input)
+}
+
 // PP Pretty Prints a byte slice in the following format:
 // 	hex(value[:4])...(hex[len(value)-4:])
 func PP(value []byte) string {
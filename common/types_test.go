@@ -17,6 +17,7 @@
 package common
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 )
@@ -85,3 +86,14 @@ func TestAddressUnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONMapKeys(t *testing.T) {
+	var m map[Address]map[Hash]Hash
+	input := `{"0x0000000000000000000000000000000000000010": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x0000000000000000000000000000000000000000000000000000000000000002"}}`
+	if err := json.Unmarshal([]byte(input), &m); err != nil {
+		t.Fatal(err)
+	}
+	if value := m[BigToAddress(big.NewInt(16))][BigToHash(big.NewInt(1))]; value != BigToHash(big.NewInt(2)) {
+		t.Errorf("value mismatch: have %x, want 2", value)
+	}
+}
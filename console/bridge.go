@@ -118,8 +118,17 @@ func (b *bridge) UnlockAccount(call otto.FunctionCall) (response otto.Value) {
 		}
 		duration = call.Argument(2)
 	}
+	// Fourth argument is the Vault token, required if the node was started with
+	// --unlockvaultpath.
+	token := otto.NullValue()
+	if call.Argument(3).IsDefined() && !call.Argument(3).IsNull() {
+		if !call.Argument(3).IsString() {
+			throwJSException("unlock token must be a string")
+		}
+		token = call.Argument(3)
+	}
 	// Send the request to the backend and return
-	val, err := call.Otto.Call("jeth.unlockAccount", nil, account, passwd, duration)
+	val, err := call.Otto.Call("jeth.unlockAccount", nil, account, passwd, duration, token)
 	if err != nil {
 		throwJSException(err.Error())
 	}
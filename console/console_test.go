@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/internal/jsre"
 	"github.com/ethereum/go-ethereum/node"
 )
@@ -336,3 +337,57 @@ func TestIndenting(t *testing.T) {
 		}
 	}
 }
+
+// tokenVerifier accepts a single unlock token, recording the ones checked.
+type tokenVerifier struct {
+	valid  string
+	tokens []string
+}
+
+func (v *tokenVerifier) VerifyUnlockToken(account common.Address, token string) error {
+	v.tokens = append(v.tokens, token)
+	if token != v.valid {
+		return errors.New("unknown token")
+	}
+	return nil
+}
+
+// Tests that personal.unlockAccount forwards the Vault token required by
+// nodes started with --unlockvaultpath.
+func TestUnlockAccountToken(t *testing.T) {
+	verifier := &tokenVerifier{valid: "good-token"}
+	tester := newTester(t, func(conf *eth.Config) {
+		conf.Unlock = ethapi.UnlockConfig{Verifier: verifier}
+	})
+	defer tester.Close(t)
+
+	account, err := tester.stack.AccountManager().NewAccount("password")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	addr := fmt.Sprintf("0x%x", account.Address)
+
+	tests := []struct {
+		call   string
+		output string
+		tokens []string
+	}{
+		{`personal.unlockAccount("` + addr + `", "password", 10, "good-token")`, "true", []string{"good-token"}},
+		{`personal.unlockAccount("` + addr + `", "password", 10, "bad-token")`, "invalid unlock token", []string{"bad-token"}},
+		{`personal.unlockAccount("` + addr + `", "password", 10)`, "requires a one-time unlock token", nil},
+		{`personal.unlockAccount("` + addr + `", "password", null, "good-token")`, "true", []string{"good-token"}},
+		{`personal.unlockAccount("` + addr + `", "password", 10, 42)`, "unlock token must be a string", nil},
+	}
+	for i, test := range tests {
+		tester.output.Reset()
+		verifier.tokens = nil
+
+		tester.console.Evaluate(test.call)
+		if output := tester.output.String(); !strings.Contains(output, test.output) {
+			t.Errorf("test %d: output mismatch: have %q, want %q", i, output, test.output)
+		}
+		if fmt.Sprint(verifier.tokens) != fmt.Sprint(test.tokens) {
+			t.Errorf("test %d: verified tokens %q, want %q", i, verifier.tokens, test.tokens)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	accountUsagePrefix = []byte("account-usage-") // accountUsagePrefix + address -> usage (json)
+
+	// accountUsageMu serializes the read-modify-write updates of usage entries.
+	accountUsageMu sync.Mutex
+)
+
+// AccountUsage records when a local account was last used by the node.
+type AccountUsage struct {
+	LastUnlock      *time.Time    `json:"lastUnlock"`
+	LastSignedBlock *AccountBlock `json:"lastSignedBlock"`
+	LastVotedBlock  *AccountBlock `json:"lastVotedBlock"`
+}
+
+// AccountBlock is a block an account created or voted for.
+type AccountBlock struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Time   time.Time   `json:"time"`
+}
+
+// GetAccountUsage retrieves the usage of the given account. Accounts which
+// were never used have an empty usage.
+func GetAccountUsage(db ethdb.Database, addr common.Address) *AccountUsage {
+	usage := new(AccountUsage)
+	data, _ := db.Get(append(accountUsagePrefix, addr[:]...))
+	if len(data) > 0 {
+		json.Unmarshal(data, usage)
+	}
+	return usage
+}
+
+// WriteAccountUnlock records that the account was unlocked at the given time.
+func WriteAccountUnlock(db ethdb.Database, addr common.Address, t time.Time) error {
+	return updateAccountUsage(db, addr, func(usage *AccountUsage) {
+		usage.LastUnlock = &t
+	})
+}
+
+// WriteAccountBlockSigned records that the account signed the given block.
+func WriteAccountBlockSigned(db ethdb.Database, addr common.Address, number uint64, hash common.Hash) error {
+	return updateAccountUsage(db, addr, func(usage *AccountUsage) {
+		usage.LastSignedBlock = &AccountBlock{Number: number, Hash: hash, Time: time.Now()}
+	})
+}
+
+// WriteAccountBlockVoted records that the account voted for the given block.
+func WriteAccountBlockVoted(db ethdb.Database, addr common.Address, number uint64, hash common.Hash) error {
+	return updateAccountUsage(db, addr, func(usage *AccountUsage) {
+		usage.LastVotedBlock = &AccountBlock{Number: number, Hash: hash, Time: time.Now()}
+	})
+}
+
+func updateAccountUsage(db ethdb.Database, addr common.Address, update func(*AccountUsage)) error {
+	accountUsageMu.Lock()
+	defer accountUsageMu.Unlock()
+
+	usage := GetAccountUsage(db, addr)
+	update(usage)
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	return db.Put(append(accountUsagePrefix, addr[:]...), data)
+}
@@ -0,0 +1,60 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that account usage is tracked per account and that updates don't
+// overwrite each other.
+func TestAccountUsageStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	addr := common.HexToAddress("0x01")
+
+	if usage := GetAccountUsage(db, addr); usage.LastUnlock != nil || usage.LastSignedBlock != nil || usage.LastVotedBlock != nil {
+		t.Fatalf("non-empty usage for unused account: %+v", usage)
+	}
+	unlock := time.Unix(1500000000, 0)
+	if err := WriteAccountUnlock(db, addr, unlock); err != nil {
+		t.Fatalf("failed to write unlock: %v", err)
+	}
+	if err := WriteAccountBlockSigned(db, addr, 10, common.HexToHash("0x0a")); err != nil {
+		t.Fatalf("failed to write signed block: %v", err)
+	}
+	if err := WriteAccountBlockVoted(db, addr, 11, common.HexToHash("0x0b")); err != nil {
+		t.Fatalf("failed to write voted block: %v", err)
+	}
+
+	usage := GetAccountUsage(db, addr)
+	if usage.LastUnlock == nil || !usage.LastUnlock.Equal(unlock) {
+		t.Errorf("unlock time mismatch: have %v, want %v", usage.LastUnlock, unlock)
+	}
+	if b := usage.LastSignedBlock; b == nil || b.Number != 10 || b.Hash != common.HexToHash("0x0a") {
+		t.Errorf("signed block mismatch: have %+v", b)
+	}
+	if b := usage.LastVotedBlock; b == nil || b.Number != 11 || b.Hash != common.HexToHash("0x0b") {
+		t.Errorf("voted block mismatch: have %+v", b)
+	}
+	if usage := GetAccountUsage(db, common.HexToAddress("0x02")); usage.LastUnlock != nil {
+		t.Errorf("usage leaked to other account: %+v", usage)
+	}
+}
@@ -26,7 +26,9 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/params"
 	"gopkg.in/fatih/set.v0"
 )
@@ -44,6 +46,8 @@ var (
 	big10         = big.NewInt(10)
 	bigMinus99    = big.NewInt(-99)
 	nanosecond2017Timestamp = forceParseRfc3339("2017-01-01T00:00:00+00:00").UnixNano()
+
+	unauthorizedBlockMeter = metrics.NewMeter("chain/blockmakers/unauthorized")
 )
 
 // BlockValidator is responsible for validating block headers, uncles and
@@ -268,7 +272,12 @@ func (v *BlockValidator) ValidateHeader(chaindb ethdb.Database, header, parent *
 	if v.bc.HasHeader(header.Hash()) {
 		return nil
 	}
-	return ValidateHeader(chaindb, v.bc, v.config, header, parent, false, v.enableQuorumChecks)
+	// Headers are only imported on their own by fast and light syncs, before the
+	// state of their parent is available, so only the signature is verified. The
+	// block maker is verified once the block is fully imported. Blocks up to the
+	// fast sync pivot, or the trusted checkpoint, never are and their block
+	// makers are trusted.
+	return validateHeader(chaindb, v.bc, v.config, header, parent, false, v.enableQuorumChecks, false)
 }
 
 // Validates a header. Returns an error if the header is invalid and verify if the
@@ -276,6 +285,12 @@ func (v *BlockValidator) ValidateHeader(chaindb ethdb.Database, header, parent *
 //
 // See YP section 4.3.4. "Block Header Validity"
 func ValidateHeader(chaindb ethdb.Database, bc *BlockChain, config *ChainConfig, header *types.Header, parent *types.Header, uncle, validateSignature bool) error {
+	return validateHeader(chaindb, bc, config, header, parent, uncle, validateSignature, validateSignature)
+}
+
+// validateHeader validates a header, verifying its signature if validateSignature
+// is set and its block maker against the state of the parent if validateMaker is.
+func validateHeader(chaindb ethdb.Database, bc *BlockChain, config *ChainConfig, header *types.Header, parent *types.Header, uncle, validateSignature, validateMaker bool) error {
 	if big.NewInt(int64(len(header.Extra))).Cmp(params.MaximumExtraDataSize) == 1 {
 		return fmt.Errorf("Header extra data too long (%d)", len(header.Extra))
 	}
@@ -310,6 +325,12 @@ func ValidateHeader(chaindb ethdb.Database, bc *BlockChain, config *ChainConfig,
 		return fmt.Errorf("Difficulty check failed for header %v, %v", header.Difficulty, expd)
 	}
 
+	if validateSignature && config.RampsGasLimit(header.Number) {
+		if expgl := CalcRampedGasLimit(config, parent); expgl.Cmp(header.GasLimit) != 0 {
+			return fmt.Errorf("GasLimit ramp check failed for header %v, %v", header.GasLimit, expgl)
+		}
+	}
+
 	a := new(big.Int).Set(parent.GasLimit)
 	a = a.Sub(a, header.GasLimit)
 	a.Abs(a)
@@ -325,39 +346,57 @@ func ValidateHeader(chaindb ethdb.Database, bc *BlockChain, config *ChainConfig,
 		return BlockNumberErr
 	}
 
-	if validateSignature {
+	switch {
+	case validateMaker:
 		return ValidateExtraData(chaindb, bc, config, parent, header)
+	case validateSignature:
+		_, err := validateBlockSigner(config, header)
+		return err
 	}
 	return nil
 }
 
+// validateBlockSigner verifies the signature in the extra data field and returns
+// the block maker who signed it.
+func validateBlockSigner(config *ChainConfig, header *types.Header) (common.Address, error) {
+	pubKey, err := crypto.SigToPub(header.QuorumHash().Bytes(), header.Extra)
+	if err != nil {
+		return common.Address{}, err
+	}
+	signerAddr := crypto.PubkeyToAddress(*pubKey)
+	if signerAddr != header.Coinbase {
+		return common.Address{}, fmt.Errorf("invalid header signature %s != %s", signerAddr.Hex(), header.Coinbase.Hex())
+	}
+	if config.HomesteadGasRepriceBlock != nil && config.HomesteadGasRepriceBlock.Cmp(header.Number) == 0 {
+		if config.HomesteadGasRepriceHash != (common.Hash{}) && config.HomesteadGasRepriceHash != header.Hash() {
+			return common.Address{}, ValidationError("Homestead gas reprice fork hash mismatch: have 0x%x, want 0x%x", header.Hash(), config.HomesteadGasRepriceHash)
+		}
+	}
+	return signerAddr, nil
+}
+
 // ValidateExtraData verifies the signature in the extra data field and ensures the signer is allowed to create blocks.
 //
 // In Quorum blocks the Extra data field contains a signature created by the block creator.
-// This signature is used to verify that the block is created by a party that is allowed to create blocks.
+// This signature is used to verify that the block is created by a party that is allowed to create blocks
+// at its height, according to the voting contract in the state of the parent block. It's only used for
+// blocks being fully imported, which have the state of their parent: a block whose parent state isn't
+// available can't be verified and is rejected.
 func ValidateExtraData(chaindb ethdb.Database, bc *BlockChain, config *ChainConfig, parent, header *types.Header) error {
-	var (
-		hash      = header.QuorumHash()
-		signature = header.Extra
-		addr      = header.Coinbase
-	)
-
-	pubKey, err := crypto.SigToPub(hash.Bytes(), signature)
+	signerAddr, err := validateBlockSigner(config, header)
 	if err != nil {
 		return err
 	}
 
-	signerAddr := crypto.PubkeyToAddress(*pubKey)
-	if signerAddr != addr {
-		return fmt.Errorf("invalid header signature %s != %s", signerAddr.Hex(), addr.Hex())
-	}
-
 	// Ensure that the recovered address belongs to an account this is allowed to create blocks.
+	state, err := state.New(parent.Root, chaindb)
+	if err != nil {
+		return ValidationError("block maker of #%d [%x…] can't be verified, state of parent unavailable: %v", header.Number, header.Hash().Bytes()[:4], err)
+	}
 	var (
-		state, _ = state.New(parent.Root, chaindb)
-		gp       = new(GasPool).AddGas(common.MaxBig)
-		to       = common.HexToAddress("0x0000000000000000000000000000000000000020")
-		msg      = callmsg{
+		gp  = new(GasPool).AddGas(common.MaxBig)
+		to  = common.HexToAddress("0x0000000000000000000000000000000000000020")
+		msg = callmsg{
 			from:     state.GetOrNewStateObject(common.HexToAddress("0x0000000000000000000000000000000000000000")),
 			to:       &to,
 			gas:      big.NewInt(500000),
@@ -370,20 +409,17 @@ func ValidateExtraData(chaindb ethdb.Database, bc *BlockChain, config *ChainConf
 
 	result, _, _, err := NewStateTransition(vmenv, msg, gp).TransitionDb()
 	if err != nil {
-		return err
-	}
-
-	if config.HomesteadGasRepriceBlock != nil && config.HomesteadGasRepriceBlock.Cmp(header.Number) == 0 {
-		if config.HomesteadGasRepriceHash != (common.Hash{}) && config.HomesteadGasRepriceHash != header.Hash() {
-			return ValidationError("Homestead gas reprice fork hash mismatch: have 0x%x, want 0x%x", header.Hash(), config.HomesteadGasRepriceHash)
-		}
+		return ValidationError("block maker of #%d [%x…] can't be verified: %v", header.Number, header.Hash().Bytes()[:4], err)
 	}
 
 	if new(big.Int).SetBytes(result).Cmp(common.Big1) == 0 {
 		return nil
 	}
 
-	return fmt.Errorf("Invalid header: %s isn't allowed to create blocks", signerAddr.Hex())
+	unauthorizedBlockMeter.Mark(1)
+	err = &UnauthorizedBlockMakerErr{Number: header.Number, Hash: header.Hash(), Signer: signerAddr}
+	glog.V(logger.Warn).Infoln(err)
+	return err
 }
 
 // CalcDifficulty is the difficulty adjustment algorithm. It returns
@@ -509,3 +545,30 @@ func CalcGasLimit(parent *types.Block) *big.Int {
 	}
 	return gl
 }
+
+// CalcRampedGasLimit computes the gas limit of the next block after parent when
+// the chain configures deterministic gas limit ramping. The limit moves toward
+// config.TargetGasLimit by config.GasLimitRampStep, bounded by what header
+// validation permits per block. Unlike CalcGasLimit this is consensus protocol:
+// the result depends only on the parent and the chain configuration.
+func CalcRampedGasLimit(config *ChainConfig, parent *types.Header) *big.Int {
+	// the change per block must stay strictly below parentGasLimit / 4096
+	step := new(big.Int).Div(parent.GasLimit, params.GasLimitBoundDivisor)
+	step.Sub(step, common.Big1)
+	step = common.BigMin(step, config.GasLimitRampStep)
+	if step.Sign() < 0 {
+		step.SetUint64(0)
+	}
+
+	target := common.BigMax(config.TargetGasLimit, params.MinGasLimit)
+	gl := new(big.Int).Set(parent.GasLimit)
+	switch gl.Cmp(target) {
+	case -1:
+		gl.Add(gl, step)
+		gl.Set(common.BigMin(gl, target))
+	case 1:
+		gl.Sub(gl, step)
+		gl.Set(common.BigMax(gl, target))
+	}
+	return gl
+}
@@ -17,16 +17,20 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 func testChainConfig() *ChainConfig {
@@ -64,6 +68,96 @@ func TestNumber(t *testing.T) {
 	}
 }
 
+// Tests that only blocks signed by an account the voting contract at 0x20
+// allows to create blocks in the parent state pass validation.
+func TestValidateBlockMaker(t *testing.T) {
+	_, chain := proc()
+	cfg := testChainConfig()
+
+	makerKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	maker := crypto.PubkeyToAddress(makerKey.PublicKey)
+
+	// Stand-in for isBlockMaker(address), true for the maker only:
+	// return(calldataload(4) == maker)
+	code := append([]byte{0x60, 0x04, 0x35, 0x73}, maker.Bytes()...)
+	code = append(code, 0x14, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3)
+
+	statedb, _ := state.New(common.Hash{}, chain.chainDb)
+	statedb.SetCode(common.HexToAddress("0x0000000000000000000000000000000000000020"), code)
+	root, err := statedb.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent := &types.Header{Number: big.NewInt(0), Root: root}
+
+	sign := func(key *ecdsa.PrivateKey) *types.Header {
+		header := &types.Header{Number: big.NewInt(1), Coinbase: crypto.PubkeyToAddress(key.PublicKey)}
+		sig, err := crypto.Sign(header.QuorumHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header.Extra = sig
+		return header
+	}
+	if err := ValidateExtraData(chain.chainDb, chain, cfg, parent, sign(makerKey)); err != nil {
+		t.Errorf("authorized block maker rejected: %v", err)
+	}
+	if err := ValidateExtraData(chain.chainDb, chain, cfg, parent, sign(otherKey)); !IsUnauthorizedBlockMakerErr(err) {
+		t.Errorf("expected unauthorized block maker error, got %v", err)
+	}
+	missing := &types.Header{Number: big.NewInt(0), Root: common.HexToHash("0x01")}
+	if err := ValidateExtraData(chain.chainDb, chain, cfg, missing, sign(makerKey)); !IsValidationErr(err) {
+		t.Errorf("expected validation error without parent state, got %v", err)
+	}
+}
+
+// Tests that headers imported on their own are verified without the state of
+// their parent, checking the signature only, while full imports still require
+// the parent state to verify the block maker.
+func TestValidateHeaderWithoutParentState(t *testing.T) {
+	_, chain := proc()
+	cfg := testChainConfig()
+	validator := NewBlockValidator(chain.chainDb, cfg, chain, true)
+
+	makerKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	parent := &types.Header{
+		Number:     big.NewInt(0),
+		Time:       big.NewInt(1),
+		Difficulty: params.GenesisDifficulty,
+		GasLimit:   params.GenesisGasLimit,
+		Root:       common.HexToHash("0x01"),
+	}
+	sign := func(key *ecdsa.PrivateKey, coinbase common.Address) *types.Header {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     big.NewInt(1),
+			Time:       big.NewInt(2),
+			Difficulty: CalcDifficulty(cfg, 2, 1, parent.Number, parent.Difficulty),
+			GasLimit:   parent.GasLimit,
+			Coinbase:   coinbase,
+		}
+		sig, err := crypto.Sign(header.QuorumHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header.Extra = sig
+		return header
+	}
+	maker := crypto.PubkeyToAddress(makerKey.PublicKey)
+
+	if err := validator.ValidateHeader(chain.chainDb, sign(makerKey, maker), parent); err != nil {
+		t.Errorf("signed header rejected without parent state: %v", err)
+	}
+	if err := validator.ValidateHeader(chain.chainDb, sign(otherKey, maker), parent); err == nil {
+		t.Errorf("header signed by another account than its coinbase accepted")
+	}
+	if err := ValidateHeader(chain.chainDb, chain, cfg, sign(makerKey, maker), parent, false, true); !IsValidationErr(err) {
+		t.Errorf("expected validation error on full import without parent state, got %v", err)
+	}
+}
+
 func TestPutReceipt(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 
@@ -90,3 +184,111 @@ func TestPutReceipt(t *testing.T) {
 		t.Error("expected to get 1 receipt, got none.")
 	}
 }
+
+func TestCalcRampedGasLimit(t *testing.T) {
+	config := &ChainConfig{
+		TargetGasLimit:   new(big.Int).Add(params.MinGasLimit, big.NewInt(250000)),
+		GasLimitRampStep: big.NewInt(100000),
+	}
+	bound := new(big.Int).Div(params.MinGasLimit, params.GasLimitBoundDivisor)
+	tests := []struct {
+		parent, want *big.Int
+	}{
+		// ramp up by the configured step, stopping at the target
+		{params.MinGasLimit, new(big.Int).Add(params.MinGasLimit, big.NewInt(100000))},
+		{new(big.Int).Add(params.MinGasLimit, big.NewInt(200000)), config.TargetGasLimit},
+		{config.TargetGasLimit, config.TargetGasLimit},
+		// ramp down toward the target
+		{new(big.Int).Add(config.TargetGasLimit, big.NewInt(150000)), new(big.Int).Add(config.TargetGasLimit, big.NewInt(50000))},
+	}
+	for i, tt := range tests {
+		have := CalcRampedGasLimit(config, &types.Header{GasLimit: tt.parent})
+		if have.Cmp(tt.want) != 0 {
+			t.Errorf("test %d: gas limit mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	// steps larger than the validation bound are clamped to it
+	config.GasLimitRampStep = new(big.Int).Mul(bound, big.NewInt(2))
+	config.TargetGasLimit = new(big.Int).Mul(params.MinGasLimit, big.NewInt(2))
+	have := CalcRampedGasLimit(config, &types.Header{GasLimit: params.MinGasLimit})
+	if want := new(big.Int).Add(params.MinGasLimit, new(big.Int).Sub(bound, common.Big1)); have.Cmp(want) != 0 {
+		t.Errorf("clamped gas limit mismatch: have %v, want %v", have, want)
+	}
+}
+
+// Tests that the gas limit ramp is only enforced from its activation block, so
+// chains adopting it keep validating their earlier blocks.
+func TestGasLimitRampActivation(t *testing.T) {
+	_, chain := proc()
+	config := &ChainConfig{
+		HomesteadBlock:    big.NewInt(0),
+		TargetGasLimit:    new(big.Int).Mul(params.MinGasLimit, big.NewInt(2)),
+		GasLimitRampStep:  big.NewInt(1000),
+		GasLimitRampBlock: big.NewInt(10),
+	}
+	if err := config.CheckGasLimitRamp(); err != nil {
+		t.Fatalf("valid ramp rejected: %v", err)
+	}
+
+	// Stand-in for isBlockMaker(address), true for everyone
+	statedb, _ := state.New(common.Hash{}, chain.chainDb)
+	statedb.SetCode(common.HexToAddress("0x0000000000000000000000000000000000000020"), common.FromHex("600160005260206000f3"))
+	root, err := statedb.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := crypto.GenerateKey()
+	child := func(parent *types.Header, gasLimit *big.Int) *types.Header {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, common.Big1),
+			Time:       new(big.Int).Add(parent.Time, big.NewInt(1)),
+			GasLimit:   gasLimit,
+			Coinbase:   crypto.PubkeyToAddress(key.PublicKey),
+			Root:       root,
+		}
+		header.Difficulty = CalcDifficulty(config, header.Time.Uint64(), parent.Time.Uint64(), parent.Number, parent.Difficulty)
+		sig, err := crypto.Sign(header.QuorumHash().Bytes(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header.Extra = sig
+		return header
+	}
+	parent := &types.Header{Number: big.NewInt(8), Time: big.NewInt(1000), Difficulty: params.MinimumDifficulty, GasLimit: params.MinGasLimit, Root: root}
+
+	// Before the activation block the gas limit may stay off the ramp
+	block9 := child(parent, params.MinGasLimit)
+	if err := ValidateHeader(chain.chainDb, chain, config, block9, parent, false, true); err != nil {
+		t.Fatalf("block before the ramp rejected: %v", err)
+	}
+	// From the activation block it has to follow the ramp
+	if err := ValidateHeader(chain.chainDb, chain, config, child(block9, params.MinGasLimit), block9, false, true); err == nil || !strings.Contains(err.Error(), "ramp") {
+		t.Errorf("block off the ramp accepted after activation: %v", err)
+	}
+	ramped := CalcRampedGasLimit(config, block9)
+	if err := ValidateHeader(chain.chainDb, chain, config, child(block9, ramped), block9, false, true); err != nil {
+		t.Errorf("ramped block rejected: %v", err)
+	}
+
+	// Without activation block the ramp applies from genesis
+	config.GasLimitRampBlock = nil
+	if err := ValidateHeader(chain.chainDb, chain, config, block9, parent, false, true); err == nil || !strings.Contains(err.Error(), "ramp") {
+		t.Errorf("block off the ramp accepted without activation block: %v", err)
+	}
+}
+
+// Tests that gas limit ramps activated without a target or step are rejected.
+func TestCheckGasLimitRamp(t *testing.T) {
+	tests := []*ChainConfig{
+		{GasLimitRampBlock: big.NewInt(1)},
+		{TargetGasLimit: big.NewInt(1), GasLimitRampBlock: big.NewInt(1)},
+		{TargetGasLimit: big.NewInt(1), GasLimitRampStep: big.NewInt(1), GasLimitRampBlock: big.NewInt(-1)},
+		{TargetGasLimit: big.NewInt(1), GasLimitRampStep: big.NewInt(-1)},
+	}
+	for i, config := range tests {
+		if err := config.CheckGasLimitRamp(); err == nil {
+			t.Errorf("test %d: invalid ramp accepted", i)
+		}
+	}
+}
@@ -39,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/pow"
+	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/hashicorp/golang-lru"
@@ -50,6 +51,10 @@ var (
 
 	blockInsertTimer = metrics.NewTimer("chain/inserts")
 
+	blockExecutionTimer   = metrics.NewTimer("chain/execution")
+	blockGasHistogram     = metrics.NewHistogram("chain/execution/gas")
+	blockTxCountHistogram = metrics.NewHistogram("chain/execution/txs")
+
 	ErrNoGenesis = errors.New("Genesis not found in chain")
 )
 
@@ -89,12 +94,14 @@ type BlockChain struct {
 	chainmu sync.RWMutex // blockchain insertion lock
 	procmu  sync.RWMutex // block processor lock
 
-	checkpoint       int          // checkpoint counts towards the new checkpoint
-	currentBlock     *types.Block // Current head of the block chain
-	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
+	checkpoint       int           // checkpoint counts towards the new checkpoint
+	currentBlock     *types.Block  // Current head of the block chain
+	currentFastBlock *types.Block  // Current head of the fast-sync chain (may be above the block chain!)
+	repairedHead     *types.Header // Head rewound from on startup as its state was missing, nil if none
 
 	publicStateCache  *state.StateDB // Public state database to reuse between imports (contains state cache)
 	privateStateCache *state.StateDB // Private state database to reuse between imports (contains state cache)
+	flat              *state.Flat    // Flat public state accelerating reads, nil unless enabled
 	bodyCache         *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache      *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	blockCache        *lru.Cache     // Cache for the most recent entire blocks
@@ -166,6 +173,21 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 	return bc, nil
 }
 
+// EnableFlatState keeps a flat copy of the public state at the head of the
+// chain to accelerate account and storage reads, see state.Flat. It's generated
+// in the background if the database doesn't hold one at the head yet.
+func (bc *BlockChain) EnableFlatState() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.flat != nil {
+		return
+	}
+	bc.flat = state.NewFlat(bc.chainDb)
+	bc.publicStateCache.SetFlat(bc.flat)
+	bc.flat.Follow(bc.currentBlock.Root())
+}
+
 func (self *BlockChain) getProcInterrupt() bool {
 	return atomic.LoadInt32(&self.procInterrupt) == 1
 }
@@ -187,6 +209,12 @@ func (self *BlockChain) loadLastState() error {
 			self.Reset()
 		}
 	}
+	// Rewind a head whose state wasn't completely written, e.g. on power loss
+	if !self.hasState(self.currentBlock) {
+		if err := self.repairHead(); err != nil {
+			return err
+		}
+	}
 	// Restore the last known head header
 	currentHeader := self.currentBlock.Header()
 	if head := GetHeadHeaderHash(self.chainDb); head != (common.Hash{}) {
@@ -208,10 +236,14 @@ func (self *BlockChain) loadLastState() error {
 		return err
 	}
 	self.publicStateCache = statedb
+	if self.flat != nil {
+		self.publicStateCache.SetFlat(self.flat)
+		self.flat.Follow(self.currentBlock.Root())
+	}
 	self.publicStateCache.GetAccount(common.Address{})
 
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	self.privateStateCache, err = state.New(GetPrivateStateRoot(self.chainDb, self.currentBlock.Hash()), self.chainDb)
+	self.privateStateCache, err = state.New(GetPrivateStateRoot(self.chainDb, self.currentBlock.Root()), self.chainDb)
 	if err != nil {
 		return err
 	}
@@ -229,6 +261,61 @@ func (self *BlockChain) loadLastState() error {
 	return nil
 }
 
+// hasState reports whether the public and private state of the block are
+// present. Only the root nodes of the state tries are checked.
+func (self *BlockChain) hasState(block *types.Block) bool {
+	if _, err := state.New(block.Root(), self.chainDb); err != nil {
+		return false
+	}
+	_, err := state.New(GetPrivateStateRoot(self.chainDb, block.Root()), self.chainDb)
+	return err == nil
+}
+
+// repairHead rewinds the chain to the newest ancestor of the head block with
+// complete state. Blocks are written along with their state, but abrupt power
+// loss can leave the head without it, on which the node can't start. The headers
+// and bodies above the new head are deleted, so that the blocks are synced from
+// peers or replayed from the raft log again.
+func (self *BlockChain) repairHead() error {
+	lost := self.currentBlock
+	head := lost
+	for !self.hasState(head) {
+		if head.NumberU64() == 0 {
+			return fmt.Errorf("state of genesis block [%x…] missing", head.Hash().Bytes()[:4])
+		}
+		parent := self.GetBlock(head.ParentHash(), head.NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("parent of block #%d [%x…] missing while repairing chain", head.Number(), head.Hash().Bytes()[:4])
+		}
+		head = parent
+	}
+	glog.V(logger.Error).Infof("State of head block #%d [%x…] missing, rewinding chain to block #%d [%x…]", lost.Number(), lost.Hash().Bytes()[:4], head.Number(), head.Hash().Bytes()[:4])
+
+	self.hc.SetHead(head.NumberU64(), func(hash common.Hash, num uint64) {
+		DeleteBody(self.chainDb, hash, num)
+	})
+	self.bodyCache.Purge()
+	self.bodyRLPCache.Purge()
+	self.blockCache.Purge()
+
+	self.currentBlock = head
+	self.repairedHead = lost.Header()
+	if err := WriteHeadBlockHash(self.chainDb, head.Hash()); err != nil {
+		return err
+	}
+	if err := WriteHeadFastBlockHash(self.chainDb, head.Hash()); err != nil {
+		return err
+	}
+	glog.V(logger.Error).Infof("Chain repair was successful, dropped %d blocks", lost.NumberU64()-head.NumberU64())
+	return nil
+}
+
+// RepairedHead returns the head block the chain was rewound from on startup
+// because its state was missing, or nil if it wasn't.
+func (self *BlockChain) RepairedHead() *types.Header {
+	return self.repairedHead
+}
+
 // SetHead rewinds the local chain to a new head. In the case of headers, everything
 // above the new head will be deleted and the new one set. In the case of blocks
 // though, the head may be further rewound if block bodies are missing (non-archive
@@ -287,6 +374,9 @@ func (self *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	// If all checks out, manually set the head block
 	self.mu.Lock()
 	self.currentBlock = block
+	if self.flat != nil {
+		self.flat.Follow(block.Root())
+	}
 	self.mu.Unlock()
 
 	glog.V(logger.Info).Infof("committed block #%d [%x…] as new head", block.Number(), hash[:4])
@@ -463,6 +553,9 @@ func (bc *BlockChain) insert(block *types.Block) {
 		glog.Fatalf("failed to insert head block hash: %v", err)
 	}
 	bc.currentBlock = block
+	if bc.flat != nil {
+		bc.flat.Follow(block.Root())
+	}
 
 	// If the block is better than out head or is on a different chain, force update heads
 	if updateHeads {
@@ -600,6 +693,9 @@ func (bc *BlockChain) Stop() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
+	if bc.flat != nil {
+		bc.flat.Stop()
+	}
 
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
@@ -935,11 +1031,20 @@ func (self *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 			return i, err
 		}
 		// Process block using the parent state as reference point.
+		pstart := time.Now()
 		publicReceipts, privateReceipts, logs, usedGas, err := self.processor.Process(block, self.publicStateCache, self.privateStateCache, self.config.VmConfig)
+		if private.IsPayloadUnavailable(err) {
+			// Not a bad block, it can be imported once the payload is available
+			glog.V(logger.Warn).Infof("Postponing block #%v (%s): %v", block.Number(), block.Hash().Hex(), err)
+			return i, err
+		}
 		if err != nil {
 			reportBlock(block, err)
 			return i, err
 		}
+		blockExecutionTimer.UpdateSince(pstart)
+		blockGasHistogram.Update(usedGas.Int64())
+		blockTxCountHistogram.Update(int64(len(block.Transactions())))
 
 		// Validate the state using the default validator
 		err = self.Validator().ValidateState(block, self.GetBlock(block.ParentHash(), block.NumberU64()-1), self.publicStateCache, publicReceipts, usedGas)
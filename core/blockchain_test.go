@@ -1058,3 +1058,59 @@ func TestCanonicalBlockRetrieval(t *testing.T) {
 		blockchain.InsertChain(types.Blocks{chain[i]})
 	}
 }
+
+// Tests that a head block whose state is missing, e.g. after power loss, is
+// rewound on startup to the newest block with complete state.
+func TestRepairHeadMissingState(t *testing.T) {
+	var (
+		gendb, _ = ethdb.NewMemDatabase()
+		genesis  = WriteGenesisBlockForTesting(gendb)
+		db, _    = ethdb.NewMemDatabase()
+	)
+	WriteGenesisBlockForTesting(db)
+	blocks, _ := GenerateChain(nil, genesis, gendb, 10, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if blockchain.RepairedHead() != nil {
+		t.Fatalf("chain with complete state was repaired")
+	}
+	blockchain.Stop()
+
+	// Drop the state of the two newest blocks and reopen the chain
+	for _, block := range blocks[8:] {
+		if err := db.Delete(block.Root().Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blockchain, err := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != blocks[7].Hash() {
+		t.Errorf("head mismatch: have #%d, want #%d", head.Number(), blocks[7].Number())
+	}
+	if header := blockchain.CurrentHeader(); header.Hash() != blocks[7].Hash() {
+		t.Errorf("head header mismatch: have #%d, want #%d", header.Number, blocks[7].Number())
+	}
+	if lost := blockchain.RepairedHead(); lost == nil || lost.Hash() != blocks[9].Hash() {
+		t.Errorf("repaired head mismatch: have %v, want #%d", lost, blocks[9].Number())
+	}
+	for _, block := range blocks[8:] {
+		if blockchain.HasBlock(block.Hash()) {
+			t.Errorf("block #%d above the repaired head still present", block.Number())
+		}
+	}
+	// The dropped blocks can be imported again
+	if n, err := blockchain.InsertChain(blocks[8:]); err != nil {
+		t.Fatalf("failed to reinsert block %d: %v", n, err)
+	}
+	if head := blockchain.CurrentBlock(); head.Hash() != blocks[9].Hash() {
+		t.Errorf("head mismatch after reinsert: have #%d, want #%d", head.Number(), blocks[9].Number())
+	}
+}
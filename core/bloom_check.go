@@ -0,0 +1,154 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Kinds of bloom inconsistencies found by CheckBlooms.
+const (
+	BloomMissingReceipts = "missing receipts" // Fewer receipts than transactions, not repairable
+	BloomHeader          = "header bloom"     // Public receipts don't match the header, not repairable
+	BloomReceipt         = "receipt bloom"    // Stored bloom of a receipt doesn't match its logs
+	BloomPrivate         = "private bloom"    // Private block bloom doesn't match the private receipts
+	BloomMipmap          = "mipmap bloom"     // Log addresses missing from the bloom index
+)
+
+// BloomFault is a bloom of a block which is inconsistent with its receipts.
+type BloomFault struct {
+	Number   uint64
+	Hash     common.Hash
+	Kind     string
+	Detail   string
+	Repaired bool
+}
+
+func (f *BloomFault) String() string {
+	status := ""
+	if f.Repaired {
+		status = " (repaired)"
+	}
+	return fmt.Sprintf("block #%d [%x…]: %s: %s%s", f.Number, f.Hash.Bytes()[:4], f.Kind, f.Detail, status)
+}
+
+// CheckBlooms verifies the blooms of the canonical blocks from first to last
+// against their stored receipts: the bloom of every receipt, the header bloom,
+// the private block bloom and the bloom index (mipmap bins) used by log
+// filters. With repair set, the receipt and private blooms are rewritten and
+// the missing addresses added to the bloom index.
+//
+// The header bloom is part of consensus and is only checked, a mismatch means
+// the stored receipts are damaged and the blocks have to be imported again.
+func CheckBlooms(db ethdb.Database, first, last uint64, repair bool) ([]*BloomFault, error) {
+	var faults []*BloomFault
+	for number := first; number <= last; number++ {
+		hash := GetCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			return faults, fmt.Errorf("canonical block #%d not found", number)
+		}
+		block := GetBlock(db, hash, number)
+		if block == nil {
+			return faults, fmt.Errorf("block #%d [%x…] not found", number, hash[:4])
+		}
+		blockFaults, err := checkBlockBlooms(db, block, repair)
+		if err != nil {
+			return faults, err
+		}
+		faults = append(faults, blockFaults...)
+	}
+	return faults, nil
+}
+
+// checkBlockBlooms verifies and optionally repairs the blooms of a block.
+func checkBlockBlooms(db ethdb.Database, block *types.Block, repair bool) ([]*BloomFault, error) {
+	var (
+		number   = block.NumberU64()
+		hash     = block.Hash()
+		receipts = GetBlockReceipts(db, hash, number)
+		txs      = len(block.Transactions())
+		faults   []*BloomFault
+	)
+	fault := func(kind, format string, args ...interface{}) *BloomFault {
+		f := &BloomFault{Number: number, Hash: hash, Kind: kind, Detail: fmt.Sprintf(format, args...)}
+		faults = append(faults, f)
+		return f
+	}
+	if len(receipts) < txs {
+		fault(BloomMissingReceipts, "%d receipts for %d transactions", len(receipts), txs)
+		return faults, nil
+	}
+	// Block receipts hold the public receipts of all transactions, followed by
+	// the private receipts of the private transactions the node is party to
+	var receiptFaults []*BloomFault
+	for i, receipt := range receipts {
+		if want := types.BytesToBloom(types.LogsBloom(receipt.Logs).Bytes()); receipt.Bloom != want {
+			receiptFaults = append(receiptFaults, fault(BloomReceipt, "receipt %d of transaction %x", i, receipt.TxHash))
+			receipt.Bloom = want
+		}
+	}
+	if repair && len(receiptFaults) > 0 {
+		if err := WriteBlockReceipts(db, hash, number, receipts); err != nil {
+			return faults, err
+		}
+		if err := WriteReceipts(db, receipts); err != nil {
+			return faults, err
+		}
+		for _, f := range receiptFaults {
+			f.Repaired = true
+		}
+	}
+	if bloom := types.CreateBloom(receipts[:txs]); bloom != block.Bloom() {
+		fault(BloomHeader, "public receipts don't match the header")
+	}
+	if bloom := types.CreateBloom(receipts[txs:]); bloom != GetPrivateBlockBloom(db, number) {
+		f := fault(BloomPrivate, "private receipts don't match the private bloom")
+		if repair {
+			if err := WritePrivateBlockBloom(db, number, receipts[txs:]); err != nil {
+				return faults, err
+			}
+			f.Repaired = true
+		}
+	}
+	missing := 0
+	for _, level := range MIPMapLevels {
+		bloom := GetMipmapBloom(db, number, level)
+		for _, receipt := range receipts {
+			for _, log := range receipt.Logs {
+				if !bloom.TestBytes(log.Address[:]) {
+					missing++
+				}
+			}
+		}
+	}
+	if missing > 0 {
+		f := fault(BloomMipmap, "%d log addresses missing from the bloom index", missing)
+		if repair {
+			// Bins are only ever added to, so adding the addresses of the block
+			// again doesn't affect the other blocks of the bins
+			if err := WriteMipmapBloom(db, number, receipts); err != nil {
+				return faults, err
+			}
+			f.Repaired = true
+		}
+	}
+	return faults, nil
+}
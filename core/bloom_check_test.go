@@ -0,0 +1,96 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that damaged receipt, private and index blooms are detected and
+// repaired, and that damage to the receipts themselves is only reported.
+func TestCheckBlooms(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000)})
+	)
+	// Emit a log from the constructor of a contract in every block
+	blocks, _ := GenerateChain(nil, genesis, db, 3, func(i int, gen *BlockGen) {
+		tx, _ := types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), nil, common.FromHex("60006000a0")).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	chain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if faults, err := CheckBlooms(db, 0, 3, false); err != nil || len(faults) != 0 {
+		t.Fatalf("faults in intact chain: %v %v", faults, err)
+	}
+	// Damage the receipt bloom of block 1, the private bloom of block 2 and
+	// the bloom index, whose bins are shared by all blocks
+	receipts := GetBlockReceipts(db, blocks[0].Hash(), 1)
+	receipts[0].Bloom = types.Bloom{}
+	WriteBlockReceipts(db, blocks[0].Hash(), 1, receipts)
+	WritePrivateBlockBloom(db, 2, GetBlockReceipts(db, blocks[1].Hash(), 2))
+	for _, level := range MIPMapLevels {
+		db.Put(mipmapKey(3, level), nil)
+	}
+	want := []string{BloomReceipt, BloomMipmap, BloomPrivate, BloomMipmap, BloomMipmap}
+
+	faults, err := CheckBlooms(db, 1, 3, false)
+	if err != nil {
+		t.Fatalf("failed to check blooms: %v", err)
+	}
+	if len(faults) != len(want) {
+		t.Fatalf("fault count mismatch: have %v, want %v", faults, want)
+	}
+	for i, fault := range faults {
+		if fault.Kind != want[i] || fault.Repaired {
+			t.Errorf("fault %d: have %v, want %s", i, fault, want[i])
+		}
+	}
+	if faults, err = CheckBlooms(db, 1, 3, true); err != nil || len(faults) != len(want) {
+		t.Fatalf("failed to repair blooms: %v %v", faults, err)
+	}
+	for _, fault := range faults {
+		if !fault.Repaired {
+			t.Errorf("fault not repaired: %v", fault)
+		}
+	}
+	if faults, err := CheckBlooms(db, 0, 3, false); err != nil || len(faults) != 0 {
+		t.Fatalf("faults after repair: %v %v", faults, err)
+	}
+	// Receipts without logs don't match the header, which can't be repaired
+	receipts = GetBlockReceipts(db, blocks[0].Hash(), 1)
+	receipts[0].Logs = nil
+	receipts[0].Bloom = types.Bloom{}
+	WriteBlockReceipts(db, blocks[0].Hash(), 1, receipts)
+	if faults, _ := CheckBlooms(db, 1, 1, true); len(faults) != 1 || faults[0].Kind != BloomHeader || faults[0].Repaired {
+		t.Errorf("header mismatch not reported: %v", faults)
+	}
+	if _, err := CheckBlooms(db, 3, 4, false); err == nil {
+		t.Errorf("missing block not reported")
+	}
+}
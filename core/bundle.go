@@ -0,0 +1,195 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// configBundleDomain separates the hashes signed for config bundles from any
+// other data signed with the same key.
+var configBundleDomain = []byte("quorum config bundle")
+
+var errConfigBundleUntrusted = errors.New("config bundle not signed by a trusted key")
+
+// ConfigBundle is the configuration a member needs to join a network: the
+// genesis block, the chain config and the static and permissioned nodes,
+// signed by a consortium key like node manifests. Members initialise their
+// node from the bundle only if it's signed by a key they trust, so a tampered
+// genesis or node list is never applied.
+//
+// The chain config replaces the one of the genesis, also on nodes initialised
+// before, e.g. to schedule a fork. Nil node lists leave the corresponding
+// nodes of a node unchanged, empty lists remove all of them.
+type ConfigBundle struct {
+	Version           uint64          // Increases with every published bundle
+	Genesis           json.RawMessage // Genesis JSON, as given to geth init
+	ChainConfig       *ChainConfig    // Chain config replacing the one of the genesis (nil = keep)
+	StaticNodes       []string        // Enode URLs of the nodes to stay connected to
+	PermissionedNodes []string        // Enode URLs of the nodes admitted by node permissioning
+	Sig               []byte          // Signature of the consortium key
+}
+
+type jsonConfigBundle struct {
+	Version           uint64          `json:"version"`
+	Genesis           json.RawMessage `json:"genesis"`
+	ChainConfig       *ChainConfig    `json:"chainConfig,omitempty"`
+	StaticNodes       []string        `json:"staticNodes"`
+	PermissionedNodes []string        `json:"permissionedNodes"`
+	Sig               string          `json:"signature,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b *ConfigBundle) MarshalJSON() ([]byte, error) {
+	enc := &jsonConfigBundle{
+		Version:           b.Version,
+		Genesis:           b.Genesis,
+		ChainConfig:       b.ChainConfig,
+		StaticNodes:       b.StaticNodes,
+		PermissionedNodes: b.PermissionedNodes,
+	}
+	if b.Sig != nil {
+		enc.Sig = common.ToHex(b.Sig)
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *ConfigBundle) UnmarshalJSON(input []byte) error {
+	var dec jsonConfigBundle
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	b.Version, b.Genesis, b.ChainConfig = dec.Version, dec.Genesis, dec.ChainConfig
+	b.StaticNodes, b.PermissionedNodes, b.Sig = dec.StaticNodes, dec.PermissionedNodes, common.FromHex(dec.Sig)
+	return nil
+}
+
+func (b *ConfigBundle) sigHash() ([]byte, error) {
+	// The genesis is re-encoded whenever the bundle is, so its compact form is
+	// signed rather than the bytes of a particular file.
+	genesis := new(bytes.Buffer)
+	if err := json.Compact(genesis, b.Genesis); err != nil {
+		return nil, fmt.Errorf("invalid genesis: %v", err)
+	}
+	var config []byte
+	if b.ChainConfig != nil {
+		var err error
+		if config, err = json.Marshal(b.ChainConfig); err != nil {
+			return nil, err
+		}
+	}
+	// Nil and empty lists differ in meaning, so their presence is signed too
+	enc, err := rlp.EncodeToBytes([]interface{}{b.Version, genesis.Bytes(), config, b.StaticNodes != nil, b.StaticNodes, b.PermissionedNodes != nil, b.PermissionedNodes})
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(configBundleDomain, enc), nil
+}
+
+// Sign signs the bundle with a consortium key.
+func (b *ConfigBundle) Sign(key *ecdsa.PrivateKey) error {
+	hash, err := b.sigHash()
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(hash, key)
+	if err != nil {
+		return err
+	}
+	b.Sig = sig
+	return nil
+}
+
+// Signer returns the address of the key which signed the bundle.
+func (b *ConfigBundle) Signer() (common.Address, error) {
+	if len(b.Sig) != 65 {
+		return common.Address{}, errors.New("invalid config bundle signature")
+	}
+	hash, err := b.sigHash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash, b.Sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify checks that the bundle was signed by one of the trusted keys.
+func (b *ConfigBundle) Verify(signers []common.Address) error {
+	signer, err := b.Signer()
+	if err != nil {
+		return err
+	}
+	for _, trusted := range signers {
+		if signer == trusted {
+			return nil
+		}
+	}
+	return errConfigBundleUntrusted
+}
+
+// Check validates the contents of the bundle without applying them: the
+// genesis block is built in memory and every enode URL parsed. It returns the
+// genesis block the bundle initialises nodes with.
+func (b *ConfigBundle) Check() (*types.Block, error) {
+	if len(b.Genesis) == 0 {
+		return nil, errors.New("no genesis in config bundle")
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := WriteGenesisBlock(db, bytes.NewReader(b.Genesis))
+	if err != nil {
+		return nil, fmt.Errorf("invalid genesis: %v", err)
+	}
+	for _, url := range b.StaticNodes {
+		if _, err := discover.ParseNode(url); err != nil {
+			return nil, fmt.Errorf("static node %s: %v", url, err)
+		}
+	}
+	for _, url := range b.PermissionedNodes {
+		if _, err := discover.ParseNode(url); err != nil {
+			return nil, fmt.Errorf("permissioned node %s: %v", url, err)
+		}
+	}
+	return genesis, nil
+}
+
+// WriteGenesis writes the genesis block of the bundle and its chain config
+// into a chain database.
+func (b *ConfigBundle) WriteGenesis(db ethdb.Database) (*types.Block, error) {
+	genesis, err := WriteGenesisBlock(db, bytes.NewReader(b.Genesis))
+	if err != nil {
+		return nil, err
+	}
+	if err := WriteChainConfig(db, genesis.Hash(), b.ChainConfig); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const bundleTestGenesis = `{
+	"config": {"homesteadBlock": 0},
+	"gasLimit": "0x2fefd8",
+	"difficulty": "0x400",
+	"alloc": {"0x9186eb3d20cbd1f5f992a950d808c4495153abd5": {"balance": "1000"}}
+}`
+
+// Tests that signed bundles survive encoding and are only accepted from the
+// trusted keys, unmodified.
+func TestConfigBundleVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	bundle := &ConfigBundle{
+		Version:           7,
+		Genesis:           json.RawMessage(bundleTestGenesis),
+		ChainConfig:       &ChainConfig{HomesteadBlock: big.NewInt(0), ByzantiumBlock: big.NewInt(100)},
+		PermissionedNodes: []string{"enode://6598638a7c4a3ee05bbd2f1d1a0c6a4e48e36d0b0bd3f7b1a2d3b6ad9c0b7ff9e86b8b1b5e69e3c86b1c9b9a8cb5e4c3f1d5a3bf39fa7f3a9fbb36c0a13dcc6c@10.0.0.7:30303"},
+	}
+	if _, err := bundle.Check(); err != nil {
+		t.Fatalf("valid bundle rejected: %v", err)
+	}
+	if err := bundle.Sign(key); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+	// Round trip through JSON, the genesis is compacted on the way
+	blob, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	decoded := new(ConfigBundle)
+	if err := json.Unmarshal(blob, decoded); err != nil {
+		t.Fatalf("failed to decode bundle: %v", err)
+	}
+	if err := decoded.Verify([]common.Address{signer}); err != nil {
+		t.Errorf("signed bundle rejected: %v", err)
+	}
+	if err := decoded.Verify([]common.Address{crypto.PubkeyToAddress(other.PublicKey)}); err != errConfigBundleUntrusted {
+		t.Errorf("untrusted signer: have %v, want %v", err, errConfigBundleUntrusted)
+	}
+
+	// Any change invalidates the signature
+	tampered := *decoded
+	tampered.StaticNodes = []string{}
+	if err := tampered.Verify([]common.Address{signer}); err == nil {
+		t.Error("bundle with added static nodes accepted")
+	}
+	tampered = *decoded
+	tampered.Genesis = json.RawMessage(`{"gasLimit": "0x2fefd8", "difficulty": "0x400", "alloc": {}}`)
+	if err := tampered.Verify([]common.Address{signer}); err == nil {
+		t.Error("bundle with replaced genesis accepted")
+	}
+	tampered = *decoded
+	tampered.ChainConfig = &ChainConfig{HomesteadBlock: big.NewInt(0)}
+	if err := tampered.Verify([]common.Address{signer}); err == nil {
+		t.Error("bundle with replaced chain config accepted")
+	}
+}
+
+// Tests that applying a bundle writes its genesis and replaces the chain config.
+func TestConfigBundleWriteGenesis(t *testing.T) {
+	bundle := &ConfigBundle{
+		Genesis:     json.RawMessage(bundleTestGenesis),
+		ChainConfig: &ChainConfig{HomesteadBlock: big.NewInt(0), ByzantiumBlock: big.NewInt(100)},
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := bundle.WriteGenesis(db)
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	checked, _ := bundle.Check()
+	if genesis.Hash() != checked.Hash() {
+		t.Errorf("genesis mismatch: wrote %x, checked %x", genesis.Hash(), checked.Hash())
+	}
+	config, err := GetChainConfig(db, genesis.Hash())
+	if err != nil {
+		t.Fatalf("failed to read chain config: %v", err)
+	}
+	if config.ByzantiumBlock == nil || config.ByzantiumBlock.Uint64() != 100 {
+		t.Errorf("chain config not replaced: %+v", config)
+	}
+
+	bundle.StaticNodes = []string{"enode://invalid"}
+	if _, err := bundle.Check(); err == nil {
+		t.Error("bundle with an invalid static node passed the check")
+	}
+}
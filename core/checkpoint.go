@@ -0,0 +1,58 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var trustedCheckpointKey = []byte("trusted-checkpoint") // trustedCheckpointKey -> checkpoint (json)
+
+// TrustedCheckpoint is the block the node was synced to without validating the
+// chain up to it. It's kept as an audit trail of the trust placed in the peers.
+type TrustedCheckpoint struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Time   time.Time   `json:"time"` // When the node synced to the checkpoint
+}
+
+// GetTrustedCheckpoint retrieves the trusted checkpoint the node was synced to,
+// nil if the whole chain was validated.
+func GetTrustedCheckpoint(db ethdb.Database) *TrustedCheckpoint {
+	data, _ := db.Get(trustedCheckpointKey)
+	if len(data) == 0 {
+		return nil
+	}
+	checkpoint := new(TrustedCheckpoint)
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil
+	}
+	return checkpoint
+}
+
+// WriteTrustedCheckpoint records the trusted checkpoint the node was synced to.
+func WriteTrustedCheckpoint(db ethdb.Database, checkpoint *TrustedCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	return db.Put(trustedCheckpointKey, data)
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that the trusted checkpoint can be stored and retrieved.
+func TestTrustedCheckpointStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	if checkpoint := GetTrustedCheckpoint(db); checkpoint != nil {
+		t.Fatalf("non-existent checkpoint returned: %+v", checkpoint)
+	}
+	checkpoint := &TrustedCheckpoint{Number: 1000, Hash: common.HexToHash("0x0a"), Time: time.Unix(1500000000, 0).UTC()}
+	if err := WriteTrustedCheckpoint(db, checkpoint); err != nil {
+		t.Fatalf("failed to write checkpoint: %v", err)
+	}
+	if stored := GetTrustedCheckpoint(db); stored == nil || *stored != *checkpoint {
+		t.Fatalf("checkpoint mismatch: have %+v, want %+v", stored, checkpoint)
+	}
+}
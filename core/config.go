@@ -18,6 +18,8 @@ package core
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -40,9 +42,89 @@ type ChainConfig struct {
 	HomesteadGasRepriceBlock *big.Int    `json:"homesteadGasRepriceBlock"` // Homestead gas reprice switch block (nil = no fork)
 	HomesteadGasRepriceHash  common.Hash `json:"homesteadGasRepriceHash"`  // Homestead gas reprice switch block hash (fast sync aid)
 
+	// Quorum chain gas limit ramping. If both are set, block makers move the gas
+	// limit toward the target by a fixed step per block instead of following the
+	// usage based miner strategy, so that all block makers agree on every limit.
+	TargetGasLimit    *big.Int `json:"targetGasLimit,omitempty"`    // Gas limit to ramp toward
+	GasLimitRampStep  *big.Int `json:"gasLimitRampStep,omitempty"`  // Maximum gas limit change per block
+	GasLimitRampBlock *big.Int `json:"gasLimitRampBlock,omitempty"` // Block the ramp applies from (nil = genesis)
+
+	// Byzantium changes. Quorum implements the REVERT opcode and the receipt
+	// status field, which replaces the intermediate state root in receipts.
+	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` // Byzantium switch block (nil = no fork)
+
+	// EIP-155 replay protection. Transactions signed for another chain are
+	// rejected once it is enabled. Chain ID 1 is reserved, as its replay
+	// protected signatures can't be told apart from private transactions.
+	ChainId     *big.Int `json:"chainId,omitempty"`     // Chain ID replay protected transactions are signed for
+	EIP155Block *big.Int `json:"eip155Block,omitempty"` // EIP-155 switch block (nil = no fork)
+
+	// Custom precompiled contracts, registered with vm.RegisterPrecompile and
+	// activated at a fixed address from a given block.
+	Precompiles []PrecompileConfig `json:"precompiles,omitempty"`
+
+	// Gas price overrides agreed by the consortium, applied on top of the gas
+	// table of the phase from their block on, in order.
+	GasOverrides []GasOverride `json:"gasOverrides,omitempty"`
+
+	// Limit on the size of deployed contract code. Private networks may pick
+	// any limit, or none at all, instead of the 24KB of the public network.
+	CodeSizeLimit      uint64   `json:"maxCodeSize,omitempty"`      // Maximum code size in bytes (0 = no limit)
+	CodeSizeLimitBlock *big.Int `json:"maxCodeSizeBlock,omitempty"` // Block the limit is enforced from (nil = genesis)
+
+	// EVM limits for networks whose contracts legitimately need deeper call
+	// chains or larger stacks than the public network allows, or which cap the
+	// memory of a call frame as gas is free.
+	CallDepthLimit uint64   `json:"maxCallDepth,omitempty"`   // Maximum call and create depth (0 = 1024)
+	StackLimit     uint64   `json:"maxStackSize,omitempty"`   // Maximum stack items of a call frame (0 = 1024)
+	MemoryLimit    uint64   `json:"maxMemorySize,omitempty"`  // Maximum memory of a call frame in bytes (0 = bounded by gas only)
+	EVMLimitsBlock *big.Int `json:"evmLimitsBlock,omitempty"` // Block the limits apply from (nil = genesis)
+
 	VmConfig vm.Config `json:"-"`
 }
 
+// GasOverride replaces prices of the gas table from a given block. Prices which
+// aren't set keep their previous value.
+type GasOverride struct {
+	Block *big.Int `json:"block"` // Activation block
+
+	ExtcodeSize  *big.Int `json:"extcodeSize,omitempty"`
+	ExtcodeCopy  *big.Int `json:"extcodeCopy,omitempty"`
+	Balance      *big.Int `json:"balance,omitempty"`
+	SLoad        *big.Int `json:"sload,omitempty"`
+	Calls        *big.Int `json:"calls,omitempty"`
+	Suicide      *big.Int `json:"suicide,omitempty"`
+	SstoreSet    *big.Int `json:"sstoreSet,omitempty"`
+	SstoreReset  *big.Int `json:"sstoreReset,omitempty"`
+	SstoreClear  *big.Int `json:"sstoreClear,omitempty"`
+	SstoreRefund *big.Int `json:"sstoreRefund,omitempty"`
+	CreateData   *big.Int `json:"createData,omitempty"`
+}
+
+// prices returns the prices set by the override, keyed by their JSON name.
+func (o *GasOverride) prices() map[string]*big.Int {
+	return map[string]*big.Int{
+		"extcodeSize":  o.ExtcodeSize,
+		"extcodeCopy":  o.ExtcodeCopy,
+		"balance":      o.Balance,
+		"sload":        o.SLoad,
+		"calls":        o.Calls,
+		"suicide":      o.Suicide,
+		"sstoreSet":    o.SstoreSet,
+		"sstoreReset":  o.SstoreReset,
+		"sstoreClear":  o.SstoreClear,
+		"sstoreRefund": o.SstoreRefund,
+		"createData":   o.CreateData,
+	}
+}
+
+// PrecompileConfig activates a registered custom precompiled contract.
+type PrecompileConfig struct {
+	Name    string         `json:"name"`    // Name the contract is registered under
+	Address common.Address `json:"address"` // Address the contract is called at
+	Block   *big.Int       `json:"block"`   // Activation block
+}
+
 // IsHomestead returns whether num is either equal to the homestead block or greater.
 func (c *ChainConfig) IsHomestead(num *big.Int) bool {
 	if c.HomesteadBlock == nil || num == nil {
@@ -51,13 +133,232 @@ func (c *ChainConfig) IsHomestead(num *big.Int) bool {
 	return num.Cmp(c.HomesteadBlock) >= 0
 }
 
+// IsByzantium returns whether num is either equal to the Byzantium block or greater.
+func (c *ChainConfig) IsByzantium(num *big.Int) bool {
+	if c.ByzantiumBlock == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.ByzantiumBlock) >= 0
+}
+
+// IsEIP155 returns whether num is either equal to the EIP-155 block or greater.
+func (c *ChainConfig) IsEIP155(num *big.Int) bool {
+	if c.EIP155Block == nil || c.ChainId == nil || num == nil {
+		return false
+	}
+	return num.Cmp(c.EIP155Block) >= 0
+}
+
+// CheckChainId returns an error if the configured chain ID can't be used for
+// replay protection.
+func (c *ChainConfig) CheckChainId() error {
+	if c.EIP155Block == nil {
+		return nil
+	}
+	if c.ChainId == nil {
+		return errors.New("eip155Block is set without a chainId")
+	}
+	if c.ChainId.Sign() <= 0 || c.ChainId.Cmp(big.NewInt(1)) == 0 {
+		return fmt.Errorf("invalid chainId %v: must be positive and not 1", c.ChainId)
+	}
+	return nil
+}
+
+// CheckPrecompiles returns an error if a configured custom precompiled contract
+// isn't registered or can't be activated at its address.
+func (c *ChainConfig) CheckPrecompiles() error {
+	addresses := make(map[common.Address]bool)
+	for _, p := range c.Precompiles {
+		if vm.CustomPrecompile(p.Name) == nil {
+			return fmt.Errorf("precompiled contract %q isn't available in this build", p.Name)
+		}
+		if p.Block == nil || p.Block.Sign() < 0 {
+			return fmt.Errorf("precompiled contract %q has no valid activation block", p.Name)
+		}
+		if p.Address == (common.Address{}) || vm.Precompiled[string(p.Address[:])] != nil {
+			return fmt.Errorf("precompiled contract %q can't be activated at reserved address %x", p.Name, p.Address)
+		}
+		if addresses[p.Address] {
+			return fmt.Errorf("precompiled contract %q is at the address of another one (%x)", p.Name, p.Address)
+		}
+		addresses[p.Address] = true
+	}
+	return nil
+}
+
+// Precompile returns the custom precompiled contract active at the address in
+// block num, nil if there is none.
+func (c *ChainConfig) Precompile(addr common.Address, num *big.Int) vm.PrecompiledContract {
+	if num == nil {
+		return nil
+	}
+	for _, p := range c.Precompiles {
+		if p.Address == addr && p.Block != nil && num.Cmp(p.Block) >= 0 {
+			return vm.CustomPrecompile(p.Name)
+		}
+	}
+	return nil
+}
+
+// apply replaces the prices set by the override in the gas table.
+func (o *GasOverride) apply(table *params.GasTable) {
+	set := func(price **big.Int, override *big.Int) {
+		if override != nil {
+			*price = override
+		}
+	}
+	set(&table.ExtcodeSize, o.ExtcodeSize)
+	set(&table.ExtcodeCopy, o.ExtcodeCopy)
+	set(&table.Balance, o.Balance)
+	set(&table.SLoad, o.SLoad)
+	set(&table.Calls, o.Calls)
+	set(&table.Suicide, o.Suicide)
+	set(&table.SstoreSet, o.SstoreSet)
+	set(&table.SstoreReset, o.SstoreReset)
+	set(&table.SstoreClear, o.SstoreClear)
+	set(&table.SstoreRefund, o.SstoreRefund)
+	set(&table.CreateData, o.CreateData)
+}
+
+// CheckGasOverrides returns an error if the gas overrides aren't ordered by
+// block or set invalid prices.
+func (c *ChainConfig) CheckGasOverrides() error {
+	maxPrice := new(big.Int).SetUint64(math.MaxUint32)
+	for i, o := range c.GasOverrides {
+		if o.Block == nil || o.Block.Sign() < 0 {
+			return fmt.Errorf("gas override %d has no valid block", i)
+		}
+		if i > 0 && o.Block.Cmp(c.GasOverrides[i-1].Block) <= 0 {
+			return fmt.Errorf("gas override %d at block %v isn't after the previous one", i, o.Block)
+		}
+		set := 0
+		for name, price := range o.prices() {
+			if price == nil {
+				continue
+			}
+			if price.Sign() < 0 || price.Cmp(maxPrice) > 0 {
+				return fmt.Errorf("gas override %d: invalid %s price %v", i, name, price)
+			}
+			set++
+		}
+		if set == 0 {
+			return fmt.Errorf("gas override %d at block %v sets no prices", i, o.Block)
+		}
+	}
+	return nil
+}
+
+// CheckMaxCodeSize validates the contract code size limit.
+func (c *ChainConfig) CheckMaxCodeSize() error {
+	if c.CodeSizeLimit > math.MaxInt32 {
+		return fmt.Errorf("invalid maxCodeSize %d", c.CodeSizeLimit)
+	}
+	if c.CodeSizeLimitBlock != nil {
+		if c.CodeSizeLimit == 0 {
+			return fmt.Errorf("maxCodeSizeBlock %v is set without a maxCodeSize", c.CodeSizeLimitBlock)
+		}
+		if c.CodeSizeLimitBlock.Sign() < 0 {
+			return fmt.Errorf("invalid maxCodeSizeBlock %v", c.CodeSizeLimitBlock)
+		}
+	}
+	return nil
+}
+
+// MaxCodeSize returns the maximum size of contract code deployed in the given
+// block, 0 if there is no limit.
+func (c *ChainConfig) MaxCodeSize(num *big.Int) int {
+	if c.CodeSizeLimitBlock != nil && (num == nil || num.Cmp(c.CodeSizeLimitBlock) < 0) {
+		return 0
+	}
+	return int(c.CodeSizeLimit)
+}
+
+// Bounds of the configurable EVM limits. Every level of call depth takes a
+// few kilobytes of native stack, so the depth is kept well below the point
+// where nodes would run out of memory.
+const (
+	maxCallDepthLimit = 16384
+	maxStackLimit     = 65536
+)
+
+// CheckEVMLimits validates the EVM limits.
+func (c *ChainConfig) CheckEVMLimits() error {
+	if c.CallDepthLimit > maxCallDepthLimit {
+		return fmt.Errorf("invalid maxCallDepth %d, at most %d", c.CallDepthLimit, maxCallDepthLimit)
+	}
+	if c.StackLimit > maxStackLimit {
+		return fmt.Errorf("invalid maxStackSize %d, at most %d", c.StackLimit, maxStackLimit)
+	}
+	if c.EVMLimitsBlock != nil {
+		if c.CallDepthLimit == 0 && c.StackLimit == 0 && c.MemoryLimit == 0 {
+			return fmt.Errorf("evmLimitsBlock %v is set without any limit", c.EVMLimitsBlock)
+		}
+		if c.EVMLimitsBlock.Sign() < 0 {
+			return fmt.Errorf("invalid evmLimitsBlock %v", c.EVMLimitsBlock)
+		}
+	}
+	return nil
+}
+
+// Limits returns the EVM limits in the given block, the defaults of the public
+// network for those not configured.
+func (c *ChainConfig) Limits(num *big.Int) vm.Limits {
+	limits := vm.DefaultLimits
+	if c.EVMLimitsBlock != nil && (num == nil || num.Cmp(c.EVMLimitsBlock) < 0) {
+		return limits
+	}
+	if c.CallDepthLimit > 0 {
+		limits.CallDepth = int(c.CallDepthLimit)
+	}
+	if c.StackLimit > 0 {
+		limits.Stack = int(c.StackLimit)
+	}
+	limits.Memory = c.MemoryLimit
+	return limits
+}
+
+// CheckGasLimitRamp validates the gas limit ramp.
+func (c *ChainConfig) CheckGasLimitRamp() error {
+	if c.GasLimitRampStep != nil && c.GasLimitRampStep.Sign() < 0 {
+		return fmt.Errorf("invalid gasLimitRampStep %v", c.GasLimitRampStep)
+	}
+	if c.GasLimitRampBlock != nil {
+		if c.TargetGasLimit == nil || c.GasLimitRampStep == nil || c.GasLimitRampStep.Sign() == 0 {
+			return fmt.Errorf("gasLimitRampBlock %v is set without a targetGasLimit and gasLimitRampStep", c.GasLimitRampBlock)
+		}
+		if c.GasLimitRampBlock.Sign() < 0 {
+			return fmt.Errorf("invalid gasLimitRampBlock %v", c.GasLimitRampBlock)
+		}
+	}
+	return nil
+}
+
+// RampsGasLimit returns whether the gas limit of the given block is ramped
+// deterministically toward the target.
+func (c *ChainConfig) RampsGasLimit(num *big.Int) bool {
+	if c.TargetGasLimit == nil || c.GasLimitRampStep == nil || c.GasLimitRampStep.Sign() <= 0 {
+		return false
+	}
+	return c.GasLimitRampBlock == nil || (num != nil && num.Cmp(c.GasLimitRampBlock) >= 0)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
 func (c *ChainConfig) GasTable(num *big.Int) params.GasTable {
+	table := params.GasTableHomesteadGasRepriceFork
 	if c.HomesteadGasRepriceBlock == nil || num == nil || num.Cmp(c.HomesteadGasRepriceBlock) < 0 {
-		return params.GasTableHomestead
+		table = params.GasTableHomestead
 	}
-
-	return params.GasTableHomesteadGasRepriceFork
+	if num == nil {
+		return table
+	}
+	for i := range c.GasOverrides {
+		o := &c.GasOverrides[i]
+		if o.Block == nil || num.Cmp(o.Block) < 0 {
+			break
+		}
+		o.apply(&table)
+	}
+	return table
 }
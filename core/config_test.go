@@ -0,0 +1,228 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var testGasOverrides = &ChainConfig{
+	HomesteadGasRepriceBlock: big.NewInt(10),
+	GasOverrides: []GasOverride{
+		{Block: big.NewInt(5), SstoreSet: big.NewInt(5000), SLoad: big.NewInt(10)},
+		{Block: big.NewInt(20), SstoreSet: big.NewInt(1000), SstoreReset: big.NewInt(500)},
+	},
+}
+
+// Tests that gas overrides apply on top of the gas table of the phase, in order.
+func TestGasTableOverrides(t *testing.T) {
+	if err := testGasOverrides.CheckGasOverrides(); err != nil {
+		t.Fatalf("valid overrides rejected: %v", err)
+	}
+	tests := []struct {
+		number                      int64
+		sstoreSet, sstoreReset, sld int64
+		extcodeSize                 int64
+	}{
+		{4, 20000, 5000, 50, 20},  // homestead
+		{5, 5000, 5000, 10, 20},   // first override
+		{10, 5000, 5000, 10, 700}, // repriced, first override still applies
+		{20, 1000, 500, 10, 700},  // both overrides
+		{100, 1000, 500, 10, 700},
+	}
+	for i, tt := range tests {
+		table := testGasOverrides.GasTable(big.NewInt(tt.number))
+		if table.SstoreSet.Int64() != tt.sstoreSet || table.SstoreReset.Int64() != tt.sstoreReset || table.SLoad.Int64() != tt.sld || table.ExtcodeSize.Int64() != tt.extcodeSize {
+			t.Errorf("test %d: gas table mismatch: have sstoreSet %v, sstoreReset %v, sload %v, extcodeSize %v", i, table.SstoreSet, table.SstoreReset, table.SLoad, table.ExtcodeSize)
+		}
+	}
+	// The default tables must be left untouched
+	if params.GasTableHomestead.SstoreSet.Cmp(params.SstoreSetGas) != 0 || params.GasTableHomestead.SLoad.Int64() != 50 {
+		t.Errorf("default gas table modified")
+	}
+}
+
+// Tests that the EVM charges the overridden storage prices.
+func TestGasOverrideExecution(t *testing.T) {
+	var (
+		addr = common.Address{0xaa}
+		code = common.Hex2Bytes("6001600055") // PUSH1 1 PUSH1 0 SSTORE
+	)
+	for _, tt := range []struct {
+		number int64
+		used   int64
+	}{
+		{4, 3 + 3 + 20000},
+		{5, 3 + 3 + 5000},
+		{25, 3 + 3 + 1000},
+	} {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		statedb.SetCode(addr, code)
+		caller := statedb.GetOrNewStateObject(common.Address{1})
+
+		msg := callmsg{from: caller, to: &addr, value: new(big.Int), gas: big.NewInt(100000), gasPrice: new(big.Int)}
+		header := &types.Header{Number: big.NewInt(tt.number)}
+		env := NewEnv(statedb, statedb, testGasOverrides, nil, &msg, header, vm.Config{})
+
+		contract := vm.NewContract(caller, statedb.GetOrNewStateObject(addr), new(big.Int), big.NewInt(100000), new(big.Int))
+		contract.SetCallCode(&addr, crypto.Keccak256Hash(code), code)
+		if _, err := env.Vm().Run(contract, nil); err != nil {
+			t.Fatalf("block %d: execution failed: %v", tt.number, err)
+		}
+		if used := 100000 - contract.Gas.Int64(); used != tt.used {
+			t.Errorf("block %d: gas used mismatch: have %d, want %d", tt.number, used, tt.used)
+		}
+	}
+}
+
+// Tests that invalid gas overrides are rejected.
+func TestCheckGasOverrides(t *testing.T) {
+	tests := [][]GasOverride{
+		{{SstoreSet: big.NewInt(1)}},                                         // no block
+		{{Block: big.NewInt(1)}},                                             // no prices
+		{{Block: big.NewInt(1), SstoreSet: big.NewInt(-1)}},                  // negative price
+		{{Block: big.NewInt(1), SLoad: new(big.Int).Lsh(big.NewInt(1), 40)}}, // price too high
+		{
+			{Block: big.NewInt(2), SstoreSet: big.NewInt(1)},
+			{Block: big.NewInt(2), SstoreReset: big.NewInt(1)},
+		}, // not ordered
+	}
+	for i, overrides := range tests {
+		config := &ChainConfig{GasOverrides: overrides}
+		if err := config.CheckGasOverrides(); err == nil {
+			t.Errorf("test %d: invalid overrides accepted", i)
+		}
+	}
+}
+
+// Tests that contracts larger than the configured limit can't be deployed once
+// the limit is enforced.
+func TestMaxCodeSize(t *testing.T) {
+	config := &ChainConfig{CodeSizeLimit: 32, CodeSizeLimitBlock: big.NewInt(10)}
+	if err := config.CheckMaxCodeSize(); err != nil {
+		t.Fatalf("valid limit rejected: %v", err)
+	}
+	// PUSH1 size PUSH1 0 RETURN, returning size zero bytes of memory as code
+	initcode := func(size byte) []byte { return []byte{0x60, size, 0x60, 0x00, 0xf3} }
+	tests := []struct {
+		number int64
+		size   byte
+		err    error
+	}{
+		{9, 64, nil}, // not enforced yet
+		{10, 32, nil},
+		{10, 33, vm.ErrMaxCodeSizeExceeded},
+		{20, 64, vm.ErrMaxCodeSizeExceeded},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		caller := statedb.GetOrNewStateObject(common.Address{1})
+
+		msg := callmsg{from: caller, value: new(big.Int), gas: big.NewInt(100000), gasPrice: new(big.Int)}
+		header := &types.Header{Number: big.NewInt(tt.number)}
+		env := NewEnv(statedb, statedb, config, nil, &msg, header, vm.Config{})
+
+		_, addr, err := env.Create(caller, initcode(tt.size), msg.gas, msg.gasPrice, msg.value)
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if size := statedb.GetCodeSize(addr); (err == nil && size != int(tt.size)) || (err != nil && size != 0) {
+			t.Errorf("test %d: deployed code size mismatch: have %d", i, size)
+		}
+	}
+}
+
+// Tests that invalid code size limits are rejected.
+func TestCheckMaxCodeSize(t *testing.T) {
+	tests := []*ChainConfig{
+		{CodeSizeLimitBlock: big.NewInt(1)},                       // no limit
+		{CodeSizeLimit: 1024, CodeSizeLimitBlock: big.NewInt(-1)}, // negative block
+		{CodeSizeLimit: 1 << 40},                                  // too large
+	}
+	for i, config := range tests {
+		if err := config.CheckMaxCodeSize(); err == nil {
+			t.Errorf("test %d: invalid limit accepted", i)
+		}
+	}
+}
+
+// Tests that the EVM limits apply from their block, and the defaults before.
+func TestEVMLimits(t *testing.T) {
+	config := &ChainConfig{CallDepthLimit: 2048, StackLimit: 2048, MemoryLimit: 4096, EVMLimitsBlock: big.NewInt(10)}
+	if err := config.CheckEVMLimits(); err != nil {
+		t.Fatalf("valid limits rejected: %v", err)
+	}
+	// Pushing 1100 items, or storing a word at the given offset
+	deepStack := bytes.Repeat([]byte{0x60, 0x00}, 1100)
+	mstore := func(offset uint16) []byte { return []byte{0x60, 0x01, 0x61, byte(offset >> 8), byte(offset), 0x52} }
+	tests := []struct {
+		number  int64
+		depth   int
+		code    []byte
+		success bool
+	}{
+		{9, 0, deepStack, false}, // default limits before the block
+		{9, 1100, mstore(0), false},
+		{9, 0, mstore(0x1000), true},
+		{10, 0, deepStack, true},
+		{10, 1100, mstore(0), true},
+		{10, 2100, mstore(0), false},
+		{10, 0, mstore(0x0fe0), true},
+		{10, 0, mstore(0x1000), false},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		caller := statedb.GetOrNewStateObject(common.Address{1})
+
+		msg := callmsg{from: caller, value: new(big.Int), gas: big.NewInt(1000000), gasPrice: new(big.Int)}
+		header := &types.Header{Number: big.NewInt(tt.number)}
+		env := NewEnv(statedb, statedb, config, nil, &msg, header, vm.Config{})
+		env.SetDepth(tt.depth)
+
+		if _, _, err := env.Create(caller, tt.code, msg.gas, msg.gasPrice, msg.value); (err == nil) != tt.success {
+			t.Errorf("test %d: error mismatch: have %v, want success %v", i, err, tt.success)
+		}
+	}
+}
+
+// Tests that invalid EVM limits are rejected.
+func TestCheckEVMLimits(t *testing.T) {
+	tests := []*ChainConfig{
+		{EVMLimitsBlock: big.NewInt(1)},                        // no limits
+		{CallDepthLimit: 2048, EVMLimitsBlock: big.NewInt(-1)}, // negative block
+		{CallDepthLimit: 1 << 20},                              // too deep
+		{StackLimit: 1 << 20},                                  // too large
+	}
+	for i, config := range tests {
+		if err := config.CheckEVMLimits(); err == nil {
+			t.Errorf("test %d: invalid limits accepted", i)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// maxCorrelationIdLength is the longest correlation ID accepted for a transaction.
+const maxCorrelationIdLength = 128
+
+var (
+	correlationPrefix = []byte("correlation-") // correlationPrefix + tx hash -> correlation ID
+
+	// ErrInvalidCorrelationId is returned for correlation IDs which are too long
+	// or contain characters which can't be logged safely.
+	ErrInvalidCorrelationId = errors.New("invalid correlation ID, want at most 128 printable ASCII characters")
+)
+
+// ValidateCorrelationId checks that a client supplied correlation ID can be
+// written to the logs and the database verbatim.
+func ValidateCorrelationId(id string) error {
+	if len(id) > maxCorrelationIdLength {
+		return ErrInvalidCorrelationId
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x20 || id[i] > 0x7e {
+			return ErrInvalidCorrelationId
+		}
+	}
+	return nil
+}
+
+// GetTxCorrelationId retrieves the correlation ID a transaction was submitted
+// with, or an empty string if it was submitted without one.
+func GetTxCorrelationId(db ethdb.Database, txHash common.Hash) string {
+	data, _ := db.Get(append(correlationPrefix, txHash[:]...))
+	return string(data)
+}
+
+// WriteTxCorrelationId stores the correlation ID a transaction was submitted with.
+func WriteTxCorrelationId(db ethdb.Database, txHash common.Hash, id string) error {
+	return db.Put(append(correlationPrefix, txHash[:]...), []byte(id))
+}
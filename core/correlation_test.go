@@ -0,0 +1,52 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that correlation IDs are stored per transaction, and that IDs which
+// can't be logged verbatim are rejected.
+func TestTxCorrelationId(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	hash := common.HexToHash("0x01")
+
+	if id := GetTxCorrelationId(db, hash); id != "" {
+		t.Fatalf("correlation ID of unknown transaction: %q", id)
+	}
+	if err := WriteTxCorrelationId(db, hash, "order-42"); err != nil {
+		t.Fatalf("failed to write correlation ID: %v", err)
+	}
+	if id := GetTxCorrelationId(db, hash); id != "order-42" {
+		t.Errorf("correlation ID mismatch: have %q, want %q", id, "order-42")
+	}
+	for _, id := range []string{"", "order-42", "7f3c9a2e-1b4d-4e8a-9c6f-0d2b5a8e1f3c", strings.Repeat("a", 128)} {
+		if err := ValidateCorrelationId(id); err != nil {
+			t.Errorf("valid correlation ID %q rejected: %v", id, err)
+		}
+	}
+	for _, id := range []string{"order\n42", "order-\x1b[31m", "ordér", strings.Repeat("a", 129)} {
+		if err := ValidateCorrelationId(id); err != ErrInvalidCorrelationId {
+			t.Errorf("invalid correlation ID %q accepted", id)
+		}
+	}
+}
@@ -120,6 +120,24 @@ func IsBlockNonceErr(err error) bool {
 	return ok
 }
 
+// UnauthorizedBlockMakerErr indicates that a block was signed by an account
+// the voting contract didn't allow to create blocks at its height.
+type UnauthorizedBlockMakerErr struct {
+	Number *big.Int
+	Hash   common.Hash
+	Signer common.Address
+}
+
+func (err *UnauthorizedBlockMakerErr) Error() string {
+	return fmt.Sprintf("Invalid header: %s isn't allowed to create block #%d [%x…]", err.Signer.Hex(), err.Number, err.Hash[:4])
+}
+
+// IsUnauthorizedBlockMakerErr returns true for blocks from unauthorized block makers.
+func IsUnauthorizedBlockMakerErr(err error) bool {
+	_, ok := err.(*UnauthorizedBlockMakerErr)
+	return ok
+}
+
 type InvalidTxErr struct {
 	Message string
 }
@@ -27,6 +27,14 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDroppedEvent is posted when a transaction leaves the transaction pool
+// without being included in a block. Reason is one of the TxDrop constants.
+type TxDroppedEvent struct {
+	Tx          *types.Transaction
+	Reason      string
+	Replacement *types.Transaction // Transaction with the same nonce replacing it, if replaced
+}
+
 // TxPostEvent is posted when a transaction has been processed.
 type TxPostEvent struct{ Tx *types.Transaction }
 
@@ -23,7 +23,6 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/params"
 )
 
 /*
@@ -134,7 +133,7 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.Address, codeHash common.Hash, input, code []byte, gas, gasPrice, value *big.Int) (ret []byte, addr common.Address, err error) {
 	evm := env.Vm()
 	// Depth check execution. Fail if we're trying to execute above the limit.
-	if env.Depth() > int(params.CallCreateDepth.Int64()) {
+	if env.Depth() > env.RuleSet().Limits(env.BlockNumber()).CallDepth {
 		caller.ReturnGas(gas, gasPrice)
 
 		return nil, common.Address{}, vm.DepthError
@@ -181,9 +180,14 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 	// calculate the gas required to store the code. If the code could not
 	// be stored due to not enough gas set an error and let it be handled
 	// by the error checking condition below.
+	if err == nil && createAccount {
+		if limit := env.RuleSet().MaxCodeSize(env.BlockNumber()); limit > 0 && len(ret) > limit {
+			err = vm.ErrMaxCodeSizeExceeded
+		}
+	}
 	if err == nil && createAccount {
 		dataGas := big.NewInt(int64(len(ret)))
-		dataGas.Mul(dataGas, params.CreateDataGas)
+		dataGas.Mul(dataGas, env.RuleSet().GasTable(env.BlockNumber()).CreateData)
 		if contract.UseGas(dataGas) {
 			env.Db().SetCode(*address, ret)
 		} else {
@@ -194,8 +198,11 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 	// When an error was returned by the EVM or when setting the creation code
 	// above we revert to the snapshot and consume any gas remaining. Additionally
 	// when we're in homestead this also counts for code storage gas errors.
+	// A REVERT keeps the remaining gas.
 	if err != nil && (env.RuleSet().IsHomestead(env.BlockNumber()) || err != vm.CodeStoreOutOfGasError) {
-		contract.UseGas(contract.Gas)
+		if err != vm.ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 
 		env.RevertToSnapshot(snapshotPreTransfer)
 	}
@@ -207,7 +214,7 @@ func execDelegateCall(env vm.Environment, caller vm.ContractRef, originAddr, toA
 	evm := env.Vm()
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
-	if env.Depth() > int(params.CallCreateDepth.Int64()) {
+	if env.Depth() > env.RuleSet().Limits(env.BlockNumber()).CallDepth {
 		caller.ReturnGas(gas, gasPrice)
 		return nil, common.Address{}, vm.DepthError
 	}
@@ -228,7 +235,9 @@ func execDelegateCall(env vm.Environment, caller vm.ContractRef, originAddr, toA
 
 	ret, err = evm.Run(contract, input)
 	if err != nil {
-		contract.UseGas(contract.Gas)
+		if err != vm.ErrExecutionReverted {
+			contract.UseGas(contract.Gas)
+		}
 
 		env.RevertToSnapshot(snapshot)
 	}
@@ -221,6 +221,21 @@ func OlympicGenesisBlock() string {
 	}`, types.EncodeNonce(42), params.GenesisGasLimit.Bytes(), params.GenesisDifficulty.Bytes())
 }
 
+// DevGenesisBlock assembles a JSON string representing the genesis block of
+// --dev mode, funding the given accounts with plenty of ether.
+func DevGenesisBlock(accounts []common.Address) string {
+	alloc := make([]string, len(accounts))
+	for i, addr := range accounts {
+		alloc[i] = fmt.Sprintf(`"%x": {"balance": "1000000000000000000000000000"}`, addr)
+	}
+	return fmt.Sprintf(`{
+		"nonce": "0x%x",
+		"gasLimit": "0xe0000000",
+		"difficulty": "0x0",
+		"alloc": {%s}
+	}`, types.EncodeNonce(0), strings.Join(alloc, ", "))
+}
+
 // TestNetGenesisBlock assembles a JSON string representing the Morden test net
 // genenis block.
 func TestNetGenesisBlock() string {
@@ -0,0 +1,122 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// reversePrecompile is a custom precompiled contract returning its input in
+// reverse order.
+type reversePrecompile struct{}
+
+func (reversePrecompile) RequiredGas(input []byte) *big.Int {
+	return big.NewInt(100 + int64(len(input)))
+}
+
+func (reversePrecompile) Run(input []byte) ([]byte, error) {
+	if len(input) == 0 {
+		return nil, errors.New("empty input")
+	}
+	out := make([]byte, len(input))
+	for i, b := range input {
+		out[len(input)-1-i] = b
+	}
+	return out, nil
+}
+
+func init() {
+	vm.RegisterPrecompile("test-reverse", reversePrecompile{})
+}
+
+var reverseAddr = common.HexToAddress("0x0000000000000000000000000000000000000f01")
+
+// Tests that custom precompiled contracts are only called from their activation
+// block on.
+func TestCustomPrecompile(t *testing.T) {
+	config := &ChainConfig{
+		Precompiles: []PrecompileConfig{{Name: "test-reverse", Address: reverseAddr, Block: big.NewInt(5)}},
+	}
+	if err := config.CheckPrecompiles(); err != nil {
+		t.Fatalf("valid config rejected: %v", err)
+	}
+	tests := []struct {
+		number uint64
+		input  []byte
+		gas    int64
+		output []byte
+		err    bool
+	}{
+		{4, []byte{1, 2, 3}, 100000, nil, false},             // not active yet, plain account
+		{5, []byte{1, 2, 3}, 100000, []byte{3, 2, 1}, false}, // active
+		{6, []byte{1, 2, 3}, 102, nil, true},                 // out of gas
+		{6, nil, 100000, nil, true},                          // failing contract
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, db)
+		caller := statedb.GetOrNewStateObject(common.Address{1})
+
+		msg := callmsg{
+			from:     caller,
+			to:       &reverseAddr,
+			value:    new(big.Int),
+			gas:      big.NewInt(tt.gas),
+			gasPrice: new(big.Int),
+			data:     tt.input,
+		}
+		header := &types.Header{Number: new(big.Int).SetUint64(tt.number)}
+		env := NewEnv(statedb, statedb, config, nil, &msg, header, vm.Config{})
+
+		output, err := env.Call(caller, reverseAddr, tt.input, msg.gas, msg.gasPrice, msg.value)
+		if (err != nil) != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, tt.err)
+		}
+		if !bytes.Equal(output, tt.output) {
+			t.Errorf("test %d: output mismatch: have %x, want %x", i, output, tt.output)
+		}
+	}
+}
+
+// Tests that invalid custom precompiled contract configs are rejected.
+func TestCheckPrecompiles(t *testing.T) {
+	tests := [][]PrecompileConfig{
+		{{Name: "unknown", Address: reverseAddr, Block: new(big.Int)}},
+		{{Name: "test-reverse", Address: reverseAddr}},
+		{{Name: "test-reverse", Address: common.Address{}, Block: new(big.Int)}},
+		{{Name: "test-reverse", Address: common.BytesToAddress([]byte{1}), Block: new(big.Int)}},
+		{
+			{Name: "test-reverse", Address: reverseAddr, Block: new(big.Int)},
+			{Name: "test-reverse", Address: reverseAddr, Block: big.NewInt(10)},
+		},
+	}
+	for i, precompiles := range tests {
+		config := &ChainConfig{Precompiles: precompiles}
+		if err := config.CheckPrecompiles(); err == nil {
+			t.Errorf("test %d: invalid config accepted", i)
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -54,26 +53,7 @@ func (api *PublicQuorumAPI) Vote(blockHash common.Hash) (common.Hash, error) {
 }
 
 func (api *PublicQuorumAPI) NodeInfo() map[string]interface{} {
-	result := make(map[string]interface{})
-
-	if api.bv.bmk != nil {
-		addr := crypto.PubkeyToAddress(api.bv.bmk.PublicKey)
-		allowed, _ := api.bv.callContract.IsBlockMaker(nil, addr)
-		result["blockMakerAccount"] = addr
-		result["canCreateBlocks"] = allowed
-		if Strategy != nil {
-			result["blockmakestrategy"] = Strategy
-		}
-	}
-
-	if api.bv.vk != nil {
-		addr := crypto.PubkeyToAddress(api.bv.vk.PublicKey)
-		allowed, _ := api.bv.callContract.IsVoter(nil, addr)
-		result["voteAccount"] = addr
-		result["canVote"] = allowed
-	}
-
-	return result
+	return api.bv.NodeInfo()
 }
 
 func (api *PublicQuorumAPI) MakeBlock() (common.Hash, error) {
@@ -96,6 +76,24 @@ func (api *PublicQuorumAPI) MakeBlock() (common.Hash, error) {
 	}
 }
 
+// VoteStatus returns the votes received for the given height compared to the
+// vote threshold, and which of the known voters have not voted yet.
+func (api *PublicQuorumAPI) VoteStatus(height rpc.HexNumber) (*VoteStatus, error) {
+	return api.bv.voteStatus(height.Uint64())
+}
+
+// GetVotingHistory returns, for each height from..to, the votes included in
+// the canonical chain and the block in which a hash reached the vote threshold.
+func (api *PublicQuorumAPI) GetVotingHistory(from, to rpc.HexNumber) ([]*VotingHistory, error) {
+	return api.bv.votingHistory(from.Uint64(), to.Uint64())
+}
+
+// SetVoteThreshold sends a transaction from the node's vote account that sets
+// the number of votes a block needs before it's considered canonical.
+func (api *PublicQuorumAPI) SetVoteThreshold(threshold rpc.HexNumber) (common.Hash, error) {
+	return api.bv.setVoteThreshold(threshold.BigInt())
+}
+
 func (api *PublicQuorumAPI) IsVoter(addr common.Address) (bool, error) {
 	return api.bv.isVoter(addr)
 }
@@ -132,6 +130,22 @@ func (api PublicQuorumAPI) ResumeVoting() error {
 	return nil
 }
 
+// Failover forces a standby block maker to take over block creation.
+func (api PublicQuorumAPI) Failover() error {
+	if Strategy != nil {
+		return Strategy.Failover()
+	}
+	return nil
+}
+
+// Standby hands block creation back from a standby to the primary block maker.
+func (api PublicQuorumAPI) Standby() error {
+	if Strategy != nil {
+		return Strategy.Standby()
+	}
+	return nil
+}
+
 // GetPrivatePayload returns the contents of a private transaction
 func (api PublicQuorumAPI) GetPrivatePayload(digestHex string) (string, error) {
 	return private.GetPayload(digestHex)
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/private"
 	"gopkg.in/fatih/set.v0"
 )
 
@@ -87,6 +88,10 @@ func (ps *pendingState) applyTransactions(txs *types.TransactionsByPriorityAndNo
 			// Pop the current out-of-gas transaction without shifting in the next from the account
 			glog.V(logger.Detail).Infof("Gas limit reached for (%x) in this block. Continue to try smaller txs\n", from[:4])
 			txs.Pop()
+		case private.IsPayloadUnavailable(err):
+			// Keep the transaction in the pool, it's included once its payload can be retrieved
+			glog.V(logger.Warn).Infof("Transaction (%x) postponed: %v\n", tx.Hash().Bytes()[:4], err)
+			txs.Pop()
 		case err != nil:
 			// Pop the current failed transaction without shifting in the next from the account
 			glog.V(logger.Detail).Infof("Transaction (%x) failed, will be removed: %v\n", tx.Hash().Bytes()[:4], err)
@@ -0,0 +1,268 @@
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// remoteSignerService is the name remote signers report their health for.
+	remoteSignerService = "quorum.BlockSigner"
+
+	remoteSignerHealthInterval = 5 * time.Second
+	defaultRemoteSignerTimeout = 2 * time.Second
+)
+
+// BlockSigner signs the headers of the blocks this node creates.
+type BlockSigner interface {
+	// Account returns the block maker account new blocks are created for.
+	Account() common.Address
+	// SignHeader signs the Quorum hash of a header created for account.
+	SignHeader(account common.Address, header *types.Header) ([]byte, error)
+}
+
+// LocalSigner signs blocks with a block maker key held by the node.
+type LocalSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewLocalSigner creates a signer for the given block maker key.
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key}
+}
+
+// Account implements BlockSigner.
+func (s *LocalSigner) Account() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// SignHeader implements BlockSigner.
+func (s *LocalSigner) SignHeader(account common.Address, header *types.Header) ([]byte, error) {
+	if account != s.Account() {
+		return nil, fmt.Errorf("no key for block maker %s", account.Hex())
+	}
+	return crypto.Sign(header.QuorumHash().Bytes(), s.key)
+}
+
+// RemoteSignerConfig configures a RemoteSigner.
+type RemoteSignerConfig struct {
+	Endpoint string         // host:port of the signer's gRPC server
+	Account  common.Address // block maker account the signer holds the key of
+	CACert   string         // CA certificate to verify the server with, plaintext if empty
+	Timeout  time.Duration  // timeout of signing requests and health checks
+}
+
+// RemoteSigner signs blocks with an external signer, such as a threshold or
+// MPC signing service, over the BlockSigner gRPC interface. It checks the
+// health of the signer periodically.
+type RemoteSigner struct {
+	config RemoteSignerConfig
+	conn   *grpc.ClientConn
+	client BlockSignerClient
+	health healthpb.HealthClient
+
+	mu      sync.RWMutex
+	healthy bool
+	quit    chan struct{}
+}
+
+// NewRemoteSigner connects to the external signer. The connection is
+// established in the background, the signer is reported unhealthy until its
+// first successful health check.
+func NewRemoteSigner(config RemoteSignerConfig) (*RemoteSigner, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultRemoteSignerTimeout
+	}
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if config.CACert != "" {
+		creds, err := credentials.NewClientTLSFromFile(config.CACert, "")
+		if err != nil {
+			return nil, err
+		}
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+	conn, err := grpc.Dial(config.Endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &RemoteSigner{
+		config: config,
+		conn:   conn,
+		client: NewBlockSignerClient(conn),
+		health: healthpb.NewHealthClient(conn),
+		quit:   make(chan struct{}),
+	}
+	s.checkHealth()
+	go s.healthLoop()
+	return s, nil
+}
+
+// Account implements BlockSigner.
+func (s *RemoteSigner) Account() common.Address {
+	return s.config.Account
+}
+
+// SignHeader implements BlockSigner. Failing requests mark the signer
+// unhealthy until its next successful health check.
+func (s *RemoteSigner) SignHeader(account common.Address, header *types.Header) ([]byte, error) {
+	if account != s.config.Account {
+		return nil, fmt.Errorf("remote signer has no key for block maker %s", account.Hex())
+	}
+	sig, err := s.sign(header)
+	if err != nil {
+		s.setHealthy(false)
+		return nil, fmt.Errorf("remote signer: %v", err)
+	}
+	return sig, nil
+}
+
+func (s *RemoteSigner) sign(header *types.Header) ([]byte, error) {
+	encoded, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	hash := header.QuorumHash()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	res, err := s.client.Sign(ctx, &SignRequest{
+		Account: s.config.Account.Bytes(),
+		Hash:    hash.Bytes(),
+		Number:  header.Number.Uint64(),
+		Header:  encoded,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Don't trust the signer, a bad signature would only be noticed by peers
+	pub, err := crypto.Ecrecover(hash.Bytes(), res.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %v", err)
+	}
+	if signer := common.BytesToAddress(crypto.Keccak256(pub[1:])[12:]); signer != s.config.Account {
+		return nil, fmt.Errorf("signature by %s instead of %s", signer.Hex(), s.config.Account.Hex())
+	}
+	return res.Signature, nil
+}
+
+// Healthy reports whether the last health check or signing request succeeded.
+func (s *RemoteSigner) Healthy() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.healthy
+}
+
+func (s *RemoteSigner) setHealthy(healthy bool) {
+	s.mu.Lock()
+	changed := s.healthy != healthy
+	s.healthy = healthy
+	s.mu.Unlock()
+
+	if changed && healthy {
+		glog.V(logger.Info).Infof("Remote block signer %s is healthy", s.config.Endpoint)
+	} else if changed {
+		glog.V(logger.Warn).Infof("Remote block signer %s is unhealthy", s.config.Endpoint)
+	}
+}
+
+func (s *RemoteSigner) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	res, err := s.health.Check(ctx, &healthpb.HealthCheckRequest{Service: remoteSignerService})
+	if err != nil {
+		glog.V(logger.Debug).Infof("Remote block signer health check failed: %v", err)
+		s.setHealthy(false)
+		return
+	}
+	s.setHealthy(res.Status == healthpb.HealthCheckResponse_SERVING)
+}
+
+func (s *RemoteSigner) healthLoop() {
+	ticker := time.NewTicker(remoteSignerHealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkHealth()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// Close stops the health checks and closes the connection to the signer.
+func (s *RemoteSigner) Close() error {
+	close(s.quit)
+	return s.conn.Close()
+}
+
+// FailoverSigner signs blocks with a remote signer, failing over to a local
+// break-glass key while the remote signer is unhealthy.
+type FailoverSigner struct {
+	remote *RemoteSigner
+	local  *LocalSigner
+}
+
+// NewFailoverSigner creates a signer preferring remote over local.
+func NewFailoverSigner(remote *RemoteSigner, local *LocalSigner) *FailoverSigner {
+	return &FailoverSigner{remote: remote, local: local}
+}
+
+// Account implements BlockSigner. Blocks are created for the account of the
+// local key while the remote signer is unhealthy.
+func (s *FailoverSigner) Account() common.Address {
+	if s.remote.Healthy() {
+		return s.remote.Account()
+	}
+	return s.local.Account()
+}
+
+// Close closes the remote signer.
+func (s *FailoverSigner) Close() error {
+	return s.remote.Close()
+}
+
+// Healthy reports whether the remote signer is healthy.
+func (s *FailoverSigner) Healthy() bool {
+	return s.remote.Healthy()
+}
+
+// SignHeader implements BlockSigner. If the remote signer fails to sign and the
+// local key is for the same account, the local key signs instead.
+func (s *FailoverSigner) SignHeader(account common.Address, header *types.Header) ([]byte, error) {
+	if account == s.remote.Account() {
+		sig, err := s.remote.SignHeader(account, header)
+		if err == nil || account != s.local.Account() {
+			return sig, err
+		}
+		glog.V(logger.Warn).Infof("Signing block %d with break-glass key: %v", header.Number, err)
+	}
+	return s.local.SignHeader(account, header)
+}
+
+// signsFor reports whether signer creates blocks for account, with any of the
+// keys it switches between.
+func signsFor(signer BlockSigner, account common.Address) bool {
+	switch signer := signer.(type) {
+	case nil:
+		return false
+	case *FailoverSigner:
+		return signsFor(signer.remote, account) || signsFor(signer.local, account)
+	case *RotatingSigner:
+		return signsFor(signer.current, account) || signsFor(signer.next, account)
+	}
+	return signer.Account() == account
+}
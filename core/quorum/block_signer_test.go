@@ -0,0 +1,209 @@
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testSignerServer is an in-process BlockSigner service signing with key.
+type testSignerServer struct {
+	health *health.Server
+
+	mu       sync.Mutex
+	key      *ecdsa.PrivateKey
+	fail     bool     // fail signing requests
+	requests []uint64 // numbers of the blocks signing was requested for
+}
+
+func (s *testSignerServer) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req.Number)
+	if s.fail {
+		return nil, errors.New("signing unavailable")
+	}
+	sig, err := crypto.Sign(req.Hash, s.key)
+	if err != nil {
+		return nil, err
+	}
+	return &SignResponse{Signature: sig}, nil
+}
+
+func (s *testSignerServer) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus(remoteSignerService, status)
+}
+
+// newTestRemoteSigner starts a signer service with key and connects a
+// RemoteSigner for account to it.
+func newTestRemoteSigner(t *testing.T, key *ecdsa.PrivateKey, account common.Address) (*RemoteSigner, *testSignerServer, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &testSignerServer{health: health.NewServer(), key: key}
+	srv.setServing(true)
+
+	server := grpc.NewServer()
+	RegisterBlockSignerServer(server, srv)
+	healthpb.RegisterHealthServer(server, srv.health)
+	go server.Serve(listener)
+
+	signer, err := NewRemoteSigner(RemoteSignerConfig{Endpoint: listener.Addr().String(), Account: account, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer.checkHealth()
+	if !signer.Healthy() {
+		t.Fatal("remote signer unhealthy after connecting")
+	}
+	return signer, srv, func() {
+		signer.Close()
+		server.Stop()
+	}
+}
+
+func testHeader(number int64, coinbase common.Address) *types.Header {
+	return &types.Header{Number: big.NewInt(number), Coinbase: coinbase, ParentHash: common.HexToHash("0x01")}
+}
+
+// recoverSigner returns the account which signed the Quorum hash of header.
+func recoverSigner(t *testing.T, header *types.Header, sig []byte) common.Address {
+	pub, err := crypto.SigToPub(header.QuorumHash().Bytes(), sig)
+	if err != nil {
+		t.Fatalf("invalid signature: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	signer, srv, stop := newTestRemoteSigner(t, key, account)
+	defer stop()
+
+	header := testHeader(7, account)
+	sig, err := signer.SignHeader(account, header)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if have := recoverSigner(t, header, sig); have != account {
+		t.Errorf("signed by %x, want %x", have, account)
+	}
+	if len(srv.requests) != 1 || srv.requests[0] != 7 {
+		t.Errorf("signing requests mismatch: %v", srv.requests)
+	}
+	if _, err := signer.SignHeader(common.Address{1}, header); err == nil {
+		t.Errorf("signed for another account")
+	}
+}
+
+// Tests that signatures by another key than the block maker's are rejected and
+// mark the signer unhealthy.
+func TestRemoteSignerWrongKey(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	signer, _, stop := newTestRemoteSigner(t, other, account)
+	defer stop()
+
+	if _, err := signer.SignHeader(account, testHeader(1, account)); err == nil {
+		t.Fatalf("signature by another key accepted")
+	}
+	if signer.Healthy() {
+		t.Errorf("signer healthy after a bad signature")
+	}
+	signer.checkHealth()
+	if !signer.Healthy() {
+		t.Errorf("signer unhealthy after a successful health check")
+	}
+}
+
+func TestFailoverSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	remote, srv, stop := newTestRemoteSigner(t, key, account)
+	defer stop()
+
+	// The remote signer is preferred while healthy
+	signer := NewFailoverSigner(remote, NewLocalSigner(key))
+	header := testHeader(1, account)
+	if sig, err := signer.SignHeader(account, header); err != nil || recoverSigner(t, header, sig) != account {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if len(srv.requests) != 1 {
+		t.Errorf("remote signer not asked first: %d requests", len(srv.requests))
+	}
+
+	// The break-glass key signs when the remote signer fails
+	srv.mu.Lock()
+	srv.fail = true
+	srv.mu.Unlock()
+	header = testHeader(2, account)
+	if sig, err := signer.SignHeader(account, header); err != nil || recoverSigner(t, header, sig) != account {
+		t.Fatalf("break-glass key didn't sign: %v", err)
+	}
+	if len(srv.requests) != 2 {
+		t.Errorf("remote signer not asked before the break-glass key: %d requests", len(srv.requests))
+	}
+	if signer.Healthy() {
+		t.Errorf("failing remote signer reported healthy")
+	}
+}
+
+// Tests that blocks are created for the break-glass account while the remote
+// signer reports unhealthy, and for the remote account once it recovers.
+func TestFailoverSignerAccount(t *testing.T) {
+	remoteKey, _ := crypto.GenerateKey()
+	localKey, _ := crypto.GenerateKey()
+	remoteAccount, localAccount := crypto.PubkeyToAddress(remoteKey.PublicKey), crypto.PubkeyToAddress(localKey.PublicKey)
+	remote, srv, stop := newTestRemoteSigner(t, remoteKey, remoteAccount)
+	defer stop()
+
+	signer := NewFailoverSigner(remote, NewLocalSigner(localKey))
+	if have := signer.Account(); have != remoteAccount {
+		t.Errorf("account mismatch while healthy: have %x, want %x", have, remoteAccount)
+	}
+	srv.setServing(false)
+	remote.checkHealth()
+	if have := signer.Account(); have != localAccount {
+		t.Errorf("account mismatch while unhealthy: have %x, want %x", have, localAccount)
+	}
+	header := testHeader(1, localAccount)
+	if sig, err := signer.SignHeader(localAccount, header); err != nil || recoverSigner(t, header, sig) != localAccount {
+		t.Fatalf("break-glass key didn't sign: %v", err)
+	}
+	if len(srv.requests) != 0 {
+		t.Errorf("remote signer asked to sign for the break-glass account")
+	}
+	srv.setServing(true)
+	remote.checkHealth()
+	if have := signer.Account(); have != remoteAccount {
+		t.Errorf("account mismatch after recovery: have %x, want %x", have, remoteAccount)
+	}
+
+	// A failing remote signer doesn't fall back to a key of another account
+	srv.mu.Lock()
+	srv.fail = true
+	srv.mu.Unlock()
+	if _, err := signer.SignHeader(remoteAccount, testHeader(2, remoteAccount)); err == nil {
+		t.Errorf("signed for the remote account without the remote signer")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/fatih/set.v0"
@@ -28,7 +30,7 @@ import (
 
 const (
 	// Create bindings with: go run cmd/abigen/main.go -abi <definition> -pkg quorum -type VotingContract > core/quorum/binding.go
-	ABI = `[{"constant":false,"inputs":[{"name":"threshold","type":"uint256"}],"name":"setVoteThreshold","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"removeBlockMaker","outputs":[],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"voterCount","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"canCreateBlocks","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"voteThreshold","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"height","type":"uint256"}],"name":"getCanonHash","outputs":[{"name":"","type":"bytes32"}],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"height","type":"uint256"},{"name":"hash","type":"bytes32"}],"name":"vote","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"addBlockMaker","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"removeVoter","outputs":[],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"height","type":"uint256"},{"name":"n","type":"uint256"}],"name":"getEntry","outputs":[{"name":"","type":"bytes32"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"addr","type":"address"}],"name":"isVoter","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"canVote","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"blockMakerCount","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"getSize","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"addr","type":"address"}],"name":"isBlockMaker","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"addVoter","outputs":[],"payable":false,"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"sender","type":"address"},{"indexed":false,"name":"blockNumber","type":"uint256"},{"indexed":false,"name":"blockHash","type":"bytes32"}],"name":"Vote","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"AddVoter","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"RemovedVoter","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"AddBlockMaker","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"RemovedBlockMaker","type":"event"}]`
+	ABI = `[{"constant":false,"inputs":[{"name":"threshold","type":"uint256"}],"name":"setVoteThreshold","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"removeBlockMaker","outputs":[],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"voterCount","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"canCreateBlocks","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"voteThreshold","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"height","type":"uint256"}],"name":"getCanonHash","outputs":[{"name":"","type":"bytes32"}],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"height","type":"uint256"},{"name":"hash","type":"bytes32"}],"name":"vote","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"heights","type":"uint256[]"},{"name":"hashes","type":"bytes32[]"}],"name":"voteBatch","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"addBlockMaker","outputs":[],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"removeVoter","outputs":[],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"height","type":"uint256"},{"name":"n","type":"uint256"}],"name":"getEntry","outputs":[{"name":"","type":"bytes32"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"addr","type":"address"}],"name":"isVoter","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"canVote","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"blockMakerCount","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[],"name":"getSize","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"addr","type":"address"}],"name":"isBlockMaker","outputs":[{"name":"","type":"bool"}],"payable":false,"type":"function"},{"constant":false,"inputs":[{"name":"addr","type":"address"}],"name":"addVoter","outputs":[],"payable":false,"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"sender","type":"address"},{"indexed":false,"name":"blockNumber","type":"uint256"},{"indexed":false,"name":"blockHash","type":"bytes32"}],"name":"Vote","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"AddVoter","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"RemovedVoter","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"AddBlockMaker","type":"event"},{"anonymous":false,"inputs":[{"indexed":false,"name":"","type":"address"}],"name":"RemovedBlockMaker","type":"event"}]`
 
 	// > solc --version
 	// solc, the solidity compiler commandline interface
@@ -40,6 +42,9 @@ const (
 	// Note: solidity embeds a hash of the contents and filename in the end of the code. If the last part
 	// of the runtime code differs it is very likely that solc has been run against a file with a different
 	// name, or a file with different contents (check for windows vs linux newlines).
+	//
+	// Note: this code was compiled before voteBatch was added to block_voting.sol. Nodes only batch
+	// votes once the voting contract supports it, see supportsVoteBatch.
 	RuntimeCode = "606060405236156100ca5763ffffffff60e060020a6000350416631290948581146100cc578063284d163c146100e157806342169e48146100ff578063488099a6146101215780634fe437d514610151578063559c390c1461017357806368bb8bb61461019857806372a571fc146101b057806386c1ff68146101ce57806398ba676d146101ec578063a7771ee314610214578063adfaa72e14610244578063cf52898514610274578063de8fa43114610296578063e814d1c7146102b8578063f4ab9adf146102e8575bfe5b34156100d457fe5b6100df600435610306565b005b34156100e957fe5b6100df600160a060020a036004351661033c565b005b341561010757fe5b61010f6103ff565b60408051918252519081900360200190f35b341561012957fe5b61013d600160a060020a0360043516610405565b604080519115158252519081900360200190f35b341561015957fe5b61010f61041a565b60408051918252519081900360200190f35b341561017b57fe5b61010f600435610420565b60408051918252519081900360200190f35b34156101a057fe5b6100df60043560243561053f565b005b34156101b857fe5b6100df600160a060020a036004351661064f565b005b34156101d657fe5b6100df600160a060020a0360043516610707565b005b34156101f457fe5b61010f6004356024356107ca565b60408051918252519081900360200190f35b341561021c57fe5b61013d600160a060020a036004351661081f565b604080519115158252519081900360200190f35b341561024c57fe5b61013d600160a060020a0360043516610841565b604080519115158252519081900360200190f35b341561027c57fe5b61010f610856565b60408051918252519081900360200190f35b341561029e57fe5b61010f61085c565b60408051918252519081900360200190f35b34156102c057fe5b61013d600160a060020a0360043516610863565b604080519115158252519081900360200190f35b34156102f057fe5b6100df600160a060020a0360043516610885565b005b600160a060020a03331660009081526003602052604090205460ff16156103325760018190555b610338565b60006000fd5b5b50565b600160a060020a03331660009081526005602052604090205460ff1615610332576004546001141561036e5760006000fd5b600160a060020a03811660009081526005602052604090205460ff161561032d57600160a060020a038116600081815260056020908152604091829020805460ff1916905560048054600019019055815192835290517f8cee3054364d6799f1c8962580ad61273d9d38ca1ff26516bd1ad23c099a60229281900390910190a15b5b610338565b60006000fd5b5b50565b60025481565b60056020526000908152604090205460ff1681565b60015481565b600080808084158061043457506000548590105b1561044157829350610537565b60008054600019870190811061045357fe5b906000526020600020906002020160005b509150600090505b60018201548110156105335760018201805483916000918490811061048d57fe5b906000526020600020900160005b505481526020808201929092526040908101600090812054868252928590522054108015610502575060015482600001600084600101848154811015156104de57fe5b906000526020600020900160005b5054815260208101919091526040016000205410155b1561052a576001820180548290811061051757fe5b906000526020600020900160005b505492505b5b60010161046c565b8293505b505050919050565b600160a060020a03331660009081526003602052604081205460ff161561033257600054839010156105805760008054808503019061057e908261093d565b505b60008054600019850190811061059257fe5b906000526020600020906002020160005b5060008381526020829052604090205490915015156105e6578060010180548060010182816105d2919061096f565b916000526020600020900160005b50839055505b600082815260208281526040918290208054600101905581518581529081018490528151600160a060020a033316927f3d03ba7f4b5227cdb385f2610906e5bcee147171603ec40005b30915ad20e258928290030190a25b610649565b60006000fd5b5b505050565b600160a060020a03331660009081526005602052604090205460ff161561033257600160a060020a03811660009081526005602052604090205460ff16151561032d57600160a060020a038116600081815260056020908152604091829020805460ff19166001908117909155600480549091019055815192835290517f1a4ce6942f7aa91856332e618fc90159f13a340611a308f5d7327ba0707e56859281900390910190a15b5b610338565b60006000fd5b5b50565b600160a060020a03331660009081526003602052604090205460ff161561033257600254600114156107395760006000fd5b600160a060020a03811660009081526003602052604090205460ff161561032d57600160a060020a038116600081815260036020908152604091829020805460ff1916905560028054600019019055815192835290517f183393fc5cffbfc7d03d623966b85f76b9430f42d3aada2ac3f3deabc78899e89281900390910190a15b5b610338565b60006000fd5b5b50565b600060006000600185038154811015156107e057fe5b906000526020600020906002020160005b509050806001018381548110151561080557fe5b906000526020600020900160005b505491505b5092915050565b600160a060020a03811660009081526003602052604090205460ff165b919050565b60036020526000908152604090205460ff1681565b60045481565b6000545b90565b600160a060020a03811660009081526005602052604090205460ff165b919050565b600160a060020a03331660009081526003602052604090205460ff161561033257600160a060020a03811660009081526003602052604090205460ff16151561032d57600160a060020a038116600081815260036020908152604091829020805460ff19166001908117909155600280549091019055815192835290517f0ad2eca75347acd5160276fe4b5dad46987e4ff4af9e574195e3e9bc15d7e0ff9281900390910190a15b5b610338565b60006000fd5b5b50565b815481835581811511610649576002028160020283600052602060002091820191016106499190610999565b5b505050565b815481835581811511610649576000838152602090206106499181019083016109c6565b5b505050565b61086091905b808211156109bf5760006109b660018301826109e7565b5060020161099f565b5090565b90565b61086091905b808211156109bf57600081556001016109cc565b5090565b90565b508054600082559060005260206000209081019061033891906109c6565b5b505600a165627a7a72305820df91be6846d93d6718da2cc8c61239c8a2fcf7378c4bf162a1e021f868254edd0029"
 )
 
@@ -47,6 +52,8 @@ var (
 	errSyncing             = fmt.Errorf("Node synchronising with network")
 	errCouldNotVote        = fmt.Errorf("Not not configured/allowed to vote")
 	errCouldNotCreateBlock = fmt.Errorf("Not not configured/allowed to create block")
+	errMaintenance         = fmt.Errorf("Node in maintenance")
+	errVoteDeferred        = fmt.Errorf("Vote deferred to the next batch")
 )
 
 // BlockVoting is a type of BlockMaker that uses a smart contract
@@ -66,12 +73,28 @@ type BlockVoting struct {
 
 	voteSession  *VotingContractSession
 	callContract *VotingContractCaller
+	ethClient    *ethclient.Client
 
-	bmk *ecdsa.PrivateKey
-	vk  *ecdsa.PrivateKey
+	signer BlockSigner // signs created blocks, nil if the node doesn't create blocks
+	vk     *ecdsa.PrivateKey
 
 	pStateMu sync.Mutex
 	pState   *pendingState
+
+	doubleProduction        *doubleProductionDetector
+	pauseOnDoubleProduction bool // pause local block creation if our key created competing blocks
+
+	maintenance int32 // neither create blocks nor vote while set, accessed atomically
+
+	voteBatchSize int            // votes sent per voteBatch transaction, 0 or 1 to vote individually
+	voteBatching  bool           // batch votes, set on start if the voting contract supports it
+	deferredVotes []*batchedVote // votes for decided heights waiting for the next batch, only used by the event loop
+}
+
+// batchedVote is a vote to be sent in a voteBatch transaction.
+type batchedVote struct {
+	height *big.Int
+	hash   common.Hash
 }
 
 // Vote is posted to the event mux when the BlockVoting instance
@@ -94,8 +117,12 @@ type CreateBlock struct {
 
 // NewBlockVoting creates a new BlockVoting instance.
 // blockMakerKey and/or voteKey can be nil in case this node doesn't create blocks or vote.
+// If pauseOnDoubleProduction is set, local block creation is paused when another
+// node is found to create blocks with this node's block maker key. Votes for
+// heights which already have enough votes are sent voteBatchSize at a time if
+// it's larger than 1.
 // Note, don't forget to call Start.
-func NewBlockVoting(bc *core.BlockChain, chainConfig *core.ChainConfig, txpool *core.TxPool, mux *event.TypeMux, db ethdb.Database, accountMgr *accounts.Manager) *BlockVoting {
+func NewBlockVoting(bc *core.BlockChain, chainConfig *core.ChainConfig, txpool *core.TxPool, mux *event.TypeMux, db ethdb.Database, accountMgr *accounts.Manager, pauseOnDoubleProduction bool, voteBatchSize int) *BlockVoting {
 	bv := &BlockVoting{
 		bc:           bc,
 		cc:           chainConfig,
@@ -104,6 +131,10 @@ func NewBlockVoting(bc *core.BlockChain, chainConfig *core.ChainConfig, txpool *
 		db:           db,
 		am:           accountMgr,
 		syncingChain: false,
+
+		doubleProduction:        newDoubleProductionDetector(),
+		pauseOnDoubleProduction: pauseOnDoubleProduction,
+		voteBatchSize:           voteBatchSize,
 	}
 
 	return bv
@@ -151,26 +182,31 @@ func (bv *BlockVoting) makeHeader(parent *types.Block) *types.Header {
 		time.Sleep(wait)
 	}
 
-	num := parent.Number()
+	num := new(big.Int).Add(parent.Number(), common.Big1)
+	gasLimit := core.CalcGasLimit(parent)
+	if bv.cc.RampsGasLimit(num) {
+		gasLimit = core.CalcRampedGasLimit(bv.cc, parent.Header())
+	}
+
 	header := &types.Header{
-		Number:     num.Add(num, common.Big1),
+		Number:     num,
 		ParentHash: parent.Hash(),
 		Difficulty: core.CalcDifficulty(bv.cc, uint64(tstamp), parent.Time().Uint64(), parent.Number(), parent.Difficulty()),
-		GasLimit:   core.CalcGasLimit(parent),
+		GasLimit:   gasLimit,
 		GasUsed:    new(big.Int),
 		Time:       big.NewInt(tstamp),
 	}
 
-	if bv.bmk != nil {
-		header.Coinbase = crypto.PubkeyToAddress(bv.bmk.PublicKey)
+	if bv.signer != nil {
+		header.Coinbase = bv.signer.Account()
 	}
 
 	return header
 }
 
 // Start runs the event loop.
-func (bv *BlockVoting) Start(client *rpc.Client, strat BlockVoteMakerStrategy, voteKey, blockMakerKey *ecdsa.PrivateKey) error {
-	bv.bmk = blockMakerKey
+func (bv *BlockVoting) Start(client *rpc.Client, strat BlockVoteMakerStrategy, voteKey *ecdsa.PrivateKey, blockSigner BlockSigner) error {
+	bv.signer = blockSigner
 	bv.vk = voteKey
 
 	ethClient := ethclient.NewClient(client)
@@ -179,6 +215,7 @@ func (bv *BlockVoting) Start(client *rpc.Client, strat BlockVoteMakerStrategy, v
 		return err
 	}
 	bv.callContract = callContract
+	bv.ethClient = ethClient
 
 	if voteKey != nil {
 		contract, err := NewVotingContract(params.QuorumVotingContractAddr, ethClient)
@@ -194,9 +231,22 @@ func (bv *BlockVoting) Start(client *rpc.Client, strat BlockVoteMakerStrategy, v
 			},
 			TransactOpts: bind.TransactOpts{
 				From:   auth.From,
-				Signer: auth.Signer,
+				Signer: bv.voteSigner(voteKey),
 			},
 		}
+
+		if bv.voteBatchSize > 1 {
+			publicState, _, err := bv.bc.State()
+			if err != nil {
+				return err
+			}
+			if supportsVoteBatch(publicState.GetCode(params.QuorumVotingContractAddr)) {
+				glog.V(logger.Info).Infof("Batching up to %d votes per transaction", bv.voteBatchSize)
+				bv.voteBatching = true
+			} else {
+				glog.V(logger.Warn).Infoln("Voting contract doesn't support voteBatch, voting individually")
+			}
+		}
 	}
 
 	bv.run(strat)
@@ -204,22 +254,43 @@ func (bv *BlockVoting) Start(client *rpc.Client, strat BlockVoteMakerStrategy, v
 	return nil
 }
 
+// voteSigner returns a signer for vote transactions that replay protects them
+// once EIP-155 is active for the next block.
+func (bv *BlockVoting) voteSigner(voteKey *ecdsa.PrivateKey) bind.SignerFn {
+	unprotected := bind.NewKeyedTransactor(voteKey).Signer
+	if bv.cc.ChainId == nil || bv.cc.EIP155Block == nil {
+		return unprotected
+	}
+	protected := bind.NewKeyedEIP155Transactor(voteKey, bv.cc.ChainId).Signer
+	return func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+		next := new(big.Int).Add(bv.bc.CurrentBlock().Number(), common.Big1)
+		if bv.cc.IsEIP155(next) {
+			return protected(address, tx)
+		}
+		return unprotected(address, tx)
+	}
+}
+
 func (bv *BlockVoting) run(strat BlockVoteMakerStrategy) {
-	if bv.bmk != nil {
-		glog.Infof("Node configured for block creation: %s", crypto.PubkeyToAddress(bv.bmk.PublicKey).Hex())
+	if bv.signer != nil {
+		glog.Infof("Node configured for block creation: %s", bv.signer.Account().Hex())
 	}
 	if bv.vk != nil {
 		glog.Infof("Node configured for block voting: %s", crypto.PubkeyToAddress(bv.vk.PublicKey).Hex())
 	}
 
-	sub := bv.mux.Subscribe(downloader.StartEvent{},
+	sub := bv.mux.SubscribeWith(event.SubscriptionConfig{Name: "quorum/blockvoting", Buffer: 256},
+		downloader.StartEvent{},
 		downloader.DoneEvent{},
 		downloader.FailedEvent{},
 		core.ChainHeadEvent{},
+		core.ChainEvent{},
+		core.ChainSideEvent{},
 		core.TxPreEvent{},
 		Vote{},
 		CreateBlock{})
 
+	bv.checkKeyRotation(bv.bc.CurrentBlock())
 	bv.resetPendingState(bv.bc.CurrentBlock())
 
 	go func() {
@@ -244,10 +315,26 @@ func (bv *BlockVoting) run(strat BlockVoteMakerStrategy) {
 					strat.ResumeVoting()
 					bv.syncingChain = false
 				case core.ChainHeadEvent: // got a new header, reset pending state
+					bv.checkKeyRotation(e.Block)
 					bv.resetPendingState(e.Block)
+				case core.ChainEvent: // block imported, check its maker didn't create a competing one and index its votes
+					bv.checkDoubleProduction(e.Block, strat)
+					if _, err := bv.indexVotes(e.Block, e.Logs); err != nil {
+						glog.V(logger.Error).Infof("Failed to index votes of block #%d [%x…]: %v", e.Block.Number(), e.Hash.Bytes()[:4], err)
+					}
+				case core.ChainSideEvent:
+					bv.checkDoubleProduction(e.Block, strat)
 				case core.TxPreEvent: // tx entered pool, apply to pending state
 					bv.applyTransaction(e.Tx)
 				case Vote:
+					// node is in maintenance, leave voting to the others
+					if bv.inMaintenance() {
+						if e.Err != nil {
+							e.Err <- errMaintenance
+						}
+						continue
+					}
+
 					// node is currently catching up with the chain
 					if bv.syncingChain {
 						if e.Err != nil {
@@ -274,7 +361,9 @@ func (bv *BlockVoting) run(strat BlockVoteMakerStrategy) {
 					}
 
 					txHash, err := bv.vote(e.Number, e.Hash, e.Err != nil)
-					if err == nil && e.TxHash != nil {
+					if err == errVoteDeferred {
+						glog.V(logger.Detail).Infof("Deferred vote for height %v to the next batch", e.Number)
+					} else if err == nil && e.TxHash != nil {
 						e.TxHash <- txHash
 					} else if err != nil && e.Err != nil {
 						e.Err <- err
@@ -285,6 +374,13 @@ func (bv *BlockVoting) run(strat BlockVoteMakerStrategy) {
 					}
 
 				case CreateBlock:
+					if bv.inMaintenance() {
+						if e.Err != nil {
+							e.Err <- errMaintenance
+						}
+						continue
+					}
+
 					if bv.syncingChain {
 						if e.Err != nil {
 							e.Err <- errSyncing
@@ -315,12 +411,45 @@ func (bv *BlockVoting) run(strat BlockVoteMakerStrategy) {
 	}()
 }
 
+// checkDoubleProduction alerts if the maker of the given block has created a
+// different block at the same height, and pauses local block creation if the
+// offending key is ours and the node is configured to do so.
+func (bv *BlockVoting) checkDoubleProduction(block *types.Block, strat BlockVoteMakerStrategy) {
+	ev := bv.doubleProduction.observe(block)
+	if ev == nil {
+		return
+	}
+	doubleProductionMeter.Mark(1)
+	glog.Errorf("DOUBLE PRODUCTION: block maker %s sealed competing blocks %x and %x on the same parent at height %v. Is its key configured on more than one node?", ev.BlockMaker.Hex(), ev.Hashes[0], ev.Hashes[1], ev.Number)
+
+	if bv.signer != nil && bv.signer.Account() == ev.BlockMaker && bv.pauseOnDoubleProduction {
+		glog.Errorf("DOUBLE PRODUCTION: competing blocks were created with this node's block maker key, pausing block creation")
+		strat.PauseBlockMaking()
+	}
+	// post in different go-routine, subscribers may be waiting on this loop
+	go bv.mux.Post(*ev)
+}
+
+// SetMaintenance stops or resumes block creation and voting. Unlike pausing
+// the strategy, maintenance isn't lifted when the node finished syncing.
+func (bv *BlockVoting) SetMaintenance(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&bv.maintenance, v)
+}
+
+func (bv *BlockVoting) inMaintenance() bool {
+	return atomic.LoadInt32(&bv.maintenance) == 1
+}
+
 func (bv *BlockVoting) canCreateBlocks() bool {
-	if bv.bmk == nil {
+	if bv.signer == nil {
 		return false
 	}
 
-	r, err := bv.isBlockMaker(crypto.PubkeyToAddress(bv.bmk.PublicKey))
+	r, err := bv.isBlockMaker(bv.signer.Account())
 	if err != nil {
 		glog.Errorf("Could not determine is node is allowed to create blocks: %v", err)
 		return false
@@ -351,6 +480,61 @@ func (bv *BlockVoting) applyTransaction(tx *types.Transaction) {
 	bv.pStateMu.Unlock()
 }
 
+// NodeInfo describes the QuorumChain role of the node: its block maker and
+// vote accounts and whether the voting contract allows them to create blocks
+// and vote.
+func (bv *BlockVoting) NodeInfo() map[string]interface{} {
+	result := map[string]interface{}{"engine": "quorumchain"}
+	if bv.callContract == nil { // not started
+		return result
+	}
+
+	var roles []string
+	if bv.signer != nil {
+		addr := bv.signer.Account()
+		allowed, _ := bv.callContract.IsBlockMaker(nil, addr)
+		result["blockMakerAccount"] = addr
+		result["canCreateBlocks"] = allowed
+		signer := bv.signer
+		if rotating, ok := signer.(*RotatingSigner); ok {
+			result["nextBlockMakerAccount"] = rotating.next.Account()
+			result["keyRotationBlock"] = rotating.activation
+			result["keyRotationActive"] = rotating.Activated()
+			signer = rotating.current
+		}
+		if signer, ok := signer.(interface {
+			Healthy() bool
+		}); ok {
+			result["remoteSignerHealthy"] = signer.Healthy()
+		}
+		if Strategy != nil {
+			result["blockmakestrategy"] = Strategy
+		}
+		roles = append(roles, "blockmaker")
+	}
+
+	if bv.vk != nil {
+		addr := crypto.PubkeyToAddress(bv.vk.PublicKey)
+		allowed, _ := bv.callContract.IsVoter(nil, addr)
+		result["voteAccount"] = addr
+		result["canVote"] = allowed
+		roles = append(roles, "voter")
+	}
+	if len(roles) == 0 {
+		roles = append(roles, "observer")
+	}
+	result["roles"] = roles
+
+	if threshold, err := bv.callContract.VoteThreshold(nil); err == nil {
+		result["voteThreshold"] = threshold
+	}
+	if voters, err := bv.callContract.VoterCount(nil); err == nil {
+		result["voterCount"] = voters
+	}
+
+	return result
+}
+
 func (bv *BlockVoting) Pending() (*types.Block, *state.StateDB, *state.StateDB) {
 	bv.pStateMu.Lock()
 	defer bv.pStateMu.Unlock()
@@ -358,7 +542,7 @@ func (bv *BlockVoting) Pending() (*types.Block, *state.StateDB, *state.StateDB)
 }
 
 func (bv *BlockVoting) createBlock() (*types.Block, error) {
-	if bv.bmk == nil {
+	if bv.signer == nil {
 		return nil, fmt.Errorf("Node not configured for block creation")
 	}
 
@@ -380,6 +564,13 @@ func (bv *BlockVoting) createBlock() (*types.Block, error) {
 		return nil, fmt.Errorf("Winning parent block [0x%x] differs than pending block parent [0x%x]", ch, bv.pState.header.Hash())
 	}
 
+	if account := bv.signer.Account(); account != bv.pState.header.Coinbase {
+		// the signer failed over to another block maker account since the
+		// pending block was started, rebuild it for that account
+		bv.resetPendingState(bv.pState.parent)
+		return nil, fmt.Errorf("Block maker account changed to %s, pending block reset", account.Hex())
+	}
+
 	bv.pStateMu.Lock()
 	defer bv.pStateMu.Unlock()
 
@@ -394,7 +585,7 @@ func (bv *BlockVoting) createBlock() (*types.Block, error) {
 	// Quorum blocks contain a signature of the header in the Extra field.
 	// This signature is verified during block import and ensures that the
 	// block is created by a party that is allowed to create blocks.
-	signature, err := crypto.Sign(header.QuorumHash().Bytes(), bv.bmk)
+	signature, err := bv.signer.SignHeader(header.Coinbase, header)
 	if err != nil {
 		return nil, err
 	}
@@ -414,6 +605,10 @@ func (bv *BlockVoting) createBlock() (*types.Block, error) {
 		return nil, err
 	}
 
+	if err := core.WriteAccountBlockSigned(bv.db, header.Coinbase, block.NumberU64(), block.Hash()); err != nil {
+		glog.V(logger.Error).Infof("Failed to record block signed by %x: %v", header.Coinbase, err)
+	}
+
 	bv.mux.Post(core.NewMinedBlockEvent{Block: block})
 
 	return block, nil
@@ -431,6 +626,7 @@ func (bv *BlockVoting) vote(height *big.Int, hash common.Hash, force bool) (comm
 		return common.Hash{}, fmt.Errorf("%s is not allowed to vote", bv.voteSession.TransactOpts.From.Hex())
 	}
 
+	votes := []*batchedVote{{height: height, hash: hash}}
 	if !force {
 		if ch, err := bv.canonHash(height.Uint64()); err == nil && ch != (common.Hash{}) {
 			// already enough votes, test if this node already has voted, if so don't vote again
@@ -440,17 +636,43 @@ func (bv *BlockVoting) vote(height *big.Int, hash common.Hash, force bool) (comm
 			if alreadyVoted {
 				return common.Hash{}, fmt.Errorf("Node already voted on this height")
 			}
+			// the vote isn't needed for the height to be decided, it can wait for the next batch
+			if bv.voteBatching && !bv.deferVote(height, hash) {
+				return common.Hash{}, errVoteDeferred
+			}
+		}
+	}
+	if bv.voteBatching {
+		// send the deferred votes along, the vote for height replacing an earlier one
+		for _, v := range bv.deferredVotes {
+			if v.height.Cmp(height) != 0 {
+				votes = append(votes, v)
+			}
 		}
+		sort.Sort(votesByHeight(votes))
 	}
 
 	nonce := bv.txpool.Nonce(bv.voteSession.TransactOpts.From)
 	bv.voteSession.TransactOpts.Nonce = new(big.Int).SetUint64(nonce)
 	defer func() { bv.voteSession.TransactOpts.Nonce = nil }()
 
-	tx, err := bv.voteSession.Vote(height, hash)
+	var tx *types.Transaction
+	if len(votes) == 1 {
+		tx, err = bv.voteSession.Vote(height, hash)
+	} else {
+		heights, hashes := make([]*big.Int, len(votes)), make([][32]byte, len(votes))
+		for i, v := range votes {
+			heights[i], hashes[i] = v.height, v.hash
+		}
+		tx, err = bv.voteSession.VoteBatch(heights, hashes)
+	}
 	if err != nil {
 		return common.Hash{}, err
 	}
+	bv.deferredVotes = nil
+	if len(votes) > 1 {
+		glog.V(logger.Debug).Infof("Sent %d votes for heights %v-%v in one transaction", len(votes), votes[0].height, votes[len(votes)-1].height)
+	}
 
 	bv.pStateMu.Lock()
 	if height.Uint64() == bv.pState.header.Number.Uint64() {
@@ -458,6 +680,77 @@ func (bv *BlockVoting) vote(height *big.Int, hash common.Hash, force bool) (comm
 	}
 	bv.pStateMu.Unlock()
 
+	for _, v := range votes {
+		if err := core.WriteAccountBlockVoted(bv.db, bv.voteSession.TransactOpts.From, v.height.Uint64(), v.hash); err != nil {
+			glog.V(logger.Error).Infof("Failed to record vote of %x: %v", bv.voteSession.TransactOpts.From, err)
+		}
+	}
+
+	return tx.Hash(), nil
+}
+
+// deferVote holds back a vote for a height which already has enough votes,
+// replacing an earlier vote for the height. It reports whether the deferred
+// votes are due: once there are voteBatchSize of them, or before the oldest
+// would be included too late to count towards the voting history.
+//
+// Deferred votes are only kept in memory and are dropped when the node stops.
+// As their heights were decided without them, that only leaves them out of
+// the voting history of the voter.
+func (bv *BlockVoting) deferVote(height *big.Int, hash common.Hash) bool {
+	for _, v := range bv.deferredVotes {
+		if v.height.Cmp(height) == 0 {
+			v.hash = hash
+			return false
+		}
+	}
+	if len(bv.deferredVotes)+1 >= bv.voteBatchSize {
+		return true
+	}
+	if len(bv.deferredVotes) > 0 && new(big.Int).Sub(height, bv.deferredVotes[0].height).Int64() >= maxVoteDeferral {
+		return true
+	}
+	bv.deferredVotes = append(bv.deferredVotes, &batchedVote{height: height, hash: hash})
+
+	bv.pStateMu.Lock()
+	if height.Uint64() == bv.pState.header.Number.Uint64() {
+		bv.pState.alreadyVoted = true
+	}
+	bv.pStateMu.Unlock()
+	return false
+}
+
+type votesByHeight []*batchedVote
+
+func (v votesByHeight) Len() int           { return len(v) }
+func (v votesByHeight) Less(i, j int) bool { return v[i].height.Cmp(v[j].height) < 0 }
+func (v votesByHeight) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+
+// setVoteThreshold sends a transaction to the voting contract that changes the
+// number of votes a block needs before it is considered canonical.
+func (bv *BlockVoting) setVoteThreshold(threshold *big.Int) (common.Hash, error) {
+	if bv.voteSession == nil {
+		return common.Hash{}, fmt.Errorf("Node is not configured for voting")
+	}
+	if threshold.Sign() <= 0 {
+		return common.Hash{}, fmt.Errorf("vote threshold must be larger than 0")
+	}
+	voterCount, err := bv.callContract.VoterCount(nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if threshold.Cmp(voterCount) > 0 {
+		return common.Hash{}, fmt.Errorf("vote threshold %v exceeds the number of voters (%v)", threshold, voterCount)
+	}
+
+	nonce := bv.txpool.Nonce(bv.voteSession.TransactOpts.From)
+	bv.voteSession.TransactOpts.Nonce = new(big.Int).SetUint64(nonce)
+	defer func() { bv.voteSession.TransactOpts.Nonce = nil }()
+
+	tx, err := bv.voteSession.SetVoteThreshold(threshold)
+	if err != nil {
+		return common.Hash{}, err
+	}
 	return tx.Hash(), nil
 }
 
@@ -98,6 +98,16 @@ contract BlockVoting {
 		Vote(msg.sender, height, hash);
 	}
 
+    // Make a vote for each of the given heights, see vote. Lets voters send
+    // their votes for heights that already have enough votes in one transaction.
+	function voteBatch(uint[] heights, bytes32[] hashes) mustBeVoter {
+	    if (heights.length != hashes.length) throw;
+
+	    for (uint i = 0; i < heights.length; i++) {
+	        vote(heights[i], hashes[i]);
+	    }
+	}
+
     // Get canonical head for a given block number.
     // E.g. [block 124] - [block 125] - [block 126 (pending)]
     // getCanonHash(126) will return the hash of block 125
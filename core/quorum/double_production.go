@@ -0,0 +1,94 @@
+package quorum
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// doubleProductionWindow is the number of heights below the highest seen block
+// for which produced blocks are remembered.
+const doubleProductionWindow = 256
+
+var doubleProductionMeter = metrics.NewMeter("quorum/blockmaker/doubleproduction")
+
+// DoubleProductionEvent is posted when two different blocks sealed by the
+// same block maker are seen on top of the same parent. This happens when
+// multiple nodes are (mis)configured with the same block maker key, which
+// silently forks the network.
+type DoubleProductionEvent struct {
+	BlockMaker common.Address
+	Number     *big.Int
+	Hashes     [2]common.Hash
+}
+
+// producedKey identifies the blocks a block maker created on a parent.
+type producedKey struct {
+	parent common.Hash
+	maker  common.Address
+}
+
+// doubleProductionDetector remembers which block each block maker created on
+// the parents at recent heights. Blocks a block maker creates at a height it
+// already created a block at, but on another parent, aren't double production:
+// block makers rebuild on the new head after a reorg.
+type doubleProductionDetector struct {
+	seen    map[uint64]map[producedKey]common.Hash
+	highest uint64
+}
+
+func newDoubleProductionDetector() *doubleProductionDetector {
+	return &doubleProductionDetector{
+		seen: make(map[uint64]map[producedKey]common.Hash),
+	}
+}
+
+// observe records the given block and returns a DoubleProductionEvent if its
+// block maker already sealed a different block on the same parent. Blocks not
+// sealed by their block maker are ignored.
+func (d *doubleProductionDetector) observe(block *types.Block) *DoubleProductionEvent {
+	var (
+		number = block.NumberU64()
+		key    = producedKey{parent: block.ParentHash(), maker: block.Coinbase()}
+		hash   = block.Hash()
+	)
+	if number+doubleProductionWindow < d.highest || !sealedByMaker(block.Header()) {
+		return nil // too old to tell, or not created by the block maker
+	}
+	if number > d.highest {
+		d.highest = number
+		for n := range d.seen {
+			if n+doubleProductionWindow < d.highest {
+				delete(d.seen, n)
+			}
+		}
+	}
+	produced, ok := d.seen[number]
+	if !ok {
+		produced = make(map[producedKey]common.Hash)
+		d.seen[number] = produced
+	}
+	prev, ok := produced[key]
+	if !ok {
+		produced[key] = hash
+		return nil
+	}
+	if prev == hash {
+		return nil
+	}
+	return &DoubleProductionEvent{
+		BlockMaker: key.maker,
+		Number:     block.Number(),
+		Hashes:     [2]common.Hash{prev, hash},
+	}
+}
+
+// sealedByMaker reports whether the header carries the signature of its block
+// maker.
+func sealedByMaker(header *types.Header) bool {
+	pub, err := crypto.SigToPub(header.QuorumHash().Bytes(), header.Extra)
+	return err == nil && crypto.PubkeyToAddress(*pub) == header.Coinbase
+}
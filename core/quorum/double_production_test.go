@@ -0,0 +1,81 @@
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// sealedBlock returns a block at the given height on parent, sealed by key.
+func sealedBlock(t *testing.T, key *ecdsa.PrivateKey, number int64, parent common.Hash, time int64) *types.Block {
+	header := &types.Header{
+		ParentHash: parent,
+		Number:     big.NewInt(number),
+		Time:       big.NewInt(time),
+		Coinbase:   crypto.PubkeyToAddress(key.PublicKey),
+	}
+	sig, err := crypto.Sign(header.QuorumHash().Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Extra = sig
+	return types.NewBlockWithHeader(header)
+}
+
+func TestDoubleProduction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	var (
+		parent  = common.HexToHash("0x01")
+		first   = sealedBlock(t, key, 10, parent, 1)
+		second  = sealedBlock(t, key, 10, parent, 2)
+		rebuilt = sealedBlock(t, key, 10, common.HexToHash("0x02"), 3)
+	)
+	d := newDoubleProductionDetector()
+	if ev := d.observe(first); ev != nil {
+		t.Fatalf("first block flagged: %v", ev)
+	}
+	if ev := d.observe(first); ev != nil {
+		t.Errorf("same block seen twice flagged: %v", ev)
+	}
+	// A rebuild on the new head after a reorg isn't double production
+	if ev := d.observe(rebuilt); ev != nil {
+		t.Errorf("block on another parent flagged: %v", ev)
+	}
+	// Neither is a block of another block maker on the same parent
+	if ev := d.observe(sealedBlock(t, other, 10, parent, 2)); ev != nil {
+		t.Errorf("block of another block maker flagged: %v", ev)
+	}
+	// Nor a block which isn't sealed by its block maker
+	forged := types.NewBlockWithHeader(&types.Header{ParentHash: parent, Number: big.NewInt(10), Coinbase: first.Coinbase(), Root: common.HexToHash("0x03"), Extra: second.Extra()})
+	if ev := d.observe(forged); ev != nil {
+		t.Errorf("unsealed block flagged: %v", ev)
+	}
+
+	ev := d.observe(second)
+	if ev == nil {
+		t.Fatalf("competing block on the same parent not flagged")
+	}
+	if ev.BlockMaker != first.Coinbase() || ev.Number.Int64() != 10 || ev.Hashes != [2]common.Hash{first.Hash(), second.Hash()} {
+		t.Errorf("event mismatch: %+v", ev)
+	}
+}
+
+func TestDoubleProductionWindow(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	parent := common.HexToHash("0x01")
+
+	d := newDoubleProductionDetector()
+	d.observe(sealedBlock(t, key, 1, parent, 1))
+	d.observe(sealedBlock(t, key, 1+doubleProductionWindow+1, common.HexToHash("0x02"), 1))
+	if len(d.seen) != 1 {
+		t.Errorf("old heights not forgotten: %d heights remembered", len(d.seen))
+	}
+	if ev := d.observe(sealedBlock(t, key, 1, parent, 2)); ev != nil {
+		t.Errorf("block below the window flagged: %v", ev)
+	}
+}
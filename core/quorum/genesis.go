@@ -0,0 +1,62 @@
+package quorum
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Storage slots of the state variables of the voting contract, in the order
+// they are declared in block_voting.sol.
+const (
+	slotPeriods         = 0
+	slotVoteThreshold   = 1
+	slotVoterCount      = 2
+	slotCanVote         = 3
+	slotBlockMakerCount = 4
+	slotCanCreateBlocks = 5
+)
+
+// GenesisStorage returns the storage of the voting contract in the genesis
+// block, admitting the given voters and block makers with a vote threshold.
+func GenesisStorage(voters, blockMakers []common.Address, threshold int) (map[common.Hash]common.Hash, error) {
+	if len(voters) == 0 {
+		return nil, errors.New("no voters")
+	}
+	if len(blockMakers) == 0 {
+		return nil, errors.New("no block makers")
+	}
+	if threshold < 1 || threshold > len(voters) {
+		return nil, fmt.Errorf("vote threshold %d out of range 1 to %d voters", threshold, len(voters))
+	}
+	one := common.BigToHash(big.NewInt(1))
+	storage := map[common.Hash]common.Hash{
+		common.BigToHash(big.NewInt(slotVoteThreshold)):   common.BigToHash(big.NewInt(int64(threshold))),
+		common.BigToHash(big.NewInt(slotVoterCount)):      common.BigToHash(big.NewInt(int64(len(voters)))),
+		common.BigToHash(big.NewInt(slotBlockMakerCount)): common.BigToHash(big.NewInt(int64(len(blockMakers)))),
+	}
+	for _, addr := range voters {
+		slot := MappingSlot(addr, slotCanVote)
+		if _, ok := storage[slot]; ok {
+			return nil, fmt.Errorf("duplicate voter %x", addr)
+		}
+		storage[slot] = one
+	}
+	for _, addr := range blockMakers {
+		slot := MappingSlot(addr, slotCanCreateBlocks)
+		if _, ok := storage[slot]; ok {
+			return nil, fmt.Errorf("duplicate block maker %x", addr)
+		}
+		storage[slot] = one
+	}
+	return storage, nil
+}
+
+// MappingSlot returns the storage slot of a key of a Solidity mapping declared
+// at the given slot.
+func MappingSlot(key common.Address, slot int64) common.Hash {
+	return crypto.Keccak256Hash(common.LeftPadBytes(key[:], 32), common.BigToHash(big.NewInt(slot)).Bytes())
+}
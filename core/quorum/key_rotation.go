@@ -0,0 +1,208 @@
+package quorum
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"io"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// RotatingSigner rotates the block maker key without downtime. Blocks are
+// signed by the current signer until the chain reaches the activation block,
+// from then on by the next key. The next key is registered as block maker in
+// the voting contract ahead of the activation, and the previous account is
+// removed once the next key signed its first block.
+//
+// Blocks are validated against the block makers in the state of their parent,
+// so blocks signed with the previous key stay valid after it's removed.
+type RotatingSigner struct {
+	current    BlockSigner
+	next       *LocalSigner
+	activation uint64
+
+	activated int32       // set while blocks are signed by the next key, accessed atomically
+	pendingTx common.Hash // last transaction of the rotation sent, only accessed by the event loop
+}
+
+// NewRotatingSigner creates a signer switching from current to the next key at
+// the given block.
+func NewRotatingSigner(current BlockSigner, next *ecdsa.PrivateKey, activation uint64) *RotatingSigner {
+	return &RotatingSigner{current: current, next: NewLocalSigner(next), activation: activation}
+}
+
+// Account implements BlockSigner.
+func (s *RotatingSigner) Account() common.Address {
+	if s.Activated() {
+		return s.next.Account()
+	}
+	return s.current.Account()
+}
+
+// SignHeader implements BlockSigner. The next key doesn't sign blocks below the
+// activation block.
+func (s *RotatingSigner) SignHeader(account common.Address, header *types.Header) ([]byte, error) {
+	if account == s.next.Account() {
+		if header.Number.Uint64() < s.activation {
+			return nil, fmt.Errorf("block maker key %s not active before block %d", account.Hex(), s.activation)
+		}
+		return s.next.SignHeader(account, header)
+	}
+	return s.current.SignHeader(account, header)
+}
+
+// Close closes the current signer if it holds a connection, e.g. to a remote
+// signer.
+func (s *RotatingSigner) Close() error {
+	if closer, ok := s.current.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Activated reports whether blocks are signed by the next key.
+func (s *RotatingSigner) Activated() bool {
+	return atomic.LoadInt32(&s.activated) == 1
+}
+
+// currentKey returns the key of the current block maker account if the node
+// holds it, to send the transactions of the rotation with.
+func (s *RotatingSigner) currentKey() *ecdsa.PrivateKey {
+	switch signer := s.current.(type) {
+	case *LocalSigner:
+		return signer.key
+	case *FailoverSigner:
+		if signer.local.Account() == signer.remote.Account() {
+			return signer.local.key
+		}
+	}
+	return nil
+}
+
+// rotationTx is a transaction advancing the key rotation.
+type rotationTx int
+
+const (
+	rotationNone     rotationTx = iota
+	rotationRegister            // register the next key with addBlockMaker
+	rotationRetire              // remove the previous account with removeBlockMaker
+)
+
+// update derives the state of the rotation on a new chain head from whether
+// the next and the previous account are block makers in its state. It switches
+// to the next key for the block at the activation height if it's registered,
+// and returns the transaction needed to advance the rotation: registering the
+// next key before, and removing the previous account once the next key signed
+// the head.
+func (s *RotatingSigner) update(head *types.Header, nextRegistered, prevRegistered bool) rotationTx {
+	activated := int32(0)
+	if nextRegistered && head.Number.Uint64()+1 >= s.activation {
+		activated = 1
+	}
+	atomic.StoreInt32(&s.activated, activated)
+
+	switch {
+	case !nextRegistered:
+		return rotationRegister
+	case activated == 1 && prevRegistered && head.Coinbase == s.next.Account():
+		return rotationRetire
+	}
+	return rotationNone
+}
+
+// checkKeyRotation advances the block maker key rotation, if one is configured,
+// on a new chain head. The state of the rotation is derived from the voting
+// contract on every head, so transactions of the rotation that were dropped
+// are sent again.
+func (bv *BlockVoting) checkKeyRotation(head *types.Block) {
+	signer, ok := bv.signer.(*RotatingSigner)
+	if !ok || bv.callContract == nil {
+		return
+	}
+	var (
+		prev = signer.current.Account()
+		next = signer.next.Account()
+	)
+	nextRegistered, err := bv.isBlockMaker(next)
+	if err != nil {
+		glog.V(logger.Error).Infof("Could not determine if block maker %s is registered: %v", next.Hex(), err)
+		return
+	}
+	prevRegistered, err := bv.isBlockMaker(prev)
+	if err != nil {
+		glog.V(logger.Error).Infof("Could not determine if block maker %s is registered: %v", prev.Hex(), err)
+		return
+	}
+	activated := signer.Activated()
+	action := signer.update(head.Header(), nextRegistered, prevRegistered)
+	switch {
+	case !activated && signer.Activated():
+		glog.V(logger.Info).Infof("Switched block maker key from %s to %s at block %d", prev.Hex(), next.Hex(), head.NumberU64()+1)
+	case activated && !signer.Activated():
+		glog.V(logger.Warn).Infof("Block maker %s no longer registered, signing with %s again", next.Hex(), prev.Hex())
+	case !nextRegistered && head.NumberU64()+1 >= signer.activation:
+		glog.V(logger.Warn).Infof("Block maker %s not registered at activation block %d, still signing with %s", next.Hex(), signer.activation, prev.Hex())
+	}
+
+	// Wait for a transaction sent on an earlier head to be included or dropped
+	if action == rotationNone || (signer.pendingTx != (common.Hash{}) && bv.txpool.Get(signer.pendingTx) != nil) {
+		return
+	}
+	var tx *types.Transaction
+	switch action {
+	case rotationRegister:
+		key := signer.currentKey()
+		if key == nil {
+			glog.V(logger.Debug).Infof("No local key for block maker %s, register %s with addBlockMaker from another block maker", prev.Hex(), next.Hex())
+			return
+		}
+		tx, err = bv.transactBlockMaker(key, func(session *VotingContractSession) (*types.Transaction, error) {
+			return session.AddBlockMaker(next)
+		})
+		if err != nil {
+			glog.V(logger.Error).Infof("Failed to register block maker %s: %v", next.Hex(), err)
+			return
+		}
+		glog.V(logger.Info).Infof("Registering block maker %s for activation at block %d: tx %x", next.Hex(), signer.activation, tx.Hash())
+
+	case rotationRetire:
+		tx, err = bv.transactBlockMaker(signer.next.key, func(session *VotingContractSession) (*types.Transaction, error) {
+			return session.RemoveBlockMaker(prev)
+		})
+		if err != nil {
+			glog.V(logger.Error).Infof("Failed to remove previous block maker %s: %v", prev.Hex(), err)
+			return
+		}
+		glog.V(logger.Info).Infof("Removing previous block maker %s: tx %x", prev.Hex(), tx.Hash())
+	}
+	signer.pendingTx = tx.Hash()
+}
+
+// transactBlockMaker sends a transaction to the voting contract from the given
+// block maker key.
+func (bv *BlockVoting) transactBlockMaker(key *ecdsa.PrivateKey, send func(*VotingContractSession) (*types.Transaction, error)) (*types.Transaction, error) {
+	contract, err := NewVotingContract(params.QuorumVotingContractAddr, bv.ethClient)
+	if err != nil {
+		return nil, err
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	return send(&VotingContractSession{
+		Contract: contract,
+		CallOpts: bind.CallOpts{
+			Pending: true,
+		},
+		TransactOpts: bind.TransactOpts{
+			From:   from,
+			Nonce:  new(big.Int).SetUint64(bv.txpool.Nonce(from)),
+			Signer: bv.voteSigner(key),
+		},
+	})
+}
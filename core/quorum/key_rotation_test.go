@@ -0,0 +1,76 @@
+package quorum
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that the next key signs from the activation block on, and only while
+// it's registered as block maker.
+func TestKeyRotationSwitchover(t *testing.T) {
+	currentKey, _ := crypto.GenerateKey()
+	nextKey, _ := crypto.GenerateKey()
+	current, next := crypto.PubkeyToAddress(currentKey.PublicKey), crypto.PubkeyToAddress(nextKey.PublicKey)
+	signer := NewRotatingSigner(NewLocalSigner(currentKey), nextKey, 10)
+
+	tests := []struct {
+		head                           int64
+		coinbase                       common.Address
+		nextRegistered, prevRegistered bool
+		action                         rotationTx
+		account                        common.Address
+	}{
+		// Registration is requested on every head until it's included
+		{head: 5, coinbase: current, prevRegistered: true, action: rotationRegister, account: current},
+		{head: 6, coinbase: current, prevRegistered: true, action: rotationRegister, account: current},
+		// Registered ahead of the activation block
+		{head: 7, coinbase: current, nextRegistered: true, prevRegistered: true, account: current},
+		{head: 8, coinbase: current, nextRegistered: true, prevRegistered: true, account: current},
+		// The block at the activation height is created by the next key
+		{head: 9, coinbase: current, nextRegistered: true, prevRegistered: true, account: next},
+		// The previous account is removed once the next key signed a block
+		{head: 10, coinbase: next, nextRegistered: true, prevRegistered: true, action: rotationRetire, account: next},
+		{head: 11, coinbase: next, nextRegistered: true, prevRegistered: true, action: rotationRetire, account: next},
+		{head: 12, coinbase: next, nextRegistered: true, account: next},
+	}
+	for i, tt := range tests {
+		header := testHeader(tt.head, tt.coinbase)
+		if action := signer.update(header, tt.nextRegistered, tt.prevRegistered); action != tt.action {
+			t.Errorf("test %d: action mismatch: have %v, want %v", i, action, tt.action)
+		}
+		if have := signer.Account(); have != tt.account {
+			t.Errorf("test %d: account mismatch: have %x, want %x", i, have, tt.account)
+		}
+	}
+
+	// The next key doesn't sign before the activation block
+	if _, err := signer.SignHeader(next, testHeader(9, next)); err == nil {
+		t.Errorf("next key signed before the activation block")
+	}
+	header := testHeader(10, next)
+	if sig, err := signer.SignHeader(next, header); err != nil || recoverSigner(t, header, sig) != next {
+		t.Errorf("next key didn't sign the activation block: %v", err)
+	}
+}
+
+// Tests that blocks are signed by the current key at the activation block if
+// the next key isn't registered in time.
+func TestKeyRotationNotRegistered(t *testing.T) {
+	currentKey, _ := crypto.GenerateKey()
+	nextKey, _ := crypto.GenerateKey()
+	current, next := crypto.PubkeyToAddress(currentKey.PublicKey), crypto.PubkeyToAddress(nextKey.PublicKey)
+	signer := NewRotatingSigner(NewLocalSigner(currentKey), nextKey, 10)
+
+	if action := signer.update(testHeader(9, current), false, true); action != rotationRegister {
+		t.Errorf("registration not requested at the activation block: %v", action)
+	}
+	if have := signer.Account(); have != current {
+		t.Errorf("account mismatch: have %x, want %x", have, current)
+	}
+	signer.update(testHeader(12, current), true, true)
+	if have := signer.Account(); have != next {
+		t.Errorf("account mismatch once registered: have %x, want %x", have, next)
+	}
+}
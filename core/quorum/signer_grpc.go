@@ -0,0 +1,89 @@
+package quorum
+
+import (
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Message types, client and server registration of the BlockSigner service
+// defined in signer.proto. They're written by hand rather than generated by
+// protoc: the struct tags tell the proto package how to encode the messages and
+// have to match the field numbers of signer.proto.
+
+type SignRequest struct {
+	Account []byte `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Hash    []byte `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	Number  uint64 `protobuf:"varint,3,opt,name=number,proto3" json:"number,omitempty"`
+	Header  []byte `protobuf:"bytes,4,opt,name=header,proto3" json:"header,omitempty"`
+}
+
+func (m *SignRequest) Reset()         { *m = SignRequest{} }
+func (m *SignRequest) String() string { return proto.CompactTextString(m) }
+func (*SignRequest) ProtoMessage()    {}
+
+type SignResponse struct {
+	Signature []byte `protobuf:"bytes,1,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *SignResponse) Reset()         { *m = SignResponse{} }
+func (m *SignResponse) String() string { return proto.CompactTextString(m) }
+func (*SignResponse) ProtoMessage()    {}
+
+// BlockSignerClient is the client API for the BlockSigner service.
+type BlockSignerClient interface {
+	Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error)
+}
+
+type blockSignerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBlockSignerClient(cc *grpc.ClientConn) BlockSignerClient {
+	return &blockSignerClient{cc}
+}
+
+func (c *blockSignerClient) Sign(ctx context.Context, in *SignRequest, opts ...grpc.CallOption) (*SignResponse, error) {
+	out := new(SignResponse)
+	if err := c.cc.Invoke(ctx, "/quorum.BlockSigner/Sign", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BlockSignerServer is the server API for the BlockSigner service.
+type BlockSignerServer interface {
+	Sign(context.Context, *SignRequest) (*SignResponse, error)
+}
+
+func RegisterBlockSignerServer(s *grpc.Server, srv BlockSignerServer) {
+	s.RegisterService(&_BlockSigner_serviceDesc, srv)
+}
+
+func _BlockSigner_Sign_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlockSignerServer).Sign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.BlockSigner/Sign"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlockSignerServer).Sign(ctx, req.(*SignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BlockSigner_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "quorum.BlockSigner",
+	HandlerType: (*BlockSignerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Sign",
+			Handler:    _BlockSigner_Sign_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "signer.proto",
+}
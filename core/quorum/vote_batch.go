@@ -0,0 +1,21 @@
+package quorum
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxVoteDeferral is the number of heights a vote is deferred for at most, so
+// it's included well within voteInclusionRange and counts towards the voting
+// history.
+const maxVoteDeferral = voteInclusionRange / 2
+
+var voteBatchSelector = crypto.Keccak256([]byte("voteBatch(uint256[],bytes32[])"))[:4]
+
+// supportsVoteBatch reports whether the voting contract code dispatches
+// voteBatch. Contracts compiled before it was added to block_voting.sol don't.
+func supportsVoteBatch(code []byte) bool {
+	return bytes.Contains(code, append([]byte{byte(vm.PUSH4)}, voteBatchSelector...))
+}
@@ -0,0 +1,159 @@
+package quorum
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testVoter    = common.HexToAddress("0x0000000000000000000000000000000000000aaa")
+	testNonVoter = common.HexToAddress("0x0000000000000000000000000000000000000bbb")
+)
+
+// newVotingState returns a state holding the voting contract with testVoter
+// as the only voter and a vote threshold of 1.
+func newVotingState(t *testing.T, code []byte) *state.StateDB {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+	storage, err := GenesisStorage([]common.Address{testVoter}, []common.Address{testVoter}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statedb.SetCode(params.QuorumVotingContractAddr, code)
+	for k, v := range storage {
+		statedb.SetState(params.QuorumVotingContractAddr, k, v)
+	}
+	return statedb
+}
+
+func callVoting(t *testing.T, statedb *state.StateDB, from common.Address, method string, args ...interface{}) ([]byte, error) {
+	contract, err := abi.JSON(strings.NewReader(ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := contract.Pack(method, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.Call(params.QuorumVotingContractAddr, input, &runtime.Config{State: statedb, Origin: from, DisableJit: true})
+}
+
+func canonHash(t *testing.T, statedb *state.StateDB, height int64) common.Hash {
+	ret, err := callVoting(t, statedb, testVoter, "getCanonHash", big.NewInt(height))
+	if err != nil {
+		t.Fatalf("getCanonHash(%d) failed: %v", height, err)
+	}
+	return common.BytesToHash(ret)
+}
+
+// skipWithoutVoteBatch skips tests of voteBatch while RuntimeCode is compiled
+// from a block_voting.sol without it.
+func skipWithoutVoteBatch(t *testing.T) {
+	if !supportsVoteBatch(common.FromHex(RuntimeCode)) {
+		t.Skip("RuntimeCode compiled without voteBatch")
+	}
+}
+
+func TestVoteBatch(t *testing.T) {
+	skipWithoutVoteBatch(t)
+	statedb := newVotingState(t, common.FromHex(RuntimeCode))
+
+	heights := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(4)}
+	hashes := [][32]byte{crypto.Keccak256Hash([]byte("1")), crypto.Keccak256Hash([]byte("2")), crypto.Keccak256Hash([]byte("4"))}
+	if _, err := callVoting(t, statedb, testVoter, "voteBatch", heights, hashes); err != nil {
+		t.Fatalf("voteBatch failed: %v", err)
+	}
+	for i, height := range heights {
+		if have := canonHash(t, statedb, height.Int64()); have != common.Hash(hashes[i]) {
+			t.Errorf("height %v: canonical hash mismatch: have %x, want %x", height, have, hashes[i])
+		}
+	}
+	if have := canonHash(t, statedb, 3); have != (common.Hash{}) {
+		t.Errorf("height 3: unexpected canonical hash %x", have)
+	}
+
+	// Every vote is logged like a vote transaction
+	logs := statedb.Logs()
+	if len(logs) != len(heights) {
+		t.Fatalf("logged %d votes, want %d", len(logs), len(heights))
+	}
+	for i, l := range logs {
+		voter, height, hash, ok := decodeVoteLog(*l)
+		if !ok || voter != testVoter || height != heights[i].Uint64() || hash != common.Hash(hashes[i]) {
+			t.Errorf("vote log %d mismatch: have %x %d %x", i, voter, height, hash)
+		}
+	}
+}
+
+func TestVoteBatchRejected(t *testing.T) {
+	skipWithoutVoteBatch(t)
+	statedb := newVotingState(t, common.FromHex(RuntimeCode))
+	hash := [32]byte(crypto.Keccak256Hash([]byte("1")))
+
+	if _, err := callVoting(t, statedb, testNonVoter, "voteBatch", []*big.Int{big.NewInt(1)}, [][32]byte{hash}); err == nil {
+		t.Errorf("non-voter could vote")
+	}
+	if _, err := callVoting(t, statedb, testVoter, "voteBatch", []*big.Int{big.NewInt(1), big.NewInt(2)}, [][32]byte{hash}); err == nil {
+		t.Errorf("voted with more heights than hashes")
+	}
+	if _, err := callVoting(t, statedb, testVoter, "voteBatch", []*big.Int{big.NewInt(0)}, [][32]byte{hash}); err == nil {
+		t.Errorf("voted for height 0")
+	}
+	if have := canonHash(t, statedb, 1); have != (common.Hash{}) {
+		t.Errorf("rejected vote counted: canonical hash %x", have)
+	}
+}
+
+func TestSupportsVoteBatch(t *testing.T) {
+	dispatch := func(selector string) []byte {
+		return append(append([]byte{byte(vm.DUP1), byte(vm.PUSH4)}, common.FromHex(selector)...), byte(vm.EQ))
+	}
+	if supportsVoteBatch(dispatch("68bb8bb6")) {
+		t.Errorf("voteBatch detected in a dispatch of vote")
+	}
+	if !supportsVoteBatch(append(dispatch("68bb8bb6"), dispatch(common.Bytes2Hex(voteBatchSelector))...)) {
+		t.Errorf("voteBatch not detected")
+	}
+}
+
+func TestDeferVote(t *testing.T) {
+	bv := &BlockVoting{voteBatchSize: 3, pState: &pendingState{header: &types.Header{Number: big.NewInt(10)}}}
+	hash := common.HexToHash("0x01")
+
+	if bv.deferVote(big.NewInt(10), hash) || bv.deferVote(big.NewInt(11), hash) {
+		t.Fatalf("votes due before the batch is full")
+	}
+	if !bv.pState.alreadyVoted {
+		t.Errorf("deferred vote for the pending height not recorded")
+	}
+	// A vote for a deferred height replaces it
+	if bv.deferVote(big.NewInt(11), common.HexToHash("0x02")) {
+		t.Fatalf("votes due after replacing a deferred vote")
+	}
+	if len(bv.deferredVotes) != 2 || bv.deferredVotes[1].hash != common.HexToHash("0x02") {
+		t.Fatalf("deferred vote not replaced: %v", bv.deferredVotes)
+	}
+	if !bv.deferVote(big.NewInt(12), hash) {
+		t.Errorf("votes not due once the batch is full")
+	}
+
+	// Votes are due before the oldest is too late to count
+	bv = &BlockVoting{voteBatchSize: 100, pState: &pendingState{header: &types.Header{Number: big.NewInt(10)}}}
+	if bv.deferVote(big.NewInt(10), hash) || bv.deferVote(big.NewInt(10+maxVoteDeferral-1), hash) {
+		t.Fatalf("votes due too early")
+	}
+	if !bv.deferVote(big.NewInt(10+maxVoteDeferral), hash) {
+		t.Errorf("votes not due after %d heights", maxVoteDeferral)
+	}
+}
@@ -0,0 +1,189 @@
+package quorum
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxVotingHistoryRange is the number of heights the voting history can be
+// requested for at once.
+const maxVotingHistoryRange = 256
+
+var blockVotesPrefix = []byte("quorum-votes-") // blockVotesPrefix + num (uint64 big endian) -> votes in the canonical block (json)
+
+// VoteRecord is a vote included in the chain.
+type VoteRecord struct {
+	Voter       common.Address `json:"voter"`
+	Height      uint64         `json:"height"` // Voted height
+	Hash        common.Hash    `json:"hash"`   // Voted block hash
+	BlockNumber uint64         `json:"blockNumber"`
+	TxHash      common.Hash    `json:"txHash"`
+}
+
+// VotingHistory is the outcome of voting on a particular height, as recorded
+// in the chain.
+type VotingHistory struct {
+	Height     uint64           `json:"height"`
+	Candidates []*VoteCandidate `json:"candidates"`
+	Votes      []*VoteRecord    `json:"votes"` // In the order they were included
+	// QuorumHash is the first hash whose votes reached the vote threshold and
+	// QuorumBlock the block including the vote that made it, both nil if no
+	// hash reached it.
+	QuorumHash  *common.Hash `json:"quorumHash"`
+	QuorumBlock *uint64      `json:"quorumBlock"`
+	Threshold   uint64       `json:"threshold"` // At QuorumBlock, or at the last block searched
+}
+
+// blockVotes are the votes included in a canonical block, indexed on import
+// or on first request.
+type blockVotes struct {
+	Hash      common.Hash   `json:"hash"`
+	Threshold uint64        `json:"threshold"` // After the block, 0 if its state is unavailable
+	Votes     []*VoteRecord `json:"votes"`
+}
+
+func blockVotesKey(number uint64) []byte {
+	key := make([]byte, len(blockVotesPrefix)+8)
+	copy(key, blockVotesPrefix)
+	binary.BigEndian.PutUint64(key[len(blockVotesPrefix):], number)
+	return key
+}
+
+// readBlockVotes retrieves the indexed votes of the block at the given number,
+// nil if it wasn't indexed.
+func readBlockVotes(db ethdb.Database, number uint64) *blockVotes {
+	data, _ := db.Get(blockVotesKey(number))
+	if len(data) == 0 {
+		return nil
+	}
+	votes := new(blockVotes)
+	if err := json.Unmarshal(data, votes); err != nil {
+		return nil
+	}
+	return votes
+}
+
+// indexVotes records the votes included in a block imported as the canonical
+// one at its height, replacing those of a block it was reorganised away for.
+func (bv *BlockVoting) indexVotes(block *types.Block, logs vm.Logs) (*blockVotes, error) {
+	votes := &blockVotes{Hash: block.Hash(), Votes: []*VoteRecord{}}
+	if publicState, _, err := bv.bc.StateAt(block.Root()); err == nil {
+		votes.Threshold = publicState.GetState(params.QuorumVotingContractAddr, common.BigToHash(big.NewInt(slotVoteThreshold))).Big().Uint64()
+	}
+	for _, l := range logs {
+		if l.Address != params.QuorumVotingContractAddr || len(l.Topics) == 0 || l.Topics[0] != voteEventTopic {
+			continue
+		}
+		if voter, height, hash, ok := decodeVoteLog(*l); ok {
+			votes.Votes = append(votes.Votes, &VoteRecord{
+				Voter:       voter,
+				Height:      height,
+				Hash:        hash,
+				BlockNumber: block.NumberU64(),
+				TxHash:      l.TxHash,
+			})
+		}
+	}
+	data, err := json.Marshal(votes)
+	if err != nil {
+		return nil, err
+	}
+	if err := bv.db.Put(blockVotesKey(block.NumberU64()), data); err != nil {
+		return nil, err
+	}
+	return votes, nil
+}
+
+// blockVotes returns the votes included in the canonical block at the given
+// number, indexing them from its receipts if it was imported before the index
+// was, or reorganised into the chain. Nil is returned if there's no such block.
+func (bv *BlockVoting) blockVotes(number uint64) (*blockVotes, error) {
+	hash := core.GetCanonicalHash(bv.db, number)
+	if hash == (common.Hash{}) {
+		return nil, nil
+	}
+	if votes := readBlockVotes(bv.db, number); votes != nil && votes.Hash == hash {
+		return votes, nil
+	}
+	block := bv.bc.GetBlock(hash, number)
+	if block == nil {
+		return nil, nil
+	}
+	var logs vm.Logs
+	for _, receipt := range core.GetBlockReceipts(bv.db, hash, number) {
+		logs = append(logs, receipt.Logs...)
+	}
+	return bv.indexVotes(block, logs)
+}
+
+// votingHistory replays the votes cast for the heights from..to as they were
+// included in the canonical chain.
+func (bv *BlockVoting) votingHistory(from, to uint64) ([]*VotingHistory, error) {
+	if from == 0 {
+		return nil, fmt.Errorf("height must be larger than 0")
+	}
+	if to < from {
+		return nil, fmt.Errorf("end height %d before start height %d", to, from)
+	}
+	if to-from >= maxVotingHistoryRange {
+		return nil, fmt.Errorf("at most %d heights can be requested at once", maxVotingHistoryRange)
+	}
+	var (
+		history = make([]*VotingHistory, 0, to-from+1)
+		tallies = make([]map[common.Hash]*VoteCandidate, 0, to-from+1)
+	)
+	for height := from; height <= to; height++ {
+		history = append(history, &VotingHistory{Height: height, Candidates: []*VoteCandidate{}, Votes: []*VoteRecord{}})
+		tallies = append(tallies, make(map[common.Hash]*VoteCandidate))
+	}
+	// Walk the blocks votes for the heights may be included in, in order
+	for number := from; number <= to+voteInclusionRange; number++ {
+		votes, err := bv.blockVotes(number)
+		if err != nil {
+			return nil, err
+		}
+		if votes == nil {
+			break // Beyond the head
+		}
+		for _, h := range history {
+			if h.QuorumHash == nil && number >= h.Height && number <= h.Height+voteInclusionRange {
+				h.Threshold = votes.Threshold
+			}
+		}
+		for _, vote := range votes.Votes {
+			if vote.Height < from || vote.Height > to || number < vote.Height || number > vote.Height+voteInclusionRange {
+				continue
+			}
+			h, tally := history[vote.Height-from], tallies[vote.Height-from]
+			h.Votes = append(h.Votes, vote)
+
+			c, ok := tally[vote.Hash]
+			if !ok {
+				c = &VoteCandidate{Hash: vote.Hash}
+				tally[vote.Hash] = c
+				h.Candidates = append(h.Candidates, c)
+			}
+			c.Votes++
+			c.Voters = append(c.Voters, vote.Voter)
+
+			if h.QuorumHash == nil && votes.Threshold > 0 && uint64(c.Votes) >= votes.Threshold {
+				hash, block := vote.Hash, number
+				h.QuorumHash, h.QuorumBlock = &hash, &block
+			}
+		}
+	}
+	for _, h := range history {
+		sort.Sort(candidatesByVotes(h.Candidates))
+	}
+	return history, nil
+}
@@ -0,0 +1,213 @@
+package quorum
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"golang.org/x/net/context"
+)
+
+const (
+	// voteInclusionRange is the number of blocks, starting at the voted height,
+	// searched for vote transactions. Votes are normally included in the block
+	// they vote a parent for, but may be delayed under load.
+	voteInclusionRange = 16
+
+	// voterDiscoveryRange is the number of heights before the queried height
+	// searched for votes to discover voters that never emitted an AddVoter event
+	// (e.g. voters configured in the genesis block).
+	voterDiscoveryRange = 256
+
+	// voterAdditionRange is the number of blocks before the head searched for
+	// AddVoter events. Voters added earlier are only identified if they voted
+	// within voterDiscoveryRange.
+	voterAdditionRange = 65536
+)
+
+var (
+	voteEventTopic     = crypto.Keccak256Hash([]byte("Vote(address,uint256,bytes32)"))
+	addVoterEventTopic = crypto.Keccak256Hash([]byte("AddVoter(address)"))
+)
+
+// VoteCandidate is a block hash that received votes at a particular height.
+type VoteCandidate struct {
+	Hash   common.Hash      `json:"hash"`
+	Votes  int              `json:"votes"`
+	Voters []common.Address `json:"voters"`
+}
+
+// VoteStatus reports the progress of voting on a particular height.
+type VoteStatus struct {
+	Height        uint64           `json:"height"`
+	Threshold     *big.Int         `json:"threshold"`
+	VoterCount    *big.Int         `json:"voterCount"`
+	CanonicalHash common.Hash      `json:"canonicalHash"`
+	Candidates    []*VoteCandidate `json:"candidates"`
+	Voted         []common.Address `json:"voted"`
+	Missing       []common.Address `json:"missing"`
+	UnknownVoters uint64           `json:"unknownVoters"` // voters that could not be identified from the logs
+}
+
+// voteStatus collects the votes cast for the given height from the voting
+// contract logs and compares them against the current voter set.
+func (bv *BlockVoting) voteStatus(height uint64) (*VoteStatus, error) {
+	if height == 0 {
+		return nil, fmt.Errorf("height must be larger than 0")
+	}
+	threshold, err := bv.callContract.VoteThreshold(nil)
+	if err != nil {
+		return nil, err
+	}
+	voterCount, err := bv.callContract.VoterCount(nil)
+	if err != nil {
+		return nil, err
+	}
+	canonHash, err := bv.canonHash(height)
+	if err != nil {
+		return nil, err
+	}
+	status := &VoteStatus{
+		Height:        height,
+		Threshold:     threshold,
+		VoterCount:    voterCount,
+		CanonicalHash: canonHash,
+		Candidates:    []*VoteCandidate{},
+		Voted:         []common.Address{},
+		Missing:       []common.Address{},
+	}
+
+	// Tally the votes cast for the requested height
+	votes, err := bv.voteLogs(height, height+voteInclusionRange)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[common.Hash]*VoteCandidate)
+	voted := make(map[common.Address]bool)
+	for _, l := range votes {
+		voter, h, hash, ok := decodeVoteLog(l)
+		if !ok || h != height {
+			continue
+		}
+		c, ok := candidates[hash]
+		if !ok {
+			c = &VoteCandidate{Hash: hash}
+			candidates[hash] = c
+			status.Candidates = append(status.Candidates, c)
+		}
+		c.Votes++
+		c.Voters = append(c.Voters, voter)
+		if !voted[voter] {
+			voted[voter] = true
+			status.Voted = append(status.Voted, voter)
+		}
+	}
+	sort.Sort(candidatesByVotes(status.Candidates))
+
+	// Determine which of the known voters didn't vote
+	known, err := bv.knownVoters(height, bv.bc.CurrentBlock().NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	identified := uint64(0)
+	for _, voter := range known {
+		allowed, err := bv.isVoter(voter)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			continue
+		}
+		identified++
+		if !voted[voter] {
+			status.Missing = append(status.Missing, voter)
+		}
+	}
+	if count := voterCount.Uint64(); count > identified {
+		status.UnknownVoters = count - identified
+	}
+	return status, nil
+}
+
+// knownVoters returns all addresses that were added as voter within
+// voterAdditionRange blocks of the head, or that voted in the heights leading
+// up to the given height.
+func (bv *BlockVoting) knownVoters(height, head uint64) ([]common.Address, error) {
+	first := uint64(0)
+	if head > voterAdditionRange {
+		first = head - voterAdditionRange
+	}
+	added, err := bv.ethClient.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(first),
+		ToBlock:   new(big.Int).SetUint64(head),
+		Addresses: []common.Address{params.QuorumVotingContractAddr},
+		Topics:    [][]common.Hash{{addVoterEventTopic}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	from := uint64(1)
+	if height > voterDiscoveryRange {
+		from = height - voterDiscoveryRange
+	}
+	votes, err := bv.voteLogs(from, height+voteInclusionRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		seen   = make(map[common.Address]bool)
+		voters []common.Address
+	)
+	add := func(addr common.Address) {
+		if !seen[addr] {
+			seen[addr] = true
+			voters = append(voters, addr)
+		}
+	}
+	for _, l := range added {
+		if len(l.Data) == 32 {
+			add(common.BytesToAddress(l.Data))
+		}
+	}
+	for _, l := range votes {
+		if voter, _, _, ok := decodeVoteLog(l); ok {
+			add(voter)
+		}
+	}
+	return voters, nil
+}
+
+// voteLogs returns the Vote events included in the given block range.
+func (bv *BlockVoting) voteLogs(from, to uint64) ([]vm.Log, error) {
+	return bv.ethClient.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{params.QuorumVotingContractAddr},
+		Topics:    [][]common.Hash{{voteEventTopic}},
+	})
+}
+
+// decodeVoteLog extracts the voter, height and block hash from a Vote event.
+func decodeVoteLog(l vm.Log) (common.Address, uint64, common.Hash, bool) {
+	if len(l.Topics) != 2 || len(l.Data) != 64 {
+		return common.Address{}, 0, common.Hash{}, false
+	}
+	var (
+		voter  = common.BytesToAddress(l.Topics[1].Bytes())
+		height = new(big.Int).SetBytes(l.Data[:32]).Uint64()
+		hash   = common.BytesToHash(l.Data[32:])
+	)
+	return voter, height, hash, true
+}
+
+type candidatesByVotes []*VoteCandidate
+
+func (c candidatesByVotes) Len() int           { return len(c) }
+func (c candidatesByVotes) Less(i, j int) bool { return c[i].Votes > c[j].Votes }
+func (c candidatesByVotes) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
@@ -0,0 +1,92 @@
+package quorum
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// LogService serves the logs of the voting contract over eth_getLogs and
+// records the block ranges queried.
+type LogService struct {
+	logs   []*vm.Log
+	ranges map[common.Hash][2]uint64 // block range queried per event topic
+}
+
+func (s *LogService) GetLogs(crit map[string]interface{}) ([]*vm.Log, error) {
+	from, _ := strconv.ParseUint(crit["fromBlock"].(string)[2:], 16, 64)
+	to, _ := strconv.ParseUint(crit["toBlock"].(string)[2:], 16, 64)
+	topic := common.HexToHash(crit["topics"].([]interface{})[0].([]interface{})[0].(string))
+	s.ranges[topic] = [2]uint64{from, to}
+
+	var logs []*vm.Log
+	for _, l := range s.logs {
+		if l.Topics[0] == topic && l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+func testVoteLog(voter common.Address, number, height uint64) *vm.Log {
+	data := append(common.BigToHash(new(big.Int).SetUint64(height)).Bytes(), common.HexToHash("0x01").Bytes()...)
+	return &vm.Log{Address: params.QuorumVotingContractAddr, Topics: []common.Hash{voteEventTopic, voter.Hash()}, Data: data, BlockNumber: number}
+}
+
+func testAddVoterLog(voter common.Address, number uint64) *vm.Log {
+	return &vm.Log{Address: params.QuorumVotingContractAddr, Topics: []common.Hash{addVoterEventTopic}, Data: voter.Hash().Bytes(), BlockNumber: number}
+}
+
+func TestKnownVoters(t *testing.T) {
+	var (
+		head   = uint64(100000)
+		height = uint64(99000)
+		added  = common.HexToAddress("0x01") // added within voterAdditionRange
+		old    = common.HexToAddress("0x02") // added before voterAdditionRange, voted recently
+		idle   = common.HexToAddress("0x03") // added before voterAdditionRange, never voted
+	)
+	service := &LogService{
+		logs: []*vm.Log{
+			testAddVoterLog(old, 10),
+			testAddVoterLog(idle, 20),
+			testAddVoterLog(added, head-10),
+			testVoteLog(old, height-100, height-100),
+			testVoteLog(added, height+1, height),
+		},
+		ranges: make(map[common.Hash][2]uint64),
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	bv := &BlockVoting{ethClient: ethclient.NewClient(rpc.DialInProc(server))}
+
+	voters, err := bv.knownVoters(height, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(voters) != 2 || voters[0] != added || voters[1] != old {
+		t.Errorf("known voters mismatch: have %x, want [%x %x]", voters, added, old)
+	}
+	if have, want := service.ranges[addVoterEventTopic], [2]uint64{head - voterAdditionRange, head}; have != want {
+		t.Errorf("AddVoter scan range mismatch: have %v, want %v", have, want)
+	}
+	if have, want := service.ranges[voteEventTopic], [2]uint64{height - voterDiscoveryRange, height + voteInclusionRange}; have != want {
+		t.Errorf("Vote scan range mismatch: have %v, want %v", have, want)
+	}
+
+	// Short chains are scanned from genesis
+	if _, err := bv.knownVoters(10, 20); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := service.ranges[addVoterEventTopic], [2]uint64{0, 20}; have != want {
+		t.Errorf("AddVoter scan range mismatch: have %v, want %v", have, want)
+	}
+}
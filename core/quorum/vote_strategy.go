@@ -13,6 +13,7 @@ import (
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -50,6 +51,12 @@ type BlockVoteMakerStrategy interface {
 	// Status returns indication if this implementation
 	// is generation CreateBlock and/or Voting events.
 	Status() (Status, Status)
+	// Failover makes a standby block maker take over block creation
+	// immediately instead of waiting for the primary to miss its windows.
+	Failover() error
+	// Standby hands block creation back to the primary, provided the
+	// block maker is configured as a standby.
+	Standby() error
 }
 
 // randomDeadlineStrategy asks the block voter to generate blocks
@@ -65,11 +72,25 @@ type randomDeadlineStrategy struct {
 	voteTimer                  *time.Timer
 	deadlineTimer              *time.Timer
 	rand                       *rand.Rand
+
+	// A standby block maker only creates blocks once standbyWindows
+	// consecutive block deadlines passed without a new head being imported,
+	// after which it has taken over from the primary. It hands block creation
+	// back once a head created by another block maker is imported, unless the
+	// takeover was forced, or when told to stand by.
+	standbyWindows int
+	missedWindows  int
+	takenOver      bool
+	forced         bool        // takeover forced by Failover, kept until Standby
+	signer         BlockSigner // signer of the blocks this node creates, nil if it doesn't
 }
 
 // NewRandomDeadelineStrategy returns a block maker strategy that
 // generated blocks randomly between the given min and max seconds.
-func NewRandomDeadelineStrategy(mux *event.TypeMux, minBlockTime, maxBlockTime, minVoteTime, maxVoteTime uint, activateVoting, activateBlockCreation bool) *randomDeadlineStrategy {
+// If standbyWindows is larger than 0 the block maker acts as a standby
+// that only takes over after that many consecutive missed deadlines, and
+// tells the blocks of the primary from the ones signed by signer.
+func NewRandomDeadelineStrategy(mux *event.TypeMux, minBlockTime, maxBlockTime, minVoteTime, maxVoteTime uint, standbyWindows int, signer BlockSigner, activateVoting, activateBlockCreation bool) *randomDeadlineStrategy {
 	if minBlockTime > maxBlockTime {
 		minBlockTime, maxBlockTime = maxBlockTime, minBlockTime
 	}
@@ -106,6 +127,8 @@ func NewRandomDeadelineStrategy(mux *event.TypeMux, minBlockTime, maxBlockTime,
 		blockCreateActive: activateBlockCreation,
 		votingActive:      activateVoting,
 		rand:              rand.New(rand.NewSource(seed.Int64())),
+		standbyWindows:    standbyWindows,
+		signer:            signer,
 	}
 
 	return s
@@ -131,7 +154,9 @@ func (s *randomDeadlineStrategy) Start() error {
 	s.deadlineTimer = time.NewTimer(time.Duration(s.minBlockTime+rand.Intn(s.maxBlockTime-s.minBlockTime)) * time.Second)
 
 	go func() {
-		sub := s.mux.Subscribe(core.ChainHeadEvent{})
+		// Only the latest head matters for the deadlines and votes, so older
+		// heads are dropped rather than stalling the chain import
+		sub := s.mux.SubscribeWith(event.SubscriptionConfig{Name: "quorum/strategy/heads", Buffer: 16, Overflow: event.DropOldest}, core.ChainHeadEvent{})
 		for {
 			select {
 			case <-s.voteTimer.C:
@@ -144,15 +169,23 @@ func (s *randomDeadlineStrategy) Start() error {
 				resetTimer(s.voteTimer, time.Duration(s.minVoteTime+s.rand.Intn(s.maxVoteTime-s.minVoteTime))*time.Second)
 			case <-s.deadlineTimer.C:
 				s.activeMu.Lock()
-				if s.blockCreateActive {
+				if s.blockCreateActive && s.primaryMissedWindow() {
 					s.mux.Post(CreateBlock{})
 				}
 				s.activeMu.Unlock()
 				resetTimer(s.deadlineTimer, time.Duration(s.minBlockTime+s.rand.Intn(s.maxBlockTime-s.minBlockTime))*time.Second)
-			case e := <-sub.Chan():
+			case e, ok := <-sub.Chan():
+				if !ok {
+					// The event mux was stopped with the node
+					return
+				}
+				che := e.Data.(core.ChainHeadEvent)
+				s.activeMu.Lock()
+				s.headImported(che.Block)
+				s.activeMu.Unlock()
+
 				if s.votingActive {
 					// don't wait for the timer and vote immediately when a new block is imported
-					che := e.Data.(core.ChainHeadEvent)
 					go func() {
 						// post in different go-routine to prevent a deadlock when a
 						// new ChainHeadEvent is posted before the Vote event.
@@ -170,6 +203,66 @@ func (s *randomDeadlineStrategy) Start() error {
 	return nil
 }
 
+// primaryMissedWindow records a block deadline that passed without a new
+// head and reports whether this node should create a block for it. That is
+// always the case unless the node is a standby which has not taken over yet.
+// Callers must hold activeMu.
+func (s *randomDeadlineStrategy) primaryMissedWindow() bool {
+	if s.standbyWindows <= 0 || s.takenOver {
+		return true
+	}
+	s.missedWindows++
+	if s.missedWindows < s.standbyWindows {
+		glog.V(logger.Debug).Infof("Standby block maker: primary missed %d/%d block windows", s.missedWindows, s.standbyWindows)
+		return false
+	}
+	glog.Infof("Standby block maker: primary missed %d consecutive block windows, taking over block creation", s.missedWindows)
+	s.takenOver = true
+	return true
+}
+
+// headImported resets the missed block windows on a new head. A standby which
+// has taken over hands block creation back when the head was created by
+// another block maker, as the primary is creating blocks again. Callers must
+// hold activeMu.
+func (s *randomDeadlineStrategy) headImported(head *types.Block) {
+	s.missedWindows = 0
+	if !s.takenOver || s.forced || signsFor(s.signer, head.Coinbase()) {
+		return
+	}
+	glog.Infof("Standby block maker: block #%d created by %x, handing block creation back to the primary", head.Number(), head.Coinbase())
+	s.takenOver = false
+}
+
+// Failover makes a standby block maker take over block creation immediately.
+func (s *randomDeadlineStrategy) Failover() error {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+
+	if s.standbyWindows <= 0 {
+		return fmt.Errorf("block maker is not configured as a standby")
+	}
+	glog.Infoln("Standby block maker: forced failover, taking over block creation")
+	s.takenOver = true
+	s.forced = true
+	return nil
+}
+
+// Standby hands block creation back to the primary block maker.
+func (s *randomDeadlineStrategy) Standby() error {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+
+	if s.standbyWindows <= 0 {
+		return fmt.Errorf("block maker is not configured as a standby")
+	}
+	glog.Infoln("Standby block maker: returning to standby")
+	s.takenOver = false
+	s.forced = false
+	s.missedWindows = 0
+	return nil
+}
+
 // Pause stops generating block create requests.
 // Can be resumed with Resume.
 func (s *randomDeadlineStrategy) PauseBlockMaking() error {
@@ -226,7 +319,7 @@ func (s *randomDeadlineStrategy) MarshalJSON() ([]byte, error) {
 	s.activeMu.Lock()
 	defer s.activeMu.Unlock()
 
-	return json.Marshal(map[string]interface{}{
+	info := map[string]interface{}{
 		"type":          "deadline",
 		"minblocktime":  s.minBlockTime,
 		"maxblocktime":  s.maxBlockTime,
@@ -234,5 +327,14 @@ func (s *randomDeadlineStrategy) MarshalJSON() ([]byte, error) {
 		"maxvotetime":   s.maxVoteTime,
 		"blockCreation": block,
 		"voting":        vote,
-	})
+	}
+	if s.standbyWindows > 0 {
+		info["standby"] = map[string]interface{}{
+			"windows":       s.standbyWindows,
+			"missedWindows": s.missedWindows,
+			"takenOver":     s.takenOver,
+			"forced":        s.forced,
+		}
+	}
+	return json.Marshal(info)
 }
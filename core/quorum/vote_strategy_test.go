@@ -0,0 +1,98 @@
+package quorum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+func newTestStandby(windows int) (*randomDeadlineStrategy, common.Address) {
+	key, _ := crypto.GenerateKey()
+	signer := NewLocalSigner(key)
+	return NewRandomDeadelineStrategy(new(event.TypeMux), 1, 2, 1, 2, windows, signer, false, true), signer.Account()
+}
+
+func testHead(number int64, coinbase common.Address) *types.Block {
+	return types.NewBlockWithHeader(&types.Header{Number: big.NewInt(number), Coinbase: coinbase})
+}
+
+func TestStandbyTakeover(t *testing.T) {
+	s, own := newTestStandby(3)
+	primary := common.HexToAddress("0x01")
+
+	// Missed windows only count while no head is imported
+	if s.primaryMissedWindow() || s.primaryMissedWindow() {
+		t.Fatalf("standby took over before missing 3 windows")
+	}
+	s.headImported(testHead(1, primary))
+	if s.primaryMissedWindow() || s.primaryMissedWindow() {
+		t.Fatalf("standby took over although the primary created a block")
+	}
+	if !s.primaryMissedWindow() || !s.takenOver {
+		t.Fatalf("standby didn't take over after 3 missed windows")
+	}
+	// Its own blocks keep it in charge
+	s.headImported(testHead(2, own))
+	if !s.takenOver || !s.primaryMissedWindow() {
+		t.Fatalf("standby handed back after creating a block")
+	}
+}
+
+func TestStandbyHandBack(t *testing.T) {
+	s, _ := newTestStandby(2)
+	primary := common.HexToAddress("0x01")
+
+	s.primaryMissedWindow()
+	s.primaryMissedWindow()
+	if !s.takenOver {
+		t.Fatalf("standby didn't take over")
+	}
+	// A block of the recovered primary hands block creation back
+	s.headImported(testHead(3, primary))
+	if s.takenOver {
+		t.Fatalf("standby didn't hand back after a block of the primary")
+	}
+	if s.primaryMissedWindow() {
+		t.Errorf("standby created a block right after handing back")
+	}
+
+	// A forced failover is kept until told to stand by
+	if err := s.Failover(); err != nil {
+		t.Fatal(err)
+	}
+	s.headImported(testHead(4, primary))
+	if !s.takenOver {
+		t.Fatalf("forced failover handed back after a block of the primary")
+	}
+	if err := s.Standby(); err != nil {
+		t.Fatal(err)
+	}
+	if s.takenOver || s.forced {
+		t.Errorf("standby still in charge after standing by")
+	}
+}
+
+// Tests that blocks signed by any key of a failover or rotating signer count as
+// the node's own.
+func TestSignsFor(t *testing.T) {
+	remoteKey, _ := crypto.GenerateKey()
+	localKey, _ := crypto.GenerateKey()
+	nextKey, _ := crypto.GenerateKey()
+	var (
+		remote = &RemoteSigner{config: RemoteSignerConfig{Account: crypto.PubkeyToAddress(remoteKey.PublicKey)}}
+		local  = NewLocalSigner(localKey)
+		signer = NewRotatingSigner(NewFailoverSigner(remote, local), nextKey, 10)
+	)
+	for _, account := range []common.Address{remote.Account(), local.Account(), crypto.PubkeyToAddress(nextKey.PublicKey)} {
+		if !signsFor(signer, account) {
+			t.Errorf("%x not recognized as own block maker", account)
+		}
+	}
+	if signsFor(signer, common.HexToAddress("0x01")) || signsFor(nil, common.Address{}) {
+		t.Errorf("other block maker recognized as own")
+	}
+}
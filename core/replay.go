@@ -0,0 +1,309 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Divergence describes a block whose re-execution on top of the stored parent
+// state doesn't reproduce the stored state or receipts.
+type Divergence struct {
+	Number uint64
+	Hash   common.Hash
+
+	Err error // Execution failed, the block isn't valid on top of the parent state
+
+	Public  *StateDiff // nil if the public state matches
+	Private *StateDiff // nil if the private state matches
+
+	// Index of the first transaction whose public or private receipt differs
+	// from the stored one, -1 if none does
+	Transaction int
+
+	Traces [][]vm.StructLog // Traces of the executed transactions, if tracing
+}
+
+func (d *Divergence) String() string {
+	var parts []string
+	if d.Err != nil {
+		parts = append(parts, fmt.Sprintf("execution failed: %v", d.Err))
+	}
+	if d.Public != nil {
+		parts = append(parts, fmt.Sprintf("public state root %x, stored %x", d.Public.Have, d.Public.Want))
+	}
+	if d.Private != nil {
+		parts = append(parts, fmt.Sprintf("private state root %x, stored %x", d.Private.Have, d.Private.Want))
+	}
+	if d.Transaction >= 0 {
+		parts = append(parts, fmt.Sprintf("receipt of transaction %d differs", d.Transaction))
+	}
+	return fmt.Sprintf("block #%d [%x…]: %s", d.Number, d.Hash[:4], strings.Join(parts, ", "))
+}
+
+// StateDiff is the difference between a stored and a recomputed state.
+type StateDiff struct {
+	Want common.Hash // Stored root
+	Have common.Hash // Recomputed root
+
+	Accounts []AccountDiff // Differing account fields, ordered by address
+}
+
+// AccountDiff is an account field whose stored and recomputed values differ.
+// Values are empty for accounts or storage slots missing from a state.
+type AccountDiff struct {
+	Address common.Address
+	Field   string // balance, nonce, code or the hex storage key
+	Want    string // Stored value
+	Have    string // Recomputed value
+}
+
+func (d AccountDiff) String() string {
+	return fmt.Sprintf("%x %s: have %q, stored %q", d.Address, d.Field, d.Have, d.Want)
+}
+
+// ReplayChain re-executes the canonical blocks first to last on top of the
+// stored state of their parents, and returns the first block which doesn't
+// reproduce the stored public and private state roots and receipts, or nil if
+// all do. The chain database isn't modified.
+//
+// Private transactions are executed with the payloads of the privacy manager,
+// so the node has to be connected to the one it used when importing the blocks.
+func ReplayChain(bc *BlockChain, first, last uint64, progress func(*types.Block)) (*Divergence, error) {
+	if first == 0 {
+		first = 1 // The genesis state isn't the result of executing a block
+	}
+	for number := first; number <= last; number++ {
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("canonical block #%d not found", number)
+		}
+		d, err := ReplayBlock(bc, block, false)
+		if err != nil || d != nil {
+			return d, err
+		}
+		if progress != nil {
+			progress(block)
+		}
+	}
+	return nil, nil
+}
+
+// ReplayBlock re-executes a block on top of the stored state of its parent and
+// compares the result with the stored state and receipts. It returns nil if
+// they match. With trace set, every transaction is executed with a struct
+// logger and the traces are part of the result.
+func ReplayBlock(bc *BlockChain, block *types.Block, trace bool) (*Divergence, error) {
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block #%d not found", block.NumberU64())
+	}
+	// Writes go to memory, so the recomputed states can be dumped without
+	// touching the chain database
+	db := newReplayDatabase(bc.chainDb)
+	publicState, err := state.New(parent.Root(), db)
+	if err != nil {
+		return nil, fmt.Errorf("public state of block #%d not available: %v", parent.NumberU64(), err)
+	}
+	privateState, err := state.New(GetPrivateStateRoot(bc.chainDb, parent.Root()), db)
+	if err != nil {
+		return nil, fmt.Errorf("private state of block #%d not available: %v", parent.NumberU64(), err)
+	}
+
+	d := &Divergence{Number: block.NumberU64(), Hash: block.Hash(), Transaction: -1}
+	var (
+		header   = block.Header()
+		txs      = block.Transactions()
+		stored   = GetBlockReceipts(bc.chainDb, block.Hash(), block.NumberU64())
+		usedGas  = new(big.Int)
+		gp       = new(GasPool).AddGas(block.GasLimit())
+		privates = 0
+	)
+	for i, tx := range txs {
+		publicState.StartRecord(tx.Hash(), block.Hash(), i)
+		privateState.StartRecord(tx.Hash(), block.Hash(), i)
+
+		cfg := bc.config.VmConfig
+		var tracer *vm.StructLogger
+		if trace {
+			tracer = vm.NewStructLogger(nil)
+			cfg.Debug, cfg.Tracer = true, tracer
+		}
+		publicReceipt, privateReceipt, _, err := ApplyTransaction(bc.config, bc, gp, publicState, privateState, header, tx, usedGas, cfg)
+		if tracer != nil {
+			d.Traces = append(d.Traces, tracer.StructLogs())
+		}
+		if err != nil {
+			d.Err = fmt.Errorf("transaction %d [%x…]: %v", i, tx.Hash().Bytes()[:4], err)
+			return d, nil
+		}
+		// Stored receipts are the public ones followed by the private ones
+		if d.Transaction < 0 && len(stored) > i && !receiptEqual(publicReceipt, stored[i]) {
+			d.Transaction = i
+		}
+		if privateReceipt != nil {
+			if d.Transaction < 0 && len(stored) > len(txs)+privates && !receiptEqual(privateReceipt, stored[len(txs)+privates]) {
+				d.Transaction = i
+			}
+			privates++
+		}
+	}
+	AccumulateRewards(publicState, header, block.Uncles())
+
+	if root := publicState.IntermediateRoot(); root != block.Root() {
+		if d.Public, err = diffStates(db, publicState, block.Root()); err != nil {
+			return nil, err
+		}
+	}
+	if want := GetPrivateStateRoot(bc.chainDb, block.Root()); privateState.IntermediateRoot() != want {
+		if d.Private, err = diffStates(db, privateState, want); err != nil {
+			return nil, err
+		}
+	}
+	if d.Err == nil && d.Public == nil && d.Private == nil && d.Transaction < 0 {
+		return nil, nil
+	}
+	return d, nil
+}
+
+func receiptEqual(a, b *types.Receipt) bool {
+	ablob, aerr := rlp.EncodeToBytes(a)
+	bblob, berr := rlp.EncodeToBytes(b)
+	return aerr == nil && berr == nil && bytes.Equal(ablob, bblob)
+}
+
+// diffStates commits a recomputed state to the replay database and compares
+// its accounts with those of the stored state with the given root.
+func diffStates(db ethdb.Database, have *state.StateDB, want common.Hash) (*StateDiff, error) {
+	root, err := have.Commit()
+	if err != nil {
+		return nil, err
+	}
+	stored, err := state.New(want, db)
+	if err != nil {
+		return nil, fmt.Errorf("stored state %x not available: %v", want, err)
+	}
+	recomputed, err := state.New(root, db)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		wantDump = stored.RawDump()
+		haveDump = recomputed.RawDump()
+		addrs    = make(map[string]struct{})
+	)
+	for addr := range wantDump.Accounts {
+		addrs[addr] = struct{}{}
+	}
+	for addr := range haveDump.Accounts {
+		addrs[addr] = struct{}{}
+	}
+	sorted := make([]string, 0, len(addrs))
+	for addr := range addrs {
+		sorted = append(sorted, addr)
+	}
+	sort.Strings(sorted)
+
+	diff := &StateDiff{Want: want, Have: root}
+	for _, addr := range sorted {
+		diff.Accounts = append(diff.Accounts, diffAccounts(common.HexToAddress(addr), wantDump.Accounts[addr], haveDump.Accounts[addr])...)
+	}
+	return diff, nil
+}
+
+// diffAccounts compares the dumps of an account, the zero dump standing in
+// for a missing account.
+func diffAccounts(addr common.Address, want, have state.DumpAccount) []AccountDiff {
+	var diffs []AccountDiff
+	add := func(field, w, h string) {
+		if w != h {
+			diffs = append(diffs, AccountDiff{Address: addr, Field: field, Want: w, Have: h})
+		}
+	}
+	nonce := func(dump state.DumpAccount) string {
+		if dump.Balance == "" {
+			return ""
+		}
+		return fmt.Sprint(dump.Nonce)
+	}
+	add("balance", want.Balance, have.Balance)
+	add("nonce", nonce(want), nonce(have))
+	add("code", want.CodeHash, have.CodeHash)
+
+	keys := make([]string, 0, len(want.Storage)+len(have.Storage))
+	for key := range want.Storage {
+		keys = append(keys, key)
+	}
+	for key := range have.Storage {
+		if _, ok := want.Storage[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(key, storageValue(want.Storage[key]), storageValue(have.Storage[key]))
+	}
+	return diffs
+}
+
+// storageValue decodes a storage value of a state dump, which holds the RLP
+// encoding of the value.
+func storageValue(dump string) string {
+	if dump == "" {
+		return ""
+	}
+	_, content, _, err := rlp.Split(common.Hex2Bytes(dump))
+	if err != nil {
+		return dump
+	}
+	return common.BytesToHash(content).Hex()
+}
+
+// replayDatabase reads through to the chain database but keeps writes in
+// memory.
+type replayDatabase struct {
+	ethdb.Database
+	mem *ethdb.MemDatabase
+}
+
+func newReplayDatabase(db ethdb.Database) *replayDatabase {
+	mem, _ := ethdb.NewMemDatabase()
+	return &replayDatabase{Database: db, mem: mem}
+}
+
+func (db *replayDatabase) Get(key []byte) ([]byte, error) {
+	if value, err := db.mem.Get(key); err == nil {
+		return value, nil
+	}
+	return db.Database.Get(key)
+}
+
+func (db *replayDatabase) Put(key, value []byte) error { return db.mem.Put(key, value) }
+func (db *replayDatabase) Delete(key []byte) error     { return db.mem.Delete(key) }
+func (db *replayDatabase) NewBatch() ethdb.Batch       { return db.mem.NewBatch() }
+func (db *replayDatabase) Close()                      {}
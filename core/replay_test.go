@@ -0,0 +1,97 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that replaying an intact chain reproduces it, and that damaged private
+// state roots and receipts are reported at the first block they occur in.
+func TestReplayChain(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000000)})
+	)
+	// Create a contract storing 42 in slot 0 in every block
+	blocks, _ := GenerateChain(nil, genesis, db, 3, func(i int, gen *BlockGen) {
+		tx, _ := types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), nil, common.FromHex("602a600055")).SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	chain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if d, err := ReplayChain(chain, 0, 3, nil); err != nil || d != nil {
+		t.Fatalf("divergence in intact chain: %v %v", d, err)
+	}
+	d, err := ReplayBlock(chain, blocks[0], true)
+	if err != nil || d != nil {
+		t.Fatalf("divergence in traced block: %v %v", d, err)
+	}
+
+	// Point the private state of block 2 to one with an extra account
+	private, _ := state.New(common.Hash{}, db)
+	private.SetState(common.Address{1}, common.Hash{1}, common.Hash{2})
+	root, _ := private.Commit()
+	WritePrivateStateRoot(db, blocks[1].Root(), root)
+
+	d, err = ReplayChain(chain, 1, 3, nil)
+	if err != nil {
+		t.Fatalf("failed to replay chain: %v", err)
+	}
+	if d == nil || d.Number != 2 || d.Public != nil || d.Private == nil || d.Transaction != -1 {
+		t.Fatalf("private state divergence not reported: %v", d)
+	}
+	if d.Private.Want != root {
+		t.Errorf("stored private root mismatch: have %x, want %x", d.Private.Want, root)
+	}
+	want := []AccountDiff{
+		{Address: common.Address{1}, Field: "balance", Want: "0"},
+		{Address: common.Address{1}, Field: "nonce", Want: "0"},
+		{Address: common.Address{1}, Field: "code", Want: common.Bytes2Hex(crypto.Keccak256(nil))},
+		{Address: common.Address{1}, Field: common.Bytes2Hex(common.Hash{1}.Bytes()), Want: common.Hash{2}.Hex()},
+	}
+	if len(d.Private.Accounts) != len(want) {
+		t.Fatalf("account diff mismatch: have %v, want %v", d.Private.Accounts, want)
+	}
+	for i := range want {
+		if d.Private.Accounts[i] != want[i] {
+			t.Errorf("account diff %d: have %v, want %v", i, d.Private.Accounts[i], want[i])
+		}
+	}
+	// A stored receipt which doesn't match is attributed to its transaction
+	receipts := GetBlockReceipts(db, blocks[0].Hash(), 1)
+	receipts[0].CumulativeGasUsed = big.NewInt(1)
+	WriteBlockReceipts(db, blocks[0].Hash(), 1, receipts)
+	if d, err = ReplayChain(chain, 1, 3, nil); err != nil || d == nil || d.Number != 1 || d.Transaction != 0 || d.Private != nil {
+		t.Fatalf("receipt divergence not reported: %v %v", d, err)
+	}
+	if d, err = ReplayBlock(chain, blocks[0], true); err != nil || d == nil || len(d.Traces) != 1 || len(d.Traces[0]) == 0 {
+		t.Fatalf("transaction not traced: %v %v", d, err)
+	}
+}
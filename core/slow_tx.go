@@ -0,0 +1,55 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var slowTxMeter = metrics.NewMeter("chain/execution/slowtxs")
+
+// SlowTxEvent is posted for every transaction of a processed block whose
+// execution took longer than the slow transaction threshold of the chain.
+type SlowTxEvent struct {
+	Block    *types.Block
+	Index    int // Index of the transaction in the block
+	Tx       *types.Transaction
+	Duration time.Duration
+	GasUsed  *big.Int
+}
+
+// SetSlowTxThreshold sets the execution time above which transactions of the
+// blocks the chain processes are reported as a SlowTxEvent, 0 disables the
+// reports. It only applies to the StateProcessor the chain was created with.
+func (self *BlockChain) SetSlowTxThreshold(threshold time.Duration) {
+	if p, ok := self.Processor().(*StateProcessor); ok {
+		atomic.StoreInt64(&p.slowTxThreshold, int64(threshold))
+	}
+}
+
+// reportSlowTxs posts the slow transactions of a processed block.
+func (p *StateProcessor) reportSlowTxs(events []SlowTxEvent) {
+	for _, ev := range events {
+		slowTxMeter.Mark(1)
+		p.bc.eventMux.Post(ev)
+	}
+}
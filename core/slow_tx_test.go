@@ -0,0 +1,76 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that transactions executing longer than the threshold are reported
+// while importing their block.
+func TestSlowTxEvent(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr1, big.NewInt(10000000000000)})
+	)
+	evmux := &event.TypeMux{}
+	blockchain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, evmux, false)
+	sub := evmux.SubscribeWith(event.SubscriptionConfig{Name: "test/slowtx", Buffer: 16}, SlowTxEvent{})
+	defer sub.Unsubscribe()
+
+	chain, _ := GenerateChain(nil, genesis, db, 2, func(i int, gen *BlockGen) {
+		tx, err := types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), nil).SignECDSA(key1)
+		if err != nil {
+			t.Fatalf("failed to create tx: %v", err)
+		}
+		gen.AddTx(tx)
+	})
+	// Nothing is reported without a threshold
+	if _, err := blockchain.InsertChain(chain[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	blockchain.SetSlowTxThreshold(time.Nanosecond)
+	if _, err := blockchain.InsertChain(chain[1:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	select {
+	case ev := <-sub.Chan():
+		slow := ev.Data.(SlowTxEvent)
+		if slow.Block.Hash() != chain[1].Hash() || slow.Index != 0 || slow.Tx.Hash() != chain[1].Transactions()[0].Hash() {
+			t.Errorf("reported transaction %d of block %x, want transaction 0 of block %x", slow.Index, slow.Block.Hash(), chain[1].Hash())
+		}
+		if slow.GasUsed.Sign() <= 0 || slow.Duration <= 0 {
+			t.Errorf("reported %v gas in %v", slow.GasUsed, slow.Duration)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow transaction not reported")
+	}
+	select {
+	case ev := <-sub.Chan():
+		t.Errorf("unexpected report: %+v", ev.Data)
+	default:
+	}
+}
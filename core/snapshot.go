@@ -0,0 +1,283 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// snapshotVersion is the version of the state snapshot format.
+const snapshotVersion = 1
+
+// snapshotFlushSize is the number of trie updates after which a restore commits
+// the tries it builds, bounding its memory use.
+const snapshotFlushSize = 10000
+
+var (
+	// ErrSnapshotChainNotEmpty is returned when a snapshot is restored into a
+	// database which already has blocks beyond the genesis.
+	ErrSnapshotChainNotEmpty = errors.New("chain not empty, snapshots can only be restored after init")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+)
+
+// A snapshot is an RLP stream of a snapshotHeader followed by the public and,
+// if included, the private state. A state is a snapshotState followed by its
+// accounts, each account by its storage slots. The trie nodes aren't part of
+// the snapshot, they are rebuilt and checked against the roots on restore.
+type snapshotHeader struct {
+	Version     uint64
+	Genesis     common.Hash
+	Block       *types.Block
+	Td          *big.Int
+	PrivateRoot common.Hash // Empty if the private state isn't included
+}
+
+type snapshotState struct {
+	Root     common.Hash
+	Accounts uint64
+}
+
+type snapshotAccount struct {
+	Hash    common.Hash // Hash of the address
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+	Slots   uint64
+}
+
+type snapshotSlot struct {
+	Hash  common.Hash // Hash of the key
+	Value []byte      // Value as stored in the trie
+}
+
+// ExportSnapshot writes the state at the given block to w, together with the
+// block itself. The private state is only included if private is set and the
+// node has any, it's only meaningful to the parties of the node's private
+// transactions.
+func ExportSnapshot(db ethdb.Database, block *types.Block, private bool, w io.Writer) error {
+	td := GetTd(db, block.Hash(), block.NumberU64())
+	if td == nil {
+		return fmt.Errorf("total difficulty of block #%d not found", block.NumberU64())
+	}
+	header := &snapshotHeader{
+		Version: snapshotVersion,
+		Genesis: GetCanonicalHash(db, 0),
+		Block:   block,
+		Td:      td,
+	}
+	if private {
+		header.PrivateRoot = GetPrivateStateRoot(db, block.Root())
+	}
+	if err := rlp.Encode(w, header); err != nil {
+		return err
+	}
+	if err := exportState(db, block.Root(), w); err != nil {
+		return fmt.Errorf("public state: %v", err)
+	}
+	if header.PrivateRoot != (common.Hash{}) {
+		if err := exportState(db, header.PrivateRoot, w); err != nil {
+			return fmt.Errorf("private state: %v", err)
+		}
+	}
+	return nil
+}
+
+// exportState writes the accounts and storage of the state with the given root.
+// The tries are iterated twice, counting the entries before writing them.
+func exportState(db ethdb.Database, root common.Hash, w io.Writer) error {
+	accounts, err := trie.New(root, db)
+	if err != nil {
+		return err
+	}
+	var count uint64
+	for it := trie.NewIterator(accounts); it.Next(); {
+		count++
+	}
+	if err := rlp.Encode(w, &snapshotState{Root: root, Accounts: count}); err != nil {
+		return err
+	}
+	for it := trie.NewIterator(accounts); it.Next(); {
+		var data state.Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return err
+		}
+		account := &snapshotAccount{
+			Hash:    common.BytesToHash(it.Key),
+			Nonce:   data.Nonce,
+			Balance: data.Balance,
+		}
+		if codeHash := common.BytesToHash(data.CodeHash); codeHash != emptyCodeHash {
+			if account.Code, err = db.Get(codeHash[:]); err != nil {
+				return fmt.Errorf("code %x of account %x not found", codeHash, account.Hash)
+			}
+		}
+		storage, err := trie.New(data.Root, db)
+		if err != nil {
+			return err
+		}
+		for st := trie.NewIterator(storage); st.Next(); {
+			account.Slots++
+		}
+		if err := rlp.Encode(w, account); err != nil {
+			return err
+		}
+		for st := trie.NewIterator(storage); st.Next(); {
+			if err := rlp.Encode(w, &snapshotSlot{Hash: common.BytesToHash(st.Key), Value: st.Value}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RestoreSnapshot initializes a chain from a snapshot written by ExportSnapshot.
+// The database must have been initialized with the genesis of the snapshot and
+// have no other blocks. The state tries are rebuilt from the snapshot and must
+// hash to the roots of the block, which then becomes the head of the chain.
+//
+// The chain before the snapshot block isn't available, so the block is recorded
+// as the trusted checkpoint of the node.
+func RestoreSnapshot(db ethdb.Database, r io.Reader) (*types.Block, error) {
+	stream := rlp.NewStream(r, 0)
+
+	header := new(snapshotHeader)
+	if err := stream.Decode(header); err != nil {
+		return nil, fmt.Errorf("invalid snapshot header: %v", err)
+	}
+	if header.Version != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", header.Version)
+	}
+	genesis := GetCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, errors.New("no genesis block, run init first")
+	}
+	if genesis != header.Genesis {
+		return nil, fmt.Errorf("genesis mismatch: have %x, snapshot %x", genesis, header.Genesis)
+	}
+	if head := GetHeadHeaderHash(db); GetHeadBlockHash(db) != genesis || (head != genesis && head != common.Hash{}) {
+		return nil, ErrSnapshotChainNotEmpty
+	}
+	block := header.Block
+	if err := restoreState(db, stream, block.Root()); err != nil {
+		return nil, fmt.Errorf("public state: %v", err)
+	}
+	if header.PrivateRoot != (common.Hash{}) {
+		if err := restoreState(db, stream, header.PrivateRoot); err != nil {
+			return nil, fmt.Errorf("private state: %v", err)
+		}
+		if err := WritePrivateStateRoot(db, block.Root(), header.PrivateRoot); err != nil {
+			return nil, err
+		}
+	}
+	if err := WriteTd(db, block.Hash(), block.NumberU64(), header.Td); err != nil {
+		return nil, err
+	}
+	if err := WriteBlock(db, block); err != nil {
+		return nil, err
+	}
+	if err := WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+		return nil, err
+	}
+	WriteHeadBlockHash(db, block.Hash())
+	WriteHeadHeaderHash(db, block.Hash())
+	WriteHeadFastBlockHash(db, block.Hash())
+
+	checkpoint := &TrustedCheckpoint{Number: block.NumberU64(), Hash: block.Hash(), Time: time.Now()}
+	if err := WriteTrustedCheckpoint(db, checkpoint); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// restoreState rebuilds a state trie and the storage tries of its accounts from
+// a snapshot stream and checks that it hashes to root.
+func restoreState(db ethdb.Database, stream *rlp.Stream, root common.Hash) error {
+	st := new(snapshotState)
+	if err := stream.Decode(st); err != nil {
+		return err
+	}
+	if st.Root != root {
+		return fmt.Errorf("root mismatch: have %x, want %x", st.Root, root)
+	}
+	accounts, _ := trie.New(common.Hash{}, db)
+	for i := uint64(0); i < st.Accounts; i++ {
+		account := new(snapshotAccount)
+		if err := stream.Decode(account); err != nil {
+			return err
+		}
+		storage, _ := trie.New(common.Hash{}, db)
+		for j := uint64(0); j < account.Slots; j++ {
+			slot := new(snapshotSlot)
+			if err := stream.Decode(slot); err != nil {
+				return err
+			}
+			storage.Update(slot.Hash[:], slot.Value)
+			if (j+1)%snapshotFlushSize == 0 {
+				if _, err := storage.CommitTo(db); err != nil {
+					return err
+				}
+			}
+		}
+		storageRoot, err := storage.CommitTo(db)
+		if err != nil {
+			return err
+		}
+		codeHash := emptyCodeHash
+		if len(account.Code) > 0 {
+			codeHash = crypto.Keccak256Hash(account.Code)
+			if err := db.Put(codeHash[:], account.Code); err != nil {
+				return err
+			}
+		}
+		data, err := rlp.EncodeToBytes(&state.Account{
+			Nonce:    account.Nonce,
+			Balance:  account.Balance,
+			Root:     storageRoot,
+			CodeHash: codeHash[:],
+		})
+		if err != nil {
+			return err
+		}
+		accounts.Update(account.Hash[:], data)
+		if (i+1)%snapshotFlushSize == 0 {
+			if _, err := accounts.CommitTo(db); err != nil {
+				return err
+			}
+		}
+	}
+	have, err := accounts.CommitTo(db)
+	if err != nil {
+		return err
+	}
+	if have != root {
+		return fmt.Errorf("rebuilt state root mismatch: have %x, want %x", have, root)
+	}
+	return nil
+}
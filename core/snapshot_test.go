@@ -0,0 +1,131 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a chain restored from a snapshot has the public and private state
+// of the snapshot block and continues with the blocks after it.
+func TestSnapshotRestore(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		funds    = big.NewInt(1000000000)
+		contract = crypto.CreateAddress(addr, 0)
+		db, _    = ethdb.NewMemDatabase()
+		genesis  = WriteGenesisBlockForTesting(db, GenesisAccount{addr, funds})
+	)
+	// Store 42 in slot 0 of a contract, then move some funds around
+	blocks, _ := GenerateChain(nil, genesis, db, 5, func(i int, gen *BlockGen) {
+		var tx *types.Transaction
+		if i == 0 {
+			tx = types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), nil, common.FromHex("602a600055"))
+		} else {
+			tx = types.NewTransaction(gen.TxNonce(addr), common.Address{byte(i)}, big.NewInt(1000), params.TxGas, nil, nil)
+		}
+		tx, _ = tx.SignECDSA(key)
+		gen.AddTx(tx)
+	})
+	chain, _ := NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	private, _ := state.New(common.Hash{}, db)
+	private.SetState(contract, common.Hash{1}, common.Hash{2})
+	privateRoot, _ := private.Commit()
+	WritePrivateStateRoot(db, blocks[2].Root(), privateRoot)
+
+	snapshot := new(bytes.Buffer)
+	if err := ExportSnapshot(db, blocks[2], true, snapshot); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+
+	restored, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(restored, GenesisAccount{addr, funds})
+	block, err := RestoreSnapshot(restored, bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to restore snapshot: %v", err)
+	}
+	if block.Hash() != blocks[2].Hash() {
+		t.Fatalf("restored block mismatch: have %x, want %x", block.Hash(), blocks[2].Hash())
+	}
+	if checkpoint := GetTrustedCheckpoint(restored); checkpoint == nil || checkpoint.Hash != block.Hash() {
+		t.Errorf("snapshot not recorded as checkpoint: %+v", checkpoint)
+	}
+	chain, err = NewBlockChain(restored, testChainConfig(), FakePow{}, new(event.TypeMux), false)
+	if err != nil {
+		t.Fatalf("failed to open restored chain: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != block.Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", head, block.Hash())
+	}
+	public, private, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open restored state: %v", err)
+	}
+	if value := public.GetState(contract, common.Hash{}); value != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("public storage mismatch: have %x, want 42", value)
+	}
+	if value := private.GetState(contract, common.Hash{1}); value != (common.Hash{2}) {
+		t.Errorf("private storage mismatch: have %x, want %x", value, common.Hash{2})
+	}
+	if _, err := chain.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert blocks after snapshot: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[4].Hash() {
+		t.Errorf("head mismatch after import: have %x, want %x", head, blocks[4].Hash())
+	}
+	if _, err := RestoreSnapshot(restored, bytes.NewReader(snapshot.Bytes())); err != ErrSnapshotChainNotEmpty {
+		t.Errorf("restore into non-empty chain: have %v, want %v", err, ErrSnapshotChainNotEmpty)
+	}
+}
+
+// Tests that snapshots whose state doesn't hash to the roots of the block are
+// rejected.
+func TestSnapshotCorrupt(t *testing.T) {
+	var (
+		addr    = common.Address{1}
+		funds   = big.NewInt(1000)
+		db, _   = ethdb.NewMemDatabase()
+		genesis = WriteGenesisBlockForTesting(db, GenesisAccount{addr, funds})
+	)
+	snapshot := new(bytes.Buffer)
+	if err := ExportSnapshot(db, genesis, false, snapshot); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	blob := snapshot.Bytes()
+	funded := bytes.LastIndex(blob, funds.Bytes())
+	blob[funded]++
+
+	restored, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(restored, GenesisAccount{addr, funds})
+	if _, err := RestoreSnapshot(restored, bytes.NewReader(blob)); err == nil {
+		t.Fatalf("corrupt snapshot restored")
+	}
+}
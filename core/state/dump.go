@@ -17,10 +17,12 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -47,6 +49,10 @@ func (self *StateDB) RawDump() Dump {
 	it := self.trie.Iterator()
 	for it.Next() {
 		addr := self.trie.GetKey(it.Key)
+		if addr == nil {
+			// Preimages aren't recorded, key the account by its hash instead
+			addr = it.Key
+		}
 		var data Account
 		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
 			panic(err)
@@ -78,3 +84,91 @@ func (self *StateDB) Dump() []byte {
 
 	return json
 }
+
+// StorageEntry is a storage slot of an account. The key is nil if the preimage
+// of its hash isn't known.
+type StorageEntry struct {
+	Key   *common.Hash `json:"key"`
+	Value common.Hash  `json:"value"`
+}
+
+// StorageRangeResult is a range of the storage of an account, keyed by the
+// hashes of the slot keys.
+type StorageRangeResult struct {
+	Storage map[common.Hash]StorageEntry `json:"storage"`
+	NextKey *common.Hash                 `json:"nextKey"` // nil if the range ends with the last slot
+}
+
+// StorageRange returns up to max storage slots of an account in the order of
+// their hashed keys, starting from the first hash not below start. A max of
+// zero returns all the slots from start on. Slots written since the last
+// IntermediateRoot or Commit aren't included.
+//
+// The storage trie is iterated from the beginning on every call, so paging
+// through large contracts costs more with every page.
+func (self *StateDB) StorageRange(addr common.Address, start []byte, max int) (StorageRangeResult, error) {
+	so := self.GetStateObject(addr)
+	if so == nil {
+		return StorageRangeResult{}, fmt.Errorf("account %x doesn't exist", addr)
+	}
+	tr := so.getTrie(self.db)
+	result := StorageRangeResult{Storage: make(map[common.Hash]StorageEntry)}
+
+	it := tr.Iterator()
+	for it.Next() {
+		if bytes.Compare(it.Key, start) < 0 {
+			continue
+		}
+		if max > 0 && len(result.Storage) >= max {
+			next := common.BytesToHash(it.Key)
+			result.NextKey = &next
+			break
+		}
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return StorageRangeResult{}, err
+		}
+		entry := StorageEntry{Value: common.BytesToHash(content)}
+		if preimage := tr.GetKey(it.Key); preimage != nil {
+			key := common.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		result.Storage[common.BytesToHash(it.Key)] = entry
+	}
+	return result, nil
+}
+
+// Preimages are the preimages of the hashed keys of an account and its storage,
+// for interpreting dumps of the tries which are keyed by the hashes.
+type Preimages struct {
+	Address common.Address              `json:"address"`
+	Hash    common.Hash                 `json:"hash"`    // Hash of the address, its key in the account trie
+	Storage map[common.Hash]common.Hash `json:"storage"` // Storage keys by their hashes
+	Missing []common.Hash               `json:"missing"` // Hashed storage keys without a recorded preimage
+}
+
+// StoragePreimages returns the preimages of the hashed storage keys of an
+// account. Preimages are only known for keys written while they were recorded,
+// see trie.RecordPreimages, the hashes of the others are listed as missing.
+func (self *StateDB) StoragePreimages(addr common.Address) (Preimages, error) {
+	so := self.GetStateObject(addr)
+	if so == nil {
+		return Preimages{}, fmt.Errorf("account %x doesn't exist", addr)
+	}
+	tr := so.getTrie(self.db)
+	result := Preimages{
+		Address: addr,
+		Hash:    crypto.Keccak256Hash(addr[:]),
+		Storage: make(map[common.Hash]common.Hash),
+		Missing: []common.Hash{},
+	}
+	it := tr.Iterator()
+	for it.Next() {
+		if preimage := tr.GetKey(it.Key); preimage != nil {
+			result.Storage[common.BytesToHash(it.Key)] = common.BytesToHash(preimage)
+		} else {
+			result.Missing = append(result.Missing, common.BytesToHash(it.Key))
+		}
+	}
+	return result, it.Err
+}
@@ -0,0 +1,549 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// flatMaxDiffs is the number of committed state changes kept in memory to
+	// move the flat state along the chain, reorgs deeper than this regenerate it.
+	flatMaxDiffs = 128
+
+	// flatFlushSize is the number of entries after which a generation writes
+	// its batch.
+	flatFlushSize = 10000
+)
+
+var (
+	flatStateKey    = []byte("flat-state") // Generation and root of the flat state
+	flatStatePrefix = []byte("flat-")      // flatStatePrefix + generation (uint64 big endian) + entry
+
+	flatHitMeter  = metrics.NewMeter("state/flat/hits")
+	flatMissMeter = metrics.NewMeter("state/flat/misses")
+
+	// emptyRoot is the root of empty storage tries.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	errFlatAborted = errors.New("flat state generation aborted")
+)
+
+// Flat is a flat copy of the state at the head of the chain, every account and
+// storage slot stored under its hashed key, so reads take a single database
+// lookup instead of a walk down the tries.
+//
+// The flat state is moved along the chain with the changes state databases
+// commit. If it can't be moved to the new head, because of a reorg beyond the
+// changes kept or an unclean shutdown, it's regenerated from the tries in the
+// background. Reads fall back to the tries whenever the flat state doesn't
+// hold the root they are made at, so it never serves a state it doesn't have.
+//
+// Regenerated flat states are written under a new generation of keys and the
+// previous ones are pruned, deleting or recreating an account changes its
+// incarnation, which likewise drops its storage.
+type Flat struct {
+	db ethdb.Database
+
+	lock   sync.RWMutex
+	gen    uint64      // Generation of the keys holding the flat state
+	next   uint64      // Next generation, never reused as aborted ones may have left keys
+	root   common.Hash // State root held by the flat state
+	target common.Hash // State root the flat state is moved to, the chain head
+
+	diffs      map[common.Hash]*flatDiff // Committed state changes by the root they lead to
+	order      []common.Hash             // Roots of the committed changes, oldest first
+	generating bool
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+type flatMarker struct {
+	Gen, Next uint64
+	Root      common.Hash
+}
+
+// flatEntry is the flat state entry of an account, Data is the account as
+// stored in the trie or empty if it was deleted.
+type flatEntry struct {
+	Inc  uint64
+	Data []byte
+}
+
+// flatDiff holds the state changes of a commit, by hashed address and key.
+type flatDiff struct {
+	parent   common.Hash
+	accounts map[common.Hash][]byte // Accounts as stored in the trie, nil if deleted
+	resets   map[common.Hash]bool   // Accounts created anew, dropping their storage
+	storage  map[common.Hash]map[common.Hash][]byte
+}
+
+func newFlatDiff(parent common.Hash) *flatDiff {
+	return &flatDiff{
+		parent:   parent,
+		accounts: make(map[common.Hash][]byte),
+		resets:   make(map[common.Hash]bool),
+		storage:  make(map[common.Hash]map[common.Hash][]byte),
+	}
+}
+
+func (d *flatDiff) deleteAccount(obj *StateObject) {
+	hash := crypto.Keccak256Hash(obj.address[:])
+	d.accounts[hash] = nil
+	delete(d.storage, hash)
+}
+
+func (d *flatDiff) updateAccount(obj *StateObject) {
+	hash := crypto.Keccak256Hash(obj.address[:])
+	data, err := rlp.EncodeToBytes(obj)
+	if err != nil {
+		panic(err)
+	}
+	d.accounts[hash] = data
+	if obj.created {
+		d.resets[hash] = true
+	}
+	if len(obj.flatStorage) > 0 {
+		slots := make(map[common.Hash][]byte, len(obj.flatStorage))
+		for key, value := range obj.flatStorage {
+			slots[key] = value
+		}
+		d.storage[hash] = slots
+	}
+}
+
+// NewFlat opens the flat state in db. It's used once the chain calls Follow
+// with its head.
+func NewFlat(db ethdb.Database) *Flat {
+	f := &Flat{
+		db:    db,
+		next:  1,
+		diffs: make(map[common.Hash]*flatDiff),
+		quit:  make(chan struct{}),
+	}
+	if enc, err := db.Get(flatStateKey); err == nil {
+		var marker flatMarker
+		if err := rlp.DecodeBytes(enc, &marker); err != nil {
+			glog.V(logger.Warn).Infof("Invalid flat state marker, regenerating: %v", err)
+		} else {
+			f.gen, f.next, f.root = marker.Gen, marker.Next, marker.Root
+		}
+	}
+	return f
+}
+
+// Root returns the state root held by the flat state, it's empty while the
+// flat state is generated for the first time.
+func (f *Flat) Root() common.Hash {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.root
+}
+
+// Follow moves the flat state to the given root, applying the changes
+// committed since the root it holds or regenerating it if there's no such
+// path, like after a deep reorg.
+func (f *Flat) Follow(root common.Hash) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.target = root
+	if f.generating || f.root == root {
+		return
+	}
+	if path, ok := f.path(f.root, root); ok {
+		if err := f.apply(path, root); err != nil {
+			glog.V(logger.Error).Infof("Failed to update flat state: %v", err)
+		} else {
+			return
+		}
+	}
+	f.generating = true
+	f.wg.Add(1)
+	go f.generate(root)
+}
+
+// Stop aborts a running generation, which restarts after the next Follow.
+func (f *Flat) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+// account returns the account with the given hashed address as stored in the
+// trie and its incarnation, ok is false if the flat state doesn't hold root.
+func (f *Flat) account(root, hash common.Hash) (data []byte, inc uint64, ok bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.root != root || root == (common.Hash{}) {
+		return nil, 0, false
+	}
+	enc, err := f.db.Get(flatAccountKey(f.gen, hash))
+	if err != nil {
+		return nil, 0, true
+	}
+	var entry flatEntry
+	if err := rlp.DecodeBytes(enc, &entry); err != nil {
+		glog.V(logger.Error).Infof("Invalid flat state account %x: %v", hash, err)
+		return nil, 0, false
+	}
+	return entry.Data, entry.Inc, true
+}
+
+// storage returns a storage slot as stored in the trie, ok is false if the
+// flat state doesn't hold root.
+func (f *Flat) storage(root, hash common.Hash, inc uint64, key common.Hash) (value []byte, ok bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	if f.root != root || root == (common.Hash{}) {
+		return nil, false
+	}
+	value, _ = f.db.Get(flatStorageKey(f.gen, hash, inc, key))
+	return value, true
+}
+
+// addDiff keeps the changes committed from parent to root to move the flat
+// state along.
+func (f *Flat) addDiff(root common.Hash, diff *flatDiff) {
+	if root == diff.parent {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.diffs[root]; !ok {
+		f.order = append(f.order, root)
+	}
+	f.diffs[root] = diff
+
+	// Generations catch up with all the changes made meanwhile
+	if !f.generating && len(f.order) > flatMaxDiffs {
+		for _, old := range f.order[:len(f.order)-flatMaxDiffs] {
+			delete(f.diffs, old)
+		}
+		f.order = append(f.order[:0], f.order[len(f.order)-flatMaxDiffs:]...)
+	}
+}
+
+// path returns the diffs leading from one root to the other, oldest first.
+func (f *Flat) path(from, to common.Hash) ([]*flatDiff, bool) {
+	if from == (common.Hash{}) {
+		return nil, false
+	}
+	var path []*flatDiff
+	for root := to; root != from; {
+		diff := f.diffs[root]
+		if diff == nil || len(path) > len(f.diffs) {
+			return nil, false
+		}
+		path = append(path, diff)
+		root = diff.parent
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+// apply writes the diffs of a path to root, it must be called with the lock
+// held.
+func (f *Flat) apply(path []*flatDiff, root common.Hash) error {
+	batch := f.db.NewBatch()
+	incs := make(map[common.Hash]uint64)
+	for _, diff := range path {
+		for hash, data := range diff.accounts {
+			inc, ok := incs[hash]
+			if !ok {
+				inc = f.incarnation(hash)
+			}
+			if data == nil || diff.resets[hash] {
+				inc++
+			}
+			incs[hash] = inc
+			enc, _ := rlp.EncodeToBytes(flatEntry{Inc: inc, Data: data})
+			if err := batch.Put(flatAccountKey(f.gen, hash), enc); err != nil {
+				return err
+			}
+		}
+		// Storage only changes along with its account
+		for hash, slots := range diff.storage {
+			for key, value := range slots {
+				if err := batch.Put(flatStorageKey(f.gen, hash, incs[hash], key), value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := f.putMarker(batch, f.gen, root); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	f.root = root
+	return nil
+}
+
+func (f *Flat) incarnation(hash common.Hash) uint64 {
+	var entry flatEntry
+	if enc, err := f.db.Get(flatAccountKey(f.gen, hash)); err == nil {
+		rlp.DecodeBytes(enc, &entry)
+	}
+	return entry.Inc
+}
+
+func (f *Flat) putMarker(w trie.DatabaseWriter, gen uint64, root common.Hash) error {
+	enc, _ := rlp.EncodeToBytes(flatMarker{Gen: gen, Next: f.next, Root: root})
+	return w.Put(flatStateKey, enc)
+}
+
+// generate regenerates the flat state at root under a new generation and
+// catches up with the changes committed meanwhile.
+func (f *Flat) generate(root common.Hash) {
+	defer f.wg.Done()
+
+	for {
+		f.lock.Lock()
+		gen := f.next
+		f.next++
+		err := f.putMarker(f.db, f.gen, f.root)
+		f.lock.Unlock()
+		if err == nil {
+			err = f.write(gen, root)
+		}
+		f.lock.Lock()
+		if err != nil {
+			if err != errFlatAborted {
+				glog.V(logger.Error).Infof("Failed to generate flat state: %v", err)
+			}
+			f.generating = false
+			f.lock.Unlock()
+			return
+		}
+		if err := f.putMarker(f.db, gen, root); err != nil {
+			glog.V(logger.Error).Infof("Failed to generate flat state: %v", err)
+			f.generating = false
+			f.lock.Unlock()
+			return
+		}
+		f.gen, f.root = gen, root
+
+		if f.target != root {
+			path, ok := f.path(root, f.target)
+			if !ok || f.apply(path, f.target) != nil {
+				// The chain moved on without a path, like after a reorg
+				root = f.target
+				f.lock.Unlock()
+				continue
+			}
+		}
+		f.generating = false
+		f.lock.Unlock()
+
+		f.prune(gen)
+		return
+	}
+}
+
+// write writes the flat state at root under the given generation.
+func (f *Flat) write(gen uint64, root common.Hash) error {
+	glog.V(logger.Info).Infof("Generating flat state at root %x…", root[:4])
+
+	tr, err := trie.NewSecure(root, f.db, 0)
+	if err != nil {
+		return err
+	}
+	var (
+		start    = time.Now()
+		logged   = time.Now()
+		batch    = f.db.NewBatch()
+		entries  int
+		accounts int
+		slots    int
+	)
+	put := func(key, value []byte) error {
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		if entries++; entries%flatFlushSize == 0 {
+			select {
+			case <-f.quit:
+				return errFlatAborted
+			default:
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = f.db.NewBatch()
+		}
+		return nil
+	}
+	it := tr.Iterator()
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		enc, _ := rlp.EncodeToBytes(flatEntry{Data: it.Value})
+		if err := put(flatAccountKey(gen, hash), enc); err != nil {
+			return err
+		}
+		var account Account
+		if err := rlp.DecodeBytes(it.Value, &account); err != nil {
+			return err
+		}
+		if account.Root != emptyRoot {
+			storage, err := trie.New(account.Root, f.db)
+			if err != nil {
+				return err
+			}
+			sit := trie.NewIterator(storage)
+			for sit.Next() {
+				if err := put(flatStorageKey(gen, hash, 0, common.BytesToHash(sit.Key)), sit.Value); err != nil {
+					return err
+				}
+				slots++
+			}
+			if sit.Err != nil {
+				return sit.Err
+			}
+		}
+		accounts++
+
+		if time.Since(logged) > 8*time.Second {
+			glog.V(logger.Info).Infof("Generating flat state: %d accounts, %d storage slots", accounts, slots)
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	glog.V(logger.Info).Infof("Generated flat state at root %x…: %d accounts, %d storage slots in %v", root[:4], accounts, slots, time.Since(start))
+	return nil
+}
+
+// prune deletes the keys of the generations before gen, which is only
+// possible for databases that can be iterated.
+func (f *Flat) prune(gen uint64) {
+	ldb, ok := f.db.(interface {
+		LDB() *leveldb.DB
+	})
+	if !ok {
+		return
+	}
+	it := ldb.LDB().NewIterator(util.BytesPrefix(flatStatePrefix), nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		key := it.Key()
+		if bytes.Equal(key, flatStateKey) || len(key) < len(flatStatePrefix)+8 {
+			continue
+		}
+		if binary.BigEndian.Uint64(key[len(flatStatePrefix):]) >= gen {
+			continue
+		}
+		batch.Delete(common.CopyBytes(key))
+		if batch.Len() >= flatFlushSize {
+			select {
+			case <-f.quit:
+				return
+			default:
+			}
+			if err := ldb.LDB().Write(batch, nil); err != nil {
+				glog.V(logger.Error).Infof("Failed to prune flat state: %v", err)
+				return
+			}
+			batch.Reset()
+		}
+	}
+	if err := ldb.LDB().Write(batch, nil); err != nil {
+		glog.V(logger.Error).Infof("Failed to prune flat state: %v", err)
+	}
+}
+
+func flatAccountKey(gen uint64, hash common.Hash) []byte {
+	key := make([]byte, 0, len(flatStatePrefix)+8+1+common.HashLength)
+	key = append(key, flatStatePrefix...)
+	key = append(key, encodeUint64(gen)...)
+	key = append(key, 'a')
+	return append(key, hash[:]...)
+}
+
+func flatStorageKey(gen uint64, hash common.Hash, inc uint64, slot common.Hash) []byte {
+	key := make([]byte, 0, len(flatStatePrefix)+8+1+common.HashLength+8+common.HashLength)
+	key = append(key, flatStatePrefix...)
+	key = append(key, encodeUint64(gen)...)
+	key = append(key, 's')
+	key = append(key, hash[:]...)
+	key = append(key, encodeUint64(inc)...)
+	return append(key, slot[:]...)
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
+
+// getFlatAccount reads an account from the flat state if it holds the root
+// the state database was opened at.
+func (self *StateDB) getFlatAccount(addr common.Address) (data []byte, inc uint64, ok bool) {
+	if self.flat == nil {
+		return nil, 0, false
+	}
+	data, inc, ok = self.flat.account(self.originRoot, crypto.Keccak256Hash(addr[:]))
+	if ok {
+		flatHitMeter.Mark(1)
+	} else {
+		flatMissMeter.Mark(1)
+	}
+	return data, inc, ok
+}
+
+// getFlatState reads a storage slot from the flat state if the account was
+// loaded from it.
+func (self *StateObject) getFlatState(key common.Hash) ([]byte, bool) {
+	if !self.fromFlat || self.created || self.db.flat == nil {
+		return nil, false
+	}
+	enc, ok := self.db.flat.storage(self.db.originRoot, crypto.Keccak256Hash(self.address[:]), self.flatInc, crypto.Keccak256Hash(key[:]))
+	if ok {
+		flatHitMeter.Mark(1)
+	} else {
+		flatMissMeter.Mark(1)
+	}
+	return enc, ok
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	flatAddrs = []common.Address{{1}, {2}, {3}, {4}}
+	flatKeys  = []common.Hash{{1}, {2}, {3}}
+)
+
+// checkFlat checks that reads at root through the flat state match the trie
+// and are served by the flat state.
+func checkFlat(t *testing.T, db ethdb.Database, flat *Flat, root common.Hash) {
+	if have := flat.Root(); have != root {
+		t.Fatalf("flat state root mismatch: have %x, want %x", have, root)
+	}
+	want, _ := New(root, db)
+	have, _ := New(root, db)
+	have.SetFlat(flat)
+	for _, addr := range flatAddrs {
+		if have.Exist(addr) != want.Exist(addr) {
+			t.Errorf("%x: existence mismatch: have %v, want %v", addr, have.Exist(addr), want.Exist(addr))
+			continue
+		}
+		if obj := have.GetStateObject(addr); obj != nil && !obj.fromFlat {
+			t.Errorf("%x: not read from the flat state", addr)
+		}
+		if h, w := have.GetBalance(addr), want.GetBalance(addr); h.Cmp(w) != 0 {
+			t.Errorf("%x: balance mismatch: have %v, want %v", addr, h, w)
+		}
+		for _, key := range flatKeys {
+			if h, w := have.GetState(addr, key), want.GetState(addr, key); h != w {
+				t.Errorf("%x: slot %x mismatch: have %x, want %x", addr, key, h, w)
+			}
+		}
+	}
+}
+
+func TestFlatState(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	// Generate the flat state of an existing state
+	state, _ := New(common.Hash{}, db)
+	for i, addr := range flatAddrs[:3] {
+		state.AddBalance(addr, big.NewInt(int64(i+1)))
+		state.SetState(addr, flatKeys[0], common.Hash{byte(i + 1)})
+	}
+	root1, _ := state.Commit()
+
+	flat := NewFlat(db)
+	flat.Follow(root1)
+	flat.wg.Wait()
+	checkFlat(t, db, flat, root1)
+
+	// Update it with the changes committed: a slot changed and one cleared,
+	// an account deleted, one recreated without its storage and a new one
+	state, _ = New(root1, db)
+	state.SetFlat(flat)
+	state.SetState(flatAddrs[0], flatKeys[1], common.Hash{9})
+	state.SetState(flatAddrs[0], flatKeys[0], common.Hash{})
+	state.Suicide(flatAddrs[1])
+	state.CreateAccount(flatAddrs[2])
+	state.SetState(flatAddrs[2], flatKeys[2], common.Hash{7})
+	state.AddBalance(flatAddrs[3], big.NewInt(4))
+	root2, _ := state.Commit()
+
+	flat.Follow(root2)
+	if flat.generating {
+		t.Fatal("flat state regenerated instead of updated")
+	}
+	checkFlat(t, db, flat, root2)
+
+	// Without a path to the root, like after a reorg, it's regenerated
+	flat.Follow(root1)
+	flat.wg.Wait()
+	checkFlat(t, db, flat, root1)
+
+	// The flat state persists
+	flat.Stop()
+	if root := NewFlat(db).Root(); root != root1 {
+		t.Errorf("persisted root mismatch: have %x, want %x", root, root1)
+	}
+}
@@ -89,6 +89,12 @@ type StateObject struct {
 	suicided  bool
 	deleted   bool
 	onDirty   func(addr common.Address) // Callback method to mark a state object newly dirty
+
+	// Flat state, see Flat.
+	created     bool                   // true if the account was created rather than loaded
+	fromFlat    bool                   // true if the account was loaded from the flat state
+	flatInc     uint64                 // Incarnation of the account in the flat state
+	flatStorage map[common.Hash][]byte // Storage written to the trie since the last commit, by hashed key
 }
 
 // Account is the Ethereum consensus representation of accounts.
@@ -156,8 +162,12 @@ func (self *StateObject) GetState(db trie.Database, key common.Hash) common.Hash
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	if enc := self.getTrie(db).Get(key[:]); len(enc) > 0 {
+	// Load from DB in case it is missing, the flat state if it can.
+	enc, ok := self.getFlatState(key)
+	if !ok {
+		enc = self.getTrie(db).Get(key[:])
+	}
+	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
 		if err != nil {
 			self.setError(err)
@@ -195,13 +205,21 @@ func (self *StateObject) updateTrie(db trie.Database) {
 	tr := self.getTrie(db)
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+
+		var v []byte
 		if (value == common.Hash{}) {
 			tr.Delete(key[:])
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			tr.Update(key[:], v)
+		}
+		if self.db.flat != nil {
+			if self.flatStorage == nil {
+				self.flatStorage = make(map[common.Hash][]byte)
+			}
+			self.flatStorage[crypto.Keccak256Hash(key[:])] = v
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		tr.Update(key[:], v)
 	}
 }
 
@@ -275,6 +293,13 @@ func (self *StateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
+	stateObject.created = self.created
+	if self.flatStorage != nil {
+		stateObject.flatStorage = make(map[common.Hash][]byte, len(self.flatStorage))
+		for key, value := range self.flatStorage {
+			stateObject.flatStorage[key] = value
+		}
+	}
 	return stateObject
 }
 
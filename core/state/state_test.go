@@ -19,6 +19,7 @@ package state
 import (
 	"bytes"
 	"math/big"
+	"reflect"
 	"testing"
 
 	checker "gopkg.in/check.v1"
@@ -26,6 +27,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 type StateSuite struct {
@@ -91,6 +93,94 @@ func (s *StateSuite) SetUpTest(c *checker.C) {
 	s.state, _ = New(common.Hash{}, db)
 }
 
+// Tests that storage ranges page through the slots in the order of their
+// hashed keys and resolve the preimages of the keys.
+func TestStorageRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := New(common.Hash{}, db)
+	addr := toAddr([]byte{0x01})
+	for i := byte(1); i <= 5; i++ {
+		statedb.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	statedb.IntermediateRoot()
+
+	all, err := statedb.StorageRange(addr, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all.Storage) != 5 || all.NextKey != nil {
+		t.Fatalf("full range mismatch: %d slots, next key %v", len(all.Storage), all.NextKey)
+	}
+	for hash, entry := range all.Storage {
+		if entry.Key == nil || crypto.Keccak256Hash(entry.Key[:]) != hash {
+			t.Fatalf("slot %x: preimage mismatch: %v", hash, entry.Key)
+		}
+		if want := statedb.GetState(addr, *entry.Key); entry.Value != want {
+			t.Errorf("slot %x: value mismatch: have %x, want %x", *entry.Key, entry.Value, want)
+		}
+	}
+	paged := make(map[common.Hash]StorageEntry)
+	var start []byte
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("paging doesn't terminate")
+		}
+		result, err := statedb.StorageRange(addr, start, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for hash, entry := range result.Storage {
+			if _, ok := paged[hash]; ok {
+				t.Fatalf("slot %x returned twice", hash)
+			}
+			paged[hash] = entry
+		}
+		if result.NextKey == nil {
+			break
+		}
+		start = result.NextKey[:]
+	}
+	if !reflect.DeepEqual(paged, all.Storage) {
+		t.Errorf("paged storage mismatch: have %v, want %v", paged, all.Storage)
+	}
+	if _, err := statedb.StorageRange(toAddr([]byte{0x02}), nil, 0); err == nil {
+		t.Errorf("storage range of missing account returned no error")
+	}
+}
+
+// Tests that storage preimages are exported for the keys written while they
+// were recorded and the others are listed as missing.
+func TestStoragePreimages(t *testing.T) {
+	defer func(record bool) { trie.RecordPreimages = record }(trie.RecordPreimages)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := New(common.Hash{}, db)
+	addr := toAddr([]byte{0x01})
+	recorded, unrecorded := common.Hash{1}, common.Hash{2}
+	statedb.SetState(addr, recorded, common.Hash{1})
+	root, _ := statedb.Commit()
+
+	trie.RecordPreimages = false
+	statedb, _ = New(root, db)
+	statedb.SetState(addr, unrecorded, common.Hash{2})
+	root, _ = statedb.Commit()
+
+	statedb, _ = New(root, db)
+	result, err := statedb.StoragePreimages(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Hash != crypto.Keccak256Hash(addr[:]) {
+		t.Errorf("address hash mismatch: have %x", result.Hash)
+	}
+	if len(result.Storage) != 1 || result.Storage[crypto.Keccak256Hash(recorded[:])] != recorded {
+		t.Errorf("preimages mismatch: %v", result.Storage)
+	}
+	if len(result.Missing) != 1 || result.Missing[0] != crypto.Keccak256Hash(unrecorded[:]) {
+		t.Errorf("missing preimages mismatch: %x", result.Missing)
+	}
+}
+
 func TestNull(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	state, _ := New(common.Hash{}, db)
@@ -84,6 +84,12 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
+	// Flat state accelerating reads, see Flat. It's only read if it holds the
+	// root the state was opened at and updated with the changes committed.
+	flat       *Flat
+	originRoot common.Hash
+	flatStale  bool // whether changes were dropped from the dirty set, so the committed changes are incomplete
+
 	lock sync.Mutex
 }
 
@@ -102,6 +108,7 @@ func New(root common.Hash, db ethdb.Database) (*StateDB, error) {
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash]vm.Logs),
+		originRoot:        root,
 	}, nil
 }
 
@@ -123,9 +130,20 @@ func (self *StateDB) New(root common.Hash) (*StateDB, error) {
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash]vm.Logs),
+		flat:              self.flat,
+		originRoot:        root,
 	}, nil
 }
 
+// SetFlat sets the flat state accelerating the reads of the state database
+// and the ones it opens, which it updates with the changes committed.
+func (self *StateDB) SetFlat(flat *Flat) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.flat = flat
+}
+
 // Reset clears out all emphemeral state objects from the state db, but keeps
 // the underlying state trie to avoid reloading data for the next operations.
 func (self *StateDB) Reset(root common.Hash) error {
@@ -144,6 +162,8 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.txIndex = 0
 	self.logs = make(map[common.Hash]vm.Logs)
 	self.logSize = 0
+	self.originRoot = root
+	self.flatStale = false
 	self.clearJournalAndRefund()
 
 	return nil
@@ -385,8 +405,11 @@ func (self *StateDB) GetStateObject(addr common.Address) (stateObject *StateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc := self.trie.Get(addr[:])
+	// Load the object from the database, the flat state if it can.
+	enc, inc, fromFlat := self.getFlatAccount(addr)
+	if !fromFlat {
+		enc = self.trie.Get(addr[:])
+	}
 	if len(enc) == 0 {
 		return nil
 	}
@@ -397,6 +420,7 @@ func (self *StateDB) GetStateObject(addr common.Address) (stateObject *StateObje
 	}
 	// Insert into the live set.
 	obj := newObject(self, addr, data, self.MarkStateObjectDirty)
+	obj.fromFlat, obj.flatInc = fromFlat, inc
 	self.setStateObject(obj)
 	return obj
 }
@@ -426,6 +450,7 @@ func (self *StateDB) createObject(addr common.Address) (newobj, prev *StateObjec
 	prev = self.GetStateObject(addr)
 	newobj = newObject(self, addr, Account{}, self.MarkStateObjectDirty)
 	newobj.setNonce(StartingNonce) // sets the object to dirty
+	newobj.created = true
 	if prev == nil {
 		if glog.V(logger.Core) {
 			glog.Infof("(+) %x\n", addr)
@@ -473,6 +498,9 @@ func (self *StateDB) Copy() *StateDB {
 		refund:            new(big.Int).Set(self.refund),
 		logs:              make(map[common.Hash]vm.Logs, len(self.logs)),
 		logSize:           self.logSize,
+		flat:              self.flat,
+		originRoot:        self.originRoot,
+		flatStale:         self.flatStale,
 	}
 	// Copy the dirty states and logs
 	for addr, _ := range self.stateObjectsDirty {
@@ -559,6 +587,7 @@ func (s *StateDB) DeleteSuicides() {
 		}
 		delete(s.stateObjectsDirty, addr)
 	}
+	s.flatStale = true
 }
 
 // Commit commits all state changes to the database.
@@ -587,12 +616,20 @@ func (s *StateDB) clearJournalAndRefund() {
 func (s *StateDB) commit(dbw trie.DatabaseWriter) (root common.Hash, err error) {
 	defer s.clearJournalAndRefund()
 
+	// Collect the changes for the flat state, unless some were dropped
+	var diff *flatDiff
+	if s.flat != nil && !s.flatStale {
+		diff = newFlatDiff(s.originRoot)
+	}
 	// Commit objects to the trie.
 	for addr, stateObject := range s.stateObjects {
 		if stateObject.suicided {
 			// If the object has been removed, don't bother syncing it
 			// and just mark it for deletion in the trie.
 			s.deleteStateObject(stateObject)
+			if diff != nil {
+				diff.deleteAccount(stateObject)
+			}
 		} else if _, ok := s.stateObjectsDirty[addr]; ok {
 			// Write any contract code associated with the state object
 			if stateObject.code != nil && stateObject.dirtyCode {
@@ -607,6 +644,9 @@ func (s *StateDB) commit(dbw trie.DatabaseWriter) (root common.Hash, err error)
 			}
 			// Update the object in the main account trie.
 			s.updateStateObject(stateObject)
+			if diff != nil {
+				diff.updateAccount(stateObject)
+			}
 		}
 		delete(s.stateObjectsDirty, addr)
 	}
@@ -614,6 +654,16 @@ func (s *StateDB) commit(dbw trie.DatabaseWriter) (root common.Hash, err error)
 	root, err = s.trie.CommitTo(dbw)
 	if err == nil {
 		s.pushTrie(s.trie)
+
+		// Objects kept live are read at the new root from now on
+		if diff != nil {
+			s.flat.addDiff(root, diff)
+		}
+		for _, stateObject := range s.stateObjects {
+			stateObject.created, stateObject.fromFlat = false, false
+			stateObject.flatStorage = nil
+		}
+		s.originRoot, s.flatStale = root, false
 	}
 	return root, err
 }
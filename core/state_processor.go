@@ -18,6 +18,8 @@ package core
 
 import (
 	"math/big"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -38,6 +40,8 @@ var (
 type StateProcessor struct {
 	config *ChainConfig
 	bc     *BlockChain
+
+	slowTxThreshold int64 // atomic, nanoseconds of execution after which transactions are reported, 0 = off
 }
 
 // NewStateProcessor initialises a new StateProcessor.
@@ -64,16 +68,22 @@ func (p *StateProcessor) Process(block *types.Block, publicState, privateState *
 		header          = block.Header()
 		allLogs         vm.Logs
 		gp              = new(GasPool).AddGas(block.GasLimit())
+		slowTxThreshold = time.Duration(atomic.LoadInt64(&p.slowTxThreshold))
+		slowTxs         []SlowTxEvent
 	)
 
 	for i, tx := range block.Transactions() {
 		publicState.StartRecord(tx.Hash(), block.Hash(), i)
 		privateState.StartRecord(tx.Hash(), block.Hash(), i)
 
-		publicReceipt, privateReceipt, _, err := ApplyTransaction(p.config, p.bc, gp, publicState, privateState, header, tx, totalUsedGas, cfg)
+		tstart := time.Now()
+		publicReceipt, privateReceipt, gas, err := ApplyTransaction(p.config, p.bc, gp, publicState, privateState, header, tx, totalUsedGas, cfg)
 		if err != nil {
 			return nil, nil, nil, totalUsedGas, err
 		}
+		if elapsed := time.Since(tstart); slowTxThreshold > 0 && elapsed > slowTxThreshold {
+			slowTxs = append(slowTxs, SlowTxEvent{Block: block, Index: i, Tx: tx, Duration: elapsed, GasUsed: gas})
+		}
 		publicReceipts = append(publicReceipts, publicReceipt)
 		allLogs = append(allLogs, publicReceipt.Logs...)
 
@@ -85,6 +95,7 @@ func (p *StateProcessor) Process(block *types.Block, publicState, privateState *
 		}
 	}
 	AccumulateRewards(publicState, header, block.Uncles())
+	p.reportSlowTxs(slowTxs)
 
 	return publicReceipts, privateReceipts, allLogs, totalUsedGas, err
 }
@@ -102,17 +113,33 @@ func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, publicSt
 	if tx.GasPrice() != nil && tx.GasPrice().Cmp(common.Big0) > 0 {
 		return nil, nil, nil, ErrInvalidGasPrice
 	}
+	if tx.Protected() && (!config.IsEIP155(header.Number) || tx.ChainId().Cmp(config.ChainId) != 0) {
+		return nil, nil, nil, types.ErrInvalidChainId
+	}
 
-	_, gas, err := ApplyMessage(NewEnv(publicState, privateState, config, bc, tx, header, cfg), tx, gp)
+	result, err := ApplyMessage(NewEnv(publicState, privateState, config, bc, tx, header, cfg), tx, gp)
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	gas := result.UsedGas
 
-	// Update the state with pending changes
+	// Update the state with pending changes. Since Byzantium receipts carry a
+	// status code instead of the intermediate state root.
+	byzantium := config.IsByzantium(header.Number)
 	usedGas.Add(usedGas, gas)
-	publicReceipt := types.NewReceipt(publicState.IntermediateRoot().Bytes(), usedGas)
+	publicRoot := publicState.IntermediateRoot().Bytes()
+	if byzantium {
+		publicRoot = nil
+	}
+	publicReceipt := types.NewReceipt(publicRoot, usedGas)
 	publicReceipt.TxHash = tx.Hash()
 	publicReceipt.GasUsed = new(big.Int).Set(gas)
+	// The outcome of a private transaction is only known to its parties, so
+	// the public receipt, which is part of consensus, always reports success.
+	publicReceipt.Status = types.ReceiptStatusSuccessful
+	if !tx.IsPrivate() {
+		setReceiptStatus(publicReceipt, result)
+	}
 	if MessageCreatesContract(tx) {
 		from, _ := tx.From()
 		publicReceipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
@@ -124,9 +151,14 @@ func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, publicSt
 
 	var privateReceipt *types.Receipt
 	if tx.IsPrivate() {
-		privateReceipt = types.NewReceipt(privateState.IntermediateRoot().Bytes(), usedGas)
+		privateRoot := privateState.IntermediateRoot().Bytes()
+		if byzantium {
+			privateRoot = nil
+		}
+		privateReceipt = types.NewReceipt(privateRoot, usedGas)
 		privateReceipt.TxHash = tx.Hash()
 		privateReceipt.GasUsed = new(big.Int).Set(gas)
+		setReceiptStatus(privateReceipt, result)
 		if MessageCreatesContract(tx) {
 			from, _ := tx.From()
 			privateReceipt.ContractAddress = crypto.CreateAddress(from, tx.Nonce())
@@ -140,6 +172,16 @@ func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, publicSt
 	return publicReceipt, privateReceipt, gas, err
 }
 
+// setReceiptStatus records the outcome of a transaction in its receipt.
+func setReceiptStatus(receipt *types.Receipt, result *ExecutionResult) {
+	if result.Failed() {
+		receipt.Status = types.ReceiptStatusFailed
+	} else {
+		receipt.Status = types.ReceiptStatusSuccessful
+	}
+	receipt.RevertReason = result.Revert()
+}
+
 // AccumulateRewards credits the coinbase of the given block with the
 // mining reward. The total reward consists of the static block reward
 // and rewards for included uncles. The coinbase of each uncle block is
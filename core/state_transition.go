@@ -57,6 +57,7 @@ type StateTransition struct {
 	value         *big.Int
 	data          []byte
 	state         vm.Database
+	vmErr         error // Error returned by the EVM, if any
 
 	env vm.Environment
 }
@@ -131,18 +132,49 @@ func NewStateTransition(env vm.Environment, msg Message, gp *GasPool) *StateTran
 	return st
 }
 
+// ExecutionResult is the outcome of a message applied by ApplyMessage.
+type ExecutionResult struct {
+	UsedGas    *big.Int // Gas used, including refunds
+	Err        error    // Error returned by the EVM, e.g. out of gas or a revert
+	ReturnData []byte   // Data returned by the EVM, or the revert data
+}
+
+// Failed returns whether the EVM execution failed. Failed executions are
+// still included in blocks.
+func (r *ExecutionResult) Failed() bool { return r.Err != nil }
+
+// Return returns the data returned by a successful execution.
+func (r *ExecutionResult) Return() []byte {
+	if r.Err != nil {
+		return nil
+	}
+	return common.CopyBytes(r.ReturnData)
+}
+
+// Revert returns the revert data if the execution was reverted.
+func (r *ExecutionResult) Revert() []byte {
+	if r.Err != vm.ErrExecutionReverted {
+		return nil
+	}
+	return common.CopyBytes(r.ReturnData)
+}
+
 // ApplyMessage computes the new state by applying the given message
 // against the old state within the environment.
 //
-// ApplyMessage returns the bytes returned by any EVM execution (if it took place),
-// the gas used (which includes gas refunds) and an error if it failed. An error always
-// indicates a core error meaning that the message would always fail for that particular
-// state and would never be accepted within a block.
-func ApplyMessage(env vm.Environment, msg Message, gp *GasPool) ([]byte, *big.Int, error) {
+// ApplyMessage returns the result of any EVM execution (if it took place),
+// including the gas used (which includes gas refunds), and an error if it
+// failed. An error always indicates a core error meaning that the message would
+// always fail for that particular state and would never be accepted within a
+// block. EVM failures are reported in the result instead.
+func ApplyMessage(env vm.Environment, msg Message, gp *GasPool) (*ExecutionResult, error) {
 	st := NewStateTransition(env, msg, gp)
 
 	ret, _, gasUsed, err := st.TransitionDb()
-	return ret, gasUsed, err
+	if err != nil {
+		return nil, err
+	}
+	return &ExecutionResult{UsedGas: gasUsed, Err: st.vmErr, ReturnData: ret}, nil
 }
 
 func (self *StateTransition) from() (vm.Account, error) {
@@ -239,6 +271,11 @@ func (self *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *b
 	if msg, ok := msg.(PrivateMessage); ok && msg.IsPrivate() {
 		isPrivate = true
 		data, err = private.P.Receive(self.data)
+		if private.IsPayloadUnavailable(err) {
+			// The node is a party to the transaction but couldn't retrieve its
+			// payload, skipping it would make the private state diverge.
+			return nil, nil, nil, err
+		}
 		// Increment the public account nonce if:
 		// 1. Tx is private and *not* a participant of the group and either call or create
 		// 2. Tx is private we are part of the group and is a call
@@ -267,8 +304,10 @@ func (self *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *b
 			self.gas = Big0
 		}
 
-		if err != nil {
+		if err != nil && err != vm.ErrExecutionReverted {
 			ret = nil
+		}
+		if err != nil {
 			glog.V(logger.Core).Infoln("VM create err:", err)
 		}
 	} else {
@@ -291,6 +330,7 @@ func (self *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *b
 
 	// We aren't interested in errors here. Errors returned by the VM are non-consensus errors and therefor shouldn't bubble up
 	if err != nil {
+		self.vmErr = err
 		err = nil
 	}
 
@@ -43,6 +43,16 @@ var (
 	ErrGasLimit           = errors.New("Exceeds block gas limit")
 	ErrNegativeValue      = errors.New("Negative value")
 	ErrNonExistentAccount = errors.New("Account doesn't exist")
+	ErrUnprotectedTx      = errors.New("Only replay protected transactions allowed")
+)
+
+// Reasons of the transaction pool for dropping transactions.
+const (
+	TxDropReplaced    = "replaced"             // A transaction with the same nonce took its place
+	TxDropUnderpriced = "underpriced"          // It didn't outbid the transaction with the same nonce
+	TxDropTimeout     = "timeout"              // It was queued for too long without activity of the sender
+	TxDropPoolFull    = "pool-full"            // It was evicted as the pool exceeded its limits
+	TxDropInvalidated = "invalidated-by-reorg" // The new chain head took its nonce or the funds it spends
 )
 
 var (
@@ -71,6 +81,7 @@ type TxPool struct {
 	eventMux     *event.TypeMux
 	events       event.Subscription
 	localTx      *txSet
+	correlations map[common.Hash]string // Correlation IDs of the traced transactions
 	mu           sync.RWMutex
 
 	pending map[common.Address]*txList         // All currently processable transactions
@@ -81,7 +92,11 @@ type TxPool struct {
 	wg   sync.WaitGroup // for shutdown sync
 	quit chan struct{}
 
-	homestead bool
+	homestead        bool
+	requireProtected bool                   // Reject public transactions without EIP-155 replay protection
+	votes            *voteFilter            // Checks QuorumChain vote transactions, nil if not
+	senders          *TxRateLimiter         // Throttles the transactions of each sender, nil if not
+	included         func(common.Hash) bool // Whether a transaction is in the chain, nil if unknown
 }
 
 func NewTxPool(config *ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
@@ -96,7 +111,8 @@ func NewTxPool(config *ChainConfig, eventMux *event.TypeMux, currentStateFn stat
 		gasLimit:     gasLimitFn,
 		pendingState: nil,
 		localTx:      newTxSet(),
-		events:       eventMux.Subscribe(ChainHeadEvent{}, RemovedTransactionEvent{}),
+		correlations: make(map[common.Hash]string),
+		events:       eventMux.Subscribe(ChainHeadEvent{}, ChainEvent{}, RemovedTransactionEvent{}),
 		quit:         make(chan struct{}),
 	}
 
@@ -125,6 +141,10 @@ func (pool *TxPool) eventLoop() {
 
 			pool.resetState()
 			pool.mu.Unlock()
+		case ChainEvent:
+			pool.mu.Lock()
+			pool.traceCorrelated(ev.Block)
+			pool.mu.Unlock()
 		case RemovedTransactionEvent:
 			pool.AddBatch(ev.Txs)
 		}
@@ -199,6 +219,30 @@ func (pool *TxPool) Stats() (pending int, queued int) {
 	return
 }
 
+// PrivateStats retrieves the number of pending and queued private transactions,
+// which are waiting to be sealed after their payload was sent to the privacy
+// manager.
+func (pool *TxPool) PrivateStats() (pending int, queued int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	for _, list := range pool.pending {
+		for _, tx := range list.Flatten() {
+			if tx.IsPrivate() {
+				pending++
+			}
+		}
+	}
+	for _, list := range pool.queue {
+		for _, tx := range list.Flatten() {
+			if tx.IsPrivate() {
+				queued++
+			}
+		}
+	}
+	return
+}
+
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
 func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
@@ -243,6 +287,40 @@ func (pool *TxPool) SetLocal(tx *types.Transaction) {
 	pool.localTx.add(tx.Hash())
 }
 
+// SetRequireProtected sets whether public transactions must be replay protected
+// to enter the pool. Private transactions can't be, and are always accepted.
+func (pool *TxPool) SetRequireProtected(require bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.requireProtected = require
+}
+
+// SetVoteFilter enables the checks of QuorumChain vote transactions against the
+// chain head returned by head.
+func (pool *TxPool) SetVoteFilter(config VoteFilterConfig, head func() *types.Block) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.votes = newVoteFilter(config, head)
+}
+
+// SetRateLimit limits the transactions admitted per sender and minute, and
+// bans senders exceeding it for the ban time of the config. Earlier throttling
+// and bans are lifted.
+func (pool *TxPool) SetRateLimit(config TxRateLimitConfig) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.senders = NewTxRateLimiter("txpool/ratelimit", config.Sender, config.BanTime)
+}
+
+// SetTxLookup sets the check whether a transaction is included in the chain,
+// which tells transactions dropped for their nonce apart from included ones.
+// Without it, no drop events are posted for these.
+func (pool *TxPool) SetTxLookup(included func(common.Hash) bool) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.included = included
+}
+
 // validateTx checks whether a transaction is valid according
 // to the consensus rules.
 func (pool *TxPool) validateTx(tx *types.Transaction) error {
@@ -252,6 +330,16 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 		return ErrInvalidGasPrice
 	}
 
+	// Replay protected transactions must be signed for this chain. Whether
+	// EIP-155 is already active is left to block processing.
+	if tx.Protected() {
+		if pool.config.ChainId == nil || tx.ChainId().Cmp(pool.config.ChainId) != 0 {
+			return types.ErrInvalidChainId
+		}
+	} else if pool.requireProtected && !tx.IsPrivate() {
+		return ErrUnprotectedTx
+	}
+
 	currentState, _, err := pool.currentState()
 	if err != nil {
 		return err
@@ -297,7 +385,13 @@ func (pool *TxPool) validateTx(tx *types.Transaction) error {
 		return ErrIntrinsicGas
 	}
 
-	return nil
+	if pool.votes != nil {
+		if err := pool.votes.check(tx, from, currentState, pool.config); err != nil {
+			return err
+		}
+	}
+	// Only valid transactions count towards the rate limit
+	return pool.senders.Allow(from.Hex(), time.Now())
 }
 
 // add validates a transaction and inserts it into the non-executable queue for
@@ -337,11 +431,13 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) {
 	}
 	inserted, old := pool.queue[from].Add(tx)
 	if !inserted {
+		pool.dropped(tx, TxDropUnderpriced, nil)
 		return // An older transaction was better, discard this
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.dropped(old, TxDropReplaced, tx)
 	}
 	pool.all[hash] = tx
 }
@@ -360,11 +456,13 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if !inserted {
 		// An older transaction was better, discard this
 		delete(pool.all, hash)
+		pool.dropped(tx, TxDropUnderpriced, nil)
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		pool.dropped(old, TxDropReplaced, tx)
 	}
 	pool.all[hash] = tx // Failsafe to work around direct pending inserts (tests)
 
@@ -376,17 +474,50 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 
 // Add queues a single transaction in the pool if it is valid.
 func (pool *TxPool) Add(tx *types.Transaction) error {
+	return pool.AddCorrelated(tx, "")
+}
+
+// AddCorrelated queues a single transaction in the pool if it is valid, and
+// traces it in the logs under the given correlation ID until it's included in
+// a block or dropped from the pool. An empty ID doesn't trace the transaction.
+func (pool *TxPool) AddCorrelated(tx *types.Transaction, id string) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
 	if err := pool.add(tx); err != nil {
 		return err
 	}
+	if id != "" {
+		pool.correlations[tx.Hash()] = id
+		glog.V(logger.Info).Infof("Tx(%x) [correlation id %s] added to the pool\n", tx.Hash(), id)
+	}
 	pool.promoteExecutables()
 
 	return nil
 }
 
+// traceCorrelated logs which traced transactions were included in the given
+// block and which were dropped from the pool since, and stops tracing them.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) traceCorrelated(block *types.Block) {
+	if len(pool.correlations) == 0 {
+		return
+	}
+	for _, tx := range block.Transactions() {
+		if id, ok := pool.correlations[tx.Hash()]; ok {
+			glog.V(logger.Info).Infof("Tx(%x) [correlation id %s] included in block #%d [%x]\n", tx.Hash(), id, block.NumberU64(), block.Hash())
+			delete(pool.correlations, tx.Hash())
+		}
+	}
+	for hash, id := range pool.correlations {
+		if pool.all[hash] == nil {
+			glog.V(logger.Info).Infof("Tx(%x) [correlation id %s] dropped from the pool\n", hash, id)
+			delete(pool.correlations, hash)
+		}
+	}
+}
+
 // AddBatch attempts to queue a batch of transactions.
 func (pool *TxPool) AddBatch(txs []*types.Transaction) {
 	pool.mu.Lock()
@@ -491,6 +622,7 @@ func (pool *TxPool) promoteExecutables() {
 				glog.Infof("Removed old queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.droppedStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance)
 		drops, _ := list.Filter(state.GetBalance(addr))
@@ -499,6 +631,7 @@ func (pool *TxPool) promoteExecutables() {
 				glog.Infof("Removed unpayable queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.dropped(tx, TxDropInvalidated, nil)
 		}
 		// Gather all executable transactions and promote them
 		nonce := pool.pendingState.GetNonce(addr)
@@ -514,6 +647,7 @@ func (pool *TxPool) promoteExecutables() {
 				glog.Infof("Removed cap-exceeding queued transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.dropped(tx, TxDropPoolFull, nil)
 		}
 		queued += uint64(list.Len())
 
@@ -558,7 +692,7 @@ func (pool *TxPool) promoteExecutables() {
 				for pending > maxPendingTotal && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
 					for i := 0; i < len(offenders)-1; i++ {
 						list := pool.pending[offenders[i]]
-						list.Cap(list.Len() - 1)
+						pool.evict(list.Cap(list.Len() - 1))
 						pending--
 					}
 				}
@@ -569,7 +703,7 @@ func (pool *TxPool) promoteExecutables() {
 			for pending > maxPendingTotal && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > minPendingPerAccount {
 				for _, addr := range offenders {
 					list := pool.pending[addr]
-					list.Cap(list.Len() - 1)
+					pool.evict(list.Cap(list.Len() - 1))
 					pending--
 				}
 			}
@@ -595,6 +729,7 @@ func (pool *TxPool) promoteExecutables() {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash())
+					pool.dropped(tx, TxDropPoolFull, nil)
 				}
 				drop -= size
 				continue
@@ -603,6 +738,7 @@ func (pool *TxPool) promoteExecutables() {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash())
+				pool.dropped(txs[i], TxDropPoolFull, nil)
 				drop--
 			}
 		}
@@ -629,6 +765,7 @@ func (pool *TxPool) demoteUnexecutables() {
 				glog.Infof("Removed old pending transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.droppedStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance), and queue any invalids back for later
 		drops, invalids := list.Filter(state.GetBalance(addr))
@@ -637,6 +774,7 @@ func (pool *TxPool) demoteUnexecutables() {
 				glog.Infof("Removed unpayable pending transaction: %v", tx)
 			}
 			delete(pool.all, tx.Hash())
+			pool.dropped(tx, TxDropInvalidated, nil)
 		}
 		for _, tx := range invalids {
 			if glog.V(logger.Core) {
@@ -652,6 +790,40 @@ func (pool *TxPool) demoteUnexecutables() {
 	}
 }
 
+// evict drops transactions evicted from the pending lists as the pool is full.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) evict(txs types.Transactions) {
+	for _, tx := range txs {
+		delete(pool.all, tx.Hash())
+		pool.dropped(tx, TxDropPoolFull, nil)
+	}
+}
+
+// dropped announces a transaction leaving the pool without being included.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) dropped(tx *types.Transaction, reason string, replacement *types.Transaction) {
+	go pool.eventMux.Post(TxDroppedEvent{Tx: tx, Reason: reason, Replacement: replacement})
+}
+
+// droppedStale announces a transaction dropped as the chain reached its nonce,
+// unless that's because it was included. The chain is looked up outside of the
+// pool lock.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) droppedStale(tx *types.Transaction) {
+	included := pool.included
+	if included == nil {
+		return
+	}
+	go func() {
+		if !included(tx.Hash()) {
+			pool.eventMux.Post(TxDroppedEvent{Tx: tx, Reason: TxDropInvalidated})
+		}
+	}()
+}
+
 // expirationLoop is a loop that periodically iterates over all accounts with
 // queued transactions and drop all that have been inactive for a prolonged amount
 // of time.
@@ -669,6 +841,7 @@ func (pool *TxPool) expirationLoop() {
 				if time.Since(pool.beats[addr]) > maxQueuedLifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash())
+						pool.dropped(tx, TxDropTimeout, nil)
 					}
 				}
 			}
@@ -0,0 +1,105 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var txPoolSnapshotKey = []byte("txpool-snapshot")
+
+// txPoolSnapshot is the content of the transaction pool saved across a restart.
+type txPoolSnapshot struct {
+	Time   uint64 // Unix time it was taken at
+	Txs    []*types.Transaction
+	Locals []common.Hash // Transactions marked local, spared when the pool is full
+}
+
+// SaveSnapshot stores all pending and queued transactions of the pool in the
+// database, for RestoreSnapshot to add them back after a restart. It returns
+// the number of transactions saved.
+func (pool *TxPool) SaveSnapshot(db ethdb.Database) (int, error) {
+	pool.mu.RLock()
+	snapshot := &txPoolSnapshot{Time: uint64(time.Now().Unix())}
+	for _, lists := range []map[common.Address]*txList{pool.pending, pool.queue} {
+		for _, list := range lists {
+			for _, tx := range list.Flatten() {
+				snapshot.Txs = append(snapshot.Txs, tx)
+				if pool.localTx.contains(tx.Hash()) {
+					snapshot.Locals = append(snapshot.Locals, tx.Hash())
+				}
+			}
+		}
+	}
+	pool.mu.RUnlock()
+
+	blob, err := rlp.EncodeToBytes(snapshot)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.Put(txPoolSnapshotKey, blob); err != nil {
+		return 0, err
+	}
+	return len(snapshot.Txs), nil
+}
+
+// RestoreSnapshot adds the transactions saved by SaveSnapshot back to the pool
+// and deletes the snapshot. Transactions which became invalid in the meantime,
+// e.g. as they were included in a block, are dropped. The sender rate limit
+// doesn't apply to them, as they were admitted before. It returns the number of
+// transactions restored and dropped, and nothing if there is no snapshot.
+func (pool *TxPool) RestoreSnapshot(db ethdb.Database) (restored, dropped int, err error) {
+	blob, _ := db.Get(txPoolSnapshotKey)
+	if len(blob) == 0 {
+		return 0, 0, nil
+	}
+	snapshot := new(txPoolSnapshot)
+	if err := rlp.DecodeBytes(blob, snapshot); err != nil {
+		db.Delete(txPoolSnapshotKey)
+		return 0, 0, err
+	}
+
+	pool.mu.Lock()
+	senders := pool.senders
+	pool.senders = nil
+	for _, tx := range snapshot.Txs {
+		if err := pool.add(tx); err != nil {
+			glog.V(logger.Debug).Infof("dropped transaction %x of the pool snapshot: %v", tx.Hash(), err)
+			dropped++
+			continue
+		}
+		restored++
+	}
+	for _, hash := range snapshot.Locals {
+		if pool.all[hash] != nil {
+			pool.localTx.add(hash)
+		}
+	}
+	pool.senders = senders
+	pool.promoteExecutables()
+	pool.mu.Unlock()
+
+	glog.V(logger.Info).Infof("Restored %d transactions of the pool snapshot taken %v ago, %d no longer valid", restored, common.PrettyDuration(time.Since(time.Unix(int64(snapshot.Time), 0))), dropped)
+	return restored, dropped, db.Delete(txPoolSnapshotKey)
+}
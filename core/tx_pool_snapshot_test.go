@@ -0,0 +1,72 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that the pending and queued transactions of the pool survive a restart
+// through a snapshot, except the ones no longer valid.
+func TestTxPoolSnapshot(t *testing.T) {
+	pool, key := setupTxPool()
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		included = transaction(0, big.NewInt(0), big.NewInt(100000), key)
+		pending  = transaction(1, big.NewInt(0), big.NewInt(100000), key)
+		queued   = transaction(3, big.NewInt(0), big.NewInt(100000), key)
+	)
+	from, _ := included.From()
+	state, _, _ := pool.currentState()
+	state.AddBalance(from, big.NewInt(1000000))
+	for _, tx := range []*types.Transaction{included, pending, queued} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pool.SetLocal(pending)
+	if saved, err := pool.SaveSnapshot(db); err != nil || saved != 3 {
+		t.Fatalf("saved %d transactions: %v", saved, err)
+	}
+	pool.Stop()
+
+	// Restore into a new pool after the first transaction was included
+	state.SetNonce(from, 1)
+	pool = NewTxPool(testChainConfig(), new(event.TypeMux), pool.currentState, pool.gasLimit)
+	pool.resetState()
+	defer pool.Stop()
+
+	restored, dropped, err := pool.RestoreSnapshot(db)
+	if err != nil || restored != 2 || dropped != 1 {
+		t.Fatalf("restored %d and dropped %d transactions: %v", restored, dropped, err)
+	}
+	if p, q := pool.Stats(); p != 1 || q != 1 {
+		t.Errorf("pool mismatch: have %d pending and %d queued, want 1 and 1", p, q)
+	}
+	if !pool.localTx.contains(pending.Hash()) {
+		t.Error("local mark not restored")
+	}
+	if restored, _, _ := pool.RestoreSnapshot(db); restored != 0 {
+		t.Errorf("snapshot restored twice")
+	}
+}
@@ -72,6 +72,33 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+func TestReplayProtectedTransactions(t *testing.T) {
+	pool, key := setupTxPool()
+	pool.config = &ChainConfig{HomesteadBlock: big.NewInt(0), ChainId: big.NewInt(10), EIP155Block: big.NewInt(0)}
+
+	wrongChain, _ := types.NewTransaction(0, common.Address{}, big.NewInt(100), big.NewInt(100000), big.NewInt(0), nil).SignECDSAEIP155(key, big.NewInt(11))
+	if err := pool.Add(wrongChain); err != types.ErrInvalidChainId {
+		t.Error("expected", types.ErrInvalidChainId, "got", err)
+	}
+
+	pool.SetRequireProtected(true)
+	if err := pool.Add(transaction(0, big.NewInt(0), big.NewInt(100000), key)); err != ErrUnprotectedTx {
+		t.Error("expected", ErrUnprotectedTx, "got", err)
+	}
+
+	// Private transactions can't be protected and are exempt
+	private := transaction(0, big.NewInt(0), big.NewInt(100000), key)
+	private.SetPrivate()
+	if err := pool.Add(private); err == ErrUnprotectedTx {
+		t.Error("private transaction rejected as unprotected")
+	}
+
+	protected, _ := types.NewTransaction(0, common.Address{}, big.NewInt(100), big.NewInt(100000), big.NewInt(0), nil).SignECDSAEIP155(key, big.NewInt(10))
+	if err := pool.Add(protected); err == ErrUnprotectedTx || err == types.ErrInvalidChainId {
+		t.Error("protected transaction rejected:", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	pool, key := setupTxPool()
 	tx := transaction(0, big.NewInt(0), big.NewInt(100), key)
@@ -143,6 +170,61 @@ func TestRemoveTx(t *testing.T) {
 	}
 }
 
+// Tests that private transactions are counted separately in both the pending
+// and the queued pool.
+func TestTransactionPrivateStats(t *testing.T) {
+	pool, key := setupTxPool()
+	currentState, _, _ := pool.currentState()
+	currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	for _, nonce := range []uint64{0, 1, 5, 6} {
+		tx := transaction(nonce, big.NewInt(0), big.NewInt(100), key)
+		if nonce != 1 {
+			tx.SetPrivate()
+		}
+		pool.enqueueTx(tx.Hash(), tx)
+	}
+	pool.promoteExecutables()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d pending, %d queued", pending, queued)
+	}
+	if pending, queued := pool.PrivateStats(); pending != 1 || queued != 2 {
+		t.Errorf("private stats mismatch: have %d pending, %d queued, want 1, 2", pending, queued)
+	}
+}
+
+// Tests that correlated transactions are traced until they're included in a
+// block or dropped from the pool.
+func TestTransactionCorrelation(t *testing.T) {
+	pool, key := setupTxPool()
+	currentState, _, _ := pool.currentState()
+	currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	included := transaction(0, big.NewInt(0), big.NewInt(100000), key)
+	dropped := transaction(1, big.NewInt(0), big.NewInt(100000), key)
+	if err := pool.AddCorrelated(included, "order-1"); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.AddCorrelated(dropped, "order-2"); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if err := pool.AddCorrelated(transaction(2, big.NewInt(0), big.NewInt(100000), key), ""); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	if len(pool.correlations) != 2 {
+		t.Fatalf("traced transactions mismatch: have %d, want 2", len(pool.correlations))
+	}
+	pool.mu.Lock()
+	pool.removeTx(dropped.Hash())
+	pool.traceCorrelated(types.NewBlock(&types.Header{Number: big.NewInt(1)}, types.Transactions{included}, nil, nil))
+	pool.mu.Unlock()
+
+	if len(pool.correlations) != 0 {
+		t.Errorf("transactions still traced: %v", pool.correlations)
+	}
+}
+
 func TestTransactionChainFork(t *testing.T) {
 	pool, key := setupTxPool()
 	addr := crypto.PubkeyToAddress(key.PublicKey)
@@ -225,6 +307,63 @@ func TestRemovedTxEvent(t *testing.T) {
 	}
 }
 
+// Tests that transactions leaving the pool without being included are reported
+// with the reason, and that the ones included in a block aren't.
+func TestTxDroppedEvent(t *testing.T) {
+	pool, key := setupTxPool()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000000))
+
+	included := make(map[common.Hash]bool)
+	pool.SetTxLookup(func(hash common.Hash) bool { return included[hash] })
+
+	sub := pool.eventMux.Subscribe(TxDroppedEvent{})
+	defer sub.Unsubscribe()
+
+	expect := func(tx *types.Transaction, reason string) {
+		select {
+		case ev := <-sub.Chan():
+			drop := ev.Data.(TxDroppedEvent)
+			if drop.Tx.Hash() != tx.Hash() || drop.Reason != reason {
+				t.Fatalf("dropped event mismatch: have %x %q, want %x %q", drop.Tx.Hash(), drop.Reason, tx.Hash(), reason)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no dropped event for %x (%s)", tx.Hash(), reason)
+		}
+	}
+	// A transaction not outbidding the queued one with the same nonce, which
+	// it can't as quorum transactions are free
+	queued := transaction(1, big.NewInt(0), big.NewInt(100000), key)
+	if err := pool.Add(queued); err != nil {
+		t.Fatal(err)
+	}
+	duplicate := transaction(1, big.NewInt(0), big.NewInt(200000), key)
+	if err := pool.Add(duplicate); err != nil {
+		t.Fatal(err)
+	}
+	expect(duplicate, TxDropUnderpriced)
+
+	// Pending transactions whose nonces a new block used, one of them in it
+	mined := transaction(0, big.NewInt(0), big.NewInt(100000), key)
+	if err := pool.Add(mined); err != nil {
+		t.Fatal(err)
+	}
+	if pool.pending[from].Len() != 2 {
+		t.Fatalf("expected 2 pending transactions, got %d", pool.pending[from].Len())
+	}
+	included[mined.Hash()] = true
+	currentState.SetNonce(from, 2)
+	pool.resetState()
+	expect(queued, TxDropInvalidated)
+
+	select {
+	case ev := <-sub.Chan():
+		t.Fatalf("unexpected dropped event for included transaction: %+v", ev.Data)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // Tests that if the transaction count belonging to a single account goes above
 // some threshold, the higher transactions are dropped to prevent DOS attacks.
 func TestTransactionQueueAccountLimiting(t *testing.T) {
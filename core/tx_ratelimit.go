@@ -0,0 +1,132 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+var (
+	ErrTxRateLimit = errors.New("transaction rate limit exceeded")
+	ErrTxBanned    = errors.New("temporarily banned for exceeding the transaction rate limit")
+)
+
+// TxRateLimitConfig limits the rate transactions are submitted at. With gas
+// prices of 0, flooding the pool costs senders nothing.
+type TxRateLimitConfig struct {
+	Sender  int           // Transactions per sender and minute admitted to the pool, 0 = unlimited
+	RPC     int           // Transactions per source IP and minute submitted over RPC, 0 = unlimited
+	BanTime time.Duration // Time senders and IPs exceeding their limit are rejected for, 0 = throttle only
+}
+
+// TxRateLimiter throttles the transactions of each key, such as a sender or a
+// source IP, with a token bucket holding a minute's worth of transactions.
+// Keys exceeding the limit are banned for the ban time, rejecting all their
+// transactions until it has passed. A nil limiter admits every transaction.
+type TxRateLimiter struct {
+	limit   int
+	banTime time.Duration
+	name    string
+
+	throttledMeter gometrics.Meter
+	bannedMeter    gometrics.Meter
+
+	lock    sync.Mutex
+	buckets map[string]*txBucket
+	pruned  time.Time
+}
+
+type txBucket struct {
+	tokens  float64
+	updated time.Time
+	banned  time.Time // End of the ban, zero if not banned
+}
+
+// NewTxRateLimiter creates a limiter of the given transactions per key and
+// minute, named in the logs and metrics, or returns nil if the limit is 0.
+func NewTxRateLimiter(name string, limit int, banTime time.Duration) *TxRateLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &TxRateLimiter{
+		limit:          limit,
+		banTime:        banTime,
+		name:           name,
+		throttledMeter: metrics.NewMeter(name + "/throttled"),
+		bannedMeter:    metrics.NewMeter(name + "/banned"),
+		buckets:        make(map[string]*txBucket),
+	}
+}
+
+// Allow takes a token from the bucket of a key, returning why the transaction
+// is rejected if the bucket is empty or the key is banned. Empty keys, of
+// callers which can't be told apart, aren't limited.
+func (l *TxRateLimiter) Allow(key string, now time.Time) error {
+	if l == nil || key == "" {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.prune(now)
+	limit := float64(l.limit)
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &txBucket{tokens: limit, updated: now}
+		l.buckets[key] = bucket
+	}
+	if now.Before(bucket.banned) {
+		l.bannedMeter.Mark(1)
+		return ErrTxBanned
+	}
+	bucket.tokens += now.Sub(bucket.updated).Minutes() * limit
+	if bucket.tokens > limit {
+		bucket.tokens = limit
+	}
+	bucket.updated = now
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return nil
+	}
+	l.throttledMeter.Mark(1)
+	if l.banTime > 0 {
+		bucket.banned = now.Add(l.banTime)
+		glog.V(logger.Warn).Infof("%s: banned %s for %v, exceeded %d transactions per minute", l.name, key, l.banTime, l.limit)
+	}
+	return ErrTxRateLimit
+}
+
+// prune drops the buckets which refilled and aren't banned once a minute, so
+// keys seen once don't accumulate.
+func (l *TxRateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+	for key, bucket := range l.buckets {
+		if now.After(bucket.banned) && now.Sub(bucket.updated) >= time.Minute {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that keys are throttled to the limit per minute and banned for the ban
+// time once they exceed it.
+func TestTxRateLimiter(t *testing.T) {
+	var (
+		limiter = NewTxRateLimiter("test", 2, time.Minute)
+		now     = time.Now()
+	)
+	if err := limiter.Allow("a", now); err != nil {
+		t.Fatalf("first transaction rejected: %v", err)
+	}
+	if err := limiter.Allow("a", now); err != nil {
+		t.Fatalf("second transaction rejected: %v", err)
+	}
+	if err := limiter.Allow("a", now); err != ErrTxRateLimit {
+		t.Fatalf("transaction over the limit: have %v, want %v", err, ErrTxRateLimit)
+	}
+	if err := limiter.Allow("b", now); err != nil {
+		t.Fatalf("transaction of another key rejected: %v", err)
+	}
+	if err := limiter.Allow("", now); err != nil {
+		t.Fatalf("transaction of an unknown key rejected: %v", err)
+	}
+	if err := limiter.Allow("a", now.Add(59*time.Second)); err != ErrTxBanned {
+		t.Fatalf("transaction while banned: have %v, want %v", err, ErrTxBanned)
+	}
+	if err := limiter.Allow("a", now.Add(61*time.Second)); err != nil {
+		t.Fatalf("transaction after the ban rejected: %v", err)
+	}
+	// Idle keys are dropped, banned ones kept
+	limiter = NewTxRateLimiter("test", 1, time.Hour)
+	limiter.Allow("idle", now)
+	limiter.Allow("banned", now)
+	limiter.Allow("banned", now)
+	limiter.Allow("new", now.Add(2*time.Minute))
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Errorf("idle key not dropped")
+	}
+	if _, ok := limiter.buckets["banned"]; !ok {
+		t.Errorf("banned key dropped")
+	}
+
+	if NewTxRateLimiter("test", 0, time.Minute).Allow("a", now) != nil {
+		t.Errorf("transaction rejected without a limit")
+	}
+}
+
+// Tests that the pool throttles senders, counting only valid transactions.
+func TestTxPoolRateLimit(t *testing.T) {
+	pool, key := setupTxPool()
+	pool.SetRateLimit(TxRateLimitConfig{Sender: 1})
+
+	statedb, _, _ := pool.currentState()
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	if err := pool.Add(transaction(0, common.Big0, big.NewInt(100), key)); err != ErrIntrinsicGas {
+		t.Fatalf("invalid transaction: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	if err := pool.Add(transaction(0, common.Big0, big.NewInt(100000), key)); err != nil {
+		t.Fatalf("transaction within the limit rejected: %v", err)
+	}
+	if err := pool.Add(transaction(1, common.Big0, big.NewInt(100000), key)); err != ErrTxRateLimit {
+		t.Fatalf("transaction over the limit: have %v, want %v", err, ErrTxRateLimit)
+	}
+}
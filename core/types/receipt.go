@@ -17,6 +17,7 @@
 package types
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -33,10 +34,25 @@ var (
 	errMissingReceiptFields    = errors.New("missing required JSON receipt fields")
 )
 
+var (
+	receiptStatusFailedRLP     = []byte{}
+	receiptStatusSuccessfulRLP = []byte{0x01}
+)
+
+const (
+	// ReceiptStatusFailed is the status code of a transaction if execution failed.
+	ReceiptStatusFailed = uint(0)
+
+	// ReceiptStatusSuccessful is the status code of a transaction if execution succeeded.
+	ReceiptStatusSuccessful = uint(1)
+)
+
 // Receipt represents the results of a transaction.
 type Receipt struct {
-	// Consensus fields
+	// Consensus fields. Since Byzantium, receipts carry a status code instead
+	// of the intermediate state root.
 	PostState         []byte
+	Status            uint
 	CumulativeGasUsed *big.Int
 	Bloom             Bloom
 	Logs              vm.Logs
@@ -45,16 +61,19 @@ type Receipt struct {
 	TxHash          common.Hash
 	ContractAddress common.Address
 	GasUsed         *big.Int
+	RevertReason    []byte // Data passed to REVERT, if the transaction reverted
 }
 
 type jsonReceipt struct {
-	PostState         *common.Hash    `json:"root"`
+	PostState         *common.Hash    `json:"root,omitempty"`
+	Status            *hexUint64      `json:"status,omitempty"`
 	CumulativeGasUsed *hexBig         `json:"cumulativeGasUsed"`
 	Bloom             *Bloom          `json:"logsBloom"`
 	Logs              *vm.Logs        `json:"logs"`
 	TxHash            *common.Hash    `json:"transactionHash"`
 	ContractAddress   *common.Address `json:"contractAddress"`
 	GasUsed           *hexBig         `json:"gasUsed"`
+	RevertReason      *hexBytes       `json:"revertReason,omitempty"`
 }
 
 // NewReceipt creates a barebone transaction receipt, copying the init fields.
@@ -65,7 +84,7 @@ func NewReceipt(root []byte, cumulativeGasUsed *big.Int) *Receipt {
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs})
+	return rlp.Encode(w, []interface{}{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
 }
 
 // DecodeRLP implements rlp.Decoder, and loads the consensus fields of a receipt
@@ -80,23 +99,57 @@ func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
 	if err := s.Decode(&receipt); err != nil {
 		return err
 	}
-	r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs = receipt.PostState, receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs
+	r.setStatus(receipt.PostState)
+	r.CumulativeGasUsed, r.Bloom, r.Logs = receipt.CumulativeGasUsed, receipt.Bloom, receipt.Logs
 	return nil
 }
 
+// statusEncoding returns the intermediate state root, or the encoded status
+// code if there is none.
+func (r *Receipt) statusEncoding() []byte {
+	if len(r.PostState) > 0 {
+		return r.PostState
+	}
+	if r.Status == ReceiptStatusFailed {
+		return receiptStatusFailedRLP
+	}
+	return receiptStatusSuccessfulRLP
+}
+
+// setStatus sets the status code or the intermediate state root, whichever
+// postStateOrStatus contains.
+func (r *Receipt) setStatus(postStateOrStatus []byte) {
+	switch {
+	case bytes.Equal(postStateOrStatus, receiptStatusSuccessfulRLP):
+		r.PostState, r.Status = nil, ReceiptStatusSuccessful
+	case bytes.Equal(postStateOrStatus, receiptStatusFailedRLP):
+		r.PostState, r.Status = nil, ReceiptStatusFailed
+	default:
+		r.PostState = common.CopyBytes(postStateOrStatus)
+	}
+}
+
 // MarshalJSON encodes receipts into the web3 RPC response block format.
 func (r *Receipt) MarshalJSON() ([]byte, error) {
-	root := common.BytesToHash(r.PostState)
-
-	return json.Marshal(&jsonReceipt{
-		PostState:         &root,
+	dec := &jsonReceipt{
 		CumulativeGasUsed: (*hexBig)(r.CumulativeGasUsed),
 		Bloom:             &r.Bloom,
 		Logs:              &r.Logs,
 		TxHash:            &r.TxHash,
 		ContractAddress:   &r.ContractAddress,
 		GasUsed:           (*hexBig)(r.GasUsed),
-	})
+	}
+	if len(r.PostState) > 0 {
+		root := common.BytesToHash(r.PostState)
+		dec.PostState = &root
+	} else {
+		status := hexUint64(r.Status)
+		dec.Status = &status
+	}
+	if len(r.RevertReason) > 0 {
+		dec.RevertReason = (*hexBytes)(&r.RevertReason)
+	}
+	return json.Marshal(dec)
 }
 
 // UnmarshalJSON decodes the web3 RPC receipt format.
@@ -107,8 +160,9 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 	}
 	// Ensure that all fields are set. PostState is checked separately because it is a
 	// recent addition to the RPC spec (as of August 2016) and older implementations might
-	// not provide it. Note that ContractAddress is not checked because it can be null.
-	if dec.PostState == nil {
+	// not provide it. Since Byzantium it is replaced by the status code. Note that
+	// ContractAddress is not checked because it can be null.
+	if dec.PostState == nil && dec.Status == nil {
 		return errMissingReceiptPostState
 	}
 	if dec.CumulativeGasUsed == nil || dec.Bloom == nil ||
@@ -116,21 +170,31 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		return errMissingReceiptFields
 	}
 	*r = Receipt{
-		PostState:         (*dec.PostState)[:],
 		CumulativeGasUsed: (*big.Int)(dec.CumulativeGasUsed),
 		Bloom:             *dec.Bloom,
 		Logs:              *dec.Logs,
 		TxHash:            *dec.TxHash,
 		GasUsed:           (*big.Int)(dec.GasUsed),
 	}
+	if dec.PostState != nil {
+		r.PostState = (*dec.PostState)[:]
+	} else {
+		r.Status = uint(*dec.Status)
+	}
 	if dec.ContractAddress != nil {
 		r.ContractAddress = *dec.ContractAddress
 	}
+	if dec.RevertReason != nil {
+		r.RevertReason = *dec.RevertReason
+	}
 	return nil
 }
 
 // String implements the Stringer interface.
 func (r *Receipt) String() string {
+	if len(r.PostState) == 0 {
+		return fmt.Sprintf("receipt{status=%d cgas=%v bloom=%x logs=%v}", r.Status, r.CumulativeGasUsed, r.Bloom, r.Logs)
+	}
 	return fmt.Sprintf("receipt{med=%x cgas=%v bloom=%x logs=%v}", r.PostState, r.CumulativeGasUsed, r.Bloom, r.Logs)
 }
 
@@ -145,32 +209,67 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 	for i, log := range r.Logs {
 		logs[i] = (*vm.LogForStorage)(log)
 	}
-	return rlp.Encode(w, []interface{}{r.PostState, r.CumulativeGasUsed, r.Bloom, r.TxHash, r.ContractAddress, logs, r.GasUsed})
+	return rlp.Encode(w, []interface{}{(*Receipt)(r).statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.TxHash, r.ContractAddress, logs, r.GasUsed, r.RevertReason})
+}
+
+// storedReceiptRLP is the storage encoding of a receipt. Receipts stored
+// before revert reasons were recorded lack the last field.
+type storedReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed *big.Int
+	Bloom             Bloom
+	TxHash            common.Hash
+	ContractAddress   common.Address
+	Logs              []*vm.LogForStorage
+	GasUsed           *big.Int
+	RevertReason      []byte
+}
+
+type legacyStoredReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed *big.Int
+	Bloom             Bloom
+	TxHash            common.Hash
+	ContractAddress   common.Address
+	Logs              []*vm.LogForStorage
+	GasUsed           *big.Int
 }
 
 // DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
 // fields of a receipt from an RLP stream.
 func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
-	var receipt struct {
-		PostState         []byte
-		CumulativeGasUsed *big.Int
-		Bloom             Bloom
-		TxHash            common.Hash
-		ContractAddress   common.Address
-		Logs              []*vm.LogForStorage
-		GasUsed           *big.Int
-	}
-	if err := s.Decode(&receipt); err != nil {
+	raw, err := s.Raw()
+	if err != nil {
 		return err
 	}
+	var receipt storedReceiptRLP
+	if err := rlp.DecodeBytes(raw, &receipt); err != nil {
+		var legacy legacyStoredReceiptRLP
+		if rlp.DecodeBytes(raw, &legacy) != nil {
+			return err
+		}
+		receipt = storedReceiptRLP{
+			PostStateOrStatus: legacy.PostStateOrStatus,
+			CumulativeGasUsed: legacy.CumulativeGasUsed,
+			Bloom:             legacy.Bloom,
+			TxHash:            legacy.TxHash,
+			ContractAddress:   legacy.ContractAddress,
+			Logs:              legacy.Logs,
+			GasUsed:           legacy.GasUsed,
+		}
+	}
 	// Assign the consensus fields
-	r.PostState, r.CumulativeGasUsed, r.Bloom = receipt.PostState, receipt.CumulativeGasUsed, receipt.Bloom
+	(*Receipt)(r).setStatus(receipt.PostStateOrStatus)
+	r.CumulativeGasUsed, r.Bloom = receipt.CumulativeGasUsed, receipt.Bloom
 	r.Logs = make(vm.Logs, len(receipt.Logs))
 	for i, log := range receipt.Logs {
 		r.Logs[i] = (*vm.Log)(log)
 	}
 	// Assign the implementation fields
 	r.TxHash, r.ContractAddress, r.GasUsed = receipt.TxHash, receipt.ContractAddress, receipt.GasUsed
+	if len(receipt.RevertReason) > 0 {
+		r.RevertReason = receipt.RevertReason
+	}
 
 	return nil
 }
@@ -0,0 +1,94 @@
+// Copyright 2016 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that receipts without an intermediate state root encode their status
+// code instead, both in consensus and storage encoding.
+func TestReceiptStatusEncoding(t *testing.T) {
+	for _, status := range []uint{ReceiptStatusFailed, ReceiptStatusSuccessful} {
+		receipt := &Receipt{Status: status, CumulativeGasUsed: big.NewInt(1)}
+
+		enc, err := rlp.EncodeToBytes(receipt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := new(Receipt)
+		if err := rlp.DecodeBytes(enc, dec); err != nil {
+			t.Fatal(err)
+		}
+		if dec.Status != status || len(dec.PostState) != 0 {
+			t.Errorf("status %d: decoded status %d, post state %x", status, dec.Status, dec.PostState)
+		}
+	}
+
+	root := common.HexToHash("0x0102").Bytes()
+	enc, err := rlp.EncodeToBytes(&Receipt{PostState: root, CumulativeGasUsed: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Receipt)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.PostState, root) {
+		t.Errorf("post state mismatch: have %x, want %x", dec.PostState, root)
+	}
+}
+
+// Tests that stored receipts keep their revert reason, and that receipts stored
+// before revert reasons were recorded still decode.
+func TestReceiptStorageRevertReason(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusFailed,
+		CumulativeGasUsed: big.NewInt(1),
+		GasUsed:           big.NewInt(1),
+		RevertReason:      []byte{0x08, 0xc3, 0x79, 0xa0},
+	}
+	enc, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dec.RevertReason, receipt.RevertReason) {
+		t.Errorf("revert reason mismatch: have %x, want %x", dec.RevertReason, receipt.RevertReason)
+	}
+
+	legacy, err := rlp.EncodeToBytes([]interface{}{common.HexToHash("0x01").Bytes(), big.NewInt(1), Bloom{}, common.Hash{}, common.Address{}, []interface{}{}, big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec = new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(legacy, dec); err != nil {
+		t.Fatalf("failed to decode legacy receipt: %v", err)
+	}
+	if len(dec.PostState) != 32 || dec.RevertReason != nil {
+		t.Errorf("legacy receipt decoded with post state %x, revert reason %x", dec.PostState, dec.RevertReason)
+	}
+}
@@ -34,6 +34,10 @@ import (
 
 var ErrInvalidSig = errors.New("invalid transaction v, r, s values")
 
+// ErrInvalidChainId is returned for replay protected transactions signed for
+// a different chain.
+var ErrInvalidChainId = errors.New("invalid chain id for signer")
+
 var (
 	errMissingTxSignatureFields = errors.New("missing required JSON transaction signature fields")
 	errMissingTxFields          = errors.New("missing required JSON transaction fields")
@@ -53,7 +57,7 @@ type txdata struct {
 	Recipient       *common.Address `rlp:"nil"` // nil means contract creation
 	Amount          *big.Int
 	Payload         []byte
-	V               byte     // signature
+	V               *big.Int // signature
 	R, S            *big.Int // signature
 }
 
@@ -65,7 +69,7 @@ type jsonTransaction struct {
 	Recipient    *common.Address `json:"to"`
 	Amount       *hexBig         `json:"value"`
 	Payload      *hexBytes       `json:"input"`
-	V            *hexUint64      `json:"v"`
+	V            *hexBig         `json:"v"`
 	R            *hexBig         `json:"r"`
 	S            *hexBig         `json:"s"`
 }
@@ -82,6 +86,7 @@ func NewContractCreation(nonce uint64, amount, gasLimit, gasPrice *big.Int, data
 		GasLimit:     new(big.Int).Set(gasLimit),
 		Price:        new(big.Int),
 		Payload:      data,
+		V:            new(big.Int),
 		R:            new(big.Int),
 		S:            new(big.Int),
 	}}
@@ -99,6 +104,7 @@ func NewTransaction(nonce uint64, to common.Address, amount, gasLimit, gasPrice
 		Amount:       new(big.Int),
 		GasLimit:     new(big.Int),
 		Price:        new(big.Int),
+		V:            new(big.Int),
 		R:            new(big.Int),
 		S:            new(big.Int),
 	}
@@ -128,7 +134,7 @@ func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
 
 // MarshalJSON encodes transactions into the web3 RPC response block format.
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
-	hash, v := tx.Hash(), uint64(tx.data.V)
+	hash := tx.Hash()
 
 	return json.Marshal(&jsonTransaction{
 		Hash:         &hash,
@@ -138,7 +144,7 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 		Recipient:    tx.data.Recipient,
 		Amount:       (*hexBig)(tx.data.Amount),
 		Payload:      (*hexBytes)(&tx.data.Payload),
-		V:            (*hexUint64)(&v),
+		V:            (*hexBig)(tx.data.V),
 		R:            (*hexBig)(tx.data.R),
 		S:            (*hexBig)(tx.data.S),
 	})
@@ -157,7 +163,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if dec.V == nil || dec.R == nil || dec.S == nil {
 		return errMissingTxSignatureFields
 	}
-	if !crypto.ValidateSignatureValues(byte(*dec.V), (*big.Int)(dec.R), (*big.Int)(dec.S), false) {
+	if v, ok := recoveryV((*big.Int)(dec.V)); !ok || !crypto.ValidateSignatureValues(v, (*big.Int)(dec.R), (*big.Int)(dec.S), false) {
 		return ErrInvalidSig
 	}
 	if dec.AccountNonce == nil || dec.Price == nil || dec.GasLimit == nil || dec.Amount == nil || dec.Payload == nil {
@@ -173,7 +179,7 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		GasLimit:     (*big.Int)(dec.GasLimit),
 		Price:        (*big.Int)(dec.Price),
 		Payload:      *dec.Payload,
-		V:            byte(*dec.V),
+		V:            (*big.Int)(dec.V),
 		R:            (*big.Int)(dec.R),
 		S:            (*big.Int)(dec.S),
 	}
@@ -220,6 +226,73 @@ func (tx *Transaction) SigHash() common.Hash {
 	})
 }
 
+// EIP155SigHash returns the hash to be signed by the sender for a replay
+// protected transaction on the given chain, as specified by EIP-155.
+func (tx *Transaction) EIP155SigHash(chainId *big.Int) common.Hash {
+	return rlpHash([]interface{}{
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		chainId, uint(0), uint(0),
+	})
+}
+
+// Protected returns whether the transaction is replay protected, i.e. whether
+// its signature commits to a chain ID as specified by EIP-155.
+//
+// Private transactions mark themselves with a V of 37 or 38, which would be
+// the V of a replay protected transaction for chain ID 1. Chain ID 1 is
+// therefore not supported, and private transactions are never protected.
+func (tx *Transaction) Protected() bool {
+	return isProtectedV(tx.data.V)
+}
+
+func isProtectedV(v *big.Int) bool {
+	if v.BitLen() > 8 {
+		return true
+	}
+	switch v := v.Uint64(); v {
+	case 27, 28, 37, 38:
+		return false
+	default:
+		return v >= 35
+	}
+}
+
+// ChainId returns the chain ID a replay protected transaction was signed for,
+// or nil if the transaction isn't protected.
+func (tx *Transaction) ChainId() *big.Int {
+	if !tx.Protected() {
+		return nil
+	}
+	return deriveChainId(tx.data.V)
+}
+
+func deriveChainId(v *big.Int) *big.Int {
+	id := new(big.Int).Sub(v, big.NewInt(35))
+	return id.Rsh(id, 1)
+}
+
+// recoveryV returns the yellow paper (27/28) recovery id for V, which is
+// either yellow paper formatted, marked private (37/38) or EIP-155 formatted.
+func recoveryV(v *big.Int) (byte, bool) {
+	if isProtectedV(v) {
+		id := deriveChainId(v)
+		id.Lsh(id, 1).Add(id, big.NewInt(35))
+		return byte(new(big.Int).Sub(v, id).Uint64() + 27), true
+	}
+	if v.BitLen() > 8 {
+		return 0, false
+	}
+	if v := byte(v.Uint64()); v > 28 {
+		return v - 10, true
+	}
+	return byte(v.Uint64()), true
+}
+
 func (tx *Transaction) Size() common.StorageSize {
 	if size := tx.size.Load(); size != nil {
 		return size.(common.StorageSize)
@@ -288,24 +361,31 @@ func (tx *Transaction) Cost() *big.Int {
 }
 
 // SignatureValues returns the ECDSA signature values contained in the transaction.
-func (tx *Transaction) SignatureValues() (v byte, r *big.Int, s *big.Int) {
-	return tx.data.V, new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
+func (tx *Transaction) SignatureValues() (v, r, s *big.Int) {
+	return new(big.Int).Set(tx.data.V), new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
 }
 
 func (tx *Transaction) IsPrivate() bool {
-	return tx.data.V == 37 || tx.data.V == 38
+	if tx.data.V.BitLen() > 8 {
+		return false
+	}
+	v := tx.data.V.Uint64()
+	return v == 37 || v == 38
 }
 
+// SetPrivate marks the transaction as private. It must have been signed
+// without replay protection.
 func (tx *Transaction) SetPrivate() {
-	if tx.data.V == 28 {
-		tx.data.V = 38
+	if tx.data.V.Cmp(big.NewInt(28)) == 0 {
+		tx.data.V = big.NewInt(38)
 	} else {
-		tx.data.V = 37
+		tx.data.V = big.NewInt(37)
 	}
 }
 
 func (tx *Transaction) publicKey(homestead bool) ([]byte, error) {
-	if !crypto.ValidateSignatureValues(tx.data.V, tx.data.R, tx.data.S, homestead) {
+	v, ok := recoveryV(tx.data.V)
+	if !ok || !crypto.ValidateSignatureValues(v, tx.data.R, tx.data.S, homestead) {
 		return nil, ErrInvalidSig
 	}
 
@@ -314,13 +394,13 @@ func (tx *Transaction) publicKey(homestead bool) ([]byte, error) {
 	sig := make([]byte, 65)
 	copy(sig[32-len(r):32], r)
 	copy(sig[64-len(s):64], s)
-	sig[64] = tx.data.V - 27
-	if tx.data.V > 28 {
-		sig[64] -= 10
-	}
+	sig[64] = v - 27
 
 	// recover the public key from the signature
 	hash := tx.SigHash()
+	if tx.Protected() {
+		hash = tx.EIP155SigHash(tx.ChainId())
+	}
 	pub, err := crypto.Ecrecover(hash[:], sig)
 	if err != nil {
 		return nil, err
@@ -340,7 +420,21 @@ func (tx *Transaction) WithSignature(sig []byte) (*Transaction, error) {
 	cpy := &Transaction{data: tx.data}
 	cpy.data.R = new(big.Int).SetBytes(sig[:32])
 	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
-	cpy.data.V = sig[64]
+	cpy.data.V = new(big.Int).SetUint64(uint64(sig[64]))
+	return cpy, nil
+}
+
+// WithEIP155Signature returns a new replay protected transaction with the
+// given signature of EIP155SigHash(chainId). Like for WithSignature, the
+// signature needs to be formatted as described in the yellow paper (v+27).
+func (tx *Transaction) WithEIP155Signature(sig []byte, chainId *big.Int) (*Transaction, error) {
+	cpy, err := tx.WithSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	cpy.data.V.Sub(cpy.data.V, big.NewInt(27))
+	cpy.data.V.Add(cpy.data.V, new(big.Int).Lsh(chainId, 1))
+	cpy.data.V.Add(cpy.data.V, big.NewInt(35))
 	return cpy, nil
 }
 
@@ -353,6 +447,17 @@ func (tx *Transaction) SignECDSA(prv *ecdsa.PrivateKey) (*Transaction, error) {
 	return tx.WithSignature(sig)
 }
 
+// SignECDSAEIP155 signs the transaction with replay protection for the given
+// chain.
+func (tx *Transaction) SignECDSAEIP155(prv *ecdsa.PrivateKey, chainId *big.Int) (*Transaction, error) {
+	h := tx.EIP155SigHash(chainId)
+	sig, err := crypto.SignEthereum(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithEIP155Signature(sig, chainId)
+}
+
 func (tx *Transaction) String() string {
 	var from, to string
 	if f, err := tx.From(); err != nil {
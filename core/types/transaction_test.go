@@ -273,3 +273,81 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the replay protected signing hash matches the example from the
+// EIP-155 specification. The example is signed for chain ID 1, so its V of 37
+// marks it private here instead.
+func TestEIP155SigHash(t *testing.T) {
+	var tx Transaction
+	enc := common.FromHex("f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	if err := rlp.DecodeBytes(enc, &tx); err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToHash("daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53")
+	if hash := tx.EIP155SigHash(big.NewInt(1)); hash != want {
+		t.Errorf("signing hash mismatch: have %x, want %x", hash, want)
+	}
+	if tx.Protected() || !tx.IsPrivate() {
+		t.Errorf("protected = %v, private = %v; want false, true", tx.Protected(), tx.IsPrivate())
+	}
+}
+
+func TestEIP155Signing(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	chainId := big.NewInt(10)
+
+	tx, err := NewTransaction(0, addr, new(big.Int), new(big.Int), new(big.Int), nil).SignECDSAEIP155(key, chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Protected() || tx.IsPrivate() {
+		t.Fatalf("protected = %v, private = %v; want true, false", tx.Protected(), tx.IsPrivate())
+	}
+	if tx.ChainId().Cmp(chainId) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %v", tx.ChainId(), chainId)
+	}
+
+	// The protection must survive a round trip through RLP
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Transaction)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatal(err)
+	}
+	from, err := dec.From()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("sender mismatch: have %x, want %x", from, addr)
+	}
+	if dec.ChainId().Cmp(chainId) != 0 {
+		t.Errorf("decoded chain id mismatch: have %v, want %v", dec.ChainId(), chainId)
+	}
+}
+
+// Tests that private transactions, whose V collides with chain ID 1, are not
+// mistaken for replay protected ones.
+func TestPrivateTransactionNotProtected(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, err := NewTransaction(0, addr, new(big.Int), new(big.Int), new(big.Int), nil).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetPrivate()
+	if !tx.IsPrivate() || tx.Protected() || tx.ChainId() != nil {
+		t.Fatalf("private = %v, protected = %v, chain id = %v", tx.IsPrivate(), tx.Protected(), tx.ChainId())
+	}
+	from, err := tx.From()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != addr {
+		t.Errorf("sender mismatch: have %x, want %x", from, addr)
+	}
+}
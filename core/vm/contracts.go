@@ -17,7 +17,9 @@
 package vm
 
 import (
+	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -117,3 +119,54 @@ func ecrecoverFunc(in []byte) []byte {
 func memCpy(in []byte) []byte {
 	return in
 }
+
+// PrecompiledContract is a native contract which isn't part of the default set.
+// Custom precompiled contracts are registered by name and activated at a fixed
+// address from a block configured in the chain config, so consortiums can add
+// native code without changing the EVM.
+type PrecompiledContract interface {
+	// RequiredGas returns the gas charged for running the contract on input.
+	RequiredGas(input []byte) *big.Int
+	// Run executes the contract. An error fails the call, consuming all gas.
+	Run(input []byte) ([]byte, error)
+}
+
+var (
+	customPrecompilesMu sync.RWMutex
+	customPrecompiles   = make(map[string]PrecompiledContract)
+)
+
+// RegisterPrecompile makes a custom precompiled contract available under the
+// given name. It's meant to be called from init functions and panics if the
+// name is already taken.
+func RegisterPrecompile(name string, contract PrecompiledContract) {
+	customPrecompilesMu.Lock()
+	defer customPrecompilesMu.Unlock()
+
+	if _, ok := customPrecompiles[name]; ok {
+		panic(fmt.Sprintf("precompiled contract %q registered twice", name))
+	}
+	customPrecompiles[name] = contract
+}
+
+// CustomPrecompile returns the custom precompiled contract registered under the
+// given name, nil if there is none.
+func CustomPrecompile(name string) PrecompiledContract {
+	customPrecompilesMu.RLock()
+	defer customPrecompilesMu.RUnlock()
+
+	return customPrecompiles[name]
+}
+
+// CustomPrecompiles returns the names of the registered custom precompiled
+// contracts.
+func CustomPrecompiles() []string {
+	customPrecompilesMu.RLock()
+	defer customPrecompilesMu.RUnlock()
+
+	names := make([]string, 0, len(customPrecompiles))
+	for name := range customPrecompiles {
+		names = append(names, name)
+	}
+	return names
+}
@@ -27,9 +27,34 @@ import (
 // execution of the EVM instructions (e.g. whether it's homestead)
 type RuleSet interface {
 	IsHomestead(*big.Int) bool
+	// IsByzantium returns whether the REVERT opcode is available.
+	IsByzantium(*big.Int) bool
 	// GasTable returns the gas prices for this phase, which is based on
 	// block number passed in.
 	GasTable(*big.Int) params.GasTable
+	// Precompile returns the custom precompiled contract active at the
+	// address in the given block, nil if there is none.
+	Precompile(common.Address, *big.Int) PrecompiledContract
+	// MaxCodeSize returns the maximum size of contract code deployed in the
+	// given block, 0 if there is no limit.
+	MaxCodeSize(*big.Int) int
+	// Limits returns the call depth, stack and memory limits of the EVM in the
+	// given block.
+	Limits(*big.Int) Limits
+}
+
+// Limits are the limits of the EVM, which private chains may change from the
+// defaults of the public network.
+type Limits struct {
+	CallDepth int    // Maximum depth of nested calls and contract creations
+	Stack     int    // Maximum number of items on the stack of a call frame
+	Memory    uint64 // Maximum memory of a call frame in bytes (0 = bounded by gas only)
+}
+
+// DefaultLimits are the limits of the public network.
+var DefaultLimits = Limits{
+	CallDepth: int(params.CallCreateDepth.Int64()),
+	Stack:     int(params.StackLimit.Int64()),
 }
 
 // Environment is an EVM requirement and helper which allows access to outside
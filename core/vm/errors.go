@@ -16,13 +16,20 @@
 
 package vm
 
-import (
-	"errors"
-	"fmt"
-
-	"github.com/ethereum/go-ethereum/params"
-)
+import "errors"
 
 var OutOfGasError = errors.New("Out of gas")
 var CodeStoreOutOfGasError = errors.New("Contract creation code storage out of gas")
-var DepthError = fmt.Errorf("Max call depth exceeded (%d)", params.CallCreateDepth)
+var ErrMaxCodeSizeExceeded = errors.New("Max code size exceeded")
+var DepthError = errors.New("Max call depth exceeded")
+
+// ErrMemoryLimitExceeded is returned when a call frame expands its memory
+// beyond the memory limit of the chain.
+var ErrMemoryLimitExceeded = errors.New("Max memory size exceeded")
+
+// ErrExecutionReverted is returned when the code executed the REVERT opcode.
+// Unlike other errors it returns the remaining gas and the revert data.
+var ErrExecutionReverted = errors.New("execution reverted")
+
+// ErrExecutionAborted is returned when the execution was cancelled.
+var ErrExecutionAborted = errors.New("execution aborted")
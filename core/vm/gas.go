@@ -56,8 +56,9 @@ func callGas(gasTable params.GasTable, availableGas, base, callCost *big.Int) *b
 	return callCost
 }
 
-// baseCheck checks for any stack error underflows
-func baseCheck(op OpCode, stack *Stack, gas *big.Int) error {
+// baseCheck checks for any stack error underflows, and overflows beyond the
+// given stack limit
+func baseCheck(op OpCode, stack *Stack, gas *big.Int, stackLimit int) error {
 	// PUSH and DUP are a bit special. They all cost the same but we do want to have checking on stack push limit
 	// PUSH is also allowed to calculate the same price for all PUSHes
 	// DUP requirements are handled elsewhere (except for the stack limit check)
@@ -74,8 +75,8 @@ func baseCheck(op OpCode, stack *Stack, gas *big.Int) error {
 			return err
 		}
 
-		if r.stackPush > 0 && stack.len()-r.stackPop+r.stackPush > int(params.StackLimit.Int64()) {
-			return fmt.Errorf("stack limit reached %d (%d)", stack.len(), params.StackLimit.Int64())
+		if r.stackPush > 0 && stack.len()-r.stackPop+r.stackPush > stackLimit {
+			return fmt.Errorf("stack limit reached %d (%d)", stack.len(), stackLimit)
 		}
 
 		gas.Add(gas, r.gas)
@@ -83,6 +84,14 @@ func baseCheck(op OpCode, stack *Stack, gas *big.Int) error {
 	return nil
 }
 
+// checkMemoryLimit checks that memory isn't expanded beyond the memory limit.
+func checkMemoryLimit(newMemSize *big.Int, limits Limits) error {
+	if limits.Memory > 0 && newMemSize != nil && newMemSize.Cmp(new(big.Int).SetUint64(limits.Memory)) > 0 {
+		return ErrMemoryLimitExceeded
+	}
+	return nil
+}
+
 // casts a arbitrary number to the amount of words (sets of 32 bytes)
 func toWordSize(size *big.Int) *big.Int {
 	tmp := new(big.Int)
@@ -160,6 +169,7 @@ var _baseCheck = map[OpCode]req{
 	SUICIDE:      {1, Zero, 0},
 	JUMPDEST:     {0, params.JumpdestGas, 0},
 	RETURN:       {2, Zero, 0},
+	REVERT:       {2, Zero, 0},
 	PUSH1:        {0, GasFastestStep, 1},
 	DUP1:         {0, Zero, 1},
 }
@@ -96,6 +96,9 @@ func (instr instruction) do(program *Program, pc *uint64, env Environment, contr
 	case RETURN:
 		offset, size := stack.pop(), stack.pop()
 		return memory.GetPtr(offset.Int64(), size.Int64()), nil
+	case REVERT:
+		offset, size := stack.pop(), stack.pop()
+		return memory.GetPtr(offset.Int64(), size.Int64()), ErrExecutionReverted
 	default:
 		if instr.fn == nil {
 			return nil, fmt.Errorf("Invalid opcode 0x%x", instr.op)
@@ -554,7 +557,11 @@ func opCall(instr instruction, pc *uint64, env Environment, contract *Contract,
 	}
 
 	ret, err := env.Call(contract, address, args, gas, contract.Price, value)
-	if err != nil {
+	if err == ErrExecutionReverted {
+		stack.push(new(big.Int))
+
+		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
+	} else if err != nil {
 		stack.push(new(big.Int))
 
 	} else {
@@ -585,7 +592,11 @@ func opCallCode(instr instruction, pc *uint64, env Environment, contract *Contra
 
 	ret, err := env.CallCode(contract, address, args, gas, contract.Price, value)
 
-	if err != nil {
+	if err == ErrExecutionReverted {
+		stack.push(new(big.Int))
+
+		memory.Set(retOffset.Uint64(), retSize.Uint64(), ret)
+	} else if err != nil {
 		stack.push(new(big.Int))
 
 	} else {
@@ -601,7 +612,10 @@ func opDelegateCall(instr instruction, pc *uint64, env Environment, contract *Co
 	toAddr := common.BigToAddress(to)
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 	ret, err := env.DelegateCall(contract, toAddr, args, gas, contract.Price)
-	if err != nil {
+	if err == ErrExecutionReverted {
+		stack.push(new(big.Int))
+		memory.Set(outOffset.Uint64(), outSize.Uint64(), ret)
+	} else if err != nil {
 		stack.push(new(big.Int))
 	} else {
 		stack.push(big.NewInt(1))
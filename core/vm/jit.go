@@ -116,7 +116,7 @@ func (p *Program) addInstr(op OpCode, pc uint64, fn instrFn, data *big.Int) {
 	}
 	base := _baseCheck[baseOp]
 
-	returns := op == RETURN || op == SUICIDE || op == STOP
+	returns := op == RETURN || op == REVERT || op == SUICIDE || op == STOP
 	instr := instruction{op, pc, fn, data, base.gas, base.stackPop, base.stackPush, returns}
 
 	p.instructions = append(p.instructions, instr)
@@ -283,6 +283,9 @@ func CompileProgram(program *Program) (err error) {
 			program.addInstr(op, pc, opCallCode, nil)
 		case RETURN:
 			program.addInstr(op, pc, opReturn, nil)
+		case REVERT:
+			// Like DELEGATECALL, availability is checked at runtime.
+			program.addInstr(op, pc, opReturn, nil)
 		case SUICIDE:
 			program.addInstr(op, pc, opSuicide, nil)
 		case STOP: // Stop the contract
@@ -320,15 +323,25 @@ func runProgram(program *Program, pcstart uint64, mem *Memory, stack *Stack, env
 	}
 
 	homestead := env.RuleSet().IsHomestead(env.BlockNumber())
+	byzantium := env.RuleSet().IsByzantium(env.BlockNumber())
+	canceller, _ := env.Vm().(interface {
+		Cancelled() bool
+	})
 	for pc < uint64(len(program.instructions)) {
+		if canceller != nil && canceller.Cancelled() {
+			return nil, ErrExecutionAborted
+		}
 		instrCount++
 
 		instr := program.instructions[pc]
-		if instr.Op() == DELEGATECALL && !homestead {
+		if (instr.Op() == DELEGATECALL && !homestead) || (instr.Op() == REVERT && !byzantium) {
 			return nil, fmt.Errorf("Invalid opcode 0x%x", instr.Op())
 		}
 
 		ret, err := instr.do(program, &pc, env, contract, mem, stack)
+		if err == ErrExecutionReverted {
+			return ret, err
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -362,7 +375,8 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
 	)
-	err := jitBaseCheck(instr, stack, gas)
+	limits := env.RuleSet().Limits(env.BlockNumber())
+	err := jitBaseCheck(instr, stack, gas, limits.Stack)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -406,7 +420,10 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 			return nil, nil, err
 		}
 
-		var g *big.Int
+		var (
+			g        *big.Int
+			gasTable = env.RuleSet().GasTable(env.BlockNumber())
+		)
 		y, x := stack.data[stack.len()-2], stack.data[stack.len()-1]
 		val := statedb.GetState(contract.Address(), common.BigToHash(x))
 
@@ -415,13 +432,13 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 		// 2. From a non-zero value address to a zero-value address (DELETE)
 		// 3. From a non-zero to a non-zero                         (CHANGE)
 		if common.EmptyHash(val) && !common.EmptyHash(common.BigToHash(y)) {
-			g = params.SstoreSetGas
+			g = gasTable.SstoreSet
 		} else if !common.EmptyHash(val) && common.EmptyHash(common.BigToHash(y)) {
-			statedb.AddRefund(params.SstoreRefundGas)
+			statedb.AddRefund(gasTable.SstoreRefund)
 
-			g = params.SstoreClearGas
+			g = gasTable.SstoreClear
 		} else {
-			g = params.SstoreResetGas
+			g = gasTable.SstoreReset
 		}
 		gas.Set(g)
 	case SUICIDE:
@@ -434,7 +451,7 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 		newMemSize = calcMemSize(stack.peek(), u256(1))
 	case MSTORE:
 		newMemSize = calcMemSize(stack.peek(), u256(32))
-	case RETURN:
+	case RETURN, REVERT:
 		newMemSize = calcMemSize(stack.peek(), stack.data[stack.len()-2])
 	case SHA3:
 		newMemSize = calcMemSize(stack.peek(), stack.data[stack.len()-2])
@@ -484,6 +501,9 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 
 		newMemSize = common.BigMax(x, y)
 	}
+	if err := checkMemoryLimit(newMemSize, limits); err != nil {
+		return nil, nil, err
+	}
 	quadMemGas(mem, newMemSize, gas)
 
 	return newMemSize, gas, nil
@@ -491,14 +511,14 @@ func jitCalculateGasAndSize(env Environment, contract *Contract, instr instructi
 
 // jitBaseCheck is the same as baseCheck except it doesn't do the look up in the
 // gas table. This is done during compilation instead.
-func jitBaseCheck(instr instruction, stack *Stack, gas *big.Int) error {
+func jitBaseCheck(instr instruction, stack *Stack, gas *big.Int, stackLimit int) error {
 	err := stack.require(instr.spop)
 	if err != nil {
 		return err
 	}
 
-	if instr.spush > 0 && stack.len()-instr.spop+instr.spush > int(params.StackLimit.Int64()) {
-		return fmt.Errorf("stack limit reached %d (%d)", stack.len(), params.StackLimit.Int64())
+	if instr.spush > 0 && stack.len()-instr.spop+instr.spush > stackLimit {
+		return fmt.Errorf("stack limit reached %d (%d)", stack.len(), stackLimit)
 	}
 
 	// nil on gas means no base calculation
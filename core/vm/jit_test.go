@@ -96,6 +96,22 @@ func TestResetInput(t *testing.T) {
 	}
 }
 
+// Tests that the JIT stops executing a program once the EVM was cancelled.
+func TestRunProgramCancelled(t *testing.T) {
+	var sender account
+
+	env := NewEnv(&Config{EnableJit: true, ForceJit: true})
+	env.evm.Cancel()
+	contract := NewContract(sender, sender, big.NewInt(100), big.NewInt(10000), big.NewInt(0))
+	contract.CodeAddr = &common.Address{}
+
+	program := NewProgram([]byte{byte(PUSH1), 0x01, byte(PUSH1), 0x01, byte(ADD)})
+	CompileProgram(program)
+	if _, err := RunProgram(program, env, contract, nil); err != ErrExecutionAborted {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrExecutionAborted)
+	}
+}
+
 func TestPcMappingToInstruction(t *testing.T) {
 	program := NewProgram([]byte{byte(PUSH2), 0xbe, 0xef, byte(ADD)})
 	CompileProgram(program)
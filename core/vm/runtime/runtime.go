@@ -28,10 +28,16 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 )
 
-// The default, always homestead, rule set for the vm env
+// The default, always latest, rule set for the vm env
 type ruleSet struct{}
 
 func (ruleSet) IsHomestead(*big.Int) bool { return true }
+func (ruleSet) IsByzantium(*big.Int) bool { return true }
+func (ruleSet) Precompile(common.Address, *big.Int) vm.PrecompiledContract {
+	return nil
+}
+func (ruleSet) MaxCodeSize(*big.Int) int { return 0 }
+func (ruleSet) Limits(*big.Int) vm.Limits { return vm.DefaultLimits }
 func (ruleSet) GasTable(*big.Int) params.GasTable {
 	return params.GasTableHomesteadGasRepriceFork
 }
@@ -20,6 +20,7 @@ import (
 	"math/big"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -93,6 +94,47 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+func TestRevert(t *testing.T) {
+	ret, _, err := Execute([]byte{
+		byte(vm.PUSH1), 10,
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.REVERT),
+	}, nil, nil)
+	if err != vm.ErrExecutionReverted {
+		t.Fatal("expected", vm.ErrExecutionReverted, "got", err)
+	}
+
+	num := common.BytesToBig(ret)
+	if num.Cmp(big.NewInt(10)) != 0 {
+		t.Error("Expected revert data 10, got", num)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	cfg := &Config{}
+	cfg.State, _ = state.New(common.Hash{}, db)
+	setDefaults(cfg)
+
+	// Loop forever, gas is unlimited.
+	address := common.HexToAddress("0x0a")
+	cfg.State.SetCode(address, []byte{
+		byte(vm.JUMPDEST),
+		byte(vm.PUSH1), 0,
+		byte(vm.JUMP),
+	})
+	env := NewEnv(cfg, cfg.State)
+	time.AfterFunc(50*time.Millisecond, env.Vm().(*vm.EVM).Cancel)
+
+	sender := cfg.State.GetOrNewStateObject(cfg.Origin)
+	if _, err := env.Call(sender, address, nil, cfg.GasLimit, cfg.GasPrice, cfg.Value); err != vm.ErrExecutionAborted {
+		t.Fatal("expected", vm.ErrExecutionAborted, "got", err)
+	}
+}
+
 func TestCall(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	state, _ := state.New(common.Hash{}, db)
@@ -19,6 +19,7 @@ package vm
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -27,6 +28,12 @@ type ruleSet struct {
 }
 
 func (r ruleSet) IsHomestead(n *big.Int) bool { return n.Cmp(r.hs) >= 0 }
+func (r ruleSet) IsByzantium(n *big.Int) bool { return false }
+func (r ruleSet) Precompile(common.Address, *big.Int) PrecompiledContract {
+	return nil
+}
+func (r ruleSet) MaxCodeSize(*big.Int) int { return 0 }
+func (r ruleSet) Limits(*big.Int) Limits { return DefaultLimits }
 func (r ruleSet) GasTable(*big.Int) params.GasTable {
 	return params.GasTableHomestead
 }
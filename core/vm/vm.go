@@ -19,6 +19,7 @@ package vm
 import (
 	"fmt"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -45,6 +46,9 @@ type EVM struct {
 	jumpTable vmJumpTable
 	cfg       Config
 	gasTable  params.GasTable
+	limits    Limits
+
+	abort int32 // set by Cancel, checked before every instruction
 }
 
 // New returns a new instance of the EVM.
@@ -54,9 +58,21 @@ func New(env Environment, cfg Config) *EVM {
 		jumpTable: newJumpTable(env.RuleSet(), env.BlockNumber()),
 		cfg:       cfg,
 		gasTable:  env.RuleSet().GasTable(env.BlockNumber()),
+		limits:    env.RuleSet().Limits(env.BlockNumber()),
 	}
 }
 
+// Cancel aborts the current execution before its next instruction. It may be
+// called concurrently with Run.
+func (evm *EVM) Cancel() {
+	atomic.StoreInt32(&evm.abort, 1)
+}
+
+// Cancelled returns whether the execution has been cancelled.
+func (evm *EVM) Cancelled() bool {
+	return atomic.LoadInt32(&evm.abort) == 1
+}
+
 // Run loops and evaluates the contract's code with the given input data
 func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	evm.env.SetDepth(evm.env.Depth() + 1)
@@ -66,6 +82,9 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 		if p := Precompiled[contract.CodeAddr.Str()]; p != nil {
 			return evm.RunPrecompiled(p, input, contract)
 		}
+		if p := evm.env.RuleSet().Precompile(*contract.CodeAddr, evm.env.BlockNumber()); p != nil {
+			return evm.runCustomPrecompile(p, input, contract)
+		}
 	}
 
 	// Don't bother with the execution if there's no code.
@@ -125,13 +144,16 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 	}
 
 	for ; ; instrCount++ {
+		if evm.Cancelled() {
+			return nil, ErrExecutionAborted
+		}
 		// Get the memory location of pc
 		op = contract.GetOp(pc)
 		if evm.env.ReadOnly() && op.isMutating() {
 			return nil, fmt.Errorf("VM in read-only mode. Mutating opcode prohibited")
 		}
 		// calculate the new memory size and gas price for the current executing opcode
-		newMemSize, cost, err = calculateGasAndSize(evm.gasTable, evm.env, contract, caller, op, statedb, mem, stack)
+		newMemSize, cost, err = calculateGasAndSize(evm.gasTable, evm.limits, evm.env, contract, caller, op, statedb, mem, stack)
 		if err != nil {
 			return nil, err
 		}
@@ -177,6 +199,11 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 					ret := mem.GetPtr(offset.Int64(), size.Int64())
 
 					return ret, nil
+				case REVERT:
+					offset, size := stack.pop(), stack.pop()
+					ret := mem.GetPtr(offset.Int64(), size.Int64())
+
+					return ret, ErrExecutionReverted
 				case SUICIDE:
 					opSuicide(instruction{}, nil, evm.env, contract, mem, stack)
 
@@ -196,12 +223,12 @@ func (evm *EVM) Run(contract *Contract, input []byte) (ret []byte, err error) {
 
 // calculateGasAndSize calculates the required given the opcode and stack items calculates the new memorysize for
 // the operation. This does not reduce gas or resizes the memory.
-func calculateGasAndSize(gasTable params.GasTable, env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *Stack) (*big.Int, *big.Int, error) {
+func calculateGasAndSize(gasTable params.GasTable, limits Limits, env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *Stack) (*big.Int, *big.Int, error) {
 	var (
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
 	)
-	err := baseCheck(op, stack, gas)
+	err := baseCheck(op, stack, gas, limits.Stack)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -274,14 +301,14 @@ func calculateGasAndSize(gasTable params.GasTable, env Environment, contract *Co
 		// 3. From a non-zero to a non-zero                         (CHANGE)
 		if common.EmptyHash(val) && !common.EmptyHash(common.BigToHash(y)) {
 			// 0 => non 0
-			g = params.SstoreSetGas
+			g = gasTable.SstoreSet
 		} else if !common.EmptyHash(val) && common.EmptyHash(common.BigToHash(y)) {
-			statedb.AddRefund(params.SstoreRefundGas)
+			statedb.AddRefund(gasTable.SstoreRefund)
 
-			g = params.SstoreClearGas
+			g = gasTable.SstoreClear
 		} else {
 			// non 0 => non 0 (or 0 => 0)
-			g = params.SstoreResetGas
+			g = gasTable.SstoreReset
 		}
 		gas.Set(g)
 	case MLOAD:
@@ -293,7 +320,7 @@ func calculateGasAndSize(gasTable params.GasTable, env Environment, contract *Co
 	case MSTORE:
 		newMemSize = calcMemSize(stack.peek(), u256(32))
 		quadMemGas(mem, newMemSize, gas)
-	case RETURN:
+	case RETURN, REVERT:
 		newMemSize = calcMemSize(stack.peek(), stack.data[stack.len()-2])
 		quadMemGas(mem, newMemSize, gas)
 	case SHA3:
@@ -378,6 +405,9 @@ func calculateGasAndSize(gasTable params.GasTable, env Environment, contract *Co
 		gas.Add(gas, cg)
 
 	}
+	if err := checkMemoryLimit(newMemSize, limits); err != nil {
+		return nil, nil, err
+	}
 
 	return newMemSize, gas, nil
 }
@@ -393,3 +423,11 @@ func (evm *EVM) RunPrecompiled(p *PrecompiledAccount, input []byte, contract *Co
 		return nil, OutOfGasError
 	}
 }
+
+// runCustomPrecompile runs a custom precompiled contract activated by the rule set.
+func (evm *EVM) runCustomPrecompile(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
+	if !contract.UseGas(p.RequiredGas(input)) {
+		return nil, OutOfGasError
+	}
+	return p.Run(input)
+}
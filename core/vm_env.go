@@ -87,6 +87,11 @@ func NewEnv(publicState, privateState *state.StateDB, chainConfig *ChainConfig,
 	return env
 }
 
+// Cancel aborts the execution running in the environment, e.g. because it
+// took too long.
+func (env *VMEnv) Cancel()         { env.evm.Cancel() }
+func (env *VMEnv) Cancelled() bool { return env.evm.Cancelled() }
+
 func (env *VMEnv) ReadOnly() bool               { return env.readOnly }
 func (env *VMEnv) PublicState() *state.StateDB  { return env.publicState }
 func (env *VMEnv) PrivateState() *state.StateDB { return env.privateState }
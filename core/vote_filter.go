@@ -0,0 +1,236 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	ErrVoteNotVoter  = errors.New("vote from an account not allowed to vote")
+	ErrVoteStale     = errors.New("vote for a height below the chain head")
+	ErrVoteAhead     = errors.New("vote for a height too far ahead of the chain head")
+	ErrVoteNonceGap  = errors.New("vote nonce too far ahead of the voter's account nonce")
+	ErrVoteRateLimit = errors.New("voter exceeded the vote rate limit")
+)
+
+var (
+	votingContract = common.HexToAddress("0x0000000000000000000000000000000000000020")
+	voteMethodId   = crypto.Keccak256([]byte("vote(uint256,bytes32)"))[:4]
+	voteBatchId    = crypto.Keccak256([]byte("voteBatch(uint256[],bytes32[])"))[:4]
+	canVoteMethod  = crypto.Keccak256([]byte("canVote(address)"))[:4]
+
+	voteAcceptMeter    = metrics.NewMeter("txpool/votes/accepted")
+	voteNotVoterMeter  = metrics.NewMeter("txpool/votes/rejected/notvoter")
+	voteStaleMeter     = metrics.NewMeter("txpool/votes/rejected/stale")
+	voteAheadMeter     = metrics.NewMeter("txpool/votes/rejected/ahead")
+	voteNonceGapMeter  = metrics.NewMeter("txpool/votes/rejected/nonce")
+	voteRateLimitMeter = metrics.NewMeter("txpool/votes/rejected/ratelimit")
+)
+
+// VoteFilterConfig are the checks vote transactions of QuorumChain have to pass
+// to enter the transaction pool.
+type VoteFilterConfig struct {
+	MaxHeightAhead uint64 // Votes for heights further ahead of the next block are rejected
+	MaxNonceGap    uint64 // Votes with a nonce further ahead of the voter's account nonce are rejected
+	RateLimit      int    // Votes admitted per voter and minute, 0 = unlimited
+}
+
+// DefaultVoteFilterConfig binds votes to the heights around the chain head,
+// without limiting their rate.
+var DefaultVoteFilterConfig = VoteFilterConfig{
+	MaxHeightAhead: 64,
+	MaxNonceGap:    16,
+}
+
+// voteFilter rejects vote transactions to the voting contract which can't count
+// towards a current block: votes of accounts which aren't allowed to vote, votes
+// for heights already sealed and queued votes which would only be executed once
+// their height is stale. Vote batches are judged by their highest height, they
+// may carry late votes for sealed heights along with a current vote. Voters
+// sending more votes than the rate limit are throttled. It's only used by the
+// pool, under its lock.
+type voteFilter struct {
+	config  VoteFilterConfig
+	head    func() *types.Block
+	buckets map[common.Address]*voteBucket
+
+	voters     map[common.Address]bool // canVote results in the state of votersHead
+	votersHead common.Hash
+}
+
+// voteBucket is a token bucket of the votes a voter may send.
+type voteBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func newVoteFilter(config VoteFilterConfig, head func() *types.Block) *voteFilter {
+	return &voteFilter{config: config, head: head, buckets: make(map[common.Address]*voteBucket), voters: make(map[common.Address]bool)}
+}
+
+// voteHeight returns the height voted on if a transaction calls
+// vote(uint256,bytes32) on the voting contract, or the highest height voted on
+// if it calls voteBatch(uint256[],bytes32[]), nil otherwise. A batch without
+// votes yields height 0, which is always stale.
+func voteHeight(tx *types.Transaction) *big.Int {
+	data := tx.Data()
+	if to := tx.To(); to == nil || *to != votingContract || tx.IsPrivate() {
+		return nil
+	}
+	switch {
+	case len(data) == 4+2*32 && bytes.Equal(data[:4], voteMethodId):
+		return new(big.Int).SetBytes(data[4:36])
+	case len(data) >= 4+2*32 && bytes.Equal(data[:4], voteBatchId):
+		heights := batchHeights(data[4:])
+		max := new(big.Int)
+		for _, height := range heights {
+			if height.Cmp(max) > 0 {
+				max = height
+			}
+		}
+		return max
+	}
+	return nil
+}
+
+// batchHeights decodes the heights of the ABI encoded arguments of voteBatch.
+// Malformed arguments yield no heights.
+func batchHeights(args []byte) []*big.Int {
+	word := func(offset uint64) (*big.Int, bool) {
+		if offset > uint64(len(args)) || uint64(len(args))-offset < 32 {
+			return nil, false
+		}
+		return new(big.Int).SetBytes(args[offset : offset+32]), true
+	}
+	offset, ok := word(0)
+	if !ok || offset.BitLen() > 64 {
+		return nil
+	}
+	n, ok := word(offset.Uint64())
+	if !ok || n.BitLen() > 64 || n.Uint64() > uint64(len(args))/32 {
+		return nil
+	}
+	heights := make([]*big.Int, 0, n.Uint64())
+	for i := uint64(0); i < n.Uint64(); i++ {
+		height, ok := word(offset.Uint64() + 32*(i+1))
+		if !ok {
+			return nil
+		}
+		heights = append(heights, height)
+	}
+	return heights
+}
+
+// check returns why a transaction isn't admitted to the pool, or nil if it
+// isn't a vote or passes the checks.
+func (f *voteFilter) check(tx *types.Transaction, from common.Address, statedb *state.StateDB, config *ChainConfig) error {
+	height := voteHeight(tx)
+	if height == nil {
+		return nil
+	}
+	head := f.head()
+	if height.Cmp(head.Number()) < 0 {
+		voteStaleMeter.Mark(1)
+		return ErrVoteStale
+	}
+	if max := new(big.Int).SetUint64(head.NumberU64() + 1 + f.config.MaxHeightAhead); height.Cmp(max) > 0 {
+		voteAheadMeter.Mark(1)
+		return ErrVoteAhead
+	}
+	if tx.Nonce() > statedb.GetNonce(from)+f.config.MaxNonceGap {
+		voteNonceGapMeter.Mark(1)
+		return ErrVoteNonceGap
+	}
+	if !f.canVote(statedb, config, head, from) {
+		voteNotVoterMeter.Mark(1)
+		return ErrVoteNotVoter
+	}
+	if !f.allow(from, time.Now()) {
+		voteRateLimitMeter.Mark(1)
+		return ErrVoteRateLimit
+	}
+	voteAcceptMeter.Mark(1)
+	return nil
+}
+
+// allow takes a token from the bucket of a voter. Buckets hold a minute's worth
+// of votes, refilled at the rate limit.
+func (f *voteFilter) allow(voter common.Address, now time.Time) bool {
+	if f.config.RateLimit <= 0 {
+		return true
+	}
+	limit := float64(f.config.RateLimit)
+	bucket := f.buckets[voter]
+	if bucket == nil {
+		bucket = &voteBucket{tokens: limit, updated: now}
+		f.buckets[voter] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.updated).Minutes() * limit
+	if bucket.tokens > limit {
+		bucket.tokens = limit
+	}
+	bucket.updated = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// canVote reports whether an account may vote, calling the voting contract only
+// once per voter and chain head.
+func (f *voteFilter) canVote(statedb *state.StateDB, config *ChainConfig, head *types.Block, voter common.Address) bool {
+	if head.Hash() != f.votersHead {
+		f.voters = make(map[common.Address]bool)
+		f.votersHead = head.Hash()
+	}
+	allowed, ok := f.voters[voter]
+	if !ok {
+		allowed = canVote(statedb, config, head.Header(), voter)
+		f.voters[voter] = allowed
+	}
+	return allowed
+}
+
+// canVote calls canVote(address) on the voting contract in the given state.
+func canVote(statedb *state.StateDB, config *ChainConfig, header *types.Header, addr common.Address) bool {
+	var (
+		gp        = new(GasPool).AddGas(common.MaxBig)
+		stateCopy = statedb.Copy()
+		msg       = callmsg{
+			from:     stateCopy.GetOrNewStateObject(common.Address{}),
+			to:       &votingContract,
+			gas:      big.NewInt(500000),
+			gasPrice: common.Big0,
+			value:    common.Big0,
+			data:     append(append([]byte{}, canVoteMethod...), common.LeftPadBytes(addr[:], 32)...),
+		}
+		vmenv = NewEnv(stateCopy, stateCopy, config, nil, msg, header, config.VmConfig)
+	)
+	result, _, _, err := NewStateTransition(vmenv, msg, gp).TransitionDb()
+	return err == nil && new(big.Int).SetBytes(result).Cmp(common.Big1) == 0
+}
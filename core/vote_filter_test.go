@@ -0,0 +1,149 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func voteTransaction(nonce uint64, height int64, key *ecdsa.PrivateKey) *types.Transaction {
+	data := common.Hex2Bytes(fmt.Sprintf("%x%064x%064x", voteMethodId, height, 1))
+	tx, _ := types.NewTransaction(nonce, votingContract, new(big.Int), big.NewInt(100000), new(big.Int), data).SignECDSA(key)
+	return tx
+}
+
+// Tests that vote transactions are only admitted to the pool for heights around
+// the chain head, with a nonce close to the voter's and from voters.
+func TestVoteFilter(t *testing.T) {
+	pool, key := setupTxPool()
+	head := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)})
+	pool.SetVoteFilter(VoteFilterConfig{MaxHeightAhead: 2, MaxNonceGap: 1}, func() *types.Block { return head })
+
+	// The voting contract returns true for canVote of any account
+	statedb, _, _ := pool.currentState()
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1))
+	statedb.SetCode(votingContract, common.Hex2Bytes("600160005260206000f3"))
+
+	tests := []struct {
+		nonce  uint64
+		height int64
+		err    error
+	}{
+		{0, 9, ErrVoteStale},
+		{0, 14, ErrVoteAhead},
+		{2, 11, ErrVoteNonceGap},
+		{0, 10, nil},
+		{1, 13, nil},
+	}
+	for i, tt := range tests {
+		if err := pool.Add(voteTransaction(tt.nonce, tt.height, key)); err != tt.err {
+			t.Errorf("test %d: vote for height %d with nonce %d: have %v, want %v", i, tt.height, tt.nonce, err, tt.err)
+		}
+	}
+	// Other transactions to the voting contract aren't checked
+	tx, _ := types.NewTransaction(2, votingContract, new(big.Int), big.NewInt(100000), new(big.Int), nil).SignECDSA(key)
+	if err := pool.Add(tx); err != nil {
+		t.Errorf("non-vote transaction rejected: %v", err)
+	}
+
+	// Voters are looked up once per chain head
+	statedb.SetCode(votingContract, common.Hex2Bytes("600060005260206000f3"))
+	if err := pool.Add(voteTransaction(0, 11, key)); err != nil {
+		t.Errorf("voter looked up again on the same head: %v", err)
+	}
+	head = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(11)})
+	if err := pool.Add(voteTransaction(0, 11, key)); err != ErrVoteNotVoter {
+		t.Errorf("vote of non-voter: have %v, want %v", err, ErrVoteNotVoter)
+	}
+}
+
+func voteBatchTransaction(nonce uint64, heights []int64, key *ecdsa.PrivateKey) *types.Transaction {
+	data := fmt.Sprintf("%x%064x%064x%064x", voteBatchId, 64, 64+32*(len(heights)+1), len(heights))
+	for _, height := range heights {
+		data += fmt.Sprintf("%064x", height)
+	}
+	data += fmt.Sprintf("%064x", len(heights))
+	for range heights {
+		data += fmt.Sprintf("%064x", 1)
+	}
+	tx, _ := types.NewTransaction(nonce, votingContract, new(big.Int), big.NewInt(100000), new(big.Int), common.Hex2Bytes(data)).SignECDSA(key)
+	return tx
+}
+
+// Tests that vote batches are admitted by their highest height, carrying late
+// votes along with a current one.
+func TestVoteFilterBatch(t *testing.T) {
+	pool, key := setupTxPool()
+	head := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10)})
+	pool.SetVoteFilter(VoteFilterConfig{MaxHeightAhead: 2, MaxNonceGap: 1}, func() *types.Block { return head })
+
+	statedb, _, _ := pool.currentState()
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1))
+	statedb.SetCode(votingContract, common.Hex2Bytes("600160005260206000f3"))
+
+	tests := []struct {
+		heights []int64
+		err     error
+	}{
+		{nil, ErrVoteStale},
+		{[]int64{8, 9}, ErrVoteStale},
+		{[]int64{9, 14}, ErrVoteAhead},
+		{[]int64{5, 7, 11}, nil},
+	}
+	for i, tt := range tests {
+		if err := pool.Add(voteBatchTransaction(0, tt.heights, key)); err != tt.err {
+			t.Errorf("test %d: vote batch for heights %v: have %v, want %v", i, tt.heights, err, tt.err)
+		}
+	}
+	// Malformed batches count as empty ones
+	tx, _ := types.NewTransaction(1, votingContract, new(big.Int), big.NewInt(100000), new(big.Int), common.Hex2Bytes(fmt.Sprintf("%x%064x%064x", voteBatchId, 1<<40, 64))).SignECDSA(key)
+	if err := pool.Add(tx); err != ErrVoteStale {
+		t.Errorf("malformed vote batch: have %v, want %v", err, ErrVoteStale)
+	}
+}
+
+// Tests that voters are limited to the configured number of votes per minute.
+func TestVoteRateLimit(t *testing.T) {
+	var (
+		filter = newVoteFilter(VoteFilterConfig{RateLimit: 2}, nil)
+		voter  = common.Address{1}
+		now    = time.Now()
+	)
+	if !filter.allow(voter, now) || !filter.allow(voter, now) {
+		t.Fatalf("votes within the limit rejected")
+	}
+	if filter.allow(voter, now) {
+		t.Fatalf("vote over the limit admitted")
+	}
+	if !filter.allow(common.Address{2}, now) {
+		t.Fatalf("vote of another voter rejected")
+	}
+	if !filter.allow(voter, now.Add(30*time.Second)) {
+		t.Fatalf("vote after refill rejected")
+	}
+	if filter.allow(voter, now.Add(30*time.Second)) {
+		t.Fatalf("vote over the refilled limit admitted")
+	}
+}
@@ -168,6 +168,13 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// BlockPropagation returns how quickly every connected peer announces new
+// blocks, keyed by peer id. See PropagationStats for the meaning of the lag and
+// delay figures.
+func (api *PrivateAdminAPI) BlockPropagation() map[string]*PeerPropagation {
+	return api.eth.protocolManager.propagationStats()
+}
+
 // PublicDebugAPI is the collection of Etheruem full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -201,6 +208,53 @@ func (api *PublicDebugAPI) DumpBlock(number uint64, typ string) (state.Dump, err
 	}
 }
 
+// DumpStorage retrieves the entire storage of a contract at a given block. The
+// private state is used if the contract is private and this node is a party to
+// it, the public state otherwise.
+func (api *PublicDebugAPI) DumpStorage(address common.Address, number uint64) (state.StorageRangeResult, error) {
+	block := api.eth.BlockChain().GetBlockByNumber(number)
+	if block == nil {
+		return state.StorageRangeResult{}, fmt.Errorf("block #%d not found", number)
+	}
+	publicDb, privateDb, err := api.eth.BlockChain().StateAt(block.Root())
+	if err != nil {
+		return state.StorageRangeResult{}, err
+	}
+	return contractState(publicDb, privateDb, address).StorageRange(address, nil, 0)
+}
+
+// StoragePreimages retrieves the preimages of the hashed storage keys of
+// contracts at a given block, for interpreting storage dumps. Preimages are
+// only known for keys written while the node recorded them.
+func (api *PublicDebugAPI) StoragePreimages(addresses []common.Address, number uint64) ([]state.Preimages, error) {
+	block := api.eth.BlockChain().GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	publicDb, privateDb, err := api.eth.BlockChain().StateAt(block.Root())
+	if err != nil {
+		return nil, err
+	}
+	results := make([]state.Preimages, 0, len(addresses))
+	for _, address := range addresses {
+		result, err := contractState(publicDb, privateDb, address).StoragePreimages(address)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// contractState returns the private state if it holds the contract, the public
+// state otherwise.
+func contractState(publicDb, privateDb *state.StateDB, address common.Address) *state.StateDB {
+	if privateDb.Exist(address) {
+		return privateDb
+	}
+	return publicDb
+}
+
 // PrivateDebugAPI is the collection of Etheruem full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -424,7 +478,7 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 		// Mutate the state if we haven't reached the tracing transaction yet
 		if uint64(idx) < txIndex {
 			vmenv := core.NewEnv(publicStateDb, privateStateDb, api.config, api.eth.BlockChain(), msg, block.Header(), vm.Config{})
-			_, _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+			_, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
 			if err != nil {
 				return nil, fmt.Errorf("mutation failed: %v", err)
 			}
@@ -434,7 +488,14 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 		}
 		// Otherwise trace the transaction and return
 		vmenv := core.NewEnv(publicStateDb, privateStateDb, api.config, api.eth.BlockChain(), msg, block.Header(), vm.Config{Debug: true, Tracer: tracer})
-		ret, gas, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+		timeout := api.eth.evmTimeouts.Timeout("debug_traceTransaction")
+		stop := ethapi.CancelOnTimeout(ctx, vmenv, timeout)
+		result, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(tx.Gas()))
+		cancelled := vmenv.Cancelled()
+		stop()
+		if cancelled {
+			return nil, ethapi.AbortError(ctx, timeout)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("tracing failed: %v", err)
 		}
@@ -442,8 +503,9 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 		switch tracer := tracer.(type) {
 		case *vm.StructLogger:
 			return &ethapi.ExecutionResult{
-				Gas:         gas,
-				ReturnValue: fmt.Sprintf("%x", ret),
+				Gas:         result.UsedGas,
+				Failed:      result.Failed(),
+				ReturnValue: fmt.Sprintf("%x", result.ReturnData),
 				StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
 			}, nil
 		case *ethapi.JavascriptTracer:
@@ -452,3 +514,54 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, txHash common.
 	}
 	return nil, errors.New("database inconsistency")
 }
+
+// StorageRangeAt returns up to maxResult storage slots of a contract, in the
+// order of the hashes of their keys starting at keyStart, as the transaction
+// at txIndex of the given block found them. The private state is used if the
+// contract is private and this node is a party to it.
+func (api *PrivateDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, contractAddress common.Address, keyStart string, maxResult int) (state.StorageRangeResult, error) {
+	if maxResult <= 0 {
+		return state.StorageRangeResult{}, fmt.Errorf("invalid maxResult %d", maxResult)
+	}
+	block := api.eth.BlockChain().GetBlockByHash(blockHash)
+	if block == nil {
+		return state.StorageRangeResult{}, fmt.Errorf("block %x not found", blockHash)
+	}
+	publicDb, privateDb, err := computeTxState(api.eth.BlockChain(), api.config, block, txIndex)
+	if err != nil {
+		return state.StorageRangeResult{}, err
+	}
+	return contractState(publicDb, privateDb, contractAddress).StorageRange(contractAddress, common.FromHex(keyStart), maxResult)
+}
+
+// computeTxState returns the public and private state the transaction at
+// txIndex of a block is executed on, replaying the transactions before it on
+// the state of the parent block.
+func computeTxState(bc *core.BlockChain, config *core.ChainConfig, block *types.Block, txIndex int) (*state.StateDB, *state.StateDB, error) {
+	if txIndex < 0 || txIndex > len(block.Transactions()) {
+		return nil, nil, fmt.Errorf("transaction index %d out of range for block %x", txIndex, block.Hash())
+	}
+	parent := bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	publicStateDb, privateStateDb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	var (
+		gp      = new(core.GasPool).AddGas(block.GasLimit())
+		usedGas = new(big.Int)
+	)
+	for idx, tx := range block.Transactions()[:txIndex] {
+		publicStateDb.StartRecord(tx.Hash(), block.Hash(), idx)
+		privateStateDb.StartRecord(tx.Hash(), block.Hash(), idx)
+		if _, _, _, err := core.ApplyTransaction(config, bc, gp, publicStateDb, privateStateDb, block.Header(), tx, usedGas, vm.Config{}); err != nil {
+			return nil, nil, fmt.Errorf("transaction %x failed: %v", tx.Hash(), err)
+		}
+	}
+	// Move the writes of the replayed transactions into the storage tries
+	publicStateDb.IntermediateRoot()
+	privateStateDb.IntermediateRoot()
+	return publicStateDb, privateStateDb, nil
+}
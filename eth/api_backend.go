@@ -19,7 +19,10 @@ package eth
 import (
 	"fmt"
 	"math/big"
+	"net"
+	"time"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -30,6 +33,9 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/node"
 	rpc "github.com/ethereum/go-ethereum/rpc"
 	"golang.org/x/net/context"
 )
@@ -111,7 +117,7 @@ func (b *EthApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
 
-func (b *EthApiBackend) GetVMEnv(ctx context.Context, msg core.Message, state ethapi.State, header *types.Header) (vm.Environment, func() error, error) {
+func (b *EthApiBackend) GetVMEnv(ctx context.Context, msg core.Message, state ethapi.State, header *types.Header, tracer vm.Tracer) (*core.VMEnv, func() error, error) {
 	var (
 		statedb      = state.(EthApiState)
 		publicState  = statedb.publicState
@@ -126,15 +132,77 @@ func (b *EthApiBackend) GetVMEnv(ctx context.Context, msg core.Message, state et
 	from := privateState.GetOrNewStateObject(addr)
 	from.SetBalance(common.MaxBig)
 	vmError := func() error { return nil }
-	return core.NewEnv(publicState, privateState, b.eth.chainConfig, b.eth.blockchain, msg, header, b.eth.chainConfig.VmConfig), vmError, nil
+	cfg := b.eth.chainConfig.VmConfig
+	if tracer != nil {
+		cfg = vm.Config{Debug: true, Tracer: tracer}
+	}
+	return core.NewEnv(publicState, privateState, b.eth.chainConfig, b.eth.blockchain, msg, header, cfg), vmError, nil
+}
+
+func (b *EthApiBackend) EVMTimeout(method string) time.Duration {
+	return b.eth.evmTimeouts.Timeout(method)
+}
+
+func (b *EthApiBackend) CallCache() *ethapi.CallCache {
+	return b.eth.callCache
 }
 
 func (b *EthApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
+	if err := b.admitTx(ctx); err != nil {
+		return err
+	}
+	// Routing may call out to another node, so it's done without holding txMu
+	var forwarded bool
+	if b.eth.txRouter != nil {
+		var err error
+		if forwarded, err = b.eth.txRouter(ctx, signedTx); err != nil {
+			return err
+		}
+	}
 	b.eth.txMu.Lock()
 	defer b.eth.txMu.Unlock()
 
 	b.eth.txPool.SetLocal(signedTx)
-	return b.eth.txPool.Add(signedTx)
+	err := b.eth.txPool.AddCorrelated(signedTx, rpc.CorrelationIDFromContext(ctx))
+	if b.eth.inclusion != nil && (err == nil || forwarded) {
+		b.eth.inclusion.track(signedTx.Hash(), time.Now())
+	}
+	if err != nil && forwarded {
+		// The node it was forwarded to accepted the transaction, which may even
+		// have reached our pool already. It's only kept locally for the nonces.
+		glog.V(logger.Debug).Infof("forwarded transaction %x not added to the local pool: %v", signedTx.Hash(), err)
+		return nil
+	}
+	return err
+}
+
+// admitTx returns the reason locally submitted transactions are rejected, if
+// any.
+func (b *EthApiBackend) admitTx(ctx context.Context) error {
+	b.eth.txMu.Lock()
+	defer b.eth.txMu.Unlock()
+
+	if b.eth.maintenance {
+		return node.ErrMaintenance
+	}
+	if err := b.eth.rpcTxLimit.Allow(callerIP(ctx), time.Now()); err != nil {
+		return err
+	}
+	if b.eth.txAdmission != nil {
+		return b.eth.txAdmission()
+	}
+	return nil
+}
+
+// callerIP returns the IP address of the RPC caller, or an empty string for
+// in-process and IPC callers.
+func callerIP(ctx context.Context) string {
+	caller, _ := rpc.CallerFromContext(ctx)
+	host, _, err := net.SplitHostPort(caller.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	return host
 }
 
 func (b *EthApiBackend) RemoveTx(txHash common.Hash) {
@@ -187,6 +255,32 @@ func (b *EthApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
 
+// SyncProgress returns the progress of the downloader, extended to the blocks
+// the consensus engine knows the local chain is missing. In raft mode these are
+// the blocks committed to the raft log which aren't applied yet.
+func (b *EthApiBackend) SyncProgress() ethereum.SyncProgress {
+	progress := b.eth.Downloader().Progress()
+	if b.eth.syncTarget != nil {
+		progress = extendSyncProgress(progress, b.eth.blockchain.CurrentBlock().NumberU64(), b.eth.syncTarget())
+	}
+	return progress
+}
+
+// extendSyncProgress raises the highest block of a sync progress to the target
+// of the consensus engine. If the downloader isn't syncing, the sync starts at
+// the local head.
+func extendSyncProgress(progress ethereum.SyncProgress, head, target uint64) ethereum.SyncProgress {
+	if target <= head || target <= progress.HighestBlock {
+		return progress
+	}
+	if progress.CurrentBlock >= progress.HighestBlock {
+		progress.StartingBlock = head
+		progress.CurrentBlock = head
+	}
+	progress.HighestBlock = target
+	return progress
+}
+
 func (b *EthApiBackend) ProtocolVersion() int {
 	return b.eth.EthVersion()
 }
@@ -203,6 +297,10 @@ func (b *EthApiBackend) EventMux() *event.TypeMux {
 	return b.eth.EventMux()
 }
 
+func (b *EthApiBackend) ChainConfig() *core.ChainConfig {
+	return b.eth.chainConfig
+}
+
 func (b *EthApiBackend) AccountManager() *accounts.Manager {
 	return b.eth.AccountManager()
 }
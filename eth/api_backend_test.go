@@ -0,0 +1,49 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+
+	ethereum "github.com/ethereum/go-ethereum"
+)
+
+// Tests that the sync progress covers the blocks known to the consensus engine
+// whether or not the downloader is syncing.
+func TestExtendSyncProgress(t *testing.T) {
+	progress := func(start, current, highest uint64) ethereum.SyncProgress {
+		return ethereum.SyncProgress{StartingBlock: start, CurrentBlock: current, HighestBlock: highest}
+	}
+	tests := []struct {
+		progress     ethereum.SyncProgress
+		head, target uint64
+		want         ethereum.SyncProgress
+	}{
+		// Consensus engine not ahead of the local head or the downloader
+		{progress(0, 100, 100), 100, 100, progress(0, 100, 100)},
+		{progress(0, 50, 200), 50, 150, progress(0, 50, 200)},
+		// Raft follower behind with an idle downloader
+		{progress(0, 100, 60), 100, 180, progress(100, 100, 180)},
+		// Downloader syncing to a lower block
+		{progress(10, 50, 80), 50, 120, progress(10, 50, 120)},
+	}
+	for i, test := range tests {
+		if have := extendSyncProgress(test.progress, test.head, test.target); have != test.want {
+			t.Errorf("test %d: progress mismatch: have %+v, want %+v", i, have, test.want)
+		}
+	}
+}
@@ -20,6 +20,8 @@ package eth
 import (
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -45,7 +47,9 @@ import (
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
 )
 
 const (
@@ -95,7 +99,43 @@ type Config struct {
 	MinVoteTime  uint
 	MaxVoteTime  uint
 
+	VoteRateLimit int // Vote transactions per voter and minute admitted to the pool (0 = unlimited)
+
+	TxRateLimit core.TxRateLimitConfig // Transactions per sender and RPC caller admitted to the pool
+
+	StandbyWindows          int  // Missed block windows before a standby block maker takes over (0 = not a standby)
+	PauseOnDoubleProduction bool // Pause block creation if another node creates blocks with our key
+	VoteBatchSize           int  // Votes for decided heights sent per transaction (0 or 1 = vote individually)
+
 	RaftMode bool
+
+	RequireProtectedTx bool // Reject public transactions without EIP-155 replay protection
+
+	Unlock ethapi.UnlockConfig // Restrictions on personal_unlockAccount
+
+	EVMTimeouts ethapi.EVMTimeouts     // Limits on the EVM execution time of RPC methods
+	CallCache   ethapi.CallCacheConfig // Caching of eth_call results
+
+	ConfigCheck ConfigCheck // Handling of peers with a different chain config fingerprint
+
+	MinProtocolVersion uint // Lowest eth protocol version peers are admitted with, 0 for any
+
+	SyncFrom common.Hash // Trusted block an empty chain fast syncs to before fully validating blocks
+
+	LogIndex          bool   // Index the logs of every contract to speed up eth_getLogs
+	LogIndexRetention uint64 // Blocks the logs of unregistered contracts are kept in the index for
+
+	Watchdog WatchdogConfig // Detection of and recovery from a stalled chain
+
+	FinalityHook FinalityHookConfig // Delivery of finalized blocks to an external system
+
+	SlowTxThreshold time.Duration // Transactions executing longer are traced and logged, 0 to disable
+
+	FlatState bool // Keep a flat copy of the public state at the head to accelerate reads
+
+	InclusionSLA InclusionSLAConfig // Monitoring of the time submitted transactions take to be included
+
+	TxPoolSnapshot bool // Save the transaction pool on shutdown, to be restored on the next startup
 }
 
 // Ethereum implements the Ethereum full node service.
@@ -107,6 +147,11 @@ type Ethereum struct {
 	// Handlers
 	txPool          *core.TxPool
 	txMu            sync.Mutex
+	txAdmission     func() error        // Checked before accepting locally submitted transactions
+	maintenance     bool                // Reject locally submitted transactions, guarded by txMu
+	rpcTxLimit      *core.TxRateLimiter // Throttles transactions per RPC caller IP, guarded by txMu
+	txRouter        func(context.Context, *types.Transaction) (bool, error)
+	txPoolSnapshot  bool // Save the pool on shutdown
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
 	// DB interfaces
@@ -119,22 +164,34 @@ type Ethereum struct {
 
 	apiBackend *EthApiBackend
 
-	AutoDAG     bool
-	autodagquit chan bool
-	etherbase   common.Address
-	solcPath    string
+	AutoDAG      bool
+	autodagquit  chan bool
+	etherbase    common.Address
+	solcPath     string
+	unlockConfig ethapi.UnlockConfig
+	evmTimeouts  ethapi.EVMTimeouts
+	callCache    *ethapi.CallCache
 
 	NatSpec       bool
 	PowTest       bool
 	netVersionId  int
 	netRPCService *ethapi.PublicNetAPI
+	logIndex      *filters.LogIndex
+	privateIndex  *privateIndex
+	watchdog      *watchdog
+	finality      *finalityNotifier
+	slowTxs       *slowTxSampler
+	inclusion     *inclusionMonitor
+	syncTarget    func() uint64 // Highest block the consensus engine knows of, nil if only peers tell
 
 	blockVoting     *quorum.BlockVoting
 	minBlockTime    uint
 	maxBlockTime    uint
 	minVoteTime     uint
 	maxVoteTime     uint
+	standbyWindows  int
 	blockMakerStrat quorum.BlockVoteMakerStrategy
+	blockSigner     quorum.BlockSigner // closed on stop if it holds a connection, e.g. to a remote signer
 }
 
 // New creates a new Ethereum object (including the
@@ -167,10 +224,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		etherbase:      config.Etherbase,
 		AutoDAG:        config.AutoDAG,
 		solcPath:       config.SolcPath,
+		unlockConfig:   config.Unlock,
+		evmTimeouts:    config.EVMTimeouts,
+		callCache:      ethapi.NewCallCache(config.CallCache),
 		minBlockTime:   config.MinBlockTime,
 		maxBlockTime:   config.MaxBlockTime,
 		minVoteTime:    config.MinVoteTime,
 		maxVoteTime:    config.MaxVoteTime,
+		standbyWindows: config.StandbyWindows,
 	}
 
 	if err := upgradeChainDatabase(chainDb); err != nil {
@@ -204,6 +265,27 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.ChainConfig == nil {
 		return nil, errors.New("missing chain config")
 	}
+	if err := config.ChainConfig.CheckChainId(); err != nil {
+		return nil, err
+	}
+	if id := config.ChainConfig.ChainId; id != nil && id.Cmp(big.NewInt(int64(config.NetworkId))) != 0 {
+		glog.V(logger.Warn).Infof("Chain ID %v differs from network ID %d, tools may detect the network from either eth_chainId or net_version", id, config.NetworkId)
+	}
+	if err := config.ChainConfig.CheckPrecompiles(); err != nil {
+		return nil, err
+	}
+	if err := config.ChainConfig.CheckGasOverrides(); err != nil {
+		return nil, err
+	}
+	if err := config.ChainConfig.CheckMaxCodeSize(); err != nil {
+		return nil, err
+	}
+	if err := config.ChainConfig.CheckEVMLimits(); err != nil {
+		return nil, err
+	}
+	if err := config.ChainConfig.CheckGasLimitRamp(); err != nil {
+		return nil, err
+	}
 	core.WriteChainConfig(chainDb, genesis.Hash(), config.ChainConfig)
 
 	eth.chainConfig = config.ChainConfig
@@ -227,14 +309,91 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
+	eth.txPool.SetRequireProtected(config.RequireProtectedTx)
+	// Transactions leaving the pool because a block included them aren't
+	// reported as dropped
+	eth.txPool.SetTxLookup(func(hash common.Hash) bool {
+		tx, _, _, _ := core.GetTransaction(chainDb, hash)
+		return tx != nil
+	})
+	if !config.RaftMode {
+		voteFilter := core.DefaultVoteFilterConfig
+		voteFilter.RateLimit = config.VoteRateLimit
+		eth.txPool.SetVoteFilter(voteFilter, eth.blockchain.CurrentBlock)
+	}
+	eth.SetTxRateLimit(config.TxRateLimit)
+
+	// A snapshot saved before the restart is restored even if saving is now
+	// disabled, as its transactions would be lost otherwise
+	eth.txPoolSnapshot = config.TxPoolSnapshot
+	if _, _, err := eth.txPool.RestoreSnapshot(chainDb); err != nil {
+		glog.V(logger.Warn).Infof("Failed to restore the transaction pool snapshot: %v", err)
+	}
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.AssumeSynced, config.NetworkId, eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb, config.RaftMode); err != nil {
 		return nil, err
 	}
+	votingCode, err := genesisVotingCode(eth.blockchain, chainDb)
+	if err != nil {
+		return nil, err
+	}
+	eth.protocolManager.configCheck = config.ConfigCheck
+	if err := validateMinProtocolVersion(config.MinProtocolVersion); err != nil {
+		return nil, err
+	}
+	eth.protocolManager.versions = newVersionTracker(config.MinProtocolVersion)
+	eth.protocolManager.fingerprint = configFingerprint(eth.chainConfig, config.RaftMode, votingCode)
+	if config.SyncFrom != (common.Hash{}) {
+		if config.RaftMode {
+			return nil, errors.New("syncing from a trusted checkpoint isn't supported in raft mode")
+		}
+		eth.protocolManager.setCheckpoint(config.SyncFrom)
+	}
+
+	if private.P != nil {
+		eth.privateIndex = newPrivateIndex(chainDb, eth.eventMux, private.Parties)
+	}
+	if config.LogIndex {
+		eth.logIndex = filters.NewLogIndex(chainDb, eth.eventMux, config.LogIndexRetention)
+	}
+	if config.Watchdog.Timeout > 0 {
+		if err := config.Watchdog.Validate(); err != nil {
+			return nil, err
+		}
+		pm := eth.protocolManager
+		eth.watchdog = newWatchdog(config.Watchdog, eth.blockchain.CurrentBlock, pm.peersAhead, pm.dropPeers)
+	}
+	if config.FinalityHook.URL != "" {
+		hook, err := NewFinalityHook(config.FinalityHook)
+		if err != nil {
+			return nil, err
+		}
+		eth.finality = newFinalityNotifier(chainDb, eth.eventMux, hook, config.RaftMode)
+	}
+	if config.SlowTxThreshold > 0 {
+		eth.blockchain.SetSlowTxThreshold(config.SlowTxThreshold)
+		eth.slowTxs = newSlowTxSampler(eth.blockchain, eth.chainConfig, eth.eventMux)
+	}
+	if config.FlatState {
+		eth.blockchain.EnableFlatState()
+	}
+	if config.InclusionSLA.SLA > 0 {
+		if err := config.InclusionSLA.Validate(); err != nil {
+			return nil, err
+		}
+		eth.inclusion = newInclusionMonitor(config.InclusionSLA, eth.eventMux)
+	}
 
 	eth.apiBackend = &EthApiBackend{eth}
 
-	eth.blockVoting = quorum.NewBlockVoting(eth.blockchain, eth.chainConfig, eth.txPool, eth.eventMux, eth.chainDb, eth.accountManager)
+	eth.blockVoting = quorum.NewBlockVoting(eth.blockchain, eth.chainConfig, eth.txPool, eth.eventMux, eth.chainDb, eth.accountManager, config.PauseOnDoubleProduction, config.VoteBatchSize)
+
+	// Keep the usage index reported by account inspection up to date
+	eth.accountManager.OnUnlock(func(addr common.Address) {
+		if err := core.WriteAccountUnlock(chainDb, addr, time.Now()); err != nil {
+			glog.V(logger.Error).Infof("Failed to record unlock of %x: %v", addr, err)
+		}
+	})
 
 	return eth, nil
 }
@@ -289,7 +448,7 @@ func CreatePoW(config *Config) (*ethash.Ethash, error) {
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
-	return append(ethapi.GetAPIs(s.apiBackend, s.solcPath), []rpc.API{
+	apis := append(ethapi.GetAPIs(s.apiBackend, s.solcPath, s.unlockConfig), []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -334,6 +493,22 @@ func (s *Ethereum) APIs() []rpc.API {
 			Service:   quorum.NewPublicQuorumAPI(s.blockVoting),
 		},
 	}...)
+	if s.logIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   filters.NewPrivateLogIndexAPI(s.logIndex),
+		})
+	}
+	if s.privateIndex != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicPrivateContractAPI(s.privateIndex),
+			Public:    true,
+		})
+	}
+	return apis
 }
 
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
@@ -357,6 +532,53 @@ func (self *Ethereum) SetEtherbase(etherbase common.Address) {
 	self.etherbase = etherbase
 }
 
+// SetTxAdmission installs a check that locally submitted transactions must pass
+// before they are added to the transaction pool. Consensus engines use it to
+// push back on RPC clients while they are unable to keep up.
+func (s *Ethereum) SetTxAdmission(check func() error) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.txAdmission = check
+}
+
+// SetTxRateLimit limits the transactions admitted to the pool per sender and
+// submitted per RPC caller IP, lifting earlier throttling and bans.
+func (s *Ethereum) SetTxRateLimit(config core.TxRateLimitConfig) {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.txPool.SetRateLimit(config)
+	s.rpcTxLimit = core.NewTxRateLimiter("rpc/txratelimit", config.RPC, config.BanTime)
+}
+
+// SetTxRouter installs a hook that may send locally submitted transactions on to
+// another node, such as the raft leader, before they are added to the local
+// transaction pool. It returns whether the transaction was forwarded, or an
+// error to return to the RPC client instead. It has to be called before the
+// service is started.
+func (s *Ethereum) SetTxRouter(route func(ctx context.Context, tx *types.Transaction) (bool, error)) {
+	s.txRouter = route
+}
+
+// SetConsensusWatchdog installs the consensus specific parts of the stalled
+// chain watchdog: a check whether the consensus engine knows of blocks the
+// local chain is missing, and the stepdown recovery action. Either may be nil.
+// It has to be called before the service is started.
+func (s *Ethereum) SetConsensusWatchdog(behind func() bool, stepdown func() error) {
+	if s.watchdog != nil {
+		s.watchdog.behind = behind
+		s.watchdog.stepdown = stepdown
+	}
+}
+
+// SetSyncTarget installs the source of the highest block known to the consensus
+// engine, which the sync status reports on top of the downloader progress. It
+// has to be called before the service is started.
+func (s *Ethereum) SetSyncTarget(target func() uint64) {
+	s.syncTarget = target
+}
+
 func (s *Ethereum) AccountManager() *accounts.Manager  { return s.accountManager }
 func (s *Ethereum) BlockChain() *core.BlockChain       { return s.blockchain }
 func (s *Ethereum) TxPool() *core.TxPool               { return s.txPool }
@@ -368,6 +590,52 @@ func (s *Ethereum) EthVersion() int                    { return int(s.protocolMa
 func (s *Ethereum) NetVersion() int                    { return s.netVersionId }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
 
+// ReportNodeInfo implements node.NodeInfoReporter, reporting the chain, the
+// privacy manager and, unless raft is used, the QuorumChain role of the node.
+func (s *Ethereum) ReportNodeInfo(info *node.QuorumNodeInfo) {
+	info.Chain = &node.ChainInfo{
+		NetworkId: s.NetVersion(),
+		ChainId:   s.chainConfig.ChainId,
+		Genesis:   s.blockchain.Genesis().Hash(),
+		GasLimit:  s.blockchain.CurrentBlock().GasLimit(),
+	}
+	if pm := private.Info(); pm != nil {
+		info.PrivacyManager = pm
+	}
+	if !s.protocolManager.raftMode {
+		info.Consensus = s.blockVoting.NodeInfo()
+	}
+}
+
+// SetMaintenance implements node.MaintenanceHandler. In maintenance locally
+// submitted transactions are rejected and QuorumChain block creation and
+// voting stop, while the chain keeps syncing.
+func (s *Ethereum) SetMaintenance(enabled bool) {
+	s.txMu.Lock()
+	s.maintenance = enabled
+	s.txMu.Unlock()
+
+	s.blockVoting.SetMaintenance(enabled)
+}
+
+// ReportStatus implements node.StatusReporter, reporting the head block, the
+// transaction pool and, unless raft is used, the QuorumChain role of the node.
+func (s *Ethereum) ReportStatus(status *node.NodeStatus) {
+	head := s.blockchain.CurrentBlock()
+	status.Head = &node.HeadStatus{Number: head.NumberU64(), Hash: head.Hash(), Time: head.Time().Uint64()}
+
+	pending, queued := s.txPool.Stats()
+	privatePending, privateQueued := s.txPool.PrivateStats()
+	status.TxPool = &node.TxPoolStatus{Pending: pending, Queued: queued, Private: privatePending + privateQueued}
+
+	if !s.protocolManager.raftMode {
+		status.Consensus = s.blockVoting.NodeInfo()
+	}
+	if s.inclusion != nil {
+		status.Inclusion = s.inclusion.status()
+	}
+}
+
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -382,6 +650,27 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		s.StartAutoDAG()
 	}
 	s.protocolManager.Start()
+	if s.logIndex != nil {
+		s.logIndex.Start()
+	}
+	if s.privateIndex != nil {
+		s.privateIndex.start()
+	}
+	if s.watchdog != nil {
+		s.watchdog.start()
+	}
+	if s.finality != nil {
+		s.finality.start()
+	}
+	if s.slowTxs != nil {
+		s.slowTxs.start()
+	}
+	if s.inclusion != nil {
+		s.inclusion.start()
+	}
+	if s.callCache != nil {
+		s.callCache.Start(s.eventMux)
+	}
 	return nil
 }
 
@@ -391,10 +680,43 @@ func (s *Ethereum) Stop() error {
 	if s.stopDbUpgrade != nil {
 		s.stopDbUpgrade()
 	}
+	if s.watchdog != nil {
+		s.watchdog.stop()
+	}
+	if s.finality != nil {
+		s.finality.stop()
+	}
+	if s.slowTxs != nil {
+		s.slowTxs.stop()
+	}
+	if s.inclusion != nil {
+		s.inclusion.stop()
+	}
+	if s.callCache != nil {
+		s.callCache.Stop()
+	}
+	if s.logIndex != nil {
+		s.logIndex.Stop()
+	}
+	if s.privateIndex != nil {
+		s.privateIndex.stop()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
+	if s.txPoolSnapshot {
+		if saved, err := s.txPool.SaveSnapshot(s.chainDb); err != nil {
+			glog.V(logger.Error).Infof("Failed to save the transaction pool snapshot: %v", err)
+		} else {
+			glog.V(logger.Info).Infof("Saved %d transactions of the pool to restore on startup", saved)
+		}
+	}
 	s.txPool.Stop()
 	s.eventMux.Stop()
+	if closer, ok := s.blockSigner.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			glog.V(logger.Error).Infof("Failed to close the block signer: %v", err)
+		}
+	}
 
 	s.StopAutoDAG()
 
@@ -7,12 +7,13 @@ import (
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-func (s *Ethereum) StartBlockVoting(client *rpc.Client, voteKey, blockMakerKey *ecdsa.PrivateKey) error {
-	activateVoting, activateBlockCreation := voteKey != nil, blockMakerKey != nil
-	strat := quorum.NewRandomDeadelineStrategy(s.eventMux, s.minBlockTime, s.maxBlockTime, s.minVoteTime, s.maxVoteTime, activateVoting, activateBlockCreation)
+func (s *Ethereum) StartBlockVoting(client *rpc.Client, voteKey *ecdsa.PrivateKey, blockSigner quorum.BlockSigner) error {
+	activateVoting, activateBlockCreation := voteKey != nil, blockSigner != nil
+	strat := quorum.NewRandomDeadelineStrategy(s.eventMux, s.minBlockTime, s.maxBlockTime, s.minVoteTime, s.maxVoteTime, s.standbyWindows, blockSigner, activateVoting, activateBlockCreation)
 
 	s.blockMakerStrat = strat
+	s.blockSigner = blockSigner
 	quorum.Strategy = strat
 
-	return s.blockVoting.Start(client, s.blockMakerStrat, voteKey, blockMakerKey)
+	return s.blockVoting.Start(client, s.blockMakerStrat, voteKey, blockSigner)
 }
@@ -0,0 +1,177 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// errEmptyBundle is returned when simulating a bundle without transactions.
+var errEmptyBundle = errors.New("empty bundle")
+
+// BundleTxArgs is a transaction of a simulated bundle. Transactions with a
+// privateFor are private: their plain data is executed on the private state of
+// this node, like it would be on the nodes of the parties.
+type BundleTxArgs struct {
+	From       common.Address  `json:"from"`
+	To         *common.Address `json:"to"`
+	Gas        *rpc.HexNumber  `json:"gas"`
+	Value      *rpc.HexNumber  `json:"value"`
+	Data       rpc.HexBytes    `json:"data"`
+	PrivateFor []string        `json:"privateFor"`
+}
+
+// BundleTxResult is the outcome of a transaction of a simulated bundle.
+type BundleTxResult struct {
+	Status          *rpc.HexNumber        `json:"status"`
+	GasUsed         *rpc.HexNumber        `json:"gasUsed"`
+	ContractAddress *common.Address       `json:"contractAddress"`
+	ReturnValue     rpc.HexBytes          `json:"returnValue"`
+	Logs            vm.Logs               `json:"logs"`
+	Error           string                `json:"error,omitempty"`
+	StructLogs      []ethapi.StructLogRes `json:"structLogs,omitempty"`
+}
+
+// SimulateBundle executes the given transactions in order on top of the
+// current head, as if they were included in the next block, and returns their
+// results. The chain and the states are left untouched. If a log config is
+// given the executions are traced.
+//
+// Simulation stops at the first transaction which couldn't be included in a
+// block, its result carries the error.
+func (api *PrivateDebugAPI) SimulateBundle(ctx context.Context, txs []BundleTxArgs, logConfig *vm.LogConfig) ([]*BundleTxResult, error) {
+	return simulateBundle(ctx, api.config, api.eth.BlockChain(), txs, logConfig, api.eth.evmTimeouts.Timeout("debug_simulateBundle"))
+}
+
+func simulateBundle(ctx context.Context, config *core.ChainConfig, blockchain *core.BlockChain, txs []BundleTxArgs, logConfig *vm.LogConfig, timeout time.Duration) ([]*BundleTxResult, error) {
+	if len(txs) == 0 {
+		return nil, errEmptyBundle
+	}
+	parent := blockchain.CurrentBlock()
+	publicState, privateState, err := blockchain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   new(big.Int).Set(parent.GasLimit()),
+		Difficulty: new(big.Int).Set(parent.Difficulty()),
+		Time:       big.NewInt(time.Now().Unix()),
+	}
+	if header.Time.Cmp(parent.Time()) <= 0 {
+		header.Time = new(big.Int).Add(parent.Time(), common.Big1)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+
+	results := make([]*BundleTxResult, 0, len(txs))
+	for i, args := range txs {
+		msg := callmsg{
+			addr:     args.From,
+			to:       args.To,
+			gas:      new(big.Int).Set((*big.Int)(gp)), // remaining gas of the block by default
+			gasPrice: new(big.Int),
+			value:    new(big.Int),
+			data:     args.Data,
+		}
+		if args.Gas != nil {
+			msg.gas = args.Gas.BigInt()
+		}
+		if args.Value != nil {
+			msg.value = args.Value.BigInt()
+		}
+		txPrivateState := publicState
+		if len(args.PrivateFor) > 0 {
+			txPrivateState = privateState
+		}
+		// Transactions have no hash yet, number them to tell their logs apart
+		txHash := common.BigToHash(big.NewInt(int64(i + 1)))
+		publicState.StartRecord(txHash, common.Hash{}, i)
+		privateState.StartRecord(txHash, common.Hash{}, i)
+
+		result := new(BundleTxResult)
+		if args.To == nil {
+			addr := crypto.CreateAddress(args.From, publicState.GetNonce(args.From))
+			result.ContractAddress = &addr
+		}
+		var (
+			cfg          vm.Config
+			structLogger *vm.StructLogger
+		)
+		if logConfig != nil {
+			structLogger = vm.NewStructLogger(logConfig)
+			cfg = vm.Config{Debug: true, Tracer: structLogger}
+		}
+		vmenv := core.NewEnv(publicState, txPrivateState, config, blockchain, msg, header, cfg)
+		stop := ethapi.CancelOnTimeout(ctx, vmenv, 0)
+		res, err := core.ApplyMessage(vmenv, msg, gp)
+		cancelled := vmenv.Cancelled()
+		stop()
+		if cancelled {
+			return nil, ethapi.AbortError(ctx, timeout)
+		}
+		if err != nil {
+			result.ContractAddress = nil
+			result.Error = fmt.Sprintf("transaction %d: %v", i, err)
+			results = append(results, result)
+			break
+		}
+		publicState.DeleteSuicides()
+		privateState.DeleteSuicides()
+
+		result.Status = rpc.NewHexNumber(types.ReceiptStatusSuccessful)
+		if res.Failed() {
+			result.Status = rpc.NewHexNumber(types.ReceiptStatusFailed)
+			result.ContractAddress = nil
+			result.Error = res.Err.Error()
+		}
+		result.GasUsed = rpc.NewHexNumber(res.UsedGas)
+		result.ReturnValue = res.Return()
+		if res.Err == vm.ErrExecutionReverted {
+			result.ReturnValue = res.Revert()
+		}
+		result.Logs = txPrivateState.GetLogs(txHash)
+		if txPrivateState != publicState {
+			result.Logs = append(publicState.GetLogs(txHash), result.Logs...)
+		}
+		if result.Logs == nil {
+			result.Logs = vm.Logs{}
+		}
+		if structLogger != nil {
+			result.StructLogs = ethapi.FormatLogs(structLogger.StructLogs())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
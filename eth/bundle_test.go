@@ -0,0 +1,141 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// Creates a contract which increments storage slot 0 and emits an empty log on
+// every call.
+var bundleTestCode = common.Hex2Bytes("600f600c600039600f6000f3" + "60016000540160005560006000a000")
+
+func newBundleTestChain(t *testing.T) *core.BlockChain {
+	var (
+		db, _       = ethdb.NewMemDatabase()
+		genesis     = core.WriteGenesisBlockForTesting(db, testBank)
+		chainConfig = &core.ChainConfig{HomesteadBlock: big.NewInt(0)}
+	)
+	blockchain, err := core.NewBlockChain(db, chainConfig, new(core.FakePow), new(event.TypeMux), false)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	chain, _ := core.GenerateChain(nil, genesis, db, 1, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return blockchain
+}
+
+// Tests that a bundle is executed in order on top of the head without changing
+// the chain.
+func TestSimulateBundle(t *testing.T) {
+	blockchain := newBundleTestChain(t)
+	config := blockchain.Config()
+
+	state, _, _ := blockchain.State()
+	contract := crypto.CreateAddress(testBank.Address, state.GetNonce(testBank.Address))
+	txs := []BundleTxArgs{
+		{From: testBank.Address, Data: bundleTestCode},
+		{From: testBank.Address, To: &contract},
+		{From: testBank.Address, To: &contract},
+		{From: testBank.Address, To: &contract, Gas: rpc.NewHexNumber(21100)},
+	}
+	results, err := simulateBundle(context.Background(), config, blockchain, txs, &vm.LogConfig{}, 0)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if len(results) != len(txs) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(results), len(txs))
+	}
+	if results[0].ContractAddress == nil || *results[0].ContractAddress != contract {
+		t.Errorf("contract address mismatch: have %v, want %x", results[0].ContractAddress, contract)
+	}
+	for i, res := range results[:3] {
+		if uint(res.Status.BigInt().Uint64()) != types.ReceiptStatusSuccessful || res.Error != "" {
+			t.Errorf("tx %d: failed: %v", i, res.Error)
+		}
+		if len(res.StructLogs) == 0 {
+			t.Errorf("tx %d: no trace", i)
+		}
+	}
+	for i, res := range results[1:3] {
+		if len(res.Logs) != 1 || res.Logs[0].Address != contract {
+			t.Errorf("tx %d: logs mismatch: have %v", i+1, res.Logs)
+		}
+	}
+	if uint(results[3].Status.BigInt().Uint64()) != types.ReceiptStatusFailed || len(results[3].Logs) != 0 {
+		t.Errorf("out of gas transaction succeeded")
+	}
+	// The second call must see the storage written by the first one
+	if results[1].GasUsed.BigInt().Cmp(results[2].GasUsed.BigInt()) <= 0 {
+		t.Errorf("second call wasn't executed on the state of the first: gas used %v, %v", results[1].GasUsed.BigInt(), results[2].GasUsed.BigInt())
+	}
+	// The chain must be left untouched
+	if state, _, _ := blockchain.State(); len(state.GetCode(contract)) != 0 {
+		t.Errorf("simulated contract deployed")
+	}
+	if blockchain.CurrentBlock().NumberU64() != 1 {
+		t.Errorf("head changed")
+	}
+}
+
+// Tests that private transactions of a bundle run on the private state and
+// aren't visible to public ones.
+func TestSimulateBundlePrivate(t *testing.T) {
+	blockchain := newBundleTestChain(t)
+	config := blockchain.Config()
+
+	state, _, _ := blockchain.State()
+	contract := crypto.CreateAddress(testBank.Address, state.GetNonce(testBank.Address))
+	privateFor := []string{"ROAZBWtSacxXQrOe3FGAqJDyJjFePR5ce4TSIzmJ0Bc="}
+	txs := []BundleTxArgs{
+		{From: testBank.Address, Data: bundleTestCode, PrivateFor: privateFor},
+		{From: testBank.Address, To: &contract, PrivateFor: privateFor},
+		{From: testBank.Address, To: &contract},
+	}
+	results, err := simulateBundle(context.Background(), config, blockchain, txs, nil, 0)
+	if err != nil {
+		t.Fatalf("simulation failed: %v", err)
+	}
+	if len(results[1].Logs) != 1 {
+		t.Errorf("private call didn't reach the private contract")
+	}
+	if len(results[2].Logs) != 0 {
+		t.Errorf("public call reached the private contract")
+	}
+	if results[0].StructLogs != nil {
+		t.Errorf("traced without a log config")
+	}
+}
+
+func TestSimulateBundleEmpty(t *testing.T) {
+	if _, err := simulateBundle(context.Background(), nil, newBundleTestChain(t), nil, nil, 0); err != errEmptyBundle {
+		t.Errorf("error mismatch: have %v, want %v", err, errEmptyBundle)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ConfigCheck selects how peers whose chain config fingerprint differs from
+// ours are treated.
+type ConfigCheck int
+
+const (
+	// ConfigCheckOff neither sends nor checks fingerprints. Nodes without
+	// fingerprint support reject handshakes carrying one, so the check can only
+	// be enabled once every node of the network has been upgraded.
+	ConfigCheckOff ConfigCheck = iota
+
+	// ConfigCheckWarn logs and flags peers with a different fingerprint.
+	ConfigCheckWarn
+
+	// ConfigCheckStrict disconnects peers with a different fingerprint.
+	ConfigCheckStrict
+)
+
+// Fingerprint check results reported in the peer info.
+const (
+	configMatch    = "match"
+	configMismatch = "mismatch"
+	configUnknown  = "unknown" // the peer didn't send a fingerprint
+)
+
+// ParseConfigCheck parses "off", "warn" or "strict".
+func ParseConfigCheck(s string) (ConfigCheck, error) {
+	switch s {
+	case "", "off":
+		return ConfigCheckOff, nil
+	case "warn":
+		return ConfigCheckWarn, nil
+	case "strict":
+		return ConfigCheckStrict, nil
+	}
+	return ConfigCheckOff, fmt.Errorf("invalid config check mode %q, want off, warn or strict", s)
+}
+
+func (c ConfigCheck) String() string {
+	switch c {
+	case ConfigCheckWarn:
+		return "warn"
+	case ConfigCheckStrict:
+		return "strict"
+	}
+	return "off"
+}
+
+// configFingerprint hashes everything which decides how blocks are processed
+// and which isn't already covered by the genesis check: the chain config, the
+// consensus engine and the code of the voting contract.
+func configFingerprint(config *core.ChainConfig, raftMode bool, votingCode common.Hash) common.Hash {
+	blob, err := json.Marshal(config)
+	if err != nil {
+		panic(fmt.Sprintf("failed to encode chain config: %v", err))
+	}
+	engine := "quorumchain"
+	if raftMode {
+		engine = "raft"
+	}
+	return crypto.Keccak256Hash(blob, []byte(engine), votingCode.Bytes())
+}
+
+// genesisVotingCode returns the code hash of the voting contract in the
+// genesis state.
+func genesisVotingCode(bc *core.BlockChain, db ethdb.Database) (common.Hash, error) {
+	statedb, err := state.New(bc.Genesis().Root(), db)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetCodeHash(params.QuorumVotingContractAddr), nil
+}
+
+// checkConfig compares the chain config fingerprint the peer sent during the
+// handshake with ours, flagging or rejecting the peer on a mismatch.
+func (pm *ProtocolManager) checkConfig(p *peer) error {
+	if pm.configCheck == ConfigCheckOff {
+		return nil
+	}
+	switch {
+	case p.fingerprint == nil:
+		p.config = configUnknown
+	case *p.fingerprint == pm.fingerprint:
+		p.config = configMatch
+	default:
+		p.config = configMismatch
+		configMismatchMeter.Mark(1)
+		if pm.configCheck == ConfigCheckStrict {
+			return errResp(ErrConfigMismatch, "%x (!= %x)", *p.fingerprint, pm.fingerprint)
+		}
+		glog.V(logger.Warn).Infof("%v: chain config fingerprint %x differs from ours (%x), the peer may fork", p, *p.fingerprint, pm.fingerprint)
+	}
+	return nil
+}
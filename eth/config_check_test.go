@@ -0,0 +1,113 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+func TestConfigFingerprint(t *testing.T) {
+	config := &core.ChainConfig{HomesteadBlock: big.NewInt(0)}
+	base := configFingerprint(config, false, common.Hash{1})
+
+	if configFingerprint(&core.ChainConfig{HomesteadBlock: big.NewInt(0)}, false, common.Hash{1}) != base {
+		t.Error("fingerprint of equal configs differs")
+	}
+	if configFingerprint(&core.ChainConfig{HomesteadBlock: big.NewInt(1)}, false, common.Hash{1}) == base {
+		t.Error("fingerprint ignores the homestead block")
+	}
+	if configFingerprint(config, true, common.Hash{1}) == base {
+		t.Error("fingerprint ignores the consensus engine")
+	}
+	if configFingerprint(config, false, common.Hash{2}) == base {
+		t.Error("fingerprint ignores the voting contract")
+	}
+}
+
+// Tests that peers with a different chain config fingerprint are flagged or
+// dropped depending on the check mode.
+func TestConfigCheck62(t *testing.T) { testConfigCheck(t, 62) }
+func TestConfigCheck63(t *testing.T) { testConfigCheck(t, 63) }
+
+func testConfigCheck(t *testing.T, protocol int) {
+	tests := []struct {
+		check       ConfigCheck
+		fingerprint []common.Hash // nil = same as ours
+		want        string
+		drop        bool
+	}{
+		{ConfigCheckWarn, nil, configMatch, false},
+		{ConfigCheckWarn, []common.Hash{}, configUnknown, false},
+		{ConfigCheckWarn, []common.Hash{{1}}, configMismatch, false},
+		{ConfigCheckStrict, []common.Hash{}, configUnknown, false},
+		{ConfigCheckStrict, []common.Hash{{1}}, "", true},
+	}
+	for i, test := range tests {
+		pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
+		pm.configCheck = test.check
+		pm.fingerprint = common.Hash{0xff}
+
+		p, errc := newTestPeer("peer", protocol, pm, false)
+		td, head, genesis := pm.blockchain.Status()
+		status := &statusData{
+			ProtocolVersion: uint32(protocol),
+			NetworkId:       uint32(NetworkId),
+			TD:              td,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+			Fingerprint:     []common.Hash{pm.fingerprint},
+		}
+		if err := p2p.ExpectMsg(p.app, StatusMsg, status); err != nil {
+			t.Fatalf("test %d: status recv: %v", i, err)
+		}
+		if test.fingerprint != nil {
+			status.Fingerprint = test.fingerprint
+		}
+		if err := p2p.Send(p.app, StatusMsg, status); err != nil {
+			t.Fatalf("test %d: status send: %v", i, err)
+		}
+
+		if test.drop {
+			select {
+			case err := <-errc:
+				if want := errResp(ErrConfigMismatch, "%x (!= %x)", common.Hash{1}, pm.fingerprint); err == nil || err.Error() != want.Error() {
+					t.Errorf("test %d: wrong error: have %v, want %v", i, err, want)
+				}
+			case <-time.After(2 * time.Second):
+				t.Errorf("test %d: peer not dropped", i)
+			}
+		} else {
+			var registered *peer
+			for start := time.Now(); registered == nil && time.Since(start) < 2*time.Second; time.Sleep(10 * time.Millisecond) {
+				registered = pm.peers.Peer(p.id)
+			}
+			if registered == nil {
+				t.Errorf("test %d: peer not registered", i)
+			} else if config := registered.Info().Config; config != test.want {
+				t.Errorf("test %d: config check result mismatch: have %q, want %q", i, config, test.want)
+			}
+		}
+		p.close()
+		pm.Stop()
+	}
+}
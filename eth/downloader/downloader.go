@@ -107,6 +107,8 @@ type Downloader struct {
 	fsPivotLock  *types.Header // Pivot header on critical section entry (cannot change between retries)
 	fsPivotFails int           // Number of fast sync failures in the critical section
 
+	checkpoint common.Hash // Trusted block to fast sync to, only fully validating the chain after it
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -114,6 +116,9 @@ type Downloader struct {
 	syncStatsChainOrigin uint64       // Origin block number where syncing started at
 	syncStatsChainHeight uint64       // Highest block number known when syncing started
 	syncStatsStateDone   uint64       // Number of state trie entries already pulled
+	syncStatsStarted     time.Time    // Time when syncing from the origin block started
+	syncStatsBodies      uint64       // Number of block bodies pulled since syncing started (atomic access)
+	syncStatsReceipts    uint64       // Number of receipt lists pulled since syncing started (atomic access)
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	// Callbacks
@@ -238,6 +243,45 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	}
 }
 
+// updateSyncStats records the boundaries of a sync run. If the run doesn't
+// continue a previous one, the origin and the detailed statistics are reset.
+func (d *Downloader) updateSyncStats(origin, height uint64) {
+	d.syncStatsLock.Lock()
+	defer d.syncStatsLock.Unlock()
+
+	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
+		d.syncStatsChainOrigin = origin
+		d.syncStatsStarted = time.Now()
+		atomic.StoreUint64(&d.syncStatsBodies, 0)
+		atomic.StoreUint64(&d.syncStatsReceipts, 0)
+	}
+	d.syncStatsChainHeight = height
+}
+
+// SetCheckpoint sets a trusted block to fast sync to. Blocks up to the
+// checkpoint are imported without executing their transactions, only the state
+// of the checkpoint is downloaded and the blocks after it are fully validated.
+// The checkpoint is only used by fast syncs.
+func (d *Downloader) SetCheckpoint(hash common.Hash) {
+	d.checkpoint = hash
+}
+
+// lockCheckpoint retrieves the header of the trusted checkpoint from the peer
+// and locks it in as the pivot point of the fast sync.
+func (d *Downloader) lockCheckpoint(p *peer, height uint64) error {
+	header, err := d.fetchHeader(p, d.checkpoint)
+	if err != nil {
+		return err
+	}
+	if header.Hash() != d.checkpoint || header.Number.Uint64() > height {
+		glog.V(logger.Debug).Infof("%v: invalid checkpoint header #%v [%x…], want [%x…]", p, header.Number, header.Hash().Bytes()[:4], d.checkpoint.Bytes()[:4])
+		return errBadPeer
+	}
+	glog.V(logger.Info).Infof("Fast syncing to trusted checkpoint #%v [%x…]", header.Number, d.checkpoint.Bytes()[:4])
+	d.fsPivotLock = header
+	return nil
+}
+
 // Synchronising returns whether the downloader is currently retrieving blocks.
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
@@ -411,12 +455,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	if err != nil {
 		return err
 	}
-	d.syncStatsLock.Lock()
-	if d.syncStatsChainHeight <= origin || d.syncStatsChainOrigin > origin {
-		d.syncStatsChainOrigin = origin
-	}
-	d.syncStatsChainHeight = height
-	d.syncStatsLock.Unlock()
+	d.updateSyncStats(origin, height)
 
 	// Initiate the sync using a concurrent header and content retrieval algorithm
 	pivot := uint64(0)
@@ -424,6 +463,12 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 	case LightSync:
 		pivot = height
 	case FastSync:
+		// Pin the pivot point to the trusted checkpoint, if one was configured
+		if d.fsPivotLock == nil && d.checkpoint != (common.Hash{}) {
+			if err := d.lockCheckpoint(p, height); err != nil {
+				return err
+			}
+		}
 		// Calculate the new fast/slow sync pivot point
 		if d.fsPivotLock == nil {
 			pivotOffset, err := rand.Int(rand.Reader, big.NewInt(int64(fsPivotInterval)))
@@ -890,7 +935,10 @@ func (d *Downloader) fetchBodies(from uint64) error {
 		expire   = func() map[string]int { return d.queue.ExpireBodies(d.requestTTL()) }
 		fetch    = func(p *peer, req *fetchRequest) error { return p.FetchBodies(req) }
 		capacity = func(p *peer) int { return p.BlockCapacity(d.requestRTT()) }
-		setIdle  = func(p *peer, accepted int) { p.SetBodiesIdle(accepted) }
+		setIdle  = func(p *peer, accepted int) {
+			p.SetBodiesIdle(accepted)
+			atomic.AddUint64(&d.syncStatsBodies, uint64(accepted))
+		}
 	)
 	err := d.fetchParts(errCancelBodyFetch, d.bodyCh, deliver, d.bodyWakeCh, expire,
 		d.queue.PendingBlocks, d.queue.InFlightBlocks, d.queue.ShouldThrottleBlocks, d.queue.ReserveBodies,
@@ -914,7 +962,10 @@ func (d *Downloader) fetchReceipts(from uint64) error {
 		expire   = func() map[string]int { return d.queue.ExpireReceipts(d.requestTTL()) }
 		fetch    = func(p *peer, req *fetchRequest) error { return p.FetchReceipts(req) }
 		capacity = func(p *peer) int { return p.ReceiptCapacity(d.requestRTT()) }
-		setIdle  = func(p *peer, accepted int) { p.SetReceiptsIdle(accepted) }
+		setIdle  = func(p *peer, accepted int) {
+			p.SetReceiptsIdle(accepted)
+			atomic.AddUint64(&d.syncStatsReceipts, uint64(accepted))
+		}
 	)
 	err := d.fetchParts(errCancelReceiptFetch, d.receiptCh, deliver, d.receiptWakeCh, expire,
 		d.queue.PendingReceipts, d.queue.InFlightReceipts, d.queue.ShouldThrottleReceipts, d.queue.ReserveReceipts,
@@ -1541,12 +1592,7 @@ func (d *Downloader) syncWithPeerUntil(p *peer, hash common.Hash, td *big.Int) (
 	remoteHeight := remoteHeader.Number.Uint64()
 	localHeight := d.headBlock().NumberU64()
 
-	d.syncStatsLock.Lock()
-	if d.syncStatsChainHeight <= localHeight || d.syncStatsChainOrigin > localHeight {
-		d.syncStatsChainOrigin = localHeight
-	}
-	d.syncStatsChainHeight = remoteHeight
-	d.syncStatsLock.Unlock()
+	d.updateSyncStats(localHeight, remoteHeight)
 
 	d.queue.Prepare(localHeight+1, d.mode, uint64(0), remoteHeader)
 	if d.syncInitHook != nil {
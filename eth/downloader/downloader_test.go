@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -1271,6 +1272,126 @@ func testInvalidHeaderRollback(t *testing.T, protocol int, mode SyncMode) {
 	}
 }
 
+// Tests that fast syncs pivot on a trusted checkpoint, retrieving the receipts
+// up to it and fully importing the blocks after it, and that peers without the
+// checkpoint are rejected.
+func TestCheckpointSync63(t *testing.T) { testCheckpointSync(t, 63) }
+func TestCheckpointSync64(t *testing.T) { testCheckpointSync(t, 64) }
+
+func testCheckpointSync(t *testing.T, protocol int) {
+	t.Parallel()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil, false)
+	checkpoint := uint64(targetBlocks / 2)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.downloader.SetCheckpoint(hashes[targetBlocks-int(checkpoint)])
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if pivot := tester.downloader.queue.FastSyncPivot(); pivot != checkpoint {
+		t.Errorf("pivot mismatch: have %d, want %d", pivot, checkpoint)
+	}
+	if hs := len(tester.ownHeaders); hs != targetBlocks+1 {
+		t.Errorf("synchronised headers mismatch: have %v, want %v", hs, targetBlocks+1)
+	}
+	if bs := len(tester.ownBlocks); bs != targetBlocks+1 {
+		t.Errorf("synchronised blocks mismatch: have %v, want %v", bs, targetBlocks+1)
+	}
+	if rs := len(tester.ownReceipts); rs != int(checkpoint)+1 {
+		t.Errorf("synchronised receipts mismatch: have %v, want %v", rs, checkpoint+1)
+	}
+
+	// Sync against a peer which doesn't have the checkpoint
+	tester = newTester()
+	defer tester.terminate()
+
+	tester.downloader.SetCheckpoint(common.Hash{0xff})
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, FastSync); err != errBadPeer {
+		t.Errorf("sync error mismatch: have %v, want %v", err, errBadPeer)
+	}
+}
+
+// Tests that a chain whose blocks are signed by their block makers fast syncs to
+// a trusted checkpoint with the quorum checks enabled, although the headers up
+// to the checkpoint are imported without the state of their parents.
+func TestCheckpointSyncQuorumChecks63(t *testing.T) { testCheckpointSyncQuorumChecks(t, 63) }
+func TestCheckpointSyncQuorumChecks64(t *testing.T) { testCheckpointSyncQuorumChecks(t, 64) }
+
+func testCheckpointSyncQuorumChecks(t *testing.T, protocol int) {
+	t.Parallel()
+
+	// Stand-in for the voting contract at 0x20, returning the hash of the parent
+	// block for getCanonHash(uint256) and true for isBlockMaker(address).
+	code := common.FromHex("60003560001a605514601657600160005260206000f35b600143034060005260206000f3")
+	voting := common.HexToAddress("0x0000000000000000000000000000000000000020")
+
+	localdb, _ := ethdb.NewMemDatabase()
+	qgenesis, err := core.WriteGenesisBlock(localdb, strings.NewReader(fmt.Sprintf(`{
+	"nonce": "0x0000000000000042",
+	"gasLimit": "0x%x",
+	"difficulty": "0x%x",
+	"alloc": {"%x": {"balance": "0", "code": "%x"}}
+}`, params.GenesisGasLimit, params.GenesisDifficulty, voting, code)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Peers serve their state from testdb
+	statedb, _ := state.New(common.Hash{}, testdb)
+	statedb.SetCode(voting, code)
+	if root, err := statedb.Commit(); err != nil || root != qgenesis.Root() {
+		t.Fatalf("genesis state mismatch: have %x (%v), want %x", root, err, qgenesis.Root())
+	}
+
+	// Generate the chain and sign every block by its maker
+	makerKey, _ := crypto.GenerateKey()
+	targetBlocks := 64
+	generated, generatedReceipts := core.GenerateChain(nil, qgenesis, testdb, targetBlocks, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(crypto.PubkeyToAddress(makerKey.PublicKey))
+	})
+	hashes := []common.Hash{qgenesis.Hash()}
+	headers := map[common.Hash]*types.Header{qgenesis.Hash(): qgenesis.Header()}
+	blocks := map[common.Hash]*types.Block{qgenesis.Hash(): qgenesis}
+	receipts := map[common.Hash]types.Receipts{qgenesis.Hash(): nil}
+	for i, block := range generated {
+		header := block.Header()
+		header.ParentHash = hashes[0]
+		if header.Extra, err = crypto.Sign(header.QuorumHash().Bytes(), makerKey); err != nil {
+			t.Fatal(err)
+		}
+		signed := types.NewBlockWithHeader(header).WithBody(block.Transactions(), block.Uncles())
+		hashes = append([]common.Hash{signed.Hash()}, hashes...)
+		headers[signed.Hash()], blocks[signed.Hash()], receipts[signed.Hash()] = signed.Header(), signed, generatedReceipts[i]
+	}
+	checkpoint := uint64(targetBlocks / 2)
+
+	chain, err := core.NewBlockChain(localdb, &core.ChainConfig{HomesteadBlock: big.NewInt(0)}, new(core.FakePow), new(event.TypeMux), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tester := newTester()
+	defer tester.terminate()
+
+	tester.downloader.Terminate()
+	tester.downloader = New(localdb, new(event.TypeMux), chain.HasHeader, chain.HasBlockAndState, chain.GetHeaderByHash,
+		chain.GetBlockByHash, chain.CurrentHeader, chain.CurrentBlock, chain.CurrentFastBlock, chain.FastSyncCommitHead,
+		chain.GetTdByHash, chain.InsertHeaderChain, chain.InsertChain, chain.InsertReceiptChain, chain.Rollback, tester.dropPeer)
+
+	tester.downloader.SetCheckpoint(hashes[targetBlocks-int(checkpoint)])
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, FastSync); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != hashes[0] {
+		t.Errorf("head block mismatch: have #%d [%x…], want #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4], targetBlocks, hashes[0].Bytes()[:4])
+	}
+}
+
 // Tests that a peer advertising an high TD doesn't get to stall the downloader
 // afterwards by not sending any useful hashes.
 func TestHighTDStarvationAttack62(t *testing.T)      { testHighTDStarvationAttack(t, 62, FullSync) }
@@ -1421,6 +1542,58 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	}
 }
 
+// Tests that the detailed sync progress reports the retrieval of every kind of
+// chain data and the contribution of the peers.
+func TestSyncDetails62(t *testing.T)      { testSyncDetails(t, 62, FullSync) }
+func TestSyncDetails63Full(t *testing.T)  { testSyncDetails(t, 63, FullSync) }
+func TestSyncDetails63Fast(t *testing.T)  { testSyncDetails(t, 63, FastSync) }
+func TestSyncDetails64Full(t *testing.T)  { testSyncDetails(t, 64, FullSync) }
+func TestSyncDetails64Fast(t *testing.T)  { testSyncDetails(t, 64, FastSync) }
+func TestSyncDetails64Light(t *testing.T) { testSyncDetails(t, 64, LightSync) }
+
+func testSyncDetails(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	targetBlocks := blockCacheLimit - 15
+	hashes, headers, blocks, receipts := makeChain(targetBlocks, 0, genesis, nil, false)
+
+	tester := newTester()
+	defer tester.terminate()
+
+	// Retrieve the details and ensure they are empty (pristine sync)
+	if details := tester.downloader.Details(); details.ETA != nil || details.Elapsed != 0 || len(details.Peers) != 0 {
+		t.Fatalf("Pristine details mismatch: have %+v", details)
+	}
+	tester.newPeer("peer", protocol, hashes, headers, blocks, receipts)
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	details := tester.downloader.Details()
+	if details.Mode != mode.String() {
+		t.Errorf("mode mismatch: have %s, want %s", details.Mode, mode)
+	}
+	if details.Headers.Done != uint64(targetBlocks) || details.Headers.Pending != 0 {
+		t.Errorf("header progress mismatch: have %d/%d, want %d/%d", details.Headers.Done, details.Headers.Pending, targetBlocks, 0)
+	}
+	if details.ETA == nil || *details.ETA != 0 {
+		t.Errorf("ETA mismatch: have %v, want 0", details.ETA)
+	}
+	if len(details.Peers) != 1 || details.Peers[0].Id != "peer" {
+		t.Fatalf("peer contributions mismatch: have %+v", details.Peers)
+	}
+	contribution := details.Peers[0]
+	if contribution.Bodies != details.Bodies.Done || contribution.Receipts != details.Receipts.Done {
+		t.Errorf("peer contribution mismatch: have %d bodies, %d receipts, want %d, %d", contribution.Bodies, contribution.Receipts, details.Bodies.Done, details.Receipts.Done)
+	}
+	if mode == LightSync {
+		if details.Bodies.Done != 0 {
+			t.Errorf("bodies retrieved in light sync: %d", details.Bodies.Done)
+		}
+	} else if details.Bodies.Done == 0 {
+		t.Errorf("no bodies retrieved")
+	}
+}
+
 // Tests that synchronisation progress (origin block number and highest block
 // number) is tracked and updated correctly in case of a fork (or manual head
 // revertal).
@@ -26,3 +26,18 @@ const (
 	// Used by raft:
 	BoundedFullSync SyncMode = 100 // Perform a full sync until the requested hash, and no further
 )
+
+func (mode SyncMode) String() string {
+	switch mode {
+	case FullSync:
+		return "full"
+	case FastSync:
+		return "fast"
+	case LightSync:
+		return "light"
+	case BoundedFullSync:
+		return "bounded"
+	default:
+		return "unknown"
+	}
+}
@@ -68,6 +68,11 @@ type peer struct {
 	receiptThroughput float64 // Number of receipts measured to be retrievable per second
 	stateThroughput   float64 // Number of node data pieces measured to be retrievable per second
 
+	headerDelivered  uint64 // Number of headers delivered since the peer was registered
+	blockDelivered   uint64 // Number of blocks (bodies) delivered since the peer was registered
+	receiptDelivered uint64 // Number of receipts delivered since the peer was registered
+	stateDelivered   uint64 // Number of node data pieces delivered since the peer was registered
+
 	rtt time.Duration // Request round trip time to track responsiveness (QoS)
 
 	headerStarted  time.Time // Time instance when the last header fetch was started
@@ -217,46 +222,48 @@ func (p *peer) FetchNodeData(request *fetchRequest) error {
 // requests. Its estimated header retrieval throughput is updated with that measured
 // just now.
 func (p *peer) SetHeadersIdle(delivered int) {
-	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerIdle)
+	p.setIdle(p.headerStarted, delivered, &p.headerThroughput, &p.headerIdle, &p.headerDelivered)
 }
 
 // SetBlocksIdle sets the peer to idle, allowing it to execute new block retrieval
 // requests. Its estimated block retrieval throughput is updated with that measured
 // just now.
 func (p *peer) SetBlocksIdle(delivered int) {
-	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle)
+	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle, &p.blockDelivered)
 }
 
 // SetBodiesIdle sets the peer to idle, allowing it to execute block body retrieval
 // requests. Its estimated body retrieval throughput is updated with that measured
 // just now.
 func (p *peer) SetBodiesIdle(delivered int) {
-	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle)
+	p.setIdle(p.blockStarted, delivered, &p.blockThroughput, &p.blockIdle, &p.blockDelivered)
 }
 
 // SetReceiptsIdle sets the peer to idle, allowing it to execute new receipt
 // retrieval requests. Its estimated receipt retrieval throughput is updated
 // with that measured just now.
 func (p *peer) SetReceiptsIdle(delivered int) {
-	p.setIdle(p.receiptStarted, delivered, &p.receiptThroughput, &p.receiptIdle)
+	p.setIdle(p.receiptStarted, delivered, &p.receiptThroughput, &p.receiptIdle, &p.receiptDelivered)
 }
 
 // SetNodeDataIdle sets the peer to idle, allowing it to execute new state trie
 // data retrieval requests. Its estimated state retrieval throughput is updated
 // with that measured just now.
 func (p *peer) SetNodeDataIdle(delivered int) {
-	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateIdle)
+	p.setIdle(p.stateStarted, delivered, &p.stateThroughput, &p.stateIdle, &p.stateDelivered)
 }
 
 // setIdle sets the peer to idle, allowing it to execute new retrieval requests.
 // Its estimated retrieval throughput is updated with that measured just now.
-func (p *peer) setIdle(started time.Time, delivered int, throughput *float64, idle *int32) {
+func (p *peer) setIdle(started time.Time, delivered int, throughput *float64, idle *int32, total *uint64) {
 	// Irrelevant of the scaling, make sure the peer ends up idle
 	defer atomic.StoreInt32(idle, 0)
 
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	*total += uint64(delivered)
+
 	// If nothing was delivered (hard timeout / unavailable data), reduce throughput to minimum
 	if delivered == 0 {
 		*throughput = 0
@@ -0,0 +1,145 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package downloader
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// SyncStage is the progress of retrieving one kind of chain data.
+type SyncStage struct {
+	Done    uint64 `json:"done"`    // Items retrieved since syncing started
+	Pending uint64 `json:"pending"` // Items scheduled for retrieval
+}
+
+// PeerContribution is the share of a peer in the synchronisation.
+type PeerContribution struct {
+	Id                string  `json:"id"`
+	Headers           uint64  `json:"headers"` // Items delivered since the peer connected
+	Bodies            uint64  `json:"bodies"`
+	Receipts          uint64  `json:"receipts"`
+	States            uint64  `json:"states"`
+	HeaderThroughput  float64 `json:"headerThroughput"` // Estimated items per second
+	BodyThroughput    float64 `json:"bodyThroughput"`
+	ReceiptThroughput float64 `json:"receiptThroughput"`
+	StateThroughput   float64 `json:"stateThroughput"`
+	RTT               float64 `json:"rttMs"`
+}
+
+// SyncDetails is a detailed report of the synchronisation progress.
+type SyncDetails struct {
+	Mode          string             `json:"mode"`
+	StartingBlock uint64             `json:"startingBlock"`
+	CurrentBlock  uint64             `json:"currentBlock"`
+	HighestBlock  uint64             `json:"highestBlock"`
+	Elapsed       float64            `json:"elapsed"` // Seconds since syncing started
+	ETA           *float64           `json:"eta"`     // Estimated seconds until the highest block is reached, nil if unknown
+	Headers       SyncStage          `json:"headers"`
+	Bodies        SyncStage          `json:"bodies"`
+	Receipts      SyncStage          `json:"receipts"`
+	States        SyncStage          `json:"states"`
+	Peers         []PeerContribution `json:"peers"`
+}
+
+// Details retrieves a detailed report of the synchronisation progress: the
+// retrieval progress of every kind of chain data, the contribution of every
+// peer and an estimate of the remaining time, extrapolated from the block rate
+// since syncing started.
+func (d *Downloader) Details() *SyncDetails {
+	var (
+		progress = d.Progress()
+		header   = d.headHeader().Number.Uint64()
+		details  = &SyncDetails{
+			Mode:          d.mode.String(),
+			StartingBlock: progress.StartingBlock,
+			CurrentBlock:  progress.CurrentBlock,
+			HighestBlock:  progress.HighestBlock,
+			Bodies: SyncStage{
+				Done:    atomic.LoadUint64(&d.syncStatsBodies),
+				Pending: uint64(d.queue.PendingBlocks()),
+			},
+			Receipts: SyncStage{
+				Done:    atomic.LoadUint64(&d.syncStatsReceipts),
+				Pending: uint64(d.queue.PendingReceipts()),
+			},
+			States: SyncStage{
+				Done:    progress.PulledStates,
+				Pending: progress.KnownStates - progress.PulledStates,
+			},
+			Peers: []PeerContribution{},
+		}
+	)
+	if header > progress.StartingBlock {
+		details.Headers.Done = header - progress.StartingBlock
+	}
+	if progress.HighestBlock > header {
+		details.Headers.Pending = progress.HighestBlock - header
+	}
+	d.syncStatsLock.RLock()
+	started := d.syncStatsStarted
+	d.syncStatsLock.RUnlock()
+
+	if !started.IsZero() {
+		elapsed := time.Since(started).Seconds()
+		details.Elapsed = elapsed
+		if progress.CurrentBlock > progress.StartingBlock && progress.HighestBlock >= progress.CurrentBlock {
+			rate := float64(progress.CurrentBlock-progress.StartingBlock) / elapsed
+			eta := float64(progress.HighestBlock-progress.CurrentBlock) / rate
+			details.ETA = &eta
+		}
+	}
+	for _, p := range d.peers.AllPeers() {
+		details.Peers = append(details.Peers, p.contribution())
+	}
+	sort.Sort(contributionsByBlocks(details.Peers))
+
+	return details
+}
+
+// contribution returns the share of the peer in the synchronisation.
+func (p *peer) contribution() PeerContribution {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return PeerContribution{
+		Id:                p.id,
+		Headers:           p.headerDelivered,
+		Bodies:            p.blockDelivered,
+		Receipts:          p.receiptDelivered,
+		States:            p.stateDelivered,
+		HeaderThroughput:  p.headerThroughput,
+		BodyThroughput:    p.blockThroughput,
+		ReceiptThroughput: p.receiptThroughput,
+		StateThroughput:   p.stateThroughput,
+		RTT:               float64(p.rtt) / float64(time.Millisecond),
+	}
+}
+
+// contributionsByBlocks orders peers by the number of bodies delivered, the
+// largest contributors first.
+type contributionsByBlocks []PeerContribution
+
+func (c contributionsByBlocks) Len() int      { return len(c) }
+func (c contributionsByBlocks) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c contributionsByBlocks) Less(i, j int) bool {
+	if c[i].Bodies != c[j].Bodies {
+		return c[i].Bodies > c[j].Bodies
+	}
+	return c[i].Id < c[j].Id
+}
@@ -28,6 +28,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -163,6 +164,60 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// DroppedTransaction is the notification of a transaction which left the
+// transaction pool without being included in a block.
+type DroppedTransaction struct {
+	Hash       common.Hash    `json:"hash"`
+	From       common.Address `json:"from"`
+	Nonce      *rpc.HexNumber `json:"nonce"`
+	Reason     string         `json:"reason"`
+	ReplacedBy *common.Hash   `json:"replacedBy,omitempty"`
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction leaves the transaction pool without being included in a block:
+// it was replaced, outbid, queued for too long, evicted from the full pool or
+// invalidated by a new chain head.
+func (api *PublicFilterAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		drops := make(chan core.TxDroppedEvent)
+		droppedTxSub := api.events.SubscribeDroppedTxEvents(drops)
+
+		for {
+			select {
+			case ev := <-drops:
+				from, _ := ev.Tx.From()
+				dropped := &DroppedTransaction{
+					Hash:   ev.Tx.Hash(),
+					From:   from,
+					Nonce:  rpc.NewHexNumber(ev.Tx.Nonce()),
+					Reason: ev.Reason,
+				}
+				if ev.Replacement != nil {
+					hash := ev.Replacement.Hash()
+					dropped.ReplacedBy = &hash
+				}
+				notifier.Notify(rpcSub.ID, dropped)
+			case <-rpcSub.Err():
+				droppedTxSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				droppedTxSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 //
@@ -536,3 +591,32 @@ func (args *FilterCriteria) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// PrivateLogIndexAPI offers the management of the log index, which keeps the logs
+// of registered contracts indefinitely.
+type PrivateLogIndexAPI struct {
+	index *LogIndex
+}
+
+// NewPrivateLogIndexAPI returns a new PrivateLogIndexAPI instance.
+func NewPrivateLogIndexAPI(index *LogIndex) *PrivateLogIndexAPI {
+	return &PrivateLogIndexAPI{index: index}
+}
+
+// RegisterLogContract keeps the logs of the given contract in the log index
+// indefinitely. It returns false if the contract was already registered.
+func (api *PrivateLogIndexAPI) RegisterLogContract(addr common.Address) (bool, error) {
+	return api.index.Register(addr)
+}
+
+// UnregisterLogContract stops keeping the logs of the given contract beyond the
+// retention window. It returns false if the contract wasn't registered.
+func (api *PrivateLogIndexAPI) UnregisterLogContract(addr common.Address) (bool, error) {
+	return api.index.Unregister(addr)
+}
+
+// LogIndex returns the range of blocks covered by the log index and the
+// registered contracts.
+func (api *PrivateLogIndexAPI) LogIndex() *LogIndexStatus {
+	return api.index.Status()
+}
@@ -89,6 +89,10 @@ func (f *Filter) Find() []Log {
 	if len(f.addresses) == 0 {
 		return f.getLogs(beginBlockNo, endBlockNo)
 	}
+	// use the log index if it covers the range for all addresses
+	if logs, ok := indexedLogs(f.db, f.addresses, beginBlockNo, endBlockNo); ok {
+		return filterLogs(logs, nil, f.topics)
+	}
 	return f.mipFind(beginBlockNo, endBlockNo, 0)
 }
 
@@ -49,6 +49,9 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// DroppedTransactionsSubscription queries transactions leaving the
+	// transaction pool without being included
+	DroppedTransactionsSubscription
 )
 
 var (
@@ -86,6 +89,7 @@ type subscription struct {
 	logs      chan []Log
 	hashes    chan common.Hash
 	headers   chan *types.Header
+	drops     chan core.TxDroppedEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -145,6 +149,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.drops:
 			}
 		}
 
@@ -232,6 +237,24 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 	return es.subscribe(sub)
 }
 
+// SubscribeDroppedTxEvents creates a subscription that writes the transactions
+// dropped from the transaction pool without being included, with the reason.
+func (es *EventSystem) SubscribeDroppedTxEvents(drops chan core.TxDroppedEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       DroppedTransactionsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []Log),
+		hashes:    make(chan common.Hash),
+		headers:   make(chan *types.Header),
+		drops:     drops,
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+
+	return es.subscribe(sub)
+}
+
 type filterIndex map[Type]map[rpc.ID]*subscription
 
 // broadcast event to filters that match criteria.
@@ -273,6 +296,12 @@ func broadcast(filters filterIndex, ev *event.Event) {
 				f.hashes <- e.Tx.Hash()
 			}
 		}
+	case core.TxDroppedEvent:
+		for _, f := range filters[DroppedTransactionsSubscription] {
+			if ev.Time.After(f.created) {
+				f.drops <- e
+			}
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			if ev.Time.After(f.created) {
@@ -286,7 +315,7 @@ func broadcast(filters filterIndex, ev *event.Event) {
 func (es *EventSystem) eventLoop() {
 	var (
 		index = make(filterIndex)
-		sub   = es.mux.Subscribe(core.PendingLogsEvent{}, core.RemovedLogsEvent{}, vm.Logs{}, core.TxPreEvent{}, core.ChainEvent{})
+		sub   = es.mux.Subscribe(core.PendingLogsEvent{}, core.RemovedLogsEvent{}, vm.Logs{}, core.TxPreEvent{}, core.TxDroppedEvent{}, core.ChainEvent{})
 	)
 	for {
 		select {
@@ -90,6 +90,40 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestDroppedTxSubscription tests if a dropped transactions subscription returns the dropped transactions posted to the event mux.
+func TestDroppedTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		to    = common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268")
+		drops = []core.TxDroppedEvent{
+			{Tx: types.NewTransaction(0, to, new(big.Int), new(big.Int), new(big.Int), nil), Reason: core.TxDropUnderpriced},
+			{Tx: types.NewTransaction(1, to, new(big.Int), new(big.Int), new(big.Int), nil), Reason: core.TxDropTimeout},
+			{Tx: types.NewTransaction(2, to, new(big.Int), new(big.Int), new(big.Int), nil), Reason: core.TxDropInvalidated},
+		}
+	)
+
+	dropped := make(chan core.TxDroppedEvent)
+	sub := api.events.SubscribeDroppedTxEvents(dropped)
+
+	go func() { // simulate client
+		for i := range drops {
+			ev := <-dropped
+			if ev.Tx.Hash() != drops[i].Tx.Hash() || ev.Reason != drops[i].Reason {
+				t.Errorf("received invalid drop on index %d, want %x (%s), got %x (%s)", i, drops[i].Tx.Hash(), drops[i].Reason, ev.Tx.Hash(), ev.Reason)
+			}
+		}
+		sub.Unsubscribe()
+	}()
+
+	time.Sleep(1 * time.Second)
+	for _, e := range drops {
+		mux.Post(e)
+	}
+
+	<-sub.Err()
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -0,0 +1,621 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The log index stores the logs of every contract per block, so the logs of a
+// contract can be looked up without scanning the blocks of a range:
+//
+//	logIndexStateKey                      -> logIndexState
+//	logIndexContractsKey                  -> []registeredContract
+//	logIndexBlockPrefix + num             -> indexedBlock, the contracts with logs in a block
+//	logIndexChunkPrefix + address + chunk -> []uint64, the blocks of a chunk with logs of a contract
+//	logIndexLogsPrefix + address + num    -> []*vm.LogForStorage, the logs of a contract in a block
+var (
+	logIndexStateKey     = []byte("logindex-state")
+	logIndexContractsKey = []byte("logindex-contracts")
+	logIndexBlockPrefix  = []byte("logindex-b")
+	logIndexChunkPrefix  = []byte("logindex-c")
+	logIndexLogsPrefix   = []byte("logindex-l")
+)
+
+// logIndexChunkSize is the number of blocks covered by a chunk of the list of
+// blocks with logs of a contract.
+const logIndexChunkSize = 4096
+
+// logIndexState is the range of blocks covered by the log index.
+type logIndexState struct {
+	Start  uint64 // First indexed block
+	Next   uint64 // First block not indexed yet
+	Pruned uint64 // Logs of unregistered contracts are only kept from this block on
+}
+
+// registeredContract is a contract whose logs are kept indefinitely.
+type registeredContract struct {
+	Address common.Address
+	Since   uint64 // First block the logs of the contract are kept from
+}
+
+// indexedBlock is the record of an indexed block.
+type indexedBlock struct {
+	Hash      common.Hash
+	Contracts []common.Address
+}
+
+// LogIndex maintains an index of the logs of every contract, to speed up log
+// queries filtering by address. The logs of registered contracts are kept
+// indefinitely, those of other contracts only for the most recent blocks.
+type LogIndex struct {
+	db        ethdb.Database
+	mux       *event.TypeMux
+	retention uint64 // Number of blocks the logs of unregistered contracts are kept for
+
+	lock      sync.Mutex
+	state     logIndexState
+	contracts map[common.Address]uint64 // Registered contracts and the first block their logs are kept from
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewLogIndex creates a log index keeping the logs of unregistered contracts
+// for the given number of blocks. A new index starts with the blocks within
+// the retention window of the current head.
+func NewLogIndex(db ethdb.Database, mux *event.TypeMux, retention uint64) *LogIndex {
+	idx := &LogIndex{
+		db:        db,
+		mux:       mux,
+		retention: retention,
+		contracts: make(map[common.Address]uint64),
+		wake:      make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+	}
+	if state := readLogIndexState(db); state != nil {
+		idx.state = *state
+	} else {
+		head := core.GetBlockNumber(db, core.GetHeadBlockHash(db))
+		if head != ^uint64(0) && head+1 > retention {
+			idx.state = logIndexState{Start: head + 1 - retention}
+		}
+		idx.state.Next, idx.state.Pruned = idx.state.Start, idx.state.Start
+		writeLogIndexState(db, &idx.state)
+	}
+	for _, contract := range readRegisteredContracts(db) {
+		idx.contracts[contract.Address] = contract.Since
+	}
+	return idx
+}
+
+// Start indexes the blocks imported since the index was last updated and keeps
+// the index up to date with the chain in the background.
+func (idx *LogIndex) Start() {
+	sub := idx.mux.Subscribe(core.ChainHeadEvent{})
+
+	// Relay the head events without blocking the event mux while indexing
+	idx.wg.Add(2)
+	go func() {
+		defer idx.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case _, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				select {
+				case idx.wake <- struct{}{}:
+				default:
+				}
+			case <-idx.quit:
+				return
+			}
+		}
+	}()
+	go idx.loop()
+}
+
+// Stop terminates the background indexing.
+func (idx *LogIndex) Stop() {
+	close(idx.quit)
+	idx.wg.Wait()
+}
+
+func (idx *LogIndex) loop() {
+	defer idx.wg.Done()
+
+	idx.update()
+	for {
+		select {
+		case <-idx.wake:
+			idx.update()
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// update brings the index up to date with the canonical chain: blocks which
+// were reorganised away are removed, new blocks are indexed and the logs of
+// unregistered contracts which left the retention window are pruned.
+func (idx *LogIndex) update() {
+	idx.lock.Lock()
+	for idx.state.Next > idx.state.Start {
+		number := idx.state.Next - 1
+		if block := readIndexedBlock(idx.db, number); block != nil && block.Hash == core.GetCanonicalHash(idx.db, number) {
+			break
+		}
+		idx.unindexBlock(number)
+	}
+	idx.lock.Unlock()
+
+	head := core.GetBlockNumber(idx.db, core.GetHeadBlockHash(idx.db))
+	if head == ^uint64(0) {
+		return
+	}
+	for {
+		select {
+		case <-idx.quit:
+			return
+		default:
+		}
+		idx.lock.Lock()
+		if idx.state.Next > head {
+			idx.lock.Unlock()
+			break
+		}
+		err := idx.indexBlock(idx.state.Next)
+		idx.lock.Unlock()
+		if err != nil {
+			glog.V(logger.Error).Infof("Failed to index logs: %v", err)
+			return
+		}
+	}
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if head+1 > idx.retention {
+		idx.prune(head + 1 - idx.retention)
+	}
+}
+
+// indexBlock adds the logs of a canonical block to the index.
+func (idx *LogIndex) indexBlock(number uint64) error {
+	hash := core.GetCanonicalHash(idx.db, number)
+	if hash == (common.Hash{}) {
+		return fmt.Errorf("block %d not found", number)
+	}
+	var (
+		block = indexedBlock{Hash: hash}
+		logs  = make(map[common.Address][]*vm.LogForStorage)
+	)
+	for _, receipt := range core.GetBlockReceipts(idx.db, hash, number) {
+		for _, log := range receipt.Logs {
+			if _, ok := logs[log.Address]; !ok {
+				block.Contracts = append(block.Contracts, log.Address)
+			}
+			logs[log.Address] = append(logs[log.Address], (*vm.LogForStorage)(log))
+		}
+	}
+	batch := idx.db.NewBatch()
+	for _, addr := range block.Contracts {
+		blob, err := rlp.EncodeToBytes(logs[addr])
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(logIndexLogsKey(addr, number), blob); err != nil {
+			return err
+		}
+		numbers := append(readLogIndexChunk(idx.db, addr, number/logIndexChunkSize), number)
+		if err := putLogIndexChunk(batch, addr, number/logIndexChunkSize, numbers); err != nil {
+			return err
+		}
+	}
+	blob, err := rlp.EncodeToBytes(&block)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(logIndexBlockKey(number), blob); err != nil {
+		return err
+	}
+	state := idx.state
+	state.Next = number + 1
+	blob, _ = rlp.EncodeToBytes(&state)
+	if err := batch.Put(logIndexStateKey, blob); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	idx.state = state
+	return nil
+}
+
+// unindexBlock removes the last indexed block, which isn't canonical anymore.
+// The state is written first, so queries never use partially removed blocks.
+func (idx *LogIndex) unindexBlock(number uint64) {
+	idx.state.Next = number
+	if idx.state.Pruned > number {
+		idx.state.Pruned = number
+	}
+	writeLogIndexState(idx.db, &idx.state)
+
+	if block := readIndexedBlock(idx.db, number); block != nil {
+		for _, addr := range block.Contracts {
+			idx.deleteLogs(addr, number)
+		}
+	}
+	idx.db.Delete(logIndexBlockKey(number))
+}
+
+// prune removes the logs of unregistered contracts from the blocks before the
+// given one.
+func (idx *LogIndex) prune(until uint64) {
+	if until > idx.state.Next {
+		until = idx.state.Next
+	}
+	from := idx.state.Pruned
+	if from < idx.state.Start {
+		from = idx.state.Start
+	}
+	if from >= until {
+		return
+	}
+	idx.state.Pruned = until
+	writeLogIndexState(idx.db, &idx.state)
+
+	for number := from; number < until; number++ {
+		block := readIndexedBlock(idx.db, number)
+		if block == nil {
+			continue
+		}
+		var kept []common.Address
+		for _, addr := range block.Contracts {
+			if _, ok := idx.contracts[addr]; ok {
+				kept = append(kept, addr)
+			} else {
+				idx.deleteLogs(addr, number)
+			}
+		}
+		if len(kept) != len(block.Contracts) {
+			block.Contracts = kept
+			blob, _ := rlp.EncodeToBytes(block)
+			idx.db.Put(logIndexBlockKey(number), blob)
+		}
+	}
+}
+
+// deleteLogs removes the logs of a contract in a block from the index.
+func (idx *LogIndex) deleteLogs(addr common.Address, number uint64) {
+	idx.db.Delete(logIndexLogsKey(addr, number))
+
+	chunk := number / logIndexChunkSize
+	numbers := readLogIndexChunk(idx.db, addr, chunk)
+	for i, n := range numbers {
+		if n == number {
+			numbers = append(numbers[:i], numbers[i+1:]...)
+			break
+		}
+	}
+	if len(numbers) == 0 {
+		idx.db.Delete(logIndexChunkKey(addr, chunk))
+	} else {
+		putLogIndexChunk(idx.db, addr, chunk, numbers)
+	}
+}
+
+// Register keeps the logs of a contract indefinitely, from the oldest block
+// whose logs of the contract are still in the index. It returns false if the
+// contract was already registered.
+func (idx *LogIndex) Register(addr common.Address) (bool, error) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if _, ok := idx.contracts[addr]; ok {
+		return false, nil
+	}
+	since := idx.state.Pruned
+	if since < idx.state.Start {
+		since = idx.state.Start
+	}
+	idx.contracts[addr] = since
+	if err := idx.writeContracts(); err != nil {
+		delete(idx.contracts, addr)
+		return false, err
+	}
+	return true, nil
+}
+
+// Unregister stops keeping the logs of a contract indefinitely. The logs of
+// the contract outside of the retention window are removed. It returns false
+// if the contract wasn't registered.
+func (idx *LogIndex) Unregister(addr common.Address) (bool, error) {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	since, ok := idx.contracts[addr]
+	if !ok {
+		return false, nil
+	}
+	delete(idx.contracts, addr)
+	if err := idx.writeContracts(); err != nil {
+		idx.contracts[addr] = since
+		return false, err
+	}
+	// The contract isn't covered before the pruned block anymore, so its logs
+	// there can go
+	if idx.state.Pruned > 0 {
+		last := idx.state.Pruned - 1
+		for chunk := since / logIndexChunkSize; chunk <= last/logIndexChunkSize; chunk++ {
+			for _, number := range readLogIndexChunk(idx.db, addr, chunk) {
+				if number >= since && number <= last {
+					idx.deleteLogs(addr, number)
+					idx.removeContract(number, addr)
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// removeContract removes a contract from the record of an indexed block.
+func (idx *LogIndex) removeContract(number uint64, addr common.Address) {
+	block := readIndexedBlock(idx.db, number)
+	if block == nil {
+		return
+	}
+	for i, contract := range block.Contracts {
+		if contract == addr {
+			block.Contracts = append(block.Contracts[:i], block.Contracts[i+1:]...)
+			blob, _ := rlp.EncodeToBytes(block)
+			idx.db.Put(logIndexBlockKey(number), blob)
+			return
+		}
+	}
+}
+
+func (idx *LogIndex) writeContracts() error {
+	contracts := make([]registeredContract, 0, len(idx.contracts))
+	for addr, since := range idx.contracts {
+		contracts = append(contracts, registeredContract{Address: addr, Since: since})
+	}
+	sort.Sort(registeredContracts(contracts))
+
+	blob, err := rlp.EncodeToBytes(contracts)
+	if err != nil {
+		return err
+	}
+	return idx.db.Put(logIndexContractsKey, blob)
+}
+
+// LogIndexStatus is the coverage of the log index.
+type LogIndexStatus struct {
+	Start     uint64                   `json:"start"`     // First indexed block
+	Head      *uint64                  `json:"head"`      // Last indexed block, nil if none
+	Retention uint64                   `json:"retention"` // Blocks the logs of unregistered contracts are kept for
+	Pruned    uint64                   `json:"pruned"`    // First block the logs of unregistered contracts are kept from
+	Contracts []RegisteredContractInfo `json:"contracts"`
+}
+
+// RegisteredContractInfo is a contract whose logs are kept indefinitely.
+type RegisteredContractInfo struct {
+	Address common.Address `json:"address"`
+	Since   uint64         `json:"since"` // First block the logs of the contract are kept from
+}
+
+// Status returns the coverage of the index and the registered contracts.
+func (idx *LogIndex) Status() *LogIndexStatus {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	status := &LogIndexStatus{
+		Start:     idx.state.Start,
+		Retention: idx.retention,
+		Pruned:    idx.state.Pruned,
+		Contracts: []RegisteredContractInfo{},
+	}
+	if idx.state.Next > idx.state.Start {
+		head := idx.state.Next - 1
+		status.Head = &head
+	}
+	if status.Pruned < status.Start {
+		status.Pruned = status.Start
+	}
+	for _, contract := range readRegisteredContracts(idx.db) {
+		status.Contracts = append(status.Contracts, RegisteredContractInfo{Address: contract.Address, Since: contract.Since})
+	}
+	return status
+}
+
+// indexedLogs retrieves the logs of the given contracts in a range of blocks
+// from the log index. It returns false if the index doesn't cover the range for
+// all of the contracts, in which case the blocks must be scanned.
+func indexedLogs(db ethdb.Database, addresses []common.Address, begin, end uint64) ([]Log, bool) {
+	state := readLogIndexState(db)
+	if state == nil || end >= state.Next || begin > end {
+		return nil, false
+	}
+	since := make(map[common.Address]uint64)
+	for _, contract := range readRegisteredContracts(db) {
+		since[contract.Address] = contract.Since
+	}
+	for _, addr := range addresses {
+		from, ok := since[addr]
+		if !ok {
+			from = state.Pruned
+		}
+		if from < state.Start {
+			from = state.Start
+		}
+		if begin < from {
+			return nil, false
+		}
+	}
+	var (
+		logs = []Log{}
+		seen = make(map[common.Address]bool)
+	)
+	for _, addr := range addresses {
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		for chunk := begin / logIndexChunkSize; chunk <= end/logIndexChunkSize; chunk++ {
+			for _, number := range readLogIndexChunk(db, addr, chunk) {
+				if number < begin || number > end {
+					continue
+				}
+				blob, _ := db.Get(logIndexLogsKey(addr, number))
+				var stored []*vm.LogForStorage
+				if err := rlp.DecodeBytes(blob, &stored); err != nil {
+					glog.V(logger.Error).Infof("Invalid log index entry for %x in block %d: %v", addr, number, err)
+					return nil, false
+				}
+				for _, log := range stored {
+					logs = append(logs, Log{(*vm.Log)(log), false})
+				}
+			}
+		}
+	}
+	sort.Stable(logsByPosition(logs))
+	return logs, true
+}
+
+// logsByPosition orders logs by block, transaction and position in the block.
+type logsByPosition []Log
+
+func (l logsByPosition) Len() int      { return len(l) }
+func (l logsByPosition) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l logsByPosition) Less(i, j int) bool {
+	if l[i].BlockNumber != l[j].BlockNumber {
+		return l[i].BlockNumber < l[j].BlockNumber
+	}
+	if l[i].TxIndex != l[j].TxIndex {
+		return l[i].TxIndex < l[j].TxIndex
+	}
+	return l[i].Index < l[j].Index
+}
+
+type registeredContracts []registeredContract
+
+func (c registeredContracts) Len() int           { return len(c) }
+func (c registeredContracts) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c registeredContracts) Less(i, j int) bool { return c[i].Address.Hex() < c[j].Address.Hex() }
+
+func encodeLogIndexNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func logIndexBlockKey(number uint64) []byte {
+	return append(append([]byte{}, logIndexBlockPrefix...), encodeLogIndexNumber(number)...)
+}
+
+func logIndexChunkKey(addr common.Address, chunk uint64) []byte {
+	return append(append(append([]byte{}, logIndexChunkPrefix...), addr[:]...), encodeLogIndexNumber(chunk)...)
+}
+
+func logIndexLogsKey(addr common.Address, number uint64) []byte {
+	return append(append(append([]byte{}, logIndexLogsPrefix...), addr[:]...), encodeLogIndexNumber(number)...)
+}
+
+func readLogIndexState(db ethdb.Database) *logIndexState {
+	blob, _ := db.Get(logIndexStateKey)
+	if len(blob) == 0 {
+		return nil
+	}
+	state := new(logIndexState)
+	if err := rlp.DecodeBytes(blob, state); err != nil {
+		glog.V(logger.Error).Infof("Invalid log index state: %v", err)
+		return nil
+	}
+	return state
+}
+
+func writeLogIndexState(db ethdb.Database, state *logIndexState) {
+	blob, _ := rlp.EncodeToBytes(state)
+	if err := db.Put(logIndexStateKey, blob); err != nil {
+		glog.Fatalf("failed to store log index state: %v", err)
+	}
+}
+
+func readRegisteredContracts(db ethdb.Database) []registeredContract {
+	blob, _ := db.Get(logIndexContractsKey)
+	if len(blob) == 0 {
+		return nil
+	}
+	var contracts []registeredContract
+	if err := rlp.DecodeBytes(blob, &contracts); err != nil {
+		glog.V(logger.Error).Infof("Invalid log index contracts: %v", err)
+		return nil
+	}
+	return contracts
+}
+
+func readIndexedBlock(db ethdb.Database, number uint64) *indexedBlock {
+	blob, _ := db.Get(logIndexBlockKey(number))
+	if len(blob) == 0 {
+		return nil
+	}
+	block := new(indexedBlock)
+	if err := rlp.DecodeBytes(blob, block); err != nil {
+		glog.V(logger.Error).Infof("Invalid log index block %d: %v", number, err)
+		return nil
+	}
+	return block
+}
+
+func readLogIndexChunk(db ethdb.Database, addr common.Address, chunk uint64) []uint64 {
+	blob, _ := db.Get(logIndexChunkKey(addr, chunk))
+	if len(blob) == 0 {
+		return nil
+	}
+	var numbers []uint64
+	if err := rlp.DecodeBytes(blob, &numbers); err != nil {
+		glog.V(logger.Error).Infof("Invalid log index chunk of %x: %v", addr, err)
+		return nil
+	}
+	return numbers
+}
+
+// putLogIndexChunk stores the blocks of a chunk with logs of a contract, either
+// directly in the database or in a batch.
+func putLogIndexChunk(db interface {
+	Put(key, value []byte) error
+}, addr common.Address, chunk uint64, numbers []uint64) error {
+	blob, err := rlp.EncodeToBytes(numbers)
+	if err != nil {
+		return err
+	}
+	return db.Put(logIndexChunkKey(addr, chunk), blob)
+}
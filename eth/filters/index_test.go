@@ -0,0 +1,196 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+var (
+	indexAddr1 = common.BytesToAddress([]byte("registered"))
+	indexAddr2 = common.BytesToAddress([]byte("unregistered"))
+	indexTopic = common.BytesToHash([]byte("topic"))
+)
+
+// writeIndexTestChain generates a chain on top of the given parent, with logs of
+// both test contracts in the given blocks, and makes it canonical.
+func writeIndexTestChain(t *testing.T, db ethdb.Database, parent *types.Block, n int, seed byte, logBlocks ...uint64) []*types.Block {
+	chain, receipts := core.GenerateChain(nil, parent, db, n, func(i int, gen *core.BlockGen) {
+		gen.SetExtra([]byte{seed})
+		for _, number := range logBlocks {
+			if gen.Number().Uint64() == number {
+				receipt := types.NewReceipt(nil, new(big.Int))
+				receipt.Logs = vm.Logs{
+					&vm.Log{Address: indexAddr1, Topics: []common.Hash{indexTopic}, BlockNumber: number, Index: 0},
+					&vm.Log{Address: indexAddr2, BlockNumber: number, Index: 1},
+				}
+				gen.AddUncheckedReceipt(receipt)
+			}
+		}
+	})
+	for i, block := range chain {
+		if err := core.WriteBlock(db, block); err != nil {
+			t.Fatalf("failed to write block: %v", err)
+		}
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to write canonical hash: %v", err)
+		}
+		if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+			t.Fatalf("failed to write head hash: %v", err)
+		}
+		if err := core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i]); err != nil {
+			t.Fatalf("failed to write receipts: %v", err)
+		}
+	}
+	return chain
+}
+
+func newIndexTestDB() (ethdb.Database, *types.Block) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db)
+	return db, genesis
+}
+
+// Tests that logs are served from the index where it covers the queried range,
+// depending on the retention window and registered contracts.
+func TestLogIndexRetention(t *testing.T) {
+	db, genesis := newIndexTestDB()
+
+	idx := NewLogIndex(db, new(event.TypeMux), 10)
+	if ok, err := idx.Register(indexAddr1); !ok || err != nil {
+		t.Fatalf("failed to register contract: %v, %v", ok, err)
+	}
+	writeIndexTestChain(t, db, genesis, 100, 0, 5, 95, 97)
+	idx.update()
+
+	status := idx.Status()
+	if status.Head == nil || *status.Head != 100 || status.Pruned != 91 {
+		t.Fatalf("status mismatch: have head %v, pruned %d, want 100, 91", status.Head, status.Pruned)
+	}
+	tests := []struct {
+		addresses  []common.Address
+		begin, end uint64
+		indexed    bool
+		logs       int
+	}{
+		{[]common.Address{indexAddr1}, 0, 100, true, 3},  // registered, kept indefinitely
+		{[]common.Address{indexAddr1}, 6, 96, true, 1},   // partial range
+		{[]common.Address{indexAddr2}, 0, 100, false, 0}, // unregistered, pruned
+		{[]common.Address{indexAddr2}, 91, 100, true, 2}, // within the retention window
+		{[]common.Address{indexAddr1, indexAddr2}, 91, 100, true, 4},
+		{[]common.Address{indexAddr1, indexAddr2}, 90, 100, false, 0},
+		{[]common.Address{indexAddr1}, 0, 101, false, 0}, // beyond the head
+	}
+	for i, tt := range tests {
+		logs, ok := indexedLogs(db, tt.addresses, tt.begin, tt.end)
+		if ok != tt.indexed {
+			t.Errorf("test %d: indexed mismatch: have %v, want %v", i, ok, tt.indexed)
+			continue
+		}
+		if len(logs) != tt.logs {
+			t.Errorf("test %d: log count mismatch: have %d, want %d", i, len(logs), tt.logs)
+		}
+		for j := 1; j < len(logs); j++ {
+			if logs[j-1].BlockNumber > logs[j].BlockNumber || (logs[j-1].BlockNumber == logs[j].BlockNumber && logs[j-1].Index > logs[j].Index) {
+				t.Errorf("test %d: logs out of order: %v", i, logs)
+			}
+		}
+	}
+	if blob, _ := db.Get(logIndexLogsKey(indexAddr2, 5)); len(blob) != 0 {
+		t.Errorf("logs of unregistered contract not pruned")
+	}
+	// Filters only use the index where it covers the range, there are no
+	// bloom filters to scan the blocks with in this test
+	filter := New(db)
+	filter.SetBeginBlock(0)
+	filter.SetEndBlock(-1)
+	filter.SetAddresses([]common.Address{indexAddr1, indexAddr2})
+	filter.SetTopics([][]common.Hash{{indexTopic}})
+	if logs := filter.Find(); len(logs) != 0 {
+		t.Errorf("unexpected logs from block scan: %v", logs)
+	}
+	filter.SetBeginBlock(91)
+	if logs := filter.Find(); len(logs) != 2 || logs[0].Address != indexAddr1 {
+		t.Errorf("filter mismatch: have %v, want 2 logs of %x", logs, indexAddr1)
+	}
+}
+
+// Tests that unregistering a contract drops its logs outside of the retention
+// window.
+func TestLogIndexUnregister(t *testing.T) {
+	db, genesis := newIndexTestDB()
+
+	idx := NewLogIndex(db, new(event.TypeMux), 10)
+	idx.Register(indexAddr1)
+	writeIndexTestChain(t, db, genesis, 50, 0, 5, 45)
+	idx.update()
+
+	if ok, err := idx.Unregister(indexAddr1); !ok || err != nil {
+		t.Fatalf("failed to unregister contract: %v, %v", ok, err)
+	}
+	if ok, _ := idx.Unregister(indexAddr1); ok {
+		t.Errorf("contract unregistered twice")
+	}
+	if blob, _ := db.Get(logIndexLogsKey(indexAddr1, 5)); len(blob) != 0 {
+		t.Errorf("logs of unregistered contract not removed")
+	}
+	if _, ok := indexedLogs(db, []common.Address{indexAddr1}, 0, 50); ok {
+		t.Errorf("index used for unregistered contract")
+	}
+	if logs, ok := indexedLogs(db, []common.Address{indexAddr1}, 41, 50); !ok || len(logs) != 1 {
+		t.Errorf("logs within retention window mismatch: have %v, %v", ok, logs)
+	}
+	// Registering again only covers the logs still in the index
+	idx.Register(indexAddr1)
+	if status := idx.Status(); len(status.Contracts) != 1 || status.Contracts[0].Since != 41 {
+		t.Errorf("registered contracts mismatch: have %v", status.Contracts)
+	}
+}
+
+// Tests that blocks which were reorganised away are removed from the index.
+func TestLogIndexReorg(t *testing.T) {
+	db, genesis := newIndexTestDB()
+
+	idx := NewLogIndex(db, new(event.TypeMux), 1000)
+	chain := writeIndexTestChain(t, db, genesis, 20, 0, 15)
+	idx.update()
+	if logs, ok := indexedLogs(db, []common.Address{indexAddr1}, 0, 20); !ok || len(logs) != 1 {
+		t.Fatalf("logs before reorg mismatch: have %v, %v", ok, logs)
+	}
+	// Replace the blocks after 10 by a longer fork with logs in another block
+	fork := writeIndexTestChain(t, db, chain[9], 15, 1, 18)
+	idx.update()
+
+	logs, ok := indexedLogs(db, []common.Address{indexAddr1}, 0, 25)
+	if !ok || len(logs) != 1 {
+		t.Fatalf("logs after reorg mismatch: have %v, %v", ok, logs)
+	}
+	if logs[0].BlockNumber != 18 {
+		t.Errorf("log block mismatch: have %d, want 18", logs[0].BlockNumber)
+	}
+	if block := readIndexedBlock(db, 15); block == nil || block.Hash != fork[4].Hash() || len(block.Contracts) != 0 {
+		t.Errorf("indexed block 15 mismatch: have %v", block)
+	}
+}
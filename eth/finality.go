@@ -0,0 +1,372 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// finalityHookHeadKey -> finalityHookHead (rlp), the resume checkpoint of the
+// finality hook.
+var finalityHookHeadKey = []byte("finality-hook-head")
+
+var (
+	finalityDeliveredMeter = metrics.NewMeter("eth/finality/delivered")
+	finalityFailedMeter    = metrics.NewMeter("eth/finality/failed")
+)
+
+const (
+	defaultFinalityHookTimeout = 10 * time.Second
+
+	finalityRetryMin = time.Second // Wait after the first failed delivery
+	finalityRetryMax = time.Minute // Longest wait between failed deliveries
+)
+
+// FinalityHookConfig configures the delivery of finalized blocks to an
+// external system.
+type FinalityHookConfig struct {
+	URL     string        // http(s):// endpoint blocks are posted to, or grpc://host:port of a FinalityHook service, disabled if empty
+	CACert  string        // CA certificate to verify the endpoint's TLS certificate with, gRPC is plaintext without
+	Timeout time.Duration // Timeout of a delivery
+}
+
+// FinalizedBlock is a finalized block as delivered to the finality hook.
+type FinalizedBlock struct {
+	Number       uint64        `json:"number"`
+	Hash         common.Hash   `json:"hash"`
+	ParentHash   common.Hash   `json:"parentHash"`
+	Timestamp    uint64        `json:"timestamp"`
+	Transactions []common.Hash `json:"transactions"`
+}
+
+func newFinalizedBlock(block *types.Block) *FinalizedBlock {
+	fb := &FinalizedBlock{
+		Number:       block.NumberU64(),
+		Hash:         block.Hash(),
+		ParentHash:   block.ParentHash(),
+		Timestamp:    block.Time().Uint64(),
+		Transactions: make([]common.Hash, 0, len(block.Transactions())),
+	}
+	for _, tx := range block.Transactions() {
+		fb.Transactions = append(fb.Transactions, tx.Hash())
+	}
+	return fb
+}
+
+// FinalityHook delivers finalized blocks to an external system.
+type FinalityHook interface {
+	// Deliver returns nil once the external system acknowledged the block.
+	Deliver(block *FinalizedBlock) error
+	Close() error
+}
+
+// NewFinalityHook creates the hook of the configured endpoint.
+func NewFinalityHook(config FinalityHookConfig) (FinalityHook, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultFinalityHookTimeout
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http", "https":
+		return newHTTPFinalityHook(config)
+	case "grpc":
+		return newGRPCFinalityHook(config, u.Host)
+	default:
+		return nil, fmt.Errorf("unsupported finality hook %q, want an http://, https:// or grpc:// URL", config.URL)
+	}
+}
+
+// httpFinalityHook posts finalized blocks as JSON, a 2xx status acknowledges
+// the block.
+type httpFinalityHook struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPFinalityHook(config FinalityHookConfig) (*httpFinalityHook, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if config.CACert != "" {
+		pem, err := ioutil.ReadFile(config.CACert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", config.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &httpFinalityHook{
+		url:    config.URL,
+		client: &http.Client{Transport: transport, Timeout: config.Timeout},
+	}, nil
+}
+
+func (h *httpFinalityHook) Deliver(block *FinalizedBlock) error {
+	blob, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	res, err := h.client.Post(h.url, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, _ := ioutil.ReadAll(&io.LimitedReader{R: res.Body, N: 256})
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func (h *httpFinalityHook) Close() error {
+	return nil
+}
+
+// grpcFinalityHook delivers finalized blocks to a FinalityHook gRPC service.
+type grpcFinalityHook struct {
+	timeout time.Duration
+	conn    *grpc.ClientConn
+	client  FinalityHookClient
+}
+
+func newGRPCFinalityHook(config FinalityHookConfig, endpoint string) (*grpcFinalityHook, error) {
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if config.CACert != "" {
+		creds, err := credentials.NewClientTLSFromFile(config.CACert, "")
+		if err != nil {
+			return nil, err
+		}
+		opts = []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	}
+	conn, err := grpc.Dial(endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &grpcFinalityHook{timeout: config.Timeout, conn: conn, client: NewFinalityHookClient(conn)}, nil
+}
+
+func (h *grpcFinalityHook) Deliver(block *FinalizedBlock) error {
+	req := &FinalizedRequest{
+		Number:     block.Number,
+		Hash:       block.Hash.Bytes(),
+		ParentHash: block.ParentHash.Bytes(),
+		Timestamp:  block.Timestamp,
+	}
+	for _, hash := range block.Transactions {
+		req.Transactions = append(req.Transactions, hash.Bytes())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+	_, err := h.client.Finalized(ctx, req)
+	return err
+}
+
+func (h *grpcFinalityHook) Close() error {
+	return h.conn.Close()
+}
+
+// finalityHookHead is the last block acknowledged by the finality hook.
+type finalityHookHead struct {
+	Next uint64      // First block not acknowledged yet
+	Hash common.Hash // Hash of the last acknowledged block
+}
+
+// finalityNotifier delivers the finalized blocks to the finality hook in
+// order, one at a time. A block is delivered until the hook acknowledged it,
+// with an increasing delay between attempts, and only then is the checkpoint
+// moved past it. Delivery resumes from the checkpoint after a restart, so every
+// block is delivered at least once, a block acknowledged right before the node
+// stopped possibly twice.
+//
+// Raft only inserts blocks committed by the cluster, so the head is final. With
+// QuorumChain block makers build on the block that reached the vote threshold,
+// so a block is final once another block was built on it.
+type finalityNotifier struct {
+	db    ethdb.Database
+	mux   *event.TypeMux
+	hook  FinalityHook
+	depth uint64 // Blocks on top of a block before it's final
+
+	head finalityHookHead
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newFinalityNotifier(db ethdb.Database, mux *event.TypeMux, hook FinalityHook, raftMode bool) *finalityNotifier {
+	n := &finalityNotifier{
+		db:   db,
+		mux:  mux,
+		hook: hook,
+		wake: make(chan struct{}, 1),
+		quit: make(chan struct{}),
+	}
+	if !raftMode {
+		n.depth = 1
+	}
+	if blob, _ := db.Get(finalityHookHeadKey); len(blob) > 0 {
+		if err := rlp.DecodeBytes(blob, &n.head); err != nil {
+			glog.V(logger.Error).Infof("Invalid finality hook checkpoint, delivering from the next finalized block: %v", err)
+			n.head = finalityHookHead{}
+		}
+	}
+	if n.head.Next == 0 {
+		// Without a checkpoint, deliver the blocks finalized from now on
+		final, ok := n.finalized()
+		if !ok {
+			final = 0
+		}
+		n.head = finalityHookHead{Next: final + 1, Hash: core.GetCanonicalHash(db, final)}
+	}
+	return n
+}
+
+// finalized returns the number of the latest final block.
+func (n *finalityNotifier) finalized() (uint64, bool) {
+	head := core.GetBlockNumber(n.db, core.GetHeadBlockHash(n.db))
+	if head == ^uint64(0) || head < n.depth {
+		return 0, false
+	}
+	return head - n.depth, true
+}
+
+// start delivers the blocks finalized since the checkpoint and the blocks
+// finalized from then on in the background.
+func (n *finalityNotifier) start() {
+	glog.V(logger.Info).Infof("Delivering finalized blocks from #%d to the finality hook", n.head.Next)
+	sub := n.mux.Subscribe(core.ChainHeadEvent{})
+
+	n.wg.Add(2)
+	go func() {
+		defer n.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case _, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				select {
+				case n.wake <- struct{}{}:
+				default:
+				}
+			case <-n.quit:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer n.wg.Done()
+
+		retry := time.Duration(0)
+		for {
+			if err := n.deliver(); err != nil {
+				// Back off, blocks finalized meanwhile are delivered with the retry
+				if retry *= 2; retry < finalityRetryMin {
+					retry = finalityRetryMin
+				} else if retry > finalityRetryMax {
+					retry = finalityRetryMax
+				}
+				glog.V(logger.Warn).Infof("Failed to deliver finalized block #%d, retrying in %v: %v", n.head.Next, retry, err)
+				select {
+				case <-time.After(retry):
+					continue
+				case <-n.quit:
+					return
+				}
+			}
+			retry = 0
+			select {
+			case <-n.wake:
+			case <-n.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop terminates the delivery and closes the hook.
+func (n *finalityNotifier) stop() {
+	close(n.quit)
+	n.wg.Wait()
+	n.hook.Close()
+}
+
+// deliver delivers the final blocks after the checkpoint, stopping at the first
+// one the hook fails to acknowledge.
+func (n *finalityNotifier) deliver() error {
+	final, ok := n.finalized()
+	if !ok {
+		return nil
+	}
+	for ; n.head.Next <= final; n.head.Next++ {
+		select {
+		case <-n.quit:
+			return nil
+		default:
+		}
+		hash := core.GetCanonicalHash(n.db, n.head.Next)
+		block := core.GetBlock(n.db, hash, n.head.Next)
+		if block == nil {
+			return errors.New("block not found")
+		}
+		if block.ParentHash() != n.head.Hash && n.head.Hash != (common.Hash{}) {
+			glog.V(logger.Error).Infof("Finalized block #%d [%x…] reorganised away, delivering the new chain", n.head.Next-1, n.head.Hash[:4])
+		}
+		if err := n.hook.Deliver(newFinalizedBlock(block)); err != nil {
+			finalityFailedMeter.Mark(1)
+			return err
+		}
+		finalityDeliveredMeter.Mark(1)
+		n.head.Hash = hash
+		blob, _ := rlp.EncodeToBytes(&finalityHookHead{Next: n.head.Next + 1, Hash: hash})
+		if err := n.db.Put(finalityHookHeadKey, blob); err != nil {
+			glog.Fatalf("failed to store finality hook checkpoint: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package eth
+
+import (
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Message types and client of the FinalityHook service defined in finality.proto.
+
+type FinalizedRequest struct {
+	Number       uint64   `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Hash         []byte   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	ParentHash   []byte   `protobuf:"bytes,3,opt,name=parent_hash,json=parentHash,proto3" json:"parent_hash,omitempty"`
+	Timestamp    uint64   `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Transactions [][]byte `protobuf:"bytes,5,rep,name=transactions,proto3" json:"transactions,omitempty"`
+}
+
+func (m *FinalizedRequest) Reset()         { *m = FinalizedRequest{} }
+func (m *FinalizedRequest) String() string { return proto.CompactTextString(m) }
+func (*FinalizedRequest) ProtoMessage()    {}
+
+type FinalizedResponse struct {
+}
+
+func (m *FinalizedResponse) Reset()         { *m = FinalizedResponse{} }
+func (m *FinalizedResponse) String() string { return proto.CompactTextString(m) }
+func (*FinalizedResponse) ProtoMessage()    {}
+
+// FinalityHookClient is the client API for the FinalityHook service.
+type FinalityHookClient interface {
+	Finalized(ctx context.Context, in *FinalizedRequest, opts ...grpc.CallOption) (*FinalizedResponse, error)
+}
+
+type finalityHookClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewFinalityHookClient(cc *grpc.ClientConn) FinalityHookClient {
+	return &finalityHookClient{cc}
+}
+
+func (c *finalityHookClient) Finalized(ctx context.Context, in *FinalizedRequest, opts ...grpc.CallOption) (*FinalizedResponse, error) {
+	out := new(FinalizedResponse)
+	if err := c.cc.Invoke(ctx, "/eth.FinalityHook/Finalized", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// testFinalityHook records the delivered blocks and fails while fail is set.
+type testFinalityHook struct {
+	delivered []uint64
+	fail      bool
+}
+
+func (h *testFinalityHook) Deliver(block *FinalizedBlock) error {
+	if h.fail {
+		return errors.New("unavailable")
+	}
+	h.delivered = append(h.delivered, block.Number)
+	return nil
+}
+
+func (h *testFinalityHook) Close() error { return nil }
+
+// Tests that finalized blocks are delivered in order until acknowledged, and
+// that delivery resumes from the checkpoint after a restart.
+func TestFinalityNotifier(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	writeCanonicalBlock(t, db, 0, common.Hash{}, nil)
+	writeCanonicalBlock(t, db, 1, common.Hash{}, nil)
+
+	// Without a checkpoint, blocks finalized from now on are delivered
+	hook := new(testFinalityHook)
+	n := newFinalityNotifier(db, new(event.TypeMux), hook, false)
+	if n.head.Next != 1 {
+		t.Fatalf("first block mismatch: have %d, want 1", n.head.Next)
+	}
+	writeCanonicalBlock(t, db, 2, common.Hash{}, nil)
+	writeCanonicalBlock(t, db, 3, common.Hash{}, nil)
+
+	// The head isn't final with QuorumChain, failed blocks are delivered again
+	hook.fail = true
+	if err := n.deliver(); err == nil {
+		t.Fatalf("failed delivery succeeded")
+	}
+	hook.fail = false
+	if err := n.deliver(); err != nil {
+		t.Fatalf("delivery failed: %v", err)
+	}
+	if len(hook.delivered) != 2 || hook.delivered[0] != 1 || hook.delivered[1] != 2 {
+		t.Fatalf("delivered blocks mismatch: have %v, want [1 2]", hook.delivered)
+	}
+
+	// A restarted raft node resumes after the checkpoint, up to the head
+	hook = new(testFinalityHook)
+	n = newFinalityNotifier(db, new(event.TypeMux), hook, true)
+	if err := n.deliver(); err != nil {
+		t.Fatalf("delivery failed: %v", err)
+	}
+	if len(hook.delivered) != 1 || hook.delivered[0] != 3 {
+		t.Fatalf("delivered blocks after restart mismatch: have %v, want [3]", hook.delivered)
+	}
+}
+
+// Tests that the HTTP finality hook only counts 2xx responses as acknowledged.
+func TestHTTPFinalityHook(t *testing.T) {
+	status := http.StatusOK
+	var received FinalizedBlock
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hook, err := NewFinalityHook(FinalityHookConfig{URL: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create hook: %v", err)
+	}
+	block := &FinalizedBlock{Number: 7, Hash: common.Hash{7}, Transactions: []common.Hash{{1}}}
+	if err := hook.Deliver(block); err != nil {
+		t.Fatalf("delivery failed: %v", err)
+	}
+	if received.Number != 7 || received.Hash != block.Hash || len(received.Transactions) != 1 {
+		t.Errorf("received block mismatch: have %+v", received)
+	}
+	status = http.StatusServiceUnavailable
+	if err := hook.Deliver(block); err == nil {
+		t.Errorf("delivery acknowledged with status %d", status)
+	}
+	if _, err := NewFinalityHook(FinalityHookConfig{URL: "ftp://settlement"}); err == nil {
+		t.Errorf("unsupported scheme accepted")
+	}
+}
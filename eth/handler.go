@@ -92,6 +92,15 @@ type ProtocolManager struct {
 	badBlockReportingEnabled bool
 
 	raftMode bool
+
+	configCheck ConfigCheck // Handling of peers with a different chain config
+	fingerprint common.Hash // Fingerprint of our chain config
+
+	propagation *propagationTracker // Block propagation latency of the peers
+
+	checkpoint common.Hash // Trusted block the initial sync fast syncs to
+
+	versions *versionTracker // Minimum eth protocol version of the peers
 }
 
 // NewProtocolManager returns a new ethereum sub protocol manager. The Ethereum sub protocol manages peers capable
@@ -111,6 +120,8 @@ func NewProtocolManager(config *core.ChainConfig, assumeSyncedInitially bool, ne
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 		raftMode:    raftMode,
+		propagation: newPropagationTracker(),
+		versions:    newVersionTracker(0),
 	}
 	if assumeSyncedInitially {
 		manager.synced = uint32(1)
@@ -193,6 +204,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and Ethereum peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.propagation.remove(id)
 	if err := pm.peers.Unregister(id); err != nil {
 		glog.V(logger.Error).Infoln("Removal failed:", err)
 	}
@@ -259,12 +271,24 @@ func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *p
 func (pm *ProtocolManager) handle(p *peer) error {
 	glog.V(logger.Debug).Infof("%v: peer connected [%s]", p, p.Name())
 
+	if err := pm.versions.check(p); err != nil {
+		return err
+	}
+
 	// Execute the Ethereum handshake
+	var fingerprint *common.Hash
+	if pm.configCheck != ConfigCheckOff {
+		fingerprint = &pm.fingerprint
+	}
 	td, head, genesis := pm.blockchain.Status()
-	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
+	if err := p.Handshake(pm.networkId, td, head, genesis, fingerprint); err != nil {
 		glog.V(logger.Debug).Infof("%v: handshake failed: %v", p, err)
 		return err
 	}
+	if err := pm.checkConfig(p); err != nil {
+		glog.V(logger.Debug).Infof("%v: config check failed: %v", p, err)
+		return err
+	}
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
@@ -640,8 +664,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		// Mark the hashes as present at the remote node
+		now := time.Now()
 		for _, block := range announces {
 			p.MarkBlock(block.Hash)
+			pm.propagation.announced(p.id, block.Hash, nil, now)
 		}
 		// Schedule all the unknown hashes for retrieval
 		unknown := make([]announce, 0, len(announces))
@@ -665,6 +691,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 
 		// Mark the peer as owning the block and schedule it for import
 		p.MarkBlock(request.Block.Hash())
+		pm.propagation.announced(p.id, request.Block.Hash(), request.Block.Time(), msg.ReceivedAt)
 		pm.fetcher.Enqueue(p.id, request.Block)
 
 		// Assuming the block is importable by the peer, but possibly not yet done so,
@@ -765,6 +792,7 @@ func (self *ProtocolManager) minedBroadcastLoop() {
 	for obj := range self.minedBlockSub.Chan() {
 		switch ev := obj.Data.(type) {
 		case core.NewMinedBlockEvent:
+			self.propagation.created(ev.Block.Hash(), time.Now())
 			self.BroadcastBlock(ev.Block, true)  // First propagate block to peers
 			self.BroadcastBlock(ev.Block, false) // Only then announce to the rest
 		}
@@ -786,6 +814,13 @@ type EthNodeInfo struct {
 	Difficulty *big.Int    `json:"difficulty"` // Total difficulty of the host's blockchain
 	Genesis    common.Hash `json:"genesis"`    // SHA3 hash of the host's genesis block
 	Head       common.Hash `json:"head"`       // SHA3 hash of the host's best owned block
+
+	ConfigFingerprint common.Hash `json:"configFingerprint"` // Fingerprint of the chain config
+	ConfigCheck       string      `json:"configCheck"`       // Handling of peers with a different fingerprint
+
+	Checkpoint *core.TrustedCheckpoint `json:"checkpoint,omitempty"` // Trusted block the node was synced to
+
+	PeerVersions *PeerVersions `json:"peerVersions"` // Protocol versions of the peers
 }
 
 // NodeInfo retrieves some protocol metadata about the running host node.
@@ -796,5 +831,12 @@ func (self *ProtocolManager) NodeInfo() *EthNodeInfo {
 		Difficulty: self.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64()),
 		Genesis:    self.blockchain.Genesis().Hash(),
 		Head:       currentBlock.Hash(),
+
+		ConfigFingerprint: self.fingerprint,
+		ConfigCheck:       self.configCheck.String(),
+
+		Checkpoint: core.GetTrustedCheckpoint(self.chaindb),
+
+		PeerVersions: self.versions.report(self.peers),
 	}
 }
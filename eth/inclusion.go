@@ -0,0 +1,320 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+const (
+	// inclusionMaxTracked is the number of submitted transactions tracked at
+	// once, further ones aren't measured until some are included or expire.
+	inclusionMaxTracked = 65536
+
+	// inclusionExpiry is the multiple of the SLA after which a transaction that
+	// still isn't included is given up on, e.g. as it was replaced.
+	inclusionExpiry = 10
+
+	// inclusionAlertQueue is the number of alerts queued for the hook, further
+	// ones are only logged while the hook is slow.
+	inclusionAlertQueue = 64
+
+	defaultInclusionHookTimeout = 10 * time.Second
+)
+
+var (
+	inclusionIncludedMeter = metrics.NewMeter("eth/inclusion/included")
+	inclusionBreachMeter   = metrics.NewMeter("eth/inclusion/breaches")
+	inclusionExpiredMeter  = metrics.NewMeter("eth/inclusion/expired")
+)
+
+// InclusionSLAConfig configures the monitoring of the time transactions
+// submitted through the node take to be included in a block.
+type InclusionSLAConfig struct {
+	SLA         time.Duration // Time to inclusion above which alerts are raised, 0 disables the monitor
+	Hook        string        // http(s):// endpoint alerts are posted to as JSON, only logged if empty
+	HookTimeout time.Duration // Timeout of an alert delivery
+}
+
+// Validate checks the alert hook of the inclusion SLA monitor.
+func (c InclusionSLAConfig) Validate() error {
+	if c.Hook == "" {
+		return nil
+	}
+	u, err := url.Parse(c.Hook)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported inclusion alert hook %q, want an http:// or https:// URL", c.Hook)
+	}
+	return nil
+}
+
+// InclusionAlert is an alert about a transaction missing the inclusion SLA, as
+// posted to the alert hook.
+type InclusionAlert struct {
+	TxHash      common.Hash  `json:"transactionHash"`
+	Submitted   time.Time    `json:"submitted"`
+	Latency     float64      `json:"latency"` // Seconds since submission, until inclusion if included
+	SLA         float64      `json:"sla"`     // Seconds
+	Included    bool         `json:"included"`
+	BlockNumber *uint64      `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+}
+
+// inclusionMonitor measures the time from the submission of transactions
+// through the node to their inclusion in a block. Latencies are sampled into
+// a histogram, whose percentiles the node status reports, and transactions
+// exceeding the SLA, included late or still pending, raise an alert: it's
+// logged and posted to the hook if one is configured. Every transaction raises
+// one alert at most.
+type inclusionMonitor struct {
+	config  InclusionSLAConfig
+	mux     *event.TypeMux
+	latency gometrics.Histogram // Milliseconds
+
+	lock     sync.Mutex
+	pending  map[common.Hash]*trackedTx
+	included int64
+	breaches int64
+
+	alerts chan *InclusionAlert
+	client *http.Client
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type trackedTx struct {
+	submitted time.Time
+	alerted   bool
+}
+
+func newInclusionMonitor(config InclusionSLAConfig, mux *event.TypeMux) *inclusionMonitor {
+	if config.HookTimeout == 0 {
+		config.HookTimeout = defaultInclusionHookTimeout
+	}
+	// The percentiles are reported in the status, so the latencies are sampled
+	// even if the metrics system is disabled
+	latency := gometrics.NewHistogram(gometrics.NewExpDecaySample(1028, 0.015))
+	if metrics.Enabled {
+		gometrics.Register("eth/inclusion/latency", latency)
+	}
+	return &inclusionMonitor{
+		config:  config,
+		mux:     mux,
+		latency: latency,
+		pending: make(map[common.Hash]*trackedTx),
+		alerts:  make(chan *InclusionAlert, inclusionAlertQueue),
+		client:  &http.Client{Timeout: config.HookTimeout},
+		quit:    make(chan struct{}),
+	}
+}
+
+func (m *inclusionMonitor) start() {
+	sub := m.mux.SubscribeWith(event.SubscriptionConfig{Name: "eth/inclusion", Buffer: 64, Overflow: event.DropOldest}, core.ChainEvent{})
+
+	interval := m.config.SLA / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer sub.Unsubscribe()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				m.include(ev.Data.(core.ChainEvent).Block, ev.Time)
+			case now := <-ticker.C:
+				m.check(now)
+			case <-m.quit:
+				return
+			}
+		}
+	}()
+	if m.config.Hook != "" {
+		m.wg.Add(1)
+		go m.deliverLoop()
+	}
+}
+
+func (m *inclusionMonitor) stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// track starts measuring the time to inclusion of a submitted transaction.
+func (m *inclusionMonitor) track(hash common.Hash, now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.pending[hash]; ok || len(m.pending) >= inclusionMaxTracked {
+		return
+	}
+	m.pending[hash] = &trackedTx{submitted: now}
+}
+
+// include records the latencies of the tracked transactions in a block.
+func (m *inclusionMonitor) include(block *types.Block, now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, tx := range block.Transactions() {
+		tracked := m.pending[tx.Hash()]
+		if tracked == nil {
+			continue
+		}
+		delete(m.pending, tx.Hash())
+
+		latency := now.Sub(tracked.submitted)
+		m.latency.Update(int64(latency / time.Millisecond))
+		m.included++
+		inclusionIncludedMeter.Mark(1)
+
+		if latency > m.config.SLA && !tracked.alerted {
+			number, hash := block.NumberU64(), block.Hash()
+			m.alert(&InclusionAlert{
+				TxHash:      tx.Hash(),
+				Submitted:   tracked.submitted,
+				Latency:     latency.Seconds(),
+				SLA:         m.config.SLA.Seconds(),
+				Included:    true,
+				BlockNumber: &number,
+				BlockHash:   &hash,
+			})
+		}
+	}
+}
+
+// check raises alerts for the tracked transactions pending longer than the
+// SLA and gives up on the ones pending for much longer.
+func (m *inclusionMonitor) check(now time.Time) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for hash, tracked := range m.pending {
+		latency := now.Sub(tracked.submitted)
+		if latency > inclusionExpiry*m.config.SLA {
+			delete(m.pending, hash)
+			inclusionExpiredMeter.Mark(1)
+			continue
+		}
+		if latency > m.config.SLA && !tracked.alerted {
+			tracked.alerted = true
+			m.alert(&InclusionAlert{
+				TxHash:    hash,
+				Submitted: tracked.submitted,
+				Latency:   latency.Seconds(),
+				SLA:       m.config.SLA.Seconds(),
+			})
+		}
+	}
+}
+
+// alert logs an alert and queues it for the hook, it must be called with the
+// lock held.
+func (m *inclusionMonitor) alert(alert *InclusionAlert) {
+	m.breaches++
+	inclusionBreachMeter.Mark(1)
+	if alert.Included {
+		glog.V(logger.Warn).Infof("Transaction %x included in block #%d after %v, exceeding the inclusion SLA of %v", alert.TxHash, *alert.BlockNumber, common.PrettyDuration(time.Duration(alert.Latency*float64(time.Second))), m.config.SLA)
+	} else {
+		glog.V(logger.Warn).Infof("Transaction %x not included after %v, exceeding the inclusion SLA of %v", alert.TxHash, common.PrettyDuration(time.Duration(alert.Latency*float64(time.Second))), m.config.SLA)
+	}
+	if m.config.Hook == "" {
+		return
+	}
+	select {
+	case m.alerts <- alert:
+	default:
+		glog.V(logger.Debug).Infof("Inclusion alert hook busy, not posting the alert of transaction %x", alert.TxHash)
+	}
+}
+
+func (m *inclusionMonitor) deliverLoop() {
+	defer m.wg.Done()
+	for {
+		select {
+		case alert := <-m.alerts:
+			if err := m.deliver(alert); err != nil {
+				glog.V(logger.Warn).Infof("Failed to post inclusion alert of transaction %x: %v", alert.TxHash, err)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// deliver posts an alert to the hook, a 2xx status acknowledges it.
+func (m *inclusionMonitor) deliver(alert *InclusionAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	res, err := m.client.Post(m.config.Hook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("%s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// status reports the inclusion latencies for the node status.
+func (m *inclusionMonitor) status() *node.InclusionStatus {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ps := m.latency.Percentiles([]float64{0.5, 0.95, 0.99})
+	return &node.InclusionStatus{
+		SLA:      uint64(m.config.SLA / time.Millisecond),
+		Pending:  len(m.pending),
+		Included: m.included,
+		Breaches: m.breaches,
+		P50:      uint64(ps[0]),
+		P95:      uint64(ps[1]),
+		P99:      uint64(ps[2]),
+	}
+}
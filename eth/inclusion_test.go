@@ -0,0 +1,96 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that transactions exceeding the inclusion SLA raise one alert, whether
+// they are still pending or included late, and that the alerts are posted.
+func TestInclusionMonitor(t *testing.T) {
+	posted := make(chan *InclusionAlert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := new(InclusionAlert)
+		if err := json.NewDecoder(r.Body).Decode(alert); err != nil {
+			t.Errorf("invalid alert: %v", err)
+		}
+		posted <- alert
+	}))
+	defer server.Close()
+
+	m := newInclusionMonitor(InclusionSLAConfig{SLA: 5 * time.Second, Hook: server.URL}, new(event.TypeMux))
+	var (
+		start   = time.Now()
+		fast    = types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		late    = types.NewTransaction(1, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		pending = types.NewTransaction(2, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	)
+	for _, tx := range []*types.Transaction{fast, late, pending} {
+		m.track(tx.Hash(), start)
+	}
+	alerts := func(want int) []*InclusionAlert {
+		var have []*InclusionAlert
+		for len(m.alerts) > 0 {
+			have = append(have, <-m.alerts)
+		}
+		if len(have) != want {
+			t.Fatalf("alerts mismatch: have %d, want %d", len(have), want)
+		}
+		return have
+	}
+
+	m.include(types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{fast}, nil, nil), start.Add(time.Second))
+	alerts(0)
+
+	m.include(types.NewBlock(&types.Header{Number: big.NewInt(2)}, []*types.Transaction{late}, nil, nil), start.Add(7*time.Second))
+	if alert := alerts(1)[0]; alert.TxHash != late.Hash() || !alert.Included || *alert.BlockNumber != 2 || alert.Latency != 7 {
+		t.Errorf("late inclusion alert mismatch: %+v", alert)
+	}
+	m.check(start.Add(6 * time.Second))
+	alert := alerts(1)[0]
+	if alert.TxHash != pending.Hash() || alert.Included || alert.BlockNumber != nil {
+		t.Errorf("pending alert mismatch: %+v", alert)
+	}
+	// Alerted transactions don't raise another alert once included
+	m.include(types.NewBlock(&types.Header{Number: big.NewInt(3)}, []*types.Transaction{pending}, nil, nil), start.Add(8*time.Second))
+	alerts(0)
+
+	status := m.status()
+	if status.Pending != 0 || status.Included != 3 || status.Breaches != 2 || status.SLA != 5000 {
+		t.Errorf("status mismatch: %+v", status)
+	}
+	if status.P50 != 7000 || status.P99 != 8000 {
+		t.Errorf("percentiles mismatch: p50 %d, p99 %d", status.P50, status.P99)
+	}
+
+	if err := m.deliver(alert); err != nil {
+		t.Fatal(err)
+	}
+	if have := <-posted; have.TxHash != alert.TxHash || have.Latency != alert.Latency || have.SLA != 5 {
+		t.Errorf("posted alert mismatch: have %+v, want %+v", have, alert)
+	}
+}
@@ -54,6 +54,9 @@ var (
 	miscInTrafficMeter        = metrics.NewMeter("eth/misc/in/traffic")
 	miscOutPacketsMeter       = metrics.NewMeter("eth/misc/out/packets")
 	miscOutTrafficMeter       = metrics.NewMeter("eth/misc/out/traffic")
+	configMismatchMeter       = metrics.NewMeter("eth/peers/configmismatch")
+	propBlockLagTimer         = metrics.NewTimer("eth/prop/blocks/lag")
+	propBlockDelayTimer       = metrics.NewTimer("eth/prop/blocks/delay")
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -47,9 +47,10 @@ const (
 // PeerInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about a connected peer.
 type PeerInfo struct {
-	Version    int      `json:"version"`    // Ethereum protocol version negotiated
-	Difficulty *big.Int `json:"difficulty"` // Total difficulty of the peer's blockchain
-	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
+	Version    int      `json:"version"`          // Ethereum protocol version negotiated
+	Difficulty *big.Int `json:"difficulty"`       // Total difficulty of the peer's blockchain
+	Head       string   `json:"head"`             // SHA3 hash of the peer's best owned block
+	Config     string   `json:"config,omitempty"` // Result of the chain config fingerprint check, if enabled
 }
 
 type peer struct {
@@ -65,6 +66,9 @@ type peer struct {
 	td   *big.Int
 	lock sync.RWMutex
 
+	fingerprint *common.Hash // Chain config fingerprint sent in the handshake, if any
+	config      string       // Result of the chain config fingerprint check
+
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
 }
@@ -90,6 +94,7 @@ func (p *peer) Info() *PeerInfo {
 		Version:    p.version,
 		Difficulty: td,
 		Head:       hash.Hex(),
+		Config:     p.config,
 	}
 }
 
@@ -232,19 +237,23 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 
 // Handshake executes the eth protocol handshake, negotiating version number,
 // network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network int, td *big.Int, head common.Hash, genesis common.Hash) error {
+func (p *peer) Handshake(network int, td *big.Int, head common.Hash, genesis common.Hash, fingerprint *common.Hash) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 	var status statusData // safe to read after two values have been received from errc
 
 	go func() {
-		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
+		out := &statusData{
 			ProtocolVersion: uint32(p.version),
 			NetworkId:       uint32(network),
 			TD:              td,
 			CurrentBlock:    head,
 			GenesisBlock:    genesis,
-		})
+		}
+		if fingerprint != nil {
+			out.Fingerprint = []common.Hash{*fingerprint}
+		}
+		errc <- p2p.Send(p.rw, StatusMsg, out)
 	}()
 	go func() {
 		errc <- p.readStatus(network, &status, genesis)
@@ -262,6 +271,9 @@ func (p *peer) Handshake(network int, td *big.Int, head common.Hash, genesis com
 		}
 	}
 	p.td, p.head = status.TD, status.CurrentBlock
+	if len(status.Fingerprint) > 0 {
+		p.fingerprint = &status.Fingerprint[0]
+	}
 	return nil
 }
 
@@ -359,6 +371,18 @@ func (ps *peerSet) Len() int {
 	return len(ps.peers)
 }
 
+// Versions returns the number of peers speaking each eth protocol version.
+func (ps *peerSet) Versions() map[int]int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	versions := make(map[int]int)
+	for _, p := range ps.peers {
+		versions[p.version]++
+	}
+	return versions
+}
+
 // PeersWithoutBlock retrieves a list of peers that do not have a given block in
 // their set of known hashes.
 func (ps *peerSet) PeersWithoutBlock(hash common.Hash) []*peer {
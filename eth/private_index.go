@@ -0,0 +1,331 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// The private contract index records which local keys of the privacy manager
+// are parties to the private contracts the node is a party to:
+//
+//	privateIndexHeadKey                    -> privateIndexHead
+//	privateIndexContractsKey               -> []common.Address, the indexed contracts
+//	privateIndexContractPrefix + address   -> PrivateContract
+var (
+	privateIndexHeadKey        = []byte("privindex-head")
+	privateIndexContractsKey   = []byte("privindex-contracts")
+	privateIndexContractPrefix = []byte("privindex-c")
+)
+
+// privateIndexHead is the last block covered by the private contract index.
+type privateIndexHead struct {
+	Next uint64      // First block not indexed yet
+	Hash common.Hash // Hash of the last indexed block, to detect reorgs
+}
+
+// PrivateContract is a private contract the node is a party to.
+type PrivateContract struct {
+	Address     common.Address `json:"address"`
+	Parties     []string       `json:"parties"` // Local public keys of the privacy manager which are parties
+	TxHash      common.Hash    `json:"transactionHash"`
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+}
+
+// privateIndex maintains the private contract index. The parties of a contract
+// are those of the transaction creating it, looked up once as the block is
+// indexed, so queries don't have to retrieve payloads from the privacy manager.
+type privateIndex struct {
+	db      ethdb.Database
+	mux     *event.TypeMux
+	parties func(digest []byte) ([]string, error) // Local keys which are recipients of a payload
+
+	lock sync.Mutex
+	head privateIndexHead
+
+	wake chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPrivateIndex(db ethdb.Database, mux *event.TypeMux, parties func([]byte) ([]string, error)) *privateIndex {
+	idx := &privateIndex{
+		db:      db,
+		mux:     mux,
+		parties: parties,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+	if blob, _ := db.Get(privateIndexHeadKey); len(blob) > 0 {
+		if err := rlp.DecodeBytes(blob, &idx.head); err != nil {
+			glog.V(logger.Error).Infof("Invalid private contract index head, reindexing: %v", err)
+			idx.head = privateIndexHead{}
+		}
+	}
+	return idx
+}
+
+// start indexes the blocks imported since the index was last updated and keeps
+// the index up to date with the chain in the background.
+func (idx *privateIndex) start() {
+	sub := idx.mux.Subscribe(core.ChainHeadEvent{})
+
+	idx.wg.Add(2)
+	go func() {
+		defer idx.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case _, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				select {
+				case idx.wake <- struct{}{}:
+				default:
+				}
+			case <-idx.quit:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer idx.wg.Done()
+
+		idx.update()
+		for {
+			select {
+			case <-idx.wake:
+				idx.update()
+			case <-idx.quit:
+				return
+			}
+		}
+	}()
+}
+
+// stop terminates the background indexing.
+func (idx *privateIndex) stop() {
+	close(idx.quit)
+	idx.wg.Wait()
+}
+
+// update indexes the canonical blocks after the last indexed one. After a reorg
+// indexing resumes from the common ancestor, contracts created in the blocks
+// reorganised away are skipped by the queries.
+func (idx *privateIndex) update() {
+	idx.lock.Lock()
+	defer idx.lock.Unlock()
+
+	if idx.head.Next > 0 && core.GetCanonicalHash(idx.db, idx.head.Next-1) != idx.head.Hash {
+		next, hash := uint64(0), common.Hash{}
+		for header := core.GetHeader(idx.db, idx.head.Hash, idx.head.Next-1); header != nil; header = core.GetHeader(idx.db, header.ParentHash, header.Number.Uint64()-1) {
+			if core.GetCanonicalHash(idx.db, header.Number.Uint64()) == header.Hash() {
+				next, hash = header.Number.Uint64()+1, header.Hash()
+				break
+			}
+			if header.Number.Sign() == 0 {
+				break
+			}
+		}
+		glog.V(logger.Info).Infof("Private contract index reorged from block #%d to #%d", idx.head.Next, next)
+		idx.head = privateIndexHead{Next: next, Hash: hash}
+	}
+	head := core.GetBlockNumber(idx.db, core.GetHeadBlockHash(idx.db))
+	if head == ^uint64(0) {
+		return
+	}
+	for ; idx.head.Next <= head; idx.head.Next++ {
+		select {
+		case <-idx.quit:
+			return
+		default:
+		}
+		hash := core.GetCanonicalHash(idx.db, idx.head.Next)
+		block := core.GetBlock(idx.db, hash, idx.head.Next)
+		if block == nil {
+			glog.V(logger.Error).Infof("Failed to index private contracts: block #%d not found", idx.head.Next)
+			return
+		}
+		if err := idx.indexBlock(block); err != nil {
+			glog.V(logger.Warn).Infof("Failed to index private contracts of block #%d, retrying on the next block: %v", block.NumberU64(), err)
+			return
+		}
+		idx.head.Hash = hash
+		blob, _ := rlp.EncodeToBytes(&privateIndexHead{Next: idx.head.Next + 1, Hash: hash})
+		if err := idx.db.Put(privateIndexHeadKey, blob); err != nil {
+			glog.Fatalf("failed to store private contract index head: %v", err)
+		}
+	}
+}
+
+// indexBlock records the private contracts created in a block which exist in
+// the private state of the node, and the local keys which are parties to them.
+func (idx *privateIndex) indexBlock(block *types.Block) error {
+	var privateState *state.StateDB
+	for _, tx := range block.Transactions() {
+		if !tx.IsPrivate() || tx.To() != nil {
+			continue
+		}
+		if privateState == nil {
+			var err error
+			if privateState, err = state.New(core.GetPrivateStateRoot(idx.db, block.Root()), idx.db); err != nil {
+				return err
+			}
+		}
+		from, err := tx.From()
+		if err != nil {
+			continue
+		}
+		addr := crypto.CreateAddress(from, tx.Nonce())
+		if len(privateState.GetCode(addr)) == 0 {
+			continue // Not a party
+		}
+		parties, err := idx.parties(tx.Data())
+		if err != nil {
+			return err
+		}
+		contract := &PrivateContract{
+			Address:     addr,
+			Parties:     parties,
+			TxHash:      tx.Hash(),
+			BlockNumber: block.NumberU64(),
+			BlockHash:   block.Hash(),
+		}
+		if err := idx.writeContract(contract); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (idx *privateIndex) writeContract(contract *PrivateContract) error {
+	existing := readPrivateContract(idx.db, contract.Address)
+	blob, err := rlp.EncodeToBytes(contract)
+	if err != nil {
+		return err
+	}
+	if err := idx.db.Put(append(append([]byte{}, privateIndexContractPrefix...), contract.Address[:]...), blob); err != nil {
+		return err
+	}
+	// Contracts created again after a reorg are listed already
+	if existing != nil {
+		return nil
+	}
+	blob, err = rlp.EncodeToBytes(append(readPrivateContracts(idx.db), contract.Address))
+	if err != nil {
+		return err
+	}
+	return idx.db.Put(privateIndexContractsKey, blob)
+}
+
+// contract returns the index entry of a private contract, or nil if the node
+// isn't a party to a contract created at the address in the canonical chain.
+func (idx *privateIndex) contract(addr common.Address) *PrivateContract {
+	contract := readPrivateContract(idx.db, addr)
+	if contract == nil || core.GetCanonicalHash(idx.db, contract.BlockNumber) != contract.BlockHash {
+		return nil
+	}
+	return contract
+}
+
+// contracts returns the indexed private contracts a key is a party to, all of
+// them if the key is empty.
+func (idx *privateIndex) contracts(key string) []*PrivateContract {
+	contracts := []*PrivateContract{}
+	for _, addr := range readPrivateContracts(idx.db) {
+		contract := idx.contract(addr)
+		if contract == nil {
+			continue
+		}
+		if key == "" {
+			contracts = append(contracts, contract)
+			continue
+		}
+		for _, party := range contract.Parties {
+			if party == key {
+				contracts = append(contracts, contract)
+				break
+			}
+		}
+	}
+	return contracts
+}
+
+// PublicPrivateContractAPI answers which local keys of the privacy manager are
+// parties to which private contracts.
+type PublicPrivateContractAPI struct {
+	index *privateIndex
+}
+
+// NewPublicPrivateContractAPI returns a new PublicPrivateContractAPI instance.
+func NewPublicPrivateContractAPI(index *privateIndex) *PublicPrivateContractAPI {
+	return &PublicPrivateContractAPI{index: index}
+}
+
+// PrivateContract returns the local keys which are parties to the private
+// contract at the given address, or nil if the node isn't a party to it.
+func (api *PublicPrivateContractAPI) PrivateContract(addr common.Address) *PrivateContract {
+	return api.index.contract(addr)
+}
+
+// PrivateContracts returns the private contracts the given local key is a party
+// to, or all private contracts the node is a party to without a key.
+func (api *PublicPrivateContractAPI) PrivateContracts(key *string) []*PrivateContract {
+	if key == nil {
+		return api.index.contracts("")
+	}
+	return api.index.contracts(*key)
+}
+
+func readPrivateContract(db ethdb.Database, addr common.Address) *PrivateContract {
+	blob, _ := db.Get(append(append([]byte{}, privateIndexContractPrefix...), addr[:]...))
+	if len(blob) == 0 {
+		return nil
+	}
+	contract := new(PrivateContract)
+	if err := rlp.DecodeBytes(blob, contract); err != nil {
+		glog.V(logger.Error).Infof("Invalid private contract index entry of %x: %v", addr, err)
+		return nil
+	}
+	return contract
+}
+
+func readPrivateContracts(db ethdb.Database) []common.Address {
+	blob, _ := db.Get(privateIndexContractsKey)
+	if len(blob) == 0 {
+		return nil
+	}
+	var addrs []common.Address
+	if err := rlp.DecodeBytes(blob, &addrs); err != nil {
+		glog.V(logger.Error).Infof("Invalid private contract index: %v", err)
+		return nil
+	}
+	return addrs
+}
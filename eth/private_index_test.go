@@ -0,0 +1,109 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// writeCanonicalBlock stores a block with the given transactions as the
+// canonical block at its number and as the chain head.
+func writeCanonicalBlock(t *testing.T, db ethdb.Database, number int64, root common.Hash, txs []*types.Transaction) *types.Block {
+	block := types.NewBlock(&types.Header{Number: big.NewInt(number), Root: root}, txs, nil, nil)
+	if err := core.WriteBlock(db, block); err != nil {
+		t.Fatalf("failed to write block: %v", err)
+	}
+	if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+		t.Fatalf("failed to write canonical hash: %v", err)
+	}
+	if err := core.WriteHeadBlockHash(db, block.Hash()); err != nil {
+		t.Fatalf("failed to write head block hash: %v", err)
+	}
+	return block
+}
+
+// Tests that the private contracts the node is a party to are indexed with the
+// local keys which are parties, and that contracts reorganised away are dropped.
+func TestPrivateIndex(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	// A party to the first contract only, which exists in the private state
+	create := func(nonce uint64) *types.Transaction {
+		tx, _ := types.NewContractCreation(nonce, new(big.Int), big.NewInt(100000), new(big.Int), []byte{byte(nonce)}).SignECDSA(key)
+		tx.SetPrivate()
+		return tx
+	}
+	party, other := crypto.CreateAddress(from, 0), crypto.CreateAddress(from, 1)
+
+	privateState, _ := state.New(common.Hash{}, db)
+	privateState.SetCode(party, []byte{0x00})
+	privateRoot, _ := privateState.Commit()
+
+	root := common.Hash{1}
+	if err := core.WritePrivateStateRoot(db, root, privateRoot); err != nil {
+		t.Fatalf("failed to write private state root: %v", err)
+	}
+	writeCanonicalBlock(t, db, 0, common.Hash{}, nil)
+	block := writeCanonicalBlock(t, db, 1, root, []*types.Transaction{create(0), create(1)})
+
+	idx := newPrivateIndex(db, new(event.TypeMux), func(digest []byte) ([]string, error) {
+		return []string{"key" + string('0'+digest[0])}, nil
+	})
+	idx.update()
+
+	contract := idx.contract(party)
+	if contract == nil {
+		t.Fatalf("private contract not indexed")
+	}
+	if len(contract.Parties) != 1 || contract.Parties[0] != "key0" || contract.BlockHash != block.Hash() {
+		t.Errorf("private contract mismatch: have %+v", contract)
+	}
+	if idx.contract(other) != nil {
+		t.Errorf("contract of other parties indexed")
+	}
+	if contracts := idx.contracts("key0"); len(contracts) != 1 || contracts[0].Address != party {
+		t.Errorf("contracts of key0 mismatch: have %v", contracts)
+	}
+	if contracts := idx.contracts("key1"); len(contracts) != 0 {
+		t.Errorf("contracts of key1 mismatch: have %v", contracts)
+	}
+
+	// Reorganise the contract creation away
+	writeCanonicalBlock(t, db, 1, common.Hash{2}, nil)
+	idx.update()
+
+	if idx.contract(party) != nil {
+		t.Errorf("contract reorganised away still indexed")
+	}
+	if contracts := idx.contracts(""); len(contracts) != 0 {
+		t.Errorf("contracts mismatch after reorg: have %v", contracts)
+	}
+	if idx.head.Next != 2 {
+		t.Errorf("index head mismatch: have %d, want 2", idx.head.Next)
+	}
+}
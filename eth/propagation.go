@@ -0,0 +1,213 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// propagationWindow is how long the first sighting of a block is kept to
+	// measure the lag of later announcements against.
+	propagationWindow = 5 * time.Minute
+
+	// maxTrackedBlocks bounds the number of blocks sightings are kept of.
+	maxTrackedBlocks = 4096
+)
+
+// PropagationStats summarizes how quickly a peer announces new blocks.
+//
+// The lag of an announcement is the time since the block was first seen from
+// any peer, or created by this node. It doesn't depend on clock
+// synchronisation, so it locates slow peers reliably. The delay is the time
+// since the timestamp of the block, only known for propagated blocks, not for
+// announced hashes. It includes the clock offset between the nodes and is
+// limited to the one second resolution of block timestamps.
+type PropagationStats struct {
+	Announcements uint64  `json:"announcements"` // Blocks announced or propagated by the peer
+	First         uint64  `json:"first"`         // Blocks the peer was the first to announce
+	AvgLag        float64 `json:"avgLagMs"`
+	MaxLag        float64 `json:"maxLagMs"`
+	LastLag       float64 `json:"lastLagMs"`
+	Delays        uint64  `json:"delays"` // Propagated blocks the delay was measured for
+	AvgDelay      float64 `json:"avgDelayMs"`
+	MaxDelay      float64 `json:"maxDelayMs"`
+	LastDelay     float64 `json:"lastDelayMs"`
+}
+
+// PeerPropagation is the block propagation summary of a connected peer.
+type PeerPropagation struct {
+	Name string `json:"name"`
+	*PropagationStats
+}
+
+// propagationTracker measures the block propagation latency of every peer.
+type propagationTracker struct {
+	mu        sync.Mutex
+	firstSeen map[common.Hash]time.Time
+	peers     map[string]*peerPropagation
+}
+
+type peerPropagation struct {
+	announcements, first, delays  uint64
+	lagSum, maxLag, lastLag       time.Duration
+	delaySum, maxDelay, lastDelay time.Duration
+}
+
+func newPropagationTracker() *propagationTracker {
+	return &propagationTracker{
+		firstSeen: make(map[common.Hash]time.Time),
+		peers:     make(map[string]*peerPropagation),
+	}
+}
+
+// created records a block created by this node, which is seen before any peer
+// announces it.
+func (t *propagationTracker) created(hash common.Hash, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.see(hash, now)
+}
+
+// see records the first sighting of a block and returns it.
+func (t *propagationTracker) see(hash common.Hash, now time.Time) time.Time {
+	if first, ok := t.firstSeen[hash]; ok {
+		return first
+	}
+	if len(t.firstSeen) >= maxTrackedBlocks {
+		for h, seen := range t.firstSeen {
+			if now.Sub(seen) > propagationWindow {
+				delete(t.firstSeen, h)
+			}
+		}
+		// Drop arbitrary blocks if all of them are recent
+		for h := range t.firstSeen {
+			if len(t.firstSeen) < maxTrackedBlocks {
+				break
+			}
+			delete(t.firstSeen, h)
+		}
+	}
+	t.firstSeen[hash] = now
+	return now
+}
+
+// announced records a block announced by a peer at the given time. The block
+// timestamp is nil for announced hashes.
+func (t *propagationTracker) announced(peer string, hash common.Hash, timestamp *big.Int, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.peers[peer]
+	if stats == nil {
+		stats = new(peerPropagation)
+		t.peers[peer] = stats
+	}
+	first := t.see(hash, now)
+	lag := now.Sub(first)
+	if lag == 0 {
+		stats.first++
+	}
+	stats.announcements++
+	stats.lagSum += lag
+	stats.lastLag = lag
+	if lag > stats.maxLag {
+		stats.maxLag = lag
+	}
+	propBlockLagTimer.Update(lag)
+
+	if timestamp == nil {
+		return
+	}
+	delay := now.Sub(time.Unix(timestamp.Int64(), 0))
+	if delay < 0 {
+		delay = 0 // clock of the block creator is ahead
+	}
+	stats.delays++
+	stats.delaySum += delay
+	stats.lastDelay = delay
+	if delay > stats.maxDelay {
+		stats.maxDelay = delay
+	}
+	propBlockDelayTimer.Update(delay)
+}
+
+// remove drops the statistics of a disconnected peer.
+func (t *propagationTracker) remove(peer string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.peers, peer)
+}
+
+// stats returns the statistics of a peer, nil if it hasn't announced any block.
+func (t *propagationTracker) stats(peer string) *PropagationStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p := t.peers[peer]
+	if p == nil {
+		return nil
+	}
+	stats := &PropagationStats{
+		Announcements: p.announcements,
+		First:         p.first,
+		AvgLag:        milliseconds(p.lagSum / time.Duration(p.announcements)),
+		MaxLag:        milliseconds(p.maxLag),
+		LastLag:       milliseconds(p.lastLag),
+		Delays:        p.delays,
+	}
+	if p.delays > 0 {
+		stats.AvgDelay = milliseconds(p.delaySum / time.Duration(p.delays))
+		stats.MaxDelay = milliseconds(p.maxDelay)
+		stats.LastDelay = milliseconds(p.lastDelay)
+	}
+	return stats
+}
+
+// propagationStats returns the block propagation summary of every connected
+// peer which has announced a block, keyed by peer id.
+func (pm *ProtocolManager) propagationStats() map[string]*PeerPropagation {
+	t := pm.propagation
+	t.mu.Lock()
+	ids := make([]string, 0, len(t.peers))
+	for id := range t.peers {
+		ids = append(ids, id)
+	}
+	t.mu.Unlock()
+
+	result := make(map[string]*PeerPropagation)
+	for _, id := range ids {
+		p := pm.peers.Peer(id)
+		if p == nil {
+			continue
+		}
+		if stats := t.stats(id); stats != nil {
+			result[id] = &PeerPropagation{Name: p.Name(), PropagationStats: stats}
+		}
+	}
+	return result
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that announcement lags are measured against the first sighting of a
+// block and delays against its timestamp.
+func TestPropagationTracker(t *testing.T) {
+	var (
+		tracker = newPropagationTracker()
+		start   = time.Unix(1000, 0)
+		ms      = time.Millisecond
+	)
+	// Block 1 is announced by a, then propagated by b and c
+	tracker.announced("a", common.Hash{1}, nil, start)
+	tracker.announced("b", common.Hash{1}, big.NewInt(1000), start.Add(100*ms))
+	tracker.announced("c", common.Hash{1}, big.NewInt(1000), start.Add(300*ms))
+
+	// Block 2 is created locally, then propagated by c and announced by a
+	tracker.created(common.Hash{2}, start.Add(time.Second))
+	tracker.announced("c", common.Hash{2}, big.NewInt(1001), start.Add(time.Second+500*ms))
+	tracker.announced("a", common.Hash{2}, nil, start.Add(time.Second+20*ms))
+
+	tests := map[string]*PropagationStats{
+		"a": {Announcements: 2, First: 1, AvgLag: 10, MaxLag: 20, LastLag: 20},
+		"b": {Announcements: 1, AvgLag: 100, MaxLag: 100, LastLag: 100, Delays: 1, AvgDelay: 100, MaxDelay: 100, LastDelay: 100},
+		"c": {Announcements: 2, AvgLag: 400, MaxLag: 500, LastLag: 500, Delays: 2, AvgDelay: 400, MaxDelay: 500, LastDelay: 500},
+	}
+	for peer, want := range tests {
+		if have := tracker.stats(peer); !reflect.DeepEqual(have, want) {
+			t.Errorf("peer %s: stats mismatch: have %+v, want %+v", peer, have, want)
+		}
+	}
+	tracker.remove("a")
+	if stats := tracker.stats("a"); stats != nil {
+		t.Errorf("stats of removed peer: %+v", stats)
+	}
+}
+
+// Tests that the number of tracked blocks stays bounded.
+func TestPropagationTrackerLimit(t *testing.T) {
+	tracker := newPropagationTracker()
+	start := time.Unix(1000, 0)
+
+	for i := 0; i < 2*maxTrackedBlocks; i++ {
+		tracker.created(common.BigToHash(big.NewInt(int64(i))), start.Add(time.Duration(i)*time.Second))
+	}
+	if n := len(tracker.firstSeen); n > maxTrackedBlocks {
+		t.Errorf("tracked blocks: have %d, want at most %d", n, maxTrackedBlocks)
+	}
+}
@@ -77,6 +77,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrConfigMismatch
 )
 
 func (e errCode) String() string {
@@ -94,6 +95,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrConfigMismatch:          "Chain config mismatch",
 }
 
 type txPool interface {
@@ -112,6 +114,9 @@ type statusData struct {
 	TD              *big.Int
 	CurrentBlock    common.Hash
 	GenesisBlock    common.Hash
+
+	// Fingerprint of the chain config, only sent if config checks are enabled
+	Fingerprint []common.Hash `rlp:"tail"`
 }
 
 // newBlockHashesData is the network packet for the block announcements.
@@ -0,0 +1,199 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// slowTxContracts is the number of contracts a slow transaction report lists.
+const slowTxContracts = 5
+
+// slowTxSampler traces the transactions the chain reports as slow and logs the
+// contracts their execution time was spent in, to find the contracts degrading
+// block times. Transactions are traced in the background, reports arriving
+// while the sampler is busy are dropped, oldest first.
+type slowTxSampler struct {
+	bc     *core.BlockChain
+	config *core.ChainConfig
+	mux    *event.TypeMux
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newSlowTxSampler(bc *core.BlockChain, config *core.ChainConfig, mux *event.TypeMux) *slowTxSampler {
+	return &slowTxSampler{bc: bc, config: config, mux: mux, quit: make(chan struct{})}
+}
+
+func (s *slowTxSampler) start() {
+	sub := s.mux.SubscribeWith(event.SubscriptionConfig{Name: "eth/slowtx", Buffer: 16, Overflow: event.DropOldest}, core.SlowTxEvent{})
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				s.sample(ev.Data.(core.SlowTxEvent))
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+func (s *slowTxSampler) stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// sample traces a slow transaction and logs where its time was spent.
+func (s *slowTxSampler) sample(ev core.SlowTxEvent) {
+	to := "contract creation"
+	if ev.Tx.To() != nil {
+		to = ev.Tx.To().Hex()
+	}
+	glog.V(logger.Warn).Infof("Slow transaction %x in block #%d [%x…]: %v, %v gas, to %s", ev.Tx.Hash(), ev.Block.NumberU64(), ev.Block.Hash().Bytes()[:4], common.PrettyDuration(ev.Duration), ev.GasUsed, to)
+
+	profile, err := s.trace(ev)
+	if err != nil {
+		glog.V(logger.Warn).Infof("Failed to trace slow transaction %x: %v", ev.Tx.Hash(), err)
+		return
+	}
+	glog.V(logger.Warn).Infof("Slow transaction %x trace:\n%s", ev.Tx.Hash(), profile)
+}
+
+// trace executes a transaction again on the state it was executed on and
+// profiles the contracts it ran.
+func (s *slowTxSampler) trace(ev core.SlowTxEvent) (*txProfile, error) {
+	publicState, privateState, err := computeTxState(s.bc, s.config, ev.Block, ev.Index)
+	if err != nil {
+		return nil, err
+	}
+	publicState.StartRecord(ev.Tx.Hash(), ev.Block.Hash(), ev.Index)
+	privateState.StartRecord(ev.Tx.Hash(), ev.Block.Hash(), ev.Index)
+
+	profile := newTxProfile()
+	gp := new(core.GasPool).AddGas(ev.Block.GasLimit())
+	if _, _, _, err := core.ApplyTransaction(s.config, s.bc, gp, publicState, privateState, ev.Block.Header(), ev.Tx, new(big.Int), vm.Config{Debug: true, Tracer: profile}); err != nil {
+		return nil, err
+	}
+	profile.finish()
+	return profile, nil
+}
+
+// contractProfile is the execution of a transaction spent in a contract.
+type contractProfile struct {
+	address common.Address
+	time    time.Duration
+	ops     uint64
+	gas     uint64
+	sloads  uint64
+	sstores uint64
+	calls   uint64
+}
+
+// txProfile is a vm.Tracer attributing the time, gas and instructions of a
+// transaction to the contracts whose code executed them. The time includes the
+// overhead of tracing, so only the shares of the contracts are meaningful.
+type txProfile struct {
+	contracts map[common.Address]*contractProfile
+	last      *contractProfile
+	lastTime  time.Time
+	total     time.Duration
+}
+
+func newTxProfile() *txProfile {
+	return &txProfile{contracts: make(map[common.Address]*contractProfile)}
+}
+
+func (p *txProfile) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	now := time.Now()
+	p.finish()
+
+	// Delegated calls run the code of a library on the storage of the caller
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	c := p.contracts[addr]
+	if c == nil {
+		c = &contractProfile{address: addr}
+		p.contracts[addr] = c
+	}
+	c.ops++
+	if cost != nil {
+		c.gas += cost.Uint64()
+	}
+	switch op {
+	case vm.SLOAD:
+		c.sloads++
+	case vm.SSTORE:
+		c.sstores++
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.CREATE:
+		c.calls++
+	}
+	p.last, p.lastTime = c, now
+}
+
+// finish attributes the time since the last instruction to its contract.
+func (p *txProfile) finish() {
+	if p.last != nil {
+		elapsed := time.Since(p.lastTime)
+		p.last.time += elapsed
+		p.total += elapsed
+		p.last = nil
+	}
+}
+
+// String lists the contracts the most time was spent in.
+func (p *txProfile) String() string {
+	contracts := make([]*contractProfile, 0, len(p.contracts))
+	for _, c := range p.contracts {
+		contracts = append(contracts, c)
+	}
+	sort.Slice(contracts, func(i, j int) bool { return contracts[i].time > contracts[j].time })
+	if len(contracts) > slowTxContracts {
+		contracts = contracts[:slowTxContracts]
+	}
+	var lines []string
+	for _, c := range contracts {
+		share := 0.0
+		if p.total > 0 {
+			share = float64(c.time) / float64(p.total) * 100
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %5.1f%% of the time, %d instructions, %d gas, %d SLOAD, %d SSTORE, %d calls", c.address.Hex(), share, c.ops, c.gas, c.sloads, c.sstores, c.calls))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+type profileRef common.Address
+
+func (r profileRef) ReturnGas(*big.Int, *big.Int)                     {}
+func (r profileRef) Address() common.Address                          { return common.Address(r) }
+func (r profileRef) Value() *big.Int                                  { return new(big.Int) }
+func (r profileRef) SetCode(common.Hash, []byte)                      {}
+func (r profileRef) ForEachStorage(func(key, value common.Hash) bool) {}
+
+// Tests that the slow transaction profile attributes instructions to the
+// contracts whose code ran them, libraries included.
+func TestTxProfile(t *testing.T) {
+	var (
+		caller   = profileRef(common.HexToAddress("0x01"))
+		contract = common.HexToAddress("0x02")
+		library  = common.HexToAddress("0x03")
+	)
+	outer := vm.NewContract(caller, profileRef(contract), new(big.Int), big.NewInt(100000), new(big.Int))
+	inner := vm.NewContract(outer, profileRef(contract), new(big.Int), big.NewInt(100000), new(big.Int))
+	inner.CodeAddr = &library
+
+	profile := newTxProfile()
+	ops := []struct {
+		op       vm.OpCode
+		contract *vm.Contract
+	}{
+		{vm.SLOAD, outer}, {vm.SSTORE, outer}, {vm.DELEGATECALL, outer},
+		{vm.SLOAD, inner}, {vm.SLOAD, inner}, {vm.STOP, inner},
+	}
+	for i, op := range ops {
+		profile.CaptureState(nil, uint64(i), op.op, new(big.Int), big.NewInt(10), nil, nil, op.contract, 1, nil)
+	}
+	profile.finish()
+
+	if c := profile.contracts[contract]; c == nil || c.ops != 3 || c.gas != 30 || c.sloads != 1 || c.sstores != 1 || c.calls != 1 {
+		t.Errorf("contract profile mismatch: %+v", c)
+	}
+	if c := profile.contracts[library]; c == nil || c.ops != 3 || c.sloads != 2 || c.sstores != 0 {
+		t.Errorf("library profile mismatch: %+v", c)
+	}
+	if len(profile.contracts) != 2 {
+		t.Errorf("profiled %d contracts, want 2", len(profile.contracts))
+	}
+	if report := profile.String(); strings.Count(report, "\n") != 1 || !strings.Contains(report, library.Hex()) {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/logger"
@@ -200,6 +201,39 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		if pm.blockchain.CurrentBlock().NumberU64() > 0 {
 			glog.V(logger.Info).Infof("fast sync complete, auto disabling")
 			atomic.StoreUint32(&pm.fastSync, 0)
+			pm.recordCheckpoint()
 		}
 	}
 }
+
+// setCheckpoint makes the initial sync of an empty chain fast sync to the
+// trusted checkpoint block, only fully validating the blocks after it. Chains
+// which already have blocks ignore the checkpoint.
+func (pm *ProtocolManager) setCheckpoint(hash common.Hash) {
+	if head := pm.blockchain.CurrentBlock(); head.NumberU64() > 0 {
+		glog.V(logger.Warn).Infof("Blockchain not empty (head #%d), ignoring trusted checkpoint %x", head.NumberU64(), hash)
+		return
+	}
+	glog.V(logger.Info).Infof("Initial sync trusts checkpoint %x", hash)
+	pm.checkpoint = hash
+	pm.downloader.SetCheckpoint(hash)
+	atomic.StoreUint32(&pm.fastSync, 1)
+}
+
+// recordCheckpoint keeps an audit trail of the trusted checkpoint once the chain
+// was synced to it.
+func (pm *ProtocolManager) recordCheckpoint() {
+	if pm.checkpoint == (common.Hash{}) {
+		return
+	}
+	header := pm.blockchain.GetHeaderByHash(pm.checkpoint)
+	if header == nil {
+		return
+	}
+	checkpoint := &core.TrustedCheckpoint{Number: header.Number.Uint64(), Hash: pm.checkpoint, Time: time.Now()}
+	if err := core.WriteTrustedCheckpoint(pm.chaindb, checkpoint); err != nil {
+		glog.V(logger.Error).Infof("Failed to record trusted checkpoint #%d [%x…]: %v", checkpoint.Number, checkpoint.Hash[:4], err)
+		return
+	}
+	glog.V(logger.Info).Infof("Synced to trusted checkpoint #%d [%x…], blocks up to it weren't validated", checkpoint.Number, checkpoint.Hash[:4])
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var versionRejectMeter = metrics.NewMeter("eth/versions/rejected")
+
+// validateMinProtocolVersion checks that a minimum eth protocol version is one
+// we speak, so the node can't lock itself out of the network. Zero admits any.
+func validateMinProtocolVersion(version uint) error {
+	if version == 0 {
+		return nil
+	}
+	for _, v := range ProtocolVersions {
+		if v == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported minimum eth protocol version %d, want one of %v", version, ProtocolVersions)
+}
+
+// PeerVersions is the eth protocol version distribution reported in the node
+// info, keyed on the version.
+type PeerVersions struct {
+	Minimum   uint           `json:"minimum"`   // Lowest version admitted, 0 if any
+	Connected map[int]int    `json:"connected"` // Peers connected with each version
+	Rejected  map[int]uint64 `json:"rejected"`  // Peers disconnected for speaking each version
+}
+
+// versionTracker enforces the minimum eth protocol version and counts the
+// peers rejected for speaking an older one.
+type versionTracker struct {
+	minimum uint
+
+	lock     sync.Mutex
+	rejected map[int]uint64
+}
+
+func newVersionTracker(minimum uint) *versionTracker {
+	return &versionTracker{minimum: minimum, rejected: make(map[int]uint64)}
+}
+
+// check rejects a peer speaking a version below the minimum. As both sides pick
+// the highest version they share, such a peer doesn't support the minimum.
+func (t *versionTracker) check(p *peer) error {
+	if uint(p.version) >= t.minimum {
+		return nil
+	}
+	t.lock.Lock()
+	t.rejected[p.version]++
+	t.lock.Unlock()
+
+	versionRejectMeter.Mark(1)
+	glog.V(logger.Debug).Infof("%v: eth/%d below minimum eth/%d", p, p.version, t.minimum)
+	return errResp(ErrProtocolVersionMismatch, "eth/%d (< eth/%d)", p.version, t.minimum)
+}
+
+// report returns the versions of the connected peers and those rejected.
+func (t *versionTracker) report(peers *peerSet) *PeerVersions {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	report := &PeerVersions{
+		Minimum:   t.minimum,
+		Connected: peers.Versions(),
+		Rejected:  make(map[int]uint64, len(t.rejected)),
+	}
+	for version, count := range t.rejected {
+		report.Rejected[version] = count
+	}
+	return report
+}
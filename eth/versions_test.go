@@ -0,0 +1,71 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests that peers below the minimum protocol version are dropped and that the
+// versions of connected and rejected peers are reported.
+func TestMinProtocolVersion(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 0, nil, nil)
+	defer pm.Stop()
+	pm.versions = newVersionTracker(eth63)
+
+	old, errc := newTestPeer("old", eth62, pm, false)
+	defer old.close()
+	select {
+	case err := <-errc:
+		if want := errResp(ErrProtocolVersionMismatch, "eth/%d (< eth/%d)", eth62, eth63); err == nil || err.Error() != want.Error() {
+			t.Errorf("wrong error: have %v, want %v", err, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("old peer not dropped")
+	}
+
+	current, _ := newTestPeer("current", eth63, pm, true)
+	defer current.close()
+	for start := time.Now(); pm.peers.Peer(current.id) == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 2*time.Second {
+			t.Fatal("current peer not registered")
+		}
+	}
+
+	versions := pm.NodeInfo().PeerVersions
+	if versions.Minimum != eth63 {
+		t.Errorf("minimum mismatch: have %d, want %d", versions.Minimum, eth63)
+	}
+	if len(versions.Connected) != 1 || versions.Connected[eth63] != 1 {
+		t.Errorf("connected versions mismatch: have %v, want one eth/63 peer", versions.Connected)
+	}
+	if len(versions.Rejected) != 1 || versions.Rejected[eth62] != 1 {
+		t.Errorf("rejected versions mismatch: have %v, want one eth/62 peer", versions.Rejected)
+	}
+}
+
+func TestValidateMinProtocolVersion(t *testing.T) {
+	for _, version := range []uint{0, eth62, eth63} {
+		if err := validateMinProtocolVersion(version); err != nil {
+			t.Errorf("version %d rejected: %v", version, err)
+		}
+	}
+	if err := validateMinProtocolVersion(64); err == nil {
+		t.Error("unsupported version 64 accepted")
+	}
+}
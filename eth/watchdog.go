@@ -0,0 +1,195 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Recovery actions the watchdog can take once the chain stalled.
+const (
+	WatchdogRedial   = "redial"   // drop all peers, static and trusted peers are redialed
+	WatchdogStepdown = "stepdown" // hand over block production, e.g. raft leadership
+	WatchdogExit     = "exit"     // exit the process for a supervisor to restart it
+)
+
+var watchdogStallMeter = metrics.NewMeter("eth/watchdog/stalls")
+
+// WatchdogConfig configures the stalled chain watchdog.
+type WatchdogConfig struct {
+	Timeout time.Duration // Time without a new head while peers are ahead before the chain counts as stalled, 0 disables the watchdog
+	Actions []string      // Recovery actions taken in order on a stall, alerting only if empty
+}
+
+// Validate checks the recovery actions of the watchdog.
+func (c WatchdogConfig) Validate() error {
+	for _, action := range c.Actions {
+		switch action {
+		case WatchdogRedial, WatchdogStepdown, WatchdogExit:
+		default:
+			return fmt.Errorf("unknown watchdog action %q, want %s, %s or %s", action, WatchdogRedial, WatchdogStepdown, WatchdogExit)
+		}
+	}
+	return nil
+}
+
+// watchdog detects a stalled chain: no new head for the configured timeout
+// while the peers, or the consensus engine, report that the chain has moved
+// on. A stall is logged and counted, then the recovery actions are taken. The
+// watchdog waits a full timeout before acting on the same stall again.
+type watchdog struct {
+	config WatchdogConfig
+
+	head     func() *types.Block // Current head of the local chain
+	ahead    func() bool         // Whether a peer reports a better head
+	behind   func() bool         // Consensus specific stall check, may be nil
+	redial   func()
+	stepdown func() error // Consensus specific, may be nil
+	exit     func()
+
+	lastHead     common.Hash
+	lastProgress time.Time
+	stalled      bool
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newWatchdog(config WatchdogConfig, head func() *types.Block, ahead func() bool, redial func()) *watchdog {
+	return &watchdog{
+		config: config,
+		head:   head,
+		ahead:  ahead,
+		redial: redial,
+		exit: func() {
+			glog.Flush()
+			os.Exit(1)
+		},
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+func (w *watchdog) start() {
+	w.lastHead, w.lastProgress = w.head().Hash(), time.Now()
+	go w.loop()
+}
+
+func (w *watchdog) stop() {
+	close(w.quit)
+	<-w.done
+}
+
+func (w *watchdog) loop() {
+	defer close(w.done)
+
+	interval := w.config.Timeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			w.check(now)
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// check looks for a stall at the given time and recovers from it.
+func (w *watchdog) check(now time.Time) {
+	head := w.head()
+	if head.Hash() != w.lastHead {
+		if w.stalled {
+			glog.V(logger.Info).Infof("Watchdog: chain resumed at #%d [%x…]", head.NumberU64(), head.Hash().Bytes()[:4])
+		}
+		w.lastHead, w.lastProgress, w.stalled = head.Hash(), now, false
+		return
+	}
+	if now.Sub(w.lastProgress) < w.config.Timeout {
+		return
+	}
+	if !w.ahead() && (w.behind == nil || !w.behind()) {
+		return
+	}
+	w.stalled = true
+	watchdogStallMeter.Mark(1)
+	glog.V(logger.Error).Infof("Watchdog: no new block for %v since #%d [%x…] while the network is ahead", now.Sub(w.lastProgress), head.NumberU64(), head.Hash().Bytes()[:4])
+
+	for _, action := range w.config.Actions {
+		switch action {
+		case WatchdogRedial:
+			glog.V(logger.Warn).Infof("Watchdog: dropping all peers to redial")
+			w.redial()
+		case WatchdogStepdown:
+			if w.stepdown == nil {
+				glog.V(logger.Warn).Infof("Watchdog: stepping down isn't supported by the consensus engine")
+				continue
+			}
+			glog.V(logger.Warn).Infof("Watchdog: stepping down")
+			if err := w.stepdown(); err != nil {
+				glog.V(logger.Warn).Infof("Watchdog: failed to step down: %v", err)
+			}
+		case WatchdogExit:
+			glog.V(logger.Error).Infof("Watchdog: exiting for the supervisor to restart the node")
+			w.exit()
+		}
+	}
+	// Give the recovery a full timeout before acting again
+	w.lastProgress = now
+}
+
+// peersAhead reports whether the best peer has a higher total difficulty than
+// the local head.
+func (pm *ProtocolManager) peersAhead() bool {
+	best := pm.peers.BestPeer()
+	if best == nil {
+		return false
+	}
+	head := pm.blockchain.CurrentBlock()
+	td := pm.blockchain.GetTd(head.Hash(), head.NumberU64())
+	if td == nil {
+		return false
+	}
+	_, bestTd := best.Head()
+	return bestTd.Cmp(td) > 0
+}
+
+// dropPeers disconnects all peers.
+func (pm *ProtocolManager) dropPeers() {
+	pm.peers.lock.RLock()
+	ids := make([]string, 0, len(pm.peers.peers))
+	for id := range pm.peers.peers {
+		ids = append(ids, id)
+	}
+	pm.peers.lock.RUnlock()
+
+	for _, id := range ids {
+		pm.removePeer(id)
+	}
+}
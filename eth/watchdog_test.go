@@ -0,0 +1,89 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the watchdog only recovers once the chain stalled while the
+// network is ahead, and waits a full timeout before acting again.
+func TestWatchdog(t *testing.T) {
+	var (
+		head    = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+		ahead   bool
+		behind  bool
+		actions []string
+	)
+	w := newWatchdog(WatchdogConfig{Timeout: time.Minute, Actions: []string{WatchdogRedial, WatchdogStepdown, WatchdogExit}},
+		func() *types.Block { return head },
+		func() bool { return ahead },
+		func() { actions = append(actions, WatchdogRedial) },
+	)
+	w.stepdown = func() error { actions = append(actions, WatchdogStepdown); return nil }
+	w.exit = func() { actions = append(actions, WatchdogExit) }
+
+	start := time.Now()
+	w.lastHead, w.lastProgress = head.Hash(), start
+
+	check := func(at time.Duration, want ...string) {
+		actions = nil
+		w.check(start.Add(at))
+		if len(actions) != len(want) || (len(want) > 0 && !reflect.DeepEqual(actions, want)) {
+			t.Fatalf("at %v: actions mismatch: have %v, want %v", at, actions, want)
+		}
+	}
+	check(30 * time.Second) // not stalled yet
+	ahead = true
+	check(59 * time.Second)
+	check(time.Minute, WatchdogRedial, WatchdogStepdown, WatchdogExit)
+	check(90 * time.Second) // recovering
+	check(2*time.Minute, WatchdogRedial, WatchdogStepdown, WatchdogExit)
+
+	// A new head resets the watchdog
+	head = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2)})
+	check(150 * time.Second)
+	if w.stalled {
+		t.Fatalf("still stalled after a new head")
+	}
+	check(200 * time.Second)
+	check(210*time.Second, WatchdogRedial, WatchdogStepdown, WatchdogExit)
+
+	// Without the network being ahead the chain is just idle, unless the
+	// consensus engine knows better
+	ahead = false
+	check(300 * time.Second)
+	w.behind = func() bool { return behind }
+	check(400 * time.Second)
+	behind = true
+	check(500*time.Second, WatchdogRedial, WatchdogStepdown, WatchdogExit)
+}
+
+// Tests that unknown recovery actions are rejected.
+func TestWatchdogConfigValidate(t *testing.T) {
+	if err := (WatchdogConfig{Actions: []string{WatchdogRedial, WatchdogExit}}).Validate(); err != nil {
+		t.Fatalf("valid actions rejected: %v", err)
+	}
+	if err := (WatchdogConfig{Actions: []string{"reboot"}}).Validate(); err == nil {
+		t.Fatalf("unknown action accepted")
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -50,8 +51,9 @@ var handleRatio = map[string]float64{
 }
 
 type LDBDatabase struct {
-	fn string      // filename for reporting
-	db *leveldb.DB // LevelDB instance
+	fn   string          // filename for reporting
+	db   *leveldb.DB     // LevelDB instance
+	stor storage.Storage // Storage of the instance, closed after it
 
 	getTimer       gometrics.Timer // Timer for measuring the database get request counts and latencies
 	putTimer       gometrics.Timer // Timer for measuring the database put request counts and latencies
@@ -69,6 +71,16 @@ type LDBDatabase struct {
 
 // NewLDBDatabase returns a LevelDB wrapped object.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	return newLDBDatabase(file, cache, handles, nil)
+}
+
+// NewEncryptedLDBDatabase returns a LevelDB wrapped object whose files are
+// encrypted with the given 32 byte key.
+func NewEncryptedLDBDatabase(file string, cache int, handles int, key []byte) (*LDBDatabase, error) {
+	return newLDBDatabase(file, cache, handles, key)
+}
+
+func newLDBDatabase(file string, cache int, handles int, key []byte) (*LDBDatabase, error) {
 	// Calculate the cache and file descriptor allowance for this particular database
 	cache = int(float64(cache) * cacheRatio[filepath.Base(file)])
 	if cache < 16 {
@@ -80,23 +92,34 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	}
 	glog.V(logger.Info).Infof("Allotted %dMB cache and %d file handles to %s", cache, handles, file)
 
+	stor, err := storage.OpenFile(file, false)
+	if err != nil {
+		return nil, err
+	}
+	if key != nil {
+		if stor, err = NewEncryptedStorage(stor, key); err != nil {
+			return nil, err
+		}
+	}
 	// Open the db and recover any potential corruptions
-	db, err := leveldb.OpenFile(file, &opt.Options{
+	db, err := leveldb.Open(stor, &opt.Options{
 		OpenFilesCacheCapacity: handles,
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
 	})
 	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
-		db, err = leveldb.RecoverFile(file, nil)
+		db, err = leveldb.Recover(stor, nil)
 	}
 	// (Re)check for errors and abort if opening of the db failed
 	if err != nil {
+		stor.Close()
 		return nil, err
 	}
 	return &LDBDatabase{
-		fn: file,
-		db: db,
+		fn:   file,
+		db:   db,
+		stor: stor,
 	}, nil
 }
 
@@ -169,6 +192,9 @@ func (self *LDBDatabase) Close() {
 		}
 	}
 	err := self.db.Close()
+	if cerr := self.stor.Close(); err == nil {
+		err = cerr
+	}
 	if glog.V(logger.Error) {
 		if err == nil {
 			glog.Infoln("closed db:", self.fn)
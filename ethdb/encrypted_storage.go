@@ -0,0 +1,225 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// encryptedFileMagic starts every file written by an encrypted storage, so
+// plaintext databases are detected instead of being decrypted into garbage.
+var encryptedFileMagic = []byte("qenc")
+
+const encryptedHeaderSize = 4 + aes.BlockSize + 8 // magic, initial counter and key check value
+
+var (
+	// ErrNotEncrypted is returned when an encrypted database is opened on files
+	// which were written in plaintext.
+	ErrNotEncrypted = errors.New("database file not encrypted")
+
+	// ErrWrongKey is returned when an encrypted database is opened with another
+	// key than it was written with. It's checked before any data is decrypted,
+	// so leveldb doesn't try to recover the database from garbage.
+	ErrWrongKey = errors.New("database file encrypted with another key")
+)
+
+// encryptedStorage is a leveldb storage encrypting the contents of the table,
+// journal and manifest files with AES in counter mode. Every file has its own
+// random initial counter, stored in the file header, so reads at arbitrary
+// offsets don't need to decrypt the file from the start.
+//
+// The encryption provides confidentiality only, the integrity of the data is
+// still checked by the leveldb block checksums.
+type encryptedStorage struct {
+	storage.Storage
+	block cipher.Block
+	check []byte // Key check value, the encryption of a constant block
+}
+
+// NewEncryptedStorage wraps a leveldb storage to encrypt all files it writes
+// with the given 32 byte key.
+func NewEncryptedStorage(stor storage.Storage, key []byte) (storage.Storage, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid database key length %d, want 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	check := make([]byte, aes.BlockSize)
+	block.Encrypt(check, []byte("qenc key check\x00\x00"))
+	return &encryptedStorage{Storage: stor, block: block, check: check[:8]}, nil
+}
+
+// Open implements storage.Storage, decrypting the file on the fly.
+func (s *encryptedStorage) Open(fd storage.FileDesc) (storage.Reader, error) {
+	r, err := s.Storage.Open(fd)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encryptedHeaderSize)
+	n, err := r.ReadAt(header, 0)
+	switch {
+	case n == 0 && err == io.EOF:
+		// Created but never written to before a crash, treat as empty
+		return &encryptedReader{r: r, stream: s.stream, empty: true}, nil
+	case n < len(header) || !bytes.Equal(header[:4], encryptedFileMagic):
+		r.Close()
+		return nil, fmt.Errorf("%v: %v", fd, ErrNotEncrypted)
+	case !bytes.Equal(header[4+aes.BlockSize:], s.check):
+		r.Close()
+		return nil, fmt.Errorf("%v: %v", fd, ErrWrongKey)
+	}
+	if _, err := r.Seek(encryptedHeaderSize, io.SeekStart); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return &encryptedReader{r: r, iv: header[4 : 4+aes.BlockSize], stream: s.stream}, nil
+}
+
+// Create implements storage.Storage, encrypting the file on the fly.
+func (s *encryptedStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	w, err := s.Storage.Create(fd)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encryptedHeaderSize)
+	copy(header, encryptedFileMagic)
+	if _, err := io.ReadFull(rand.Reader, header[4:4+aes.BlockSize]); err != nil {
+		w.Close()
+		return nil, err
+	}
+	copy(header[4+aes.BlockSize:], s.check)
+	if _, err := w.Write(header); err != nil {
+		w.Close()
+		return nil, err
+	}
+	iv := header[4 : 4+aes.BlockSize]
+	return &encryptedWriter{w: w, iv: iv, streamAt: s.stream, stream: s.stream(iv, 0)}, nil
+}
+
+// stream returns the key stream of a file with the given initial counter,
+// positioned at offset.
+func (s *encryptedStorage) stream(iv []byte, offset int64) cipher.Stream {
+	ctr := make([]byte, aes.BlockSize)
+	copy(ctr, iv)
+
+	// Add the number of blocks before offset to the 128 bit big endian counter
+	blocks := uint64(offset / aes.BlockSize)
+	lo := binary.BigEndian.Uint64(ctr[8:])
+	binary.BigEndian.PutUint64(ctr[8:], lo+blocks)
+	if lo+blocks < lo {
+		binary.BigEndian.PutUint64(ctr[:8], binary.BigEndian.Uint64(ctr[:8])+1)
+	}
+	stream := cipher.NewCTR(s.block, ctr)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		discard := make([]byte, skip)
+		stream.XORKeyStream(discard, discard)
+	}
+	return stream
+}
+
+type encryptedReader struct {
+	r      storage.Reader
+	iv     []byte
+	stream func(iv []byte, offset int64) cipher.Stream
+	pos    int64 // Offset of the next Read in the plaintext
+	empty  bool
+}
+
+func (r *encryptedReader) Read(p []byte) (int, error) {
+	if r.empty {
+		return 0, io.EOF
+	}
+	n, err := r.r.Read(p)
+	r.stream(r.iv, r.pos).XORKeyStream(p[:n], p[:n])
+	r.pos += int64(n)
+	return n, err
+}
+
+func (r *encryptedReader) ReadAt(p []byte, off int64) (int, error) {
+	if r.empty {
+		return 0, io.EOF
+	}
+	n, err := r.r.ReadAt(p, off+encryptedHeaderSize)
+	r.stream(r.iv, off).XORKeyStream(p[:n], p[:n])
+	return n, err
+}
+
+func (r *encryptedReader) Seek(offset int64, whence int) (int64, error) {
+	if r.empty {
+		return 0, nil
+	}
+	if whence == io.SeekStart {
+		offset += encryptedHeaderSize
+	}
+	pos, err := r.r.Seek(offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	r.pos = pos - encryptedHeaderSize
+	return r.pos, nil
+}
+
+func (r *encryptedReader) Close() error {
+	return r.r.Close()
+}
+
+type encryptedWriter struct {
+	w        storage.Writer
+	iv       []byte
+	streamAt func(iv []byte, offset int64) cipher.Stream
+	stream   cipher.Stream // Key stream positioned at pos
+	pos      int64         // Offset of the next Write in the plaintext
+	buf      []byte
+}
+
+// Write encrypts p and writes it to the file. If the file takes fewer bytes, the
+// key stream is repositioned after them, so the remainder can be written again.
+func (w *encryptedWriter) Write(p []byte) (int, error) {
+	if cap(w.buf) < len(p) {
+		w.buf = make([]byte, len(p))
+	}
+	buf := w.buf[:len(p)]
+	w.stream.XORKeyStream(buf, p)
+	n, err := w.w.Write(buf)
+	w.pos += int64(n)
+	if n < len(p) {
+		w.stream = w.streamAt(w.iv, w.pos)
+		if err == nil {
+			err = io.ErrShortWrite
+		}
+	}
+	return n, err
+}
+
+func (w *encryptedWriter) Sync() error {
+	return w.w.Sync()
+}
+
+func (w *encryptedWriter) Close() error {
+	return w.w.Close()
+}
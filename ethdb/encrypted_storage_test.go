@@ -0,0 +1,192 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// Tests that encrypted files read back at any offset, in any order.
+func TestEncryptedStorageFiles(t *testing.T) {
+	stor, err := NewEncryptedStorage(storage.NewMemStorage(), bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	fd := storage.FileDesc{Type: storage.TypeTable, Num: 1}
+	w, err := stor.Create(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(data[:333])
+	w.Write(data[333:])
+	w.Close()
+
+	r, err := stor.Open(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, off := range []int64{999, 17, 0, 512, 16} {
+		buf := make([]byte, 100)
+		n, _ := r.ReadAt(buf, off)
+		if !bytes.Equal(buf[:n], data[off:off+int64(n)]) {
+			t.Errorf("ReadAt(%d) mismatch", off)
+		}
+	}
+	if pos, err := r.Seek(500, io.SeekStart); pos != 500 || err != nil {
+		t.Fatalf("Seek failed: %d %v", pos, err)
+	}
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || !bytes.Equal(rest, data[500:]) {
+		t.Errorf("Read after seek mismatch: %v", err)
+	}
+	if _, err := NewEncryptedStorage(storage.NewMemStorage(), make([]byte, 16)); err == nil {
+		t.Errorf("short key accepted")
+	}
+}
+
+// shortStorage is a storage whose writers take at most limit bytes per Write.
+type shortStorage struct {
+	storage.Storage
+	limit int
+}
+
+type shortWriter struct {
+	storage.Writer
+	limit int
+}
+
+func (s shortStorage) Create(fd storage.FileDesc) (storage.Writer, error) {
+	w, err := s.Storage.Create(fd)
+	return shortWriter{w, s.limit}, err
+}
+
+func (w shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		p = p[:w.limit]
+	}
+	return w.Writer.Write(p)
+}
+
+// Tests that short writes are reported, and the rest of the data encrypts at
+// its offset when written again.
+func TestEncryptedStorageShortWrite(t *testing.T) {
+	stor, err := NewEncryptedStorage(shortStorage{storage.NewMemStorage(), encryptedHeaderSize + 10}, bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	fd := storage.FileDesc{Type: storage.TypeJournal, Num: 1}
+	w, err := stor.Create(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rest := data; len(rest) > 0; {
+		n, err := w.Write(rest)
+		if n != encryptedHeaderSize+10 && n != len(rest) {
+			t.Fatalf("wrote %d of %d bytes", n, len(rest))
+		}
+		if n < len(rest) && err != io.ErrShortWrite {
+			t.Fatalf("short write error mismatch: have %v, want %v", err, io.ErrShortWrite)
+		}
+		rest = rest[n:]
+	}
+	w.Close()
+
+	r, err := stor.Open(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if have, err := ioutil.ReadAll(r); err != nil || !bytes.Equal(have, data) {
+		t.Errorf("data mismatch after short writes: %v", err)
+	}
+}
+
+// Tests that encrypted databases hold no plaintext, can only be read with the
+// key they were written with, and plaintext databases aren't opened encrypted.
+func TestEncryptedLDBDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := bytes.Repeat([]byte{1}, 32)
+	db, err := NewEncryptedLDBDatabase(filepath.Join(dir, "encrypted"), 0, 0, key)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		db.Put([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("secret ledger data %d", i)))
+	}
+	db.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "encrypted", "*"))
+	for _, file := range files {
+		if blob, _ := ioutil.ReadFile(file); bytes.Contains(blob, []byte("secret ledger data")) {
+			t.Errorf("plaintext found in %s", file)
+		}
+	}
+	db, err = NewEncryptedLDBDatabase(filepath.Join(dir, "encrypted"), 0, 0, key)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	if val, err := db.Get([]byte("key42")); err != nil || string(val) != "secret ledger data 42" {
+		t.Errorf("value mismatch after reopen: %q %v", val, err)
+	}
+	db.Close()
+
+	if _, err := NewEncryptedLDBDatabase(filepath.Join(dir, "encrypted"), 0, 0, bytes.Repeat([]byte{2}, 32)); err == nil || !strings.Contains(err.Error(), ErrWrongKey.Error()) {
+		t.Errorf("database opened with another key: %v", err)
+	}
+	db, err = NewEncryptedLDBDatabase(filepath.Join(dir, "encrypted"), 0, 0, key)
+	if err != nil {
+		t.Fatalf("failed to reopen database after wrong key: %v", err)
+	}
+	if _, err := db.Get([]byte("key42")); err != nil {
+		t.Errorf("value lost after opening with wrong key: %v", err)
+	}
+	db.Close()
+
+	db, err = NewLDBDatabase(filepath.Join(dir, "plain"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put([]byte("key"), []byte("value"))
+	db.Close()
+	if _, err := NewEncryptedLDBDatabase(filepath.Join(dir, "plain"), 0, 0, key); err == nil || !strings.Contains(err.Error(), ErrNotEncrypted.Error()) {
+		t.Errorf("plaintext database opened encrypted: %v", err)
+	}
+}
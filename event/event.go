@@ -15,6 +15,28 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 // Package event implements an event multiplexer.
+//
+// Events posted to a TypeMux are delivered to the subscriptions for their type
+// one after the other, in the order they subscribed. Each subscription has a
+// buffer of events it didn't receive yet, the size and overflow behaviour of
+// which the subscriber chooses:
+//
+//   - Subscribe creates an unbuffered, blocking subscription: Post returns once
+//     the subscriber received the event. A subscriber which stops receiving
+//     stalls the poster and every subscriber after it.
+//   - SubscribeWith creates a subscription with a bounded buffer. Post returns
+//     as soon as the event is buffered, and only blocks or drops events once
+//     the buffer is full, as configured by the Overflow of the subscription.
+//
+// A subscriber receives the events of concurrent Posts in any order, and those
+// of consecutive Posts in the order they were posted, unless dropped. Events
+// posted before a subscription was created aren't delivered to it. Buffered
+// events are still received after Unsubscribe or Stop, before the channel is
+// closed.
+//
+// How far behind subscribers are, how many events they dropped and for how
+// long they blocked Post is reported by Stats, and in the metrics of named
+// subscriptions.
 package event
 
 import (
@@ -22,6 +44,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -60,9 +83,15 @@ var ErrMuxClosed = errors.New("event: mux closed")
 
 // Subscribe creates a subscription for events of the given types. The
 // subscription's channel is closed when it is unsubscribed
-// or the mux is closed.
+// or the mux is closed. Posting blocks until the subscriber received the event.
 func (mux *TypeMux) Subscribe(types ...interface{}) Subscription {
-	sub := newsub(mux)
+	return mux.SubscribeWith(SubscriptionConfig{}, types...)
+}
+
+// SubscribeWith creates a subscription for events of the given types whose
+// events are buffered and overflow as configured.
+func (mux *TypeMux) SubscribeWith(config SubscriptionConfig, types ...interface{}) Subscription {
+	sub := newsub(mux, config)
 	mux.mutex.Lock()
 	defer mux.mutex.Unlock()
 	if mux.stopped {
@@ -125,6 +154,26 @@ func (mux *TypeMux) Stop() {
 	mux.mutex.Unlock()
 }
 
+// Stats returns the delivery statistics of the live subscriptions.
+func (mux *TypeMux) Stats() []SubscriptionStats {
+	mux.mutex.RLock()
+	defer mux.mutex.RUnlock()
+
+	var (
+		stats []SubscriptionStats
+		seen  = make(map[*muxsub]bool)
+	)
+	for _, subs := range mux.subm {
+		for _, sub := range subs {
+			if !seen[sub] {
+				seen[sub] = true
+				stats = append(stats, sub.stats())
+			}
+		}
+	}
+	return stats
+}
+
 func (mux *TypeMux) del(s *muxsub) {
 	mux.mutex.Lock()
 	for typ, subs := range mux.subm {
@@ -157,11 +206,16 @@ func posdelete(slice []*muxsub, pos int) []*muxsub {
 
 type muxsub struct {
 	mux     *TypeMux
+	config  SubscriptionConfig
 	created time.Time
 	closeMu sync.Mutex
 	closing chan struct{}
 	closed  bool
 
+	dropped uint64 // atomic, events dropped as the buffer was full
+	blocked int64  // atomic, nanoseconds Post waited for the subscriber
+	metrics *subMetrics
+
 	// these two are the same channel. they are stored separately so
 	// postC can be set to nil without affecting the return value of
 	// Chan.
@@ -170,15 +224,30 @@ type muxsub struct {
 	postC  chan<- *Event
 }
 
-func newsub(mux *TypeMux) *muxsub {
-	c := make(chan *Event)
-	return &muxsub{
+func newsub(mux *TypeMux, config SubscriptionConfig) *muxsub {
+	if config.Overflow != Block && config.Buffer < 1 {
+		config.Buffer = 1
+	}
+	c := make(chan *Event, config.Buffer)
+	sub := &muxsub{
 		mux:     mux,
+		config:  config,
 		created: time.Now(),
 		readC:   c,
 		postC:   c,
 		closing: make(chan struct{}),
 	}
+	sub.metrics = newSubMetrics(config.Name, func() int64 { return int64(len(c)) })
+	return sub
+}
+
+func (s *muxsub) stats() SubscriptionStats {
+	return SubscriptionStats{
+		Name:    s.config.Name,
+		Lag:     len(s.readC),
+		Dropped: atomic.LoadUint64(&s.dropped),
+		Blocked: time.Duration(atomic.LoadInt64(&s.blocked)),
+	}
 }
 
 func (s *muxsub) Chan() <-chan *Event {
@@ -198,6 +267,7 @@ func (s *muxsub) closewait() {
 	}
 	close(s.closing)
 	s.closed = true
+	s.metrics.unregister()
 
 	s.postMu.Lock()
 	close(s.postC)
@@ -216,6 +286,44 @@ func (s *muxsub) deliver(event *Event) {
 
 	select {
 	case s.postC <- event:
+		return
 	case <-s.closing:
+		return
+	default:
 	}
+	switch s.config.Overflow {
+	case DropOldest:
+		// The subscriber may receive concurrently, so only drop an event
+		// while the buffer is still full
+		for {
+			select {
+			case s.postC <- event:
+				return
+			case <-s.closing:
+				return
+			default:
+			}
+			select {
+			case <-s.readC:
+				s.drop()
+			default:
+			}
+		}
+	case DropNewest:
+		s.drop()
+	default:
+		start := time.Now()
+		select {
+		case s.postC <- event:
+		case <-s.closing:
+		}
+		blocked := time.Since(start)
+		atomic.AddInt64(&s.blocked, int64(blocked))
+		s.metrics.blocked.Update(blocked)
+	}
+}
+
+func (s *muxsub) drop() {
+	atomic.AddUint64(&s.dropped, 1)
+	s.metrics.dropped.Mark(1)
 }
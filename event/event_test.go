@@ -198,3 +198,64 @@ func BenchmarkChanSend(b *testing.B) {
 		}
 	}
 }
+
+func TestSubscribeOverflow(t *testing.T) {
+	tests := []struct {
+		overflow Overflow
+		want     []testEvent
+	}{
+		{DropOldest, []testEvent{3, 4}},
+		{DropNewest, []testEvent{0, 1}},
+	}
+	for _, tt := range tests {
+		mux := new(TypeMux)
+		sub := mux.SubscribeWith(SubscriptionConfig{Buffer: 2, Overflow: tt.overflow}, testEvent(0))
+		for i := 0; i < 5; i++ {
+			if err := mux.Post(testEvent(i)); err != nil {
+				t.Fatalf("overflow %d: Post returned unexpected error: %v", tt.overflow, err)
+			}
+		}
+		stats := mux.Stats()
+		if len(stats) != 1 || stats[0].Lag != 2 || stats[0].Dropped != 3 {
+			t.Errorf("overflow %d: stats mismatch: %+v", tt.overflow, stats)
+		}
+		mux.Stop()
+
+		var got []testEvent
+		for ev := range sub.Chan() {
+			got = append(got, ev.Data.(testEvent))
+		}
+		if len(got) != len(tt.want) || got[0] != tt.want[0] || got[1] != tt.want[1] {
+			t.Errorf("overflow %d: received %v, want %v", tt.overflow, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribeBufferedBlock(t *testing.T) {
+	mux := new(TypeMux)
+	defer mux.Stop()
+
+	sub := mux.SubscribeWith(SubscriptionConfig{Buffer: 2}, testEvent(0))
+	mux.Post(testEvent(0))
+	mux.Post(testEvent(1))
+
+	posted := make(chan struct{})
+	go func() {
+		mux.Post(testEvent(2))
+		close(posted)
+	}()
+	select {
+	case <-posted:
+		t.Fatalf("Post returned with a full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for i := 0; i < 3; i++ {
+		if ev := <-sub.Chan(); ev.Data.(testEvent) != testEvent(i) {
+			t.Errorf("event %d: received %v", i, ev.Data)
+		}
+	}
+	<-posted
+	if stats := mux.Stats(); len(stats) != 1 || stats[0].Dropped != 0 || stats[0].Blocked < 50*time.Millisecond {
+		t.Errorf("stats mismatch: %+v", stats)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package event
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	gometrics "github.com/rcrowley/go-metrics"
+)
+
+// Overflow is what happens to an event posted to a subscription whose buffer
+// is full.
+type Overflow int
+
+const (
+	// Block makes Post wait until the subscriber received an older event,
+	// slowing down the poster and the delivery to later subscribers. Nothing
+	// is lost, which is what subscribers relying on every event need.
+	Block Overflow = iota
+
+	// DropOldest discards the oldest buffered event to make room, so the
+	// subscriber catches up with the most recent events. It suits events
+	// superseding the earlier ones, like chain heads.
+	DropOldest
+
+	// DropNewest discards the posted event, keeping those already buffered.
+	DropNewest
+)
+
+// SubscriptionConfig configures the delivery to a subscription.
+type SubscriptionConfig struct {
+	// Name of the subscriber in the metrics, without metrics if empty. Live
+	// subscriptions of the same name share their metrics.
+	Name string
+
+	// Events buffered for the subscriber before Overflow applies. Dropping
+	// subscriptions buffer at least one event.
+	Buffer int
+
+	Overflow Overflow
+}
+
+// SubscriptionStats are the delivery statistics of a subscription.
+type SubscriptionStats struct {
+	Name    string
+	Lag     int    // Events buffered which the subscriber didn't receive yet
+	Dropped uint64 // Events dropped as the buffer was full
+	Blocked time.Duration
+}
+
+// subMetrics are the metrics of a named subscription:
+//
+//	event/<name>/lag      gauge of the buffered events
+//	event/<name>/dropped  meter of the dropped events
+//	event/<name>/blocked  timer of the Post calls waiting for the subscriber
+type subMetrics struct {
+	names   []string
+	dropped gometrics.Meter
+	blocked gometrics.Timer
+}
+
+func newSubMetrics(name string, lag func() int64) *subMetrics {
+	m := &subMetrics{
+		dropped: new(gometrics.NilMeter),
+		blocked: new(gometrics.NilTimer),
+	}
+	if name == "" || !metrics.Enabled {
+		return m
+	}
+	prefix := "event/" + name + "/"
+	m.names = []string{prefix + "lag", prefix + "dropped", prefix + "blocked"}
+	gometrics.DefaultRegistry.Register(m.names[0], gometrics.NewFunctionalGauge(lag))
+	m.dropped = metrics.NewMeter(m.names[1])
+	m.blocked = metrics.NewTimer(m.names[2])
+	return m
+}
+
+// unregister removes the metrics of an unsubscribed subscription, so the name
+// can be used again.
+func (m *subMetrics) unregister() {
+	for _, name := range m.names {
+		gometrics.DefaultRegistry.Unregister(name)
+	}
+}
@@ -0,0 +1,487 @@
+package grpcapi
+
+import (
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// Message types, client and server registration of the Node service defined
+// in quorum.proto.
+
+type Empty struct {
+}
+
+func (m *Empty) Reset()         { *m = Empty{} }
+func (m *Empty) String() string { return proto.CompactTextString(m) }
+func (*Empty) ProtoMessage()    {}
+
+type BlockNumberResponse struct {
+	Number uint64 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+}
+
+func (m *BlockNumberResponse) Reset()         { *m = BlockNumberResponse{} }
+func (m *BlockNumberResponse) String() string { return proto.CompactTextString(m) }
+func (*BlockNumberResponse) ProtoMessage()    {}
+
+type GetBlockRequest struct {
+	Hash   []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	Number uint64 `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
+	Latest bool   `protobuf:"varint,3,opt,name=latest,proto3" json:"latest,omitempty"`
+}
+
+func (m *GetBlockRequest) Reset()         { *m = GetBlockRequest{} }
+func (m *GetBlockRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBlockRequest) ProtoMessage()    {}
+
+type Block struct {
+	Number       uint64   `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Hash         []byte   `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	ParentHash   []byte   `protobuf:"bytes,3,opt,name=parent_hash,json=parentHash,proto3" json:"parent_hash,omitempty"`
+	Timestamp    uint64   `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	GasLimit     uint64   `protobuf:"varint,5,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
+	GasUsed      uint64   `protobuf:"varint,6,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	Coinbase     []byte   `protobuf:"bytes,7,opt,name=coinbase,proto3" json:"coinbase,omitempty"`
+	StateRoot    []byte   `protobuf:"bytes,8,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	ExtraData    []byte   `protobuf:"bytes,9,opt,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`
+	Transactions [][]byte `protobuf:"bytes,10,rep,name=transactions,proto3" json:"transactions,omitempty"`
+}
+
+func (m *Block) Reset()         { *m = Block{} }
+func (m *Block) String() string { return proto.CompactTextString(m) }
+func (*Block) ProtoMessage()    {}
+
+type SendTransactionRequest struct {
+	From          []byte   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            []byte   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Gas           uint64   `protobuf:"varint,3,opt,name=gas,proto3" json:"gas,omitempty"`
+	GasPrice      uint64   `protobuf:"varint,4,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	Value         []byte   `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	Data          []byte   `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	PrivateFrom   string   `protobuf:"bytes,7,opt,name=private_from,json=privateFrom,proto3" json:"private_from,omitempty"`
+	PrivateFor    []string `protobuf:"bytes,8,rep,name=private_for,json=privateFor,proto3" json:"private_for,omitempty"`
+	CorrelationId string   `protobuf:"bytes,9,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+}
+
+func (m *SendTransactionRequest) Reset()         { *m = SendTransactionRequest{} }
+func (m *SendTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*SendTransactionRequest) ProtoMessage()    {}
+
+type RawTransaction struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *RawTransaction) Reset()         { *m = RawTransaction{} }
+func (m *RawTransaction) String() string { return proto.CompactTextString(m) }
+func (*RawTransaction) ProtoMessage()    {}
+
+type TransactionHash struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *TransactionHash) Reset()         { *m = TransactionHash{} }
+func (m *TransactionHash) String() string { return proto.CompactTextString(m) }
+func (*TransactionHash) ProtoMessage()    {}
+
+type Receipt struct {
+	TransactionHash   []byte `protobuf:"bytes,1,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	TransactionIndex  uint64 `protobuf:"varint,2,opt,name=transaction_index,json=transactionIndex,proto3" json:"transaction_index,omitempty"`
+	BlockHash         []byte `protobuf:"bytes,3,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	BlockNumber       uint64 `protobuf:"varint,4,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	From              []byte `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To                []byte `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	ContractAddress   []byte `protobuf:"bytes,7,opt,name=contract_address,json=contractAddress,proto3" json:"contract_address,omitempty"`
+	GasUsed           uint64 `protobuf:"varint,8,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	CumulativeGasUsed uint64 `protobuf:"varint,9,opt,name=cumulative_gas_used,json=cumulativeGasUsed,proto3" json:"cumulative_gas_used,omitempty"`
+	Status            uint64 `protobuf:"varint,10,opt,name=status,proto3" json:"status,omitempty"`
+	Logs              []*Log `protobuf:"bytes,11,rep,name=logs" json:"logs,omitempty"`
+}
+
+func (m *Receipt) Reset()         { *m = Receipt{} }
+func (m *Receipt) String() string { return proto.CompactTextString(m) }
+func (*Receipt) ProtoMessage()    {}
+
+type LogFilter struct {
+	Addresses [][]byte  `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	Topics    []*Topics `protobuf:"bytes,2,rep,name=topics" json:"topics,omitempty"`
+}
+
+func (m *LogFilter) Reset()         { *m = LogFilter{} }
+func (m *LogFilter) String() string { return proto.CompactTextString(m) }
+func (*LogFilter) ProtoMessage()    {}
+
+type Topics struct {
+	Topics [][]byte `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty"`
+}
+
+func (m *Topics) Reset()         { *m = Topics{} }
+func (m *Topics) String() string { return proto.CompactTextString(m) }
+func (*Topics) ProtoMessage()    {}
+
+type Log struct {
+	Address          []byte   `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Topics           [][]byte `protobuf:"bytes,2,rep,name=topics,proto3" json:"topics,omitempty"`
+	Data             []byte   `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	BlockNumber      uint64   `protobuf:"varint,4,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	BlockHash        []byte   `protobuf:"bytes,5,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	TransactionHash  []byte   `protobuf:"bytes,6,opt,name=transaction_hash,json=transactionHash,proto3" json:"transaction_hash,omitempty"`
+	TransactionIndex uint64   `protobuf:"varint,7,opt,name=transaction_index,json=transactionIndex,proto3" json:"transaction_index,omitempty"`
+	LogIndex         uint64   `protobuf:"varint,8,opt,name=log_index,json=logIndex,proto3" json:"log_index,omitempty"`
+}
+
+func (m *Log) Reset()         { *m = Log{} }
+func (m *Log) String() string { return proto.CompactTextString(m) }
+func (*Log) ProtoMessage()    {}
+
+type NodeVersionResponse struct {
+	Client         string   `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	Consensus      string   `protobuf:"bytes,2,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	PrivacyManager string   `protobuf:"bytes,3,opt,name=privacy_manager,json=privacyManager,proto3" json:"privacy_manager,omitempty"`
+	Protocols      []string `protobuf:"bytes,4,rep,name=protocols,proto3" json:"protocols,omitempty"`
+	NetworkId      uint64   `protobuf:"varint,5,opt,name=network_id,json=networkId,proto3" json:"network_id,omitempty"`
+	ChainId        uint64   `protobuf:"varint,6,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+}
+
+func (m *NodeVersionResponse) Reset()         { *m = NodeVersionResponse{} }
+func (m *NodeVersionResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeVersionResponse) ProtoMessage()    {}
+
+type CanonicalHashRequest struct {
+	Height uint64 `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+}
+
+func (m *CanonicalHashRequest) Reset()         { *m = CanonicalHashRequest{} }
+func (m *CanonicalHashRequest) String() string { return proto.CompactTextString(m) }
+func (*CanonicalHashRequest) ProtoMessage()    {}
+
+type BlockHash struct {
+	Hash []byte `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+}
+
+func (m *BlockHash) Reset()         { *m = BlockHash{} }
+func (m *BlockHash) String() string { return proto.CompactTextString(m) }
+func (*BlockHash) ProtoMessage()    {}
+
+// NodeClient is the client API for the Node service.
+type NodeClient interface {
+	BlockNumber(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BlockNumberResponse, error)
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	SendTransaction(ctx context.Context, in *SendTransactionRequest, opts ...grpc.CallOption) (*TransactionHash, error)
+	SendRawTransaction(ctx context.Context, in *RawTransaction, opts ...grpc.CallOption) (*TransactionHash, error)
+	GetTransactionReceipt(ctx context.Context, in *TransactionHash, opts ...grpc.CallOption) (*Receipt, error)
+	SubscribeNewHeads(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Node_SubscribeNewHeadsClient, error)
+	SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Node_SubscribeLogsClient, error)
+	NodeVersion(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeVersionResponse, error)
+	CanonicalHash(ctx context.Context, in *CanonicalHashRequest, opts ...grpc.CallOption) (*BlockHash, error)
+}
+
+type nodeClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewNodeClient(cc *grpc.ClientConn) NodeClient {
+	return &nodeClient{cc}
+}
+
+func (c *nodeClient) BlockNumber(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*BlockNumberResponse, error) {
+	out := new(BlockNumberResponse)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/BlockNumber", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/GetBlock", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SendTransaction(ctx context.Context, in *SendTransactionRequest, opts ...grpc.CallOption) (*TransactionHash, error) {
+	out := new(TransactionHash)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/SendTransaction", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SendRawTransaction(ctx context.Context, in *RawTransaction, opts ...grpc.CallOption) (*TransactionHash, error) {
+	out := new(TransactionHash)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/SendRawTransaction", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetTransactionReceipt(ctx context.Context, in *TransactionHash, opts ...grpc.CallOption) (*Receipt, error) {
+	out := new(Receipt)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/GetTransactionReceipt", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) SubscribeNewHeads(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Node_SubscribeNewHeadsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Node_serviceDesc.Streams[0], "/quorum.Node/SubscribeNewHeads", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubscribeNewHeadsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Node_SubscribeNewHeadsClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type nodeSubscribeNewHeadsClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubscribeNewHeadsClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeClient) SubscribeLogs(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Node_SubscribeLogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Node_serviceDesc.Streams[1], "/quorum.Node/SubscribeLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubscribeLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Node_SubscribeLogsClient interface {
+	Recv() (*Log, error)
+	grpc.ClientStream
+}
+
+type nodeSubscribeLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubscribeLogsClient) Recv() (*Log, error) {
+	m := new(Log)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeClient) NodeVersion(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*NodeVersionResponse, error) {
+	out := new(NodeVersionResponse)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/NodeVersion", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) CanonicalHash(ctx context.Context, in *CanonicalHashRequest, opts ...grpc.CallOption) (*BlockHash, error) {
+	out := new(BlockHash)
+	if err := c.cc.Invoke(ctx, "/quorum.Node/CanonicalHash", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// NodeServer is the server API for the Node service.
+type NodeServer interface {
+	BlockNumber(context.Context, *Empty) (*BlockNumberResponse, error)
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	SendTransaction(context.Context, *SendTransactionRequest) (*TransactionHash, error)
+	SendRawTransaction(context.Context, *RawTransaction) (*TransactionHash, error)
+	GetTransactionReceipt(context.Context, *TransactionHash) (*Receipt, error)
+	SubscribeNewHeads(*Empty, Node_SubscribeNewHeadsServer) error
+	SubscribeLogs(*LogFilter, Node_SubscribeLogsServer) error
+	NodeVersion(context.Context, *Empty) (*NodeVersionResponse, error)
+	CanonicalHash(context.Context, *CanonicalHashRequest) (*BlockHash, error)
+}
+
+func RegisterNodeServer(s *grpc.Server, srv NodeServer) {
+	s.RegisterService(&_Node_serviceDesc, srv)
+}
+
+func _Node_BlockNumber_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).BlockNumber(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/BlockNumber"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).BlockNumber(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/GetBlock"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SendTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SendTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/SendTransaction"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SendTransaction(ctx, req.(*SendTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SendRawTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RawTransaction)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SendRawTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/SendRawTransaction"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SendRawTransaction(ctx, req.(*RawTransaction))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetTransactionReceipt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionHash)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetTransactionReceipt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/GetTransactionReceipt"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetTransactionReceipt(ctx, req.(*TransactionHash))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_SubscribeNewHeads_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).SubscribeNewHeads(m, &nodeSubscribeNewHeadsServer{stream})
+}
+
+type Node_SubscribeNewHeadsServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type nodeSubscribeNewHeadsServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubscribeNewHeadsServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Node_SubscribeLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(NodeServer).SubscribeLogs(m, &nodeSubscribeLogsServer{stream})
+}
+
+type Node_SubscribeLogsServer interface {
+	Send(*Log) error
+	grpc.ServerStream
+}
+
+type nodeSubscribeLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubscribeLogsServer) Send(m *Log) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Node_NodeVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).NodeVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/NodeVersion"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).NodeVersion(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_CanonicalHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CanonicalHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).CanonicalHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/quorum.Node/CanonicalHash"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).CanonicalHash(ctx, req.(*CanonicalHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Node_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "quorum.Node",
+	HandlerType: (*NodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "BlockNumber", Handler: _Node_BlockNumber_Handler},
+		{MethodName: "GetBlock", Handler: _Node_GetBlock_Handler},
+		{MethodName: "SendTransaction", Handler: _Node_SendTransaction_Handler},
+		{MethodName: "SendRawTransaction", Handler: _Node_SendRawTransaction_Handler},
+		{MethodName: "GetTransactionReceipt", Handler: _Node_GetTransactionReceipt_Handler},
+		{MethodName: "NodeVersion", Handler: _Node_NodeVersion_Handler},
+		{MethodName: "CanonicalHash", Handler: _Node_CanonicalHash_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "SubscribeNewHeads", Handler: _Node_SubscribeNewHeads_Handler, ServerStreams: true},
+		{StreamName: "SubscribeLogs", Handler: _Node_SubscribeLogs_Handler, ServerStreams: true},
+	},
+	Metadata: "quorum.proto",
+}
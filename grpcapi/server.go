@@ -0,0 +1,373 @@
+// Package grpcapi serves the Node gRPC service, a protobuf interface to the
+// JSON-RPC methods internal services use most.
+package grpcapi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/private"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Node service on top of an RPC server. Every call is
+// made to the RPC server on behalf of the gRPC caller, so the module whitelist
+// of the server, the access log and limits per caller apply as they do to the
+// HTTP and websocket endpoints.
+type Server struct {
+	handler *rpc.Server
+}
+
+// NewServer creates a Node service calling the methods of the given RPC server.
+func NewServer(handler *rpc.Server) *Server {
+	return &Server{handler: handler}
+}
+
+// caller returns the RPC caller of a gRPC call. As with HTTP, the identity is
+// the x-forwarded-user set by an authenticating proxy in front of the node and
+// clients attach correlation IDs with x-correlation-id.
+func caller(ctx context.Context) rpc.Caller {
+	c := rpc.Caller{Transport: "grpc"}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		c.RemoteAddr = p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md["x-forwarded-user"]; len(v) > 0 {
+			c.Identity = v[0]
+		}
+		if v := md["x-correlation-id"]; len(v) > 0 {
+			c.CorrelationID = v[0]
+		}
+	}
+	return c
+}
+
+// call calls an RPC method on behalf of the gRPC caller.
+func (s *Server) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	client := rpc.DialInProcAs(s.handler, caller(ctx))
+	defer client.Close()
+	return statusError(client.CallContext(ctx, result, method, args...))
+}
+
+// statusError converts an RPC error to a gRPC status.
+func statusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if rpcErr, ok := err.(rpc.Error); ok {
+		switch rpcErr.ErrorCode() {
+		case -32601:
+			return status.Errorf(codes.Unimplemented, "%v", err)
+		case -32602:
+			return status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return status.Errorf(codes.Unknown, "%v", err)
+	}
+	if err == context.Canceled {
+		return status.Errorf(codes.Canceled, "%v", err)
+	}
+	if err == context.DeadlineExceeded {
+		return status.Errorf(codes.DeadlineExceeded, "%v", err)
+	}
+	return status.Errorf(codes.Unavailable, "%v", err)
+}
+
+func (s *Server) BlockNumber(ctx context.Context, _ *Empty) (*BlockNumberResponse, error) {
+	var number rpc.HexNumber
+	if err := s.call(ctx, &number, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+	return &BlockNumberResponse{Number: number.Uint64()}, nil
+}
+
+// rpcBlock is a block as returned by eth_getBlockByNumber without transaction
+// details.
+type rpcBlock struct {
+	Number       rpc.HexNumber  `json:"number"`
+	Hash         common.Hash    `json:"hash"`
+	ParentHash   common.Hash    `json:"parentHash"`
+	Timestamp    rpc.HexNumber  `json:"timestamp"`
+	GasLimit     rpc.HexNumber  `json:"gasLimit"`
+	GasUsed      rpc.HexNumber  `json:"gasUsed"`
+	Miner        common.Address `json:"miner"`
+	StateRoot    common.Hash    `json:"stateRoot"`
+	ExtraData    rpc.HexBytes   `json:"extraData"`
+	Transactions []common.Hash  `json:"transactions"`
+}
+
+func (s *Server) GetBlock(ctx context.Context, req *GetBlockRequest) (*Block, error) {
+	var (
+		block *rpcBlock
+		err   error
+	)
+	switch {
+	case len(req.Hash) > 0:
+		err = s.call(ctx, &block, "eth_getBlockByHash", common.BytesToHash(req.Hash), false)
+	case req.Latest:
+		err = s.call(ctx, &block, "eth_getBlockByNumber", "latest", false)
+	default:
+		err = s.call(ctx, &block, "eth_getBlockByNumber", rpc.NewHexNumber(req.Number), false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, status.Errorf(codes.NotFound, "block not found")
+	}
+	out := &Block{
+		Number:     block.Number.Uint64(),
+		Hash:       block.Hash.Bytes(),
+		ParentHash: block.ParentHash.Bytes(),
+		Timestamp:  block.Timestamp.Uint64(),
+		GasLimit:   block.GasLimit.Uint64(),
+		GasUsed:    block.GasUsed.Uint64(),
+		Coinbase:   block.Miner.Bytes(),
+		StateRoot:  block.StateRoot.Bytes(),
+		ExtraData:  block.ExtraData,
+	}
+	for _, hash := range block.Transactions {
+		out.Transactions = append(out.Transactions, hash.Bytes())
+	}
+	return out, nil
+}
+
+func (s *Server) SendTransaction(ctx context.Context, req *SendTransactionRequest) (*TransactionHash, error) {
+	args := map[string]interface{}{
+		"from":  common.BytesToAddress(req.From),
+		"value": rpc.NewHexNumber(new(big.Int).SetBytes(req.Value)),
+		"data":  fmt.Sprintf("0x%x", req.Data),
+	}
+	if len(req.To) > 0 {
+		args["to"] = common.BytesToAddress(req.To)
+	}
+	if req.Gas > 0 {
+		args["gas"] = rpc.NewHexNumber(req.Gas)
+	}
+	if req.GasPrice > 0 {
+		args["gasPrice"] = rpc.NewHexNumber(req.GasPrice)
+	}
+	if req.PrivateFrom != "" {
+		args["privateFrom"] = req.PrivateFrom
+	}
+	if len(req.PrivateFor) > 0 {
+		args["privateFor"] = req.PrivateFor
+	}
+	if req.CorrelationId != "" {
+		args["correlationId"] = req.CorrelationId
+	}
+	var hash common.Hash
+	if err := s.call(ctx, &hash, "eth_sendTransaction", args); err != nil {
+		return nil, err
+	}
+	return &TransactionHash{Hash: hash.Bytes()}, nil
+}
+
+func (s *Server) SendRawTransaction(ctx context.Context, req *RawTransaction) (*TransactionHash, error) {
+	var hash common.Hash
+	if err := s.call(ctx, &hash, "eth_sendRawTransaction", fmt.Sprintf("0x%x", req.Data)); err != nil {
+		return nil, err
+	}
+	return &TransactionHash{Hash: hash.Bytes()}, nil
+}
+
+// rpcReceipt is a receipt as returned by eth_getTransactionReceipt.
+type rpcReceipt struct {
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  rpc.HexNumber   `json:"transactionIndex"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       rpc.HexNumber   `json:"blockNumber"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	GasUsed           rpc.HexNumber   `json:"gasUsed"`
+	CumulativeGasUsed rpc.HexNumber   `json:"cumulativeGasUsed"`
+	Status            *rpc.HexNumber  `json:"status"`
+	Logs              []*vm.Log       `json:"logs"`
+}
+
+func (s *Server) GetTransactionReceipt(ctx context.Context, req *TransactionHash) (*Receipt, error) {
+	var receipt *rpcReceipt
+	if err := s.call(ctx, &receipt, "eth_getTransactionReceipt", common.BytesToHash(req.Hash)); err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, status.Errorf(codes.NotFound, "receipt not found")
+	}
+	out := &Receipt{
+		TransactionHash:   receipt.TransactionHash.Bytes(),
+		TransactionIndex:  receipt.TransactionIndex.Uint64(),
+		BlockHash:         receipt.BlockHash.Bytes(),
+		BlockNumber:       receipt.BlockNumber.Uint64(),
+		From:              receipt.From.Bytes(),
+		GasUsed:           receipt.GasUsed.Uint64(),
+		CumulativeGasUsed: receipt.CumulativeGasUsed.Uint64(),
+	}
+	if receipt.To != nil {
+		out.To = receipt.To.Bytes()
+	}
+	if receipt.ContractAddress != nil {
+		out.ContractAddress = receipt.ContractAddress.Bytes()
+	}
+	// Receipts before Byzantium carry a state root instead of a status
+	if receipt.Status == nil || receipt.Status.Uint() == types.ReceiptStatusSuccessful {
+		out.Status = 1
+	}
+	for _, log := range receipt.Logs {
+		out.Logs = append(out.Logs, newLog(log))
+	}
+	return out, nil
+}
+
+func newLog(log *vm.Log) *Log {
+	out := &Log{
+		Address:          log.Address.Bytes(),
+		Data:             log.Data,
+		BlockNumber:      log.BlockNumber,
+		BlockHash:        log.BlockHash.Bytes(),
+		TransactionHash:  log.TxHash.Bytes(),
+		TransactionIndex: uint64(log.TxIndex),
+		LogIndex:         uint64(log.Index),
+	}
+	for _, topic := range log.Topics {
+		out.Topics = append(out.Topics, topic.Bytes())
+	}
+	return out
+}
+
+func (s *Server) SubscribeNewHeads(_ *Empty, stream Node_SubscribeNewHeadsServer) error {
+	ctx := stream.Context()
+	client := rpc.DialInProcAs(s.handler, caller(ctx))
+	defer client.Close()
+
+	heads := make(chan *types.Header, 16)
+	sub, err := client.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return statusError(err)
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case head := <-heads:
+			err := stream.Send(&Block{
+				Number:     head.Number.Uint64(),
+				Hash:       head.Hash().Bytes(),
+				ParentHash: head.ParentHash.Bytes(),
+				Timestamp:  head.Time.Uint64(),
+				GasLimit:   head.GasLimit.Uint64(),
+				GasUsed:    head.GasUsed.Uint64(),
+				Coinbase:   head.Coinbase.Bytes(),
+				StateRoot:  head.Root.Bytes(),
+				ExtraData:  head.Extra,
+			})
+			if err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return statusError(err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) SubscribeLogs(filter *LogFilter, stream Node_SubscribeLogsServer) error {
+	ctx := stream.Context()
+	client := rpc.DialInProcAs(s.handler, caller(ctx))
+	defer client.Close()
+
+	logs := make(chan *vm.Log, 64)
+	sub, err := client.EthSubscribe(ctx, logs, "logs", logCriteria(filter))
+	if err != nil {
+		return statusError(err)
+	}
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case log := <-logs:
+			if err := stream.Send(newLog(log)); err != nil {
+				return err
+			}
+		case err := <-sub.Err():
+			return statusError(err)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// logCriteria returns the eth_subscribe criteria of a log filter. An empty
+// topic position matches any topic.
+func logCriteria(filter *LogFilter) map[string]interface{} {
+	crit := make(map[string]interface{})
+	if len(filter.Addresses) > 0 {
+		addresses := make([]common.Address, len(filter.Addresses))
+		for i, addr := range filter.Addresses {
+			addresses[i] = common.BytesToAddress(addr)
+		}
+		crit["address"] = addresses
+	}
+	if len(filter.Topics) > 0 {
+		topics := make([]interface{}, len(filter.Topics))
+		for i, position := range filter.Topics {
+			if position == nil || len(position.Topics) == 0 {
+				continue
+			}
+			hashes := make([]common.Hash, len(position.Topics))
+			for j, topic := range position.Topics {
+				hashes[j] = common.BytesToHash(topic)
+			}
+			topics[i] = hashes
+		}
+		crit["topics"] = topics
+	}
+	return crit
+}
+
+// rpcNodeVersion is the result of quorum_nodeVersion.
+type rpcNodeVersion struct {
+	Client         string               `json:"client"`
+	Consensus      string               `json:"consensus"`
+	PrivacyManager *private.ManagerInfo `json:"privacyManager"`
+	Protocols      []string             `json:"protocols"`
+	NetworkId      uint64               `json:"networkId"`
+	ChainId        *big.Int             `json:"chainId"`
+}
+
+func (s *Server) NodeVersion(ctx context.Context, _ *Empty) (*NodeVersionResponse, error) {
+	var version rpcNodeVersion
+	if err := s.call(ctx, &version, "quorum_nodeVersion"); err != nil {
+		return nil, err
+	}
+	out := &NodeVersionResponse{
+		Client:    version.Client,
+		Consensus: version.Consensus,
+		Protocols: version.Protocols,
+		NetworkId: version.NetworkId,
+	}
+	if pm := version.PrivacyManager; pm != nil {
+		out.PrivacyManager = pm.Type
+		if pm.Version != "" {
+			out.PrivacyManager += "/" + pm.Version
+		}
+	}
+	if version.ChainId != nil {
+		out.ChainId = version.ChainId.Uint64()
+	}
+	return out, nil
+}
+
+func (s *Server) CanonicalHash(ctx context.Context, req *CanonicalHashRequest) (*BlockHash, error) {
+	var hash common.Hash
+	if err := s.call(ctx, &hash, "quorum_canonicalHash", rpc.NewHexNumber(req.Height)); err != nil {
+		return nil, err
+	}
+	return &BlockHash{Hash: hash.Bytes()}, nil
+}
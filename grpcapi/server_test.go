@@ -0,0 +1,154 @@
+package grpcapi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// EthService serves the eth methods the tests call.
+type EthService struct{}
+
+func (EthService) BlockNumber() *rpc.HexNumber {
+	return rpc.NewHexNumber(42)
+}
+
+func (EthService) GetTransactionReceipt(hash common.Hash) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (EthService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		// Notifications are dropped until the subscription is active, keep sending
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for number := int64(1); ; number++ {
+			select {
+			case <-ticker.C:
+				notifier.Notify(sub.ID, &types.Header{Number: big.NewInt(number), Difficulty: new(big.Int), GasLimit: new(big.Int), GasUsed: new(big.Int), Time: new(big.Int)})
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// newTestNode starts a gRPC server over an RPC server of the eth test service
+// and returns a client of it.
+func newTestNode(t *testing.T) (NodeClient, *bytes.Buffer, func()) {
+	handler := rpc.NewServer()
+	if err := handler.RegisterName("eth", EthService{}); err != nil {
+		t.Fatal(err)
+	}
+	log := new(bytes.Buffer)
+	handler.SetAccessLog(rpc.NewAccessLog(log, 1))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	RegisterNodeServer(server, NewServer(handler))
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewNodeClient(conn), log, func() {
+		conn.Close()
+		server.Stop()
+		handler.Stop()
+	}
+}
+
+func TestServerCall(t *testing.T) {
+	client, log, stop := newTestNode(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("x-forwarded-user", "settlement"))
+
+	res, err := client.BlockNumber(ctx, &Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Number != 42 {
+		t.Errorf("block number mismatch: have %d, want 42", res.Number)
+	}
+	// Calls are logged on behalf of the gRPC caller
+	var entry struct {
+		Transport string `json:"transport"`
+		Identity  string `json:"identity"`
+		Method    string `json:"method"`
+	}
+	line, _ := bufio.NewReader(log).ReadBytes('\n')
+	if err := json.Unmarshal(line, &entry); err != nil {
+		t.Fatalf("invalid access log entry %q: %v", line, err)
+	}
+	if entry.Transport != "grpc" || entry.Identity != "settlement" || entry.Method != "eth_blockNumber" {
+		t.Errorf("access log entry mismatch: %+v", entry)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	client, _, stop := newTestNode(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.GetTransactionReceipt(ctx, &TransactionHash{Hash: make([]byte, 32)}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing receipt: have %v, want NotFound", err)
+	}
+	// The quorum module isn't served
+	if _, err := client.NodeVersion(ctx, &Empty{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("disabled module: have %v, want Unimplemented", err)
+	}
+}
+
+func TestServerSubscribe(t *testing.T) {
+	client, _, stop := newTestNode(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.SubscribeNewHeads(ctx, &Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last uint64
+	for i := 0; i < 3; i++ {
+		head, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.Number <= last || len(head.Hash) != common.HashLength {
+			t.Fatalf("head %d: number %d after %d, hash %x", i, head.Number, last, head.Hash)
+		}
+		last = head.Number
+	}
+}
@@ -74,6 +74,18 @@ var (
 		Name:  "trace",
 		Usage: "Write execution trace to the given file",
 	}
+	dumpGoroutinesFlag = cli.IntFlag{
+		Name:  "dump.goroutines",
+		Usage: "Dump goroutine and heap profiles above this many goroutines (0 = disabled)",
+	}
+	dumpHeapFlag = cli.Uint64Flag{
+		Name:  "dump.heap",
+		Usage: "Dump goroutine and heap profiles above this many MB of heap in use (0 = disabled)",
+	}
+	dumpDirFlag = cli.StringFlag{
+		Name:  "dump.dir",
+		Usage: "Directory the profiles are dumped to (default: profiles in the data directory)",
+	}
 )
 
 // Flags holds all command-line flags required for debugging.
@@ -81,6 +93,7 @@ var Flags = []cli.Flag{
 	verbosityFlag, vmoduleFlag, backtraceAtFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
+	dumpGoroutinesFlag, dumpHeapFlag, dumpDirFlag,
 }
 
 // Setup initializes profiling and logging based on the CLI flags.
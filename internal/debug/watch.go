@@ -0,0 +1,163 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package debug
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	watchInterval = 10 * time.Second
+	watchKeep     = 10 // Dumps of each kind kept in the dump directory
+)
+
+var (
+	watchGoroutinesMeter = metrics.NewMeter("system/watch/goroutines")
+	watchHeapMeter       = metrics.NewMeter("system/watch/heap")
+)
+
+// WatchConfig configures the resource watch, which dumps profiles when the
+// process uses more goroutines or heap than expected.
+type WatchConfig struct {
+	Goroutines int    // Goroutine count triggering dumps, 0 disables
+	Heap       uint64 // Bytes of heap in use triggering dumps, 0 disables
+	Dir        string // Directory the profiles are written to
+}
+
+// resourceWatch samples the goroutine count and heap in use. Crossing a
+// threshold writes a goroutine and a heap profile, logs an error and marks the
+// meter of the threshold. While above, profiles are written again each time
+// the value grew by half since the last dump, so the profiles of a leak show
+// what grew until the process was killed. Only the latest dumps are kept.
+type resourceWatch struct {
+	config WatchConfig
+
+	goroutines int    // Goroutines at the last dump, 0 if below the threshold
+	heap       uint64 // Heap at the last dump, 0 if below the threshold
+}
+
+// StartWatch starts the resource watch configured by the command line flags,
+// writing profiles to the dump directory or, if not set, to the given one.
+func StartWatch(ctx *cli.Context, dir string) {
+	config := WatchConfig{
+		Goroutines: ctx.GlobalInt(dumpGoroutinesFlag.Name),
+		Heap:       ctx.GlobalUint64(dumpHeapFlag.Name) * 1024 * 1024,
+		Dir:        dir,
+	}
+	if d := ctx.GlobalString(dumpDirFlag.Name); d != "" {
+		config.Dir = d
+	}
+	if config.Goroutines == 0 && config.Heap == 0 {
+		return
+	}
+	glog.V(logger.Info).Infof("Dumping profiles to %s above %d goroutines or %d MB of heap", config.Dir, config.Goroutines, config.Heap/1024/1024)
+	go (&resourceWatch{config: config}).loop()
+}
+
+func (w *resourceWatch) loop() {
+	for range time.Tick(watchInterval) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		w.check(runtime.NumGoroutine(), stats.HeapInuse)
+	}
+}
+
+// check compares a sample with the thresholds and dumps profiles if needed.
+func (w *resourceWatch) check(goroutines int, heap uint64) {
+	var reasons []string
+	if w.config.Goroutines > 0 {
+		switch {
+		case goroutines < w.config.Goroutines:
+			w.goroutines = 0
+		case w.goroutines == 0 || goroutines >= w.goroutines+w.goroutines/2:
+			w.goroutines = goroutines
+			watchGoroutinesMeter.Mark(1)
+			reasons = append(reasons, fmt.Sprintf("%d goroutines", goroutines))
+		}
+	}
+	if w.config.Heap > 0 {
+		switch {
+		case heap < w.config.Heap:
+			w.heap = 0
+		case w.heap == 0 || heap >= w.heap+w.heap/2:
+			w.heap = heap
+			watchHeapMeter.Mark(1)
+			reasons = append(reasons, fmt.Sprintf("%d MB of heap", heap/1024/1024))
+		}
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	files, err := w.dump(time.Now())
+	if err != nil {
+		glog.V(logger.Error).Infof("Resource threshold exceeded with %s, failed to dump profiles: %v", strings.Join(reasons, " and "), err)
+		return
+	}
+	glog.V(logger.Error).Infof("Resource threshold exceeded with %s, dumped profiles to %s", strings.Join(reasons, " and "), strings.Join(files, ", "))
+}
+
+// dump writes the goroutine stacks and a heap profile named after the time,
+// and removes the oldest dumps.
+func (w *resourceWatch) dump(now time.Time) ([]string, error) {
+	if err := os.MkdirAll(w.config.Dir, 0700); err != nil {
+		return nil, err
+	}
+	stamp := now.UTC().Format("20060102T150405")
+	var files []string
+	for _, p := range []struct {
+		kind  string
+		debug int
+	}{{"goroutine", 2}, {"heap", 0}} {
+		path := filepath.Join(w.config.Dir, fmt.Sprintf("%s-%s.pprof", p.kind, stamp))
+		f, err := os.Create(path)
+		if err != nil {
+			return files, err
+		}
+		err = pprof.Lookup(p.kind).WriteTo(f, p.debug)
+		f.Close()
+		if err != nil {
+			return files, err
+		}
+		files = append(files, path)
+		pruneDumps(w.config.Dir, p.kind)
+	}
+	return files, nil
+}
+
+// pruneDumps removes all but the latest dumps of a kind.
+func pruneDumps(dir, kind string) {
+	matches, _ := filepath.Glob(filepath.Join(dir, kind+"-*.pprof"))
+	if len(matches) <= watchKeep {
+		return
+	}
+	sort.Strings(matches)
+	for _, path := range matches[:len(matches)-watchKeep] {
+		os.Remove(path)
+	}
+}
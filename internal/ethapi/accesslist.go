@@ -0,0 +1,150 @@
+package ethapi
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// AccessTuple is an account touched by a simulated transaction together with
+// the storage slots read or written. Private is set for contracts which live
+// in the private state.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+	Private     bool           `json:"private"`
+}
+
+// AccessListCall is a message call made by a contract during the simulation.
+type AccessListCall struct {
+	Type  string         `json:"type"`
+	From  common.Address `json:"from"`
+	To    common.Address `json:"to"`
+	Depth int            `json:"depth"`
+}
+
+// AccessListResult is the result of eth_createAccessList.
+type AccessListResult struct {
+	AccessList []AccessTuple    `json:"accessList"`
+	Calls      []AccessListCall `json:"calls"`
+	GasUsed    *rpc.HexNumber   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// accessListTracer records the accounts and storage slots touched by an
+// execution, and the calls made between contracts.
+type accessListTracer struct {
+	exclude common.Address // sender of the message
+	list    []AccessTuple
+	index   map[common.Address]int
+	slots   map[common.Address]map[common.Hash]bool
+	calls   []AccessListCall
+}
+
+func newAccessListTracer(from common.Address) *accessListTracer {
+	return &accessListTracer{
+		exclude: from,
+		index:   make(map[common.Address]int),
+		slots:   make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+// touch adds an account to the access list unless it's the sender or a
+// precompiled contract.
+func (t *accessListTracer) touch(env vm.Environment, addr common.Address) {
+	if _, ok := t.index[addr]; ok || addr == t.exclude {
+		return
+	}
+	if vm.Precompiled[string(addr.Bytes())] != nil || env.RuleSet().Precompile(addr, env.BlockNumber()) != nil {
+		return
+	}
+	var private bool
+	if env, ok := env.(core.DualStateEnv); ok {
+		private = env.PrivateState() != env.PublicState() && env.PrivateState().Exist(addr)
+	}
+	t.index[addr] = len(t.list)
+	t.list = append(t.list, AccessTuple{Address: addr, StorageKeys: []common.Hash{}, Private: private})
+}
+
+func (t *accessListTracer) touchSlot(env vm.Environment, addr common.Address, slot common.Hash) {
+	t.touch(env, addr)
+	if t.slots[addr] == nil {
+		t.slots[addr] = make(map[common.Hash]bool)
+	}
+	if !t.slots[addr][slot] {
+		t.slots[addr][slot] = true
+		i := t.index[addr]
+		t.list[i].StorageKeys = append(t.list[i].StorageKeys, slot)
+	}
+}
+
+// CaptureState implements vm.Tracer.
+func (t *accessListTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost *big.Int, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) {
+	t.touch(env, contract.Address())
+
+	data := stack.Data()
+	switch op {
+	case vm.SLOAD, vm.SSTORE:
+		if len(data) >= 1 {
+			t.touchSlot(env, contract.Address(), common.BigToHash(data[len(data)-1]))
+		}
+	case vm.BALANCE, vm.EXTCODESIZE, vm.EXTCODECOPY:
+		if len(data) >= 1 {
+			t.touch(env, common.BigToAddress(data[len(data)-1]))
+		}
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL:
+		if len(data) >= 2 {
+			to := common.BigToAddress(data[len(data)-2])
+			t.touch(env, to)
+			if err == nil {
+				t.calls = append(t.calls, AccessListCall{Type: op.String(), From: contract.Address(), To: to, Depth: depth})
+			}
+		}
+	}
+}
+
+// CreateAccessList simulates the given transaction on the state of the given
+// block, pending by default, and returns the accounts and storage slots it
+// touches together with the calls it makes. It helps to pick the privateFor
+// participants of a private transaction: every private contract in the list
+// has to be known to them.
+//
+// A reverting execution still returns what was touched up to the revert, with
+// the error set.
+func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args CallArgs, blockNr *rpc.BlockNumber) (*AccessListResult, error) {
+	number := rpc.PendingBlockNumber
+	if blockNr != nil {
+		number = *blockNr
+	}
+	from := args.From
+	if from == (common.Address{}) {
+		if accounts := s.b.AccountManager().Accounts(); len(accounts) > 0 {
+			from = accounts[0].Address
+		}
+	}
+	tracer := newAccessListTracer(from)
+	_, gas, err := s.doCall(ctx, args, number, nil, s.b.EVMTimeout("eth_createAccessList"), tracer)
+
+	result := &AccessListResult{
+		AccessList: tracer.list,
+		Calls:      tracer.calls,
+		GasUsed:    rpc.NewHexNumber(gas),
+	}
+	if result.AccessList == nil {
+		result.AccessList = []AccessTuple{}
+	}
+	if result.Calls == nil {
+		result.Calls = []AccessListCall{}
+	}
+	if err != nil {
+		if _, ok := err.(*revertError); !ok {
+			return nil, err
+		}
+		result.Error = err.Error()
+	}
+	return result, nil
+}
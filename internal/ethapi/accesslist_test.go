@@ -0,0 +1,60 @@
+package ethapi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestAccessListTracer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	publicState, _ := state.New(common.Hash{}, db)
+	privateState, _ := state.New(common.Hash{}, db)
+
+	var (
+		sender  = common.HexToAddress("0x01aa")
+		caller  = common.HexToAddress("0x0a")
+		account = common.HexToAddress("0x0b")
+		callee  = common.HexToAddress("0x0c")
+	)
+	// SLOAD slot 1, BALANCE of account, CALL callee, CALL the sha256 precompile
+	privateState.SetCode(caller, common.Hex2Bytes(
+		"6001545073"+common.Bytes2Hex(account.Bytes())+"3150"+
+			"6000600060006000600073"+common.Bytes2Hex(callee.Bytes())+"61fffff150"+
+			"6000600060006000600060026161a8f15000"))
+	// SSTORE 42 to slot 2
+	privateState.SetCode(callee, common.Hex2Bytes("602a60025500"))
+	publicState.SetBalance(account, big.NewInt(1))
+
+	tracer := newAccessListTracer(sender)
+	msg := callmsg{addr: sender, to: &caller, gas: big.NewInt(1000000), gasPrice: new(big.Int), value: new(big.Int)}
+	header := &types.Header{Number: big.NewInt(1)}
+	env := core.NewEnv(publicState, privateState, &core.ChainConfig{HomesteadBlock: new(big.Int)}, nil, msg, header, vm.Config{Debug: true, Tracer: tracer})
+	privateState.GetOrNewStateObject(sender).SetBalance(common.MaxBig)
+
+	if _, err := core.ApplyMessage(env, msg, new(core.GasPool).AddGas(common.MaxBig)); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	wantList := []AccessTuple{
+		{Address: caller, StorageKeys: []common.Hash{common.HexToHash("0x01")}, Private: true},
+		{Address: account, StorageKeys: []common.Hash{}, Private: false},
+		{Address: callee, StorageKeys: []common.Hash{common.HexToHash("0x02")}, Private: true},
+	}
+	if !reflect.DeepEqual(tracer.list, wantList) {
+		t.Errorf("access list mismatch:\nhave %+v\nwant %+v", tracer.list, wantList)
+	}
+	wantCalls := []AccessListCall{
+		{Type: "CALL", From: caller, To: callee, Depth: 1},
+		{Type: "CALL", From: caller, To: common.BytesToAddress([]byte{2}), Depth: 1},
+	}
+	if !reflect.DeepEqual(tracer.calls, wantCalls) {
+		t.Errorf("calls mismatch:\nhave %+v\nwant %+v", tracer.calls, wantCalls)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -69,15 +70,26 @@ func (s *PublicEthereumAPI) ProtocolVersion() *rpc.HexNumber {
 	return rpc.NewHexNumber(s.b.ProtocolVersion())
 }
 
+// ChainId returns the EIP-155 chain ID public transactions are signed for, as
+// set in the chain config. Chains without one don't replay protect their
+// transactions, and the network ID of net_version is all that tells them apart.
+func (s *PublicEthereumAPI) ChainId() (*rpc.HexNumber, error) {
+	id := s.b.ChainConfig().ChainId
+	if id == nil {
+		return nil, errors.New("no chainId in the chain config, the network is identified by net_version only")
+	}
+	return rpc.NewHexNumber(id), nil
+}
+
 // Syncing returns false in case the node is currently not syncing with the network. It can be up to date or has not
 // yet received the latest block headers from its pears. In case it is synchronizing:
 // - startingBlock: block number this node started to synchronise from
 // - currentBlock:  block number this node is currently importing
-// - highestBlock:  block number of the highest block header this node has received from peers
+// - highestBlock:  block number of the highest block header this node has received from peers (or raft log)
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
-	progress := s.b.Downloader().Progress()
+	progress := s.b.SyncProgress()
 
 	// Return not syncing if the synchronisation already completed
 	if progress.CurrentBlock >= progress.HighestBlock {
@@ -93,6 +105,19 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	}, nil
 }
 
+// SyncDetails returns false in case the node is currently not syncing with the network. In case it is synchronizing
+// it returns the progress of the header, body, receipt and state retrieval, the contribution and throughput of every
+// peer and the estimated number of seconds until the sync completes.
+func (s *PublicEthereumAPI) SyncDetails() (interface{}, error) {
+	details := s.b.Downloader().Details()
+
+	// Return not syncing if the synchronisation already completed
+	if details.CurrentBlock >= details.HighestBlock {
+		return false, nil
+	}
+	return details, nil
+}
+
 // PublicTxPoolAPI offers and API for the transaction pool. It only operates on data that is non confidential.
 type PublicTxPoolAPI struct {
 	b Backend
@@ -194,15 +219,17 @@ func (s *PublicAccountAPI) Accounts() []accounts.Account {
 // It offers methods to create, (un)lock en list accounts. Some methods accept
 // passwords and are therefore considered private by default.
 type PrivateAccountAPI struct {
-	am *accounts.Manager
-	b  Backend
+	am     *accounts.Manager
+	b      Backend
+	unlock *unlockGuard
 }
 
 // NewPrivateAccountAPI create a new PrivateAccountAPI.
-func NewPrivateAccountAPI(b Backend) *PrivateAccountAPI {
+func NewPrivateAccountAPI(b Backend, unlockConfig UnlockConfig) *PrivateAccountAPI {
 	return &PrivateAccountAPI{
-		am: b.AccountManager(),
-		b:  b,
+		am:     b.AccountManager(),
+		b:      b,
+		unlock: newUnlockGuard(unlockConfig),
 	}
 }
 
@@ -240,18 +267,35 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
-func (s *PrivateAccountAPI) UnlockAccount(addr common.Address, password string, duration *rpc.HexNumber) (bool, error) {
+//
+// If the node does not allow insecure unlocking, the caller must also supply a
+// one-time token which is verified before the password is tried. Failed
+// attempts are rate limited per account and all attempts are audit logged.
+func (s *PrivateAccountAPI) UnlockAccount(ctx context.Context, addr common.Address, password string, duration *rpc.HexNumber, token *string) (bool, error) {
+	attempt, err := s.unlock.authorize(ctx, addr, token)
+	if err != nil {
+		return false, rpcError(err)
+	}
 	if duration == nil {
 		duration = rpc.NewHexNumber(300)
 	}
 	a := accounts.Account{Address: addr}
 	d := time.Duration(duration.Int64()) * time.Second
 	if err := s.am.TimedUnlock(a, password, d); err != nil {
-		return false, err
+		return false, rpcError(s.unlock.audit(ctx, addr, err))
 	}
+	s.unlock.succeeded(addr, attempt)
+	s.unlock.audit(ctx, addr, nil)
 	return true, nil
 }
 
+// InspectAccount reports the key file metadata of the account with the given
+// address, such as its KDF parameters and creation time, and when it was last
+// unlocked and used to sign or vote for a block.
+func (s *PrivateAccountAPI) InspectAccount(addr common.Address) (*AccountInspection, error) {
+	return InspectAccount(s.am, s.b.ChainDb(), addr)
+}
+
 // LockAccount will lock the account associated with the given address when it's unlocked.
 func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return s.am.Lock(addr) == nil
@@ -261,7 +305,10 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 // tries to sign it with the key associated with args.To. If the given passwd isn't
 // able to decrypt the key it fails.
 func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs, passwd string) (common.Hash, error) {
-	var err error
+	ctx, err := withCorrelationId(ctx, args.CorrelationId)
+	if err != nil {
+		return common.Hash{}, err
+	}
 	args, err = prepareSendTxArgs(ctx, args, s.b)
 	if err != nil {
 		return common.Hash{}, err
@@ -279,7 +326,7 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 	data := common.FromHex(args.Data)
 	isPrivate := args.PrivateFor != nil
 	if isPrivate {
-		data, err = private.P.Send(data, args.PrivateFrom, args.PrivateFor)
+		data, err = private.Send(data, args.PrivateFrom, args.PrivateFor)
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -290,12 +337,13 @@ func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs
 		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), nil, data)
 	}
 
-	signature, err := s.am.SignWithPassphrase(args.From, passwd, tx.SigHash().Bytes())
+	chainId := signingChainId(s.b, isPrivate)
+	signature, err := s.am.SignWithPassphrase(args.From, passwd, sigHash(tx, chainId).Bytes())
 	if err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, rpcError(err)
 	}
 
-	return submitTransaction(ctx, s.b, tx, signature, isPrivate)
+	return submitTransaction(ctx, s.b, tx, signature, chainId, isPrivate)
 }
 
 // Please note: This is a temporary integration to improve performance in high-latency
@@ -339,13 +387,18 @@ func (a *Async) send(ctx context.Context, s *PublicTransactionPoolAPI, asyncArgs
 			}
 		}()
 	}
+	ctx, err := withCorrelationId(ctx, asyncArgs.CorrelationId)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
 	args, err := prepareSendTxArgs(ctx, asyncArgs.SendTxArgs, s.b)
 	if err != nil {
 		glog.V(logger.Info).Infof("Async.send: Error doing prepareSendTxArgs: %v", err)
 		res.Error = err.Error()
 		return
 	}
-	b, err := private.P.Send(common.FromHex(args.Data), args.PrivateFrom, args.PrivateFor)
+	b, err := private.Send(common.FromHex(args.Data), args.PrivateFrom, args.PrivateFor)
 	if err != nil {
 		glog.V(logger.Info).Infof("Error running Private.P.Send: %v", err)
 		res.Error = err.Error()
@@ -373,11 +426,13 @@ func (a *Async) save(ctx context.Context, s *PublicTransactionPoolAPI, args Send
 	} else {
 		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data)
 	}
-	signature, err := s.b.AccountManager().SignEthereum(args.From, tx.SigHash().Bytes())
+	isPrivate := args.PrivateFor != nil
+	chainId := signingChainId(s.b, isPrivate)
+	signature, err := s.b.AccountManager().SignEthereum(args.From, sigHash(tx, chainId).Bytes())
 	if err != nil {
 		return common.Hash{}, err
 	}
-	return submitTransaction(ctx, s.b, tx, signature, args.PrivateFor != nil)
+	return submitTransaction(ctx, s.b, tx, signature, chainId, isPrivate)
 }
 
 func newAsync(n int) *Async {
@@ -583,6 +638,12 @@ func (s *PublicBlockChainAPI) GetQuorumPayload(digestHex string) (string, error)
 	return private.GetPayload(digestHex)
 }
 
+// PrivateKeys returns the public keys of the privacy manager of the node, the
+// active ones payloads should be addressed to and the retired ones.
+func (s *PublicBlockChainAPI) PrivateKeys() (*private.Keys, error) {
+	return private.PublicKeys()
+}
+
 // GetCode returns the code stored at the given address in the state for the given block number.
 func (s *PublicBlockChainAPI) GetCode(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (string, error) {
 	state, _, err := s.b.StateAndHeaderByNumber(blockNr)
@@ -641,30 +702,21 @@ type CallArgs struct {
 	Data     string          `json:"data"`
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides StateOverride, timeout time.Duration, tracer vm.Tracer) (string, *big.Int, error) {
 	defer func(start time.Time) { glog.V(logger.Debug).Infof("call took %v", time.Since(start)) }(time.Now())
 
 	state, header, err := s.b.StateAndHeaderByNumber(blockNr)
 	if state == nil || err != nil {
 		return "0x", common.Big0, err
 	}
+	return s.doCallAt(ctx, args, state, header, overrides, timeout, tracer)
+}
 
-	// Set the account address to interact with
-	var addr common.Address
-	if args.From == (common.Address{}) {
-		accounts := s.b.AccountManager().Accounts()
-		if len(accounts) == 0 {
-			addr = common.Address{}
-		} else {
-			addr = accounts[0].Address
-		}
-	} else {
-		addr = args.From
-	}
-
+// doCallAt executes a call on the given state of a block.
+func (s *PublicBlockChainAPI) doCallAt(ctx context.Context, args CallArgs, state State, header *types.Header, overrides StateOverride, timeout time.Duration, tracer vm.Tracer) (string, *big.Int, error) {
 	// Assemble the CALL invocation
 	msg := callmsg{
-		addr:     addr,
+		addr:     callSender(args.From, s.b.AccountManager().Accounts()),
 		to:       args.To,
 		gas:      args.Gas.BigInt(),
 		gasPrice: args.GasPrice.BigInt(),
@@ -681,31 +733,85 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	}
 
 	// Execute the call and return
-	vmenv, vmError, err := s.b.GetVMEnv(ctx, msg, state, header)
+	vmenv, vmError, err := s.b.GetVMEnv(ctx, msg, state, header, tracer)
 	if err != nil {
 		return "0x", common.Big0, err
 	}
+	if err := overrides.Apply(vmenv.PublicState(), vmenv.PrivateState()); err != nil {
+		return "0x", common.Big0, err
+	}
+	// Abort the execution if it runs for too long
+	defer CancelOnTimeout(ctx, vmenv, timeout)()
+
 	gp := new(core.GasPool).AddGas(common.MaxBig)
-	res, gas, err := core.ApplyMessage(vmenv, msg, gp)
+	result, err := core.ApplyMessage(vmenv, msg, gp)
+	if vmenv.Cancelled() {
+		return "0x", common.Big0, AbortError(ctx, timeout)
+	}
 	if err := vmError(); err != nil {
 		return "0x", common.Big0, err
 	}
+	if err != nil {
+		return "0x", common.Big0, err
+	}
+	if result.Err == vm.ErrExecutionReverted {
+		return "0x", result.UsedGas, newRevertError(result.Revert())
+	}
+	res := result.Return()
 	if len(res) == 0 { // backwards compatability
-		return "0x", gas, err
+		return "0x", result.UsedGas, nil
 	}
-	return common.ToHex(res), gas, err
+	return common.ToHex(res), result.UsedGas, nil
+}
+
+// callSender returns the account a call is made from, the first local account
+// if the call doesn't name one.
+func callSender(from common.Address, local []accounts.Account) common.Address {
+	if from == (common.Address{}) && len(local) > 0 {
+		return local[0].Address
+	}
+	return from
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is usefull to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, error) {
-	result, _, err := s.doCall(ctx, args, blockNr)
+//
+// The optional overrides replace the balance, nonce, code or storage of accounts
+// for the duration of the call.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride) (string, error) {
+	var diff StateOverride
+	if overrides != nil {
+		diff = *overrides
+	}
+	cache := s.b.CallCache()
+	if cache == nil || blockNr == rpc.PendingBlockNumber {
+		result, _, err := s.doCall(ctx, args, blockNr, diff, s.b.EVMTimeout("eth_call"), nil)
+		return result, err
+	}
+	// Cached results are keyed on the block the call executes on, so the state
+	// is resolved first. The default sender changes with the local accounts, so
+	// it's resolved too.
+	state, header, err := s.b.StateAndHeaderByNumber(blockNr)
+	if state == nil || err != nil {
+		return "0x", err
+	}
+	args.From = callSender(args.From, s.b.AccountManager().Accounts())
+	key, cacheable := callCacheKey(header.Hash(), args, diff)
+	if cacheable {
+		if result, ok := cache.get(key); ok {
+			return result, nil
+		}
+	}
+	result, _, err := s.doCallAt(ctx, args, state, header, diff, s.b.EVMTimeout("eth_call"), nil)
+	if err == nil && cacheable {
+		cache.put(key, header.Hash(), result)
+	}
 	return result, err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the given transaction.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*rpc.HexNumber, error) {
-	_, gas, err := s.doCall(ctx, args, rpc.PendingBlockNumber)
+	_, gas, err := s.doCall(ctx, args, rpc.PendingBlockNumber, nil, s.b.EVMTimeout("eth_estimateGas"), nil)
 	return rpc.NewHexNumber(gas), err
 }
 
@@ -714,6 +820,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 // gas used and the return value
 type ExecutionResult struct {
 	Gas         *big.Int       `json:"gas"`
+	Failed      bool           `json:"failed"`
 	ReturnValue string         `json:"returnValue"`
 	StructLogs  []StructLogRes `json:"structLogs"`
 }
@@ -1064,6 +1171,15 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	return rlp.EncodeToBytes(tx)
 }
 
+// GetTransactionCorrelationId returns the correlation ID the transaction with
+// the given hash was submitted to this node with, or nil if it had none.
+func (s *PublicTransactionPoolAPI) GetTransactionCorrelationId(txHash common.Hash) *string {
+	if id := core.GetTxCorrelationId(s.b.ChainDb(), txHash); id != "" {
+		return &id
+	}
+	return nil
+}
+
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (map[string]interface{}, error) {
 	receipt := core.GetReceipt(s.b.ChainDb(), txHash)
@@ -1091,7 +1207,6 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	}
 
 	fields := map[string]interface{}{
-		"root":              rpc.HexBytes(receipt.PostState),
 		"blockHash":         txBlock,
 		"blockNumber":       rpc.NewHexNumber(blockIndex),
 		"transactionHash":   txHash,
@@ -1104,6 +1219,18 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		"logs":              receipt.Logs,
 		"logsBloom":         receipt.Bloom,
 	}
+	// Receipts carry a status code instead of the state root since Byzantium
+	if len(receipt.PostState) > 0 {
+		fields["root"] = rpc.HexBytes(receipt.PostState)
+	} else {
+		fields["status"] = rpc.NewHexNumber(receipt.Status)
+	}
+	if len(receipt.RevertReason) > 0 {
+		fields["revertReason"] = rpc.HexBytes(receipt.RevertReason)
+	}
+	if id := core.GetTxCorrelationId(s.b.ChainDb(), txHash); id != "" {
+		fields["correlationId"] = id
+	}
 	if receipt.Logs == nil {
 		fields["logs"] = []vm.Logs{}
 	}
@@ -1116,11 +1243,43 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
-	signature, err := s.b.AccountManager().SignEthereum(addr, tx.SigHash().Bytes())
+	chainId := signingChainId(s.b, false)
+	signature, err := s.b.AccountManager().SignEthereum(addr, sigHash(tx, chainId).Bytes())
 	if err != nil {
-		return nil, err
+		return nil, rpcError(err)
+	}
+	return withSignature(tx, signature, chainId)
+}
+
+// signingChainId returns the chain ID to replay protect a new transaction
+// with, or nil if it has to be signed without protection. Private
+// transactions are never protected, as their V marks them private.
+func signingChainId(b Backend, isPrivate bool) *big.Int {
+	config := b.ChainConfig()
+	if isPrivate || config == nil {
+		return nil
+	}
+	head := b.HeaderByNumber(rpc.LatestBlockNumber)
+	if head == nil || !config.IsEIP155(new(big.Int).Add(head.Number, common.Big1)) {
+		return nil
 	}
-	return tx.WithSignature(signature)
+	return config.ChainId
+}
+
+// sigHash returns the hash to sign for tx, replay protected if chainId is set.
+func sigHash(tx *types.Transaction, chainId *big.Int) common.Hash {
+	if chainId == nil {
+		return tx.SigHash()
+	}
+	return tx.EIP155SigHash(chainId)
+}
+
+// withSignature returns a copy of tx with a signature of sigHash(tx, chainId).
+func withSignature(tx *types.Transaction, signature []byte, chainId *big.Int) (*types.Transaction, error) {
+	if chainId == nil {
+		return tx.WithSignature(signature)
+	}
+	return tx.WithEIP155Signature(signature, chainId)
 }
 
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
@@ -1134,6 +1293,10 @@ type SendTxArgs struct {
 	Nonce       *rpc.HexNumber  `json:"nonce"`
 	PrivateFrom string          `json:"privateFrom"`
 	PrivateFor  []string        `json:"privateFor"`
+
+	// CorrelationId traces the transaction in the logs of the node, overriding
+	// the X-Correlation-ID header of the request.
+	CorrelationId *string `json:"correlationId"`
 }
 
 // prepareSendTxArgs is a helper function that fills in default values for unspecified tx fields.
@@ -1154,9 +1317,34 @@ func prepareSendTxArgs(ctx context.Context, args SendTxArgs, b Backend) (SendTxA
 	return args, nil
 }
 
+// withCorrelationId returns ctx carrying the correlation ID a transaction is
+// submitted with: the given one, or else the one the caller attached to the
+// request. IDs which can't be logged verbatim are rejected.
+func withCorrelationId(ctx context.Context, id *string) (context.Context, error) {
+	if id != nil {
+		ctx = rpc.WithCorrelationID(ctx, *id)
+	}
+	if err := core.ValidateCorrelationId(rpc.CorrelationIDFromContext(ctx)); err != nil {
+		return ctx, err
+	}
+	return ctx, nil
+}
+
+// recordCorrelationId stores the correlation ID a transaction was submitted
+// with, so it can be looked up with the transaction and its receipt.
+func recordCorrelationId(ctx context.Context, b Backend, txHash common.Hash) {
+	id := rpc.CorrelationIDFromContext(ctx)
+	if id == "" {
+		return
+	}
+	if err := core.WriteTxCorrelationId(b.ChainDb(), txHash, id); err != nil {
+		glog.V(logger.Warn).Infof("Failed to store correlation id of tx %x: %v", txHash, err)
+	}
+}
+
 // submitTransaction is a helper function that submits tx to txPool and creates a log entry.
-func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, signature []byte, isPrivate bool) (common.Hash, error) {
-	signedTx, err := tx.WithSignature(signature)
+func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, signature []byte, chainId *big.Int, isPrivate bool) (common.Hash, error) {
+	signedTx, err := withSignature(tx, signature, chainId)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1167,8 +1355,9 @@ func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, si
 	}
 
 	if err := b.SendTx(ctx, signedTx); err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, txError(err, signedTx)
 	}
+	recordCorrelationId(ctx, b, signedTx.Hash())
 
 	if signedTx.To() == nil {
 		from, _ := signedTx.From()
@@ -1186,7 +1375,10 @@ func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction, si
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
 func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
-	var err error
+	ctx, err := withCorrelationId(ctx, args.CorrelationId)
+	if err != nil {
+		return common.Hash{}, err
+	}
 	args, err = prepareSendTxArgs(ctx, args, s.b)
 	if err != nil {
 		return common.Hash{}, err
@@ -1204,7 +1396,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	data := common.FromHex(args.Data)
 	isPrivate := args.PrivateFor != nil
 	if isPrivate {
-		data, err = private.P.Send(data, args.PrivateFrom, args.PrivateFor)
+		data, err = private.Send(data, args.PrivateFrom, args.PrivateFor)
 		if err != nil {
 			return common.Hash{}, err
 		}
@@ -1215,12 +1407,13 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), nil, data)
 	}
 
-	signature, err := s.b.AccountManager().SignEthereum(args.From, tx.SigHash().Bytes())
+	chainId := signingChainId(s.b, isPrivate)
+	signature, err := s.b.AccountManager().SignEthereum(args.From, sigHash(tx, chainId).Bytes())
 	if err != nil {
-		return common.Hash{}, err
+		return common.Hash{}, rpcError(err)
 	}
 
-	return submitTransaction(ctx, s.b, tx, signature, isPrivate)
+	return submitTransaction(ctx, s.b, tx, signature, chainId, isPrivate)
 }
 
 // SendRawTransaction will add the signed transaction to the transaction pool.
@@ -1230,10 +1423,15 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 	if err := rlp.DecodeBytes(common.FromHex(encodedTx), tx); err != nil {
 		return "", err
 	}
+	ctx, err := withCorrelationId(ctx, nil)
+	if err != nil {
+		return "", err
+	}
 
 	if err := s.b.SendTx(ctx, tx); err != nil {
-		return "", err
+		return "", txError(err, tx)
 	}
+	recordCorrelationId(ctx, s.b, tx.Hash())
 
 	if tx.To() == nil {
 		from, err := tx.FromFrontier()
@@ -1258,7 +1456,7 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, encod
 func (s *PublicTransactionPoolAPI) Sign(addr common.Address, message string) (string, error) {
 	hash := signHash(message)
 	signature, err := s.b.AccountManager().SignEthereum(addr, hash)
-	return common.ToHex(signature), err
+	return common.ToHex(signature), rpcError(err)
 }
 
 // SignTransactionArgs represents the arguments to sign a transaction.
@@ -1449,7 +1647,7 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, tx Tx, gasPrice,
 
 			s.b.RemoveTx(tx.Hash)
 			if err = s.b.SendTx(ctx, signedTx); err != nil {
-				return common.Hash{}, err
+				return common.Hash{}, txError(err, signedTx)
 			}
 
 			return signedTx.Hash(), nil
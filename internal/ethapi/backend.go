@@ -19,7 +19,9 @@ package ethapi
 
 import (
 	"math/big"
+	"time"
 
+	ethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -37,11 +39,13 @@ import (
 type Backend interface {
 	// general Ethereum API
 	Downloader() *downloader.Downloader
+	SyncProgress() ethereum.SyncProgress
 	ProtocolVersion() int
 	SuggestPrice(ctx context.Context) (*big.Int, error)
 	ChainDb() ethdb.Database
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
+	ChainConfig() *core.ChainConfig
 	// BlockChain API
 	SetHead(number uint64)
 	HeaderByNumber(blockNr rpc.BlockNumber) *types.Header
@@ -50,7 +54,11 @@ type Backend interface {
 	GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error)
 	GetReceipts(ctx context.Context, blockHash common.Hash) (types.Receipts, error)
 	GetTd(blockHash common.Hash) *big.Int
-	GetVMEnv(ctx context.Context, msg core.Message, state State, header *types.Header) (vm.Environment, func() error, error)
+	// GetVMEnv returns an environment to execute the message in. Execution is
+	// traced if a tracer is given.
+	GetVMEnv(ctx context.Context, msg core.Message, state State, header *types.Header, tracer vm.Tracer) (*core.VMEnv, func() error, error)
+	EVMTimeout(method string) time.Duration
+	CallCache() *CallCache // nil if eth_call results aren't cached
 	// TxPool API
 	SendTx(ctx context.Context, signedTx *types.Transaction) error
 	RemoveTx(txHash common.Hash)
@@ -68,7 +76,7 @@ type State interface {
 	GetNonce(ctx context.Context, addr common.Address) (uint64, error)
 }
 
-func GetAPIs(apiBackend Backend, solcPath string) []rpc.API {
+func GetAPIs(apiBackend Backend, solcPath string, unlockConfig UnlockConfig) []rpc.API {
 	compiler := makeCompilerAPIs(solcPath)
 	all := []rpc.API{
 		{
@@ -108,8 +116,13 @@ func GetAPIs(apiBackend Backend, solcPath string) []rpc.API {
 		}, {
 			Namespace: "personal",
 			Version:   "1.0",
-			Service:   NewPrivateAccountAPI(apiBackend),
+			Service:   NewPrivateAccountAPI(apiBackend, unlockConfig),
 			Public:    false,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicQuorumSendAPI(apiBackend),
+			Public:    true,
 		},
 	}
 	return append(compiler, all...)
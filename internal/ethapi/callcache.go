@@ -0,0 +1,147 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	callCacheHitMeter  = metrics.NewMeter("rpc/callcache/hits")
+	callCacheMissMeter = metrics.NewMeter("rpc/callcache/misses")
+)
+
+// CallCacheConfig configures the eth_call result cache.
+type CallCacheConfig struct {
+	TTL  time.Duration // How long results are served from the cache, 0 disables the cache
+	Size int           // Maximum number of cached results
+}
+
+// CallCache caches the results of eth_call, keyed on the block the call
+// executed on and its parameters. Results only depend on these, so the TTL
+// merely bounds how long a result is kept. Calls on the pending block aren't
+// cached, as its state changes with every transaction. Results of earlier
+// blocks are dropped as a new head is imported, as calls rarely target them.
+type CallCache struct {
+	config CallCacheConfig
+
+	lock    sync.Mutex
+	entries map[common.Hash]*callCacheEntry
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+type callCacheEntry struct {
+	block   common.Hash
+	result  string
+	expires time.Time
+}
+
+// NewCallCache creates a call cache, or returns nil if the config disables it.
+func NewCallCache(config CallCacheConfig) *CallCache {
+	if config.TTL <= 0 || config.Size <= 0 {
+		return nil
+	}
+	return &CallCache{
+		config:  config,
+		entries: make(map[common.Hash]*callCacheEntry),
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start drops the results of earlier blocks whenever a new head is imported.
+func (c *CallCache) Start(mux *event.TypeMux) {
+	sub := mux.SubscribeWith(event.SubscriptionConfig{Name: "rpc/callcache", Buffer: 1, Overflow: event.DropOldest}, core.ChainHeadEvent{})
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case ev, ok := <-sub.Chan():
+				if !ok {
+					return
+				}
+				c.invalidate(ev.Data.(core.ChainHeadEvent).Block.Hash())
+			case <-c.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop terminates the invalidation of results.
+func (c *CallCache) Stop() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// callCacheKey returns the cache key of a call on a block, false if the call
+// can't be encoded.
+func callCacheKey(block common.Hash, args CallArgs, overrides StateOverride) (common.Hash, bool) {
+	blob, err := json.Marshal(struct {
+		Args      CallArgs
+		Overrides StateOverride
+	}{args, overrides})
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return crypto.Keccak256Hash(block[:], blob), true
+}
+
+// get returns the cached result of a call, if any.
+func (c *CallCache) get(key common.Hash) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		callCacheMissMeter.Mark(1)
+		return "", false
+	}
+	callCacheHitMeter.Mark(1)
+	return entry.result, true
+}
+
+// put caches the result of a call. Once the cache is full, expired results are
+// dropped and, if none expired, an arbitrary one.
+func (c *CallCache) put(key, block common.Hash, result string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.config.Size {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		for k := range c.entries {
+			if len(c.entries) < c.config.Size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = &callCacheEntry{block: block, result: result, expires: now.Add(c.config.TTL)}
+}
+
+// invalidate drops the results of all blocks but the given head.
+func (c *CallCache) invalidate(head common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, entry := range c.entries {
+		if entry.block != head {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package ethapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Tests that cached results are keyed on block and call, expire after the TTL
+// and are dropped for earlier blocks on a new head.
+func TestCallCache(t *testing.T) {
+	if NewCallCache(CallCacheConfig{Size: 10}) != nil {
+		t.Fatalf("cache without TTL created")
+	}
+	cache := NewCallCache(CallCacheConfig{TTL: time.Hour, Size: 2})
+
+	var (
+		to          = common.Address{1}
+		block1      = common.Hash{1}
+		block2      = common.Hash{2}
+		call        = CallArgs{To: &to, Data: "0x01"}
+		other       = CallArgs{To: &to, Data: "0x02"}
+		key1, _     = callCacheKey(block1, call, nil)
+		key2, _     = callCacheKey(block2, call, nil)
+		keyOther, _ = callCacheKey(block1, other, nil)
+	)
+	if key1 == key2 || key1 == keyOther {
+		t.Fatalf("keys of different blocks or calls collide")
+	}
+	if overridden, _ := callCacheKey(block1, call, StateOverride{to: {}}); overridden == key1 {
+		t.Fatalf("key ignores the state overrides")
+	}
+	cache.put(key1, block1, "0x2a")
+	if result, ok := cache.get(key1); !ok || result != "0x2a" {
+		t.Fatalf("cached result mismatch: have %q %v, want 0x2a", result, ok)
+	}
+	if _, ok := cache.get(key2); ok {
+		t.Fatalf("result served for another block")
+	}
+	// A full cache makes room for new results
+	cache.put(keyOther, block1, "0x01")
+	cache.put(key2, block2, "0x2b")
+	if len(cache.entries) > 2 {
+		t.Fatalf("cache exceeds its size: %d entries", len(cache.entries))
+	}
+	// New heads drop the results of other blocks
+	mux := new(event.TypeMux)
+	cache.Start(mux)
+	defer cache.Stop()
+	mux.Post(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{})})
+	size := func() int {
+		cache.lock.Lock()
+		defer cache.lock.Unlock()
+		return len(cache.entries)
+	}
+	for i := 0; i < 100 && size() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := cache.get(key2); ok {
+		t.Fatalf("result of an earlier block served after a new head")
+	}
+	// Results expire after the TTL
+	cache.config.TTL = -time.Second
+	cache.put(key1, block1, "0x2a")
+	if _, ok := cache.get(key1); ok {
+		t.Fatalf("expired result served")
+	}
+}
+
+// Tests that calls without a sender are keyed on the local account they're made
+// from, so results don't outlive a change of the first account.
+func TestCallCacheKeySender(t *testing.T) {
+	var (
+		to     = common.Address{1}
+		block  = common.Hash{1}
+		first  = []accounts.Account{{Address: common.Address{0xa}}, {Address: common.Address{0xb}}}
+		second = []accounts.Account{{Address: common.Address{0xb}}}
+	)
+	key := func(from common.Address, local []accounts.Account) common.Hash {
+		args := CallArgs{From: callSender(from, local), To: &to, Data: "0x01"}
+		key, _ := callCacheKey(block, args, nil)
+		return key
+	}
+	if key(common.Address{}, first) != key(common.Address{0xa}, first) {
+		t.Errorf("call without sender keyed apart from one by the first account")
+	}
+	if key(common.Address{}, first) == key(common.Address{}, second) {
+		t.Errorf("calls without sender keyed alike for different first accounts")
+	}
+	if key(common.Address{0xa}, first) != key(common.Address{0xa}, second) {
+		t.Errorf("explicit sender replaced by the first account")
+	}
+	if have := callSender(common.Address{}, nil); have != (common.Address{}) {
+		t.Errorf("sender without local accounts: have %x, want the zero address", have)
+	}
+}
@@ -0,0 +1,146 @@
+package ethapi
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// JSON-RPC error codes for common transaction and account failures. Errors
+// carrying these codes also carry a data object with a machine readable
+// "reason" and, where known, the transaction sender and nonce.
+const (
+	ErrCodeInsufficientFunds = -32010
+	ErrCodeNonceTooLow       = -32011
+	ErrCodeGasLimit          = -32012
+	ErrCodeIntrinsicGas      = -32013
+	ErrCodeInvalidChainId    = -32014
+	ErrCodeUnprotectedTx     = -32015
+	ErrCodePermissionDenied  = -32030
+
+	// ErrCodeReverted is returned by eth_call and eth_estimateGas for reverted
+	// executions, with the revert data as error data, like other clients do.
+	ErrCodeReverted = 3
+)
+
+// codedError is an error returned over JSON-RPC with a specific code and
+// structured data.
+type codedError struct {
+	code    int
+	message string
+	data    map[string]interface{}
+}
+
+func (e *codedError) Error() string          { return e.message }
+func (e *codedError) ErrorCode() int         { return e.code }
+func (e *codedError) ErrorData() interface{} { return e.data }
+
+func permissionDenied(reason, message string) error {
+	return &codedError{code: ErrCodePermissionDenied, message: message, data: map[string]interface{}{"reason": reason}}
+}
+
+// rpcError attaches an error code and data to well-known errors. Other errors
+// are returned unchanged.
+func rpcError(err error) error {
+	var (
+		code   int
+		reason string
+	)
+	switch {
+	case err == core.ErrInsufficientFunds:
+		code, reason = ErrCodeInsufficientFunds, "insufficientFunds"
+	case err == core.ErrNonce || core.IsNonceErr(err):
+		code, reason = ErrCodeNonceTooLow, "nonceTooLow"
+	case err == core.ErrGasLimit:
+		code, reason = ErrCodeGasLimit, "gasLimitExceeded"
+	case err == core.ErrIntrinsicGas:
+		code, reason = ErrCodeIntrinsicGas, "intrinsicGasTooLow"
+	case err == types.ErrInvalidChainId:
+		code, reason = ErrCodeInvalidChainId, "invalidChainId"
+	case err == core.ErrUnprotectedTx:
+		code, reason = ErrCodeUnprotectedTx, "unprotectedTx"
+	case err == accounts.ErrLocked:
+		code, reason = ErrCodePermissionDenied, "accountLocked"
+	case err == accounts.ErrDecrypt:
+		code, reason = ErrCodePermissionDenied, "invalidPassword"
+	case err == accounts.ErrNoMatch:
+		code, reason = ErrCodePermissionDenied, "unknownAccount"
+	case err == errUnlockDisabled, err == errUnlockTokenRequired:
+		code, reason = ErrCodePermissionDenied, "unlockDisabled"
+	default:
+		return err
+	}
+	return &codedError{code: code, message: err.Error(), data: map[string]interface{}{"reason": reason}}
+}
+
+// txError is like rpcError, but adds the sender and nonce of tx to the data.
+func txError(err error, tx *types.Transaction) error {
+	coded, ok := rpcError(err).(*codedError)
+	if !ok {
+		return err
+	}
+	if from, err := tx.From(); err == nil {
+		coded.data["from"] = from
+	}
+	coded.data["nonce"] = tx.Nonce()
+	return coded
+}
+
+// revertReasonSelector is the selector of Error(string), which Solidity uses to
+// encode the reason passed to revert and require.
+var revertReasonSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// revertError is an execution reverted error. Its data is the hex encoded
+// revert data.
+type revertError struct {
+	message string
+	data    string
+}
+
+func (e *revertError) Error() string          { return e.message }
+func (e *revertError) ErrorCode() int         { return ErrCodeReverted }
+func (e *revertError) ErrorData() interface{} { return e.data }
+
+func newRevertError(data []byte) *revertError {
+	message := "execution reverted"
+	if reason, ok := unpackRevertReason(data); ok {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	return &revertError{message: message, data: common.ToHex(data)}
+}
+
+// unpackRevertReason decodes the reason string of revert data encoded as
+// Error(string).
+func unpackRevertReason(data []byte) (string, bool) {
+	if len(data) < 4+64 || string(data[:4]) != string(revertReasonSelector) {
+		return "", false
+	}
+	data = data[4:]
+	offset, ok := abiWord(data, 0)
+	if !ok {
+		return "", false
+	}
+	length, ok := abiWord(data, offset)
+	if !ok || length > uint64(len(data)) || offset+32+length > uint64(len(data)) {
+		return "", false
+	}
+	return string(data[offset+32 : offset+32+length]), true
+}
+
+// abiWord returns the ABI encoded integer at offset, if it fits into 64 bits.
+func abiWord(data []byte, offset uint64) (uint64, bool) {
+	if offset+32 > uint64(len(data)) || offset+32 < offset {
+		return 0, false
+	}
+	word := data[offset : offset+32]
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), true
+}
@@ -0,0 +1,52 @@
+package ethapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/net/context"
+)
+
+func TestRevertErrorReason(t *testing.T) {
+	// Error("insufficient balance")
+	data := common.FromHex("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000014" +
+		"696e73756666696369656e742062616c616e6365000000000000000000000000")
+
+	err := newRevertError(data)
+	if want := "execution reverted: insufficient balance"; err.Error() != want {
+		t.Errorf("message mismatch: have %q, want %q", err.Error(), want)
+	}
+	if err.ErrorCode() != ErrCodeReverted {
+		t.Errorf("code mismatch: have %d, want %d", err.ErrorCode(), ErrCodeReverted)
+	}
+	if err.ErrorData() != common.ToHex(data) {
+		t.Errorf("data mismatch: have %v, want %s", err.ErrorData(), common.ToHex(data))
+	}
+
+	// Revert data that isn't a reason string is only returned as data
+	if err := newRevertError([]byte{0x01, 0x02}); err.Error() != "execution reverted" {
+		t.Errorf("unexpected message for raw revert data: %q", err.Error())
+	}
+	if _, ok := unpackRevertReason(data[:4+64+10]); ok {
+		t.Error("decoded truncated reason")
+	}
+}
+
+func TestAbortError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := AbortError(ctx, time.Second); err != ErrExecutionCancelled {
+		t.Errorf("cancelled request: have %v, want %v", err, ErrExecutionCancelled)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	err, ok := AbortError(ctx, time.Second).(*TimeoutError)
+	if !ok || err.Timeout != time.Second {
+		t.Errorf("timed out request: have %v, want a timeout of 1s", err)
+	}
+}
@@ -0,0 +1,94 @@
+package ethapi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// maxFeeHistory is the maximum number of blocks that can be requested from
+// eth_feeHistory.
+const maxFeeHistory = 1024
+
+// FeeHistoryResult is the result of eth_feeHistory. Quorum networks have no
+// base fee and don't price transactions, so base fees and rewards are zero;
+// only the gas used ratios reflect actual usage.
+type FeeHistoryResult struct {
+	OldestBlock   *rpc.HexNumber     `json:"oldestBlock"`
+	BaseFeePerGas []*rpc.HexNumber   `json:"baseFeePerGas"`
+	GasUsedRatio  []float64          `json:"gasUsedRatio"`
+	Reward        [][]*rpc.HexNumber `json:"reward,omitempty"`
+}
+
+// MaxPriorityFeePerGas returns a suggestion for the gas tip of dynamic fee
+// transactions. Quorum doesn't price gas, so this is always zero.
+func (s *PublicEthereumAPI) MaxPriorityFeePerGas() *rpc.HexNumber {
+	return rpc.NewHexNumber(0)
+}
+
+// FeeHistory returns the fee market history of the blockCount blocks up to
+// and including newestBlock. It exists so that clients which estimate fees
+// before sending a transaction work against Quorum unmodified. A blockCount of
+// zero yields an empty history starting at newestBlock.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount rpc.HexNumber, newestBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	for i, p := range rewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid reward percentile: %f", p)
+		}
+		if i > 0 && p < rewardPercentiles[i-1] {
+			return nil, fmt.Errorf("invalid reward percentile: #%d:%f > #%d:%f", i-1, rewardPercentiles[i-1], i, p)
+		}
+	}
+	count := blockCount.Uint64()
+	if count > maxFeeHistory {
+		count = maxFeeHistory
+	}
+	newest := s.b.HeaderByNumber(newestBlock)
+	if newest == nil {
+		return nil, fmt.Errorf("block %d not found", newestBlock)
+	}
+	if count == 0 {
+		return &FeeHistoryResult{
+			OldestBlock:   rpc.NewHexNumber(newest.Number.Uint64()),
+			BaseFeePerGas: []*rpc.HexNumber{},
+			GasUsedRatio:  []float64{},
+		}, nil
+	}
+	if last := newest.Number.Uint64(); count > last+1 {
+		count = last + 1
+	}
+	oldest := newest.Number.Uint64() + 1 - count
+
+	result := &FeeHistoryResult{
+		OldestBlock:   rpc.NewHexNumber(oldest),
+		BaseFeePerGas: make([]*rpc.HexNumber, count+1),
+		GasUsedRatio:  make([]float64, count),
+	}
+	for i := range result.BaseFeePerGas {
+		result.BaseFeePerGas[i] = rpc.NewHexNumber(0)
+	}
+	if len(rewardPercentiles) > 0 {
+		result.Reward = make([][]*rpc.HexNumber, count)
+	}
+	for i := uint64(0); i < count; i++ {
+		header := newest
+		if number := oldest + i; number != newest.Number.Uint64() {
+			if header = s.b.HeaderByNumber(rpc.BlockNumber(number)); header == nil {
+				return nil, fmt.Errorf("block %d not found", number)
+			}
+		}
+		if header.GasLimit.Sign() > 0 {
+			ratio, _ := new(big.Rat).SetFrac(header.GasUsed, header.GasLimit).Float64()
+			result.GasUsedRatio[i] = ratio
+		}
+		if result.Reward != nil {
+			result.Reward[i] = make([]*rpc.HexNumber, len(rewardPercentiles))
+			for j := range rewardPercentiles {
+				result.Reward[i][j] = rpc.NewHexNumber(0)
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// feeTestBackend serves a chain of headers, each using half its gas limit.
+type feeTestBackend struct {
+	Backend
+	head uint64
+}
+
+func (b *feeTestBackend) HeaderByNumber(blockNr rpc.BlockNumber) *types.Header {
+	number := uint64(blockNr)
+	if blockNr == rpc.LatestBlockNumber {
+		number = b.head
+	}
+	if number > b.head {
+		return nil
+	}
+	return &types.Header{Number: new(big.Int).SetUint64(number), GasLimit: big.NewInt(100), GasUsed: big.NewInt(50)}
+}
+
+func TestMaxPriorityFeePerGas(t *testing.T) {
+	enc, err := json.Marshal(new(PublicEthereumAPI).MaxPriorityFeePerGas())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(enc) != `"0x0"` {
+		t.Errorf("suggested tip encoded as %s, want \"0x0\"", enc)
+	}
+}
+
+func TestFeeHistory(t *testing.T) {
+	api := NewPublicEthereumAPI(&feeTestBackend{head: 10})
+
+	tests := []struct {
+		count, newest   int64
+		oldest, results int
+	}{
+		{count: 2, newest: 10, oldest: 9, results: 2},
+		{count: 3, newest: -1, oldest: 8, results: 3}, // latest
+		{count: 20, newest: 5, oldest: 0, results: 6},
+		{count: 0, newest: 10, oldest: 10, results: 0},
+		{count: 0, newest: 0, oldest: 0, results: 0},
+	}
+	for i, tt := range tests {
+		result, err := api.FeeHistory(context.Background(), *rpc.NewHexNumber(tt.count), rpc.BlockNumber(tt.newest), []float64{50})
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if have := result.OldestBlock.Int64(); have != int64(tt.oldest) {
+			t.Errorf("test %d: oldest block mismatch: have %d, want %d", i, have, tt.oldest)
+		}
+		if len(result.GasUsedRatio) != tt.results || len(result.Reward) != tt.results {
+			t.Errorf("test %d: history of %d blocks, want %d", i, len(result.GasUsedRatio), tt.results)
+		}
+		fees := 0 // base fees include the one of the block after the newest
+		if tt.results > 0 {
+			fees = tt.results + 1
+		}
+		if len(result.BaseFeePerGas) != fees {
+			t.Errorf("test %d: %d base fees, want %d", i, len(result.BaseFeePerGas), fees)
+		}
+		for j, ratio := range result.GasUsedRatio {
+			if ratio != 0.5 {
+				t.Errorf("test %d: block %d gas used ratio %v, want 0.5", i, j, ratio)
+			}
+		}
+	}
+	if _, err := api.FeeHistory(context.Background(), *rpc.NewHexNumber(1), 11, nil); err == nil {
+		t.Errorf("history of a missing block returned")
+	}
+}
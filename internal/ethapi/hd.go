@@ -0,0 +1,63 @@
+package ethapi
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// NewHDWallet creates the HD wallet of the keystore from the given BIP-39
+// mnemonic, or from a newly generated one if none is given, and encrypts its
+// seed with the password. It returns the mnemonic, which must be backed up.
+func (s *PrivateAccountAPI) NewHDWallet(password string, mnemonic *string) (string, error) {
+	var m string
+	if mnemonic != nil {
+		m = *mnemonic
+	}
+	return s.am.NewHDWallet(m, password)
+}
+
+// DeriveHDAccount returns the address of the HD wallet account at the given
+// derivation path, e.g. m/44'/60'/0'/0/1.
+func (s *PrivateAccountAPI) DeriveHDAccount(path accounts.DerivationPath, password string) (common.Address, error) {
+	return s.am.DeriveHDAccount(path, password)
+}
+
+// UnlockHDAccount unlocks the HD wallet account at the given derivation path
+// for duration seconds (300 if nil), so that it can be used like any unlocked
+// account, and returns its address. Unlocking is restricted like
+// personal_unlockAccount, with attempts counted against the wallet.
+func (s *PrivateAccountAPI) UnlockHDAccount(ctx context.Context, path accounts.DerivationPath, password string, duration *rpc.HexNumber, token *string) (common.Address, error) {
+	wallet, err := s.am.HDWallet()
+	if err != nil {
+		return common.Address{}, err
+	}
+	attempt, err := s.unlock.authorize(ctx, wallet, token)
+	if err != nil {
+		return common.Address{}, rpcError(err)
+	}
+	if duration == nil {
+		duration = rpc.NewHexNumber(300)
+	}
+	d := time.Duration(duration.Int64()) * time.Second
+	addr, err := s.am.UnlockHDAccount(path, password, d)
+	if err != nil {
+		return common.Address{}, rpcError(s.unlock.audit(ctx, wallet, err))
+	}
+	s.unlock.succeeded(wallet, attempt)
+	s.unlock.audit(ctx, addr, nil)
+	return addr, nil
+}
+
+// SignHD calculates an Ethereum ECDSA signature like personal_sign, with the
+// HD wallet account at the given derivation path.
+func (s *PrivateAccountAPI) SignHD(ctx context.Context, message string, path accounts.DerivationPath, passwd string) (string, error) {
+	signature, err := s.am.SignHDWithPassphrase(path, passwd, signHash(message))
+	if err != nil {
+		return "0x", err
+	}
+	return common.ToHex(signature), nil
+}
@@ -0,0 +1,28 @@
+package ethapi
+
+import (
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// AccountInspection is the key provenance and usage of a keystore account.
+type AccountInspection struct {
+	*accounts.KeyInfo
+	*core.AccountUsage
+}
+
+// InspectAccount reports the key file metadata of the given account together
+// with its usage recorded in db. Usage is left out if db is nil.
+func InspectAccount(am *accounts.Manager, db ethdb.Database, addr common.Address) (*AccountInspection, error) {
+	info, err := am.Inspect(accounts.Account{Address: addr})
+	if err != nil {
+		return nil, err
+	}
+	inspection := &AccountInspection{KeyInfo: info}
+	if db != nil {
+		inspection.AccountUsage = core.GetAccountUsage(db, addr)
+	}
+	return inspection, nil
+}
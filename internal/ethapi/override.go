@@ -0,0 +1,66 @@
+package ethapi
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// OverrideAccount holds the fields of an account to override for the duration
+// of an eth_call. State replaces the whole storage of the account, StateDiff
+// only the given slots.
+type OverrideAccount struct {
+	Nonce     *rpc.HexNumber               `json:"nonce"`
+	Code      *rpc.HexBytes                `json:"code"`
+	Balance   *rpc.HexNumber               `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the set of accounts to override in an eth_call, keyed by
+// address.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the accounts in the given states. Accounts which exist in
+// the private state are overridden there, all others in the public state, so
+// that the call sees them where it would look them up.
+func (diff StateOverride) Apply(publicState, privateState *state.StateDB) error {
+	for addr, account := range diff {
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		statedb := publicState
+		if privateState.Exist(addr) {
+			statedb = privateState
+		}
+		if account.State != nil {
+			// Recreate the account to drop its storage, keeping everything else
+			nonce, code := statedb.GetNonce(addr), statedb.GetCode(addr)
+			statedb.CreateAccount(addr)
+			statedb.SetNonce(addr, nonce)
+			statedb.SetCode(addr, code)
+		}
+		if account.Nonce != nil {
+			statedb.SetNonce(addr, (*big.Int)(account.Nonce).Uint64())
+		}
+		if account.Code != nil {
+			statedb.SetCode(addr, *account.Code)
+		}
+		if account.Balance != nil {
+			statedb.SetBalance(addr, (*big.Int)(account.Balance))
+		}
+		storage := account.StateDiff
+		if account.State != nil {
+			storage = account.State
+		}
+		if storage != nil {
+			for key, value := range *storage {
+				statedb.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
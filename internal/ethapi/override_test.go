@@ -0,0 +1,86 @@
+package ethapi
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestStateOverride(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	publicState, _ := state.New(common.Hash{}, db)
+	privateState, _ := state.New(common.Hash{}, db)
+
+	var (
+		public  = common.HexToAddress("0x01")
+		private = common.HexToAddress("0x02")
+		slot1   = common.HexToHash("0x01")
+		slot2   = common.HexToHash("0x02")
+	)
+	publicState.SetState(public, slot1, common.HexToHash("0x11"))
+	publicState.SetState(public, slot2, common.HexToHash("0x12"))
+	privateState.SetNonce(private, 5)
+	privateState.SetState(private, slot1, common.HexToHash("0x21"))
+	privateState.SetState(private, slot2, common.HexToHash("0x22"))
+
+	var overrides StateOverride
+	if err := json.Unmarshal([]byte(`{
+		"0x0000000000000000000000000000000000000001": {
+			"balance": "0x64",
+			"code": "0x6001",
+			"stateDiff": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000ff"}
+		},
+		"0x0000000000000000000000000000000000000002": {
+			"state": {"0x0000000000000000000000000000000000000000000000000000000000000001": "0x00000000000000000000000000000000000000000000000000000000000000ff"}
+		}
+	}`), &overrides); err != nil {
+		t.Fatal(err)
+	}
+	if err := overrides.Apply(publicState, privateState); err != nil {
+		t.Fatal(err)
+	}
+
+	if balance := publicState.GetBalance(public); balance.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("balance mismatch: have %v, want 100", balance)
+	}
+	if code := publicState.GetCode(public); string(code) != "\x60\x01" {
+		t.Errorf("code mismatch: have %x, want 6001", code)
+	}
+	if value := publicState.GetState(public, slot1); value != common.HexToHash("0xff") {
+		t.Errorf("overridden slot mismatch: have %x", value)
+	}
+	if value := publicState.GetState(public, slot2); value != common.HexToHash("0x12") {
+		t.Errorf("stateDiff changed other slot: have %x", value)
+	}
+
+	// The private account is overridden in the private state, its storage replaced
+	if publicState.Exist(private) {
+		t.Error("private account overridden in the public state")
+	}
+	if nonce := privateState.GetNonce(private); nonce != 5 {
+		t.Errorf("nonce not kept: have %d, want 5", nonce)
+	}
+	if value := privateState.GetState(private, slot1); value != common.HexToHash("0xff") {
+		t.Errorf("overridden slot mismatch: have %x", value)
+	}
+	if value := privateState.GetState(private, slot2); value != (common.Hash{}) {
+		t.Errorf("state didn't replace other slot: have %x", value)
+	}
+}
+
+func TestStateOverrideStateAndStateDiff(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, db)
+
+	storage := map[common.Hash]common.Hash{}
+	overrides := StateOverride{
+		common.HexToAddress("0x01"): {State: &storage, StateDiff: &storage},
+	}
+	if err := overrides.Apply(statedb, statedb); err == nil {
+		t.Fatal("expected error for both state and stateDiff")
+	}
+}
@@ -0,0 +1,93 @@
+package ethapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"golang.org/x/net/context"
+)
+
+// ErrCodeWaitTimeout is the JSON-RPC error code of quorum_sendAndWait calls
+// whose transaction wasn't included within the timeout.
+const ErrCodeWaitTimeout = -32041
+
+const (
+	defaultWaitTimeout = 60 * time.Second
+	maxWaitTimeout     = 10 * time.Minute
+)
+
+// PublicQuorumSendAPI sends transactions and waits for their inclusion, so
+// clients don't have to poll for receipts.
+type PublicQuorumSendAPI struct {
+	txPool *PublicTransactionPoolAPI
+	b      Backend
+}
+
+// NewPublicQuorumSendAPI creates a new PublicQuorumSendAPI instance.
+func NewPublicQuorumSendAPI(b Backend) *PublicQuorumSendAPI {
+	return &PublicQuorumSendAPI{NewPublicTransactionPoolAPI(b), b}
+}
+
+// SendAndWait sends a (private) transaction like eth_sendTransaction, and
+// returns its receipt once the block including it is part of the canonical
+// chain: once the block was applied in raft mode, once it got the votes to
+// become canonical with QuorumChain. The timeout is in seconds, 60 if omitted
+// and at most 600. After a timeout the transaction is still pending, its hash
+// is in the error data.
+func (s *PublicQuorumSendAPI) SendAndWait(ctx context.Context, args SendTxArgs, timeout *int) (map[string]interface{}, error) {
+	wait := defaultWaitTimeout
+	if timeout != nil {
+		if *timeout <= 0 {
+			return nil, errors.New("timeout must be positive")
+		}
+		wait = time.Duration(*timeout) * time.Second
+		if wait > maxWaitTimeout {
+			wait = maxWaitTimeout
+		}
+	}
+	hash, err := s.txPool.SendTransaction(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	wctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	if err := waitCanonical(wctx, s.b.ChainDb(), s.b.EventMux(), hash); err != nil {
+		if err == context.DeadlineExceeded {
+			return nil, &codedError{
+				code:    ErrCodeWaitTimeout,
+				message: fmt.Sprintf("transaction %x not included within %v", hash, wait),
+				data:    map[string]interface{}{"reason": "waitTimeout", "transactionHash": hash, "timeout": wait.String()},
+			}
+		}
+		return nil, err
+	}
+	return s.txPool.GetTransactionReceipt(hash)
+}
+
+// waitCanonical returns once the transaction with the given hash is included
+// in the canonical chain, or with the error of ctx once it's done.
+func waitCanonical(ctx context.Context, db ethdb.Database, mux *event.TypeMux, hash common.Hash) error {
+	sub := mux.Subscribe(core.ChainHeadEvent{})
+	defer sub.Unsubscribe()
+
+	for {
+		// Checked after subscribing, so heads imported meanwhile aren't missed
+		if tx, blockHash, number, _ := core.GetTransaction(db, hash); tx != nil && core.GetCanonicalHash(db, number) == blockHash && core.GetReceipt(db, hash) != nil {
+			return nil
+		}
+		select {
+		case _, ok := <-sub.Chan():
+			if !ok {
+				return errors.New("node stopped")
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
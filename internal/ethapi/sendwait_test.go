@@ -0,0 +1,53 @@
+package ethapi
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"golang.org/x/net/context"
+)
+
+// Tests that waiting for a transaction returns once a block including it
+// becomes the canonical head, and times out otherwise.
+func TestWaitCanonical(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	mux := new(event.TypeMux)
+	tx := types.NewTransaction(0, [20]byte{1}, new(big.Int), big.NewInt(21000), new(big.Int), nil)
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := waitCanonical(ctx, db, mux, tx.Hash()); err != context.DeadlineExceeded {
+		t.Fatalf("wait for a pending transaction: have %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- waitCanonical(context.Background(), db, mux, tx.Hash())
+	}()
+	// Blocks which aren't canonical don't count
+	core.WriteTransactions(db, block)
+	core.WriteReceipts(db, types.Receipts{&types.Receipt{TxHash: tx.Hash(), Logs: nil, CumulativeGasUsed: new(big.Int), GasUsed: new(big.Int)}})
+	mux.Post(core.ChainHeadEvent{})
+	select {
+	case err := <-done:
+		t.Fatalf("wait returned before the block was canonical: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	core.WriteCanonicalHash(db, block.Hash(), 1)
+	mux.Post(core.ChainHeadEvent{Block: block})
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("wait failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("wait didn't return once the block was canonical")
+	}
+}
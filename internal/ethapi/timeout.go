@@ -0,0 +1,83 @@
+package ethapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"golang.org/x/net/context"
+)
+
+// ErrCodeTimeout is the JSON-RPC error code of executions aborted because they
+// exceeded their timeout.
+const ErrCodeTimeout = -32040
+
+// EVMTimeouts limits how long RPC methods may execute the EVM.
+type EVMTimeouts struct {
+	Default time.Duration            // Timeout of methods without a specific one (0 = unlimited)
+	Methods map[string]time.Duration // Per method timeouts, keyed by method name, e.g. "eth_call"
+}
+
+// Timeout returns the EVM timeout of the given RPC method.
+func (t EVMTimeouts) Timeout(method string) time.Duration {
+	if timeout, ok := t.Methods[method]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+// TimeoutError is returned by RPC methods whose EVM execution was aborted
+// because it exceeded the configured timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("execution aborted (timeout = %v)", e.Timeout)
+}
+
+// ErrorCode implements rpc.Error.
+func (e *TimeoutError) ErrorCode() int { return ErrCodeTimeout }
+
+// ErrorData implements rpc.DataError.
+func (e *TimeoutError) ErrorData() interface{} {
+	return map[string]interface{}{"reason": "executionTimeout", "timeout": e.Timeout.String()}
+}
+
+// ErrExecutionCancelled is returned by RPC methods whose EVM execution was
+// aborted because the request was cancelled, e.g. by the client disconnecting.
+var ErrExecutionCancelled = errors.New("execution aborted (request cancelled)")
+
+// AbortError returns the error of an execution aborted by CancelOnTimeout:
+// ErrExecutionCancelled if ctx was cancelled, a TimeoutError otherwise.
+func AbortError(ctx context.Context, timeout time.Duration) error {
+	if ctx.Err() == context.Canceled {
+		return ErrExecutionCancelled
+	}
+	return &TimeoutError{Timeout: timeout}
+}
+
+// CancelOnTimeout aborts the execution in env once timeout has passed or ctx
+// is done, whichever comes first. A zero timeout only aborts on ctx. The
+// returned function must be called when the execution finished.
+func CancelOnTimeout(ctx context.Context, env *core.VMEnv, timeout time.Duration) context.CancelFunc {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			env.Cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		cancel()
+	}
+}
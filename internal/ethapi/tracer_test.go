@@ -32,7 +32,13 @@ import (
 type ruleSet struct{}
 
 func (self *ruleSet) IsHomestead(*big.Int) bool    { return true }
+func (self *ruleSet) IsByzantium(*big.Int) bool    { return true }
 func (*ruleSet) GasTable(*big.Int) params.GasTable { return params.GasTableHomesteadGasRepriceFork }
+func (*ruleSet) Precompile(common.Address, *big.Int) vm.PrecompiledContract {
+	return nil
+}
+func (*ruleSet) MaxCodeSize(*big.Int) int { return 0 }
+func (*ruleSet) Limits(*big.Int) vm.Limits { return vm.DefaultLimits }
 
 type Env struct {
 	gasLimit *big.Int
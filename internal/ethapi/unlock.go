@@ -0,0 +1,156 @@
+package ethapi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// unlockFailureWindow is the period over which failed unlock attempts are
+// counted against UnlockConfig.MaxFailures.
+const unlockFailureWindow = time.Minute
+
+var (
+	errUnlockDisabled      = errors.New("account unlocking via RPC is disabled (--allow-insecure-unlock=false)")
+	errUnlockTokenRequired = errors.New("account unlocking via RPC requires a one-time unlock token")
+)
+
+// UnlockTokenVerifier validates the one-time tokens callers must supply to
+// personal_unlockAccount when password-only unlocking is not allowed. Tokens
+// are bound to an account: a verifier must reject tokens issued for another one.
+type UnlockTokenVerifier interface {
+	VerifyUnlockToken(account common.Address, token string) error
+}
+
+// UnlockConfig restricts how personal_unlockAccount may be used.
+// The zero value permits password-only unlocking without rate limiting.
+type UnlockConfig struct {
+	DisableInsecure bool                // Reject password-only unlocking
+	Verifier        UnlockTokenVerifier // If set, callers must supply a token it accepts
+	MaxFailures     int                 // Failed attempts permitted per account and minute (0 = unlimited)
+}
+
+// unlockGuard enforces an UnlockConfig, rate limiting failed attempts per
+// account and writing every attempt to the audit log.
+type unlockGuard struct {
+	config UnlockConfig
+
+	lock     sync.Mutex
+	failures map[common.Address][]time.Time
+}
+
+func newUnlockGuard(config UnlockConfig) *unlockGuard {
+	return &unlockGuard{
+		config:   config,
+		failures: make(map[common.Address][]time.Time),
+	}
+}
+
+// authorize checks whether an unlock attempt for account may proceed at all,
+// verifying that the supplied token was issued for account if the configuration
+// requires one. The attempt is counted as failed from then on, until succeeded
+// is called with the returned time.
+func (g *unlockGuard) authorize(ctx context.Context, account common.Address, token *string) (time.Time, error) {
+	now := time.Now()
+	switch {
+	case g.config.Verifier != nil:
+		if token == nil || *token == "" {
+			return now, g.audit(ctx, account, errUnlockTokenRequired)
+		}
+	case g.config.DisableInsecure:
+		return now, g.audit(ctx, account, errUnlockDisabled)
+	}
+	if err := g.throttle(account, now); err != nil {
+		return now, g.audit(ctx, account, err)
+	}
+	if g.config.Verifier != nil {
+		if err := g.config.Verifier.VerifyUnlockToken(account, *token); err != nil {
+			return now, g.audit(ctx, account, permissionDenied("invalidUnlockToken", fmt.Sprintf("invalid unlock token: %v", err)))
+		}
+	}
+	return now, nil
+}
+
+// throttle returns an error if account has exhausted its failed attempts
+// within the current window, and otherwise counts an attempt at now as failed.
+// Checking and counting under one lock keeps concurrent attempts from getting
+// past the limit before their failures are recorded.
+func (g *unlockGuard) throttle(account common.Address, now time.Time) error {
+	if g.config.MaxFailures <= 0 {
+		return nil
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	recent := g.failures[account][:0]
+	for _, t := range g.failures[account] {
+		if now.Sub(t) < unlockFailureWindow {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= g.config.MaxFailures {
+		g.failures[account] = recent
+		retry := unlockFailureWindow - now.Sub(recent[0])
+		return permissionDenied("unlockThrottled", fmt.Sprintf("too many failed unlock attempts, retry in %v", retry))
+	}
+	g.failures[account] = append(recent, now)
+	return nil
+}
+
+// succeeded stops counting the attempt for account made at the given time as
+// failed.
+func (g *unlockGuard) succeeded(account common.Address, at time.Time) {
+	if g.config.MaxFailures <= 0 {
+		return
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	failures := g.failures[account]
+	for i, t := range failures {
+		if t.Equal(at) {
+			failures = append(failures[:i], failures[i+1:]...)
+			break
+		}
+	}
+	if len(failures) == 0 {
+		delete(g.failures, account)
+	} else {
+		g.failures[account] = failures
+	}
+}
+
+// audit logs the outcome of an unlock attempt along with the RPC caller it
+// came from, and passes err through.
+func (g *unlockGuard) audit(ctx context.Context, account common.Address, err error) error {
+	origin := unlockOrigin(ctx)
+	if err != nil {
+		glog.V(logger.Warn).Infof("personal_unlockAccount audit: account %x rejected, caller %s: %v", account, origin, err)
+	} else {
+		glog.V(logger.Info).Infof("personal_unlockAccount audit: account %x unlocked, caller %s", account, origin)
+	}
+	return err
+}
+
+// unlockOrigin describes the RPC caller of an unlock attempt for the audit log.
+func unlockOrigin(ctx context.Context) string {
+	caller, ok := rpc.CallerFromContext(ctx)
+	if !ok {
+		return "in-process"
+	}
+	origin := caller.Transport
+	if caller.RemoteAddr != "" {
+		origin += " " + caller.RemoteAddr
+	}
+	if caller.Identity != "" {
+		origin += " as " + caller.Identity
+	}
+	return origin
+}
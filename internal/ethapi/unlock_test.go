@@ -0,0 +1,168 @@
+package ethapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+type testUnlockVerifier string
+
+func (v testUnlockVerifier) VerifyUnlockToken(account common.Address, token string) error {
+	if token != string(v) {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+// testAccountVerifier accepts the tokens issued for each account.
+type testAccountVerifier map[common.Address]string
+
+func (v testAccountVerifier) VerifyUnlockToken(account common.Address, token string) error {
+	if issued, ok := v[account]; !ok || token != issued {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+func TestUnlockGuardModes(t *testing.T) {
+	var (
+		account = common.HexToAddress("0x01")
+		good    = "secret"
+		bad     = "guess"
+	)
+	tests := []struct {
+		config UnlockConfig
+		token  *string
+		ok     bool
+	}{
+		{UnlockConfig{}, nil, true},
+		{UnlockConfig{DisableInsecure: true}, nil, false},
+		{UnlockConfig{DisableInsecure: true}, &good, false},
+		{UnlockConfig{Verifier: testUnlockVerifier(good)}, nil, false},
+		{UnlockConfig{Verifier: testUnlockVerifier(good)}, &bad, false},
+		{UnlockConfig{Verifier: testUnlockVerifier(good)}, &good, true},
+		{UnlockConfig{DisableInsecure: true, Verifier: testUnlockVerifier(good)}, &good, true},
+	}
+	for i, tt := range tests {
+		_, err := newUnlockGuard(tt.config).authorize(context.Background(), account, tt.token)
+		if (err == nil) != tt.ok {
+			t.Errorf("test %d: authorize error mismatch: have %v, want ok %v", i, err, tt.ok)
+		}
+	}
+}
+
+func TestUnlockGuardThrottle(t *testing.T) {
+	var (
+		guard = newUnlockGuard(UnlockConfig{MaxFailures: 2})
+		alice = common.HexToAddress("0x01")
+		bob   = common.HexToAddress("0x02")
+		now   = time.Now()
+	)
+	for i := 0; i < 2; i++ {
+		if err := guard.throttle(alice, now); err != nil {
+			t.Fatalf("attempt %d throttled early: %v", i, err)
+		}
+	}
+	if err := guard.throttle(alice, now); err == nil {
+		t.Fatalf("attempt after %d failures not throttled", 2)
+	}
+	if err := guard.throttle(bob, now); err != nil {
+		t.Fatalf("unrelated account throttled: %v", err)
+	}
+	if err := guard.throttle(alice, now.Add(unlockFailureWindow)); err != nil {
+		t.Fatalf("attempt after window expiry throttled: %v", err)
+	}
+}
+
+func TestUnlockGuardSucceeded(t *testing.T) {
+	var (
+		guard = newUnlockGuard(UnlockConfig{MaxFailures: 1})
+		alice = common.HexToAddress("0x01")
+		now   = time.Now()
+	)
+	for i := 0; i < 3; i++ {
+		at := now.Add(time.Duration(i) * time.Second)
+		if err := guard.throttle(alice, at); err != nil {
+			t.Fatalf("attempt %d after successful ones throttled: %v", i, err)
+		}
+		guard.succeeded(alice, at)
+	}
+	if len(guard.failures) != 0 {
+		t.Errorf("failures recorded after successful attempts: %v", guard.failures)
+	}
+}
+
+// Tests that concurrent attempts can't get past the limit before their
+// failures are recorded.
+func TestUnlockGuardConcurrentAttempts(t *testing.T) {
+	var (
+		guard   = newUnlockGuard(UnlockConfig{MaxFailures: 3, Verifier: testUnlockVerifier("secret")})
+		alice   = common.HexToAddress("0x01")
+		guess   = "guess"
+		results = make(chan error)
+	)
+	for i := 0; i < 20; i++ {
+		go func() {
+			_, err := guard.authorize(context.Background(), alice, &guess)
+			results <- err
+		}()
+	}
+	verified := 0
+	for i := 0; i < 20; i++ {
+		if err := <-results; !strings.Contains(err.Error(), "too many failed unlock attempts") {
+			verified++
+		}
+	}
+	if verified != 3 {
+		t.Errorf("tokens verified for %d concurrent attempts, want 3", verified)
+	}
+}
+
+// Tests that tokens only unlock the account they were issued for.
+func TestUnlockGuardTokenAccount(t *testing.T) {
+	var (
+		alice, bob = common.HexToAddress("0x01"), common.HexToAddress("0x02")
+		aliceToken = "alice-token"
+		guard      = newUnlockGuard(UnlockConfig{Verifier: testAccountVerifier{alice: aliceToken, bob: "bob-token"}})
+	)
+	if _, err := guard.authorize(context.Background(), alice, &aliceToken); err != nil {
+		t.Errorf("token rejected for its account: %v", err)
+	}
+	if _, err := guard.authorize(context.Background(), bob, &aliceToken); err == nil {
+		t.Errorf("token accepted for another account")
+	}
+}
+
+// UnlockOriginService reports the origin of its callers as audit logged.
+type UnlockOriginService struct{}
+
+func (UnlockOriginService) Origin(ctx context.Context) string {
+	return unlockOrigin(ctx)
+}
+
+func TestUnlockOrigin(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("test", UnlockOriginService{}); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+
+	client := rpc.DialInProcAs(server, rpc.Caller{Transport: "http", RemoteAddr: "10.0.0.1:4321", Identity: "ops"})
+	defer client.Close()
+	var origin string
+	if err := client.Call(&origin, "test_origin"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "http 10.0.0.1:4321 as ops"; origin != want {
+		t.Errorf("origin mismatch: have %q, want %q", origin, want)
+	}
+	if origin := unlockOrigin(context.Background()); origin != "in-process" {
+		t.Errorf("origin mismatch without a caller: have %q", origin)
+	}
+}
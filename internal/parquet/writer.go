@@ -0,0 +1,370 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package parquet implements a writer for flat, uncompressed Parquet files.
+//
+// Only what's needed to export tables of integers, strings and booleans is
+// supported: every row group holds a single PLAIN encoded data page per column
+// and optional columns carry RLE encoded definition levels.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var magic = []byte("PAR1")
+
+// RowGroupSize is the number of rows buffered before a row group is written.
+var RowGroupSize = 65536
+
+// Type is the type of the values of a column.
+type Type int
+
+const (
+	Int64  Type = iota // int64, stored as INT64
+	String             // string, stored as UTF8 annotated BYTE_ARRAY
+	Bool               // bool, stored as BOOLEAN
+)
+
+// Parquet physical types, encodings and annotations.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8 = 0
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageData = 0
+)
+
+func (t Type) physical() int32 {
+	switch t {
+	case Int64:
+		return typeInt64
+	case Bool:
+		return typeBoolean
+	default:
+		return typeByteArray
+	}
+}
+
+// Column describes a column of a Parquet file.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool // Whether the column may hold nulls
+}
+
+// columnBuffer holds the values of a column in the current row group.
+type columnBuffer struct {
+	values  []byte // PLAIN encoded values, except booleans
+	bools   []bool // Boolean values, bit packed when the page is written
+	defined []bool // Definition levels of an optional column
+}
+
+// columnChunk is the metadata of a column written in a row group.
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// rowGroup is the metadata of a written row group.
+type rowGroup struct {
+	columns []columnChunk
+	size    int64
+	numRows int64
+}
+
+// Writer writes rows to a Parquet file.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	buffers []columnBuffer
+	rows    int // Rows in the current row group
+	numRows int64
+	offset  int64
+	groups  []rowGroup
+}
+
+// NewWriter creates a writer of a Parquet file with the given columns.
+func NewWriter(w io.Writer, columns []Column) *Writer {
+	return &Writer{
+		w:       w,
+		columns: columns,
+		buffers: make([]columnBuffer, len(columns)),
+	}
+}
+
+// Write appends a row. The values must be given in column order, an int64 for
+// Int64 columns, a string for String columns, a bool for Bool columns and nil
+// for nulls in optional columns.
+func (w *Writer) Write(row ...interface{}) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values, want %d", len(row), len(w.columns))
+	}
+	for i, value := range row {
+		if err := w.checkValue(i, value); err != nil {
+			return err
+		}
+	}
+	for i, value := range row {
+		buf := &w.buffers[i]
+		if w.columns[i].Optional {
+			buf.defined = append(buf.defined, value != nil)
+		}
+		switch v := value.(type) {
+		case int64:
+			buf.values = appendUint64(buf.values, uint64(v))
+		case string:
+			buf.values = appendUint32(buf.values, uint32(len(v)))
+			buf.values = append(buf.values, v...)
+		case bool:
+			buf.bools = append(buf.bools, v)
+		}
+	}
+	w.rows++
+	if w.rows >= RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *Writer) checkValue(i int, value interface{}) error {
+	column := w.columns[i]
+	if value == nil {
+		if !column.Optional {
+			return fmt.Errorf("column %s: null value in required column", column.Name)
+		}
+		return nil
+	}
+	var ok bool
+	switch column.Type {
+	case Int64:
+		_, ok = value.(int64)
+	case String:
+		_, ok = value.(string)
+	case Bool:
+		_, ok = value.(bool)
+	}
+	if !ok {
+		return fmt.Errorf("column %s: invalid value type %T", column.Name, value)
+	}
+	return nil
+}
+
+// Close writes the buffered rows and the file metadata. It doesn't close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	if w.rows > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	footer := w.footer()
+	footer = appendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return w.write(footer)
+}
+
+// start writes the leading magic bytes.
+func (w *Writer) start() error {
+	if w.offset > 0 {
+		return nil
+	}
+	return w.write(magic)
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as a row group, with a single data page per
+// column.
+func (w *Writer) flush() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	group := rowGroup{numRows: int64(w.rows)}
+	for i, column := range w.columns {
+		buf := &w.buffers[i]
+
+		var page []byte
+		if column.Optional {
+			levels := encodeLevels(buf.defined)
+			page = appendUint32(page, uint32(len(levels)))
+			page = append(page, levels...)
+		}
+		if column.Type == Bool {
+			page = append(page, packBools(buf.bools)...)
+		} else {
+			page = append(page, buf.values...)
+		}
+		header := pageHeader(len(page), w.rows)
+
+		chunk := columnChunk{offset: w.offset, size: int64(len(header) + len(page)), numValues: int64(w.rows)}
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+
+		*buf = columnBuffer{}
+	}
+	w.groups = append(w.groups, group)
+	w.numRows += int64(w.rows)
+	w.rows = 0
+	return nil
+}
+
+// pageHeader encodes the header of a data page.
+func pageHeader(size, numValues int) []byte {
+	t := new(thriftWriter)
+	t.begin()
+	t.i32(1, pageData)
+	t.i32(2, int32(size)) // uncompressed size
+	t.i32(3, int32(size)) // compressed size
+	t.structField(5)      // data page header
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE) // definition levels
+	t.i32(4, encodingRLE) // repetition levels
+	t.end()
+	t.end()
+	return t.buf
+}
+
+// footer encodes the file metadata.
+func (w *Writer) footer() []byte {
+	t := new(thriftWriter)
+	t.begin()
+	t.i32(1, 1) // version
+
+	// The schema is a root element with a child per column
+	t.list(2, thriftStruct, len(w.columns)+1)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, column := range w.columns {
+		t.begin()
+		t.i32(1, column.Type.physical())
+		if column.Optional {
+			t.i32(3, repetitionOptional)
+		} else {
+			t.i32(3, repetitionRequired)
+		}
+		t.string(4, column.Name)
+		if column.Type == String {
+			t.i32(6, convertedUTF8)
+		}
+		t.end()
+	}
+	t.i64(3, w.numRows)
+
+	t.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		t.begin()
+		t.list(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			t.begin()
+			t.i64(2, chunk.offset) // file offset
+			t.structField(3)       // column metadata
+			t.i32(1, w.columns[i].Type.physical())
+			t.list(2, thriftI32, 2)
+			t.varint(encodingPlain)
+			t.varint(encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.binary([]byte(w.columns[i].Name))
+			t.i32(4, 0) // uncompressed
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset) // data page offset
+			t.end()
+			t.end()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.end()
+	}
+	t.string(6, "go-ethereum")
+	t.end()
+	return t.buf
+}
+
+// encodeLevels encodes definition levels of bit width 1 with the RLE/bit-packing
+// hybrid encoding, using RLE runs only.
+func encodeLevels(defined []bool) []byte {
+	var (
+		out []byte
+		buf [binary.MaxVarintLen64]byte
+	)
+	for i := 0; i < len(defined); {
+		j := i + 1
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		n := binary.PutUvarint(buf[:], uint64(j-i)<<1)
+		out = append(out, buf[:n]...)
+		if defined[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// packBools encodes booleans PLAIN, as bits in least significant bit order.
+func packBools(values []bool) []byte {
+	out := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			out[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return out
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,247 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// thriftReader decodes the Thrift compact protocol into generic values: structs
+// as maps from field id, lists as slices, integers as int64 and binaries as
+// byte slices.
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		panic("invalid varint")
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.buf[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		typ := header & 0x0f
+		if delta := int16(header >> 4); delta != 0 {
+			last += delta
+		} else {
+			last = int16(r.varint())
+		}
+		fields[last] = r.value(typ)
+	}
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		v := r.buf[r.pos : r.pos+n]
+		r.pos += n
+		return v
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		size, elem := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d", typ))
+}
+
+// readFile decodes a file written by Writer into its rows.
+func readFile(t *testing.T, data []byte, columns []Column) [][]interface{} {
+	if !bytes.HasPrefix(data, magic) || !bytes.HasSuffix(data, magic) {
+		t.Fatalf("missing magic bytes")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := (&thriftReader{buf: data[len(data)-8-size : len(data)-8]}).readStruct()
+
+	schema := footer[2].([]interface{})
+	if len(schema) != len(columns)+1 {
+		t.Fatalf("schema has %d elements, want %d", len(schema), len(columns)+1)
+	}
+	for i, column := range columns {
+		element := schema[i+1].(map[int16]interface{})
+		if name := string(element[4].([]byte)); name != column.Name {
+			t.Errorf("column %d: name mismatch: have %s, want %s", i, name, column.Name)
+		}
+		if typ := element[1].(int64); typ != int64(column.Type.physical()) {
+			t.Errorf("column %d: type mismatch: have %d, want %d", i, typ, column.Type.physical())
+		}
+	}
+	var rows [][]interface{}
+	for _, group := range footer[4].([]interface{}) {
+		group := group.(map[int16]interface{})
+		numRows := int(group[3].(int64))
+		groupRows := make([][]interface{}, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(columns))
+		}
+		for i, chunk := range group[1].([]interface{}) {
+			meta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			r := &thriftReader{buf: data, pos: int(meta[9].(int64))}
+			header := r.readStruct()
+			page := data[r.pos : r.pos+int(header[3].(int64))]
+			if n := header[5].(map[int16]interface{})[1].(int64); int(n) != numRows {
+				t.Fatalf("page has %d values, want %d", n, numRows)
+			}
+			// Expand the definition levels of optional columns
+			defined := make([]bool, numRows)
+			for j := range defined {
+				defined[j] = true
+			}
+			if columns[i].Optional {
+				length := int(binary.LittleEndian.Uint32(page))
+				levels := &thriftReader{buf: page[4 : 4+length]}
+				for j := 0; levels.pos < len(levels.buf); {
+					run := int(levels.uvarint() >> 1)
+					value := levels.buf[levels.pos] == 1
+					levels.pos++
+					for k := 0; k < run; k++ {
+						defined[j] = value
+						j++
+					}
+				}
+				page = page[4+length:]
+			}
+			// Decode the PLAIN encoded values
+			var pos, index int
+			for j := range groupRows {
+				if !defined[j] {
+					continue
+				}
+				switch columns[i].Type {
+				case Int64:
+					groupRows[j][i] = int64(binary.LittleEndian.Uint64(page[pos:]))
+					pos += 8
+				case String:
+					n := int(binary.LittleEndian.Uint32(page[pos:]))
+					groupRows[j][i] = string(page[pos+4 : pos+4+n])
+					pos += 4 + n
+				case Bool:
+					groupRows[j][i] = page[index/8]&(1<<uint(index%8)) != 0
+					index++
+				}
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if n := int(footer[3].(int64)); n != len(rows) {
+		t.Errorf("row count mismatch: have %d, want %d", n, len(rows))
+	}
+	return rows
+}
+
+var testColumns = []Column{
+	{Name: "number", Type: Int64},
+	{Name: "hash", Type: String},
+	{Name: "to", Type: String, Optional: true},
+	{Name: "private", Type: Bool},
+	{Name: "gas", Type: Int64, Optional: true},
+}
+
+// Tests that written rows can be decoded again, across row groups.
+func TestWriter(t *testing.T) {
+	defer func(size int) { RowGroupSize = size }(RowGroupSize)
+	RowGroupSize = 3
+
+	var rows [][]interface{}
+	for i := 0; i < 20; i++ {
+		row := []interface{}{int64(i) - 10, fmt.Sprintf("0x%064x", i), nil, i%3 == 0, nil}
+		if i%4 != 0 {
+			row[2] = fmt.Sprintf("0x%040x", i)
+		}
+		if i > 5 {
+			row[4] = int64(21000 * i)
+		}
+		rows = append(rows, row)
+	}
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf, testColumns)
+	for _, row := range rows {
+		if err := w.Write(row...); err != nil {
+			t.Fatalf("failed to write row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	if have := readFile(t, buf.Bytes(), testColumns); !reflect.DeepEqual(have, rows) {
+		t.Errorf("rows mismatch:\nhave %v\nwant %v", have, rows)
+	}
+}
+
+// Tests that files without rows are valid.
+func TestWriterEmpty(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := NewWriter(buf, testColumns).Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	if rows := readFile(t, buf.Bytes(), testColumns); len(rows) != 0 {
+		t.Errorf("rows in empty file: %v", rows)
+	}
+}
+
+// Tests that rows not matching the columns are rejected.
+func TestWriterInvalidRows(t *testing.T) {
+	tests := [][]interface{}{
+		{int64(1), "0x01", nil, true},                     // too few values
+		{nil, "0x01", nil, true, nil},                     // null in required column
+		{int64(1), "0x01", nil, "true", nil},              // wrong type
+		{uint64(1), "0x01", nil, true, nil},               // unsigned integer
+		{int64(1), "0x01", nil, true, int64(1), int64(1)}, // too many values
+	}
+	for i, row := range tests {
+		if err := NewWriter(new(bytes.Buffer), testColumns).Write(row...); err == nil {
+			t.Errorf("test %d: invalid row accepted", i)
+		}
+	}
+}
@@ -188,6 +188,48 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'denyPeer',
+			call: 'admin_denyPeer',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'allowPeer',
+			call: 'admin_allowPeer',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'removePeerRule',
+			call: 'admin_removePeerRule',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setPeerBandwidth',
+			call: 'admin_setPeerBandwidth',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'reloadConfig',
+			call: 'admin_reloadConfig'
+		}),
+		new web3._extend.Method({
+			name: 'enterMaintenance',
+			call: 'admin_enterMaintenance',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'exitMaintenance',
+			call: 'admin_exitMaintenance'
+		}),
+		new web3._extend.Method({
+			name: 'exportPeerTopology',
+			call: 'admin_exportPeerTopology',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -263,6 +305,24 @@ web3._extend({
 			name: 'httpGet',
 			call: 'admin_httpGet',
 			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'blockPropagation',
+			call: 'admin_blockPropagation'
+		}),
+		new web3._extend.Method({
+			name: 'registerLogContract',
+			call: 'admin_registerLogContract',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'unregisterLogContract',
+			call: 'admin_unregisterLogContract',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'logIndex',
+			call: 'admin_logIndex'
 		})
 	],
 	properties:
@@ -278,6 +338,22 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
+		}),
+		new web3._extend.Property({
+			name: 'peerRules',
+			getter: 'admin_peerRules'
+		}),
+		new web3._extend.Property({
+			name: 'configSummary',
+			getter: 'admin_configSummary'
+		}),
+		new web3._extend.Property({
+			name: 'nodeStatus',
+			getter: 'admin_nodeStatus'
+		}),
+		new web3._extend.Property({
+			name: 'maintenance',
+			getter: 'admin_maintenance'
 		})
 	]
 });
@@ -333,6 +409,21 @@ web3._extend({
 			call: 'debug_dumpBlock',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'dumpStorage',
+			call: 'debug_dumpStorage',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'storagePreimages',
+			call: 'debug_storagePreimages',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'storageRangeAt',
+			call: 'debug_storageRangeAt',
+			params: 5
+		}),
 		new web3._extend.Method({
 			name: 'chaindbProperty',
 			call: 'debug_chaindbProperty',
@@ -434,6 +525,12 @@ web3._extend({
 			call: 'debug_traceTransaction',
 			params: 2,
 			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'simulateBundle',
+			call: 'debug_simulateBundle',
+			params: 2,
+			inputFormatter: [null, null]
 		})
 	],
 	properties: []
@@ -480,6 +577,11 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getTransactionCorrelationId',
+			call: 'eth_getTransactionCorrelationId',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -493,6 +595,30 @@ web3._extend({
 			call: 'eth_storageRoot',
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'createAccessList',
+			call: 'eth_createAccessList',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'privateContract',
+			call: 'eth_privateContract',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'privateContracts',
+			call: 'eth_privateContracts',
+			params: 1,
+			inputFormatter: [null]
 		})
 	],
 	properties:
@@ -508,6 +634,24 @@ web3._extend({
 				}
 				return formatted;
 			}
+		}),
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'eth_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
+		new web3._extend.Property({
+			name: 'syncDetails',
+			getter: 'eth_syncDetails'
+		}),
+		new web3._extend.Property({
+			name: 'privateKeys',
+			getter: 'eth_privateKeys'
+		}),
+		new web3._extend.Property({
+			name: 'chainId',
+			getter: 'eth_chainId',
+			outputFormatter: web3._extend.utils.toDecimal
 		})
 	]
 });
@@ -525,6 +669,10 @@ web3._extend({
                        name: 'role',
                        getter: 'raft_role'
                }),
+               new web3._extend.Property({
+                       name: 'leader',
+                       getter: 'raft_leader'
+               }),
                new web3._extend.Method({
                        name: 'addPeer',
                        call: 'raft_addPeer',
@@ -534,6 +682,21 @@ web3._extend({
                        name: 'removePeer',
                        call: 'raft_removePeer',
                        params: 1
+               }),
+               new web3._extend.Method({
+                       name: 'updatePeer',
+                       call: 'raft_updatePeer',
+                       params: 2
+               }),
+               new web3._extend.Method({
+                       name: 'clusterHistory',
+                       call: 'raft_clusterHistory',
+                       params: 0
+               }),
+               new web3._extend.Method({
+                       name: 'enode',
+                       call: 'raft_enode',
+                       params: 0
                })
        ]
 })
@@ -632,6 +795,40 @@ web3._extend({
 			name: 'ecRecover',
 			call: 'personal_ecRecover',
 			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'unlockAccount',
+			call: 'personal_unlockAccount',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'inspectAccount',
+			call: 'personal_inspectAccount',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'newHDWallet',
+			call: 'personal_newHDWallet',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'deriveHDAccount',
+			call: 'personal_deriveHDAccount',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'unlockHDAccount',
+			call: 'personal_unlockHDAccount',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'signHD',
+			call: 'personal_signHD',
+			params: 3
 		})
 	]
 })
@@ -697,6 +894,12 @@ const Quorum_JS = `
 web3._extend({
 	property: 'quorum',
 	methods: [
+		new web3._extend.Method({
+			name: 'sendAndWait',
+			call: 'quorum_sendAndWait',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'canonicalHash',
 			call: 'quorum_canonicalHash',
@@ -712,6 +915,24 @@ web3._extend({
 			name: 'makeBlock',
 			call: 'quorum_makeBlock'
 		}),
+		new web3._extend.Method({
+			name: 'voteStatus',
+			call: 'quorum_voteStatus',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'getVotingHistory',
+			call: 'quorum_getVotingHistory',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'setVoteThreshold',
+			call: 'quorum_setVoteThreshold',
+			params: 1,
+			inputFormatter: [null]
+		}),
 		new web3._extend.Method({
 			name: 'isVoter',
 			call: 'quorum_isVoter',
@@ -754,6 +975,14 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'resumeVoting',
 			call: 'quorum_resumeVoting'
+		}),
+		new web3._extend.Method({
+			name: 'failover',
+			call: 'quorum_failover'
+		}),
+		new web3._extend.Method({
+			name: 'standby',
+			call: 'quorum_standby'
 		})
 	],
 	properties:
@@ -762,6 +991,10 @@ web3._extend({
 			name: 'nodeInfo',
 			getter: 'quorum_nodeInfo'
 		}),
+		new web3._extend.Property({
+			name: 'nodeVersion',
+			getter: 'quorum_nodeVersion'
+		}),
 	]
 });
 `
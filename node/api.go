@@ -18,6 +18,7 @@ package node
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 	"time"
 
@@ -74,6 +75,105 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// DenyPeer disconnects from a remote node and refuses connections to and from
+// it for the given time, e.g. "24h", or until the rule is removed if it's "0".
+// The node may be given as an enode URL or its hex node ID.
+func (api *PrivateAdminAPI) DenyPeer(url string, ttl string, reason *string) (bool, error) {
+	return api.setPeerRule(url, p2p.PeerDeny, ttl, reason)
+}
+
+// AllowPeer admits a remote node for the given time even if it isn't in the
+// permissioned nodes, e.g. while the list is being rolled out to all members.
+func (api *PrivateAdminAPI) AllowPeer(url string, ttl string, reason *string) (bool, error) {
+	return api.setPeerRule(url, p2p.PeerAllow, ttl, reason)
+}
+
+func (api *PrivateAdminAPI) setPeerRule(url string, action string, ttl string, reason *string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	duration, err := time.ParseDuration(ttl)
+	if err != nil || duration < 0 {
+		return false, fmt.Errorf("invalid ttl %q, want a duration like 24h or 0 for no expiry", ttl)
+	}
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+	if err := server.SetPeerRule(node.ID, action, duration, why); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemovePeerRule removes the deny or allow rule of a remote node, reporting
+// whether there was one.
+func (api *PrivateAdminAPI) RemovePeerRule(url string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	return server.RemovePeerRule(node.ID)
+}
+
+// PeerRules retrieves the deny and allow rules in effect.
+func (api *PrivateAdminAPI) PeerRules() ([]*p2p.PeerRule, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeerRules(), nil
+}
+
+// SetPeerBandwidth limits the bandwidth of a remote node to the given KB/s
+// received from and sent to it, 0 meaning unlimited, replacing the limits
+// configured for all peers. The limits apply right away if it's connected.
+func (api *PrivateAdminAPI) SetPeerBandwidth(url string, ingress, egress uint64) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return false, fmt.Errorf("invalid enode: %v", err)
+	}
+	server.SetPeerBandwidth(node.ID, p2p.Bandwidth{Ingress: int64(ingress) * 1024, Egress: int64(egress) * 1024})
+	return true, nil
+}
+
+// ReloadConfig re-reads the config file, and the static and trusted nodes, and
+// applies the changed settings which don't require a restart, like SIGHUP.
+func (api *PrivateAdminAPI) ReloadConfig() (*ConfigReload, error) {
+	return api.node.ReloadConfig()
+}
+
+// EnterMaintenance puts the node into maintenance for rolling upgrades and
+// other planned work: it rejects transactions and stops producing blocks and
+// voting, but keeps syncing and serving reads. It reports false if the node
+// already was in maintenance.
+func (api *PrivateAdminAPI) EnterMaintenance(reason *string) (bool, error) {
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+	return api.node.EnterMaintenance(why)
+}
+
+// ExitMaintenance resumes normal operation of a node in maintenance. It reports
+// false if the node wasn't in maintenance.
+func (api *PrivateAdminAPI) ExitMaintenance() (bool, error) {
+	return api.node.ExitMaintenance()
+}
+
 // StartRPC starts the HTTP RPC API server.
 func (api *PrivateAdminAPI) StartRPC(host *string, port *rpc.HexNumber, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
@@ -194,14 +294,101 @@ func (api *PublicAdminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// ExportPeerTopology exports the view the node has of the network topology: its
+// peers, the direction and kind of its connections and the raft cluster
+// members and their health, for rendering the consortium as a graph. The format
+// is "json" (default) for the nodes and links, or "dot" for a Graphviz digraph.
+func (api *PublicAdminAPI) ExportPeerTopology(format *string) (interface{}, error) {
+	api.node.lock.RLock()
+	defer api.node.lock.RUnlock()
+
+	if api.node.server == nil {
+		return nil, ErrNodeStopped
+	}
+	topology := collectTopology(api.node.server, api.node.services)
+
+	if format == nil {
+		return topology, nil
+	}
+	switch *format {
+	case TopologyJSON:
+		return topology, nil
+	case TopologyDOT:
+		return topology.DOT(), nil
+	default:
+		return nil, fmt.Errorf("unknown topology format %q, use %q or %q", *format, TopologyJSON, TopologyDOT)
+	}
+}
+
+// NodeInfo is the information admin_nodeInfo reports about the host node.
+type NodeInfo struct {
+	*p2p.NodeInfo
+	Quorum *QuorumNodeInfo `json:"quorum"`
+}
+
+// QuorumNodeInfo describes the consensus, privacy and permissioning setup of
+// the node, so fleet inventory can be gathered with a single call.
+type QuorumNodeInfo struct {
+	Consensus      interface{} `json:"consensus,omitempty"`      // engine and role, reported by the consensus service
+	PrivacyManager interface{} `json:"privacyManager,omitempty"` // nil if private transactions are disabled
+	Permissioning  string      `json:"permissioning"`            // "node" if only permissioned nodes may connect, "none" otherwise
+	Vault          *VaultInfo  `json:"vault,omitempty"`          // nil if Vault isn't configured
+	Chain          *ChainInfo  `json:"chain,omitempty"`          // reported by the chain service
+}
+
+// ChainInfo identifies the chain the node runs.
+type ChainInfo struct {
+	NetworkId int         `json:"networkId"`
+	ChainId   *big.Int    `json:"chainId,omitempty"` // nil if transactions aren't replay protected
+	Genesis   common.Hash `json:"genesis"`
+	GasLimit  *big.Int    `json:"gasLimit"` // of the head block
+}
+
+// VaultInfo describes the Vault configuration of the node, without secrets.
+type VaultInfo struct {
+	Addr   string `json:"address"`
+	Prefix string `json:"prefix"`
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
-// protocol granularity.
-func (api *PublicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
+// protocol granularity, along with its Quorum setup.
+func (api *PublicAdminAPI) NodeInfo() (*NodeInfo, error) {
 	server := api.node.Server()
 	if server == nil {
 		return nil, ErrNodeStopped
 	}
-	return server.NodeInfo(), nil
+	return &NodeInfo{NodeInfo: server.NodeInfo(), Quorum: api.node.quorumNodeInfo()}, nil
+}
+
+// NodeStatus retrieves a compact status of the node, combining the chain head,
+// peer count, consensus role, transaction pool and resource usage, for fleet
+// dashboards to poll. It's the content of the status file, gathered on demand.
+func (api *PublicAdminAPI) NodeStatus() (*NodeStatus, error) {
+	api.node.lock.RLock()
+	defer api.node.lock.RUnlock()
+
+	if api.node.server == nil {
+		return nil, ErrNodeStopped
+	}
+	return collectStatus(api.node.maintenance.state(), api.node.started, api.node.server, api.node.services), nil
+}
+
+// ConfigSummary retrieves the summary of the effective configuration of the
+// node, gathered when it started.
+func (api *PublicAdminAPI) ConfigSummary() (*ConfigSummary, error) {
+	api.node.lock.RLock()
+	defer api.node.lock.RUnlock()
+
+	if api.node.summary == nil {
+		return nil, ErrNodeStopped
+	}
+	return api.node.summary, nil
+}
+
+// Maintenance retrieves the maintenance the node is in, or nil if it operates
+// normally.
+func (api *PublicAdminAPI) Maintenance() *MaintenanceStatus {
+	return api.node.Maintenance()
 }
 
 // Datadir retrieves the current data directory the node is using.
@@ -334,3 +521,46 @@ func (s *PublicWeb3API) ClientVersion() string {
 func (s *PublicWeb3API) Sha3(input string) string {
 	return common.ToHex(crypto.Keccak256(common.FromHex(input)))
 }
+
+// PublicQuorumAPI offers the Quorum setup of the node to tooling.
+type PublicQuorumAPI struct {
+	node *Node
+}
+
+// NewPublicQuorumAPI creates a new API definition for the public quorum methods
+// of the node itself.
+func NewPublicQuorumAPI(node *Node) *PublicQuorumAPI {
+	return &PublicQuorumAPI{node: node}
+}
+
+// NodeVersion is what quorum_nodeVersion reports for tooling to detect the
+// kind of network it's connected to.
+type NodeVersion struct {
+	Client         string      `json:"client"`                   // as web3_clientVersion
+	Consensus      string      `json:"consensus"`                // "raft" or "quorumchain"
+	PrivacyManager interface{} `json:"privacyManager,omitempty"` // nil if private transactions are disabled
+	Protocols      []string    `json:"protocols"`                // p2p protocols as name/version, e.g. eth/63
+	NetworkId      int         `json:"networkId"`                // as net_version
+	ChainId        *big.Int    `json:"chainId"`                  // as eth_chainId, nil if not set
+}
+
+// NodeVersion reports the client, consensus engine, privacy manager and
+// protocol versions of the node along with the IDs of its network.
+func (api *PublicQuorumAPI) NodeVersion() (*NodeVersion, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	info := api.node.quorumNodeInfo()
+	version := &NodeVersion{Client: server.Name, PrivacyManager: info.PrivacyManager, Protocols: []string{}}
+	if consensus, ok := info.Consensus.(map[string]interface{}); ok {
+		version.Consensus, _ = consensus["engine"].(string)
+	}
+	for _, proto := range server.Protocols {
+		version.Protocols = append(version.Protocols, fmt.Sprintf("%s/%d", proto.Name, proto.Version))
+	}
+	if info.Chain != nil {
+		version.NetworkId, version.ChainId = info.Chain.NetworkId, info.Chain.ChainId
+	}
+	return version, nil
+}
@@ -25,12 +25,14 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
 )
@@ -41,6 +43,8 @@ var (
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
+	datadirChainData       = "chaindata"          // Path within the datadir to the chain database
+	datadirKeyStoreIndex   = "keystore.index"     // Path within the datadir to the persisted keystore cache
 )
 
 // Config represents a small collection of configuration values to fine tune the
@@ -75,6 +79,25 @@ type Config struct {
 	// is created by New and destroyed when the node is stopped.
 	KeyStoreDir string
 
+	// KeyStoreRefresh is the minimum interval between rescans of the keystore for
+	// added or removed keys on platforms where it can't be watched for changes.
+	// If zero, it's rescanned at most every 2 seconds, or every minute when the
+	// keys are kept in a KeyStoreBackend.
+	KeyStoreRefresh time.Duration
+
+	// KeyStoreBackend keeps the key files outside of the file system if set, e.g.
+	// in Hashicorp Vault. KeyStoreDir is ignored then.
+	KeyStoreBackend accounts.KeyBackend
+
+	// ChainDataDir is the file system folder holding the chain database, to place
+	// it on a different volume than the rest of the data directory. If empty, the
+	// database is the "chaindata" subdirectory of the instance directory.
+	ChainDataDir string
+
+	// DataKey is the 32 byte key the databases of the node and its services are
+	// encrypted with. If nil, they are stored in plaintext.
+	DataKey []byte
+
 	// UseLightweightKDF lowers the memory and CPU requirements of the key store
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool
@@ -85,6 +108,12 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCSecurity is the security descriptor, in SDDL, of the IPC named pipe,
+	// restricting who can connect to it. IPCGroups restricts the pipe to the
+	// given local groups instead. Both are only supported on Windows.
+	IPCSecurity string
+	IPCGroups   []string
+
 	// This field should be a valid secp256k1 private key that will be used for both
 	// remote peer identification as well as network traffic encryption. If no key
 	// is configured, the preset one is loaded from the data dir, generating it if
@@ -101,6 +130,10 @@ type Config struct {
 	// Network interface address on which the node should listen for inbound peers.
 	ListenAddr string
 
+	// AdvertiseAddr is the host or host:port peers are told to dial instead of
+	// the listening address, e.g. of a load balancer in front of the node.
+	AdvertiseAddr string
+
 	// If set to a non-nil value, the given NAT port mapper is used to make the
 	// listening port available to the Internet.
 	NAT nat.Interface
@@ -121,6 +154,11 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// MaxPeerBandwidth limits the bytes per second exchanged with each peer,
+	// unless changed for a peer through admin_setPeerBandwidth. Zero limits
+	// mean unlimited.
+	MaxPeerBandwidth p2p.Bandwidth
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -159,8 +197,80 @@ type Config struct {
 	// exposed.
 	WSModules []string
 
+	// WSCompression enables negotiation of per message compression with websocket
+	// clients that support it.
+	WSCompression bool
+
+	// WSPingInterval is the interval at which keepalive pings are sent to websocket
+	// clients. Clients failing to answer a ping within WSPongTimeout are
+	// disconnected. A zero interval disables keepalive pings.
+	WSPingInterval time.Duration
+	WSPongTimeout  time.Duration
+
+	// GRPCHost is the host interface on which to start the gRPC server. If this
+	// field is empty, no gRPC endpoint will be started.
+	GRPCHost string
+
+	// GRPCPort is the TCP port number on which to start the gRPC server.
+	GRPCPort int
+
+	// GRPCModules is a list of API modules the gRPC methods may call. Methods of
+	// modules not listed fail with UNIMPLEMENTED. If the module list is empty,
+	// all RPC API endpoints designated public are available.
+	GRPCModules []string
+
+	// GRPCTLSCert and GRPCTLSKey are the PEM certificate and key files the gRPC
+	// server serves TLS with. If empty, the gRPC endpoint is plaintext.
+	GRPCTLSCert string
+	GRPCTLSKey  string
+
+	// RPCAccessLog is the file the requests served over IPC, HTTP, websocket and
+	// gRPC are logged to. If this field is empty, requests are not logged.
+	RPCAccessLog string
+
+	// RPCAccessLogSample is the fraction of successful requests which are
+	// logged. Failed requests are always logged. Zero logs all requests.
+	RPCAccessLogSample float64
+
 	//enables node level Permissioning
 	EnableNodePermission bool
+
+	// NodeCert is the certificate of this node issued by a consortium CA,
+	// presented to peers in the handshake.
+	NodeCert *p2p.NodeCert
+
+	// NodeCertRoots are the addresses of the consortium CAs whose certificates
+	// admit peers, in addition to the permissioned nodes if permissioning is
+	// enabled.
+	NodeCertRoots []common.Address
+
+	// NodeManifestURL is the URL of the node manifest signed by the consortium,
+	// polled every NodeManifestInterval to update the static and permissioned
+	// nodes at runtime. Only manifests signed by NodeManifestSigners apply.
+	NodeManifestURL      string
+	NodeManifestSigners  []common.Address
+	NodeManifestInterval time.Duration
+
+	// StatusFile is the file the status of the node is periodically written to
+	// for external supervisors, relative to the data directory. If this field
+	// is empty, no status file is written.
+	StatusFile         string
+	StatusFileInterval time.Duration
+
+	// StartupSummary enables logging a summary of the effective configuration
+	// once the node started. The summary is available through admin_configSummary
+	// regardless.
+	StartupSummary bool
+
+	// Maintenance starts the node in maintenance: it rejects transactions and
+	// doesn't take part in block production until admin_exitMaintenance.
+	Maintenance bool
+
+	// VaultAddr and VaultPrefix are the address and KV engine of the Vault
+	// server secrets are read from, if any. They are only reported in
+	// admin_nodeInfo.
+	VaultAddr   string
+	VaultPrefix string
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -238,6 +348,15 @@ func DefaultWSEndpoint() string {
 	return config.WSEndpoint()
 }
 
+// GRPCEndpoint resolves a gRPC endpoint based on the configured host interface
+// and port parameters.
+func (c *Config) GRPCEndpoint() string {
+	if c.GRPCHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", c.GRPCHost, c.GRPCPort)
+}
+
 // NodeName returns the devp2p node identifier.
 func (c *Config) NodeName() string {
 	name := c.name()
@@ -284,6 +403,9 @@ func (c *Config) resolvePath(path string) string {
 	if c.DataDir == "" {
 		return ""
 	}
+	if path == datadirChainData && c.ChainDataDir != "" {
+		return c.ChainDataDir
+	}
 	// Backwards-compatibility: ensure that data directory files created
 	// by geth 1.4 are used if they exist.
 	if c.name() == "geth" && isOldGethResource[path] {
@@ -339,17 +461,17 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 
 // StaticNodes returns a list of node enode URLs configured as static nodes.
 func (c *Config) StaticNodes() []*discover.Node {
-	return c.parsePersistentNodes(c.resolvePath(datadirStaticNodes))
+	return c.parsePersistentNodes(c.resolvePath(datadirStaticNodes), p2p.NodeListRules{Dialed: true})
 }
 
 // TrusterNodes returns a list of node enode URLs configured as trusted nodes.
 func (c *Config) TrusterNodes() []*discover.Node {
-	return c.parsePersistentNodes(c.resolvePath(datadirTrustedNodes))
+	return c.parsePersistentNodes(c.resolvePath(datadirTrustedNodes), p2p.NodeListRules{})
 }
 
 // parsePersistentNodes parses a list of discovery node URLs loaded from a .json
-// file from within the data directory.
-func (c *Config) parsePersistentNodes(path string) []*discover.Node {
+// file from within the data directory. Invalid entries are logged and skipped.
+func (c *Config) parsePersistentNodes(path string, rules p2p.NodeListRules) []*discover.Node {
 	// Short circuit if no node config is present
 	if c.DataDir == "" {
 		return nil
@@ -357,26 +479,20 @@ func (c *Config) parsePersistentNodes(path string) []*discover.Node {
 	if _, err := os.Stat(path); err != nil {
 		return nil
 	}
-	// Load the nodes from the config file.
-	var nodelist []string
-	if err := common.LoadJSON(path, &nodelist); err != nil {
-		glog.V(logger.Error).Infof("Can't load node file %s: %v", path, err)
+	list, err := p2p.LoadNodeList(path, rules)
+	if err != nil {
+		glog.V(logger.Error).Infof("Can't load node file %v", err)
 		return nil
 	}
-	// Interpret the list as a discovery node array
-	var nodes []*discover.Node
-	for _, url := range nodelist {
-		if url == "" {
-			continue
-		}
-		node, err := discover.ParseNode(url)
-		if err != nil {
-			glog.V(logger.Error).Infof("Node URL %s: %v\n", url, err)
-			continue
-		}
-		nodes = append(nodes, node)
+	for _, issue := range list.Issues {
+		glog.V(logger.Error).Infof("Node file %s: %v", path, issue)
 	}
-	return nodes
+	return list.Nodes
+}
+
+// ResolvePath returns the absolute path of a resource in the instance directory.
+func (c *Config) ResolvePath(path string) string {
+	return c.resolvePath(path)
 }
 
 func makeAccountManager(conf *Config) (am *accounts.Manager, ephemeralKeystore string, err error) {
@@ -386,6 +502,10 @@ func makeAccountManager(conf *Config) (am *accounts.Manager, ephemeralKeystore s
 		scryptN = accounts.LightScryptN
 		scryptP = accounts.LightScryptP
 	}
+	if conf.KeyStoreBackend != nil {
+		cache := accounts.CacheConfig{Refresh: conf.KeyStoreRefresh}
+		return accounts.NewBackendManager(conf.KeyStoreBackend, scryptN, scryptP, cache), "", nil
+	}
 
 	var keydir string
 	switch {
@@ -411,5 +531,11 @@ func makeAccountManager(conf *Config) (am *accounts.Manager, ephemeralKeystore s
 		return nil, "", err
 	}
 
-	return accounts.NewManager(keydir, scryptN, scryptP), ephemeralKeystore, nil
+	// The keystore cache is persisted in the instance directory, so that a
+	// restarted node only parses the key files changed in the meantime
+	cache := accounts.CacheConfig{Refresh: conf.KeyStoreRefresh}
+	if ephemeralKeystore == "" {
+		cache.Index = conf.resolvePath(datadirKeyStoreIndex)
+	}
+	return accounts.NewManagerWithCache(keydir, scryptN, scryptP, cache), ephemeralKeystore, nil
 }
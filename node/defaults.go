@@ -29,6 +29,8 @@ const (
 	DefaultHTTPPort  = 8545        // Default TCP port for the HTTP RPC server
 	DefaultWSHost    = "localhost" // Default host interface for the websocket RPC server
 	DefaultWSPort    = 8546        // Default TCP port for the websocket RPC server
+	DefaultGRPCHost  = "localhost" // Default host interface for the gRPC server
+	DefaultGRPCPort  = 8547        // Default TCP port for the gRPC server
 )
 
 // DefaultDataDir is the default data directory to use for the databases and other
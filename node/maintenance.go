@@ -0,0 +1,135 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// StatusMaintenance is the state reported in the status file while the node is
+// in maintenance.
+const StatusMaintenance = "maintenance"
+
+// ErrMaintenance is returned for transactions submitted while the node is in
+// maintenance.
+var ErrMaintenance = errors.New("node is in maintenance, transactions are not accepted")
+
+// MaintenanceStatus describes the maintenance the node is in.
+type MaintenanceStatus struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// maintenanceMode tracks whether the node is in maintenance. It outlives the
+// services, so a node restarted in maintenance stays in it.
+type maintenanceMode struct {
+	mu     sync.RWMutex
+	status *MaintenanceStatus
+}
+
+func newMaintenanceMode(enabled bool) *maintenanceMode {
+	m := new(maintenanceMode)
+	if enabled {
+		m.status = &MaintenanceStatus{Since: time.Now(), Reason: "started in maintenance"}
+	}
+	return m
+}
+
+// get returns the maintenance the node is in, or nil if it isn't.
+func (m *maintenanceMode) get() *MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+// set replaces the maintenance status, reporting whether the node entered or
+// left maintenance.
+func (m *maintenanceMode) set(status *MaintenanceStatus) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := (m.status == nil) != (status == nil)
+	if changed {
+		m.status = status
+	}
+	return changed
+}
+
+// state returns the node state reported in the status file.
+func (m *maintenanceMode) state() string {
+	if m.get() != nil {
+		return StatusMaintenance
+	}
+	return StatusRunning
+}
+
+// setMaintenance puts the services in or out of maintenance.
+func setMaintenance(services map[reflect.Type]Service, enabled bool) {
+	for _, service := range services {
+		if handler, ok := service.(MaintenanceHandler); ok {
+			handler.SetMaintenance(enabled)
+		}
+	}
+}
+
+// EnterMaintenance puts the running node into maintenance: transactions are
+// rejected and the node stops producing blocks and voting, while it keeps
+// syncing and serving reads. It reports false if the node already was in
+// maintenance.
+func (n *Node) EnterMaintenance(reason string) (bool, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.server == nil {
+		return false, ErrNodeStopped
+	}
+	if !n.maintenance.set(&MaintenanceStatus{Since: time.Now(), Reason: reason}) {
+		return false, nil
+	}
+	glog.V(logger.Warn).Infof("Entering maintenance: %s", reason)
+	setMaintenance(n.services, true)
+	return true, nil
+}
+
+// ExitMaintenance resumes normal operation of a node in maintenance. It reports
+// false if the node wasn't in maintenance.
+func (n *Node) ExitMaintenance() (bool, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.server == nil {
+		return false, ErrNodeStopped
+	}
+	if !n.maintenance.set(nil) {
+		return false, nil
+	}
+	glog.V(logger.Warn).Infof("Leaving maintenance")
+	setMaintenance(n.services, false)
+	return true, nil
+}
+
+// Maintenance returns the maintenance the node is in, or nil if it isn't.
+func (n *Node) Maintenance() *MaintenanceStatus {
+	return n.maintenance.get()
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"testing"
+)
+
+// Tests that services are put in and out of maintenance, and that the node
+// reports the maintenance.
+func TestMaintenance(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewMaintenanceService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	api := NewPrivateAdminAPI(stack)
+	reason := "upgrade"
+	if _, err := api.EnterMaintenance(&reason); err != ErrNodeStopped {
+		t.Fatalf("stopped node entered maintenance: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	var service *MaintenanceService
+	if err := stack.Service(&service); err != nil {
+		t.Fatalf("failed to retrieve service: %v", err)
+	}
+	if entered, err := api.EnterMaintenance(&reason); !entered || err != nil {
+		t.Fatalf("failed to enter maintenance: %v %v", entered, err)
+	}
+	if !service.maintenance {
+		t.Errorf("service not in maintenance")
+	}
+	if status := NewPublicAdminAPI(stack).Maintenance(); status == nil || status.Reason != "upgrade" || status.Since.IsZero() {
+		t.Errorf("maintenance mismatch: %+v", status)
+	}
+	if status, _ := NewPublicAdminAPI(stack).NodeStatus(); status.State != StatusMaintenance {
+		t.Errorf("state mismatch: have %q, want %q", status.State, StatusMaintenance)
+	}
+	if entered, err := api.EnterMaintenance(nil); entered || err != nil {
+		t.Errorf("entered maintenance twice: %v %v", entered, err)
+	}
+	if exited, err := api.ExitMaintenance(); !exited || err != nil {
+		t.Fatalf("failed to exit maintenance: %v %v", exited, err)
+	}
+	if service.maintenance || stack.Maintenance() != nil {
+		t.Errorf("still in maintenance after exit")
+	}
+	if exited, err := api.ExitMaintenance(); exited || err != nil {
+		t.Errorf("exited maintenance twice: %v %v", exited, err)
+	}
+}
+
+// Tests that a node configured to start in maintenance puts its services in
+// maintenance before serving any request, and keeps them in it on restart.
+func TestStartInMaintenance(t *testing.T) {
+	config := testNodeConfig()
+	config.Maintenance = true
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewMaintenanceService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stack.Start(); err != nil {
+			t.Fatalf("failed to start protocol stack: %v", err)
+		}
+		var service *MaintenanceService
+		if err := stack.Service(&service); err != nil {
+			t.Fatalf("failed to retrieve service: %v", err)
+		}
+		if !service.maintenance {
+			t.Errorf("start %d: service not in maintenance", i)
+		}
+		stack.Stop()
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// DefaultNodeManifestInterval is the default interval the node manifest is
+// fetched at.
+const DefaultNodeManifestInterval = 5 * time.Minute
+
+const (
+	datadirNodeManifest = "node-manifest.json" // Path within the datadir to the last applied node manifest
+	maxNodeManifestSize = 1024 * 1024
+)
+
+// manifestFetcher periodically fetches the signed node manifest published by
+// the consortium and applies the changes of the static and permissioned nodes
+// to the running p2p server, so no restart is needed to roll out a new network
+// topology.
+type manifestFetcher struct {
+	url          string
+	signers      []common.Address
+	interval     time.Duration
+	server       *p2p.Server
+	staticPath   string // static-nodes.json, rewritten on changes (empty = ephemeral)
+	manifestPath string // last applied manifest, to skip it after restarts (empty = ephemeral)
+	client       *http.Client
+
+	version uint64           // Version of the last applied manifest
+	static  []*discover.Node // Static nodes the server currently keeps connected to
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newManifestFetcher(config *Config, server *p2p.Server) *manifestFetcher {
+	interval := config.NodeManifestInterval
+	if interval <= 0 {
+		interval = DefaultNodeManifestInterval
+	}
+	return &manifestFetcher{
+		url:          config.NodeManifestURL,
+		signers:      config.NodeManifestSigners,
+		interval:     interval,
+		server:       server,
+		staticPath:   config.resolvePath(datadirStaticNodes),
+		manifestPath: config.resolvePath(datadirNodeManifest),
+		client:       &http.Client{Timeout: 30 * time.Second},
+		static:       server.StaticNodes,
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// start picks up the version of the manifest applied before the last restart,
+// then fetches the manifest in the background.
+func (f *manifestFetcher) start() error {
+	if len(f.signers) == 0 {
+		return fmt.Errorf("no trusted signers for the node manifest at %s", f.url)
+	}
+	if f.manifestPath != "" {
+		var applied p2p.NodeManifest
+		if err := common.LoadJSON(f.manifestPath, &applied); err == nil {
+			f.version = applied.Version
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to load applied node manifest: %v", err)
+		}
+	}
+	go f.loop()
+	return nil
+}
+
+func (f *manifestFetcher) stop() {
+	close(f.quit)
+	<-f.done
+}
+
+func (f *manifestFetcher) loop() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		if err := f.update(); err != nil {
+			glog.V(logger.Warn).Infof("Failed to update node manifest from %s: %v", f.url, err)
+		}
+		select {
+		case <-ticker.C:
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// update fetches the manifest and applies it if it's newer than the last one.
+func (f *manifestFetcher) update() error {
+	manifest, err := f.fetch()
+	if err != nil {
+		return err
+	}
+	if err := manifest.Verify(f.signers); err != nil {
+		return err
+	}
+	if manifest.Version <= f.version {
+		glog.V(logger.Detail).Infof("Node manifest version %d already applied", manifest.Version)
+		return nil
+	}
+	static, permissioned, err := manifest.Nodes()
+	if err != nil {
+		return err
+	}
+	return f.apply(manifest, static, permissioned)
+}
+
+func (f *manifestFetcher) fetch() (*p2p.NodeManifest, error) {
+	req, err := http.NewRequest("GET", f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Cancel = f.quit
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response %s", res.Status)
+	}
+	manifest := new(p2p.NodeManifest)
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxNodeManifestSize)).Decode(manifest); err != nil {
+		return nil, fmt.Errorf("invalid node manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// apply connects to the added static nodes, drops the removed ones, replaces
+// the permissioned nodes and records the manifest as applied. The node lists
+// are persisted, so they also hold if the node restarts before the manifest
+// server can be reached.
+func (f *manifestFetcher) apply(manifest *p2p.NodeManifest, static, permissioned []*discover.Node) error {
+	if static != nil {
+		added, removed := diffNodes(f.static, static)
+		for _, node := range removed {
+			f.server.RemovePeer(node)
+		}
+		for _, node := range added {
+			f.server.AddPeer(node)
+		}
+		f.static = static
+		if f.staticPath != "" {
+			if err := writeJSONFile(f.staticPath, manifest.StaticNodes); err != nil {
+				return fmt.Errorf("failed to save static nodes: %v", err)
+			}
+		}
+		glog.V(logger.Info).Infof("Node manifest version %d: %d static nodes added, %d removed", manifest.Version, len(added), len(removed))
+	}
+	if permissioned != nil {
+		if err := f.server.SetPermissionedNodes(permissioned); err != nil {
+			return err
+		}
+		glog.V(logger.Info).Infof("Node manifest version %d: %d permissioned nodes", manifest.Version, len(permissioned))
+	}
+	f.version = manifest.Version
+	if f.manifestPath != "" {
+		if err := writeJSONFile(f.manifestPath, manifest); err != nil {
+			return fmt.Errorf("failed to save applied node manifest: %v", err)
+		}
+	}
+	return nil
+}
+
+// diffNodes returns the nodes of next missing from prev and those of prev
+// missing from next, by node ID. Nodes moving to another endpoint are both
+// removed and added, so they're dialed at their new address.
+func diffNodes(prev, next []*discover.Node) (added, removed []*discover.Node) {
+	prevSet := make(map[string]bool)
+	for _, node := range prev {
+		prevSet[node.String()] = true
+	}
+	nextSet := make(map[string]bool)
+	for _, node := range next {
+		nextSet[node.String()] = true
+		if !prevSet[node.String()] {
+			added = append(added, node)
+		}
+	}
+	for _, node := range prev {
+		if !nextSet[node.String()] {
+			removed = append(removed, node)
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,129 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// Tests that newer signed node manifests update the static and permissioned
+// nodes, while older, unsigned or invalid ones are ignored, also across
+// restarts.
+func TestNodeManifestFetcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "test node"), 0700)
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	var published *p2p.NodeManifest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(published)
+	}))
+	defer server.Close()
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	config.NodeManifestURL = server.URL
+	config.NodeManifestSigners = []common.Address{crypto.PubkeyToAddress(key.PublicKey)}
+
+	running := &p2p.Server{Config: p2p.Config{PrivateKey: testNodeKey, MaxPeers: 10, DataDir: dir}}
+	if err := running.Start(); err != nil {
+		t.Fatalf("failed to start p2p server: %v", err)
+	}
+	defer running.Stop()
+
+	publish := func(version uint64, signer *ecdsa.PrivateKey, static, permissioned []string) {
+		published = &p2p.NodeManifest{Version: version, StaticNodes: static, PermissionedNodes: permissioned}
+		if err := published.Sign(signer); err != nil {
+			t.Fatalf("failed to sign manifest: %v", err)
+		}
+	}
+	enodes := make([]string, 3)
+	for i := range enodes {
+		nodeKey, _ := crypto.GenerateKey()
+		enodes[i] = fmt.Sprintf("enode://%x@127.0.0.1:%d", crypto.FromECDSAPub(&nodeKey.PublicKey)[1:], 30303+i)
+	}
+	loadNodes := func(file string) (urls []string) {
+		common.LoadJSON(filepath.Join(dir, file), &urls)
+		return urls
+	}
+	fetcher := newManifestFetcher(config, running)
+
+	publish(2, key, enodes[:2], enodes)
+	if err := fetcher.update(); err != nil {
+		t.Fatalf("failed to apply manifest: %v", err)
+	}
+	if urls := loadNodes("test node/static-nodes.json"); !reflect.DeepEqual(urls, enodes[:2]) {
+		t.Errorf("static nodes mismatch: have %v, want %v", urls, enodes[:2])
+	}
+	if urls := loadNodes(p2p.PERMISSIONED_CONFIG); !reflect.DeepEqual(urls, enodes) {
+		t.Errorf("permissioned nodes mismatch: have %v, want %v", urls, enodes)
+	}
+	// Stale, untrusted and invalid manifests must not change anything
+	publish(1, key, enodes[2:], nil)
+	if err := fetcher.update(); err != nil {
+		t.Errorf("stale manifest failed: %v", err)
+	}
+	publish(3, other, enodes[2:], nil)
+	if err := fetcher.update(); err == nil {
+		t.Errorf("untrusted manifest accepted")
+	}
+	publish(3, key, []string{"enode://invalid"}, nil)
+	if err := fetcher.update(); err == nil {
+		t.Errorf("invalid manifest accepted")
+	}
+	if urls := loadNodes("test node/static-nodes.json"); !reflect.DeepEqual(urls, enodes[:2]) {
+		t.Errorf("static nodes changed: have %v, want %v", urls, enodes[:2])
+	}
+	// Unset lists are left alone, and the applied version survives restarts
+	publish(3, key, enodes[1:], nil)
+	if err := fetcher.update(); err != nil {
+		t.Fatalf("failed to apply manifest: %v", err)
+	}
+	if urls := loadNodes("test node/static-nodes.json"); !reflect.DeepEqual(urls, enodes[1:]) {
+		t.Errorf("static nodes mismatch: have %v, want %v", urls, enodes[1:])
+	}
+	if urls := loadNodes(p2p.PERMISSIONED_CONFIG); !reflect.DeepEqual(urls, enodes) {
+		t.Errorf("permissioned nodes changed: have %v, want %v", urls, enodes)
+	}
+	restarted := newManifestFetcher(config, running)
+	if err := restarted.start(); err != nil {
+		t.Fatalf("failed to restart fetcher: %v", err)
+	}
+	restarted.stop()
+	if restarted.version != 3 {
+		t.Errorf("applied version lost on restart: have %d, want 3", restarted.version)
+	}
+}
@@ -19,17 +19,21 @@ package node
 import (
 	"crypto/ecdsa"
 	"errors"
+	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/grpcapi"
 	"github.com/ethereum/go-ethereum/internal/debug"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -37,6 +41,8 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/syndtr/goleveldb/leveldb/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -74,11 +80,30 @@ type Node struct {
 	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
 	httpListener  net.Listener // HTTP RPC listener socket to server API requests
 	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpCors      *swapHandler // CORS handler in front of httpHandler, replaced on reloads
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	grpcEndpoint string       // gRPC endpoint (interface + port) to listen at (empty = gRPC disabled)
+	grpcServer   *grpc.Server // gRPC server translating calls to grpcHandler
+	grpcHandler  *rpc.Server  // RPC request handler to process the calls of gRPC clients
+
+	accessLog     *rpc.AccessLog // Access log of the IPC, HTTP, websocket and gRPC endpoints (nil = disabled)
+	accessLogFile *os.File       // File the access log is written to
+
+	statusFile *statusFile    // Status file for external supervisors (nil = disabled)
+	summary    *ConfigSummary // Effective configuration gathered on startup
+	started    time.Time      // Time the node was last started at
+
+	maintenance *maintenanceMode // Maintenance the node is in, kept across restarts
+	manifest    *manifestFetcher // Fetcher of the consortium node manifest (nil = disabled)
+
+	reloadLock  sync.Mutex
+	reloader    ConfigReloader   // Reloads the settings given to the node (nil = node lists only)
+	staticNodes []*discover.Node // Static nodes the server was last told to keep connected to
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 
@@ -125,7 +150,9 @@ func New(conf *Config) (*Node, error) {
 		ipcEndpoint:       conf.IPCEndpoint(),
 		httpEndpoint:      conf.HTTPEndpoint(),
 		wsEndpoint:        conf.WSEndpoint(),
+		grpcEndpoint:      conf.GRPCEndpoint(),
 		eventmux:          new(event.TypeMux),
+		maintenance:       newMaintenanceMode(conf.Maintenance),
 	}, nil
 }
 
@@ -166,12 +193,16 @@ func (n *Node) Start() error {
 		TrustedNodes:    n.config.TrusterNodes(),
 		NodeDatabase:    n.config.NodeDB(),
 		ListenAddr:      n.config.ListenAddr,
+		AdvertiseAddr:   n.config.AdvertiseAddr,
 		NAT:             n.config.NAT,
 		Dialer:          n.config.Dialer,
 		NoDial:          n.config.NoDial,
 		MaxPeers:        n.config.MaxPeers,
 		MaxPendingPeers: n.config.MaxPendingPeers,
+		MaxPeerBandwidth: n.config.MaxPeerBandwidth,
 		EnableNodePermission: n.config.EnableNodePermission,
+		NodeCert:             n.config.NodeCert,
+		NodeCertRoots:        n.config.NodeCertRoots,
 		DataDir:           n.config.DataDir,
 
 	}
@@ -227,8 +258,45 @@ func (n *Node) Start() error {
 		// Mark the service started for potential cleanup
 		started = append(started, kind)
 	}
+	// Keep the node in maintenance before any transaction can be submitted
+	if n.maintenance.get() != nil {
+		setMaintenance(services, true)
+	}
+	// Start writing the status file for external supervisors
+	var statusFile *statusFile
+	if path := n.config.statusFilePath(); path != "" {
+		statusFile = newStatusFile(path, n.config.StatusFileInterval, running, services, n.maintenance)
+		if err := statusFile.start(); err != nil {
+			for _, service := range services {
+				service.Stop()
+			}
+			running.Stop()
+			return err
+		}
+	}
+	// Keep the static and permissioned nodes in sync with the node manifest
+	var manifest *manifestFetcher
+	if n.config.NodeManifestURL != "" {
+		manifest = newManifestFetcher(n.config, running)
+		if err := manifest.start(); err != nil {
+			if statusFile != nil {
+				statusFile.stop()
+			}
+			for _, service := range services {
+				service.Stop()
+			}
+			running.Stop()
+			return err
+		}
+	}
 	// Lastly start the configured RPC interfaces
 	if err := n.startRPC(services); err != nil {
+		if manifest != nil {
+			manifest.stop()
+		}
+		if statusFile != nil {
+			statusFile.stop()
+		}
 		for _, service := range services {
 			service.Stop()
 		}
@@ -238,8 +306,17 @@ func (n *Node) Start() error {
 	// Finish initializing the startup
 	n.services = services
 	n.server = running
+	n.statusFile = statusFile
+	n.manifest = manifest
+	n.staticNodes = n.serverConfig.StaticNodes
+	n.summary = n.configSummary(services, n.rpcAPIs)
+	n.started = time.Now()
 	n.stop = make(chan struct{})
 
+	if n.config.StartupSummary {
+		n.summary.log()
+	}
+
 	return nil
 }
 
@@ -272,23 +349,38 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
 	}
+	if err := n.openAccessLog(); err != nil {
+		return err
+	}
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startIPC(apis); err != nil {
 		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startHTTP(n.httpEndpoint, apis, n.config.HTTPModules, n.config.HTTPCors); err != nil {
 		n.stopIPC()
 		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	if err := n.startWS(n.wsEndpoint, apis, n.config.WSModules, n.config.WSOrigins); err != nil {
 		n.stopHTTP()
 		n.stopIPC()
 		n.stopInProc()
+		n.closeAccessLog()
+		return err
+	}
+	if err := n.startGRPC(n.grpcEndpoint, apis, n.config.GRPCModules); err != nil {
+		n.stopWS()
+		n.stopHTTP()
+		n.stopIPC()
+		n.stopInProc()
+		n.closeAccessLog()
 		return err
 	}
 	// All API endpoints started successfully
@@ -296,6 +388,30 @@ func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	return nil
 }
 
+// openAccessLog opens the access log of the RPC endpoints, if configured.
+func (n *Node) openAccessLog() error {
+	if n.config.RPCAccessLog == "" {
+		return nil
+	}
+	file, err := os.OpenFile(n.config.RPCAccessLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	n.accessLogFile = file
+	n.accessLog = rpc.NewAccessLog(file, n.config.RPCAccessLogSample)
+	glog.V(logger.Info).Infof("RPC access log opened: %s", n.config.RPCAccessLog)
+	return nil
+}
+
+// closeAccessLog closes the access log of the RPC endpoints.
+func (n *Node) closeAccessLog() {
+	if n.accessLogFile != nil {
+		n.accessLogFile.Close()
+		n.accessLogFile = nil
+		n.accessLog = nil
+	}
+}
+
 // startInProc initializes an in-process RPC endpoint.
 func (n *Node) startInProc(apis []rpc.API) error {
 	// Register all the APIs exposed by the services
@@ -332,12 +448,20 @@ func (n *Node) startIPC(apis []rpc.API) error {
 		}
 		glog.V(logger.Debug).Infof("IPC registered %T under '%s'", api.Service, api.Namespace)
 	}
+	handler.SetAccessLog(n.accessLog)
+
 	// All APIs registered, start the IPC listener
 	var (
 		listener net.Listener
 		err      error
 	)
-	if listener, err = rpc.CreateIPCListener(n.ipcEndpoint); err != nil {
+	sddl := n.config.IPCSecurity
+	if len(n.config.IPCGroups) > 0 {
+		if sddl, err = rpc.IPCGroupsDescriptor(n.config.IPCGroups); err != nil {
+			return err
+		}
+	}
+	if listener, err = rpc.CreateIPCListenerWithSecurity(n.ipcEndpoint, sddl); err != nil {
 		return err
 	}
 	go func() {
@@ -402,6 +526,8 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 			glog.V(logger.Debug).Infof("HTTP registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
+	handler.SetAccessLog(n.accessLog)
+
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -410,13 +536,15 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	httpCors := newSwapHandler(rpc.NewHTTPServer(cors, handler).Handler)
+	go (&http.Server{Handler: httpCors}).Serve(listener)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
 	n.httpHandler = handler
+	n.httpCors = httpCors
 
 	return nil
 }
@@ -432,6 +560,7 @@ func (n *Node) stopHTTP() {
 	if n.httpHandler != nil {
 		n.httpHandler.Stop()
 		n.httpHandler = nil
+		n.httpCors = nil
 	}
 }
 
@@ -456,6 +585,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 			glog.V(logger.Debug).Infof("WebSocket registered %T under '%s'", api.Service, api.Namespace)
 		}
 	}
+	handler.SetAccessLog(n.accessLog)
+
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -464,7 +595,12 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
+	wsConfig := rpc.WebsocketConfig{
+		Compression:  n.config.WSCompression,
+		PingInterval: n.config.WSPingInterval,
+		PongTimeout:  n.config.WSPongTimeout,
+	}
+	go (&http.Server{Handler: handler.WebsocketHandlerWithConfig(wsOrigins, wsConfig)}).Serve(listener)
 	glog.V(logger.Info).Infof("WebSocket endpoint opened: ws://%s", endpoint)
 
 	// All listeners booted successfully
@@ -489,6 +625,73 @@ func (n *Node) stopWS() {
 	}
 }
 
+// startGRPC initializes and starts the gRPC endpoint. Its methods are served by
+// an RPC server of the whitelisted modules on behalf of the gRPC callers.
+func (n *Node) startGRPC(endpoint string, apis []rpc.API, modules []string) error {
+	// Short circuit if the gRPC endpoint isn't being exposed
+	if endpoint == "" {
+		return nil
+	}
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	// Register all the APIs exposed by the services
+	handler := rpc.NewServer()
+	for _, api := range apis {
+		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return err
+			}
+			glog.V(logger.Debug).Infof("gRPC registered %T under '%s'", api.Service, api.Namespace)
+		}
+	}
+	handler.SetAccessLog(n.accessLog)
+
+	var opts []grpc.ServerOption
+	scheme := "grpc"
+	if n.config.GRPCTLSCert != "" || n.config.GRPCTLSKey != "" {
+		creds, err := credentials.NewServerTLSFromFile(n.config.GRPCTLSCert, n.config.GRPCTLSKey)
+		if err != nil {
+			return fmt.Errorf("gRPC TLS: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+		scheme = "grpcs"
+	}
+	// All APIs registered, start the gRPC listener
+	listener, err := net.Listen("tcp", endpoint)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(opts...)
+	grpcapi.RegisterNodeServer(server, grpcapi.NewServer(handler))
+	go server.Serve(listener)
+	glog.V(logger.Info).Infof("gRPC endpoint opened: %s://%s", scheme, endpoint)
+
+	// All listeners booted successfully
+	n.grpcEndpoint = endpoint
+	n.grpcServer = server
+	n.grpcHandler = handler
+
+	return nil
+}
+
+// stopGRPC terminates the gRPC endpoint.
+func (n *Node) stopGRPC() {
+	if n.grpcServer != nil {
+		// Subscriptions only end with their clients, don't wait for them
+		n.grpcServer.Stop()
+		n.grpcServer = nil
+
+		glog.V(logger.Info).Infof("gRPC endpoint closed: %s", n.grpcEndpoint)
+	}
+	if n.grpcHandler != nil {
+		n.grpcHandler.Stop()
+		n.grpcHandler = nil
+	}
+}
+
 // Stop terminates a running node along with all it's services. In the node was
 // not started, an error is returned.
 func (n *Node) Stop() error {
@@ -500,11 +703,23 @@ func (n *Node) Stop() error {
 		return ErrNodeStopped
 	}
 
+	if n.manifest != nil {
+		n.manifest.stop()
+		n.manifest = nil
+	}
+	// Record the shutdown in the status file while the services still run
+	if n.statusFile != nil {
+		n.statusFile.stop()
+		n.statusFile = nil
+	}
 	// Terminate the API, services and the p2p server.
+	n.stopGRPC()
 	n.stopWS()
 	n.stopHTTP()
 	n.stopIPC()
+	n.closeAccessLog()
 	n.rpcAPIs = nil
+	n.summary = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
 	}
@@ -605,6 +820,33 @@ func (n *Node) Service(service interface{}) error {
 	return ErrServiceUnknown
 }
 
+// quorumNodeInfo gathers the Quorum setup of the node from its configuration
+// and the running services.
+func (n *Node) quorumNodeInfo() *QuorumNodeInfo {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.collectQuorumNodeInfo(n.services)
+}
+
+// collectQuorumNodeInfo gathers the Quorum setup of the node from the given
+// services. The caller must hold the lock of the node.
+func (n *Node) collectQuorumNodeInfo(services map[reflect.Type]Service) *QuorumNodeInfo {
+	info := &QuorumNodeInfo{Permissioning: "none"}
+	if n.config.EnableNodePermission {
+		info.Permissioning = "node"
+	}
+	if n.config.VaultAddr != "" {
+		info.Vault = &VaultInfo{Addr: n.config.VaultAddr, Prefix: n.config.VaultPrefix}
+	}
+	for _, service := range services {
+		if reporter, ok := service.(NodeInfoReporter); ok {
+			reporter.ReportNodeInfo(info)
+		}
+	}
+	return info
+}
+
 // DataDir retrieves the current datadir used by the protocol stack.
 func (n *Node) DataDir() string {
 	return n.config.DataDir
@@ -630,6 +872,11 @@ func (n *Node) WSEndpoint() string {
 	return n.wsEndpoint
 }
 
+// GRPCEndpoint retrieves the current gRPC endpoint used by the protocol stack.
+func (n *Node) GRPCEndpoint() string {
+	return n.grpcEndpoint
+}
+
 // EventMux retrieves the event multiplexer used by all the network services in
 // the current protocol stack.
 func (n *Node) EventMux() *event.TypeMux {
@@ -643,6 +890,9 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	if n.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
+	if n.config.DataKey != nil {
+		return ethdb.NewEncryptedLDBDatabase(n.config.resolvePath(name), cache, handles, n.config.DataKey)
+	}
 	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
 }
 
@@ -677,6 +927,11 @@ func (n *Node) apis() []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicWeb3API(n),
 			Public:    true,
+		}, {
+			Namespace: "quorum",
+			Version:   "1.0",
+			Service:   NewPublicQuorumAPI(n),
+			Public:    true,
 		},
 	}
 }
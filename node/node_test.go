@@ -19,6 +19,7 @@ package node
 import (
 	"errors"
 	"io/ioutil"
+	"math/big"
 	"os"
 	"reflect"
 	"testing"
@@ -573,3 +574,71 @@ func TestAPIGather(t *testing.T) {
 		}
 	}
 }
+
+// Tests that admin_nodeInfo reports the Quorum setup of the node.
+func TestQuorumNodeInfo(t *testing.T) {
+	config := testNodeConfig()
+	config.EnableNodePermission = true
+	config.VaultAddr = "https://vault:8200"
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	info, err := NewPublicAdminAPI(stack).NodeInfo()
+	if err != nil {
+		t.Fatalf("failed to retrieve node info: %v", err)
+	}
+	want := &QuorumNodeInfo{
+		Consensus:     "test",
+		Permissioning: "node",
+		Vault:         &VaultInfo{Addr: "https://vault:8200"},
+	}
+	if !reflect.DeepEqual(info.Quorum, want) {
+		t.Errorf("quorum node info mismatch: have %+v, want %+v", info.Quorum, want)
+	}
+	if info.NodeInfo == nil || info.Enode == "" {
+		t.Errorf("p2p node info missing: %+v", info.NodeInfo)
+	}
+}
+
+// Tests that quorum_nodeVersion reports the consensus engine and the network
+// of the node.
+func TestQuorumNodeVersion(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewChainReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if _, err := NewPublicQuorumAPI(stack).NodeVersion(); err != ErrNodeStopped {
+		t.Fatalf("node version of a stopped node: have %v, want %v", err, ErrNodeStopped)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	version, err := NewPublicQuorumAPI(stack).NodeVersion()
+	if err != nil {
+		t.Fatalf("failed to retrieve node version: %v", err)
+	}
+	want := &NodeVersion{
+		Client:    stack.Server().Name,
+		Consensus: "raft",
+		Protocols: []string{},
+		NetworkId: 10,
+		ChainId:   big.NewInt(10),
+	}
+	if !reflect.DeepEqual(version, want) {
+		t.Errorf("node version mismatch: have %+v, want %+v", version, want)
+	}
+}
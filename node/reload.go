@@ -0,0 +1,127 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ConfigReload reports which changed settings a config reload applied.
+type ConfigReload struct {
+	Applied []string          `json:"applied"`          // Settings applied to the running node
+	Restart []string          `json:"restart"`          // Settings which only apply after a restart
+	Ignored []string          `json:"ignored"`          // Settings overridden on the command line
+	Failed  map[string]string `json:"failed,omitempty"` // Settings which couldn't be applied, with the reason
+}
+
+// Sort orders the settings of the report by name.
+func (r *ConfigReload) Sort() {
+	sort.Strings(r.Applied)
+	sort.Strings(r.Restart)
+	sort.Strings(r.Ignored)
+}
+
+// ConfigReloader re-reads the settings given to the node, applies the changes
+// which are safe to apply while running and reports them.
+type ConfigReloader func() (*ConfigReload, error)
+
+// SetConfigReloader sets the function reloading the settings of the node on
+// ReloadConfig, in addition to the static and trusted node lists.
+func (n *Node) SetConfigReloader(reloader ConfigReloader) {
+	n.reloadLock.Lock()
+	defer n.reloadLock.Unlock()
+
+	n.reloader = reloader
+}
+
+// ReloadConfig re-reads the settings of the node through the config reloader
+// and the static and trusted node lists in the data directory. Static nodes
+// are connected to or dropped right away; trusted nodes are only loaded on
+// startup.
+func (n *Node) ReloadConfig() (*ConfigReload, error) {
+	n.reloadLock.Lock()
+	defer n.reloadLock.Unlock()
+
+	report := &ConfigReload{Applied: []string{}, Restart: []string{}, Ignored: []string{}}
+	if n.reloader != nil {
+		var err error
+		if report, err = n.reloader(); err != nil {
+			return nil, err
+		}
+	}
+	n.lock.RLock()
+	server := n.server
+	n.lock.RUnlock()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	static := n.config.StaticNodes()
+	if added, removed := diffNodes(n.staticNodes, static); len(added) > 0 || len(removed) > 0 {
+		for _, node := range removed {
+			server.RemovePeer(node)
+		}
+		for _, node := range added {
+			server.AddPeer(node)
+		}
+		n.staticNodes = static
+		report.Applied = append(report.Applied, datadirStaticNodes)
+		glog.V(logger.Info).Infof("Reloaded static nodes: %d added, %d removed", len(added), len(removed))
+	}
+	if added, removed := diffNodes(n.serverConfig.TrustedNodes, n.config.TrusterNodes()); len(added) > 0 || len(removed) > 0 {
+		report.Restart = append(report.Restart, datadirTrustedNodes)
+	}
+	report.Sort()
+	return report, nil
+}
+
+// SetHTTPCors replaces the domains allowed to make cross-origin requests to the
+// HTTP endpoint, applying them to the running endpoint right away.
+func (n *Node) SetHTTPCors(cors string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.config.HTTPCors = cors
+	if n.httpCors != nil {
+		n.httpCors.set(rpc.NewHTTPServer(cors, n.httpHandler).Handler)
+	}
+}
+
+// swapHandler serves HTTP requests through a handler which can be replaced
+// while serving.
+type swapHandler struct {
+	handler atomic.Value
+}
+
+func newSwapHandler(handler http.Handler) *swapHandler {
+	h := new(swapHandler)
+	h.set(handler)
+	return h
+}
+
+func (h *swapHandler) set(handler http.Handler) {
+	h.handler.Store(handler)
+}
+
+func (h *swapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.Load().(http.Handler).ServeHTTP(w, r)
+}
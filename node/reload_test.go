@@ -0,0 +1,130 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that reloads connect to added static nodes, report changed trusted
+// nodes as requiring a restart and pass on the report of the reloader.
+func TestReloadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "test node"), 0700)
+
+	enodes := make([]string, 2)
+	for i := range enodes {
+		nodeKey, _ := crypto.GenerateKey()
+		enodes[i] = fmt.Sprintf("enode://%x@127.0.0.1:%d", crypto.FromECDSAPub(&nodeKey.PublicKey)[1:], 30303+i)
+	}
+	writeNodes := func(file string, urls ...string) {
+		if err := writeJSONFile(filepath.Join(dir, "test node", file), urls); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+	writeNodes(datadirStaticNodes, enodes[0])
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	report, err := stack.ReloadConfig()
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if len(report.Applied) != 0 || len(report.Restart) != 0 {
+		t.Errorf("unchanged reload reported changes: %+v", report)
+	}
+
+	writeNodes(datadirStaticNodes, enodes[1])
+	writeNodes(datadirTrustedNodes, enodes[0])
+	stack.SetConfigReloader(func() (*ConfigReload, error) {
+		return &ConfigReload{Applied: []string{"verbosity"}, Restart: []string{"port"}, Ignored: []string{}}, nil
+	})
+	if report, err = stack.ReloadConfig(); err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if want := []string{datadirStaticNodes, "verbosity"}; !reflect.DeepEqual(report.Applied, want) {
+		t.Errorf("applied mismatch: have %v, want %v", report.Applied, want)
+	}
+	if want := []string{"port", datadirTrustedNodes}; !reflect.DeepEqual(report.Restart, want) {
+		t.Errorf("restart mismatch: have %v, want %v", report.Restart, want)
+	}
+	if len(stack.staticNodes) != 1 || stack.staticNodes[0].String() != enodes[1] {
+		t.Errorf("static nodes not replaced: %v", stack.staticNodes)
+	}
+
+	stack.SetConfigReloader(func() (*ConfigReload, error) { return nil, errors.New("bad config") })
+	if _, err := stack.ReloadConfig(); err == nil || err.Error() != "bad config" {
+		t.Errorf("reloader error not returned: %v", err)
+	}
+}
+
+// Tests that the CORS domains of the running HTTP endpoint can be replaced.
+func TestSetHTTPCors(t *testing.T) {
+	config := testNodeConfig()
+	config.HTTPHost = "127.0.0.1"
+	config.HTTPCors = "http://a.example"
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	url := "http://" + stack.httpListener.Addr().String()
+	allowed := func(origin string) bool {
+		req, _ := http.NewRequest("OPTIONS", url, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		res.Body.Close()
+		return res.Header.Get("Access-Control-Allow-Origin") == origin
+	}
+	if !allowed("http://a.example") || allowed("http://b.example") {
+		t.Fatal("initial CORS domains not applied")
+	}
+	stack.SetHTTPCors("http://b.example")
+	if allowed("http://a.example") || !allowed("http://b.example") {
+		t.Error("replaced CORS domains not applied")
+	}
+}
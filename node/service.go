@@ -43,9 +43,18 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	if ctx.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
+	if ctx.config.DataKey != nil {
+		return ethdb.NewEncryptedLDBDatabase(ctx.config.resolvePath(name), cache, handles, ctx.config.DataKey)
+	}
 	return ethdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
 }
 
+// DataKey returns the key services encrypt the data they store with, or nil
+// if the node stores its data in plaintext.
+func (ctx *ServiceContext) DataKey() []byte {
+	return ctx.config.DataKey
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (ctx *ServiceContext) Service(service interface{}) error {
 	element := reflect.ValueOf(service).Elem()
@@ -85,3 +94,29 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// NodeInfoReporter is implemented by services which describe their part of the
+// node's setup, such as the consensus engine and role, in admin_nodeInfo.
+type NodeInfoReporter interface {
+	ReportNodeInfo(info *QuorumNodeInfo)
+}
+
+// StatusReporter is implemented by services which describe their state, such
+// as the head block or the consensus role, in the status file.
+type StatusReporter interface {
+	ReportStatus(status *NodeStatus)
+}
+
+// TopologyReporter is implemented by services which maintain links to other
+// nodes of their own, such as the raft cluster members, to add them to the
+// topology admin_exportPeerTopology exports.
+type TopologyReporter interface {
+	ReportTopology(topology *Topology)
+}
+
+// MaintenanceHandler is implemented by services which accept transactions or
+// take part in block production, and stop doing so while the node is in
+// maintenance. Syncing and serving reads carry on.
+type MaintenanceHandler interface {
+	SetMaintenance(enabled bool)
+}
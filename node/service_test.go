@@ -48,6 +48,21 @@ func TestContextDatabases(t *testing.T) {
 	if _, err := os.Stat(filepath.Join(dir, "unit-test", "persistent")); err != nil {
 		t.Fatalf("persistent database doesn't exists: %v", err)
 	}
+	// Request the chain database placed outside of the data directory
+	chaindir := filepath.Join(dir, "volume", "chaindata")
+	ctx = &ServiceContext{config: &Config{Name: "unit-test", DataDir: dir, ChainDataDir: chaindir}}
+	db, err = ctx.OpenDatabase("chaindata", 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open chain database: %v", err)
+	}
+	db.Close()
+
+	if _, err := os.Stat(chaindir); err != nil {
+		t.Fatalf("relocated chain database doesn't exist: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unit-test", "chaindata")); err == nil {
+		t.Fatalf("chain database created in the data directory")
+	}
 	// Request th opening/creation of an ephemeral database and ensure it's not persisted
 	ctx = &ServiceContext{config: &Config{DataDir: ""}}
 	db, err = ctx.OpenDatabase("ephemeral", 0, 0)
@@ -0,0 +1,234 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// DefaultStatusFileInterval is the default interval the status file is
+// rewritten at.
+const DefaultStatusFileInterval = 5 * time.Second
+
+// Node states reported in the status file.
+const (
+	StatusRunning = "running"
+	StatusStopped = "stopped" // written on clean shutdown only
+)
+
+// NodeStatus is the content of the status file. It's rewritten periodically
+// while the node runs, so a supervisor can tell a wedged or crashed node from
+// a stale Updated time without depending on the RPC endpoints.
+type NodeStatus struct {
+	Pid       int              `json:"pid"`
+	State     string           `json:"state"`
+	Started   time.Time        `json:"started"`
+	Updated   time.Time        `json:"updated"`
+	Peers     int              `json:"peers"`
+	Head      *HeadStatus      `json:"head,omitempty"`      // reported by the chain service
+	TxPool    *TxPoolStatus    `json:"txpool,omitempty"`    // reported by the chain service
+	Consensus interface{}      `json:"consensus,omitempty"` // engine specific, e.g. raft role and applied index
+	Inclusion *InclusionStatus `json:"inclusion,omitempty"` // reported by the chain service if the inclusion SLA is monitored
+	Resources *ProcStatus      `json:"resources"`
+}
+
+// HeadStatus describes the current head block.
+type HeadStatus struct {
+	Number uint64      `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	Time   uint64      `json:"timestamp"`
+}
+
+// TxPoolStatus holds the number of transactions in the transaction pool.
+type TxPoolStatus struct {
+	Pending int `json:"pending"`
+	Queued  int `json:"queued"`
+	Private int `json:"private"` // pending or queued private transactions
+}
+
+// InclusionStatus describes the time transactions submitted through the node
+// took to be included in a block, in milliseconds.
+type InclusionStatus struct {
+	SLA      uint64 `json:"sla"`
+	Pending  int    `json:"pending"`  // submitted transactions not included yet
+	Included int64  `json:"included"` // submitted transactions included since the start
+	Breaches int64  `json:"breaches"` // submitted transactions which exceeded the SLA
+	P50      uint64 `json:"p50"`
+	P95      uint64 `json:"p95"`
+	P99      uint64 `json:"p99"`
+}
+
+// ProcStatus describes the resource usage of the process.
+type ProcStatus struct {
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heapAlloc"` // bytes of allocated heap objects
+	Sys        uint64 `json:"sys"`       // bytes obtained from the OS
+	NumGC      uint32 `json:"numGC"`
+}
+
+// statusFile periodically writes the status of the node to a file.
+type statusFile struct {
+	path     string
+	interval time.Duration
+	started  time.Time
+	server   *p2p.Server
+	services map[reflect.Type]Service
+	mode     *maintenanceMode
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+func newStatusFile(path string, interval time.Duration, server *p2p.Server, services map[reflect.Type]Service, mode *maintenanceMode) *statusFile {
+	if interval <= 0 {
+		interval = DefaultStatusFileInterval
+	}
+	return &statusFile{
+		path:     path,
+		interval: interval,
+		started:  time.Now(),
+		server:   server,
+		services: services,
+		mode:     mode,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// start writes the status once, then keeps it updated in the background.
+func (f *statusFile) start() error {
+	if err := f.update(f.mode.state()); err != nil {
+		return err
+	}
+	go f.loop()
+	return nil
+}
+
+// stop ends the updates and records the clean shutdown.
+func (f *statusFile) stop() {
+	close(f.quit)
+	<-f.done
+	if err := f.update(StatusStopped); err != nil {
+		glog.V(logger.Warn).Infof("Failed to write status file: %v", err)
+	}
+}
+
+func (f *statusFile) loop() {
+	defer close(f.done)
+
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.update(f.mode.state()); err != nil {
+				glog.V(logger.Warn).Infof("Failed to write status file: %v", err)
+			}
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// collect gathers the status of the node from the running services.
+func (f *statusFile) collect(state string) *NodeStatus {
+	return collectStatus(state, f.started, f.server, f.services)
+}
+
+// collectStatus gathers the status of a node from its p2p server, the running
+// services and the runtime.
+func collectStatus(state string, started time.Time, server *p2p.Server, services map[reflect.Type]Service) *NodeStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	status := &NodeStatus{
+		Pid:     os.Getpid(),
+		State:   state,
+		Started: started,
+		Updated: time.Now(),
+		Peers:   server.PeerCount(),
+		Resources: &ProcStatus{
+			Goroutines: runtime.NumGoroutine(),
+			HeapAlloc:  mem.HeapAlloc,
+			Sys:        mem.Sys,
+			NumGC:      mem.NumGC,
+		},
+	}
+	for _, service := range services {
+		if reporter, ok := service.(StatusReporter); ok {
+			reporter.ReportStatus(status)
+		}
+	}
+	return status
+}
+
+func (f *statusFile) update(state string) error {
+	return writeJSONFile(f.path, f.collect(state))
+}
+
+// writeJSONFile atomically replaces a file with the JSON encoding of v, so
+// readers never see a partially written one, even if the node crashes while
+// writing it.
+func writeJSONFile(path string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(blob, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	// Persist the rename, not all platforms support syncing directories
+	if dir, err := os.Open(filepath.Dir(path)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// statusFilePath resolves the configured status file in the data directory.
+func (c *Config) statusFilePath() string {
+	if c.StatusFile == "" || filepath.IsAbs(c.StatusFile) || c.DataDir == "" {
+		return c.StatusFile
+	}
+	return filepath.Join(c.DataDir, c.StatusFile)
+}
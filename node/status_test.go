@@ -0,0 +1,119 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readStatusFile(t *testing.T, path string) *NodeStatus {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read status file: %v", err)
+	}
+	status := new(NodeStatus)
+	if err := json.Unmarshal(blob, status); err != nil {
+		t.Fatalf("failed to decode status file: %v", err)
+	}
+	return status
+}
+
+// Tests that the status file is written on startup, kept updated while the
+// node runs and marks a clean shutdown.
+func TestStatusFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	config.StatusFile = "status.json"
+	config.StatusFileInterval = 10 * time.Millisecond
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	path := filepath.Join(dir, "status.json")
+	status := readStatusFile(t, path)
+	if status.State != StatusRunning || status.Pid != os.Getpid() {
+		t.Errorf("status mismatch: have state %q, pid %d", status.State, status.Pid)
+	}
+	if status.Head == nil || status.Head.Number != 42 || status.Consensus != "test" {
+		t.Errorf("service status missing: %+v", status)
+	}
+	// The file must be rewritten periodically
+	updated := status.Updated
+	time.Sleep(50 * time.Millisecond)
+	if status := readStatusFile(t, path); !status.Updated.After(updated) {
+		t.Errorf("status file not updated: %v, previously %v", status.Updated, updated)
+	}
+	if err := stack.Stop(); err != nil {
+		t.Fatalf("failed to stop protocol stack: %v", err)
+	}
+	if status := readStatusFile(t, path); status.State != StatusStopped {
+		t.Errorf("shutdown not recorded: have state %q", status.State)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary status file left behind")
+	}
+}
+
+// Tests that admin_nodeStatus reports the status of a running node on demand.
+func TestNodeStatusAPI(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	api := NewPublicAdminAPI(stack)
+	if _, err := api.NodeStatus(); err != ErrNodeStopped {
+		t.Fatalf("status of stopped node: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	status, err := api.NodeStatus()
+	if err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+	if status.State != StatusRunning || status.Pid != os.Getpid() || status.Started.IsZero() {
+		t.Errorf("status mismatch: have state %q, pid %d, started %v", status.State, status.Pid, status.Started)
+	}
+	if status.Head == nil || status.Head.Number != 42 || status.Consensus != "test" {
+		t.Errorf("service status missing: %+v", status)
+	}
+	if status.Resources == nil || status.Resources.Goroutines == 0 || status.Resources.Sys == 0 {
+		t.Errorf("resource usage missing: %+v", status.Resources)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ConfigSummary summarizes the effective configuration of the node when it
+// started, so misconfiguration is obvious from the first lines of the log or
+// a single admin_configSummary call.
+type ConfigSummary struct {
+	*QuorumNodeInfo
+	Endpoints []*EndpointSummary `json:"endpoints"`
+}
+
+// EndpointSummary describes an RPC endpoint and the API modules it exposes.
+type EndpointSummary struct {
+	Transport string   `json:"transport"` // "ipc", "http" or "ws"
+	Address   string   `json:"address"`
+	Modules   []string `json:"modules"`
+}
+
+// configSummary gathers the configuration summary from the running services.
+// It's called while starting the node, before any lock is released.
+func (n *Node) configSummary(services map[reflect.Type]Service, apis []rpc.API) *ConfigSummary {
+	summary := &ConfigSummary{QuorumNodeInfo: n.collectQuorumNodeInfo(services)}
+	if n.ipcEndpoint != "" {
+		summary.Endpoints = append(summary.Endpoints, &EndpointSummary{"ipc", n.ipcEndpoint, exposedModules(apis, nil, true)})
+	}
+	if n.httpEndpoint != "" {
+		summary.Endpoints = append(summary.Endpoints, &EndpointSummary{"http", n.httpEndpoint, exposedModules(apis, n.config.HTTPModules, false)})
+	}
+	if n.wsEndpoint != "" {
+		summary.Endpoints = append(summary.Endpoints, &EndpointSummary{"ws", n.wsEndpoint, exposedModules(apis, n.config.WSModules, false)})
+	}
+	return summary
+}
+
+// exposedModules returns the sorted API namespaces an endpoint serves: all of
+// them, those whitelisted, or the public ones if there's no whitelist.
+func exposedModules(apis []rpc.API, whitelist []string, all bool) []string {
+	allowed := make(map[string]bool)
+	for _, module := range whitelist {
+		allowed[module] = true
+	}
+	seen := make(map[string]bool)
+	modules := []string{}
+	for _, api := range apis {
+		if seen[api.Namespace] {
+			continue
+		}
+		if all || allowed[api.Namespace] || (len(allowed) == 0 && api.Public) {
+			seen[api.Namespace] = true
+			modules = append(modules, api.Namespace)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// log prints the summary, one aspect of the configuration per line.
+func (s *ConfigSummary) log() {
+	describe := func(v interface{}, unset string) string {
+		if v == nil {
+			return unset
+		}
+		blob, err := json.Marshal(v)
+		if err != nil {
+			return err.Error()
+		}
+		return string(blob)
+	}
+	glog.V(logger.Info).Infoln("Configuration summary:")
+	if chain := s.Chain; chain != nil {
+		glog.V(logger.Info).Infof("  chain:           network id %d, genesis %x, gas limit %v", chain.NetworkId, chain.Genesis, chain.GasLimit)
+	}
+	glog.V(logger.Info).Infof("  consensus:       %s", describe(s.Consensus, "none"))
+	glog.V(logger.Info).Infof("  privacy manager: %s", describe(s.PrivacyManager, "disabled"))
+	glog.V(logger.Info).Infof("  permissioning:   %s", s.Permissioning)
+	if s.Vault != nil {
+		glog.V(logger.Info).Infof("  vault:           %s (prefix %s)", s.Vault.Addr, s.Vault.Prefix)
+	} else {
+		glog.V(logger.Info).Infof("  vault:           not configured")
+	}
+	if len(s.Endpoints) == 0 {
+		glog.V(logger.Info).Infof("  rpc:             no endpoints")
+	}
+	for _, endpoint := range s.Endpoints {
+		glog.V(logger.Info).Infof("  %-16s %s [%s]", endpoint.Transport+":", endpoint.Address, strings.Join(endpoint.Modules, " "))
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tests that endpoints report all modules, the whitelisted ones or the public
+// ones if there's no whitelist.
+func TestExposedModules(t *testing.T) {
+	apis := []rpc.API{
+		{Namespace: "eth", Public: true},
+		{Namespace: "admin"},
+		{Namespace: "admin", Public: true},
+		{Namespace: "personal"},
+		{Namespace: "net", Public: true},
+	}
+	tests := []struct {
+		whitelist []string
+		all       bool
+		want      []string
+	}{
+		{nil, true, []string{"admin", "eth", "net", "personal"}},
+		{nil, false, []string{"admin", "eth", "net"}},
+		{[]string{"personal", "eth", "shh"}, false, []string{"eth", "personal"}},
+	}
+	for i, test := range tests {
+		if have := exposedModules(apis, test.whitelist, test.all); !reflect.DeepEqual(have, test.want) {
+			t.Errorf("test %d: modules mismatch: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+// Tests that the configuration summary is gathered on startup and dropped when
+// the node stops.
+func TestConfigSummary(t *testing.T) {
+	config := testNodeConfig()
+	config.HTTPHost = "127.0.0.1"
+	config.HTTPModules = []string{"web3", "admin"}
+	config.StartupSummary = true
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	api := NewPublicAdminAPI(stack)
+	if _, err := api.ConfigSummary(); err != ErrNodeStopped {
+		t.Fatalf("summary of stopped node: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	summary, err := api.ConfigSummary()
+	if err != nil {
+		t.Fatalf("failed to retrieve summary: %v", err)
+	}
+	if summary.Consensus != "test" || summary.Permissioning != "none" {
+		t.Errorf("node info mismatch: %+v", summary.QuorumNodeInfo)
+	}
+	want := []*EndpointSummary{{"http", stack.HTTPEndpoint(), []string{"admin", "web3"}}}
+	if !reflect.DeepEqual(summary.Endpoints, want) {
+		t.Errorf("endpoints mismatch: have %+v, want %+v", summary.Endpoints, want)
+	}
+	stack.Stop()
+	if _, err := api.ConfigSummary(); err != ErrNodeStopped {
+		t.Errorf("summary retained after stop: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// Formats admin_exportPeerTopology exports the topology in.
+const (
+	TopologyJSON = "json" // the Topology itself
+	TopologyDOT  = "dot"  // a Graphviz digraph
+)
+
+// Kinds of links between nodes.
+const (
+	TopologyP2P  = "p2p"  // an established devp2p connection
+	TopologyRaft = "raft" // a raft cluster member, over the raft transport
+)
+
+// Topology is the view a node has of the network: the nodes it knows of and
+// its links to them. Exported by every member, the topologies combine into a
+// graph of the consortium.
+type Topology struct {
+	Self  string          `json:"self"` // ID of the exporting node
+	Nodes []*TopologyNode `json:"nodes"`
+	Links []*TopologyLink `json:"links"`
+}
+
+// TopologyNode is a node of the network, identified by its enode ID.
+type TopologyNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`    // client name, known for connected peers
+	Address   string `json:"address,omitempty"` // remote address of the connection, or the advertised address
+	Connected bool   `json:"connected"`         // whether there is a p2p connection to the node
+	RaftId    uint16 `json:"raftId,omitempty"`  // reported by the consensus service in raft mode
+	Role      string `json:"role,omitempty"`    // consensus role, e.g. raft leader or follower
+}
+
+// TopologyLink is a link between two nodes. P2P links point from the node
+// which dialed the connection to the dialed one, raft links from the exporting
+// node to the other cluster members.
+type TopologyLink struct {
+	Source  string     `json:"source"`
+	Target  string     `json:"target"`
+	Kind    string     `json:"kind"`
+	Static  bool       `json:"static,omitempty"`  // p2p link dialed as a static node
+	Trusted bool       `json:"trusted,omitempty"` // p2p link to a trusted node
+	Healthy bool       `json:"healthy"`           // p2p links are up, raft links if the transport is active
+	Since   *time.Time `json:"since,omitempty"`   // when a healthy raft link became active
+}
+
+// Node returns the node of the topology with an ID, adding it if missing.
+func (t *Topology) Node(id string) *TopologyNode {
+	for _, n := range t.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	n := &TopologyNode{ID: id}
+	t.Nodes = append(t.Nodes, n)
+	return n
+}
+
+// DOT renders the topology as a Graphviz digraph. The exporting node is drawn
+// with a double border, nodes without a p2p connection and unhealthy links
+// dashed and in red, healthy raft links in blue.
+func (t *Topology) DOT() string {
+	buf := new(bytes.Buffer)
+	fmt.Fprintln(buf, "digraph topology {")
+	for _, n := range t.Nodes {
+		label := shortTopologyID(n.ID)
+		if n.Name != "" {
+			label += "\n" + strings.SplitN(n.Name, "/", 2)[0]
+		}
+		if n.RaftId != 0 {
+			label += fmt.Sprintf("\nraft %d", n.RaftId)
+		}
+		if n.Role != "" {
+			label += " " + n.Role
+		}
+		attrs := []string{"label=" + strconv.Quote(label)}
+		if n.ID == t.Self {
+			attrs = append(attrs, "peripheries=2")
+		} else if !n.Connected {
+			attrs = append(attrs, "style=dashed", "color=red")
+		}
+		fmt.Fprintf(buf, "  %s [%s];\n", strconv.Quote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, l := range t.Links {
+		label := l.Kind
+		if l.Static {
+			label += " static"
+		}
+		if l.Trusted {
+			label += " trusted"
+		}
+		attrs := []string{"label=" + strconv.Quote(label)}
+		switch {
+		case !l.Healthy:
+			attrs = append(attrs, "style=dashed", "color=red")
+		case l.Kind == TopologyRaft:
+			attrs = append(attrs, "color=blue")
+		}
+		fmt.Fprintf(buf, "  %s -> %s [%s];\n", strconv.Quote(l.Source), strconv.Quote(l.Target), strings.Join(attrs, ", "))
+	}
+	fmt.Fprintln(buf, "}")
+	return buf.String()
+}
+
+// shortTopologyID abbreviates a node ID for graph labels.
+func shortTopologyID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// collectTopology gathers the p2p connections of the server and lets the
+// services add the links of their own, like the raft cluster members.
+func collectTopology(server *p2p.Server, services map[reflect.Type]Service) *Topology {
+	info := server.NodeInfo()
+	t := &Topology{Self: info.ID, Links: []*TopologyLink{}}
+
+	self := t.Node(info.ID)
+	self.Name, self.Address, self.Connected = info.Name, info.ListenAddr, true
+
+	for _, peer := range server.PeersInfo() {
+		n := t.Node(peer.ID)
+		n.Name, n.Address, n.Connected = peer.Name, peer.Network.RemoteAddress, true
+
+		link := &TopologyLink{
+			Source:  info.ID,
+			Target:  peer.ID,
+			Kind:    TopologyP2P,
+			Static:  peer.Network.Static,
+			Trusted: peer.Network.Trusted,
+			Healthy: true,
+		}
+		if peer.Network.Inbound {
+			link.Source, link.Target = peer.ID, info.ID
+		}
+		t.Links = append(t.Links, link)
+	}
+	for _, service := range services {
+		if reporter, ok := service.(TopologyReporter); ok {
+			reporter.ReportTopology(t)
+		}
+	}
+	return t
+}
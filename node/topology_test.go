@@ -0,0 +1,78 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"strings"
+	"testing"
+)
+
+// Tests that the exported topology holds the node, the links the services
+// report and renders as a Graphviz digraph.
+func TestExportPeerTopology(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Register(NewReportingService); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	api := NewPublicAdminAPI(stack)
+	if _, err := api.ExportPeerTopology(nil); err != ErrNodeStopped {
+		t.Errorf("stopped node: have %v, want %v", err, ErrNodeStopped)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	result, err := api.ExportPeerTopology(nil)
+	if err != nil {
+		t.Fatalf("failed to export topology: %v", err)
+	}
+	topology := result.(*Topology)
+	if topology.Self != stack.Server().NodeInfo().ID || len(topology.Nodes) != 2 || len(topology.Links) != 1 {
+		t.Fatalf("topology mismatch: %+v", topology)
+	}
+	if self := topology.Nodes[0]; self.ID != topology.Self || !self.Connected || self.RaftId != 1 {
+		t.Errorf("own node mismatch: %+v", self)
+	}
+	if member := topology.Nodes[1]; member.Connected || member.RaftId != 2 || member.Role != "leader" {
+		t.Errorf("cluster member mismatch: %+v", member)
+	}
+
+	format := TopologyDOT
+	result, err = api.ExportPeerTopology(&format)
+	if err != nil {
+		t.Fatalf("failed to export topology: %v", err)
+	}
+	dot := result.(string)
+	for _, want := range []string{
+		"digraph topology {",
+		`"0123456789abcdef" [label="01234567\nraft 2 leader", style=dashed, color=red];`,
+		`"` + topology.Self + `" -> "0123456789abcdef" [label="raft", style=dashed, color=red];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+
+	format = "svg"
+	if _, err := api.ExportPeerTopology(&format); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
@@ -20,6 +20,7 @@
 package node
 
 import (
+	"math/big"
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/p2p"
@@ -133,3 +134,49 @@ func (api *OneMethodApi) TheOneMethod() {
 		api.fun()
 	}
 }
+
+// ReportingService is a test implementation of a service reporting its
+// consensus role in admin_nodeInfo and a cluster member in the topology.
+type ReportingService struct{ NoopService }
+
+func NewReportingService(*ServiceContext) (Service, error) { return new(ReportingService), nil }
+
+func (s *ReportingService) ReportNodeInfo(info *QuorumNodeInfo) {
+	info.Consensus = "test"
+}
+
+func (s *ReportingService) ReportStatus(status *NodeStatus) {
+	status.Head = &HeadStatus{Number: 42}
+	status.Consensus = "test"
+}
+
+func (s *ReportingService) ReportTopology(topology *Topology) {
+	topology.Node(topology.Self).RaftId = 1
+	member := topology.Node("0123456789abcdef")
+	member.RaftId, member.Role = 2, "leader"
+	topology.Links = append(topology.Links, &TopologyLink{Source: topology.Self, Target: member.ID, Kind: TopologyRaft})
+}
+
+// ChainReportingService is a test implementation of a service reporting its
+// consensus engine and chain in admin_nodeInfo.
+type ChainReportingService struct{ NoopService }
+
+func NewChainReportingService(*ServiceContext) (Service, error) { return new(ChainReportingService), nil }
+
+func (s *ChainReportingService) ReportNodeInfo(info *QuorumNodeInfo) {
+	info.Consensus = map[string]interface{}{"engine": "raft", "role": "leader"}
+	info.Chain = &ChainInfo{NetworkId: 10, ChainId: big.NewInt(10)}
+}
+
+// MaintenanceService is a test implementation of a service recording whether
+// it's in maintenance.
+type MaintenanceService struct {
+	NoopService
+	maintenance bool
+}
+
+func NewMaintenanceService(*ServiceContext) (Service, error) { return new(MaintenanceService), nil }
+
+func (s *MaintenanceService) SetMaintenance(enabled bool) {
+	s.maintenance = enabled
+}
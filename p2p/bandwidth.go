@@ -0,0 +1,177 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// Bandwidth limits the bytes per second exchanged with a peer.
+type Bandwidth struct {
+	Ingress int64 // Bytes per second received from the peer, 0 = unlimited
+	Egress  int64 // Bytes per second sent to the peer, 0 = unlimited
+}
+
+// PeerTraffic is the traffic exchanged with a peer since it connected.
+type PeerTraffic struct {
+	Ingress    uint64 `json:"ingress"`    // Bytes received
+	Egress     uint64 `json:"egress"`     // Bytes sent
+	MaxIngress int64  `json:"maxIngress"` // Bytes per second received at most, 0 if unlimited
+	MaxEgress  int64  `json:"maxEgress"`  // Bytes per second sent at most, 0 if unlimited
+	Throttled  string `json:"throttled"`  // Time reads and writes were held back by the limits
+}
+
+// rateLimiter is a token bucket holding a second worth of bytes. Transfers
+// larger than the bucket drive it negative, and the transfers after them wait
+// until it refilled, so the average rate stays within the limit.
+type rateLimiter struct {
+	lock   sync.Mutex
+	rate   int64 // Bytes per second, 0 = unlimited
+	tokens float64
+	last   time.Time
+}
+
+func (l *rateLimiter) setRate(rate int64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.rate, l.tokens, l.last = rate, float64(rate), time.Now()
+}
+
+// reserve takes n bytes from the bucket and returns how long the transfer has
+// to wait to stay within the limit.
+func (l *rateLimiter) reserve(n int, now time.Time) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if l.tokens > float64(l.rate) {
+		l.tokens = float64(l.rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+}
+
+// bandwidthConn counts the bytes exchanged with a peer and holds back reads
+// and writes exceeding its bandwidth. Reads are throttled after the fact, the
+// peer's sends back up in the TCP window while the next read waits.
+type bandwidthConn struct {
+	net.Conn
+
+	ingress   uint64 // atomic
+	egress    uint64 // atomic
+	throttled int64  // atomic, nanoseconds
+
+	in, out rateLimiter
+}
+
+func newBandwidthConn(fd net.Conn, limits Bandwidth) *bandwidthConn {
+	c := &bandwidthConn{Conn: fd}
+	c.setLimits(limits)
+	return c
+}
+
+func (c *bandwidthConn) setLimits(limits Bandwidth) {
+	c.in.setRate(limits.Ingress)
+	c.out.setRate(limits.Egress)
+}
+
+func (c *bandwidthConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		atomic.AddUint64(&c.ingress, uint64(n))
+		c.wait(c.in.reserve(n, time.Now()))
+	}
+	return n, err
+}
+
+func (c *bandwidthConn) Write(b []byte) (int, error) {
+	c.wait(c.out.reserve(len(b), time.Now()))
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(&c.egress, uint64(n))
+	return n, err
+}
+
+func (c *bandwidthConn) wait(d time.Duration) {
+	if d > 0 {
+		atomic.AddInt64(&c.throttled, int64(d))
+		time.Sleep(d)
+	}
+}
+
+func (c *bandwidthConn) traffic() *PeerTraffic {
+	c.in.lock.Lock()
+	maxIngress := c.in.rate
+	c.in.lock.Unlock()
+	c.out.lock.Lock()
+	maxEgress := c.out.rate
+	c.out.lock.Unlock()
+
+	return &PeerTraffic{
+		Ingress:    atomic.LoadUint64(&c.ingress),
+		Egress:     atomic.LoadUint64(&c.egress),
+		MaxIngress: maxIngress,
+		MaxEgress:  maxEgress,
+		Throttled:  time.Duration(atomic.LoadInt64(&c.throttled)).String(),
+	}
+}
+
+// peerBandwidth returns the bandwidth of a peer: its own if one was set, the
+// configured default otherwise.
+func (srv *Server) peerBandwidth(id discover.NodeID) Bandwidth {
+	srv.bandwidthLock.Lock()
+	defer srv.bandwidthLock.Unlock()
+
+	if limits, ok := srv.bandwidths[id]; ok {
+		return limits
+	}
+	return srv.MaxPeerBandwidth
+}
+
+// SetPeerBandwidth limits the bandwidth of a node, replacing the configured
+// default for it, and applies the limits to it right away if it's connected.
+// The limits are kept until the server stops.
+func (srv *Server) SetPeerBandwidth(id discover.NodeID, limits Bandwidth) {
+	srv.bandwidthLock.Lock()
+	if srv.bandwidths == nil {
+		srv.bandwidths = make(map[discover.NodeID]Bandwidth)
+	}
+	srv.bandwidths[id] = limits
+	srv.bandwidthLock.Unlock()
+
+	select {
+	case srv.peerOp <- func(peers map[discover.NodeID]*Peer) {
+		if p := peers[id]; p != nil && p.rw.bandwidth != nil {
+			p.rw.bandwidth.setLimits(limits)
+		}
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// Tests that the rate limiter holds back transfers exceeding the rate.
+func TestRateLimiter(t *testing.T) {
+	var (
+		l   rateLimiter
+		now = time.Now()
+	)
+	l.setRate(1000)
+	l.last = now
+
+	if d := l.reserve(1000, now); d != 0 {
+		t.Fatalf("wait within the bucket: got %v, want 0", d)
+	}
+	if d := l.reserve(500, now); d != 500*time.Millisecond {
+		t.Fatalf("wait beyond the bucket: got %v, want 500ms", d)
+	}
+	if d := l.reserve(500, now.Add(time.Second)); d != 0 {
+		t.Fatalf("wait after refill: got %v, want 0", d)
+	}
+	l.setRate(0)
+	if d := l.reserve(1<<20, now); d != 0 {
+		t.Fatalf("wait without limit: got %v, want 0", d)
+	}
+}
+
+// Tests that bandwidth connections count and throttle the bytes transferred.
+func TestBandwidthConn(t *testing.T) {
+	fd1, fd2 := net.Pipe()
+	defer fd1.Close()
+	defer fd2.Close()
+
+	c := newBandwidthConn(fd1, Bandwidth{Egress: 2048})
+	go io.Copy(fd2, fd2)
+
+	start := time.Now()
+	buf := make([]byte, 1024)
+	for i := 0; i < 4; i++ {
+		if _, err := c.Write(buf); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadFull(c, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The bucket holds 2048 bytes, the other 2048 take a second.
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("writes not throttled: took %v", elapsed)
+	}
+	traffic := c.traffic()
+	if traffic.Ingress != 4096 || traffic.Egress != 4096 {
+		t.Errorf("traffic mismatch: got %d in, %d out, want 4096 each", traffic.Ingress, traffic.Egress)
+	}
+	if traffic.MaxIngress != 0 || traffic.MaxEgress != 2048 {
+		t.Errorf("limits mismatch: got %d in, %d out", traffic.MaxIngress, traffic.MaxEgress)
+	}
+}
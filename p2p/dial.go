@@ -214,6 +214,10 @@ func (s *dialstate) taskDone(t task, now time.Time) {
 }
 
 func (t *dialTask) Do(srv *Server) {
+	if srv.peerRules.denied(t.dest.ID) {
+		glog.V(logger.Detail).Infof("not dialing %x: denied by peer rule", t.dest.ID[:6])
+		return
+	}
 	if t.dest.Incomplete() {
 		if !t.resolve(srv) {
 			return
@@ -192,6 +192,21 @@ func TestHexID(t *testing.T) {
 	}
 }
 
+func TestNodeIDText(t *testing.T) {
+	id := MustHexID("1dd9d65c4552b5eb43d5ad55a2ee3f56c6cbc1c64a5c8d659f51fcd51bace24351232b8d7821617d2b29b54b81cdefb9b3e9c37d7fd5f63270bcc9e1a6f6a439")
+	text, err := id.MarshalText()
+	if err != nil || string(text) != id.String() {
+		t.Fatalf("wrong text encoding: %s, %v", text, err)
+	}
+	var dec NodeID
+	if err := dec.UnmarshalText(text); err != nil || dec != id {
+		t.Fatalf("wrong decoded id: %v, %v", dec, err)
+	}
+	if err := dec.UnmarshalText([]byte("0x1234")); err == nil {
+		t.Errorf("short id accepted")
+	}
+}
+
 func TestNodeID_recover(t *testing.T) {
 	prv := newkey()
 	hash := make([]byte, 32)
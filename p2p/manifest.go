@@ -0,0 +1,143 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// nodeManifestDomain separates the hashes signed for node manifests from any
+// other data signed with the same key.
+var nodeManifestDomain = []byte("quorum node manifest")
+
+var errNodeManifestUntrusted = errors.New("node manifest not signed by a trusted key")
+
+// NodeManifest lists the static and permissioned nodes of a network, signed by
+// a consortium key like node certificates. It's published at a URL nodes poll,
+// so the topology of the network is rolled out by publishing a single file.
+//
+// Nil lists leave the corresponding nodes of a node unchanged, empty lists
+// remove all of them.
+type NodeManifest struct {
+	Version           uint64   // Increases with every published manifest, older ones are ignored
+	StaticNodes       []string // Enode URLs of the nodes to stay connected to
+	PermissionedNodes []string // Enode URLs of the nodes admitted by node permissioning
+	Sig               []byte   // Signature of the consortium key
+}
+
+type jsonNodeManifest struct {
+	Version           uint64   `json:"version"`
+	StaticNodes       []string `json:"staticNodes"`
+	PermissionedNodes []string `json:"permissionedNodes"`
+	Sig               string   `json:"signature,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *NodeManifest) MarshalJSON() ([]byte, error) {
+	enc := &jsonNodeManifest{Version: m.Version, StaticNodes: m.StaticNodes, PermissionedNodes: m.PermissionedNodes}
+	if m.Sig != nil {
+		enc.Sig = common.ToHex(m.Sig)
+	}
+	return json.Marshal(enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *NodeManifest) UnmarshalJSON(input []byte) error {
+	var dec jsonNodeManifest
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	m.Version, m.StaticNodes, m.PermissionedNodes, m.Sig = dec.Version, dec.StaticNodes, dec.PermissionedNodes, common.FromHex(dec.Sig)
+	return nil
+}
+
+func (m *NodeManifest) sigHash() []byte {
+	// Nil and empty lists differ in meaning, so their presence is signed too
+	enc, _ := rlp.EncodeToBytes([]interface{}{m.Version, m.StaticNodes != nil, m.StaticNodes, m.PermissionedNodes != nil, m.PermissionedNodes})
+	return crypto.Keccak256(nodeManifestDomain, enc)
+}
+
+// Sign signs the manifest with a consortium key.
+func (m *NodeManifest) Sign(key *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(m.sigHash(), key)
+	if err != nil {
+		return err
+	}
+	m.Sig = sig
+	return nil
+}
+
+// Signer returns the address of the key which signed the manifest.
+func (m *NodeManifest) Signer() (common.Address, error) {
+	if len(m.Sig) != 65 {
+		return common.Address{}, errors.New("invalid node manifest signature")
+	}
+	pub, err := crypto.SigToPub(m.sigHash(), m.Sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify checks that the manifest was signed by one of the trusted keys.
+func (m *NodeManifest) Verify(signers []common.Address) error {
+	signer, err := m.Signer()
+	if err != nil {
+		return err
+	}
+	for _, trusted := range signers {
+		if signer == trusted {
+			return nil
+		}
+	}
+	return errNodeManifestUntrusted
+}
+
+// Nodes parses the static and permissioned nodes of the manifest. A manifest
+// with any invalid enode URL is rejected as a whole.
+func (m *NodeManifest) Nodes() (static, permissioned []*discover.Node, err error) {
+	if static, err = parseManifestNodes(m.StaticNodes); err != nil {
+		return nil, nil, fmt.Errorf("static node %v", err)
+	}
+	if permissioned, err = parseManifestNodes(m.PermissionedNodes); err != nil {
+		return nil, nil, fmt.Errorf("permissioned node %v", err)
+	}
+	return static, permissioned, nil
+}
+
+func parseManifestNodes(urls []string) ([]*discover.Node, error) {
+	if urls == nil {
+		return nil, nil
+	}
+	nodes := make([]*discover.Node, 0, len(urls))
+	for _, url := range urls {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", url, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
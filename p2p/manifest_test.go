@@ -0,0 +1,109 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that node manifests only verify if signed by a trusted key, survive a
+// JSON round trip and can't have their node lists emptied or dropped.
+func TestNodeManifestVerify(t *testing.T) {
+	var (
+		key, other = newkey(), newkey()
+		signers    = []common.Address{crypto.PubkeyToAddress(key.PublicKey)}
+		enode      = fmt.Sprintf("enode://%v@127.0.0.1:30303", randomID())
+	)
+	manifest := &NodeManifest{Version: 7, StaticNodes: []string{enode}, PermissionedNodes: []string{}}
+	if err := manifest.Sign(key); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	blob, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(NodeManifest)
+	if err := json.Unmarshal(blob, dec); err != nil {
+		t.Fatalf("failed to decode %s: %v", blob, err)
+	}
+	if err := dec.Verify(signers); err != nil {
+		t.Fatalf("valid manifest rejected: %v", err)
+	}
+	if dec.PermissionedNodes == nil {
+		t.Errorf("empty permissioned nodes decoded as unset")
+	}
+	static, permissioned, err := dec.Nodes()
+	if err != nil || len(static) != 1 || permissioned == nil || len(permissioned) != 0 {
+		t.Errorf("nodes mismatch: %v %v %v", static, permissioned, err)
+	}
+
+	tampered := *dec
+	tampered.PermissionedNodes = nil
+	if err := tampered.Verify(signers); err == nil {
+		t.Errorf("manifest with dropped node list accepted")
+	}
+	tampered = *dec
+	tampered.Version++
+	if err := tampered.Verify(signers); err == nil {
+		t.Errorf("manifest with altered version accepted")
+	}
+	if err := manifest.Sign(other); err != nil {
+		t.Fatal(err)
+	}
+	if err := manifest.Verify(signers); err != errNodeManifestUntrusted {
+		t.Errorf("manifest of untrusted key accepted: %v", err)
+	}
+	manifest.StaticNodes = append(manifest.StaticNodes, "enode://invalid")
+	if _, _, err := manifest.Nodes(); err == nil {
+		t.Errorf("invalid enode accepted")
+	}
+}
+
+// Tests that the permissioned nodes set on a server are read back for new
+// connections.
+func TestSetPermissionedNodes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := &Server{Config: Config{DataDir: dir}}
+	manifest := &NodeManifest{PermissionedNodes: []string{fmt.Sprintf("enode://%v@127.0.0.1:30303", randomID())}}
+	_, nodes, err := manifest.Nodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.SetPermissionedNodes(nodes); err != nil {
+		t.Fatalf("failed to set permissioned nodes: %v", err)
+	}
+	id := nodes[0].ID.String()
+	if !isNodePermissioned(id, id, dir, "INCOMING") {
+		t.Errorf("permissioned node not admitted")
+	}
+	other := randomID().String()
+	if isNodePermissioned(other, id, dir, "INCOMING") {
+		t.Errorf("other node admitted")
+	}
+}
@@ -60,6 +60,9 @@ type Interface interface {
 //     "upnp"               uses the Universal Plug and Play protocol
 //     "pmp"                uses NAT-PMP with an auto-detected gateway address
 //     "pmp:192.168.0.1"    uses NAT-PMP with the given gateway address
+//
+// Only extip and pmp take an IP address, which has to be a plain address
+// without a port. The external IP can't be unspecified or multicast.
 func Parse(spec string) (Interface, error) {
 	var (
 		parts = strings.SplitN(spec, ":", 2)
@@ -69,7 +72,16 @@ func Parse(spec string) (Interface, error) {
 	if len(parts) > 1 {
 		ip = net.ParseIP(parts[1])
 		if ip == nil {
-			return nil, errors.New("invalid IP address")
+			if _, _, err := net.SplitHostPort(parts[1]); err == nil {
+				return nil, fmt.Errorf("invalid IP address %q, expected an IP address without a port", parts[1])
+			}
+			return nil, fmt.Errorf("invalid IP address %q", parts[1])
+		}
+	}
+	switch mech {
+	case "", "none", "off", "any", "auto", "on", "upnp":
+		if ip != nil {
+			return nil, fmt.Errorf("mechanism %q doesn't take an IP address", parts[0])
 		}
 	}
 	switch mech {
@@ -81,6 +93,9 @@ func Parse(spec string) (Interface, error) {
 		if ip == nil {
 			return nil, errors.New("missing IP address")
 		}
+		if ip.IsUnspecified() || ip.IsMulticast() {
+			return nil, fmt.Errorf("invalid external IP address %v", ip)
+		}
 		return ExtIP(ip), nil
 	case "upnp":
 		return UPnP(), nil
@@ -18,6 +18,7 @@ package nat
 
 import (
 	"bytes"
+	"fmt"
 	"net"
 	"testing"
 	"time"
@@ -62,3 +63,35 @@ func TestAutoDiscRace(t *testing.T) {
 		}
 	}
 }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Interface
+		err  bool
+	}{
+		{spec: "", want: nil},
+		{spec: "none", want: nil},
+		{spec: "extip:1.2.3.4", want: ExtIP(net.ParseIP("1.2.3.4"))},
+		{spec: "EXTIP:::1", want: ExtIP(net.ParseIP("::1"))},
+		{spec: "extip", err: true},
+		{spec: "extip:1.2.3.4:30303", err: true},
+		{spec: "extip:example.com", err: true},
+		{spec: "extip:0.0.0.0", err: true},
+		{spec: "extip:224.0.0.1", err: true},
+		{spec: "none:1.2.3.4", err: true},
+		{spec: "upnp:1.2.3.4", err: true},
+		{spec: "pmp:192.168.0.1", want: PMP(net.ParseIP("192.168.0.1"))},
+		{spec: "stun", err: true},
+	}
+	for _, test := range tests {
+		have, err := Parse(test.spec)
+		if (err != nil) != test.err {
+			t.Errorf("%q: error mismatch: have %v, want error %v", test.spec, err, test.err)
+			continue
+		}
+		if !test.err && fmt.Sprint(have) != fmt.Sprint(test.want) {
+			t.Errorf("%q: interface mismatch: have %v, want %v", test.spec, have, test.want)
+		}
+	}
+}
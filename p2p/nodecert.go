@@ -0,0 +1,157 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// nodeCertDomain separates the hashes signed for node certificates from any
+// other data signed with the same key.
+var nodeCertDomain = []byte("quorum node certificate")
+
+var (
+	errNodeCertMissing   = errors.New("no node certificate")
+	errNodeCertUntrusted = errors.New("node certificate not issued by a trusted CA")
+)
+
+// NodeCert is a certificate of a consortium CA admitting a node to the
+// network. Nodes present their certificate in the protocol handshake, peers
+// trusting the CA admit them without having to list their enode.
+//
+// CAs are secp256k1 keys like node keys, identified by their address.
+type NodeCert struct {
+	ID      discover.NodeID
+	Name    string // Member operating the node, informational only
+	Expires uint64 // Unix time the certificate expires at, 0 if it never does
+	Sig     []byte // Signature of the CA
+}
+
+type jsonNodeCert struct {
+	ID      discover.NodeID `json:"id"`
+	Name    string          `json:"name,omitempty"`
+	Expires uint64          `json:"expires,omitempty"`
+	Sig     string          `json:"signature"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *NodeCert) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonNodeCert{ID: c.ID, Name: c.Name, Expires: c.Expires, Sig: common.ToHex(c.Sig)})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *NodeCert) UnmarshalJSON(input []byte) error {
+	var dec jsonNodeCert
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	c.ID, c.Name, c.Expires, c.Sig = dec.ID, dec.Name, dec.Expires, common.FromHex(dec.Sig)
+	return nil
+}
+
+// LoadNodeCert reads a JSON encoded node certificate from a file.
+func LoadNodeCert(file string) (*NodeCert, error) {
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cert := new(NodeCert)
+	if err := json.Unmarshal(blob, cert); err != nil {
+		return nil, fmt.Errorf("invalid node certificate %s: %v", file, err)
+	}
+	return cert, nil
+}
+
+func (c *NodeCert) sigHash() []byte {
+	enc, _ := rlp.EncodeToBytes([]interface{}{c.ID, c.Name, c.Expires})
+	return crypto.Keccak256(nodeCertDomain, enc)
+}
+
+// Sign signs the certificate with the key of a CA.
+func (c *NodeCert) Sign(ca *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(c.sigHash(), ca)
+	if err != nil {
+		return err
+	}
+	c.Sig = sig
+	return nil
+}
+
+// Issuer returns the address of the CA which signed the certificate.
+func (c *NodeCert) Issuer() (common.Address, error) {
+	if len(c.Sig) != 65 {
+		return common.Address{}, errors.New("invalid node certificate signature")
+	}
+	pub, err := crypto.SigToPub(c.sigHash(), c.Sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify checks that the certificate was issued for the given node by one of
+// the trusted CAs and didn't expire.
+func (c *NodeCert) Verify(id discover.NodeID, roots []common.Address, now time.Time) error {
+	if c.ID != id {
+		return fmt.Errorf("node certificate issued for %x", c.ID[:8])
+	}
+	if c.Expires != 0 && uint64(now.Unix()) >= c.Expires {
+		return fmt.Errorf("node certificate expired at %v", time.Unix(int64(c.Expires), 0))
+	}
+	issuer, err := c.Issuer()
+	if err != nil {
+		return err
+	}
+	for _, root := range roots {
+		if issuer == root {
+			return nil
+		}
+	}
+	return errNodeCertUntrusted
+}
+
+// nodeCert decodes the certificate a peer presented in its protocol handshake.
+// It's sent as the first additional handshake field, which older nodes ignore.
+func (h *protoHandshake) nodeCert() (*NodeCert, error) {
+	if len(h.Rest) == 0 {
+		return nil, errNodeCertMissing
+	}
+	cert := new(NodeCert)
+	if err := rlp.DecodeBytes(h.Rest[0], cert); err != nil {
+		return nil, fmt.Errorf("invalid node certificate: %v", err)
+	}
+	return cert, nil
+}
+
+// checkNodeCert verifies the certificate of a peer against the trusted CAs.
+func (srv *Server) checkNodeCert(c *conn, phs *protoHandshake) error {
+	cert, err := phs.nodeCert()
+	if err != nil {
+		return err
+	}
+	return cert.Verify(c.id, srv.NodeCertRoots, time.Now())
+}
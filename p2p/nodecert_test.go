@@ -0,0 +1,149 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that node certificates only verify for the certified node, before
+// they expire and if issued by a trusted CA.
+func TestNodeCertVerify(t *testing.T) {
+	var (
+		ca, other = newkey(), newkey()
+		roots     = []common.Address{crypto.PubkeyToAddress(ca.PublicKey)}
+		id        = randomID()
+		now       = time.Now()
+	)
+	cert := &NodeCert{ID: id, Name: "acme", Expires: uint64(now.Add(time.Hour).Unix())}
+	if err := cert.Sign(ca); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if err := cert.Verify(id, roots, now); err != nil {
+		t.Fatalf("valid certificate rejected: %v", err)
+	}
+	// Certificates survive a JSON round trip
+	blob, err := json.Marshal(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(NodeCert)
+	if err := json.Unmarshal(blob, dec); err != nil {
+		t.Fatalf("failed to decode %s: %v", blob, err)
+	}
+	if err := dec.Verify(id, roots, now); err != nil {
+		t.Fatalf("decoded certificate rejected: %v", err)
+	}
+
+	if err := cert.Verify(randomID(), roots, now); err == nil {
+		t.Errorf("certificate accepted for another node")
+	}
+	if err := cert.Verify(id, roots, now.Add(2*time.Hour)); err == nil {
+		t.Errorf("expired certificate accepted")
+	}
+	if err := cert.Verify(id, []common.Address{crypto.PubkeyToAddress(other.PublicKey)}, now); err != errNodeCertUntrusted {
+		t.Errorf("untrusted certificate accepted: %v", err)
+	}
+	tampered := *cert
+	tampered.Name = "evil"
+	if err := tampered.Verify(id, roots, now); err == nil {
+		t.Errorf("tampered certificate accepted")
+	}
+	unsigned := &NodeCert{ID: id}
+	if err := unsigned.Verify(id, roots, now); err == nil {
+		t.Errorf("unsigned certificate accepted")
+	}
+}
+
+// Tests that peers are only admitted with a valid certificate once CA roots
+// are configured.
+func TestServerNodeCert(t *testing.T) {
+	ca := newkey()
+	roots := []common.Address{crypto.PubkeyToAddress(ca.PublicKey)}
+
+	signed := func(id discover.NodeID) []rlp.RawValue {
+		cert := &NodeCert{ID: id}
+		if err := cert.Sign(ca); err != nil {
+			t.Fatal(err)
+		}
+		enc, _ := rlp.EncodeToBytes(cert)
+		return []rlp.RawValue{enc}
+	}
+	id, other := randomID(), randomID()
+	tests := []struct {
+		rest         []rlp.RawValue
+		wantCloseErr error
+	}{
+		{nil, DiscRequested},                    // no certificate
+		{signed(other), DiscRequested},          // certificate of another node
+		{signed(id), DiscUselessPeer},           // admitted, but no matching protocols
+		{[]rlp.RawValue{{0xc0}}, DiscRequested}, // garbage
+	}
+	for i, test := range tests {
+		tt := &setupTransport{id: id, phs: &protoHandshake{ID: id, Rest: test.rest}}
+		srv := &Server{
+			Config: Config{
+				PrivateKey:    newkey(),
+				MaxPeers:      10,
+				NoDial:        true,
+				Protocols:     []Protocol{discard},
+				NodeCertRoots: roots,
+			},
+			newTransport: func(fd net.Conn) transport { return tt },
+		}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("couldn't start server: %v", err)
+		}
+		p1, _ := net.Pipe()
+		srv.setupConn(p1, inboundConn, nil)
+		if tt.closeErr != test.wantCloseErr {
+			t.Errorf("test %d: close error mismatch: have %v, want %v", i, tt.closeErr, test.wantCloseErr)
+		}
+		srv.Stop()
+	}
+}
+
+// Tests that a server only starts with a certificate of its own node, and
+// presents it in the handshake.
+func TestServerOwnNodeCert(t *testing.T) {
+	key := newkey()
+	srv := &Server{Config: Config{PrivateKey: key, MaxPeers: 10, NoDial: true, NodeCert: &NodeCert{ID: randomID()}}}
+	if err := srv.Start(); err == nil {
+		srv.Stop()
+		t.Fatalf("started with the certificate of another node")
+	}
+	cert := &NodeCert{ID: discover.PubkeyID(&key.PublicKey)}
+	cert.Sign(newkey())
+	srv = &Server{Config: Config{PrivateKey: key, MaxPeers: 10, NoDial: true, NodeCert: cert}}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer srv.Stop()
+
+	have, err := srv.ourHandshake.nodeCert()
+	if err != nil || have.ID != cert.ID || string(have.Sig) != string(cert.Sig) {
+		t.Errorf("certificate mismatch in handshake: %v, %v", have, err)
+	}
+}
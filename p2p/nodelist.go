@@ -0,0 +1,210 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// NodeListVersion is the latest schema version of the node lists in
+// static-nodes.json, trusted-nodes.json and permissioned-nodes.json.
+const NodeListVersion = 1
+
+// NodeListRules are the checks applied to the entries of a node list, beyond
+// being valid enode URLs listed once.
+type NodeListRules struct {
+	Dialed   bool // Entries with an address need a dialable one, as for static nodes
+	Complete bool // Entries need a dialable address, as for the initial raft cluster
+	RaftPort bool // Entries need a raftport, as for the initial raft cluster
+}
+
+// NodeListIssue is a problem with an entry of a node list.
+type NodeListIssue struct {
+	Index   int    // Position of the entry in the list
+	URL     string // Entry as written in the file
+	Warning bool   // Whether the entry is used regardless
+	Message string
+}
+
+func (i NodeListIssue) String() string {
+	kind := "error"
+	if i.Warning {
+		kind = "warning"
+	}
+	url := i.URL
+	if len(url) > 40 {
+		url = url[:37] + "..."
+	}
+	return fmt.Sprintf("entry %d (%q): %s: %s", i.Index, url, kind, i.Message)
+}
+
+// NodeList is a node list file checked by LoadNodeList. The file is either a
+// JSON array of enode URLs, which is version 0, or a versioned object:
+//
+//	{"version": 1, "nodes": ["enode://...", ...]}
+type NodeList struct {
+	Path    string
+	Version int
+	Nodes   []*discover.Node // Entries without errors, in the order listed
+	Issues  []NodeListIssue
+}
+
+type jsonNodeList struct {
+	Version *int     `json:"version"`
+	Nodes   []string `json:"nodes"`
+}
+
+// Errors returns the number of issues excluding their entries from the list.
+func (l *NodeList) Errors() int {
+	n := 0
+	for _, issue := range l.Issues {
+		if !issue.Warning {
+			n++
+		}
+	}
+	return n
+}
+
+// LoadNodeList reads a node list and checks its entries. An error is returned
+// if the file can't be read or decoded, or if it's of a later version than
+// NodeListVersion. Entries failing the checks are left out of the nodes and
+// reported as issues.
+func LoadNodeList(path string, rules NodeListRules) (*NodeList, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	list := &NodeList{Path: path}
+	urls, err := decodeNodeList(blob, list)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	list.Nodes, list.Issues = checkNodeList(urls, rules)
+	return list, nil
+}
+
+func decodeNodeList(blob []byte, list *NodeList) ([]string, error) {
+	var urls []string
+	if trimmed := bytes.TrimSpace(blob); len(trimmed) > 0 && trimmed[0] == '{' {
+		var dec jsonNodeList
+		if err := json.Unmarshal(blob, &dec); err != nil {
+			return nil, jsonError(blob, err)
+		}
+		if dec.Version == nil {
+			return nil, fmt.Errorf("missing version, want 1 to %d", NodeListVersion)
+		}
+		if *dec.Version < 1 || *dec.Version > NodeListVersion {
+			return nil, fmt.Errorf("unsupported version %d, want 1 to %d", *dec.Version, NodeListVersion)
+		}
+		list.Version, urls = *dec.Version, dec.Nodes
+	} else if err := json.Unmarshal(blob, &urls); err != nil {
+		return nil, jsonError(blob, err)
+	}
+	return urls, nil
+}
+
+// jsonError adds the line and column to JSON decoding errors. The offsets of
+// the errors are past the offending character or value, so the position of
+// its last character is reported.
+func jsonError(blob []byte, err error) error {
+	var offset int64
+	switch err := err.(type) {
+	case *json.SyntaxError:
+		offset = err.Offset
+	case *json.UnmarshalTypeError:
+		offset = err.Offset
+	default:
+		return err
+	}
+	if offset > 0 {
+		offset--
+	}
+	line, col := 1, 1
+	for _, b := range blob[:offset] {
+		if b == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("line %d, column %d: %v", line, col, err)
+}
+
+// checkNodeList parses the entries of a node list and checks them against
+// the rules and each other.
+func checkNodeList(urls []string, rules NodeListRules) ([]*discover.Node, []NodeListIssue) {
+	var (
+		nodes  []*discover.Node
+		issues []NodeListIssue
+		ids    = make(map[discover.NodeID]int)
+		addrs  = make(map[string]int)
+	)
+	for i, url := range urls {
+		fail := func(warning bool, format string, args ...interface{}) {
+			issues = append(issues, NodeListIssue{Index: i, URL: url, Warning: warning, Message: fmt.Sprintf(format, args...)})
+		}
+		if url == "" {
+			fail(true, "empty entry, ignored")
+			continue
+		}
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			fail(false, "invalid enode URL: %v", err)
+			continue
+		}
+		if prev, ok := ids[node.ID]; ok {
+			fail(false, "duplicate node ID of entry %d", prev)
+			continue
+		}
+		if rules.Complete && node.Incomplete() {
+			fail(false, "missing IP address and port")
+			continue
+		}
+		if (rules.Dialed || rules.Complete) && !node.Incomplete() {
+			if node.IP.IsUnspecified() {
+				fail(false, "unspecified IP address %v can't be dialed", node.IP)
+				continue
+			}
+			if node.TCP == 0 {
+				fail(false, "missing TCP port")
+				continue
+			}
+		}
+		if rules.RaftPort && !node.HasRaftPort() {
+			fail(false, "missing raftport query parameter, e.g. ?raftport=50400")
+			continue
+		}
+		ids[node.ID] = i
+		if !node.Incomplete() {
+			addr := net.JoinHostPort(node.IP.String(), strconv.Itoa(int(node.TCP)))
+			if prev, ok := addrs[addr]; ok {
+				fail(true, "same address %s as entry %d", addr, prev)
+			} else {
+				addrs[addr] = i
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, issues
+}
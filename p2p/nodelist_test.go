@@ -0,0 +1,115 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testNodeA = "enode://6598638ac5b15ee386210156a43f565fa8c48592489d3e66ac774eac759db9eb52866898cf0c5e597a1595d9e60e1a19c84f77df489324e2f3a967207c047470"
+	testNodeB = "enode://7598638ac5b15ee386210156a43f565fa8c48592489d3e66ac774eac759db9eb52866898cf0c5e597a1595d9e60e1a19c84f77df489324e2f3a967207c047470"
+	testNodeC = "enode://8598638ac5b15ee386210156a43f565fa8c48592489d3e66ac774eac759db9eb52866898cf0c5e597a1595d9e60e1a19c84f77df489324e2f3a967207c047470"
+)
+
+func TestLoadNodeList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodelist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		file    string
+		rules   NodeListRules
+		version int
+		nodes   int
+		issues  []string // Prefixes of the issues
+		err     string
+	}{
+		{
+			file:  `["` + testNodeA + `@127.0.0.1:30303", "` + testNodeB + `"]`,
+			rules: NodeListRules{Dialed: true},
+			nodes: 2,
+		},
+		{
+			file:    `{"version": 1, "nodes": ["` + testNodeA + `@127.0.0.1:30303"]}`,
+			version: 1,
+			nodes:   1,
+		},
+		{
+			file:  `["", "enode://zz@127.0.0.1:30303", "` + testNodeA + `@0.0.0.0:30303"]`,
+			rules: NodeListRules{Dialed: true},
+			issues: []string{
+				`entry 0 (""): warning: empty entry`,
+				`entry 1 ("enode://zz@127.0.0.1:30303"): error: invalid enode URL`,
+				`entry 2 ("enode://6598638ac5b15ee386210156a43f5..."): error: unspecified IP address`,
+			},
+		},
+		{
+			file:  `["` + testNodeA + `@127.0.0.1:30303?raftport=50400", "` + testNodeA + `@127.0.0.1:30304?raftport=50401", "` + testNodeB + `@127.0.0.1:30303?raftport=50402", "` + testNodeC + `", "` + testNodeC + `@127.0.0.1:30305"]`,
+			rules: NodeListRules{Complete: true, RaftPort: true},
+			nodes: 2,
+			issues: []string{
+				`entry 1 ("enode://6598638ac5b15ee386210156a43f5..."): error: duplicate node ID of entry 0`,
+				`entry 2 ("enode://7598638ac5b15ee386210156a43f5..."): warning: same address 127.0.0.1:30303 as entry 0`,
+				`entry 3 ("enode://8598638ac5b15ee386210156a43f5..."): error: missing IP address and port`,
+				`entry 4 ("enode://8598638ac5b15ee386210156a43f5..."): error: missing raftport`,
+			},
+		},
+		{file: `{"version": 2, "nodes": []}`, err: "unsupported version 2"},
+		{file: `{"nodes": []}`, err: "missing version"},
+		{file: "[\n  \"enode://a\",\n  1\n]", err: "line 3, column 3"},
+		{file: "[\n  \"enode://a\"\n  \"enode://b\"\n]", err: "line 3, column 3"},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, "nodes.json")
+		if err := ioutil.WriteFile(path, []byte(test.file), 0600); err != nil {
+			t.Fatal(err)
+		}
+		list, err := LoadNodeList(path, test.rules)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("test %d: error mismatch: got %v, want %q", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if list.Version != test.version || len(list.Nodes) != test.nodes {
+			t.Errorf("test %d: got version %d and %d nodes, want version %d and %d nodes", i, list.Version, len(list.Nodes), test.version, test.nodes)
+		}
+		var issues []string
+		for j, issue := range list.Issues {
+			if j < len(test.issues) && strings.HasPrefix(issue.String(), test.issues[j]) {
+				issues = append(issues, test.issues[j])
+			} else {
+				issues = append(issues, issue.String())
+			}
+		}
+		if !reflect.DeepEqual(issues, test.issues) {
+			t.Errorf("test %d: issues mismatch:\ngot  %q\nwant %q", i, issues, test.issues)
+		}
+	}
+}
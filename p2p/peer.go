@@ -373,8 +373,12 @@ type PeerInfo struct {
 	Network struct {
 		LocalAddress  string `json:"localAddress"`  // Local endpoint of the TCP data connection
 		RemoteAddress string `json:"remoteAddress"` // Remote endpoint of the TCP data connection
+		Inbound       bool   `json:"inbound"`       // Whether the peer dialed us
+		Trusted       bool   `json:"trusted"`       // Whether the peer is a trusted node
+		Static        bool   `json:"static"`        // Whether we dialed the peer as a static node
 	} `json:"network"`
 	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Traffic   *PeerTraffic           `json:"traffic,omitempty"`
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -393,6 +397,12 @@ func (p *Peer) Info() *PeerInfo {
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
+	info.Network.Inbound = p.rw.is(inboundConn)
+	info.Network.Trusted = p.rw.is(trustedConn)
+	info.Network.Static = p.rw.is(staticDialedConn)
+	if p.rw.bandwidth != nil {
+		info.Traffic = p.rw.bandwidth.traffic()
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
@@ -0,0 +1,235 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// peerRulesFile is the file in the data directory peer rules are persisted to.
+const peerRulesFile = "peer-rules.json"
+
+// Actions of peer rules.
+const (
+	PeerDeny  = "deny"  // refuse connections to and from the node
+	PeerAllow = "allow" // admit the node even if it isn't permissioned
+)
+
+// PeerRule temporarily overrides whether a node may connect, e.g. to shut out
+// a misbehaving node during an incident without editing the permissioned
+// nodes of every member.
+type PeerRule struct {
+	ID      discover.NodeID `json:"id"`
+	Action  string          `json:"action"`
+	Reason  string          `json:"reason,omitempty"`
+	Added   time.Time       `json:"added"`
+	Expires *time.Time      `json:"expires,omitempty"` // nil if the rule never expires
+}
+
+func (r *PeerRule) expired(now time.Time) bool {
+	return r.Expires != nil && !now.Before(*r.Expires)
+}
+
+// peerRules is the set of peer rules of a server, persisted to disk so they
+// survive restarts. Expired rules are dropped lazily.
+type peerRules struct {
+	path  string // empty for in memory rules
+	lock  sync.Mutex
+	rules map[discover.NodeID]*PeerRule
+}
+
+// loadPeerRules loads the peer rules persisted in the data directory. Rules
+// are kept in memory only without a data directory.
+func loadPeerRules(datadir string) *peerRules {
+	r := &peerRules{rules: make(map[discover.NodeID]*PeerRule)}
+	if datadir == "" {
+		return r
+	}
+	r.path = filepath.Join(datadir, peerRulesFile)
+
+	blob, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(logger.Error).Infof("Failed to read peer rules: %v", err)
+		}
+		return r
+	}
+	var rules []*PeerRule
+	if err := json.Unmarshal(blob, &rules); err != nil {
+		glog.V(logger.Error).Infof("Failed to parse peer rules %s: %v", r.path, err)
+		return r
+	}
+	now := time.Now()
+	for _, rule := range rules {
+		if !rule.expired(now) {
+			r.rules[rule.ID] = rule
+		}
+	}
+	return r
+}
+
+// lookup returns the rule of the given node, or nil if there is none.
+func (r *peerRules) lookup(id discover.NodeID) *PeerRule {
+	if r == nil {
+		return nil // server not started
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rule := r.rules[id]
+	if rule == nil {
+		return nil
+	}
+	if rule.expired(time.Now()) {
+		glog.V(logger.Info).Infof("Peer rule for %x expired", id[:8])
+		delete(r.rules, id)
+		r.save()
+		return nil
+	}
+	return rule
+}
+
+func (r *peerRules) denied(id discover.NodeID) bool {
+	rule := r.lookup(id)
+	return rule != nil && rule.Action == PeerDeny
+}
+
+func (r *peerRules) allowed(id discover.NodeID) bool {
+	rule := r.lookup(id)
+	return rule != nil && rule.Action == PeerAllow
+}
+
+// set adds or replaces the rule of a node.
+func (r *peerRules) set(rule *PeerRule) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.rules[rule.ID] = rule
+	return r.save()
+}
+
+// remove drops the rule of a node, reporting whether there was one.
+func (r *peerRules) remove(id discover.NodeID) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if _, ok := r.rules[id]; !ok {
+		return false, nil
+	}
+	delete(r.rules, id)
+	return true, r.save()
+}
+
+// list returns the rules in effect, oldest first.
+func (r *peerRules) list() []*PeerRule {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	rules := make([]*PeerRule, 0, len(r.rules))
+	for id, rule := range r.rules {
+		if rule.expired(now) {
+			delete(r.rules, id)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	sort.Sort(peerRulesByAdded(rules))
+	return rules
+}
+
+// save atomically writes the rules to disk. The lock must be held.
+func (r *peerRules) save() error {
+	if r.path == "" {
+		return nil
+	}
+	rules := make([]*PeerRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Sort(peerRulesByAdded(rules))
+
+	blob, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, blob, 0600); err != nil {
+		return fmt.Errorf("failed to save peer rules: %v", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to save peer rules: %v", err)
+	}
+	return nil
+}
+
+type peerRulesByAdded []*PeerRule
+
+func (s peerRulesByAdded) Len() int           { return len(s) }
+func (s peerRulesByAdded) Less(i, j int) bool { return s[i].Added.Before(s[j].Added) }
+func (s peerRulesByAdded) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// SetPeerRule denies a node to connect, disconnecting it if it's connected, or
+// allows it to connect even if it isn't permissioned. A positive ttl limits
+// how long the rule applies. Rules replace earlier rules of the same node and
+// are persisted in the data directory.
+func (srv *Server) SetPeerRule(id discover.NodeID, action string, ttl time.Duration, reason string) error {
+	if action != PeerDeny && action != PeerAllow {
+		return fmt.Errorf("invalid peer rule action %q, want %s or %s", action, PeerDeny, PeerAllow)
+	}
+	rule := &PeerRule{ID: id, Action: action, Reason: reason, Added: time.Now()}
+	if ttl > 0 {
+		expires := rule.Added.Add(ttl)
+		rule.Expires = &expires
+	}
+	if err := srv.peerRules.set(rule); err != nil {
+		return err
+	}
+	if action == PeerDeny {
+		select {
+		case srv.peerOp <- func(peers map[discover.NodeID]*Peer) {
+			if p := peers[id]; p != nil {
+				p.Disconnect(DiscRequested)
+			}
+		}:
+			<-srv.peerOpDone
+		case <-srv.quit:
+		}
+	}
+	return nil
+}
+
+// RemovePeerRule removes the rule of a node, reporting whether there was one.
+func (srv *Server) RemovePeerRule(id discover.NodeID) (bool, error) {
+	return srv.peerRules.remove(id)
+}
+
+// PeerRules returns the peer rules in effect.
+func (srv *Server) PeerRules() []*PeerRule {
+	return srv.peerRules.list()
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// Tests that peer rules are persisted and expire.
+func TestPeerRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peerrules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		denied, allowed, expiring = randomID(), randomID(), randomID()
+		now                       = time.Now()
+		soon                      = now.Add(100 * time.Millisecond)
+	)
+	rules := loadPeerRules(dir)
+	rules.set(&PeerRule{ID: denied, Action: PeerDeny, Reason: "spam", Added: now})
+	rules.set(&PeerRule{ID: allowed, Action: PeerAllow, Added: now.Add(time.Second)})
+	rules.set(&PeerRule{ID: expiring, Action: PeerDeny, Added: now.Add(2 * time.Second), Expires: &soon})
+
+	// Rules survive a restart
+	rules = loadPeerRules(dir)
+	if list := rules.list(); len(list) != 3 || list[0].ID != denied || list[0].Reason != "spam" || list[2].ID != expiring {
+		t.Fatalf("rules mismatch after reload: %v", list)
+	}
+	if !rules.denied(denied) || rules.allowed(denied) {
+		t.Errorf("denied node not denied")
+	}
+	if !rules.allowed(allowed) || rules.denied(allowed) {
+		t.Errorf("allowed node not allowed")
+	}
+	if !rules.denied(expiring) {
+		t.Errorf("node denied until %v not denied", soon)
+	}
+	if rules.denied(randomID()) || rules.allowed(randomID()) {
+		t.Errorf("rule found for unknown node")
+	}
+
+	time.Sleep(soon.Sub(time.Now()))
+	if rules.denied(expiring) {
+		t.Errorf("expired rule still applies")
+	}
+	if ok, err := rules.remove(allowed); !ok || err != nil {
+		t.Fatalf("failed to remove rule: %v %v", ok, err)
+	}
+	if ok, _ := rules.remove(allowed); ok {
+		t.Errorf("removed rule twice")
+	}
+	if list := loadPeerRules(dir).list(); len(list) != 1 || list[0].ID != denied {
+		t.Errorf("rules mismatch after removal: %v", list)
+	}
+}
+
+// Tests that denied nodes can't connect.
+func TestServerDeniedPeer(t *testing.T) {
+	id := randomID()
+	tt := &setupTransport{id: id, phs: &protoHandshake{ID: id}}
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			MaxPeers:   10,
+			NoDial:     true,
+			Protocols:  []Protocol{discard},
+		},
+		newTransport: func(fd net.Conn) transport { return tt },
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("couldn't start server: %v", err)
+	}
+	defer srv.Stop()
+
+	if err := srv.SetPeerRule(id, PeerDeny, time.Hour, ""); err != nil {
+		t.Fatalf("failed to deny peer: %v", err)
+	}
+	p1, _ := net.Pipe()
+	srv.setupConn(p1, inboundConn, nil)
+	if tt.closeErr != DiscRequested || tt.calls != "doEncHandshake,close," {
+		t.Errorf("denied peer not rejected: close error %v, calls %q", tt.closeErr, tt.calls)
+	}
+	if err := srv.SetPeerRule(id, "block", 0, ""); err == nil {
+		t.Errorf("invalid action accepted")
+	}
+	if rules := srv.PeerRules(); len(rules) != 1 || rules[0].Expires == nil {
+		t.Errorf("rules mismatch: %v", rules)
+	}
+}
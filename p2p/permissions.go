@@ -2,6 +2,8 @@ package p2p
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -49,32 +51,57 @@ func parsePermissionedNodes(DataDir string) []*discover.Node {
 		glog.V(logger.Error).Infof("Read Error for permissioned-nodes.json file %v. This is because 'permissioned' flag is specified but no permissioned-nodes.json file is present.", err)
 		return nil
 	}
-	// Load the nodes from the config file
-	blob, err := ioutil.ReadFile(path)
+	list, err := LoadNodeList(path, NodeListRules{})
 	if err != nil {
-		glog.V(logger.Error).Infof("parsePermissionedNodes: Failed to access nodes: %v", err)
+		glog.V(logger.Error).Infof("parsePermissionedNodes: Failed to load nodes: %v", err)
 		return nil
 	}
+	for _, issue := range list.Issues {
+		glog.V(logger.Error).Infof("parsePermissionedNodes: %v", issue)
+	}
+	return list.Nodes
+}
 
-	nodelist := []string{}
-	if err := json.Unmarshal(blob, &nodelist); err != nil {
-		glog.V(logger.Error).Infof("parsePermissionedNodes: Failed to load nodes: %v", err)
+// SetPermissionedNodes replaces the permissioned nodes, persisting them to
+// permissioned-nodes.json in the data directory, which is read again for every
+// connection. If node permissioning is enabled, connected peers which are no
+// longer permissioned are disconnected unless a peer rule allows them. Peers
+// certified by a trusted CA are admitted again when they reconnect.
+func (srv *Server) SetPermissionedNodes(nodes []*discover.Node) error {
+	if srv.DataDir == "" {
+		return errors.New("no data directory to store the permissioned nodes in")
+	}
+	urls := make([]string, len(nodes))
+	permissioned := make(map[discover.NodeID]bool)
+	for i, node := range nodes {
+		urls[i] = node.String()
+		permissioned[node.ID] = true
+	}
+	blob, err := json.MarshalIndent(urls, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(srv.DataDir, PERMISSIONED_CONFIG)
+	if err := ioutil.WriteFile(path+".tmp", blob, 0644); err != nil {
+		return fmt.Errorf("failed to save permissioned nodes: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to save permissioned nodes: %v", err)
+	}
+	if !srv.EnableNodePermission {
 		return nil
 	}
-	// Interpret the list as a discovery node array
-	var nodes []*discover.Node
-	for _, url := range nodelist {
-		if url == "" {
-			glog.V(logger.Error).Infof("parsePermissionedNodes: Node URL blank")
-			continue
-		}
-		node, err := discover.ParseNode(url)
-		if err != nil {
-			glog.V(logger.Error).Infof("parsePermissionedNodes: Node URL %s: %v\n", url, err)
-			continue
+	select {
+	case srv.peerOp <- func(peers map[discover.NodeID]*Peer) {
+		for id, p := range peers {
+			if !permissioned[id] && !srv.peerRules.allowed(id) {
+				glog.V(logger.Info).Infof("Disconnecting %v, no longer permissioned", p)
+				p.Disconnect(DiscRequested)
+			}
 		}
-		nodes = append(nodes, node)
+	}:
+		<-srv.peerOpDone
+	case <-srv.quit:
 	}
-	return nodes
+	return nil
 }
-
@@ -22,13 +22,16 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/nat"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const (
@@ -105,6 +108,13 @@ type Config struct {
 	// the server is started.
 	ListenAddr string
 
+	// AdvertiseAddr is the address other nodes are told to dial, as host or
+	// host:port, if it differs from the listening address, e.g. behind a load
+	// balancer. It replaces the IP and TCP port of the enode URL of the node,
+	// including an external IP found through NAT. Hosts are resolved once on
+	// startup. If the port is omitted, the listening port is advertised.
+	AdvertiseAddr string
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -120,8 +130,21 @@ type Config struct {
 	//Enables Permissioning
 	EnableNodePermission bool
 
+	// NodeCert is the certificate of this node presented to peers in the
+	// protocol handshake.
+	NodeCert *NodeCert
+
+	// NodeCertRoots are the addresses of the CAs whose node certificates admit
+	// peers. Without permissioning every peer needs a valid certificate, with
+	// it only the ones not in the permissioned nodes.
+	NodeCertRoots []common.Address
+
 	//DataDir
 	DataDir string
+
+	// MaxPeerBandwidth limits the bytes per second exchanged with each peer,
+	// unless SetPeerBandwidth sets limits for it.
+	MaxPeerBandwidth Bandwidth
 }
 
 // Server manages all peer connections.
@@ -139,10 +162,15 @@ type Server struct {
 
 	ntab         discoverTable
 	listener     net.Listener
+	advertise    *net.TCPAddr // Resolved AdvertiseAddr, nil if not set
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
+	peerRules    *peerRules
+
+	bandwidthLock sync.Mutex
+	bandwidths    map[discover.NodeID]Bandwidth // Limits set by SetPeerBandwidth
 
-	// These are for Peers, PeerCount (and nothing else).
+	// These are for Peers, PeerCount and SetPeerRule (and nothing else).
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
 
@@ -176,6 +204,8 @@ type conn struct {
 	id    discover.NodeID // valid after the encryption handshake
 	caps  []Cap           // valid after the protocol handshake
 	name  string          // valid after the protocol handshake
+
+	bandwidth *bandwidthConn // Traffic accounting, nil for test connections
 }
 
 type transport interface {
@@ -289,14 +319,49 @@ func (srv *Server) Self() *discover.Node {
 		}
 		// Otherwise inject the listener address too
 		addr := srv.listener.Addr().(*net.TCPAddr)
-		return &discover.Node{
+		return srv.advertised(&discover.Node{
 			ID:  discover.PubkeyID(&srv.PrivateKey.PublicKey),
 			IP:  addr.IP,
 			TCP: uint16(addr.Port),
-		}
+		})
 	}
 	// Otherwise return the live node infos
-	return srv.ntab.Self()
+	return srv.advertised(srv.ntab.Self())
+}
+
+// advertised replaces the address of the local node with the advertised one,
+// if configured.
+func (srv *Server) advertised(node *discover.Node) *discover.Node {
+	if srv.advertise == nil {
+		return node
+	}
+	port := uint16(srv.advertise.Port)
+	if port == 0 {
+		port = node.TCP
+	}
+	return discover.NewNode(node.ID, srv.advertise.IP, node.UDP, port)
+}
+
+// resolveAdvertiseAddr resolves an advertised host or host:port to an IP
+// address, preferring IPv4 as raft only supports it.
+func resolveAdvertiseAddr(addr string) (*net.TCPAddr, error) {
+	host, port := addr, 0
+	if h, p, err := net.SplitHostPort(addr); err == nil {
+		if port, err = strconv.Atoi(p); err != nil || port < 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid advertised port %q", p)
+		}
+		host = h
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.TCPAddr{IP: ip4, Port: port}, nil
+		}
+	}
+	return &net.TCPAddr{IP: ips[0], Port: port}, nil
 }
 
 // Stop terminates the server and all active peer connections.
@@ -345,6 +410,12 @@ func (srv *Server) Start() (err error) {
 	srv.removestatic = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
+	srv.peerRules = loadPeerRules(srv.DataDir)
+	if srv.AdvertiseAddr != "" {
+		if srv.advertise, err = resolveAdvertiseAddr(srv.AdvertiseAddr); err != nil {
+			return fmt.Errorf("invalid advertised address %q: %v", srv.AdvertiseAddr, err)
+		}
+	}
 
 	// node table
 	if srv.Discovery {
@@ -369,6 +440,16 @@ func (srv *Server) Start() (err error) {
 	for _, p := range srv.Protocols {
 		srv.ourHandshake.Caps = append(srv.ourHandshake.Caps, p.cap())
 	}
+	if srv.NodeCert != nil {
+		if srv.NodeCert.ID != srv.ourHandshake.ID {
+			return fmt.Errorf("node certificate issued for %x, not for this node", srv.NodeCert.ID[:8])
+		}
+		enc, err := rlp.EncodeToBytes(srv.NodeCert)
+		if err != nil {
+			return err
+		}
+		srv.ourHandshake.Rest = []rlp.RawValue{enc}
+	}
 	// listen/dial
 	if srv.ListenAddr != "" {
 		if err := srv.startListening(); err != nil {
@@ -632,7 +713,8 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 	srv.lock.Lock()
 	running := srv.running
 	srv.lock.Unlock()
-	c := &conn{fd: fd, transport: srv.newTransport(fd), flags: flags, cont: make(chan error)}
+	bw := newBandwidthConn(fd, srv.MaxPeerBandwidth)
+	c := &conn{fd: bw, transport: srv.newTransport(bw), bandwidth: bw, flags: flags, cont: make(chan error)}
 	if !running {
 		c.close(errServerStopped)
 		return
@@ -644,7 +726,14 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 		c.close(err)
 		return
 	}
+	if srv.peerRules.denied(c.id) {
+		glog.V(logger.Debug).Infof("%v denied by peer rule", c)
+		c.close(DiscRequested)
+		return
+	}
+	bw.setLimits(srv.peerBandwidth(c.id))
 	//START - QUORUM Permissioning
+	needCert := false
 	currentNode := srv.NodeInfo().ID
 	cnodeName := srv.NodeInfo().Name
 	glog.V(logger.Debug).Infof("EnableNodePermission <%v>, DataDir <%v>, Current Node ID <%v>, Node Name <%v>, Dialed Dest<%v>, Connection ID <%v>, Connection String <%v> ", srv.EnableNodePermission, srv.DataDir, currentNode, cnodeName, dialDest, c.id, c.id.String())
@@ -659,11 +748,16 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 			glog.V(logger.Debug).Infof("Connection Direction <%v>", direction)
 		}
 
-		if !isNodePermissioned(node, currentNode, srv.DataDir, direction) {
-			return
+		if !srv.peerRules.allowed(c.id) && !isNodePermissioned(node, currentNode, srv.DataDir, direction) {
+			if len(srv.NodeCertRoots) == 0 {
+				return
+			}
+			// Admit the node if it's certified by a trusted CA instead
+			needCert = true
 		}
 	} else {
 		glog.V(logger.Debug).Infof("Node Permissioning is Disabled. ")
+		needCert = len(srv.NodeCertRoots) > 0 && !srv.peerRules.allowed(c.id)
 	}
 
 	//END - QUORUM Permissioning
@@ -691,6 +785,13 @@ func (srv *Server) setupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 		c.close(DiscUnexpectedIdentity)
 		return
 	}
+	if needCert {
+		if err := srv.checkNodeCert(c, phs); err != nil {
+			glog.V(logger.Debug).Infof("%v not admitted: %v", c, err)
+			c.close(DiscRequested)
+			return
+		}
+	}
 	c.caps, c.name = phs.Caps, phs.Name
 	if err := srv.checkpoint(c, srv.addpeer); err != nil {
 		glog.V(logger.Debug).Infof("%v failed checkpoint addpeer: %v", c, err)
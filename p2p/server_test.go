@@ -84,6 +84,45 @@ func startTestServer(t *testing.T, id discover.NodeID, pf func(*Peer)) *Server {
 	return server
 }
 
+// Tests that the advertised address replaces the listening address in the
+// enode URL of the server.
+func TestServerAdvertiseAddr(t *testing.T) {
+	tests := []struct {
+		advertise string
+		ip        string
+		port      int // -1 = listening port
+	}{
+		{"10.1.2.3:30400", "10.1.2.3", 30400},
+		{"10.1.2.3", "10.1.2.3", -1},
+		{"localhost:30401", "127.0.0.1", 30401},
+	}
+	for i, test := range tests {
+		srv := &Server{Config: Config{
+			Name:          "test",
+			MaxPeers:      10,
+			ListenAddr:    "127.0.0.1:0",
+			AdvertiseAddr: test.advertise,
+			PrivateKey:    newkey(),
+		}}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("test %d: could not start server: %v", i, err)
+		}
+		port := test.port
+		if port < 0 {
+			port = srv.listener.Addr().(*net.TCPAddr).Port
+		}
+		if self := srv.Self(); self.IP.String() != test.ip || int(self.TCP) != port {
+			t.Errorf("test %d: advertised %v:%d, want %s:%d", i, self.IP, self.TCP, test.ip, port)
+		}
+		srv.Stop()
+	}
+	srv := &Server{Config: Config{ListenAddr: "127.0.0.1:0", AdvertiseAddr: "10.1.2.3:70000", PrivateKey: newkey()}}
+	if err := srv.Start(); err == nil {
+		srv.Stop()
+		t.Error("invalid advertised port accepted")
+	}
+}
+
 func TestServerListen(t *testing.T) {
 	// start the test server
 	connected := make(chan *Peer)
@@ -26,6 +26,12 @@ type GasTable struct {
 	Calls       *big.Int
 	Suicide     *big.Int
 
+	SstoreSet    *big.Int // Storing a non-zero value in an empty slot
+	SstoreReset  *big.Int // Changing a slot without emptying or filling it
+	SstoreClear  *big.Int // Emptying a slot
+	SstoreRefund *big.Int // Refund for emptying a slot
+	CreateData   *big.Int // Per byte of code stored by a contract creation
+
 	// CreateBySuicide occurs when the
 	// refunded account is one that does
 	// not exist. This logic is similar
@@ -45,6 +51,12 @@ var (
 		Calls:       big.NewInt(40),
 		Suicide:     big.NewInt(0),
 
+		SstoreSet:    SstoreSetGas,
+		SstoreReset:  SstoreResetGas,
+		SstoreClear:  SstoreClearGas,
+		SstoreRefund: SstoreRefundGas,
+		CreateData:   CreateDataGas,
+
 		// explicitly set to nil to indicate
 		// this rule does not apply to homestead.
 		CreateBySuicide: nil,
@@ -60,6 +72,12 @@ var (
 		Calls:       big.NewInt(700),
 		Suicide:     big.NewInt(5000),
 
+		SstoreSet:    SstoreSetGas,
+		SstoreReset:  SstoreResetGas,
+		SstoreClear:  SstoreClearGas,
+		SstoreRefund: SstoreRefundGas,
+		CreateData:   CreateDataGas,
+
 		CreateBySuicide: big.NewInt(25000),
 	}
 )
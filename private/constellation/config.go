@@ -1,6 +1,8 @@
 package constellation
 
 import (
+	"fmt"
+
 	"github.com/BurntSushi/toml"
 )
 
@@ -8,6 +10,10 @@ type Config struct {
 	Socket         string   `toml:"socket"`
 	PublicKeys     []string `toml:"publickeys"`
 
+	// Keys of publickeys rotated out. Payloads are no longer addressed to them,
+	// they are only tried after the other keys to decrypt older payloads.
+	RetiredPublicKeys []string `toml:"retiredpublickeys"`
+
 	// Deprecated
 	SocketPath     string   `toml:"socketPath"`
 	PublicKeyPath  string   `toml:"publicKeyPath"`
@@ -25,5 +31,34 @@ func LoadConfig(configPath string) (*Config, error) {
 	if len(cfg.PublicKeys) == 0 {
 		cfg.PublicKeys = append(cfg.PublicKeys, cfg.PublicKeyPath)
 	}
+	if len(cfg.ActivePublicKeys()) == 0 {
+		return nil, fmt.Errorf("no public key which isn't retired in %s", configPath)
+	}
+	for _, retired := range cfg.RetiredPublicKeys {
+		if !containsKey(cfg.PublicKeys, retired) {
+			return nil, fmt.Errorf("retired public key %s isn't one of the publickeys", retired)
+		}
+	}
 	return cfg, nil
 }
+
+// ActivePublicKeys returns the paths of the public keys which aren't retired,
+// in the configured order. The first is the default sender key.
+func (cfg *Config) ActivePublicKeys() []string {
+	var active []string
+	for _, path := range cfg.PublicKeys {
+		if !containsKey(cfg.RetiredPublicKeys, path) {
+			active = append(active, path)
+		}
+	}
+	return active
+}
+
+func containsKey(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
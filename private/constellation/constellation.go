@@ -3,12 +3,16 @@ package constellation
 import (
 	"fmt"
 	"github.com/patrickmn/go-cache"
+	"io/ioutil"
+	"strings"
 	"time"
 )
 
 type Constellation struct {
-	node *Client
-	c    *cache.Cache
+	node       *Client
+	c          *cache.Cache
+	publicKeys []string // Base64 public keys of the node, in the order payloads are decrypted
+	retired    int      // Number of retired keys at the end of publicKeys
 }
 
 func (g *Constellation) Send(data []byte, from string, to []string) (out []byte, err error) {
@@ -33,11 +37,49 @@ func (g *Constellation) Receive(data []byte) ([]byte, error) {
 	if found {
 		return x.([]byte), nil
 	}
-	pl, _ := g.node.ReceivePayload(data)
+	var pl []byte
+	for _, key := range g.publicKeys {
+		if pl, _ = g.node.ReceivePayloadFor(data, key); len(pl) > 0 {
+			break
+		}
+	}
 	g.c.Set(dataStr, pl, cache.DefaultExpiration)
 	return pl, nil
 }
 
+// PublicKeys returns the public keys payloads are addressed to, the first being
+// the default sender key, and the retired keys only used to decrypt older
+// payloads.
+func (g *Constellation) PublicKeys() (active []string, retired []string) {
+	n := len(g.publicKeys) - g.retired
+	return append([]string{}, g.publicKeys[:n]...), append([]string{}, g.publicKeys[n:]...)
+}
+
+// Parties returns the public keys of the node which are recipients of the
+// payload stored under the given digest.
+func (g *Constellation) Parties(data []byte) ([]string, error) {
+	if err := g.node.Upcheck(); err != nil {
+		return nil, err
+	}
+	parties := []string{}
+	for _, key := range g.publicKeys {
+		if pl, err := g.node.ReceivePayloadFor(data, key); err == nil && len(pl) > 0 {
+			parties = append(parties, key)
+		}
+	}
+	return parties, nil
+}
+
+// Upcheck checks whether the Constellation node is reachable.
+func (g *Constellation) Upcheck() error {
+	return g.node.Upcheck()
+}
+
+// Version returns the version the Constellation node reports.
+func (g *Constellation) Version() (string, error) {
+	return g.node.Version()
+}
+
 func New(configPath string) (*Constellation, error) {
 	cfg, err := LoadConfig(configPath)
 	if err != nil {
@@ -47,13 +89,30 @@ func New(configPath string) (*Constellation, error) {
 	if err != nil {
 		return nil, err
 	}
-	n, err := NewClient(cfg.PublicKeys[0], cfg.Socket)
+	active := cfg.ActivePublicKeys()
+	n, err := NewClient(active[0], cfg.Socket)
 	if err != nil {
 		return nil, err
 	}
+	var retired []string
+	for _, path := range cfg.PublicKeys {
+		if containsKey(cfg.RetiredPublicKeys, path) {
+			retired = append(retired, path)
+		}
+	}
+	var publicKeys []string
+	for _, path := range append(active, retired...) {
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		publicKeys = append(publicKeys, strings.TrimSpace(string(key)))
+	}
 	return &Constellation{
-		node: n,
-		c:    cache.New(5*time.Minute, 5*time.Minute),
+		node:       n,
+		c:          cache.New(5*time.Minute, 5*time.Minute),
+		publicKeys: publicKeys,
+		retired:    len(retired),
 	}, nil
 }
 
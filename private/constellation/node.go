@@ -47,11 +47,15 @@ func unixClient(socketPath string) *http.Client {
 
 func RunNode(cfgPath, nodeSocketPath string) error {
 	// launchNode(cfgPath)
-	c := unixClient(nodeSocketPath)
+	return upcheck(unixClient(nodeSocketPath))
+}
+
+func upcheck(c *http.Client) error {
 	res, err := c.Get("http+unix://c/upcheck")
 	if err != nil {
 		return err
 	}
+	res.Body.Close()
 	if res.StatusCode == 200 {
 		return nil
 	}
@@ -131,10 +135,16 @@ func (c *Client) SendPayload(pl []byte, b64From string, b64To []string) ([]byte,
 }
 
 func (c *Client) ReceivePayload(key []byte) ([]byte, error) {
+	return c.ReceivePayloadFor(key, c.b64PublicKey)
+}
+
+// ReceivePayloadFor retrieves a payload as the recipient with the given public
+// key, which has to be one of the keys of the node.
+func (c *Client) ReceivePayloadFor(key []byte, b64To string) ([]byte, error) {
 	b64Key := base64.StdEncoding.EncodeToString(key)
 	req := &ReceiveRequest{
 		Key: b64Key,
-		To:  c.b64PublicKey,
+		To:  b64To,
 	}
 	res, err := c.do("receive", req)
 	if err != nil {
@@ -153,6 +163,29 @@ func (c *Client) ReceivePayload(key []byte) ([]byte, error) {
 	return pl, nil
 }
 
+// Upcheck checks whether the Constellation node is reachable.
+func (c *Client) Upcheck() error {
+	return upcheck(c.httpClient)
+}
+
+// Version returns the version the node reports, or an error if it doesn't
+// report one.
+func (c *Client) Version() (string, error) {
+	res, err := c.httpClient.Get("http+unix://c/version")
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return "", fmt.Errorf("Non-200 status code: %d", res.StatusCode)
+	}
+	version, err := ioutil.ReadAll(io.LimitReader(res.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSpace(version)), nil
+}
+
 func NewClient(publicKeyPath string, nodeSocketPath string) (*Client, error) {
 	b64PublicKey, err := ioutil.ReadFile(publicKeyPath)
 	if err != nil {
@@ -0,0 +1,75 @@
+package private
+
+import (
+	"errors"
+	"fmt"
+)
+
+// JSON-RPC error codes for failures related to private transactions.
+const (
+	ErrCodeNotAParty          = -32021
+	ErrCodeDisabled           = -32022
+	ErrCodeMixedRoutes        = -32023
+	ErrCodeSendFailed         = -32024
+	ErrCodePayloadUnavailable = -32025
+)
+
+// Error is a private transaction failure that carries a JSON-RPC error code
+// and structured data, so clients don't have to match on messages.
+type Error struct {
+	Code    int
+	Reason  string // Short machine readable reason, e.g. "notAParty"
+	Message string
+	Digest  string // Digest of the payload concerned, if any
+
+	Data map[string]interface{} // Further details, merged into the error data
+}
+
+func (e *Error) Error() string  { return e.Message }
+func (e *Error) ErrorCode() int { return e.Code }
+
+func (e *Error) ErrorData() interface{} {
+	data := map[string]interface{}{"reason": e.Reason}
+	if e.Digest != "" {
+		data["digest"] = e.Digest
+	}
+	for k, v := range e.Data {
+		data[k] = v
+	}
+	return data
+}
+
+var (
+	ErrDisabled = &Error{Code: ErrCodeDisabled, Reason: "privacyDisabled", Message: "PrivateTransactionManager is not enabled"}
+
+	ErrUnsupported = errors.New("not supported by the privacy manager")
+)
+
+func notAPartyError(digestHex string) error {
+	return &Error{
+		Code:    ErrCodeNotAParty,
+		Reason:  "notAParty",
+		Message: "not a party to this private transaction",
+		Digest:  digestHex,
+	}
+}
+
+// payloadUnavailableError is returned when the privacy manager holds the
+// capability of an off-chain payload, making the node a party, but the payload
+// itself couldn't be fetched.
+func payloadUnavailableError(uri string, err error) error {
+	return &Error{
+		Code:    ErrCodePayloadUnavailable,
+		Reason:  "payloadUnavailable",
+		Message: fmt.Sprintf("off-chain private payload %s unavailable: %v", uri, err),
+		Data:    map[string]interface{}{"uri": uri},
+	}
+}
+
+// IsPayloadUnavailable reports whether the payload of a private transaction
+// the node is a party to couldn't be retrieved. Such a transaction can't be
+// skipped without the private state diverging, so it has to be retried.
+func IsPayloadUnavailable(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == ErrCodePayloadUnavailable
+}
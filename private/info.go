@@ -0,0 +1,49 @@
+package private
+
+import "github.com/ethereum/go-ethereum/private/constellation"
+
+// ManagerInfo describes the privacy manager private transactions are sent
+// through.
+type ManagerInfo struct {
+	Type          string `json:"type"`
+	Version       string `json:"version,omitempty"`
+	Connected     bool   `json:"connected"`
+	Error         string `json:"error,omitempty"`
+	OffchainStore string `json:"offchainStore,omitempty"` // "s3" or "ipfs" if large payloads are stored off-chain
+}
+
+// Info returns the type, version and connectivity of the privacy manager, or
+// nil if private transactions are disabled.
+func Info() *ManagerInfo {
+	if P == nil {
+		return nil
+	}
+	info := &ManagerInfo{Type: "unknown"}
+	manager := P
+	if o, ok := P.(*Offchain); ok {
+		info.OffchainStore = o.scheme
+		manager = o.PrivateTransactionManager
+	}
+	switch manager.(type) {
+	case *constellation.Constellation:
+		info.Type = "constellation"
+	case *Router:
+		info.Type = "router"
+	}
+	m, ok := P.(interface {
+		Upcheck() error
+		Version() (string, error)
+	})
+	if !ok {
+		return info
+	}
+	if err := m.Upcheck(); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Connected = true
+	if version, err := m.Version(); err == nil {
+		info.Version = version
+	}
+	return info
+}
@@ -0,0 +1,138 @@
+package private
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/private/offchain"
+	"github.com/patrickmn/go-cache"
+)
+
+// Offchain is a PrivateTransactionManager storing payloads above a threshold
+// in an off-chain store. Those payloads are encrypted with a key of their own
+// and only a capability to retrieve them is sent through the privacy manager,
+// which it replaces transparently on receipt.
+type Offchain struct {
+	PrivateTransactionManager
+
+	store     offchain.Store
+	threshold int
+	scheme    string
+	c         *cache.Cache // URI -> payload, for the transactions just sent or received
+}
+
+// NewOffchain wraps a privacy manager to store large payloads off-chain.
+func NewOffchain(m PrivateTransactionManager, cfg *offchain.Config) (*Offchain, error) {
+	store, err := offchain.NewStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Offchain{
+		PrivateTransactionManager: m,
+		store:                     store,
+		threshold:                 cfg.Threshold,
+		scheme:                    strings.SplitN(cfg.URL, ":", 2)[0],
+		c:                         cache.New(5*time.Minute, 5*time.Minute),
+	}, nil
+}
+
+// Send stores payloads above the threshold off-chain and sends their
+// capability through the privacy manager, smaller payloads are sent as is.
+func (o *Offchain) Send(data []byte, from string, to []string) ([]byte, error) {
+	if len(data) <= o.threshold {
+		return o.PrivateTransactionManager.Send(data, from, to)
+	}
+	sealed, key, err := offchain.Seal(data)
+	if err != nil {
+		return nil, err
+	}
+	uri, err := o.store.Put(sealed)
+	if err != nil {
+		return nil, &Error{
+			Code:    ErrCodeSendFailed,
+			Reason:  "offchainStoreFailed",
+			Message: fmt.Sprintf("failed to store the payload off-chain: %v", err),
+		}
+	}
+	hash := sha256.Sum256(sealed)
+	capability, err := offchain.EncodeCapability(&offchain.Capability{URI: uri, Hash: hash[:], Key: key})
+	if err != nil {
+		return nil, err
+	}
+	glog.V(logger.Debug).Infof("Stored private payload of %d bytes off-chain at %s", len(data), uri)
+	o.c.Set(uri, data, cache.DefaultExpiration)
+	return o.PrivateTransactionManager.Send(capability, from, to)
+}
+
+// Receive returns the payload stored under a digest, retrieving it from the
+// off-chain store if the privacy manager holds its capability. Failing to
+// retrieve or verify it is reported as a payloadUnavailable error, unlike not
+// being a party, as the transaction can't be skipped.
+func (o *Offchain) Receive(data []byte) ([]byte, error) {
+	pl, err := o.PrivateTransactionManager.Receive(data)
+	if err != nil || !offchain.IsCapability(pl) {
+		return pl, err
+	}
+	c, err := offchain.DecodeCapability(pl)
+	if err != nil {
+		return nil, err
+	}
+	if x, found := o.c.Get(c.URI); found {
+		return x.([]byte), nil
+	}
+	sealed, err := o.store.Get(c.URI)
+	if err == nil {
+		pl, err = offchain.Open(c, sealed)
+	}
+	if err != nil {
+		glog.V(logger.Error).Infof("Failed to fetch off-chain private payload %s: %v", c.URI, err)
+		return nil, payloadUnavailableError(c.URI, err)
+	}
+	o.c.Set(c.URI, pl, cache.DefaultExpiration)
+	return pl, nil
+}
+
+// Parties returns the local public keys which are recipients of the payload.
+func (o *Offchain) Parties(digest []byte) ([]string, error) {
+	m, ok := o.PrivateTransactionManager.(interface {
+		Parties(digest []byte) ([]string, error)
+	})
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return m.Parties(digest)
+}
+
+// PublicKeys returns the public keys of the privacy manager.
+func (o *Offchain) PublicKeys() (active []string, retired []string) {
+	if m, ok := o.PrivateTransactionManager.(interface {
+		PublicKeys() ([]string, []string)
+	}); ok {
+		return m.PublicKeys()
+	}
+	return nil, nil
+}
+
+// Upcheck checks whether the privacy manager is reachable.
+func (o *Offchain) Upcheck() error {
+	if m, ok := o.PrivateTransactionManager.(interface {
+		Upcheck() error
+	}); ok {
+		return m.Upcheck()
+	}
+	return nil
+}
+
+// Version returns the version the privacy manager reports.
+func (o *Offchain) Version() (string, error) {
+	if m, ok := o.PrivateTransactionManager.(interface {
+		Version() (string, error)
+	}); ok {
+		return m.Version()
+	}
+	return "", ErrUnsupported
+}
@@ -0,0 +1,78 @@
+package offchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ipfsStore stores payloads through the HTTP API of an IPFS node, which pins
+// them. The content identifier IPFS returns is the hash of the payload.
+type ipfsStore struct {
+	api    string
+	client *http.Client
+}
+
+func newIPFSStore(u *url.URL) (*ipfsStore, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("no IPFS API address in %s", u)
+	}
+	return &ipfsStore{
+		api:    "http://" + u.Host + "/api/v0",
+		client: &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+func (s *ipfsStore) Put(data []byte) (string, error) {
+	body := new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+	part, err := form.CreateFormFile("file", "payload")
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	form.Close()
+
+	res, err := s.client.Post(s.api+"/add?pin=true", form.FormDataContentType(), body)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return "", fmt.Errorf("IPFS add: %s: %s", res.Status, strings.TrimSpace(string(msg)))
+	}
+	var added struct {
+		Hash string
+	}
+	if err := json.NewDecoder(res.Body).Decode(&added); err != nil {
+		return "", fmt.Errorf("IPFS add: %v", err)
+	}
+	if added.Hash == "" {
+		return "", fmt.Errorf("IPFS add: no content identifier returned")
+	}
+	return "ipfs://" + added.Hash, nil
+}
+
+func (s *ipfsStore) Get(uri string) ([]byte, error) {
+	if !strings.HasPrefix(uri, "ipfs://") {
+		return nil, fmt.Errorf("not an IPFS URI: %s", uri)
+	}
+	cid := strings.TrimPrefix(uri, "ipfs://")
+	res, err := s.client.Post(s.api+"/cat?arg="+url.QueryEscape(cid), "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return nil, fmt.Errorf("IPFS cat %s: %s: %s", cid, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return ioutil.ReadAll(res.Body)
+}
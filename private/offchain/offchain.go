@@ -0,0 +1,167 @@
+// Package offchain stores large private payloads outside of the privacy
+// manager. Payloads are encrypted with a key of their own and put into a
+// content-addressed store, only a capability with the location, hash and key of
+// the payload is sent through the privacy manager.
+package offchain
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultThreshold is the size above which payloads are stored off-chain if
+// the config doesn't set one.
+const DefaultThreshold = 1024 * 1024
+
+// Config is the [offchain] section of the privacy manager config.
+type Config struct {
+	URL       string `toml:"url"`       // s3://bucket/prefix or ipfs://host:port of the IPFS API
+	Threshold int    `toml:"threshold"` // Payloads larger than this many bytes are stored off-chain
+	Region    string `toml:"region"`    // AWS region of the S3 bucket
+	Endpoint  string `toml:"endpoint"`  // Endpoint of S3 compatible stores, AWS if empty
+}
+
+// LoadConfig reads the [offchain] section of a privacy manager config. It
+// returns nil if the section is missing or has no URL.
+func LoadConfig(configPath string) (*Config, error) {
+	var file struct {
+		Offchain *Config `toml:"offchain"`
+	}
+	if _, err := toml.DecodeFile(configPath, &file); err != nil {
+		return nil, err
+	}
+	cfg := file.Offchain
+	if cfg == nil || cfg.URL == "" {
+		return nil, nil
+	}
+	if cfg.Threshold < 0 {
+		return nil, fmt.Errorf("negative offchain threshold %d", cfg.Threshold)
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = DefaultThreshold
+	}
+	return cfg, nil
+}
+
+// Store is a content-addressed store of encrypted payloads.
+type Store interface {
+	// Put stores a payload and returns the URI it can be retrieved by.
+	Put(data []byte) (string, error)
+
+	// Get retrieves the payload stored under a URI.
+	Get(uri string) ([]byte, error)
+}
+
+// NewStore opens the store the config points at.
+func NewStore(cfg *Config) (Store, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "s3":
+		return newS3Store(u, cfg.Region, cfg.Endpoint)
+	case "ipfs":
+		return newIPFSStore(u)
+	default:
+		return nil, fmt.Errorf("unsupported offchain store %q, use s3:// or ipfs://", cfg.URL)
+	}
+}
+
+// capabilityPrefix marks the payloads sent through the privacy manager which
+// are capabilities of off-chain payloads rather than payloads.
+var capabilityPrefix = []byte("\x00quorum-offchain/1\x00")
+
+// Capability grants access to an off-chain payload. It's only sent through
+// the privacy manager, so only the parties of the payload learn the key.
+type Capability struct {
+	URI  string `json:"uri"`  // Location of the encrypted payload
+	Hash []byte `json:"hash"` // SHA-256 of the encrypted payload
+	Key  []byte `json:"key"`  // AES-256-GCM key of the payload
+}
+
+// IsCapability reports whether a payload received from the privacy manager is
+// the capability of an off-chain payload.
+func IsCapability(data []byte) bool {
+	return bytes.HasPrefix(data, capabilityPrefix)
+}
+
+// EncodeCapability encodes a capability to be sent through the privacy manager.
+func EncodeCapability(c *Capability) ([]byte, error) {
+	blob, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, capabilityPrefix...), blob...), nil
+}
+
+// DecodeCapability decodes a capability received from the privacy manager.
+func DecodeCapability(data []byte) (*Capability, error) {
+	if !IsCapability(data) {
+		return nil, errors.New("not an offchain capability")
+	}
+	c := new(Capability)
+	if err := json.Unmarshal(data[len(capabilityPrefix):], c); err != nil {
+		return nil, fmt.Errorf("invalid offchain capability: %v", err)
+	}
+	return c, nil
+}
+
+// Seal encrypts a payload with a new random key. It returns the encrypted
+// payload and the key, the nonce is prepended to the ciphertext.
+func Seal(data []byte) (sealed []byte, key []byte, err error) {
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), key, nil
+}
+
+// Open verifies an encrypted payload against the hash of a capability and
+// decrypts it.
+func Open(c *Capability, sealed []byte) ([]byte, error) {
+	if hash := sha256.Sum256(sealed); !bytes.Equal(hash[:], c.Hash) {
+		return nil, fmt.Errorf("offchain payload %s: hash mismatch: have %x, want %x", c.URI, hash, c.Hash)
+	}
+	gcm, err := newGCM(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("offchain payload %s: too short", c.URI)
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hashHex returns the SHA-256 of an encrypted payload, the name it's stored
+// under in stores which aren't content-addressed themselves.
+func hashHex(data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
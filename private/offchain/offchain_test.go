@@ -0,0 +1,84 @@
+package offchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func sealCapability(t *testing.T, data []byte) (*Capability, []byte) {
+	sealed, key, err := Seal(data)
+	if err != nil {
+		t.Fatalf("failed to seal payload: %v", err)
+	}
+	hash := sha256.Sum256(sealed)
+	return &Capability{URI: "mem://" + hashHex(sealed), Hash: hash[:], Key: key}, sealed
+}
+
+func TestSealOpen(t *testing.T) {
+	data := []byte("private payload")
+	c, sealed := sealCapability(t, data)
+	if bytes.Contains(sealed, data) {
+		t.Fatalf("sealed payload contains the plaintext")
+	}
+	opened, err := Open(c, sealed)
+	if err != nil {
+		t.Fatalf("failed to open payload: %v", err)
+	}
+	if !bytes.Equal(opened, data) {
+		t.Errorf("opened payload mismatch: have %q, want %q", opened, data)
+	}
+	// Payloads are sealed with keys of their own
+	if other, _ := sealCapability(t, data); bytes.Equal(other.Key, c.Key) {
+		t.Errorf("key reused across payloads")
+	}
+}
+
+func TestOpenHashMismatch(t *testing.T) {
+	c, sealed := sealCapability(t, []byte("private payload"))
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := Open(c, sealed); err == nil {
+		t.Fatalf("opened a tampered payload")
+	}
+}
+
+func TestOpenWrongKey(t *testing.T) {
+	c, sealed := sealCapability(t, []byte("private payload"))
+	other, _ := sealCapability(t, []byte("other payload"))
+	c.Key = other.Key
+	if _, err := Open(c, sealed); err == nil {
+		t.Fatalf("opened a payload with the wrong key")
+	}
+	c.Key = c.Key[:5]
+	if _, err := Open(c, sealed); err == nil {
+		t.Fatalf("opened a payload with an invalid key")
+	}
+}
+
+func TestCapabilityEncoding(t *testing.T) {
+	c, _ := sealCapability(t, []byte("private payload"))
+	enc, err := EncodeCapability(c)
+	if err != nil {
+		t.Fatalf("failed to encode capability: %v", err)
+	}
+	if !IsCapability(enc) {
+		t.Fatalf("encoded capability not recognised")
+	}
+	dec, err := DecodeCapability(enc)
+	if err != nil {
+		t.Fatalf("failed to decode capability: %v", err)
+	}
+	if dec.URI != c.URI || !bytes.Equal(dec.Hash, c.Hash) || !bytes.Equal(dec.Key, c.Key) {
+		t.Errorf("capability mismatch: have %+v, want %+v", dec, c)
+	}
+
+	if IsCapability([]byte("private payload")) {
+		t.Errorf("payload recognised as a capability")
+	}
+	if _, err := DecodeCapability([]byte("private payload")); err == nil {
+		t.Errorf("decoded a payload as a capability")
+	}
+	if _, err := DecodeCapability(append(append([]byte{}, capabilityPrefix...), "{"...)); err == nil {
+		t.Errorf("decoded a malformed capability")
+	}
+}
@@ -0,0 +1,147 @@
+package private
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/private/offchain"
+	"github.com/patrickmn/go-cache"
+)
+
+// memStore is an in-memory offchain.Store.
+type memStore struct {
+	payloads map[string][]byte
+	fail     error
+}
+
+func (s *memStore) Put(data []byte) (string, error) {
+	if s.fail != nil {
+		return "", s.fail
+	}
+	uri := fmt.Sprintf("mem://%x", sha256.Sum256(data))
+	s.payloads[uri] = append([]byte{}, data...)
+	return uri, nil
+}
+
+func (s *memStore) Get(uri string) ([]byte, error) {
+	if s.fail != nil {
+		return nil, s.fail
+	}
+	data, ok := s.payloads[uri]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return append([]byte{}, data...), nil
+}
+
+// memManager is an in-memory privacy manager, receiving what it sent.
+type memManager struct {
+	payloads map[string][]byte
+}
+
+func (m *memManager) Send(data []byte, from string, to []string) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	m.payloads[string(digest[:])] = data
+	return digest[:], nil
+}
+
+func (m *memManager) Receive(digest []byte) ([]byte, error) {
+	return m.payloads[string(digest)], nil
+}
+
+func newTestOffchain(threshold int) (*Offchain, *memManager, *memStore) {
+	m := &memManager{payloads: make(map[string][]byte)}
+	s := &memStore{payloads: make(map[string][]byte)}
+	return &Offchain{
+		PrivateTransactionManager: m,
+		store:                     s,
+		threshold:                 threshold,
+		scheme:                    "mem",
+		c:                         cache.New(time.Minute, time.Minute),
+	}, m, s
+}
+
+func TestOffchainSmallPayload(t *testing.T) {
+	o, m, s := newTestOffchain(16)
+	data := []byte("small")
+	digest, err := o.Send(data, "from", []string{"to"})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if len(s.payloads) != 0 {
+		t.Errorf("small payload stored off-chain")
+	}
+	if !bytes.Equal(m.payloads[string(digest)], data) {
+		t.Errorf("small payload not sent as is")
+	}
+	if pl, err := o.Receive(digest); err != nil || !bytes.Equal(pl, data) {
+		t.Errorf("receive mismatch: have %q (%v), want %q", pl, err, data)
+	}
+}
+
+func TestOffchainLargePayload(t *testing.T) {
+	o, m, s := newTestOffchain(16)
+	data := []byte(strings.Repeat("large payload ", 10))
+	digest, err := o.Send(data, "from", []string{"to"})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	if len(s.payloads) != 1 {
+		t.Fatalf("stored %d payloads off-chain, want 1", len(s.payloads))
+	}
+	if !offchain.IsCapability(m.payloads[string(digest)]) {
+		t.Fatalf("privacy manager got the payload instead of its capability")
+	}
+	// Receive from the store rather than the cache of sent payloads
+	o.c.Flush()
+	if pl, err := o.Receive(digest); err != nil || !bytes.Equal(pl, data) {
+		t.Errorf("receive mismatch: have %q (%v), want %q", pl, err, data)
+	}
+	// Not being a party isn't an error
+	if pl, err := o.Receive([]byte("unknown")); err != nil || len(pl) != 0 {
+		t.Errorf("receive of unknown digest: have %q (%v), want no payload", pl, err)
+	}
+}
+
+func TestOffchainStoreFailure(t *testing.T) {
+	o, _, s := newTestOffchain(16)
+	s.fail = errors.New("unreachable")
+
+	_, err := o.Send([]byte(strings.Repeat("large payload ", 10)), "from", []string{"to"})
+	if e, ok := err.(*Error); !ok || e.Code != ErrCodeSendFailed {
+		t.Errorf("send error mismatch: have %v, want code %d", err, ErrCodeSendFailed)
+	}
+}
+
+func TestOffchainPayloadUnavailable(t *testing.T) {
+	o, _, s := newTestOffchain(16)
+	digest, err := o.Send([]byte(strings.Repeat("large payload ", 10)), "from", []string{"to"})
+	if err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+	o.c.Flush()
+
+	// A store failure must not look like not being a party
+	s.fail = errors.New("unreachable")
+	if _, err := o.Receive(digest); !IsPayloadUnavailable(err) {
+		t.Errorf("store failure: have %v, want payload unavailable", err)
+	}
+	s.fail = nil
+
+	// Nor a payload which doesn't match its capability
+	for uri, sealed := range s.payloads {
+		sealed[len(sealed)-1] ^= 0xff
+		s.payloads[uri] = sealed
+	}
+	if _, err := o.Receive(digest); !IsPayloadUnavailable(err) {
+		t.Errorf("tampered payload: have %v, want payload unavailable", err)
+	}
+	if IsPayloadUnavailable(errors.New("other")) || IsPayloadUnavailable(ErrDisabled) {
+		t.Errorf("other errors reported as payload unavailable")
+	}
+}
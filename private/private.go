@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/ethereum/go-ethereum/private/constellation"
+	"github.com/ethereum/go-ethereum/private/offchain"
 )
 
 type PrivateTransactionManager interface {
@@ -27,7 +28,28 @@ func FromCommandLineEnvironmentOrNil(name string) PrivateTransactionManager {
 	if cfgPath == "" {
 		return nil
 	}
-	return constellation.MustNew(cfgPath)
+	var m PrivateTransactionManager
+	if cfg, err := LoadRouterConfig(cfgPath); err == nil && len(cfg.Managers) > 0 {
+		r, err := NewRouter(cfg)
+		if err != nil {
+			panic(fmt.Sprintf("NewRouter error: %v", err))
+		}
+		m = r
+	} else {
+		m = constellation.MustNew(cfgPath)
+	}
+	cfg, err := offchain.LoadConfig(cfgPath)
+	if err != nil {
+		panic(fmt.Sprintf("offchain config error: %v", err))
+	}
+	if cfg != nil {
+		o, err := NewOffchain(m, cfg)
+		if err != nil {
+			panic(fmt.Sprintf("NewOffchain error: %v", err))
+		}
+		return o
+	}
+	return m
 }
 
 var P = FromCommandLineEnvironmentOrNil("PRIVATE_CONFIG")
@@ -38,9 +60,55 @@ func RegeneratePrivateConfig() {
 	}
 }
 
+// Send encrypts data for the given recipients and returns the digest under
+// which the payload was stored.
+func Send(data []byte, from string, to []string) ([]byte, error) {
+	if P == nil {
+		return nil, ErrDisabled
+	}
+	return P.Send(data, from, to)
+}
+
+// Parties returns the local public keys of the privacy manager which are
+// recipients of the payload stored under the digest.
+func Parties(digest []byte) ([]string, error) {
+	if P == nil {
+		return nil, ErrDisabled
+	}
+	m, ok := P.(interface {
+		Parties(digest []byte) ([]string, error)
+	})
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	return m.Parties(digest)
+}
+
+// Keys are the public keys of the privacy manager of the node.
+type Keys struct {
+	Active  []string `json:"active"`  // Keys payloads are addressed to, the first is the default sender key
+	Retired []string `json:"retired"` // Keys rotated out, only used to decrypt older payloads
+}
+
+// PublicKeys returns the public keys of the privacy manager, for peers to
+// address payloads to the active ones after a key rotation.
+func PublicKeys() (*Keys, error) {
+	if P == nil {
+		return nil, ErrDisabled
+	}
+	m, ok := P.(interface {
+		PublicKeys() ([]string, []string)
+	})
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	active, retired := m.PublicKeys()
+	return &Keys{Active: active, Retired: retired}, nil
+}
+
 func GetPayload(digestHex string) (string, error) {
 	if P == nil {
-		return "", fmt.Errorf("PrivateTransactionManager is not enabled")
+		return "", ErrDisabled
 	}
 	if len(digestHex) < 3 {
 		return "", fmt.Errorf("Invalid digest hex")
@@ -59,5 +127,8 @@ func GetPayload(digestHex string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if len(data) == 0 {
+		return "", notAPartyError("0x" + digestHex)
+	}
 	return fmt.Sprintf("0x%x", data), nil
 }
@@ -0,0 +1,22 @@
+package private
+
+import "testing"
+
+// Tests that payloads are handed to the privacy manager as they are, including
+// empty ones.
+func TestSendEmptyPayload(t *testing.T) {
+	defer func(p PrivateTransactionManager) { P = p }(P)
+
+	P = nil
+	if _, err := Send(nil, "from", []string{"to"}); err != ErrDisabled {
+		t.Errorf("send without a privacy manager: have %v, want %v", err, ErrDisabled)
+	}
+	m := &memManager{payloads: make(map[string][]byte)}
+	P = m
+	if _, err := Send(nil, "from", []string{"to"}); err != nil {
+		t.Errorf("failed to send an empty payload: %v", err)
+	}
+	if len(m.payloads) != 1 {
+		t.Errorf("empty payload not sent to the privacy manager")
+	}
+}
@@ -0,0 +1,193 @@
+package private
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/private/constellation"
+)
+
+// RouterConfig maps the public keys of recipients to the privacy managers
+// serving them, for nodes whose counterparties use different privacy managers.
+type RouterConfig struct {
+	Default  string            `toml:"default"`  // Manager of recipients without a route
+	Managers map[string]string `toml:"managers"` // Name -> config file of the privacy manager
+	Routes   map[string]string `toml:"routes"`   // Base64 public key -> name of the manager
+}
+
+// LoadRouterConfig reads a routing config. Configs without managers are those
+// of a single privacy manager.
+func LoadRouterConfig(configPath string) (*RouterConfig, error) {
+	cfg := new(RouterConfig)
+	if _, err := toml.DecodeFile(configPath, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Router is a PrivateTransactionManager sending payloads through the privacy
+// manager serving their recipients. A payload is stored by a single manager, so
+// the recipients of a transaction have to be served by the same one.
+type Router struct {
+	names    []string // Managers in the order payloads are looked up, default first
+	managers map[string]PrivateTransactionManager
+	routes   map[string]string
+}
+
+// NewRouter opens the privacy managers of a routing config.
+func NewRouter(cfg *RouterConfig) (*Router, error) {
+	if _, ok := cfg.Managers[cfg.Default]; !ok {
+		return nil, fmt.Errorf("default privacy manager %q not configured", cfg.Default)
+	}
+	for key, name := range cfg.Routes {
+		if _, ok := cfg.Managers[name]; !ok {
+			return nil, fmt.Errorf("route of %s to unknown privacy manager %q", key, name)
+		}
+	}
+	r := &Router{
+		names:    []string{cfg.Default},
+		managers: make(map[string]PrivateTransactionManager),
+		routes:   cfg.Routes,
+	}
+	for name := range cfg.Managers {
+		if name != cfg.Default {
+			r.names = append(r.names, name)
+		}
+	}
+	sort.Strings(r.names[1:])
+
+	for _, name := range r.names {
+		m, err := constellation.New(cfg.Managers[name])
+		if err != nil {
+			return nil, fmt.Errorf("privacy manager %q: %v", name, err)
+		}
+		r.managers[name] = m
+	}
+	return r, nil
+}
+
+// route returns the manager serving a key, the default one for keys without a
+// route.
+func (r *Router) route(key string) string {
+	if name, ok := r.routes[key]; ok {
+		return name
+	}
+	return r.names[0]
+}
+
+// Send stores the payload with the privacy manager serving the recipients and
+// the sender key, if it has a route. Recipients served by different managers
+// are rejected before anything is sent, so no payload is stored for them.
+func (r *Router) Send(data []byte, from string, to []string) ([]byte, error) {
+	routes := make(map[string][]string)
+	for _, key := range to {
+		name := r.route(key)
+		routes[name] = append(routes[name], key)
+	}
+	if name, ok := r.routes[from]; ok && from != "" {
+		if _, ok := routes[name]; !ok {
+			routes[name] = []string{}
+		}
+	}
+	if len(routes) > 1 {
+		return nil, &Error{
+			Code:    ErrCodeMixedRoutes,
+			Reason:  "mixedRoutes",
+			Message: "recipients are served by different privacy managers, send a transaction per manager",
+			Data:    map[string]interface{}{"routes": routes},
+		}
+	}
+	name := r.names[0]
+	for n := range routes {
+		name = n
+	}
+	out, err := r.managers[name].Send(data, from, to)
+	if err != nil {
+		return nil, &Error{
+			Code:    ErrCodeSendFailed,
+			Reason:  "sendFailed",
+			Message: fmt.Sprintf("privacy manager %q failed to send the payload: %v", name, err),
+			Data:    map[string]interface{}{"manager": name, "recipients": to},
+		}
+	}
+	return out, nil
+}
+
+// Receive looks the payload up with each privacy manager, as any of them may
+// have received it.
+func (r *Router) Receive(data []byte) ([]byte, error) {
+	for _, name := range r.names {
+		if pl, err := r.managers[name].Receive(data); err == nil && len(pl) > 0 {
+			return pl, nil
+		}
+	}
+	return nil, nil
+}
+
+// Parties returns the public keys of all privacy managers which are recipients
+// of the payload.
+func (r *Router) Parties(digest []byte) ([]string, error) {
+	parties := []string{}
+	for _, name := range r.names {
+		m, ok := r.managers[name].(interface {
+			Parties(digest []byte) ([]string, error)
+		})
+		if !ok {
+			continue
+		}
+		keys, err := m.Parties(digest)
+		if err != nil {
+			return nil, err
+		}
+		parties = append(parties, keys...)
+	}
+	return parties, nil
+}
+
+// PublicKeys returns the public keys of all privacy managers, those of the
+// default manager first.
+func (r *Router) PublicKeys() (active []string, retired []string) {
+	for _, name := range r.names {
+		if m, ok := r.managers[name].(interface {
+			PublicKeys() ([]string, []string)
+		}); ok {
+			a, ret := m.PublicKeys()
+			active, retired = append(active, a...), append(retired, ret...)
+		}
+	}
+	return active, retired
+}
+
+// Upcheck checks whether all privacy managers are reachable.
+func (r *Router) Upcheck() error {
+	for _, name := range r.names {
+		if m, ok := r.managers[name].(interface {
+			Upcheck() error
+		}); ok {
+			if err := m.Upcheck(); err != nil {
+				return fmt.Errorf("privacy manager %q: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Version returns the versions the privacy managers report.
+func (r *Router) Version() (string, error) {
+	var versions []string
+	for _, name := range r.names {
+		if m, ok := r.managers[name].(interface {
+			Version() (string, error)
+		}); ok {
+			if version, err := m.Version(); err == nil {
+				versions = append(versions, name+"/"+version)
+			}
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no privacy manager reports a version")
+	}
+	return strings.Join(versions, " "), nil
+}
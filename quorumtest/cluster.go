@@ -0,0 +1,346 @@
+// Package quorumtest runs in-process Quorum clusters for integration tests.
+//
+// A cluster is a set of full nodes on the loopback interface, each with its
+// own temporary data directory, running raft or QuorumChain on a fresh chain
+// with prefunded accounts:
+//
+//	cluster, err := quorumtest.NewCluster(quorumtest.Config{Nodes: 3, Consensus: quorumtest.Raft})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer cluster.Stop()
+//
+//	client, _ := cluster.Nodes[0].Attach()
+//	...
+//
+// The privacy manager is global to the process, so all nodes of a cluster
+// share the one configured in private.P, if any. So is the QuorumChain block
+// maker strategy, the quorum RPC APIs pausing or forcing block creation act on
+// the last node started.
+package quorumtest
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/quorum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/raft"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// Consensus is the consensus mechanism of a cluster.
+type Consensus int
+
+const (
+	Raft        Consensus = iota // Raft, the first node starts out as the leader
+	QuorumChain                  // QuorumChain, the first node is the block maker and all nodes vote
+)
+
+// Config describes a cluster.
+type Config struct {
+	Nodes     int       // Number of nodes, at least 1
+	Consensus Consensus // Consensus mechanism of the cluster
+	Accounts  int       // Prefunded accounts unlocked on all nodes, 1 if 0
+
+	RaftBlockTime time.Duration // Minimum time between raft blocks, 50ms if 0
+	BlockTime     time.Duration // Time between QuorumChain blocks, 1s if 0
+
+	// ChainConfig of the genesis block, all forks activated at genesis if nil.
+	ChainConfig *core.ChainConfig
+}
+
+// Cluster is a running set of nodes sharing a chain.
+type Cluster struct {
+	Nodes    []*Node
+	Accounts []*ecdsa.PrivateKey // Keys of the prefunded accounts
+
+	dir string
+}
+
+// Node is a node of a cluster.
+type Node struct {
+	Stack    *node.Node
+	Ethereum *eth.Ethereum
+	Raft     *raft.RaftService // nil with QuorumChain
+
+	Key     *ecdsa.PrivateKey // Node key, which the enode ID derives from
+	VoteKey *ecdsa.PrivateKey // QuorumChain voter key, nil with raft
+	DataDir string
+
+	port, raftPort int
+}
+
+// Enode returns the enode URL of the node.
+func (n *Node) Enode() *discover.Node {
+	node := discover.NewNode(discover.PubkeyID(&n.Key.PublicKey), net.ParseIP("127.0.0.1"), uint16(n.port), uint16(n.port))
+	node.RaftPort = uint16(n.raftPort)
+	return node
+}
+
+// Attach returns an in-process RPC client of the node.
+func (n *Node) Attach() (*rpc.Client, error) {
+	return n.Stack.Attach()
+}
+
+// NewCluster starts the nodes of a cluster. The nodes are connected to each
+// other, but the first blocks may still be in flight when it returns; use
+// WaitForBlock to wait for the chain to progress.
+func NewCluster(config Config) (*Cluster, error) {
+	if config.Nodes < 1 {
+		return nil, errors.New("a cluster needs at least one node")
+	}
+	if config.Accounts == 0 {
+		config.Accounts = 1
+	}
+	if config.RaftBlockTime == 0 {
+		config.RaftBlockTime = 50 * time.Millisecond
+	}
+	if config.BlockTime == 0 {
+		config.BlockTime = time.Second
+	}
+	if config.ChainConfig == nil {
+		config.ChainConfig = &core.ChainConfig{HomesteadBlock: new(big.Int), ByzantiumBlock: new(big.Int)}
+	}
+	dir, err := ioutil.TempDir("", "quorumtest")
+	if err != nil {
+		return nil, err
+	}
+	c := &Cluster{dir: dir}
+	for i := 0; i < config.Accounts; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			c.Stop()
+			return nil, err
+		}
+		c.Accounts = append(c.Accounts, key)
+	}
+	// Keys and ports are assigned up front, as every node needs the enode URLs
+	// of the others
+	for i := 0; i < config.Nodes; i++ {
+		n := &Node{DataDir: filepath.Join(dir, fmt.Sprintf("node%d", i))}
+		if n.Key, err = crypto.GenerateKey(); err != nil {
+			c.Stop()
+			return nil, err
+		}
+		if config.Consensus == QuorumChain {
+			if n.VoteKey, err = crypto.GenerateKey(); err != nil {
+				c.Stop()
+				return nil, err
+			}
+		}
+		if n.port, err = freePort(); err != nil {
+			c.Stop()
+			return nil, err
+		}
+		if n.raftPort, err = freePort(); err != nil {
+			c.Stop()
+			return nil, err
+		}
+		c.Nodes = append(c.Nodes, n)
+	}
+	genesis := c.genesis(config.Consensus)
+	for i := range c.Nodes {
+		if err := c.startNode(config, i, genesis); err != nil {
+			c.Stop()
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+	}
+	return c, nil
+}
+
+// genesis returns the genesis JSON of the cluster. With QuorumChain, the voting
+// contract makes the first node the block maker and all nodes voters, with a
+// threshold of one vote.
+func (c *Cluster) genesis(consensus Consensus) string {
+	alloc := []string{}
+	for _, key := range c.Accounts {
+		alloc = append(alloc, fmt.Sprintf(`"%x": {"balance": "1000000000000000000000000000"}`, crypto.PubkeyToAddress(key.PublicKey)))
+	}
+	if consensus == QuorumChain {
+		var voters []common.Address
+		for _, n := range c.Nodes {
+			voter := crypto.PubkeyToAddress(n.VoteKey.PublicKey)
+			voters = append(voters, voter)
+			// Votes are free, but only accounts which exist may send transactions
+			alloc = append(alloc, fmt.Sprintf(`"%x": {"balance": "1"}`, voter))
+		}
+		storage, err := quorum.GenesisStorage(voters, voters[:1], 1)
+		if err != nil {
+			panic(err)
+		}
+		entries := []string{}
+		for k, v := range storage {
+			entries = append(entries, fmt.Sprintf(`"%x": "%x"`, k, v))
+		}
+		alloc = append(alloc, fmt.Sprintf(`"%x": {"code": "%s", "storage": {%s}}`, params.QuorumVotingContractAddr, quorum.RuntimeCode, strings.Join(entries, ", ")))
+	}
+	return fmt.Sprintf(`{
+		"nonce": "0x0",
+		"gasLimit": "0xe0000000",
+		"difficulty": "0x0",
+		"alloc": {%s}
+	}`, strings.Join(alloc, ", "))
+}
+
+func (c *Cluster) startNode(config Config, index int, genesis string) error {
+	n := c.Nodes[index]
+	if err := os.MkdirAll(n.DataDir, 0700); err != nil {
+		return err
+	}
+	// Static nodes connect QuorumChain nodes, raft connects its peers itself
+	var peers []string
+	for _, other := range c.Nodes {
+		peers = append(peers, fmt.Sprintf("%q", other.Enode().String()))
+	}
+	if err := ioutil.WriteFile(filepath.Join(n.DataDir, "static-nodes.json"), []byte("["+strings.Join(peers, ",")+"]"), 0600); err != nil {
+		return err
+	}
+	stack, err := node.New(&node.Config{
+		Name:              "geth",
+		DataDir:           n.DataDir,
+		UseLightweightKDF: true,
+		PrivateKey:        n.Key,
+		ListenAddr:        fmt.Sprintf("127.0.0.1:%d", n.port),
+		NoDiscovery:       true,
+		MaxPeers:          len(c.Nodes) + 1,
+	})
+	if err != nil {
+		return err
+	}
+	n.Stack = stack
+
+	ethConf := &eth.Config{
+		ChainConfig:     config.ChainConfig,
+		NetworkId:       1337,
+		Genesis:         genesis,
+		AssumeSynced:    true,
+		DatabaseCache:   16,
+		DatabaseHandles: 16,
+		RaftMode:        config.Consensus == Raft,
+		MinBlockTime:    uint(config.BlockTime / time.Second),
+		MaxBlockTime:    uint(config.BlockTime/time.Second) + 1,
+		MinVoteTime:     uint(config.BlockTime / time.Second),
+		MaxVoteTime:     uint(config.BlockTime/time.Second) + 1,
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		ethereum, err := eth.New(ctx, ethConf)
+		n.Ethereum = ethereum
+		return ethereum, err
+	}); err != nil {
+		return err
+	}
+	if config.Consensus == Raft {
+		var startPeers []*discover.Node
+		for _, other := range c.Nodes {
+			startPeers = append(startPeers, other.Enode())
+		}
+		// A new cluster with the defaults of the raft flags
+		var (
+			raftId              = uint16(index + 1)
+			joinExisting        = false
+			minTimeIncrement    = time.Duration(1)
+			maxSpeculativeDepth = 0 // Unlimited
+			backpressureHigh    = uint64(0)
+			backpressureLow     = uint64(0)
+			compress            = false
+			snapshotRate        = uint64(0)
+			followerWrites      = raft.FollowerWritesLocal
+			rpcEndpoint         = ""
+			advertiseAddr       = ""
+		)
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			service, err := raft.New(ctx, config.ChainConfig, raftId, uint16(n.raftPort), joinExisting, config.RaftBlockTime, minTimeIncrement, maxSpeculativeDepth, n.Ethereum, startPeers, n.DataDir, backpressureHigh, backpressureLow, compress, snapshotRate, followerWrites, rpcEndpoint, advertiseAddr)
+			n.Raft = service
+			return service, err
+		}); err != nil {
+			return err
+		}
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	accman := stack.AccountManager()
+	for _, key := range c.Accounts {
+		account, err := accman.ImportECDSA(key, "")
+		if err != nil {
+			return err
+		}
+		if err := accman.Unlock(account, ""); err != nil {
+			return err
+		}
+	}
+	if config.Consensus == QuorumChain {
+		client, err := stack.Attach()
+		if err != nil {
+			return err
+		}
+		var signer quorum.BlockSigner
+		if index == 0 {
+			signer = quorum.NewLocalSigner(n.VoteKey)
+		}
+		if err := n.Ethereum.StartBlockVoting(client, n.VoteKey, signer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForBlock waits until all nodes imported the block with the given number.
+func (c *Cluster) WaitForBlock(ctx context.Context, number uint64) error {
+	for _, n := range c.Nodes {
+		for n.Ethereum.BlockChain().CurrentBlock().NumberU64() < number {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}
+	return nil
+}
+
+// Stop stops all nodes and removes their data directories.
+func (c *Cluster) Stop() error {
+	var errs []string
+	for i, n := range c.Nodes {
+		if n.Stack == nil {
+			continue
+		}
+		if err := n.Stack.Stop(); err != nil && err != node.ErrNodeStopped {
+			errs = append(errs, fmt.Sprintf("node %d: %v", i, err))
+		}
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// freePort returns a TCP port of the loopback interface which is free.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
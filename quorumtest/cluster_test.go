@@ -0,0 +1,64 @@
+package quorumtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/net/context"
+)
+
+func TestRaftCluster(t *testing.T) {
+	cluster, err := NewCluster(Config{Nodes: 3, Consensus: Raft})
+	if err != nil {
+		t.Fatalf("failed to start cluster: %v", err)
+	}
+	defer cluster.Stop()
+
+	// Raft only mints blocks with transactions
+	client, err := cluster.Nodes[1].Attach()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	from := crypto.PubkeyToAddress(cluster.Accounts[0].PublicKey)
+	tx := map[string]interface{}{"from": from, "to": common.Address{1}, "value": "0x1"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	var hash common.Hash
+	if err := client.CallContext(ctx, &hash, "eth_sendTransaction", tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if err := cluster.WaitForBlock(ctx, 1); err != nil {
+		t.Fatalf("block 1 not reached: %v", err)
+	}
+	for i, n := range cluster.Nodes {
+		block := n.Ethereum.BlockChain().GetBlockByNumber(1)
+		if block == nil || block.Transaction(hash) == nil {
+			t.Errorf("node %d: transaction not included in block 1", i)
+		}
+	}
+	if cluster.Nodes[0].Raft == nil {
+		t.Errorf("raft service not recorded")
+	}
+}
+
+func TestQuorumChainCluster(t *testing.T) {
+	cluster, err := NewCluster(Config{Nodes: 2, Consensus: QuorumChain})
+	if err != nil {
+		t.Fatalf("failed to start cluster: %v", err)
+	}
+	defer cluster.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := cluster.WaitForBlock(ctx, 1); err != nil {
+		t.Fatalf("block 1 not reached: %v", err)
+	}
+	hash := cluster.Nodes[0].Ethereum.BlockChain().GetBlockByNumber(1).Hash()
+	if other := cluster.Nodes[1].Ethereum.BlockChain().GetBlockByNumber(1); other == nil || other.Hash() != hash {
+		t.Errorf("nodes disagree on block 1")
+	}
+}
@@ -1,5 +1,7 @@
 package raft
 
+import "errors"
+
 type RaftNodeInfo struct {
 	ClusterSize    int        `json:"clusterSize"`
 	Role           string     `json:"role"`
@@ -8,6 +10,8 @@ type RaftNodeInfo struct {
 	RemovedPeerIds []uint16   `json:"removedPeerIds"`
 	AppliedIndex   uint64     `json:"appliedIndex"`
 	SnapshotIndex  uint64     `json:"snapshotIndex"`
+
+	SnapshotTransfers []*SnapshotTransfer `json:"snapshotTransfers"` // Snapshots being sent to lagging followers
 }
 
 type PublicRaftAPI struct {
@@ -22,6 +26,14 @@ func (s *PublicRaftAPI) Role() string {
 	return s.raftService.raftProtocolManager.NodeInfo().Role
 }
 
+// Leader returns the raft ID and RPC endpoint of the current leader.
+func (s *PublicRaftAPI) Leader() (*LeaderInfo, error) {
+	if leader := s.raftService.raftProtocolManager.leader(); leader != nil {
+		return leader, nil
+	}
+	return nil, errors.New("no raft leader")
+}
+
 func (s *PublicRaftAPI) AddPeer(enodeId string) (uint16, error) {
 	return s.raftService.raftProtocolManager.ProposeNewPeer(enodeId)
 }
@@ -29,3 +41,17 @@ func (s *PublicRaftAPI) AddPeer(enodeId string) (uint16, error) {
 func (s *PublicRaftAPI) RemovePeer(raftId uint16) {
 	s.raftService.raftProtocolManager.ProposePeerRemoval(raftId)
 }
+
+func (s *PublicRaftAPI) UpdatePeer(raftId uint16, enodeId string) error {
+	return s.raftService.raftProtocolManager.ProposePeerUpdate(raftId, enodeId)
+}
+
+// Enode returns the enode URL other members add or update this node with, with
+// the advertised p2p address and raft port.
+func (s *PublicRaftAPI) Enode() string {
+	return s.raftService.raftProtocolManager.enode()
+}
+
+func (s *PublicRaftAPI) ClusterHistory() ([]*ConfChangeRecord, error) {
+	return s.raftService.raftProtocolManager.ConfChangeHistory()
+}
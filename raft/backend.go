@@ -32,9 +32,12 @@ type RaftService struct {
 	// we need an event mux to instantiate the blockchain
 	eventMux *event.TypeMux
 	minter   *minter
+
+	backpressure *backpressure
+	txRouter     *txRouter
 }
 
-func New(ctx *node.ServiceContext, chainConfig *core.ChainConfig, raftId uint16, raftPort uint16, joinExisting bool, blockTime time.Duration, e *eth.Ethereum, startPeers []*discover.Node, datadir string) (*RaftService, error) {
+func New(ctx *node.ServiceContext, chainConfig *core.ChainConfig, raftId uint16, raftPort uint16, joinExisting bool, blockTime, minTimeIncrement time.Duration, maxSpeculativeDepth int, e *eth.Ethereum, startPeers []*discover.Node, datadir string, backpressureHigh, backpressureLow uint64, compress bool, snapshotRate uint64, followerWrites, rpcEndpoint, advertiseAddr string) (*RaftService, error) {
 	service := &RaftService{
 		eventMux:       ctx.EventMux,
 		chainDb:        e.ChainDb(),
@@ -45,13 +48,32 @@ func New(ctx *node.ServiceContext, chainConfig *core.ChainConfig, raftId uint16,
 		startPeers:     startPeers,
 	}
 
-	service.minter = newMinter(chainConfig, service, blockTime)
+	service.minter = newMinter(chainConfig, service, blockTime, minTimeIncrement, maxSpeculativeDepth)
 
 	var err error
-	if service.raftProtocolManager, err = NewProtocolManager(raftId, raftPort, service.blockchain, service.eventMux, startPeers, joinExisting, datadir, service.minter, service.downloader); err != nil {
+	if service.raftProtocolManager, err = NewProtocolManager(raftId, raftPort, service.blockchain, service.eventMux, startPeers, joinExisting, datadir, service.minter, service.downloader, compress, snapshotRate, rpcEndpoint, advertiseAddr, ctx.DataKey()); err != nil {
 		return nil, err
 	}
 
+	// Reject locally submitted transactions while we're behind on applying the
+	// raft log, rather than letting the backlog grow without bound.
+	service.backpressure = newBackpressure(backpressureHigh, backpressureLow, service.raftProtocolManager.unappliedEntries)
+	e.SetTxAdmission(service.backpressure.check)
+
+	// Transactions submitted to a follower can be sent on to the leader, saving
+	// RPC clients the hop through the eth gossip.
+	service.txRouter = newTxRouter(followerWrites, service.raftProtocolManager)
+	e.SetTxRouter(service.txRouter.route)
+
+	// Raft log entries we don't get applied mean the chain is stalled even if
+	// no eth peer is ahead, and a stalled leader can hand over minting.
+	pm := service.raftProtocolManager
+	e.SetConsensusWatchdog(func() bool { return pm.unappliedEntries() > 0 }, pm.stepDown)
+
+	// Blocks are only announced through the raft log, so a follower behind on
+	// applying it is syncing even if no eth peer is ahead.
+	e.SetSyncTarget(pm.committedHead)
+
 	return service, nil
 }
 
@@ -66,7 +88,9 @@ func (service *RaftService) TxPool() *core.TxPool              { return service.
 
 // node.Service interface methods:
 
-func (service *RaftService) Protocols() []p2p.Protocol { return []p2p.Protocol{} }
+func (service *RaftService) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{service.raftProtocolManager.capabilityProtocol()}
+}
 func (service *RaftService) APIs() []rpc.API {
 	return []rpc.API{
 		{
@@ -78,6 +102,56 @@ func (service *RaftService) APIs() []rpc.API {
 	}
 }
 
+// ReportNodeInfo implements node.NodeInfoReporter. The minter is the raft
+// leader, all other nodes are followers.
+func (service *RaftService) ReportNodeInfo(info *node.QuorumNodeInfo) {
+	pm := service.raftProtocolManager
+	raftInfo := pm.NodeInfo()
+	role := "follower"
+	if raftInfo.Role == "minter" {
+		role = "leader"
+	}
+	info.Consensus = map[string]interface{}{
+		"engine":      "raft",
+		"raftId":      pm.raftId,
+		"role":        role,
+		"minter":      raftInfo.Role == "minter",
+		"clusterSize": raftInfo.ClusterSize,
+	}
+}
+
+// ReportStatus implements node.StatusReporter, reporting the raft role and
+// log progress of the node.
+func (service *RaftService) ReportStatus(status *node.NodeStatus) {
+	pm := service.raftProtocolManager
+	raftInfo := pm.NodeInfo()
+	consensus := map[string]interface{}{
+		"engine":        "raft",
+		"raftId":        pm.raftId,
+		"role":          raftInfo.Role,
+		"clusterSize":   raftInfo.ClusterSize,
+		"appliedIndex":  raftInfo.AppliedIndex,
+		"snapshotIndex": raftInfo.SnapshotIndex,
+		"unapplied":     pm.unappliedEntries(),
+	}
+	if len(raftInfo.SnapshotTransfers) > 0 {
+		consensus["snapshotTransfers"] = raftInfo.SnapshotTransfers
+	}
+	status.Consensus = consensus
+}
+
+// ReportTopology implements node.TopologyReporter, adding the raft cluster
+// members and the health of the raft transport to them.
+func (service *RaftService) ReportTopology(topology *node.Topology) {
+	service.raftProtocolManager.reportTopology(topology)
+}
+
+// SetMaintenance implements node.MaintenanceHandler. A node in maintenance
+// keeps applying the raft log, but hands over the leadership and minting.
+func (service *RaftService) SetMaintenance(enabled bool) {
+	service.raftProtocolManager.setMaintenance(enabled)
+}
+
 // Start implements node.Service, starting the background data propagation thread
 // of the protocol.
 func (service *RaftService) Start(p2pServer *p2p.Server) error {
@@ -90,6 +164,7 @@ func (service *RaftService) Start(p2pServer *p2p.Server) error {
 func (service *RaftService) Stop() error {
 	service.blockchain.Stop()
 	service.raftProtocolManager.Stop()
+	service.txRouter.close()
 	service.minter.stop()
 	service.eventMux.Stop()
 
@@ -0,0 +1,76 @@
+package raft
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var backpressureRejectMeter = metrics.NewMeter("raft/backpressure/rejected")
+
+// NodeBusyError is returned to RPC clients submitting transactions while the
+// node has too many raft log entries left to apply. The request may be retried
+// once the backlog drains.
+type NodeBusyError struct {
+	Unapplied uint64
+}
+
+func (e *NodeBusyError) Error() string {
+	return fmt.Sprintf("node busy: %d raft log entries not yet applied, retry later", e.Unapplied)
+}
+
+// ErrorCode implements rpc.Error.
+func (e *NodeBusyError) ErrorCode() int { return -32005 }
+
+// ErrorData implements rpc.DataError.
+func (e *NodeBusyError) ErrorData() interface{} {
+	return map[string]interface{}{"reason": "nodeBusy", "unapplied": e.Unapplied}
+}
+
+// backpressure rejects new transactions once the number of unapplied raft log
+// entries reaches the high watermark, and accepts them again after it dropped
+// below the low watermark.
+type backpressure struct {
+	high, low uint64
+	unapplied func() uint64
+
+	mu   sync.Mutex
+	busy bool
+}
+
+// newBackpressure creates a backpressure check. A high watermark of 0 disables
+// it, a low watermark of 0 defaults to half of the high one.
+func newBackpressure(high, low uint64, unapplied func() uint64) *backpressure {
+	if low == 0 || low > high {
+		low = high / 2
+	}
+	return &backpressure{high: high, low: low, unapplied: unapplied}
+}
+
+// check returns a NodeBusyError while the node is applying a backlog.
+func (b *backpressure) check() error {
+	if b.high == 0 {
+		return nil
+	}
+	unapplied := b.unapplied()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !b.busy && unapplied >= b.high:
+		glog.V(logger.Warn).Infof("%d raft log entries not yet applied, rejecting new transactions", unapplied)
+		b.busy = true
+	case b.busy && unapplied < b.low:
+		glog.V(logger.Info).Infof("raft backlog drained to %d entries, accepting new transactions", unapplied)
+		b.busy = false
+	}
+	if b.busy {
+		backpressureRejectMeter.Mark(1)
+		return &NodeBusyError{Unapplied: unapplied}
+	}
+	return nil
+}
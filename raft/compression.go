@@ -0,0 +1,158 @@
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/golang/snappy"
+	"golang.org/x/net/context"
+)
+
+// Raft log entries carrying blocks are RLP-encoded, so they always start with
+// an RLP list prefix (>= 0xc0). Compressed entries start with a byte below it
+// instead, marking the format they're compressed in. Entries in a format this
+// version doesn't know fail with errUnsupportedEntryFormat, rather than being
+// taken for a block.
+const (
+	snappyEntryPrefix = 0x01
+	rlpListPrefix     = 0xc0
+)
+
+var errUnsupportedEntryFormat = errors.New("raft entry format not supported by this version, upgrade the node")
+
+// Nodes that can decode compressed entries advertise the raft protocol over
+// p2p. Nodes predating compression don't advertise any raft protocol at all.
+var compressionCap = p2p.Cap{Name: protocolName, Version: uint(protocolVersion)}
+
+// The raft p2p protocol exists so that peers can discover which capabilities we
+// support during the p2p handshake. Its only message advertises the RPC endpoint
+// of a node, which nodes predating it discard.
+func (pm *ProtocolManager) capabilityProtocol() p2p.Protocol {
+	return p2p.Protocol{
+		Name:    compressionCap.Name,
+		Version: compressionCap.Version,
+		Length:  1,
+		Run:     pm.exchangeRPCEndpoints,
+	}
+}
+
+// Compression is activated for the whole cluster by an activation entry in the
+// raft log, holding this byte followed by the format entries are compressed in
+// from then on. The minter proposes it once every member
+// advertises support for compressed entries, and every node compresses the
+// entries it proposes from applying it onwards, whoever is connected at the
+// time. Nodes persist the activation, as the entry is compacted away by
+// snapshots. Nodes joining later from a snapshot don't know about it, and
+// propose it again once they mint.
+const compressionActivationEntry = 0x02
+
+// Least time between proposals of the activation entry, in case one is lost to
+// a leader change.
+const compressionActivationRetry = 10 * time.Second
+
+// Encodes a raft entry, compressing it if enabled and compression has been
+// activated for the cluster.
+func (pm *ProtocolManager) encodeEntry(data []byte) []byte {
+	if !pm.compress || !pm.isCompressionActive() {
+		return data
+	}
+	compressed := snappy.Encode(nil, data)
+	if len(compressed)+1 >= len(data) {
+		return data
+	}
+	glog.V(logger.Detail).Infof("compressed raft entry from %d to %d bytes", len(data), len(compressed)+1)
+
+	return append([]byte{snappyEntryPrefix}, compressed...)
+}
+
+// Decodes a raft entry created by encodeEntry.
+func decodeEntry(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] >= rlpListPrefix {
+		return data, nil
+	}
+	if data[0] != snappyEntryPrefix {
+		return nil, fmt.Errorf("%v: format 0x%02x", errUnsupportedEntryFormat, data[0])
+	}
+	decoded, err := snappy.Decode(nil, data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress raft entry: %v", err)
+	}
+	return decoded, nil
+}
+
+// Returns whether a raft entry is the activation entry of compression.
+func isCompressionActivation(data []byte) bool {
+	return len(data) == 2 && data[0] == compressionActivationEntry
+}
+
+func (pm *ProtocolManager) isCompressionActive() bool {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	return pm.compressionActive
+}
+
+// Proposes the activation entry if compression is enabled but not active yet,
+// and every cluster member is able to decode compressed entries.
+func (pm *ProtocolManager) maybeActivateCompression() {
+	if !pm.compress || pm.isCompressionActive() || time.Since(pm.activationProposed) < compressionActivationRetry {
+		return
+	}
+	if !pm.clusterSupportsCompression() {
+		return
+	}
+	glog.V(logger.Info).Infoln("all raft peers support compressed entries, proposing to activate compression")
+
+	pm.activationProposed = time.Now()
+	pm.rawNode().Propose(context.TODO(), []byte{compressionActivationEntry, snappyEntryPrefix})
+}
+
+// Applies the activation entry of compression, failing if it activates a
+// format this version can't decode.
+func (pm *ProtocolManager) activateCompression(entry []byte) {
+	if entry[1] != snappyEntryPrefix {
+		glog.Fatalf("compression of raft entries activated in format 0x%02x: %v", entry[1], errUnsupportedEntryFormat)
+	}
+	pm.mu.Lock()
+	active := pm.compressionActive
+	pm.compressionActive = true
+	pm.mu.Unlock()
+
+	if !active {
+		glog.V(logger.Info).Infoln("raft entry compression activated for the cluster")
+		pm.writeCompressionActive()
+	}
+}
+
+// Returns whether all cluster members are connected over p2p and advertise
+// support for compressed entries. Members we can't reach may run an older
+// version, so compression isn't activated until they're back.
+func (pm *ProtocolManager) clusterSupportsCompression() bool {
+	if pm.p2pServer == nil {
+		return false
+	}
+
+	capable := make(map[discover.NodeID]bool)
+	for _, peer := range pm.p2pServer.Peers() {
+		for _, cap := range peer.Caps() {
+			if cap.Name == compressionCap.Name && cap.Version >= compressionCap.Version {
+				capable[peer.ID()] = true
+			}
+		}
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	for _, peer := range pm.peers {
+		if !capable[peer.p2pNode.ID] {
+			return false
+		}
+	}
+	return true
+}
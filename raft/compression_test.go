@@ -0,0 +1,133 @@
+package raft
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// testBlockEntry returns the entry of a block with repetitive transactions,
+// which compresses well.
+func testBlockEntry(t *testing.T) ([]byte, *types.Block) {
+	var txs []*types.Transaction
+	for i := 0; i < 20; i++ {
+		txs = append(txs, types.NewTransaction(uint64(i), common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), make([]byte, 256)))
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil)
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data, block
+}
+
+func decodeTestBlock(t *testing.T, entry []byte) *types.Block {
+	data, err := decodeEntry(entry)
+	if err != nil {
+		t.Fatalf("failed to decode entry: %v", err)
+	}
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(data, block); err != nil {
+		t.Fatalf("failed to decode block: %v", err)
+	}
+	return block
+}
+
+func TestEntryRoundTrip(t *testing.T) {
+	data, block := testBlockEntry(t)
+
+	// Entries are only compressed once compression is active for the cluster
+	for _, pm := range []*ProtocolManager{{}, {compress: true}, {compressionActive: true}} {
+		if entry := pm.encodeEntry(data); !bytes.Equal(entry, data) {
+			t.Errorf("compress %v, active %v: entry compressed", pm.compress, pm.compressionActive)
+		}
+	}
+	pm := &ProtocolManager{compress: true, compressionActive: true}
+	entry := pm.encodeEntry(data)
+	if entry[0] != snappyEntryPrefix || len(entry) >= len(data) {
+		t.Fatalf("entry not compressed: %d bytes, block is %d", len(entry), len(data))
+	}
+	if have := decodeTestBlock(t, entry); have.Hash() != block.Hash() {
+		t.Errorf("block hash mismatch after round trip: have %x, want %x", have.Hash(), block.Hash())
+	}
+	if isCompressionActivation(entry) {
+		t.Errorf("compressed entry taken for the activation entry")
+	}
+
+	// Entries proposed before compression, or by older nodes, are plain RLP
+	if have := decodeTestBlock(t, data); have.Hash() != block.Hash() {
+		t.Errorf("block hash mismatch of legacy entry: have %x, want %x", have.Hash(), block.Hash())
+	}
+	if isCompressionActivation(data) {
+		t.Errorf("legacy entry taken for the activation entry")
+	}
+
+	// Entries which don't shrink are left alone
+	small := []byte{0xc1, 0x80}
+	if entry := pm.encodeEntry(small); !bytes.Equal(entry, small) {
+		t.Errorf("small entry changed to %x", entry)
+	}
+	if _, err := decodeEntry([]byte{snappyEntryPrefix, 0xff, 0xff}); err == nil {
+		t.Errorf("corrupt compressed entry decoded")
+	}
+}
+
+// Tests that entries compressed in a format of a later version are rejected
+// rather than taken for blocks.
+func TestEntryUnsupportedFormat(t *testing.T) {
+	data, _ := testBlockEntry(t)
+	pm := &ProtocolManager{compress: true, compressionActive: true}
+	entry := pm.encodeEntry(data)
+
+	entry[0] = 0x03
+	if _, err := decodeEntry(entry); err == nil || !strings.Contains(err.Error(), errUnsupportedEntryFormat.Error()) {
+		t.Errorf("entry in unknown format 0x03 decoded: %v", err)
+	}
+	if isCompressionActivation([]byte{compressionActivationEntry}) {
+		t.Errorf("activation entry without a format accepted")
+	}
+}
+
+func TestCompressionActivationPersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raft-compression-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := openQuorumRaftDb(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm := &ProtocolManager{compress: true, quorumRaftDb: db}
+	pm.loadCompressionActive()
+	if pm.isCompressionActive() {
+		t.Fatalf("compression active in a new cluster")
+	}
+	if !isCompressionActivation([]byte{compressionActivationEntry, snappyEntryPrefix}) {
+		t.Fatalf("activation entry not recognized")
+	}
+	pm.activateCompression([]byte{compressionActivationEntry, snappyEntryPrefix})
+	if !pm.isCompressionActive() {
+		t.Fatalf("compression not active after applying the activation entry")
+	}
+	db.Close()
+
+	// The activation outlives restarts, whoever is connected then
+	if db, err = openQuorumRaftDb(dir, nil); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	pm = &ProtocolManager{compress: true, quorumRaftDb: db}
+	pm.loadCompressionActive()
+	if !pm.isCompressionActive() {
+		t.Errorf("compression activation not persisted")
+	}
+}
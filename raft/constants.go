@@ -1,6 +1,8 @@
 package raft
 
 import (
+	"time"
+
 	etcdRaft "github.com/coreos/etcd/raft"
 )
 
@@ -28,8 +30,13 @@ const (
 	peerUrlKeyPrefix = "peerUrl-"
 
 	chainExtensionMessage = "Successfully extended chain"
+
+	// Longest wait between attempts to apply a block whose private payloads
+	// couldn't be retrieved
+	maxPayloadRetryDelay = 30 * time.Second
 )
 
 var (
-	appliedDbKey = []byte("applied")
+	appliedDbKey     = []byte("applied")
+	compressionDbKey = []byte("compression") // Format of compressed entries, present once compression has been activated
 )
@@ -0,0 +1,96 @@
+package raft
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/coreos/etcd/raft/raftpb"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var errWALDecrypt = errors.New("failed to decrypt raft data, it was written in plaintext or with another data key")
+
+// Encrypts the data of raft log entries and snapshots before they're written
+// to disk, so the WAL and snapshot files hold no plaintext blocks. Entries are
+// only encrypted at rest: raft storage and peers see them in plaintext.
+//
+// A nil cipher is used when the node doesn't encrypt its data, and passes all
+// data through unchanged.
+type walCipher struct {
+	aead cipher.AEAD
+}
+
+// Creates a cipher for the raft files from the data key of the node. The key
+// is derived from the data key to keep it apart from the database encryption.
+func newWALCipher(dataKey []byte) (*walCipher, error) {
+	if dataKey == nil {
+		return nil, nil
+	}
+	block, err := aes.NewCipher(crypto.Keccak256(dataKey, []byte("raft wal")))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &walCipher{aead: aead}, nil
+}
+
+// Encrypts data behind a random nonce. Empty data stays empty, as raft appends
+// empty entries of its own which are recognized by their length.
+func (c *walCipher) seal(data []byte) []byte {
+	if c == nil || len(data) == 0 {
+		return data
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		panic("failed to read random nonce: " + err.Error())
+	}
+	return c.aead.Seal(nonce, nonce, data, nil)
+}
+
+// Decrypts data sealed by seal.
+func (c *walCipher) open(data []byte) ([]byte, error) {
+	if c == nil || len(data) == 0 {
+		return data, nil
+	}
+	size := c.aead.NonceSize()
+	if len(data) < size+c.aead.Overhead() {
+		return nil, errWALDecrypt
+	}
+	plain, err := c.aead.Open(nil, data[:size], data[size:], nil)
+	if err != nil {
+		return nil, errWALDecrypt
+	}
+	return plain, nil
+}
+
+// Returns copies of the entries with their data encrypted, leaving the
+// entries themselves untouched for raft storage.
+func (c *walCipher) sealEntries(entries []raftpb.Entry) []raftpb.Entry {
+	if c == nil {
+		return entries
+	}
+	sealed := make([]raftpb.Entry, len(entries))
+	for i, entry := range entries {
+		sealed[i] = entry
+		sealed[i].Data = c.seal(entry.Data)
+	}
+	return sealed
+}
+
+// Decrypts the data of entries read from the WAL in place.
+func (c *walCipher) openEntries(entries []raftpb.Entry) error {
+	for i := range entries {
+		data, err := c.open(entries[i].Data)
+		if err != nil {
+			return err
+		}
+		entries[i].Data = data
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package raft
 
 import (
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -22,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/logger/glog"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/private"
 	"github.com/ethereum/go-ethereum/rlp"
 
 	"github.com/coreos/etcd/etcdserver/stats"
@@ -43,16 +46,25 @@ type ProtocolManager struct {
 	bootstrapNodes []*discover.Node
 	raftId         uint16
 	raftPort       uint16
+	compress       bool         // Whether to compress block entries once activated for the cluster
+	snapshotRate   uint64       // Bytes per second snapshots are streamed to lagging followers at, 0 = unlimited
+	rpcEndpoint    string       // RPC endpoint advertised to the peers, for forwarding writes to the leader
+	advertise      *net.TCPAddr // Raft endpoint the peers are told to dial, nil if the cluster address
 
 	// Local peer state (protected by mu vs concurrent access via JS)
 	address       *Address
 	role          int    // Role: minter or verifier
 	appliedIndex  uint64 // The index of the last-applied raft entry
 	snapshotIndex uint64 // The index of the latest snapshot.
+	maintenance   bool   // Whether to hand over the leadership whenever we have it
+
+	compressionActive  bool      // Whether the activation entry of compression has been applied
+	activationProposed time.Time // When we last proposed the activation entry, only used by serveLocalProposals
 
 	// Remote peer state (protected by mu vs concurrent access via JS)
 	peers        map[uint16]*Peer
-	removedPeers *set.Set // *Permanently removed* peers
+	removedPeers *set.Set                   // *Permanently removed* peers
+	rpcEndpoints map[discover.NodeID]string // RPC endpoints advertised by connected peers
 
 	// P2P transport
 	p2pServer *p2p.Server // Initialized in start()
@@ -75,6 +87,8 @@ type ProtocolManager struct {
 	transport     *rafthttp.Transport
 	httpstopc     chan struct{}
 	httpdonec     chan struct{}
+	httprebindc   chan uint16 // Ports to move the transport listener to
+	snapshots     *snapshotSender // Streams snapshots to lagging followers off the event loop
 
 	// Raft snapshotting
 	snapshotter *snap.Snapshotter
@@ -82,8 +96,9 @@ type ProtocolManager struct {
 	confState   raftpb.ConfState
 
 	// Raft write-ahead log
-	waldir string
-	wal    *wal.WAL
+	waldir    string
+	wal       *wal.WAL
+	walCipher *walCipher // Encrypts entries and snapshots on disk, nil if not
 
 	// Storage
 	quorumRaftDb *leveldb.DB             // Persistent storage for last-applied raft index
@@ -94,7 +109,7 @@ type ProtocolManager struct {
 // Public interface
 //
 
-func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*discover.Node, joinExisting bool, datadir string, minter *minter, downloader *downloader.Downloader) (*ProtocolManager, error) {
+func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockChain, mux *event.TypeMux, bootstrapNodes []*discover.Node, joinExisting bool, datadir string, minter *minter, downloader *downloader.Downloader, compress bool, snapshotRate uint64, rpcEndpoint, advertiseAddr string, dataKey []byte) (*ProtocolManager, error) {
 	waldir := fmt.Sprintf("%s/raft-wal", datadir)
 	snapdir := fmt.Sprintf("%s/raft-snap", datadir)
 	quorumRaftDbLoc := fmt.Sprintf("%s/quorum-raft-state", datadir)
@@ -103,6 +118,7 @@ func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockCh
 		bootstrapNodes:      bootstrapNodes,
 		peers:               make(map[uint16]*Peer),
 		removedPeers:        set.New(),
+		rpcEndpoints:        make(map[discover.NodeID]string),
 		joinExisting:        joinExisting,
 		blockchain:          blockchain,
 		eventMux:            mux,
@@ -110,22 +126,38 @@ func NewProtocolManager(raftId uint16, raftPort uint16, blockchain *core.BlockCh
 		confChangeProposalC: make(chan raftpb.ConfChange),
 		httpstopc:           make(chan struct{}),
 		httpdonec:           make(chan struct{}),
+		httprebindc:         make(chan uint16),
 		waldir:              waldir,
 		snapdir:             snapdir,
 		snapshotter:         snap.New(snapdir),
 		raftId:              raftId,
 		raftPort:            raftPort,
+		compress:            compress,
+		snapshotRate:        snapshotRate,
+		rpcEndpoint:         rpcEndpoint,
 		quitSync:            make(chan struct{}),
 		raftStorage:         etcdRaft.NewMemoryStorage(),
 		minter:              minter,
 		downloader:          downloader,
 	}
 
-	if db, err := openQuorumRaftDb(quorumRaftDbLoc); err != nil {
+	if advertiseAddr != "" {
+		addr, err := net.ResolveTCPAddr("tcp4", advertiseAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid raft advertised address %q: %v", advertiseAddr, err)
+		}
+		manager.advertise = addr
+	}
+	if db, err := openQuorumRaftDb(quorumRaftDbLoc, dataKey); err != nil {
 		return nil, err
 	} else {
 		manager.quorumRaftDb = db
 	}
+	if cipher, err := newWALCipher(dataKey); err != nil {
+		return nil, err
+	} else {
+		manager.walCipher = cipher
+	}
 
 	return manager, nil
 }
@@ -134,6 +166,9 @@ func (pm *ProtocolManager) Start(p2pServer *p2p.Server) {
 	glog.V(logger.Info).Infoln("starting raft protocol handler")
 
 	pm.p2pServer = p2pServer
+	if pm.advertise != nil && !pm.advertise.IP.Equal(p2pServer.Self().IP) {
+		glog.V(logger.Warn).Infof("Advertised raft host %v differs from the p2p host %v, peers reach both at the enode's host", pm.advertise.IP, p2pServer.Self().IP)
+	}
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	pm.startRaft()
 	go pm.minedBroadcastLoop()
@@ -157,6 +192,9 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.minedBlockSub.Unsubscribe()
 
+	if pm.snapshots != nil {
+		pm.snapshots.stop()
+	}
 	if pm.transport != nil {
 		pm.transport.Stop()
 	}
@@ -196,6 +234,11 @@ func (pm *ProtocolManager) NodeInfo() *RaftNodeInfo {
 		peerIdx += 1
 	}
 
+	var snapshotTransfers []*SnapshotTransfer
+	if pm.snapshots != nil {
+		snapshotTransfers = pm.snapshots.progress()
+	}
+
 	removedPeerIfaces := pm.removedPeers.List()
 	removedPeerIds := make([]uint16, len(removedPeerIfaces))
 	for i, removedIface := range removedPeerIfaces {
@@ -214,6 +257,8 @@ func (pm *ProtocolManager) NodeInfo() *RaftNodeInfo {
 		RemovedPeerIds: removedPeerIds,
 		AppliedIndex:   pm.appliedIndex,
 		SnapshotIndex:  pm.snapshotIndex,
+
+		SnapshotTransfers: snapshotTransfers,
 	}
 }
 
@@ -315,6 +360,67 @@ func (pm *ProtocolManager) ProposeNewPeer(enodeId string) (uint16, error) {
 	return raftId, nil
 }
 
+// enode returns the enode URL of this node peers should reach it at, with the
+// advertised p2p address and raft port.
+func (pm *ProtocolManager) enode() string {
+	self := pm.p2pServer.Self()
+	node := discover.NewNode(self.ID, self.IP, 0, self.TCP)
+	pm.mu.RLock()
+	node.RaftPort = pm.raftPort
+	pm.mu.RUnlock()
+	if pm.advertise != nil {
+		node.RaftPort = uint16(pm.advertise.Port)
+	}
+	return node.String()
+}
+
+func (pm *ProtocolManager) ProposePeerUpdate(raftId uint16, enodeId string) error {
+	node, err := discover.ParseNode(enodeId)
+	if err != nil {
+		return err
+	}
+
+	if len(node.IP) != 4 {
+		return fmt.Errorf("expected IPv4 address (with length 4), but got IP of length %v", len(node.IP))
+	}
+
+	if !node.HasRaftPort() {
+		return fmt.Errorf("enodeId is missing raftport querystring parameter: %v", enodeId)
+	}
+
+	if pm.isRaftIdRemoved(raftId) {
+		return fmt.Errorf("raft ID %v has been removed from the cluster", raftId)
+	}
+
+	pm.mu.RLock()
+	var current *Address
+	if raftId == pm.raftId {
+		current = pm.address
+	} else if peer := pm.peers[raftId]; peer != nil {
+		current = peer.address
+	}
+	pm.mu.RUnlock()
+
+	if current == nil {
+		return fmt.Errorf("raft ID %v is not a member of the cluster", raftId)
+	}
+
+	// The node keeps its raft identity, so it must keep its node key as well.
+	if current.nodeId != node.ID {
+		return fmt.Errorf("enode ID %v does not match the node ID of raft peer %v", node.ID, raftId)
+	}
+
+	address := newAddress(raftId, node.RaftPort, node)
+
+	pm.confChangeProposalC <- raftpb.ConfChange{
+		Type:    raftpb.ConfChangeUpdateNode,
+		NodeID:  uint64(raftId),
+		Context: address.toBytes(),
+	}
+
+	return nil
+}
+
 func (pm *ProtocolManager) ProposePeerRemoval(raftId uint16) {
 	pm.confChangeProposalC <- raftpb.ConfChange{
 		Type:   raftpb.ConfChangeRemoveNode,
@@ -358,6 +464,7 @@ func (pm *ProtocolManager) ReportSnapshot(id uint64, status etcdRaft.SnapshotSta
 	} else if status == etcdRaft.SnapshotFinish {
 		glog.V(logger.Info).Infof("finished sending snapshot to raft peer %v", id)
 	}
+	pm.snapshots.finish(id, status)
 
 	pm.rawNode().ReportSnapshot(id, status)
 }
@@ -368,12 +475,13 @@ func (pm *ProtocolManager) ReportSnapshot(id uint64, status etcdRaft.SnapshotSta
 
 func (pm *ProtocolManager) startRaft() {
 	if !fileutil.Exist(pm.snapdir) {
-		if err := os.Mkdir(pm.snapdir, 0750); err != nil {
+		if err := os.MkdirAll(pm.snapdir, 0750); err != nil {
 			glog.Fatalf("cannot create dir for snapshot (%v)", err)
 		}
 	}
 	walExisted := wal.Exist(pm.waldir)
 	lastAppliedIndex := pm.loadAppliedIndex()
+	pm.loadCompressionActive()
 
 	ss := &stats.ServerStats{}
 	ss.Initialize()
@@ -386,6 +494,8 @@ func (pm *ProtocolManager) startRaft() {
 		ErrorC:      make(chan error),
 	}
 	pm.transport.Start()
+	pm.snapshots = newSnapshotSender(pm.snapshotRate, pm.transport.Send, pm.ReportSnapshot)
+	pm.snapshots.start()
 
 	// We load the snapshot to connect to prev peers before replaying the WAL,
 	// which typically goes further into the future than the snapshot.
@@ -412,6 +522,14 @@ func (pm *ProtocolManager) startRaft() {
 		}
 	}
 
+	if lost := pm.blockchain.RepairedHead(); lost != nil && lastAppliedIndex > pm.snapshotIndex {
+		// The chain was rewound on startup as the state of its head was missing.
+		// Blocks up to the snapshot were synced when applying it, re-apply the
+		// entries since, skipping the blocks the chain still has.
+		glog.V(logger.Warn).Infof("chain was rewound from block %d, rolling back applied index from %v to snapshot index %v", lost.Number, lastAppliedIndex, pm.snapshotIndex)
+		lastAppliedIndex = pm.snapshotIndex
+	}
+
 	// NOTE: cockroach sets this to false for now until they've "worked out the
 	//       bugs"
 	enablePreVote := true
@@ -490,31 +608,79 @@ func (pm *ProtocolManager) setLocalAddress(addr *Address) {
 	// By setting `URLs` on the raft transport, we advertise our URL (in an HTTP
 	// header) to any recipient. This is necessary for a newcomer to the cluster
 	// to be able to accept a snapshot from us to bootstrap them.
-	if urls, err := raftTypes.NewURLs([]string{raftUrl(addr)}); err == nil {
+	endpoint := raftUrl(addr)
+	if pm.advertise != nil {
+		if advertised := fmt.Sprintf("http://%s", pm.advertise); advertised != endpoint {
+			glog.V(logger.Warn).Infof("Raft peers dial this node at %s rather than at the advertised %s, update its address with raft.updatePeer(%d, raft.enode)", endpoint, advertised, addr.raftId)
+			endpoint = advertised
+		}
+	}
+	if urls, err := raftTypes.NewURLs([]string{endpoint}); err == nil {
 		pm.transport.URLs = urls
 	} else {
 		panic(fmt.Sprintf("error: could not create URL from local address: %v", addr))
 	}
 }
 
+// Serves the raft transport on the raft port until stopped, moving to another
+// port when the address of this node is updated.
 func (pm *ProtocolManager) serveRaft() {
-	urlString := fmt.Sprintf("http://0.0.0.0:%d", pm.raftPort)
-	url, err := url.Parse(urlString)
-	if err != nil {
-		glog.Fatalf("Failed parsing URL (%v)", err)
+	defer close(pm.httpdonec)
+
+	pm.mu.RLock()
+	port := pm.raftPort
+	pm.mu.RUnlock()
+
+	for {
+		urlString := fmt.Sprintf("http://0.0.0.0:%d", port)
+		url, err := url.Parse(urlString)
+		if err != nil {
+			glog.Fatalf("Failed parsing URL (%v)", err)
+		}
+
+		stopc := make(chan struct{})
+		listener, err := newStoppableListener(url.Host, stopc)
+		if err != nil {
+			glog.Fatalf("Failed to listen rafthttp (%v)", err)
+		}
+		errc := make(chan error, 1)
+		go func() {
+			errc <- (&http.Server{Handler: pm.transport.Handler()}).Serve(listener)
+		}()
+
+		select {
+		case <-pm.httpstopc:
+			close(stopc)
+			<-errc
+			return
+		case port = <-pm.httprebindc:
+			close(stopc)
+			<-errc
+			glog.V(logger.Info).Infof("moving the raft transport to port %d", port)
+		case err := <-errc:
+			glog.Fatalf("Failed to serve rafthttp (%v)", err)
+		}
 	}
+}
 
-	listener, err := newStoppableListener(url.Host, pm.httpstopc)
-	if err != nil {
-		glog.Fatalf("Failed to listen rafthttp (%v)", err)
+// Moves the raft transport listener to the raft port of an updated address of
+// this node, so peers dialing the new address reach it. With an advertised
+// endpoint, peers dial that one, which is forwarded to the port we listen on.
+func (pm *ProtocolManager) rebindRaft(address *Address) {
+	if pm.advertise != nil {
+		return
 	}
-	err = (&http.Server{Handler: pm.transport.Handler()}).Serve(listener)
-	select {
-	case <-pm.httpstopc:
-	default:
-		glog.Fatalf("Failed to serve rafthttp (%v)", err)
+	pm.mu.Lock()
+	moved := pm.raftPort != address.raftPort
+	pm.raftPort = address.raftPort
+	pm.mu.Unlock()
+
+	if moved {
+		select {
+		case pm.httprebindc <- address.raftPort:
+		case <-pm.httpstopc:
+		}
 	}
-	close(pm.httpdonec)
 }
 
 func (pm *ProtocolManager) handleRoleChange(roleC <-chan interface{}) {
@@ -537,8 +703,13 @@ func (pm *ProtocolManager) handleRoleChange(roleC <-chan interface{}) {
 
 			pm.mu.Lock()
 			pm.role = intRole
+			maintenance := pm.maintenance
 			pm.mu.Unlock()
 
+			if intRole == minterRole && maintenance {
+				go pm.handOverLeadership()
+			}
+
 		case <-pm.quitSync:
 			return
 		}
@@ -581,7 +752,8 @@ func (pm *ProtocolManager) serveLocalProposals() {
 			r.Read(buffer)
 
 			// blocks until accepted by the raft state machine
-			pm.rawNode().Propose(context.TODO(), buffer)
+			pm.maybeActivateCompression()
+			pm.rawNode().Propose(context.TODO(), pm.encodeEntry(buffer))
 		case cc, ok := <-pm.confChangeProposalC:
 			if !ok {
 				glog.V(logger.Info).Infoln("error: read from confChangeC failed")
@@ -589,7 +761,7 @@ func (pm *ProtocolManager) serveLocalProposals() {
 			}
 
 			confChangeCount++
-			cc.ID = confChangeCount
+			cc.ID = uint64(pm.raftId)<<confChangeProposerShift | confChangeCount
 			pm.rawNode().ProposeConfChange(context.TODO(), cc)
 		case <-pm.quitSync:
 			return
@@ -638,6 +810,27 @@ func (pm *ProtocolManager) addPeer(address *Address) {
 	pm.peers[raftId] = &Peer{address, p2pNode}
 }
 
+// Points the p2p and raft transport connections for an existing peer to its
+// new address, keeping its raft ID.
+func (pm *ProtocolManager) updatePeer(address *Address) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	raftId := address.raftId
+	peer := pm.peers[raftId]
+	if peer == nil {
+		glog.V(logger.Warn).Infof("ignoring address update for unknown peer %v", raftId)
+		return
+	}
+
+	p2pNode := discover.NewNode(address.nodeId, address.ip, 0, uint16(address.p2pPort))
+	pm.p2pServer.RemovePeer(peer.p2pNode)
+	pm.p2pServer.AddPeer(p2pNode)
+
+	pm.transport.UpdatePeer(raftTypes.ID(raftId), []string{raftUrl(address)})
+	pm.peers[raftId] = &Peer{address, p2pNode}
+}
+
 func (pm *ProtocolManager) disconnectFromPeer(raftId uint16, peer *Peer) {
 	pm.p2pServer.RemovePeer(peer.p2pNode)
 	pm.transport.RemovePeer(raftTypes.ID(raftId))
@@ -677,7 +870,7 @@ func (pm *ProtocolManager) eventLoop() {
 		// when the node is first ready it gives us entries to commit and messages
 		// to immediately publish
 		case rd := <-pm.rawNode().Ready():
-			pm.wal.Save(rd.HardState, rd.Entries)
+			pm.wal.Save(rd.HardState, pm.walCipher.sealEntries(rd.Entries))
 
 			if snap := rd.Snapshot; !etcdRaft.IsEmptySnap(snap) {
 				pm.saveRaftSnapshot(snap)
@@ -689,8 +882,10 @@ func (pm *ProtocolManager) eventLoop() {
 			// are not empty.
 			pm.raftStorage.Append(rd.Entries)
 
-			// 2: Send all Messages to the nodes named in the To field.
-			pm.transport.Send(rd.Messages)
+			// 2: Send all Messages to the nodes named in the To field. Snapshots
+			// for lagging followers are streamed separately, so a large one
+			// doesn't hold up the replication of new blocks.
+			pm.transport.Send(pm.snapshots.filter(rd.Messages))
 
 			// 3: Apply Snapshot (if any) and CommittedEntries to the state machine.
 			for _, entry := range pm.entriesToApply(rd.CommittedEntries) {
@@ -699,9 +894,16 @@ func (pm *ProtocolManager) eventLoop() {
 					if len(entry.Data) == 0 {
 						break
 					}
+					if isCompressionActivation(entry.Data) {
+						pm.activateCompression(entry.Data)
+						break
+					}
 					var block types.Block
-					err := rlp.DecodeBytes(entry.Data, &block)
+					data, err := decodeEntry(entry.Data)
 					if err != nil {
+						glog.Fatalf("failed to decode raft entry %d: %v", entry.Index, err)
+					}
+					if err := rlp.DecodeBytes(data, &block); err != nil {
 						glog.V(logger.Error).Infoln("error decoding block: ", err)
 					}
 
@@ -718,31 +920,36 @@ func (pm *ProtocolManager) eventLoop() {
 
 						headBlockHash := pm.blockchain.CurrentBlock().Hash()
 						glog.V(logger.Warn).Infof("not applying already-applied block: %x (parent is %x; current head is %x)\n", block.Hash(), block.ParentHash(), headBlockHash)
-					} else {
-						pm.applyNewChainHead(&block)
+					} else if !pm.applyNewChainHead(&block) {
+						return // Stopped while waiting for a private payload
 					}
 
 				case raftpb.EntryConfChange:
 					var cc raftpb.ConfChange
 					cc.Unmarshal(entry.Data)
 					raftId := uint16(cc.NodeID)
+					enode := pm.confChangeEnode(cc)
 
 					pm.confState = *pm.rawNode().ApplyConfChange(cc)
 
 					forceSnapshot := false
+					ignored := false // Whether the membership is left as it was, for the cluster history
 
 					switch cc.Type {
 					case raftpb.ConfChangeAddNode:
 						if pm.isRaftIdRemoved(raftId) {
 							glog.V(logger.Info).Infof("ignoring ConfChangeAddNode for permanently-removed peer %v", raftId)
+							ignored = true
 						} else if raftId <= uint16(len(pm.bootstrapNodes)) {
 							// See initial cluster logic in startRaft() for more information.
 							glog.V(logger.Info).Infof("ignoring expected ConfChangeAddNode for initial peer %v", raftId)
+							ignored = true
 
 							// We need a snapshot to exist to reconnect to peers on start-up after a crash.
 							forceSnapshot = true
 						} else if pm.isRaftIdUsed(raftId) {
 							glog.V(logger.Info).Infof("ignoring ConfChangeAddNode for already-used raft ID %v", raftId)
+							ignored = true
 						} else {
 							glog.V(logger.Info).Infof("adding peer %v due to ConfChangeAddNode", raftId)
 
@@ -753,6 +960,7 @@ func (pm *ProtocolManager) eventLoop() {
 					case raftpb.ConfChangeRemoveNode:
 						if pm.isRaftIdRemoved(raftId) {
 							glog.V(logger.Info).Infof("ignoring ConfChangeRemoveNode for already-removed peer %v", raftId)
+							ignored = true
 						} else {
 							glog.V(logger.Info).Infof("removing peer %v due to ConfChangeRemoveNode", raftId)
 
@@ -766,11 +974,26 @@ func (pm *ProtocolManager) eventLoop() {
 						}
 
 					case raftpb.ConfChangeUpdateNode:
-						// NOTE: remember to forceSnapshot in this case, if we add support
-						// for this.
-						glog.Fatalln("not yet handled: ConfChangeUpdateNode")
+						if pm.isRaftIdRemoved(raftId) {
+							glog.V(logger.Info).Infof("ignoring ConfChangeUpdateNode for permanently-removed peer %v", raftId)
+							ignored = true
+						} else {
+							glog.V(logger.Info).Infof("updating address of peer %v due to ConfChangeUpdateNode", raftId)
+
+							forceSnapshot = true
+
+							address := bytesToAddress(cc.Context)
+							if raftId == pm.raftId {
+								pm.setLocalAddress(address)
+								pm.rebindRaft(address)
+							} else {
+								pm.updatePeer(address)
+							}
+						}
 					}
 
+					pm.recordConfChange(entry, cc, enode, ignored)
+
 					if forceSnapshot {
 						// We force a snapshot here to persist our updated confState, so we
 						// know our fellow cluster members when we come back online.
@@ -844,12 +1067,21 @@ func blockExtendsChain(block *types.Block, chain *core.BlockChain) bool {
 	return block.ParentHash() == chain.CurrentBlock().Hash()
 }
 
-func (pm *ProtocolManager) applyNewChainHead(block *types.Block) {
+// applyNewChainHead extends the chain with a block minted by the cluster. It
+// reports false if the handler was stopped before the block could be applied.
+func (pm *ProtocolManager) applyNewChainHead(block *types.Block) bool {
 	if !blockExtendsChain(block, pm.blockchain) {
 		headBlock := pm.blockchain.CurrentBlock()
 
 		glog.V(logger.Warn).Infof("Non-extending block: %x (parent is %x; current head is %x)\n", block.Hash(), block.ParentHash(), headBlock.Hash())
 
+		pm.eventMux.Post(InvalidRaftOrdering{headBlock: headBlock, invalidBlock: block})
+	} else if headBlock := pm.blockchain.CurrentBlock(); block.Time().Cmp(headBlock.Time()) <= 0 {
+		// Every node sees the same head here, so rejecting the block is
+		// deterministic across the cluster. Accepting it would fail header
+		// validation and halt the node.
+		glog.V(logger.Warn).Infof("Non-monotonic block: %x (timestamp %v is not after parent %x timestamp %v)\n", block.Hash(), block.Time(), headBlock.Hash(), headBlock.Time())
+
 		pm.eventMux.Post(InvalidRaftOrdering{headBlock: headBlock, invalidBlock: block})
 	} else {
 		if existingBlock := pm.blockchain.GetBlockByHash(block.Hash()); nil == existingBlock {
@@ -862,13 +1094,125 @@ func (pm *ProtocolManager) applyNewChainHead(block *types.Block) {
 			logger.LogRaftCheckpoint(logger.TxAccepted, tx.Hash().Hex())
 		}
 
-		_, err := pm.blockchain.InsertChain([]*types.Block{block})
+		for attempt := 1; ; attempt++ {
+			_, err := pm.blockchain.InsertChain([]*types.Block{block})
+			if err == nil {
+				break
+			}
+			if !private.IsPayloadUnavailable(err) {
+				panic(fmt.Sprintf("failed to extend chain: %s", err.Error()))
+			}
+			// The block can't be skipped, the whole cluster applies it. Wait for
+			// the payload to become available instead.
+			delay := time.Duration(attempt) * time.Second
+			if delay > maxPayloadRetryDelay {
+				delay = maxPayloadRetryDelay
+			}
+			glog.V(logger.Warn).Infof("failed to apply block %x, retrying in %v: %v\n", block.Hash(), delay, err)
+			select {
+			case <-time.After(delay):
+			case <-pm.quitSync:
+				return false
+			}
+		}
+
+		glog.V(logger.Info).Infof("%s: %x\n", chainExtensionMessage, block.Hash())
+	}
+	return true
+}
+
+// Returns the number of entries in the raft log which have not been applied to
+// the chain yet.
+func (pm *ProtocolManager) unappliedEntries() uint64 {
+	lastIndex, err := pm.raftStorage.LastIndex()
+	if err != nil {
+		return 0
+	}
 
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	if lastIndex <= pm.appliedIndex {
+		return 0
+	}
+	return lastIndex - pm.appliedIndex
+}
+
+// Returns the number of the last block committed to the raft log, as far as
+// this node knows, if it hasn't been applied to the chain yet. Zero otherwise.
+func (pm *ProtocolManager) committedHead() uint64 {
+	if pm.unsafeRawNode == nil {
+		return 0
+	}
+	commit := pm.rawNode().Status().Commit
+
+	pm.mu.RLock()
+	applied := pm.appliedIndex
+	pm.mu.RUnlock()
+
+	if commit <= applied {
+		return 0
+	}
+	entries, err := pm.raftStorage.Entries(applied+1, commit+1, math.MaxUint64)
+	if err != nil {
+		return 0
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Type != raftpb.EntryNormal || len(entries[i].Data) == 0 || isCompressionActivation(entries[i].Data) {
+			continue
+		}
+		data, err := decodeEntry(entries[i].Data)
 		if err != nil {
-			panic(fmt.Sprintf("failed to extend chain: %s", err.Error()))
+			return 0
+		}
+		var block types.Block
+		if err := rlp.DecodeBytes(data, &block); err != nil {
+			return 0
 		}
+		return block.NumberU64()
+	}
+	return 0
+}
 
-		glog.V(logger.Info).Infof("%s: %x\n", chainExtensionMessage, block.Hash())
+// Transfers the raft leadership, and with it minting, to the most up to date
+// follower.
+func (pm *ProtocolManager) stepDown() error {
+	status := pm.rawNode().Status()
+	if status.RaftState != etcdRaft.StateLeader {
+		return fmt.Errorf("not the raft leader")
+	}
+	var transferee, match uint64
+	for id, progress := range status.Progress {
+		if id != status.ID && (transferee == 0 || progress.Match > match) {
+			transferee, match = id, progress.Match
+		}
+	}
+	if transferee == 0 {
+		return fmt.Errorf("no other raft peer to transfer the leadership to")
+	}
+	glog.V(logger.Warn).Infof("raft: transferring leadership to raft id %d", transferee)
+	pm.rawNode().TransferLeadership(context.TODO(), status.ID, transferee)
+	return nil
+}
+
+// Puts the node in or out of maintenance. A node in maintenance doesn't mint:
+// it hands the leadership over to another peer when it has or later wins it.
+func (pm *ProtocolManager) setMaintenance(enabled bool) {
+	pm.mu.Lock()
+	pm.maintenance = enabled
+	role := pm.role
+	pm.mu.Unlock()
+
+	if enabled && role == minterRole {
+		pm.handOverLeadership()
+	}
+}
+
+// Transfers the leadership away from a node in maintenance. If there's no peer
+// to take over, the node keeps minting rather than stalling the chain.
+func (pm *ProtocolManager) handOverLeadership() {
+	if err := pm.stepDown(); err != nil {
+		glog.V(logger.Warn).Infof("raft: in maintenance, but unable to hand over the leadership: %v", err)
 	}
 }
 
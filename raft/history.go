@@ -0,0 +1,123 @@
+package raft
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/coreos/etcd/raft/raftpb"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Conf change IDs carry the raft ID of the proposing node in their upper bits.
+// Nodes predating this leave those bits zero.
+const confChangeProposerShift = 48
+
+var confChangeKeyPrefix = []byte("confChange-")
+
+// A membership change that went through the raft log.
+type ConfChangeRecord struct {
+	Index      uint64 `json:"index"`      // Raft log index of the change
+	Term       uint64 `json:"term"`       // Raft term in which the change was proposed
+	Type       string `json:"type"`       // "addPeer", "removePeer" or "updatePeer"
+	RaftId     uint16 `json:"raftId"`     // The affected peer
+	Enode      string `json:"enode"`      // The enode ID of the affected peer, if known
+	ProposedBy uint16 `json:"proposedBy"` // Raft ID of the proposing node, 0 if unknown
+	Ignored    bool   `json:"ignored"`    // Whether the change was ignored, e.g. for a removed peer
+	AppliedAt  uint64 `json:"appliedAt"`  // Unix time at which this node first applied the change
+}
+
+func confChangeTypeName(t raftpb.ConfChangeType) string {
+	switch t {
+	case raftpb.ConfChangeAddNode:
+		return "addPeer"
+	case raftpb.ConfChangeRemoveNode:
+		return "removePeer"
+	case raftpb.ConfChangeUpdateNode:
+		return "updatePeer"
+	default:
+		return t.String()
+	}
+}
+
+func confChangeKey(index uint64) []byte {
+	key := make([]byte, len(confChangeKeyPrefix)+8)
+	copy(key, confChangeKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(confChangeKeyPrefix):], index)
+	return key
+}
+
+func enodeString(address *Address) string {
+	node := discover.NewNode(address.nodeId, address.ip, 0, address.p2pPort)
+	node.RaftPort = address.raftPort
+	return node.String()
+}
+
+// Returns the enode ID affected by a conf change. This must be called before
+// applying the change, as removals don't carry the address of the peer.
+func (pm *ProtocolManager) confChangeEnode(cc raftpb.ConfChange) string {
+	if len(cc.Context) > 0 {
+		return enodeString(bytesToAddress(cc.Context))
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	raftId := uint16(cc.NodeID)
+	if raftId == pm.raftId && pm.address != nil {
+		return enodeString(pm.address)
+	} else if peer := pm.peers[raftId]; peer != nil {
+		return enodeString(peer.address)
+	}
+	return ""
+}
+
+// Appends a conf change to the persistent cluster history. Entries re-applied
+// after a restart, when replaying the write-ahead log, overwrite their earlier
+// record but keep the time the change was first applied at.
+func (pm *ProtocolManager) recordConfChange(entry raftpb.Entry, cc raftpb.ConfChange, enode string, ignored bool) {
+	record := &ConfChangeRecord{
+		Index:      entry.Index,
+		Term:       entry.Term,
+		Type:       confChangeTypeName(cc.Type),
+		RaftId:     uint16(cc.NodeID),
+		Enode:      enode,
+		ProposedBy: uint16(cc.ID >> confChangeProposerShift),
+		Ignored:    ignored,
+		AppliedAt:  uint64(time.Now().Unix()),
+	}
+	if data, err := pm.quorumRaftDb.Get(confChangeKey(entry.Index), nil); err == nil {
+		applied := new(ConfChangeRecord)
+		if err := rlp.DecodeBytes(data, applied); err == nil {
+			record.AppliedAt = applied.AppliedAt
+		}
+	}
+
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		glog.V(logger.Error).Infof("failed to encode conf change record: %v", err)
+		return
+	}
+	if err := pm.quorumRaftDb.Put(confChangeKey(entry.Index), data, noFsync); err != nil {
+		glog.V(logger.Error).Infof("failed to persist conf change record: %v", err)
+	}
+}
+
+// Returns all recorded conf changes, in raft log order.
+func (pm *ProtocolManager) ConfChangeHistory() ([]*ConfChangeRecord, error) {
+	it := pm.quorumRaftDb.NewIterator(util.BytesPrefix(confChangeKeyPrefix), nil)
+	defer it.Release()
+
+	records := []*ConfChangeRecord{}
+	for it.Next() {
+		record := new(ConfChangeRecord)
+		if err := rlp.DecodeBytes(it.Value(), record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, it.Error()
+}
@@ -0,0 +1,141 @@
+package raft
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/coreos/etcd/raft/raftpb"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func newHistoryTestManager(t *testing.T) (*ProtocolManager, func()) {
+	dir, err := ioutil.TempDir("", "raft-history-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := openQuorumRaftDb(dir, nil)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	pm := &ProtocolManager{raftId: 1, peers: make(map[uint16]*Peer), quorumRaftDb: db}
+	return pm, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func testAddress(raftId uint16) *Address {
+	return &Address{
+		raftId:   raftId,
+		nodeId:   discover.NodeID{byte(raftId)},
+		ip:       net.IPv4(127, 0, 0, 1),
+		p2pPort:  30300 + raftId,
+		raftPort: 50400 + raftId,
+	}
+}
+
+func TestConfChangeHistory(t *testing.T) {
+	pm, cleanup := newHistoryTestManager(t)
+	defer cleanup()
+
+	if records, err := pm.ConfChangeHistory(); err != nil || len(records) != 0 {
+		t.Fatalf("history of a new node: %v, %v", records, err)
+	}
+
+	added := testAddress(4)
+	changes := []struct {
+		entry   raftpb.Entry
+		cc      raftpb.ConfChange
+		ignored bool
+	}{
+		// Recorded out of order, with indexes sorting differently as strings
+		{raftpb.Entry{Index: 300, Term: 2}, raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 4, ID: 2<<confChangeProposerShift | 1}, false},
+		{raftpb.Entry{Index: 5, Term: 1}, raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 4, Context: added.toBytes(), ID: 7}, false},
+		{raftpb.Entry{Index: 40, Term: 1}, raftpb.ConfChange{Type: raftpb.ConfChangeUpdateNode, NodeID: 4, Context: added.toBytes(), ID: 3<<confChangeProposerShift | 1}, true},
+	}
+	for _, c := range changes {
+		pm.recordConfChange(c.entry, c.cc, pm.confChangeEnode(c.cc), c.ignored)
+	}
+	records, err := pm.ConfChangeHistory()
+	if err != nil {
+		t.Fatalf("failed to read history: %v", err)
+	}
+	want := []ConfChangeRecord{
+		{Index: 5, Term: 1, Type: "addPeer", RaftId: 4, Enode: enodeString(added), ProposedBy: 0},
+		{Index: 40, Term: 1, Type: "updatePeer", RaftId: 4, Enode: enodeString(added), ProposedBy: 3, Ignored: true},
+		{Index: 300, Term: 2, Type: "removePeer", RaftId: 4, Enode: "", ProposedBy: 2},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("recorded %d changes, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if record.AppliedAt == 0 {
+			t.Errorf("change %d: no application time", i)
+		}
+		record.AppliedAt = 0
+		if *record != want[i] {
+			t.Errorf("change %d mismatch:\nhave %+v\nwant %+v", i, *record, want[i])
+		}
+	}
+
+	// Changes re-applied after a restart replace their record, but keep the
+	// time they were first applied at
+	record, err := rlp.EncodeToBytes(&ConfChangeRecord{Index: 5, AppliedAt: 1500000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.quorumRaftDb.Put(confChangeKey(5), record, nil); err != nil {
+		t.Fatal(err)
+	}
+	pm.recordConfChange(changes[1].entry, changes[1].cc, "", true)
+	if records, err = pm.ConfChangeHistory(); err != nil || len(records) != len(want) {
+		t.Fatalf("re-applied change recorded again: %d records, %v", len(records), err)
+	}
+	if !records[0].Ignored {
+		t.Errorf("re-applied change didn't replace its record")
+	}
+	if records[0].AppliedAt != 1500000000 {
+		t.Errorf("re-applied change application time mismatch: have %d, want %d", records[0].AppliedAt, 1500000000)
+	}
+}
+
+func TestConfChangeEnode(t *testing.T) {
+	pm, cleanup := newHistoryTestManager(t)
+	defer cleanup()
+
+	local, peer, added := testAddress(1), testAddress(2), testAddress(3)
+	pm.address = local
+	pm.peers[2] = &Peer{address: peer}
+
+	tests := []struct {
+		cc   raftpb.ConfChange
+		want string
+	}{
+		{raftpb.ConfChange{Type: raftpb.ConfChangeAddNode, NodeID: 3, Context: added.toBytes()}, enodeString(added)},
+		{raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 2}, enodeString(peer)},
+		{raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 1}, enodeString(local)},
+		{raftpb.ConfChange{Type: raftpb.ConfChangeRemoveNode, NodeID: 9}, ""},
+	}
+	for i, test := range tests {
+		if have := pm.confChangeEnode(test.cc); have != test.want {
+			t.Errorf("test %d: enode mismatch: have %q, want %q", i, have, test.want)
+		}
+	}
+}
+
+func TestConfChangeTypeName(t *testing.T) {
+	names := map[raftpb.ConfChangeType]string{
+		raftpb.ConfChangeAddNode:    "addPeer",
+		raftpb.ConfChangeRemoveNode: "removePeer",
+		raftpb.ConfChangeUpdateNode: "updatePeer",
+	}
+	for typ, want := range names {
+		if have := confChangeTypeName(typ); have != want {
+			t.Errorf("%v named %q, want %q", typ, have, want)
+		}
+	}
+}
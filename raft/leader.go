@@ -0,0 +1,212 @@
+package raft
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/net/context"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// How followers handle transactions submitted to them over RPC.
+const (
+	FollowerWritesLocal    = "local"    // Add them to the local pool, gossiped to the minter over eth
+	FollowerWritesForward  = "forward"  // Submit them to the leader, and add them to the local pool
+	FollowerWritesRedirect = "redirect" // Reject them with a NotLeaderError pointing to the leader
+)
+
+// Message code of the raft p2p protocol advertising the RPC endpoint of a node.
+const rpcEndpointMsg = 0x00
+
+var (
+	leaderForwardMeter  = metrics.NewMeter("raft/leader/forwarded")
+	leaderRedirectMeter = metrics.NewMeter("raft/leader/redirected")
+)
+
+// NotLeaderError is returned to RPC clients submitting transactions to a
+// follower in redirect mode. The request should be sent to the endpoint of the
+// leader instead.
+type NotLeaderError struct {
+	Leader   uint16
+	Endpoint string
+}
+
+func (e *NotLeaderError) Error() string {
+	return fmt.Sprintf("not the raft leader, submit transactions to raft node %d at %s", e.Leader, e.Endpoint)
+}
+
+// ErrorCode implements rpc.Error.
+func (e *NotLeaderError) ErrorCode() int { return -32006 }
+
+// ErrorData implements rpc.DataError.
+func (e *NotLeaderError) ErrorData() interface{} {
+	return map[string]interface{}{"reason": "notLeader", "leader": e.Leader, "endpoint": e.Endpoint}
+}
+
+// LeaderInfo is the raft ID and advertised RPC endpoint of the leader, for load
+// balancers sending writes to it.
+type LeaderInfo struct {
+	RaftId   uint16 `json:"raftId"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Self     bool   `json:"self"`
+}
+
+// Returns the current raft leader, or nil while there is none.
+func (pm *ProtocolManager) leader() *LeaderInfo {
+	lead := pm.rawNode().Status().Lead
+	if lead == etcdRaft.None {
+		return nil
+	}
+	if uint16(lead) == pm.raftId {
+		return &LeaderInfo{RaftId: pm.raftId, Endpoint: pm.rpcEndpoint, Self: true}
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	info := &LeaderInfo{RaftId: uint16(lead)}
+	if peer, ok := pm.peers[info.RaftId]; ok {
+		info.Endpoint = pm.rpcEndpoints[peer.address.nodeId]
+	}
+	return info
+}
+
+// Advertises our RPC endpoint to a peer of the raft p2p protocol, and records
+// the endpoint it advertises until it disconnects.
+func (pm *ProtocolManager) exchangeRPCEndpoints(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+	if pm.rpcEndpoint != "" {
+		if err := p2p.Send(rw, rpcEndpointMsg, pm.rpcEndpoint); err != nil {
+			return err
+		}
+	}
+	defer func() {
+		pm.mu.Lock()
+		delete(pm.rpcEndpoints, peer.ID())
+		pm.mu.Unlock()
+	}()
+
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		if msg.Code == rpcEndpointMsg {
+			var endpoint string
+			if err := msg.Decode(&endpoint); err != nil {
+				return err
+			}
+			pm.mu.Lock()
+			pm.rpcEndpoints[peer.ID()] = endpoint
+			pm.mu.Unlock()
+		}
+		msg.Discard()
+	}
+}
+
+// txRouter sends transactions submitted to a follower to the leader, as
+// configured by the follower writes mode.
+type txRouter struct {
+	mode string
+	pm   *ProtocolManager
+
+	mu       sync.Mutex
+	client   *rpc.Client // Connection to the leader, reused while it leads
+	endpoint string
+}
+
+func newTxRouter(mode string, pm *ProtocolManager) *txRouter {
+	return &txRouter{mode: mode, pm: pm}
+}
+
+// route redirects or forwards a transaction to the leader, returning whether it
+// was forwarded. Without a leader whose endpoint we know, or if it can't be
+// reached, the transaction is only added to the local pool, from where it
+// reaches the minter over eth.
+func (r *txRouter) route(ctx context.Context, tx *types.Transaction) (bool, error) {
+	if r.mode != FollowerWritesForward && r.mode != FollowerWritesRedirect {
+		return false, nil
+	}
+	leader := r.pm.leader()
+	if leader == nil || leader.Self || leader.Endpoint == "" {
+		return false, nil
+	}
+	if r.mode == FollowerWritesRedirect {
+		leaderRedirectMeter.Mark(1)
+		return false, &NotLeaderError{Leader: leader.RaftId, Endpoint: leader.Endpoint}
+	}
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return false, err
+	}
+	client, err := r.dial(ctx, leader.Endpoint)
+	if err != nil {
+		glog.V(logger.Warn).Infof("failed to connect to raft leader %d at %s: %v", leader.RaftId, leader.Endpoint, err)
+		return false, nil
+	}
+	var hash common.Hash
+	if err := client.CallContext(ctx, &hash, "eth_sendRawTransaction", common.ToHex(data)); err != nil {
+		// Errors returned by the leader are final, the transaction would be
+		// rejected by the minter anyway
+		if _, ok := err.(rpc.Error); ok {
+			return false, err
+		}
+		glog.V(logger.Warn).Infof("failed to forward transaction %x to raft leader %d: %v", tx.Hash(), leader.RaftId, err)
+		r.reset(client)
+		return false, nil
+	}
+	leaderForwardMeter.Mark(1)
+	glog.V(logger.Debug).Infof("forwarded transaction %x to raft leader %d", tx.Hash(), leader.RaftId)
+	return true, nil
+}
+
+// Returns a client connected to the endpoint, reusing the last one if the
+// leader didn't change.
+func (r *txRouter) dial(ctx context.Context, endpoint string) (*rpc.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil && r.endpoint == endpoint {
+		return r.client, nil
+	}
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	r.client, r.endpoint = client, endpoint
+	return client, nil
+}
+
+// Drops a client which failed, so the next transaction reconnects.
+func (r *txRouter) reset(client *rpc.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client == client {
+		r.client.Close()
+		r.client = nil
+	}
+}
+
+// close disconnects from the leader.
+func (r *txRouter) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		r.client.Close()
+		r.client = nil
+	}
+}
@@ -0,0 +1,211 @@
+package raft
+
+import (
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/context"
+)
+
+// testRaftNode is a raft node which only reports the given leader.
+type testRaftNode struct {
+	etcdRaft.Node
+	lead uint64
+}
+
+func (n *testRaftNode) Status() etcdRaft.Status {
+	var status etcdRaft.Status
+	status.Lead = n.lead
+	return status
+}
+
+// newLeaderTestManager returns the protocol manager of raft node 1, with node 2
+// as its peer advertising the given RPC endpoint.
+func newLeaderTestManager(lead uint16, endpoint string) *ProtocolManager {
+	peer := testAddress(2)
+	return &ProtocolManager{
+		raftId:        1,
+		rpcEndpoint:   "http://node1:8545",
+		peers:         map[uint16]*Peer{2: {address: peer}},
+		rpcEndpoints:  map[discover.NodeID]string{peer.nodeId: endpoint},
+		unsafeRawNode: &testRaftNode{lead: uint64(lead)},
+	}
+}
+
+// LeaderTestAPI is the eth API of a leader, accepting transactions unless
+// configured to reject them. The rpc server only registers exported types.
+type LeaderTestAPI struct {
+	reject error
+	txs    []string
+}
+
+func (api *LeaderTestAPI) SendRawTransaction(encoded string) (common.Hash, error) {
+	if api.reject != nil {
+		return common.Hash{}, api.reject
+	}
+	api.txs = append(api.txs, encoded)
+	return common.Hash{}, nil
+}
+
+func newTestLeader(t *testing.T) (*LeaderTestAPI, *httptest.Server) {
+	api := new(LeaderTestAPI)
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	return api, httptest.NewServer(server)
+}
+
+func TestRouteNoForwarding(t *testing.T) {
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+	tests := []struct {
+		name     string
+		mode     string
+		lead     uint16
+		endpoint string
+	}{
+		{"local mode", FollowerWritesLocal, 2, "http://node2:8545"},
+		{"no leader", FollowerWritesForward, 0, "http://node2:8545"},
+		{"self as leader", FollowerWritesForward, 1, "http://node2:8545"},
+		{"leader not a peer", FollowerWritesForward, 3, "http://node2:8545"},
+		{"unknown endpoint", FollowerWritesForward, 2, ""},
+		{"unknown endpoint redirecting", FollowerWritesRedirect, 2, ""},
+	}
+	for _, test := range tests {
+		r := newTxRouter(test.mode, newLeaderTestManager(test.lead, test.endpoint))
+		if forwarded, err := r.route(context.Background(), tx); forwarded || err != nil {
+			t.Errorf("%s: have %v, %v, want the transaction kept locally", test.name, forwarded, err)
+		}
+		if r.client != nil {
+			t.Errorf("%s: connected to %s", test.name, r.endpoint)
+		}
+	}
+}
+
+func TestRouteRedirect(t *testing.T) {
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+	r := newTxRouter(FollowerWritesRedirect, newLeaderTestManager(2, "http://node2:8545"))
+
+	forwarded, err := r.route(context.Background(), tx)
+	notLeader, ok := err.(*NotLeaderError)
+	if forwarded || !ok {
+		t.Fatalf("have %v, %v, want a NotLeaderError", forwarded, err)
+	}
+	if notLeader.Leader != 2 || notLeader.Endpoint != "http://node2:8545" {
+		t.Errorf("redirected to node %d at %s", notLeader.Leader, notLeader.Endpoint)
+	}
+}
+
+func TestRouteForward(t *testing.T) {
+	api, leader := newTestLeader(t)
+	defer leader.Close()
+
+	r := newTxRouter(FollowerWritesForward, newLeaderTestManager(2, leader.URL))
+	defer r.close()
+
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+	for i := 0; i < 2; i++ {
+		if forwarded, err := r.route(context.Background(), tx); !forwarded || err != nil {
+			t.Fatalf("forward %d: have %v, %v", i, forwarded, err)
+		}
+	}
+	data, _ := rlp.EncodeToBytes(tx)
+	if len(api.txs) != 2 || api.txs[0] != common.ToHex(data) {
+		t.Errorf("leader received %v, want the transaction twice", api.txs)
+	}
+	if r.client == nil || r.endpoint != leader.URL {
+		t.Errorf("connection to the leader not kept")
+	}
+}
+
+func TestRouteLeaderError(t *testing.T) {
+	api, leader := newTestLeader(t)
+	defer leader.Close()
+	api.reject = errors.New("nonce too low")
+
+	r := newTxRouter(FollowerWritesForward, newLeaderTestManager(2, leader.URL))
+	defer r.close()
+
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+	forwarded, err := r.route(context.Background(), tx)
+	if forwarded || err == nil {
+		t.Fatalf("have %v, %v, want the leader's error", forwarded, err)
+	}
+	if _, ok := err.(rpc.Error); !ok || err.Error() != "nonce too low" {
+		t.Errorf("error mismatch: have %v (%T)", err, err)
+	}
+	if r.client == nil {
+		t.Errorf("connection to the leader dropped after a rejected transaction")
+	}
+}
+
+func TestRouteTransportFailure(t *testing.T) {
+	_, leader := newTestLeader(t)
+	leader.Close() // Unreachable
+
+	r := newTxRouter(FollowerWritesForward, newLeaderTestManager(2, leader.URL))
+	defer r.close()
+
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+	if forwarded, err := r.route(context.Background(), tx); forwarded || err != nil {
+		t.Fatalf("have %v, %v, want the transaction kept locally", forwarded, err)
+	}
+	if r.client != nil {
+		t.Errorf("failed connection kept")
+	}
+}
+
+func TestExchangeRPCEndpoints(t *testing.T) {
+	pm := &ProtocolManager{rpcEndpoint: "http://node1:8545", rpcEndpoints: make(map[discover.NodeID]string)}
+	id := discover.NodeID{2}
+	local, remote := p2p.MsgPipe()
+
+	done := make(chan error)
+	go func() {
+		done <- pm.exchangeRPCEndpoints(p2p.NewPeer(id, "test", nil), local)
+	}()
+
+	// Our endpoint is advertised first
+	msg, err := remote.ReadMsg()
+	if err != nil {
+		t.Fatalf("no endpoint advertised: %v", err)
+	}
+	var endpoint string
+	if err := msg.Decode(&endpoint); msg.Code != rpcEndpointMsg || err != nil || endpoint != pm.rpcEndpoint {
+		t.Fatalf("advertised %q with code %d (%v)", endpoint, msg.Code, err)
+	}
+
+	// The peer's endpoint is recorded while connected
+	if err := p2p.Send(remote, rpcEndpointMsg, "http://node2:8545"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		pm.mu.RLock()
+		endpoint = pm.rpcEndpoints[id]
+		pm.mu.RUnlock()
+		if endpoint == "http://node2:8545" {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("peer endpoint not recorded, have %q", endpoint)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	remote.Close()
+	if err := <-done; err == nil {
+		t.Errorf("exchange didn't fail on disconnect")
+	}
+	if _, ok := pm.rpcEndpoints[id]; ok {
+		t.Errorf("endpoint of disconnected peer kept")
+	}
+}
@@ -0,0 +1,77 @@
+package raft
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/rafthttp"
+)
+
+// freePort returns a port on the loopback interface nothing listens on.
+func freePort(t *testing.T) uint16 {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return uint16(ln.Addr().(*net.TCPAddr).Port)
+}
+
+// probeRaft reports whether the raft transport answers on the given port.
+func probeRaft(port uint16) bool {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, rafthttp.ProbingPrefix))
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// Tests that the raft transport listener follows an update of the raft port of
+// the local node, so peers dialing its new address reach it.
+func TestRebindRaft(t *testing.T) {
+	oldPort, newPort := freePort(t), freePort(t)
+	pm := &ProtocolManager{
+		raftId:      1,
+		raftPort:    oldPort,
+		transport:   &rafthttp.Transport{},
+		httpstopc:   make(chan struct{}),
+		httpdonec:   make(chan struct{}),
+		httprebindc: make(chan uint16),
+	}
+	go pm.serveRaft()
+	defer func() {
+		close(pm.httpstopc)
+		<-pm.httpdonec
+	}()
+
+	waitProbe := func(port uint16, want bool) {
+		for i := 0; i < 50 && probeRaft(port) != want; i++ {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if probeRaft(port) != want {
+			t.Fatalf("raft transport reachable on port %d: have %v, want %v", port, !want, want)
+		}
+	}
+	waitProbe(oldPort, true)
+
+	address := testAddress(1)
+	address.raftPort = newPort
+	pm.rebindRaft(address)
+	waitProbe(newPort, true)
+	waitProbe(oldPort, false)
+	if pm.raftPort != newPort {
+		t.Errorf("raft port mismatch: have %d, want %d", pm.raftPort, newPort)
+	}
+
+	// An endpoint advertised to the peers is kept
+	pm.advertise = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: int(oldPort)}
+	pm.rebindRaft(address)
+	address.raftPort = oldPort
+	pm.rebindRaft(address)
+	waitProbe(newPort, true)
+}
@@ -0,0 +1,54 @@
+package raft
+
+import (
+	"fmt"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+)
+
+// Route the logs of the raft library through glog, so that their verbosity
+// follows --verbosity and --vmodule, and can be changed at runtime with
+// debug.verbosity and debug.vmodule (e.g. "raft/*=5").
+func init() {
+	etcdRaft.SetLogger(etcdLogger{})
+}
+
+// etcdLogger implements the logger of the etcd raft library with glog.
+type etcdLogger struct{}
+
+func (etcdLogger) Debug(v ...interface{}) { glog.V(logger.Debug).Infoln(v...) }
+func (etcdLogger) Debugf(format string, v ...interface{}) {
+	glog.V(logger.Debug).Infof(format, v...)
+}
+
+func (etcdLogger) Info(v ...interface{}) { glog.V(logger.Info).Infoln(v...) }
+func (etcdLogger) Infof(format string, v ...interface{}) {
+	glog.V(logger.Info).Infof(format, v...)
+}
+
+func (etcdLogger) Warning(v ...interface{}) { glog.V(logger.Warn).Infoln(v...) }
+func (etcdLogger) Warningf(format string, v ...interface{}) {
+	glog.V(logger.Warn).Infof(format, v...)
+}
+
+func (etcdLogger) Error(v ...interface{}) { glog.V(logger.Error).Infoln(v...) }
+func (etcdLogger) Errorf(format string, v ...interface{}) {
+	glog.V(logger.Error).Infof(format, v...)
+}
+
+func (etcdLogger) Fatal(v ...interface{})                 { glog.Fatalln(v...) }
+func (etcdLogger) Fatalf(format string, v ...interface{}) { glog.Fatalf(format, v...) }
+
+func (etcdLogger) Panic(v ...interface{}) {
+	s := fmt.Sprintln(v...)
+	glog.Errorln(s)
+	panic(s)
+}
+
+func (etcdLogger) Panicf(format string, v ...interface{}) {
+	s := fmt.Sprintf(format, v...)
+	glog.Errorln(s)
+	panic(s)
+}
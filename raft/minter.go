@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/private"
 )
 
 // Current state information for building the next block
@@ -45,31 +46,39 @@ type work struct {
 }
 
 type minter struct {
-	config           *core.ChainConfig
-	mu               sync.Mutex
-	mux              *event.TypeMux
-	eth              core.Backend
-	chain            *core.BlockChain
-	chainDb          ethdb.Database
-	coinbase         common.Address
-	minting          int32 // Atomic status counter
-	shouldMine       *channels.RingChannel
-	blockTime        time.Duration
-	speculativeChain *speculativeChain
+	config              *core.ChainConfig
+	mu                  sync.Mutex
+	mux                 *event.TypeMux
+	eth                 core.Backend
+	chain               *core.BlockChain
+	chainDb             ethdb.Database
+	coinbase            common.Address
+	minting             int32 // Atomic status counter
+	shouldMine          *channels.RingChannel
+	blockTime           time.Duration
+	minTimeIncrement    time.Duration // Minimum time between the timestamps of a block and its parent
+	maxSpeculativeDepth int           // Maximum number of unaccepted blocks to build on (0 = unlimited)
+	speculativeChain    *speculativeChain
 }
 
-func newMinter(config *core.ChainConfig, eth core.Backend, blockTime time.Duration) *minter {
+func newMinter(config *core.ChainConfig, eth core.Backend, blockTime, minTimeIncrement time.Duration, maxSpeculativeDepth int) *minter {
+	if minTimeIncrement < 1 {
+		minTimeIncrement = 1
+	}
+
 	minter := &minter{
-		config:           config,
-		eth:              eth,
-		mux:              eth.EventMux(),
-		chainDb:          eth.ChainDb(),
-		chain:            eth.BlockChain(),
-		shouldMine:       channels.NewRingChannel(1),
-		blockTime:        blockTime,
-		speculativeChain: newSpeculativeChain(),
+		config:              config,
+		eth:                 eth,
+		mux:                 eth.EventMux(),
+		chainDb:             eth.ChainDb(),
+		chain:               eth.BlockChain(),
+		shouldMine:          channels.NewRingChannel(1),
+		blockTime:           blockTime,
+		minTimeIncrement:    minTimeIncrement,
+		maxSpeculativeDepth: maxSpeculativeDepth,
+		speculativeChain:    newSpeculativeChain(),
 	}
-	events := minter.mux.Subscribe(
+	events := minter.mux.SubscribeWith(event.SubscriptionConfig{Name: "raft/minter", Buffer: 256},
 		core.ChainHeadEvent{},
 		core.TxPreEvent{},
 		InvalidRaftOrdering{},
@@ -139,12 +148,8 @@ func (minter *minter) eventLoop(events event.Subscription) {
 			if atomic.LoadInt32(&minter.minting) == 1 {
 				minter.updateSpeculativeChainPerNewHead(newHeadBlock)
 
-				//
-				// TODO(bts): not sure if this is the place, but we're going to
-				// want to put an upper limit on our speculative mining chain
-				// length.
-				//
-
+				// This also resumes minting if we stopped at the maximum
+				// speculative chain depth.
 				minter.requestMinting()
 			} else {
 				minter.mu.Lock()
@@ -210,13 +215,22 @@ func (minter *minter) mintingLoop() {
 	}
 }
 
-func generateNanoTimestamp(parent *types.Block) (tstamp int64) {
+// Returns a nanosecond timestamp for a child of `parent`. Timestamps are
+// strictly monotonic: each block is at least `minIncrement` after its parent,
+// even if our clock is behind the clock of the minter that created the parent
+// (e.g. after a leader change.)
+func generateNanoTimestamp(parent *types.Block, minIncrement time.Duration) (tstamp int64) {
 	parentTime := parent.Time().Int64()
+	earliest := parentTime + int64(minIncrement)
 	tstamp = time.Now().UnixNano()
 
-	if parentTime >= tstamp {
+	if tstamp < earliest {
+		if tstamp < parentTime {
+			glog.V(logger.Warn).Infof("local clock is %v behind parent block %x; using parent timestamp + %v", time.Duration(parentTime-tstamp), parent.Hash().Bytes()[:4], minIncrement)
+		}
+
 		// Each successive block needs to be after its predecessor.
-		tstamp = parentTime + 1
+		tstamp = earliest
 	}
 
 	return
@@ -226,7 +240,7 @@ func generateNanoTimestamp(parent *types.Block) (tstamp int64) {
 func (minter *minter) createWork() *work {
 	parent := minter.speculativeChain.head
 	parentNumber := parent.Number()
-	tstamp := generateNanoTimestamp(parent)
+	tstamp := generateNanoTimestamp(parent, minter.minTimeIncrement)
 
 	header := &types.Header{
 		ParentHash: parent.Hash(),
@@ -276,6 +290,11 @@ func (minter *minter) mintNewBlock() {
 	minter.mu.Lock()
 	defer minter.mu.Unlock()
 
+	if depth := minter.speculativeChain.depth(); minter.maxSpeculativeDepth > 0 && depth >= minter.maxSpeculativeDepth {
+		glog.V(logger.Info).Infof("Not minting a new block since the speculative chain is at its maximum depth of %d", depth)
+		return
+	}
+
 	work := minter.createWork()
 	transactions := minter.getTransactions()
 
@@ -345,6 +364,10 @@ func (env *work) commitTransactions(txes *types.TransactionsByPriceAndNonce, bc
 
 		publicReceipt, privateReceipt, err := env.commitTransaction(tx, bc, gp)
 		switch {
+		case private.IsPayloadUnavailable(err):
+			// The transaction stays in the pool, it's minted once its payload can be retrieved
+			glog.V(logger.Warn).Infof("TX (%x) postponed: %v\n", tx.Hash().Bytes()[:4], err)
+			txes.Pop()
 		case err != nil:
 			if glog.V(logger.Detail) {
 				glog.Infof("TX (%x) failed, will be removed: %v\n", tx.Hash().Bytes()[:4], err)
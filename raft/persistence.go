@@ -3,12 +3,14 @@ package raft
 import (
 	"encoding/binary"
 
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
 
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 )
 
 var (
@@ -18,14 +20,31 @@ var (
 	}
 )
 
-func openQuorumRaftDb(path string) (db *leveldb.DB, err error) {
-	// Open the db and recover any potential corruptions
-	db, err = leveldb.OpenFile(path, &opt.Options{
+func openQuorumRaftDb(path string, dataKey []byte) (db *leveldb.DB, err error) {
+	options := &opt.Options{
 		OpenFilesCacheCapacity: -1, // -1 means 0??
 		BlockCacheCapacity:     -1,
-	})
+	}
+	if dataKey == nil {
+		// Open the db and recover any potential corruptions
+		db, err = leveldb.OpenFile(path, options)
+		if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+			db, err = leveldb.RecoverFile(path, nil)
+		}
+		return
+	}
+	// Encrypted dbs are opened on a wrapped storage, which the db doesn't close.
+	// The storage only holds the directory lock, released when we exit.
+	stor, err := storage.OpenFile(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if stor, err = ethdb.NewEncryptedStorage(stor, dataKey); err != nil {
+		return nil, err
+	}
+	db, err = leveldb.Open(stor, options)
 	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
-		db, err = leveldb.RecoverFile(path, nil)
+		db, err = leveldb.Recover(stor, nil)
 	}
 	return
 }
@@ -56,3 +75,21 @@ func (pm *ProtocolManager) writeAppliedIndex(index uint64) {
 	binary.LittleEndian.PutUint64(buf, index)
 	pm.quorumRaftDb.Put(appliedDbKey, buf, noFsync)
 }
+
+func (pm *ProtocolManager) loadCompressionActive() {
+	format, err := pm.quorumRaftDb.Get(compressionDbKey, nil)
+	if err != nil && err != errors.ErrNotFound {
+		glog.Fatalln(err)
+	}
+	if err == nil && (len(format) != 1 || format[0] != snappyEntryPrefix) {
+		glog.Fatalf("compression of raft entries activated in format %x: %v", format, errUnsupportedEntryFormat)
+	}
+
+	pm.mu.Lock()
+	pm.compressionActive = err == nil
+	pm.mu.Unlock()
+}
+
+func (pm *ProtocolManager) writeCompressionActive() {
+	pm.quorumRaftDb.Put(compressionDbKey, []byte{snappyEntryPrefix}, nil)
+}
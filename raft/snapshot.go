@@ -232,7 +232,9 @@ func (snapshot *Snapshot) DecodeRLP(s *rlp.Stream) error {
 // Raft snapshot
 
 func (pm *ProtocolManager) saveRaftSnapshot(snap raftpb.Snapshot) error {
-	if err := pm.snapshotter.SaveSnap(snap); err != nil {
+	sealed := snap
+	sealed.Data = pm.walCipher.seal(snap.Data)
+	if err := pm.snapshotter.SaveSnap(sealed); err != nil {
 		return err
 	}
 
@@ -253,6 +255,11 @@ func (pm *ProtocolManager) readRaftSnapshot() *raftpb.Snapshot {
 	if err != nil && err != snap.ErrNoSnapshot {
 		glog.Fatalf("error loading snapshot: %v", err)
 	}
+	if snapshot != nil {
+		if snapshot.Data, err = pm.walCipher.open(snapshot.Data); err != nil {
+			glog.Fatalf("error loading snapshot: %v", err)
+		}
+	}
 
 	return snapshot
 }
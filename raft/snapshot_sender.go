@@ -0,0 +1,203 @@
+package raft
+
+import (
+	"sync"
+	"time"
+
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/coreos/etcd/raft/raftpb"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// snapshotQueueSize is the number of snapshot transfers waiting to be
+	// streamed, further ones are reported to raft as failed and retried later.
+	snapshotQueueSize = 16
+
+	// snapshotTransferTimeout is the time after which a transfer the transport
+	// never reported on, e.g. to a removed peer, no longer holds off new ones.
+	snapshotTransferTimeout = 5 * time.Minute
+)
+
+var (
+	snapshotQueuedMeter   = metrics.NewMeter("raft/snapshot/queued")
+	snapshotSentMeter     = metrics.NewMeter("raft/snapshot/sent") // Bytes
+	snapshotFailedMeter   = metrics.NewMeter("raft/snapshot/failed")
+	snapshotThrottleTimer = metrics.NewTimer("raft/snapshot/throttled")
+	snapshotTransferTimer = metrics.NewTimer("raft/snapshot/duration")
+)
+
+// SnapshotTransfer is the progress of a raft snapshot sent to a lagging
+// follower.
+type SnapshotTransfer struct {
+	RaftId  uint16 `json:"raftId"`
+	Index   uint64 `json:"index"`   // Raft log index of the snapshot
+	Size    int    `json:"size"`    // Bytes
+	Sending bool   `json:"sending"` // Whether it left the queue
+	Elapsed string `json:"elapsed"` // Since it was queued
+}
+
+type snapshotTransfer struct {
+	msg     raftpb.Message
+	queued  time.Time
+	started time.Time // Zero while queued
+}
+
+// snapshotSender streams the raft snapshots of the leader to lagging followers
+// outside of the raft event loop. Transfers are queued and handed to the
+// transport one at a time, paced to the configured rate so that catching up a
+// follower doesn't starve the replication of new blocks, and a follower has one
+// transfer in flight at most. The transport reports the outcome back through
+// ProtocolManager.ReportSnapshot.
+type snapshotSender struct {
+	send   func([]raftpb.Message)
+	report func(uint64, etcdRaft.SnapshotStatus)
+	rate   uint64 // Bytes per second, 0 = unlimited
+
+	mu        sync.Mutex
+	transfers map[uint64]*snapshotTransfer // Queued or in flight, by raft ID
+
+	queue chan *snapshotTransfer
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newSnapshotSender(rate uint64, send func([]raftpb.Message), report func(uint64, etcdRaft.SnapshotStatus)) *snapshotSender {
+	return &snapshotSender{
+		send:      send,
+		report:    report,
+		rate:      rate,
+		transfers: make(map[uint64]*snapshotTransfer),
+		queue:     make(chan *snapshotTransfer, snapshotQueueSize),
+		quit:      make(chan struct{}),
+	}
+}
+
+func (s *snapshotSender) start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+func (s *snapshotSender) stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// filter queues the snapshot messages for streaming and returns the others,
+// which the event loop sends right away.
+func (s *snapshotSender) filter(msgs []raftpb.Message) []raftpb.Message {
+	rest := msgs[:0]
+	for _, msg := range msgs {
+		if msg.Type == raftpb.MsgSnap {
+			s.enqueue(msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+	return rest
+}
+
+func (s *snapshotSender) enqueue(msg raftpb.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if transfer := s.transfers[msg.To]; transfer != nil && now.Sub(transfer.queued) < snapshotTransferTimeout {
+		// Raft doesn't send another snapshot before hearing about this one
+		glog.V(logger.Debug).Infof("snapshot transfer to raft peer %d already in progress, skipping snapshot at index %d", msg.To, msg.Snapshot.Metadata.Index)
+		return
+	}
+	transfer := &snapshotTransfer{msg: msg, queued: now}
+	select {
+	case s.queue <- transfer:
+		s.transfers[msg.To] = transfer
+		snapshotQueuedMeter.Mark(1)
+		glog.V(logger.Info).Infof("queued snapshot at index %d (%d bytes) for raft peer %d", msg.Snapshot.Metadata.Index, msg.Size(), msg.To)
+	default:
+		// Reported off the event loop, which is in the middle of a Ready
+		snapshotFailedMeter.Mark(1)
+		glog.V(logger.Warn).Infof("snapshot queue full, failing snapshot at index %d for raft peer %d", msg.Snapshot.Metadata.Index, msg.To)
+		go s.report(msg.To, etcdRaft.SnapshotFailure)
+	}
+}
+
+// loop hands the queued transfers to the transport, waiting after each one
+// for as long as sending its bytes takes at the configured rate.
+func (s *snapshotSender) loop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case transfer := <-s.queue:
+			s.mu.Lock()
+			current := s.transfers[transfer.msg.To] == transfer
+			if current {
+				transfer.started = time.Now()
+			}
+			s.mu.Unlock()
+			if !current {
+				continue // Timed out and superseded while queued
+			}
+			size := transfer.msg.Size()
+			glog.V(logger.Info).Infof("sending snapshot at index %d (%d bytes) to raft peer %d, queued for %v", transfer.msg.Snapshot.Metadata.Index, size, transfer.msg.To, common.PrettyDuration(transfer.started.Sub(transfer.queued)))
+			s.send([]raftpb.Message{transfer.msg})
+
+			if s.rate == 0 {
+				continue
+			}
+			pause := time.Duration(uint64(size) * uint64(time.Second) / s.rate)
+			snapshotThrottleTimer.Update(pause)
+			select {
+			case <-time.After(pause):
+			case <-s.quit:
+				return
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// finish records the outcome of the transfer to a peer, as reported by the
+// transport.
+func (s *snapshotSender) finish(to uint64, status etcdRaft.SnapshotStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transfer := s.transfers[to]
+	if transfer == nil || transfer.started.IsZero() {
+		return
+	}
+	delete(s.transfers, to)
+
+	elapsed := time.Since(transfer.started)
+	if status == etcdRaft.SnapshotFinish {
+		snapshotSentMeter.Mark(int64(transfer.msg.Size()))
+		snapshotTransferTimer.Update(elapsed)
+		glog.V(logger.Info).Infof("sent snapshot at index %d (%d bytes) to raft peer %d in %v", transfer.msg.Snapshot.Metadata.Index, transfer.msg.Size(), to, common.PrettyDuration(elapsed))
+	} else {
+		snapshotFailedMeter.Mark(1)
+	}
+}
+
+// progress reports the queued and in-flight transfers.
+func (s *snapshotSender) progress() []*SnapshotTransfer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	transfers := make([]*SnapshotTransfer, 0, len(s.transfers))
+	for to, transfer := range s.transfers {
+		transfers = append(transfers, &SnapshotTransfer{
+			RaftId:  uint16(to),
+			Index:   transfer.msg.Snapshot.Metadata.Index,
+			Size:    transfer.msg.Size(),
+			Sending: !transfer.started.IsZero(),
+			Elapsed: common.PrettyDuration(now.Sub(transfer.queued)).String(),
+		})
+	}
+	return transfers
+}
@@ -5,11 +5,18 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/ethereum/go-ethereum/metrics"
 
 	"gopkg.in/fatih/set.v0"
 	lane "gopkg.in/oleiade/lane.v1"
 )
 
+var (
+	speculativeCreatedMeter   = metrics.NewMeter("raft/speculative/created")
+	speculativeAcceptedMeter  = metrics.NewMeter("raft/speculative/accepted")
+	speculativeDiscardedMeter = metrics.NewMeter("raft/speculative/discarded")
+)
+
 // The speculative chain represents blocks that we have minted which haven't been accepted into the chain yet, building
 // on each other in a chain. It has three basic operations:
 // * add new block to end
@@ -36,6 +43,8 @@ func newSpeculativeChain() *speculativeChain {
 }
 
 func (chain *speculativeChain) clear(block *types.Block) {
+	speculativeDiscardedMeter.Mark(int64(chain.unappliedBlocks.Size()))
+
 	chain.head = block
 	chain.unappliedBlocks = lane.NewDeque()
 	chain.expectedInvalidBlockHashes.Clear()
@@ -47,6 +56,13 @@ func (chain *speculativeChain) extend(block *types.Block) {
 	chain.head = block
 	chain.recordProposedTransactions(block.Transactions())
 	chain.unappliedBlocks.Append(block)
+
+	speculativeCreatedMeter.Mark(1)
+}
+
+// Returns the number of speculative blocks that haven't been accepted yet
+func (chain *speculativeChain) depth() int {
+	return chain.unappliedBlocks.Size()
 }
 
 // Set the parent of the speculative chain
@@ -67,9 +83,14 @@ func (chain *speculativeChain) accept(acceptedBlock *types.Block) {
 	if expectedBlock := earliestProposed == nil || earliestProposed.Hash() == acceptedBlock.Hash(); expectedBlock {
 		// Remove the txes in this accepted block from our blacklist.
 		chain.removeProposedTxes(acceptedBlock)
+
+		if earliestProposed != nil {
+			speculativeAcceptedMeter.Mark(1)
+		}
 	} else {
 		glog.V(logger.Warn).Infof("Another node minted %x; Clearing speculative state\n", acceptedBlock.Hash())
 
+		speculativeDiscardedMeter.Mark(1) // earliestProposed was already shifted off the queue
 		chain.clear(acceptedBlock)
 	}
 }
@@ -99,6 +120,7 @@ func (chain *speculativeChain) unwindFrom(invalidHash common.Hash, headBlock *ty
 		}
 
 		currBlock := currBlockI.(*types.Block)
+		speculativeDiscardedMeter.Mark(1)
 
 		glog.V(logger.Info).Infof("Popped block %x from queue RHS.\n", currBlock.Hash())
 
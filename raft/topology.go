@@ -0,0 +1,56 @@
+package raft
+
+import (
+	"fmt"
+	"sort"
+
+	raftTypes "github.com/coreos/etcd/pkg/types"
+	etcdRaft "github.com/coreos/etcd/raft"
+	"github.com/ethereum/go-ethereum/node"
+)
+
+// Adds the raft cluster members to a node's topology, with a link to each of
+// them which is healthy while the raft transport to the member is active.
+func (pm *ProtocolManager) reportTopology(topology *node.Topology) {
+	lead := uint16(pm.rawNode().Status().Lead)
+	role := func(raftId uint16) string {
+		switch {
+		case lead == uint16(etcdRaft.None):
+			return ""
+		case raftId == lead:
+			return "leader"
+		default:
+			return "follower"
+		}
+	}
+
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	self := topology.Node(topology.Self)
+	self.RaftId, self.Role = pm.raftId, role(pm.raftId)
+
+	raftIds := make([]int, 0, len(pm.peers))
+	for raftId := range pm.peers {
+		raftIds = append(raftIds, int(raftId))
+	}
+	sort.Ints(raftIds)
+
+	for _, id := range raftIds {
+		raftId := uint16(id)
+		address := pm.peers[raftId].address
+
+		member := topology.Node(address.nodeId.String())
+		member.RaftId, member.Role = raftId, role(raftId)
+		if member.Address == "" {
+			member.Address = fmt.Sprintf("%s:%d", address.ip, address.p2pPort)
+		}
+		link := &node.TopologyLink{Source: topology.Self, Target: member.ID, Kind: node.TopologyRaft}
+		if pm.transport != nil {
+			if since := pm.transport.ActiveSince(raftTypes.ID(raftId)); !since.IsZero() {
+				link.Healthy, link.Since = true, &since
+			}
+		}
+		topology.Links = append(topology.Links, link)
+	}
+}
@@ -47,6 +47,9 @@ func (pm *ProtocolManager) replayWAL(maybeRaftSnapshot *raftpb.Snapshot) *wal.WA
 	if err != nil {
 		glog.Fatalf("failed to read WAL: %v", err)
 	}
+	if err := pm.walCipher.openEntries(entries); err != nil {
+		glog.Fatalf("failed to read WAL: %v", err)
+	}
 
 	pm.raftStorage.SetHardState(hardState)
 	pm.raftStorage.Append(entries)
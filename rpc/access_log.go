@@ -0,0 +1,230 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	"golang.org/x/net/context"
+)
+
+const (
+	// maxLoggedParams is the number of bytes of params included in access log
+	// entries, longer params are truncated.
+	maxLoggedParams = 512
+
+	redactedParams = "[redacted]"
+)
+
+var (
+	// redactedNamespaces are the namespaces whose params are never logged, as
+	// they carry passwords and keys.
+	redactedNamespaces = []string{"personal"}
+
+	// privateMarkers identify params carrying private transaction payloads.
+	privateMarkers = [][]byte{[]byte(`"privateFor"`), []byte(`"privateFrom"`)}
+)
+
+// Caller describes the remote end of the connection a request was received on.
+type Caller struct {
+	Transport     string // "http", "ws", "grpc" or the network of the connection, e.g. "unix"
+	RemoteAddr    string // address of the caller, if known
+	Identity      string // user the caller authenticated as, if known
+	CorrelationID string // correlation ID the caller attached to the request, if any
+}
+
+type (
+	callerKey      struct{}
+	correlationKey struct{}
+)
+
+// CallerFromContext returns the caller of the request being served.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok
+}
+
+// WithCorrelationID returns a copy of ctx carrying the given correlation ID,
+// overriding the one the caller attached to the request.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID of the request being
+// served, or an empty string if the caller didn't attach one.
+func CorrelationIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationKey{}).(string); ok {
+		return id
+	}
+	c, _ := CallerFromContext(ctx)
+	return c.CorrelationID
+}
+
+// callerConn is implemented by connections which know more about their caller
+// than the remote address.
+type callerConn interface {
+	caller() Caller
+}
+
+// connCaller returns the caller of the given connection.
+func connCaller(conn interface{}) Caller {
+	switch c := conn.(type) {
+	case callerConn:
+		return c.caller()
+	case net.Conn:
+		addr := c.RemoteAddr()
+		if addr == nil {
+			return Caller{}
+		}
+		return Caller{Transport: addr.Network(), RemoteAddr: addr.String()}
+	}
+	return Caller{}
+}
+
+// httpCaller returns the caller of an HTTP request. The identity is the user
+// name of basic authentication, or the X-Forwarded-User header set by an
+// authenticating proxy in front of the node. Clients attach correlation IDs
+// with the X-Correlation-ID header.
+func httpCaller(transport string, r *http.Request) Caller {
+	c := Caller{Transport: transport, RemoteAddr: r.RemoteAddr, CorrelationID: r.Header.Get("X-Correlation-ID")}
+	if user, _, ok := r.BasicAuth(); ok {
+		c.Identity = user
+	} else {
+		c.Identity = r.Header.Get("X-Forwarded-User")
+	}
+	return c
+}
+
+// AccessLog records the requests served by RPC servers for forensic and
+// capacity analysis. Every logged request is written as a single line JSON
+// object. Params of personal_ methods and of private transactions are
+// redacted, only their size is logged.
+type AccessLog struct {
+	sample float64
+
+	mu   sync.Mutex
+	w    io.Writer
+	rand *rand.Rand
+}
+
+// accessLogEntry is the record written for a request.
+type accessLogEntry struct {
+	Time       time.Time `json:"time"`
+	Transport  string    `json:"transport,omitempty"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	Identity   string    `json:"identity,omitempty"`
+	Method     string    `json:"method"`
+	Params     string    `json:"params,omitempty"`
+	ParamsSize int       `json:"paramsSize"`
+	Duration   float64   `json:"durationMs"`
+	Status     string    `json:"status"`
+	ErrorCode  int       `json:"errorCode,omitempty"`
+}
+
+// NewAccessLog creates an access log writing to w. Only the given fraction of
+// successful requests is logged, failed requests are always logged. A sample
+// rate outside of (0, 1] logs every request.
+func NewAccessLog(w io.Writer, sample float64) *AccessLog {
+	if sample <= 0 || sample > 1 {
+		sample = 1
+	}
+	return &AccessLog{w: w, sample: sample, rand: idGenerator()}
+}
+
+// SetAccessLog makes the server record the requests it serves in l. A nil log
+// disables access logging.
+func (s *Server) SetAccessLog(l *AccessLog) {
+	s.accessLog = l
+}
+
+// record writes an access log entry for the request, if it is sampled.
+func (l *AccessLog) record(ctx context.Context, req *serverRequest, start time.Time, response interface{}) {
+	if l == nil {
+		return
+	}
+	entry := accessLogEntry{
+		Time:     start.UTC(),
+		Method:   req.method,
+		Duration: float64(time.Since(start)) / float64(time.Millisecond),
+		Status:   "ok",
+	}
+	if res, ok := response.(*jsonErrResponse); ok {
+		entry.Status, entry.ErrorCode = "error", res.Error.Code
+	}
+	if caller, ok := CallerFromContext(ctx); ok {
+		entry.Transport, entry.RemoteAddr, entry.Identity = caller.Transport, caller.RemoteAddr, caller.Identity
+	}
+	if params, ok := req.params.(json.RawMessage); ok {
+		entry.ParamsSize = len(params)
+		entry.Params = loggedParams(req.method, params)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.Status == "ok" && l.sample < 1 && l.rand.Float64() >= l.sample {
+		return
+	}
+	blob, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if _, err := l.w.Write(append(blob, '\n')); err != nil {
+		glog.V(logger.Warn).Infof("Failed to write RPC access log: %v", err)
+	}
+}
+
+// loggedParams returns the params of a request as included in the access log.
+func loggedParams(method string, params json.RawMessage) string {
+	for _, ns := range redactedNamespaces {
+		if strings.HasPrefix(method, ns+serviceMethodSeparator) {
+			return redactedParams
+		}
+	}
+	for _, marker := range privateMarkers {
+		if bytes.Contains(params, marker) {
+			return redactedParams
+		}
+	}
+	if len(params) > maxLoggedParams {
+		return string(params[:maxLoggedParams]) + "..."
+	}
+	return string(params)
+}
+
+// requestMethod returns the full name of the method called by a request.
+func requestMethod(r rpcRequest) string {
+	switch {
+	case r.isPubSub && r.method == unsubscribeMethod:
+		return unsubscribeMethod
+	case r.isPubSub:
+		return subscribeMethod
+	case r.service == "":
+		return r.method
+	}
+	return r.service + serviceMethodSeparator + r.method
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+// postRequest sends a JSON-RPC request to an HTTP server as user.
+func postRequest(t *testing.T, url, user, body string) {
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.SetBasicAuth(user, "secret")
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+}
+
+func readAccessLog(t *testing.T, buf *bytes.Buffer) []accessLogEntry {
+	var entries []accessLogEntry
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var entry accessLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid access log line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLog(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	if err := server.RegisterName("personal", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	server.SetAccessLog(NewAccessLog(buf, 1))
+
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+
+	postRequest(t, hs.URL, "alice", `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":["a",1,{"S":"b"}]}`)
+	postRequest(t, hs.URL, "", `{"jsonrpc":"2.0","id":2,"method":"personal_echo","params":["pass",1,{"S":"b"}]}`)
+	postRequest(t, hs.URL, "", `[{"jsonrpc":"2.0","id":3,"method":"service_echo","params":["a",1,{"S":"b","privateFor":["key"]}]},
+		{"jsonrpc":"2.0","id":4,"method":"service_echo","params":["a"]}]`)
+
+	entries := readAccessLog(t, buf)
+	if len(entries) != 4 {
+		t.Fatalf("have %d entries, want 4: %+v", len(entries), entries)
+	}
+	first := entries[0]
+	if first.Method != "service_echo" || first.Params != `["a",1,{"S":"b"}]` || first.ParamsSize != 17 || first.Status != "ok" {
+		t.Errorf("request entry mismatch: %+v", first)
+	}
+	if first.Transport != "http" || first.Identity != "alice" || first.RemoteAddr == "" {
+		t.Errorf("caller mismatch: %+v", first)
+	}
+	if entries[1].Method != "personal_echo" || entries[1].Params != redactedParams || entries[1].ParamsSize == 0 {
+		t.Errorf("personal params not redacted: %+v", entries[1])
+	}
+	if entries[2].Params != redactedParams || entries[2].Status != "ok" {
+		t.Errorf("private params not redacted: %+v", entries[2])
+	}
+	if entries[3].Status != "error" || entries[3].ErrorCode != -32602 {
+		t.Errorf("failed request entry mismatch: %+v", entries[3])
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	buf := new(bytes.Buffer)
+	log := NewAccessLog(buf, 0.5)
+	log.rand = rand.New(rand.NewSource(1))
+	server.SetAccessLog(log)
+
+	hs := httptest.NewServer(server)
+	defer hs.Close()
+
+	for i := 0; i < 50; i++ {
+		postRequest(t, hs.URL, "", `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":["a",1,{"S":"b"}]}`)
+		postRequest(t, hs.URL, "", `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":[]}`)
+	}
+	var ok, failed int
+	for _, entry := range readAccessLog(t, buf) {
+		if entry.Status == "ok" {
+			ok++
+		} else {
+			failed++
+		}
+	}
+	if ok == 0 || ok == 50 {
+		t.Errorf("%d of 50 successful requests logged at sample rate 0.5", ok)
+	}
+	if failed != 50 {
+		t.Errorf("%d of 50 failed requests logged, want all", failed)
+	}
+}
+
+// Tests that correlation IDs are taken from the request header, unless they're
+// overridden by the served method.
+func TestCorrelationID(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-Correlation-ID", "order-42")
+	ctx := context.WithValue(context.Background(), callerKey{}, httpCaller("http", req))
+
+	if id := CorrelationIDFromContext(ctx); id != "order-42" {
+		t.Errorf("header correlation ID mismatch: have %q, want %q", id, "order-42")
+	}
+	if id := CorrelationIDFromContext(WithCorrelationID(ctx, "order-43")); id != "order-43" {
+		t.Errorf("overridden correlation ID mismatch: have %q, want %q", id, "order-43")
+	}
+	if id := CorrelationIDFromContext(context.Background()); id != "" {
+		t.Errorf("correlation ID without caller: %q", id)
+	}
+}
+
+// Tests that requests of in-process connections on behalf of a remote caller
+// are attributed to that caller.
+func TestDialInProcAs(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	defer server.Stop()
+	buf := new(bytes.Buffer)
+	server.SetAccessLog(NewAccessLog(buf, 1))
+
+	client := DialInProcAs(server, Caller{Transport: "grpc", RemoteAddr: "10.0.0.1:4000", Identity: "settlement"})
+	defer client.Close()
+	var result Result
+	if err := client.Call(&result, "service_echo", "a", 1, &Args{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	entries := readAccessLog(t, buf)
+	if len(entries) != 1 {
+		t.Fatalf("have %d entries, want 1: %+v", len(entries), entries)
+	}
+	if entries[0].Transport != "grpc" || entries[0].RemoteAddr != "10.0.0.1:4000" || entries[0].Identity != "settlement" {
+		t.Errorf("caller mismatch: %+v", entries[0])
+	}
+}
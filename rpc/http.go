@@ -130,6 +130,11 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 type httpReadWriteNopCloser struct {
 	io.Reader
 	io.Writer
+	req *http.Request
+}
+
+func (t *httpReadWriteNopCloser) caller() Caller {
+	return httpCaller("http", t.req)
 }
 
 // Close does nothing and returns always nil
@@ -157,7 +162,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// create a codec that reads direct from the request body until
 	// EOF and writes the response to w and order the server to process
 	// a single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w, r})
 	defer codec.Close()
 	srv.ServeSingleRequest(codec, OptionMethodInvocation)
 }
@@ -32,3 +32,26 @@ func DialInProc(handler *Server) *Client {
 	})
 	return c
 }
+
+// DialInProcAs attaches an in-process connection to the given RPC server on
+// behalf of a remote caller, which the server's handlers and access log see as
+// the caller of the requests. It lets other transports bridge into the server.
+func DialInProcAs(handler *Server, caller Caller) *Client {
+	initctx := context.Background()
+	c, _ := newClient(initctx, func(context.Context) (net.Conn, error) {
+		p1, p2 := net.Pipe()
+		go handler.ServeCodec(NewJSONCodec(&callerPipe{p1, caller}), OptionMethodInvocation|OptionSubscriptions)
+		return p2, nil
+	})
+	return c
+}
+
+// callerPipe is an in-process connection reporting a remote caller.
+type callerPipe struct {
+	net.Conn
+	c Caller
+}
+
+func (p *callerPipe) caller() Caller {
+	return p.c
+}
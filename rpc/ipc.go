@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"errors"
 	"net"
 
 	"github.com/ethereum/go-ethereum/logger"
@@ -30,6 +31,25 @@ func CreateIPCListener(endpoint string) (net.Listener, error) {
 	return ipcListen(endpoint)
 }
 
+// errIPCSecurity is returned when restricting access to the IPC endpoint with a
+// security descriptor on platforms other than Windows.
+var errIPCSecurity = errors.New("IPC security descriptors are only supported for named pipes on Windows")
+
+// CreateIPCListenerWithSecurity creates an IPC listener which only the accounts
+// granted access by the given security descriptor, in SDDL, can connect to.
+// Security descriptors are only supported for named pipes on Windows, an empty
+// one creates the listener with the default security of the platform.
+func CreateIPCListenerWithSecurity(endpoint, sddl string) (net.Listener, error) {
+	return ipcListenSecurity(endpoint, sddl)
+}
+
+// IPCGroupsDescriptor creates a security descriptor, in SDDL, which restricts
+// access to the IPC endpoint to the given local groups, given by name or SID,
+// besides the system, administrators and the account running the node.
+func IPCGroupsDescriptor(groups []string) (string, error) {
+	return ipcGroupsDescriptor(groups)
+}
+
 // ServeListener accepts connections on l, serving JSON-RPC on them.
 func (srv *Server) ServeListener(l net.Listener) error {
 	for {
@@ -41,6 +41,19 @@ func ipcListen(endpoint string) (net.Listener, error) {
 	return l, nil
 }
 
+// ipcListenSecurity creates a Unix socket, which doesn't support security
+// descriptors.
+func ipcListenSecurity(endpoint, sddl string) (net.Listener, error) {
+	if sddl != "" {
+		return nil, errIPCSecurity
+	}
+	return ipcListen(endpoint)
+}
+
+func ipcGroupsDescriptor(groups []string) (string, error) {
+	return "", errIPCSecurity
+}
+
 // newIPCConnection will connect to a Unix socket on the given endpoint.
 func newIPCConnection(ctx context.Context, endpoint string) (net.Conn, error) {
 	return dialContext(ctx, "unix", endpoint)
@@ -19,8 +19,14 @@
 package rpc
 
 import (
+	"fmt"
+	"io"
 	"net"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unsafe"
 
 	"golang.org/x/net/context"
 	"gopkg.in/natefinch/npipe.v2"
@@ -35,6 +41,333 @@ func ipcListen(endpoint string) (net.Listener, error) {
 	return npipe.Listen(endpoint)
 }
 
+var (
+	procConvertStringSecurityDescriptor = syscall.NewLazyDLL("advapi32.dll").NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+
+	modkernel32             = syscall.NewLazyDLL("kernel32.dll")
+	procCreateNamedPipe     = modkernel32.NewProc("CreateNamedPipeW")
+	procConnectNamedPipe    = modkernel32.NewProc("ConnectNamedPipe")
+	procCreateEvent         = modkernel32.NewProc("CreateEventW")
+	procGetOverlappedResult = modkernel32.NewProc("GetOverlappedResult")
+)
+
+const (
+	// sddlRevision is the only revision of SDDL.
+	sddlRevision = 1
+
+	// Pipe instances are created like npipe.Listen does, so clients can't
+	// tell the difference.
+	pipeAccessDuplex          = 0x3
+	fileFlagFirstPipeInstance = 0x00080000
+	fileFlagOverlapped        = 0x40000000
+	pipeTypeByte              = 0x0
+	pipeUnlimitedInstances    = 255
+	pipeBufferSize            = 512
+
+	errorNoData        syscall.Errno = 0xE8  // client connected and went away again
+	errorPipeConnected syscall.Errno = 0x217 // client connected before ConnectNamedPipe
+)
+
+// ipcListenSecurity will create a named pipe on the given endpoint, with all
+// instances of the pipe created with the given security descriptor.
+func ipcListenSecurity(endpoint, sddl string) (net.Listener, error) {
+	if sddl == "" {
+		return ipcListen(endpoint)
+	}
+	sa, err := securityAttributes(sddl)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := createPipe(endpoint, true, sa)
+	if err != nil {
+		return nil, err
+	}
+	return &securePipeListener{addr: npipe.PipeAddr(endpoint), sa: sa, handle: handle}, nil
+}
+
+// securityAttributes converts a security descriptor in SDDL to the security
+// attributes pipes are created with. The descriptor is never freed as it's
+// needed for every new instance of the pipe.
+func securityAttributes(sddl string) (*syscall.SecurityAttributes, error) {
+	str, err := syscall.UTF16PtrFromString(sddl)
+	if err != nil {
+		return nil, err
+	}
+	var sd uintptr
+	if r, _, err := procConvertStringSecurityDescriptor.Call(uintptr(unsafe.Pointer(str)), sddlRevision, uintptr(unsafe.Pointer(&sd)), 0); r == 0 {
+		return nil, fmt.Errorf("invalid security descriptor %q: %v", sddl, err)
+	}
+	sa := &syscall.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+	return sa, nil
+}
+
+// securePipeListener accepts connections on a named pipe like npipe.Listen,
+// but creates every instance of the pipe with the given security attributes
+// instead of the default security descriptor.
+type securePipeListener struct {
+	addr npipe.PipeAddr
+	sa   *syscall.SecurityAttributes
+
+	mu      sync.Mutex
+	handle  syscall.Handle      // instance waiting for the next client, 0 if none
+	pending *syscall.Overlapped // connect in progress on handle, nil if none
+	closed  bool
+}
+
+// Accept waits for the next client to connect to the pipe.
+func (l *securePipeListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.accept()
+		if err == errorNoData {
+			continue // ignore clients that connect and immediately disconnect
+		}
+		return conn, err
+	}
+}
+
+func (l *securePipeListener) accept() (net.Conn, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, npipe.ErrClosed
+	}
+	// The first instance is created when listening, so clients can connect
+	// right away. Later ones are created once the previous one is taken.
+	if l.handle == 0 {
+		handle, err := createPipe(string(l.addr), false, l.sa)
+		if err != nil {
+			l.mu.Unlock()
+			return nil, err
+		}
+		l.handle = handle
+	}
+	handle := l.handle
+	overlapped, err := newOverlapped()
+	if err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+	defer syscall.CloseHandle(overlapped.HEvent)
+
+	// The connect is started while holding the lock, so Close can always
+	// cancel it
+	err = connectNamedPipe(handle, overlapped)
+	if err == syscall.ERROR_IO_PENDING {
+		l.pending = overlapped
+		l.mu.Unlock()
+		_, err = getOverlappedResult(handle, overlapped)
+		l.mu.Lock()
+	}
+	defer l.mu.Unlock()
+
+	// The instance is taken by the client, or discarded. If Close cancelled
+	// the connect, it left closing the instance to us.
+	l.handle, l.pending = 0, nil
+	switch {
+	case l.closed:
+		syscall.CloseHandle(handle)
+		return nil, npipe.ErrClosed
+	case err != nil && err != errorPipeConnected:
+		syscall.CloseHandle(handle)
+		return nil, err
+	}
+	return &pipeConn{handle: handle, addr: l.addr}, nil
+}
+
+// Close stops listening on the pipe. Accepted connections are not closed.
+func (l *securePipeListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	if l.handle == 0 {
+		return nil
+	}
+	if l.pending != nil {
+		// Accept closes the handle once the connect is aborted
+		return syscall.CancelIoEx(l.handle, l.pending)
+	}
+	err := syscall.CloseHandle(l.handle)
+	l.handle = 0
+	return err
+}
+
+// Addr returns the name of the pipe.
+func (l *securePipeListener) Addr() net.Addr { return l.addr }
+
+// pipeConn is a connected instance of a pipe created by securePipeListener.
+// I/O is overlapped, as the pipe is, so deadlines can abort it.
+type pipeConn struct {
+	handle syscall.Handle
+	addr   npipe.PipeAddr
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.readDeadline
+	c.mu.Unlock()
+
+	return c.do(deadline, func(n *uint32, overlapped *syscall.Overlapped) error {
+		// ReadFile rather than syscall.Read, which hides ERROR_BROKEN_PIPE
+		return syscall.ReadFile(c.handle, b, n, overlapped)
+	})
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline := c.writeDeadline
+	c.mu.Unlock()
+
+	return c.do(deadline, func(n *uint32, overlapped *syscall.Overlapped) error {
+		return syscall.WriteFile(c.handle, b, n, overlapped)
+	})
+}
+
+// do starts an I/O request and waits for it to complete, aborting it when the
+// deadline passes.
+func (c *pipeConn) do(deadline time.Time, request func(*uint32, *syscall.Overlapped) error) (int, error) {
+	overlapped, err := newOverlapped()
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.CloseHandle(overlapped.HEvent)
+
+	var n uint32
+	if err = request(&n, overlapped); err == syscall.ERROR_IO_PENDING {
+		wait := uint32(syscall.INFINITE)
+		if !deadline.IsZero() {
+			wait = 0
+			if d := deadline.Sub(time.Now()); d > 0 {
+				wait = uint32(d / time.Millisecond)
+			}
+		}
+		if ev, _ := syscall.WaitForSingleObject(overlapped.HEvent, wait); ev == syscall.WAIT_TIMEOUT {
+			syscall.CancelIoEx(c.handle, overlapped)
+			getOverlappedResult(c.handle, overlapped)
+			return 0, pipeTimeoutError{}
+		}
+		n, err = getOverlappedResult(c.handle, overlapped)
+	}
+	// The other end closing the pipe is the end of the stream
+	if err == syscall.ERROR_BROKEN_PIPE {
+		err = io.EOF
+	}
+	return int(n), err
+}
+
+func (c *pipeConn) Close() error {
+	return syscall.CloseHandle(c.handle)
+}
+
+func (c *pipeConn) LocalAddr() net.Addr {
+	return c.addr
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	// Clients of a pipe don't have an address of their own
+	return c.addr
+}
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+// pipeTimeoutError is returned by pipe I/O aborted by a deadline.
+type pipeTimeoutError struct{}
+
+func (pipeTimeoutError) Error() string   { return "i/o timeout" }
+func (pipeTimeoutError) Timeout() bool   { return true }
+func (pipeTimeoutError) Temporary() bool { return true }
+
+// createPipe creates an instance of the named pipe at address. The first
+// instance fails if the pipe exists already.
+func createPipe(address string, first bool, sa *syscall.SecurityAttributes) (syscall.Handle, error) {
+	name, err := syscall.UTF16PtrFromString(address)
+	if err != nil {
+		return 0, err
+	}
+	mode := uint32(pipeAccessDuplex | fileFlagOverlapped)
+	if first {
+		mode |= fileFlagFirstPipeInstance
+	}
+	handle, _, err := procCreateNamedPipe.Call(uintptr(unsafe.Pointer(name)), uintptr(mode), pipeTypeByte, pipeUnlimitedInstances, pipeBufferSize, pipeBufferSize, 0, uintptr(unsafe.Pointer(sa)))
+	if syscall.Handle(handle) == syscall.InvalidHandle {
+		return 0, fmt.Errorf("can't create pipe %s: %v", address, err)
+	}
+	return syscall.Handle(handle), nil
+}
+
+// connectNamedPipe starts waiting for a client to connect to the pipe.
+func connectNamedPipe(handle syscall.Handle, overlapped *syscall.Overlapped) error {
+	if r, _, err := procConnectNamedPipe.Call(uintptr(handle), uintptr(unsafe.Pointer(overlapped))); r == 0 {
+		return err
+	}
+	return nil
+}
+
+// newOverlapped creates the state of an overlapped I/O request, with a manual
+// reset event signalled on completion.
+func newOverlapped() (*syscall.Overlapped, error) {
+	event, _, err := procCreateEvent.Call(0, 1, 1, 0)
+	if event == 0 {
+		return nil, err
+	}
+	return &syscall.Overlapped{HEvent: syscall.Handle(event)}, nil
+}
+
+// getOverlappedResult waits for an overlapped I/O request to complete and
+// returns the number of bytes transferred.
+func getOverlappedResult(handle syscall.Handle, overlapped *syscall.Overlapped) (uint32, error) {
+	var n uint32
+	if r, _, err := procGetOverlappedResult.Call(uintptr(handle), uintptr(unsafe.Pointer(overlapped)), uintptr(unsafe.Pointer(&n)), 1); r == 0 {
+		return n, err
+	}
+	return n, nil
+}
+
+func ipcGroupsDescriptor(groups []string) (string, error) {
+	// Protected DACL granting full access to the system, administrators and
+	// the owner of the pipe, i.e. the account running the node
+	sddl := "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;OW)"
+	for _, group := range groups {
+		sid := group
+		if !strings.HasPrefix(group, "S-1-") {
+			s, _, _, err := syscall.LookupSID("", group)
+			if err != nil {
+				return "", fmt.Errorf("unknown group %q: %v", group, err)
+			}
+			if sid, err = s.String(); err != nil {
+				return "", err
+			}
+		}
+		sddl += "(A;;GRGW;;;" + sid + ")"
+	}
+	return sddl, nil
+}
+
 // newIPCConnection will connect to a named pipe with the given endpoint as name.
 func newIPCConnection(ctx context.Context, endpoint string) (net.Conn, error) {
 	timeout := defaultPipeDialTimeout
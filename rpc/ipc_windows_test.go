@@ -0,0 +1,94 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build windows
+
+package rpc
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Tests that group restrictions are turned into valid security descriptors
+// which the account running the node can still connect through.
+func TestIPCGroupsDescriptor(t *testing.T) {
+	sddl, err := IPCGroupsDescriptor([]string{"Users", "S-1-5-32-555"})
+	if err != nil {
+		t.Fatalf("failed to create descriptor: %v", err)
+	}
+	want := "D:P(A;;GA;;;SY)(A;;GA;;;BA)(A;;GA;;;OW)(A;;GRGW;;;S-1-5-32-545)(A;;GRGW;;;S-1-5-32-555)"
+	if sddl != want {
+		t.Fatalf("descriptor mismatch:\nhave %s\nwant %s", sddl, want)
+	}
+	if _, err := securityAttributes(sddl); err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	if _, err := securityAttributes("D:(X;;;)"); err == nil {
+		t.Errorf("invalid descriptor accepted")
+	}
+	if _, err := IPCGroupsDescriptor([]string{"no such group"}); err == nil {
+		t.Errorf("unknown group accepted")
+	}
+
+	endpoint := `\\.\pipe\geth-ipc-security-test`
+	listener, err := CreateIPCListenerWithSecurity(endpoint, sddl)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go func() {
+		// Echo a single message
+		if conn, err := listener.Accept(); err == nil {
+			buf := make([]byte, 5)
+			if n, err := conn.Read(buf); err == nil {
+				conn.Write(buf[:n])
+			}
+			conn.Close()
+		}
+	}()
+	conn, err := newIPCConnection(context.Background(), endpoint)
+	if err != nil {
+		t.Fatalf("owner can't connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("echo mismatch: have %q (%v)", buf, err)
+	}
+
+	// Closing the listener stops a pending accept
+	accepted := make(chan error)
+	go func() {
+		_, err := listener.Accept()
+		accepted <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	listener.Close()
+	select {
+	case err := <-accepted:
+		if err == nil {
+			t.Errorf("accepted a connection after closing")
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("accept not stopped by closing the listener")
+	}
+}
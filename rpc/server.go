@@ -21,6 +21,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
@@ -168,6 +169,9 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	if options&OptionSubscriptions == OptionSubscriptions {
 		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec))
 	}
+	if conn, ok := codec.(callerConn); ok {
+		ctx = context.WithValue(ctx, callerKey{}, conn.caller())
+	}
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
 		s.codecsMu.Unlock()
@@ -323,6 +327,14 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			if dataErr, ok := e.(DataError); ok {
+				// preserve application specific error codes and data
+				return codec.CreateErrorResponseWithInfo(&req.id, dataErr, dataErr.ErrorData()), nil
+			}
+			if rpcErr, ok := e.(Error); ok {
+				// preserve application specific error codes
+				return codec.CreateErrorResponse(&req.id, rpcErr), nil
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -334,11 +346,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
 	var callback func()
+	start := time.Now()
 	if req.err != nil {
 		response = codec.CreateErrorResponse(&req.id, req.err)
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	s.accessLog.record(ctx, req, start, response)
 
 	if err := codec.Write(response); err != nil {
 		glog.V(logger.Error).Infof("%v\n", err)
@@ -357,6 +371,7 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
 	for i, req := range requests {
+		start := time.Now()
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
 		} else {
@@ -365,6 +380,7 @@ func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*s
 				callbacks = append(callbacks, callback)
 			}
 		}
+		s.accessLog.record(ctx, req, start, responses[i])
 	}
 
 	if err := codec.Write(responses); err != nil {
@@ -448,5 +464,10 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service, r.method}}
 	}
 
+	if s.accessLog != nil {
+		for i, r := range reqs {
+			requests[i].method, requests[i].params = requestMethod(r), r.params
+		}
+	}
 	return requests, batch, nil
 }
@@ -161,3 +161,43 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+type dataError struct{}
+
+func (e *dataError) Error() string          { return "custom error" }
+func (e *dataError) ErrorCode() int         { return -32099 }
+func (e *dataError) ErrorData() interface{} { return map[string]string{"reason": "test"} }
+
+type ErrorService struct{}
+
+func (s *ErrorService) Fail() (string, error) {
+	return "", &dataError{}
+}
+
+func TestServerErrorCodeAndData(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(ErrorService)); err != nil {
+		t.Fatal(err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	request := map[string]interface{}{"id": 1, "method": "test_fail", "version": "2.0", "params": []interface{}{}}
+	if err := json.NewEncoder(clientConn).Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response jsonErrResponse
+	if err := json.NewDecoder(clientConn).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error.Code != -32099 {
+		t.Errorf("error code mismatch: have %d, want %d", response.Error.Code, -32099)
+	}
+	want := map[string]interface{}{"reason": "test"}
+	if !reflect.DeepEqual(response.Error.Data, want) {
+		t.Errorf("error data mismatch: have %v, want %v", response.Error.Data, want)
+	}
+}
@@ -65,6 +65,8 @@ type serverRequest struct {
 	args          []reflect.Value
 	isUnsubscribe bool
 	err           Error
+	method        string      // full method name, for the access log
+	params        interface{} // raw params, for the access log
 }
 
 type serviceRegistry map[string]*service       // collection of services
@@ -81,6 +83,8 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	accessLog *AccessLog
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -99,6 +103,13 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// DataError is an Error that carries additional structured information, which
+// is returned in the data field of the JSON-RPC error object.
+type DataError interface {
+	Error
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
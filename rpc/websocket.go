@@ -18,31 +18,73 @@ package rpc
 
 import (
 	"crypto/tls"
-	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/logger"
 	"github.com/ethereum/go-ethereum/logger/glog"
+	"github.com/gorilla/websocket"
 	"golang.org/x/net/context"
-	"golang.org/x/net/websocket"
+	xwebsocket "golang.org/x/net/websocket"
 	"gopkg.in/fatih/set.v0"
 )
 
+const (
+	wsBufferSize         = 4096
+	defaultWSPongTimeout = 30 * time.Second
+)
+
+// WebsocketConfig holds the connection options of a websocket RPC endpoint.
+type WebsocketConfig struct {
+	// Compression enables negotiation of the permessage-deflate extension with
+	// clients that support it.
+	Compression bool
+
+	// PingInterval is the interval at which keepalive pings are sent to the
+	// client. Zero disables keepalive pings.
+	PingInterval time.Duration
+
+	// PongTimeout is how long to wait for the pong answering a ping before the
+	// connection is dropped. It defaults to 30 seconds.
+	PongTimeout time.Duration
+}
+
 // WebsocketHandler returns a handler that serves JSON-RPC to WebSocket connections.
 //
 // allowedOrigins should be a comma-separated list of allowed origin URLs.
 // To allow connections with any origin, pass "*".
 func (srv *Server) WebsocketHandler(allowedOrigins string) http.Handler {
-	return websocket.Server{
-		Handshake: wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
-		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
-		},
+	return srv.WebsocketHandlerWithConfig(allowedOrigins, WebsocketConfig{})
+}
+
+// WebsocketHandlerWithConfig is like WebsocketHandler, but allows compression
+// and keepalive pings to be configured.
+func (srv *Server) WebsocketHandlerWithConfig(allowedOrigins string, config WebsocketConfig) http.Handler {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:    wsBufferSize,
+		WriteBufferSize:   wsBufferSize,
+		CheckOrigin:       wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
+		EnableCompression: config.Compression,
 	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already replied to the client.
+			glog.V(logger.Debug).Infof("WS-RPC upgrade failed: %v", err)
+			return
+		}
+		stop := make(chan struct{})
+		if config.PingInterval > 0 {
+			startWSKeepalive(conn, config, stop)
+		}
+		srv.ServeCodec(NewJSONCodec(&wsConn{conn: conn, req: r}), OptionMethodInvocation|OptionSubscriptions)
+		close(stop)
+	})
 }
 
 // NewWSServer creates a new websocket RPC server around an API provider.
@@ -52,10 +94,10 @@ func NewWSServer(allowedOrigins string, srv *Server) *http.Server {
 	return &http.Server{Handler: srv.WebsocketHandler(allowedOrigins)}
 }
 
-// wsHandshakeValidator returns a handler that verifies the origin during the
+// wsHandshakeValidator returns a function that verifies the origin during the
 // websocket upgrade process. When a '*' is specified as an allowed origins all
 // connections are accepted.
-func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http.Request) error {
+func wsHandshakeValidator(allowedOrigins []string) func(*http.Request) bool {
 	origins := set.New()
 	allowAllOrigins := false
 
@@ -78,18 +120,94 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 
 	glog.V(logger.Debug).Infof("Allowed origin(s) for WS RPC interface %v\n", origins.List())
 
-	f := func(cfg *websocket.Config, req *http.Request) error {
+	f := func(req *http.Request) bool {
 		origin := strings.ToLower(req.Header.Get("Origin"))
 		if allowAllOrigins || origins.Has(origin) {
-			return nil
+			return true
 		}
 		glog.V(logger.Debug).Infof("origin '%s' not allowed on WS-RPC interface\n", origin)
-		return fmt.Errorf("origin %s not allowed", origin)
+		return false
 	}
 
 	return f
 }
 
+// startWSKeepalive pings the client every PingInterval until stop is closed.
+// The read deadline of the connection is extended whenever a pong arrives, so
+// a client which stops answering is disconnected after PongTimeout.
+func startWSKeepalive(conn *websocket.Conn, config WebsocketConfig, stop <-chan struct{}) {
+	timeout := config.PongTimeout
+	if timeout <= 0 {
+		timeout = defaultWSPongTimeout
+	}
+	conn.SetReadDeadline(time.Now().Add(config.PingInterval + timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(config.PingInterval + timeout))
+	})
+
+	go func() {
+		ticker := time.NewTicker(config.PingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(timeout)); err != nil {
+					glog.V(logger.Debug).Infof("WS-RPC ping failed: %v", err)
+					conn.Close()
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// wsConn adapts a message based websocket connection to the byte stream
+// expected by the JSON codec. Every write is sent as a single text message.
+type wsConn struct {
+	conn *websocket.Conn
+	req  *http.Request // upgraded request
+	r    io.Reader     // reader of the current message
+}
+
+func (c *wsConn) caller() Caller {
+	return httpCaller("ws", c.req)
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	for {
+		if c.r == nil {
+			_, r, err := c.conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.r = r
+		}
+		n, err := c.r.Read(p)
+		if err == io.EOF {
+			c.r = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.conn.WriteMessage(websocket.TextMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
 // DialWebsocket creates a new RPC client that communicates with a JSON-RPC server
 // that is listening on the given endpoint.
 //
@@ -107,7 +225,7 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 			origin = "http://" + strings.ToLower(origin)
 		}
 	}
-	config, err := websocket.NewConfig(endpoint, origin)
+	config, err := xwebsocket.NewConfig(endpoint, origin)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +235,7 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 	})
 }
 
-func wsDialContext(ctx context.Context, config *websocket.Config) (*websocket.Conn, error) {
+func wsDialContext(ctx context.Context, config *xwebsocket.Config) (*xwebsocket.Conn, error) {
 	var conn net.Conn
 	var err error
 	switch config.Location.Scheme {
@@ -127,12 +245,12 @@ func wsDialContext(ctx context.Context, config *websocket.Config) (*websocket.Co
 		dialer := contextDialer(ctx)
 		conn, err = tls.DialWithDialer(dialer, "tcp", wsDialAddress(config.Location), config.TlsConfig)
 	default:
-		err = websocket.ErrBadScheme
+		err = xwebsocket.ErrBadScheme
 	}
 	if err != nil {
 		return nil, err
 	}
-	ws, err := websocket.NewClient(config, conn)
+	ws, err := xwebsocket.NewClient(config, conn)
 	if err != nil {
 		conn.Close()
 		return nil, err
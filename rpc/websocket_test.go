@@ -0,0 +1,98 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/net/context"
+)
+
+func TestWebsocketCompression(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	defer srv.Stop()
+	hs := httptest.NewServer(srv.WebsocketHandlerWithConfig("*", WebsocketConfig{Compression: true}))
+	defer hs.Close()
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws://"+hs.Listener.Addr().String(), http.Header{"Origin": {"http://localhost"}})
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer conn.Close()
+	if ext := resp.Header.Get("Sec-Websocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Fatalf("compression not negotiated, extensions: %q", ext)
+	}
+
+	req := `{"jsonrpc":"2.0","id":1,"method":"service_echo","params":["x",1,null]}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(msg), `"result":{"String":"x","Int":1`) {
+		t.Fatalf("unexpected response: %s", msg)
+	}
+}
+
+func TestWebsocketKeepalive(t *testing.T) {
+	srv := newTestServer("service", new(Service))
+	defer srv.Stop()
+	config := WebsocketConfig{PingInterval: 50 * time.Millisecond, PongTimeout: 50 * time.Millisecond}
+	hs := httptest.NewServer(srv.WebsocketHandlerWithConfig("*", config))
+	defer hs.Close()
+	url := "ws://" + hs.Listener.Addr().String()
+
+	// The RPC client answers pings, so it stays connected.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := DialWebsocket(ctx, url, "")
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer client.Close()
+	time.Sleep(300 * time.Millisecond)
+	var resp Result
+	if err := client.CallContext(ctx, &resp, "service_echo", "x", 1, nil); err != nil {
+		t.Fatal("call after keepalive period failed:", err)
+	}
+
+	// A client that doesn't read never answers pings and gets dropped.
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://localhost"}})
+	if err != nil {
+		t.Fatal("can't dial:", err)
+	}
+	defer conn.Close()
+	time.Sleep(300 * time.Millisecond)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if netErr, ok := err.(interface{ Timeout() bool }); ok && netErr.Timeout() {
+				t.Fatal("connection wasn't dropped")
+			}
+			break
+		}
+	}
+}
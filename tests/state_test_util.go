@@ -228,7 +228,11 @@ func RunState(ruleSet RuleSet, statedb *state.StateDB, env, tx map[string]string
 	message := NewMessage(addr, to, data, value, gas, price, nonce)
 	vmenv := NewEnvFromMap(ruleSet, statedb, env, tx)
 	vmenv.origin = addr
-	ret, _, err := core.ApplyMessage(vmenv, message, gaspool)
+	var ret []byte
+	result, err := core.ApplyMessage(vmenv, message, gaspool)
+	if err == nil {
+		ret = result.Return()
+	}
 	if core.IsNonceErr(err) || core.IsInvalidTxErr(err) || core.IsGasLimitErr(err) {
 		statedb.RevertToSnapshot(snapshot)
 	}
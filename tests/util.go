@@ -159,6 +159,13 @@ func (r RuleSet) IsHomestead(n *big.Int) bool {
 	return n.Cmp(r.HomesteadBlock) >= 0
 }
 
+func (r RuleSet) IsByzantium(n *big.Int) bool { return false }
+
+func (r RuleSet) Precompile(common.Address, *big.Int) vm.PrecompiledContract { return nil }
+
+func (r RuleSet) MaxCodeSize(*big.Int) int { return 0 }
+func (r RuleSet) Limits(*big.Int) vm.Limits { return vm.DefaultLimits }
+
 func (r RuleSet) GasTable(num *big.Int) params.GasTable {
 	if r.HomesteadGasRepriceBlock == nil || num == nil || num.Cmp(r.HomesteadGasRepriceBlock) < 0 {
 		return params.GasTableHomestead
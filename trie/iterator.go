@@ -26,6 +26,7 @@ type Iterator struct {
 
 	Key   []byte // Current data key on which the iterator is positioned on
 	Value []byte // Current data value on which the iterator is positioned on
+	Err   error  // Failure of the underlying node iterator, if iteration stopped early
 }
 
 // NewIterator creates a new key-value iterator.
@@ -49,6 +50,7 @@ func (it *Iterator) Next() bool {
 	}
 	it.Key = nil
 	it.Value = nil
+	it.Err = it.nodeIt.Error
 	return false
 }
 
@@ -24,6 +24,11 @@ import (
 
 var secureKeyPrefix = []byte("secure-key-")
 
+// RecordPreimages sets whether secure tries write the preimages of their hashed
+// keys to the database on commit. GetKey only returns the preimages of keys
+// written since the trie was opened otherwise.
+var RecordPreimages = true
+
 const secureKeyLength = 11 + 32 // Length of the above prefix + 32byte hash
 
 // SecureTrie wraps a trie with key hashing. In a secure trie, all
@@ -171,9 +176,11 @@ func (t *SecureTrie) NodeIterator() *NodeIterator {
 // written back to the trie's attached database before using the trie.
 func (t *SecureTrie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
 	if len(t.getSecKeyCache()) > 0 {
-		for hk, key := range t.secKeyCache {
-			if err := db.Put(t.secKey([]byte(hk)), key); err != nil {
-				return common.Hash{}, err
+		if RecordPreimages {
+			for hk, key := range t.secKeyCache {
+				if err := db.Put(t.secKey([]byte(hk)), key); err != nil {
+					return common.Hash{}, err
+				}
 			}
 		}
 		t.secKeyCache = make(map[string][]byte)
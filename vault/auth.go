@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	vaultAPI "github.com/hashicorp/vault/api"
+)
+
+// Methods the node can log in to Vault with.
+const (
+	AuthAWS        = "aws"        // IAM role of the EC2 instance
+	AuthToken      = "token"      // Token in the VAULT_TOKEN environment variable
+	AuthAppRole    = "approle"    // AppRole role ID and secret ID
+	AuthKubernetes = "kubernetes" // Service account token of the pod
+)
+
+// DefaultKubernetesTokenFile is where Kubernetes mounts the service account
+// token into the containers of a pod.
+const DefaultKubernetesTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// login authenticates with the configured method and returns the token of the
+// session along with its lease.
+func login(client *vaultAPI.Client, config Config) (*vaultAPI.SecretAuth, error) {
+	method := config.Auth
+	if method == "" {
+		method = AuthAWS
+	}
+	mount := strings.Trim(config.AuthMount, "/")
+	if mount == "" {
+		mount = method
+	}
+	switch method {
+	case AuthAWS:
+		return loginAws(client, mount, config.Role)
+	case AuthToken:
+		token := os.Getenv(vaultAPI.EnvVaultToken)
+		if token == "" {
+			return nil, fmt.Errorf("%s is not set", vaultAPI.EnvVaultToken)
+		}
+		return lookupToken(client, token)
+	case AuthAppRole:
+		if config.RoleID == "" {
+			return nil, fmt.Errorf("no AppRole role ID")
+		}
+		data := map[string]interface{}{"role_id": config.RoleID}
+		if config.SecretID != "" {
+			data["secret_id"] = config.SecretID
+		}
+		return loginWith(client, mount, data)
+	case AuthKubernetes:
+		if config.Role == "" {
+			return nil, fmt.Errorf("no role to log in as with the kubernetes method")
+		}
+		file := config.JWTFile
+		if file == "" {
+			file = DefaultKubernetesTokenFile
+		}
+		jwt, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("can't read service account token: %v", err)
+		}
+		return loginWith(client, mount, map[string]interface{}{"role": config.Role, "jwt": strings.TrimSpace(string(jwt))})
+	default:
+		return nil, fmt.Errorf("unknown auth method %q, want %s, %s, %s or %s", method, AuthAWS, AuthToken, AuthAppRole, AuthKubernetes)
+	}
+}
+
+// loginWith logs in to the auth method mounted at mount with the given login
+// data and returns the issued token.
+func loginWith(v *vaultAPI.Client, mount string, data map[string]interface{}) (*vaultAPI.SecretAuth, error) {
+	secret, err := v.Logical().Write("auth/"+mount+"/login", data)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("empty response from credential provider")
+	}
+	if secret.Auth == nil {
+		return nil, fmt.Errorf("auth secret has no auth data")
+	}
+	return secret.Auth, nil
+}
+
+// lookupToken returns the lease of a token given to the node, which doesn't
+// log in itself.
+func lookupToken(v *vaultAPI.Client, token string) (*vaultAPI.SecretAuth, error) {
+	v.SetToken(token)
+	secret, err := v.Auth().Token().LookupSelf()
+	if err != nil {
+		return nil, err
+	}
+	ttl, err := secret.TokenTTL()
+	if err != nil {
+		return nil, err
+	}
+	renewable, err := secret.TokenIsRenewable()
+	if err != nil {
+		return nil, err
+	}
+	return &vaultAPI.SecretAuth{ClientToken: token, LeaseDuration: int(ttl / time.Second), Renewable: renewable}, nil
+}
@@ -0,0 +1,160 @@
+package vault
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	vaultAPI "github.com/hashicorp/vault/api"
+)
+
+// loginServer is a Vault server accepting logins for the role "node" at any
+// mount, and serving a secret to the token it issues.
+type loginServer struct {
+	*httptest.Server
+	path  string                 // Path of the last login
+	body  map[string]interface{} // Body of the last login
+	token string                 // Token the last secret was read with
+}
+
+func newLoginServer(t *testing.T) *loginServer {
+	s := new(loginServer)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/login"):
+			s.path, s.body = r.URL.Path, nil
+			if err := json.NewDecoder(r.Body).Decode(&s.body); err != nil {
+				t.Errorf("invalid login body: %v", err)
+			}
+			if role, ok := s.body["role"]; ok && role != "node" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errors": ["invalid role name \"` + role.(string) + `\""]}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": "issued-token"}})
+		case r.URL.Path == "/v1/auth/token/lookup-self":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"ttl": 0}})
+		case r.URL.Path == "/v1/secret/test":
+			s.token = r.Header.Get("X-Vault-Token")
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"key": "value"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return s
+}
+
+func TestLogin(t *testing.T) {
+	srv := newLoginServer(t)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "vault-auth-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	jwtFile := filepath.Join(dir, "token")
+	if err := ioutil.WriteFile(jwtFile, []byte("service-account-jwt\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		config Config
+		path   string
+		body   map[string]interface{}
+	}{
+		{
+			Config{Auth: AuthAppRole, RoleID: "role-id", SecretID: "secret-id"},
+			"/v1/auth/approle/login", map[string]interface{}{"role_id": "role-id", "secret_id": "secret-id"},
+		},
+		{
+			Config{Auth: AuthAppRole, RoleID: "role-id"},
+			"/v1/auth/approle/login", map[string]interface{}{"role_id": "role-id"},
+		},
+		{
+			Config{Auth: AuthAppRole, AuthMount: "/nodes/", RoleID: "role-id"},
+			"/v1/auth/nodes/login", map[string]interface{}{"role_id": "role-id"},
+		},
+		{
+			Config{Auth: AuthKubernetes, Role: "node", JWTFile: jwtFile},
+			"/v1/auth/kubernetes/login", map[string]interface{}{"role": "node", "jwt": "service-account-jwt"},
+		},
+		{
+			Config{Auth: AuthKubernetes, AuthMount: "k8s-east", Role: "node", JWTFile: jwtFile},
+			"/v1/auth/k8s-east/login", map[string]interface{}{"role": "node", "jwt": "service-account-jwt"},
+		},
+	}
+	for i, test := range tests {
+		test.config.Addr, test.config.Prefix = srv.URL, "secret"
+		client, err := NewClient(test.config)
+		if err != nil {
+			t.Errorf("test %d: login failed: %v", i, err)
+			continue
+		}
+		if srv.path != test.path || !reflect.DeepEqual(srv.body, test.body) {
+			t.Errorf("test %d: login mismatch:\nhave %s %v\nwant %s %v", i, srv.path, srv.body, test.path, test.body)
+		}
+		if _, err := client.Read("test", "key"); err != nil {
+			t.Errorf("test %d: read failed: %v", i, err)
+		}
+		if srv.token != "issued-token" {
+			t.Errorf("test %d: secret read with token %q, want the issued one", i, srv.token)
+		}
+	}
+}
+
+func TestLoginToken(t *testing.T) {
+	srv := newLoginServer(t)
+	defer srv.Close()
+
+	defer os.Setenv(vaultAPI.EnvVaultToken, os.Getenv(vaultAPI.EnvVaultToken))
+	os.Setenv(vaultAPI.EnvVaultToken, "given-token")
+
+	client, err := NewClient(Config{Addr: srv.URL, Prefix: "secret", Auth: AuthToken})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if srv.path != "" {
+		t.Errorf("logged in at %s with a given token", srv.path)
+	}
+	if _, err := client.Read("test", "key"); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if srv.token != "given-token" {
+		t.Errorf("secret read with token %q, want the given one", srv.token)
+	}
+
+	os.Setenv(vaultAPI.EnvVaultToken, "")
+	if _, err := NewClient(Config{Addr: srv.URL, Auth: AuthToken}); err == nil || !strings.Contains(err.Error(), vaultAPI.EnvVaultToken) {
+		t.Errorf("missing token error mismatch: %v", err)
+	}
+}
+
+func TestLoginErrors(t *testing.T) {
+	srv := newLoginServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{Auth: AuthKubernetes, Role: "other", JWTFile: os.Args[0]}, `invalid role name "other"`},
+		{Config{Auth: AuthKubernetes, Role: "node", JWTFile: "/no/such/token"}, "can't read service account token"},
+		{Config{Auth: AuthKubernetes, JWTFile: os.Args[0]}, "no role to log in as"},
+		{Config{Auth: AuthAppRole}, "no AppRole role ID"},
+		{Config{Auth: "ldap"}, `unknown auth method "ldap"`},
+	}
+	for i, test := range tests {
+		test.config.Addr = srv.URL
+		_, err := NewClient(test.config)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, test.err)
+		}
+	}
+}
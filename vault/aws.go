@@ -0,0 +1,49 @@
+package vault
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	vaultAPI "github.com/hashicorp/vault/api"
+	awsauth "github.com/hashicorp/vault/builtin/credential/aws"
+)
+
+// Expects to be running in EC2
+func getIAMRole() (string, error) {
+	svc := ec2metadata.New(session.New())
+	iam, err := svc.IAMInfo()
+	if err != nil {
+		return "", err
+	}
+	// Our instance profile conveniently has the same name as the role
+	profile := iam.InstanceProfileArn
+	splitArn := strings.Split(profile, "/")
+	if len(splitArn) < 2 {
+		return "", fmt.Errorf("no / character found in instance profile ARN")
+	}
+	role := splitArn[1]
+	return role, nil
+}
+
+// loginAws logs in as the given role, or as the role named like the instance
+// profile if empty.
+func loginAws(v *vaultAPI.Client, mount, role string) (*vaultAPI.SecretAuth, error) {
+	loginData, err := awsauth.GenerateLoginData("", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	if loginData == nil {
+		return nil, fmt.Errorf("got nil response from GenerateLoginData")
+	}
+
+	if role == "" {
+		if role, err = getIAMRole(); err != nil {
+			return nil, err
+		}
+	}
+	loginData["role"] = role
+
+	return loginWith(v, mount, loginData)
+}
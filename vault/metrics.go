@@ -0,0 +1,21 @@
+package vault
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	loginTimer        = metrics.NewTimer("vault/login")
+	loginFailureMeter = metrics.NewMeter("vault/login/failures")
+	readTimer         = metrics.NewTimer("vault/read")
+	readFailureMeter  = metrics.NewMeter("vault/read/failures")
+	writeTimer        = metrics.NewTimer("vault/write")
+	writeFailureMeter = metrics.NewMeter("vault/write/failures")
+	listTimer         = metrics.NewTimer("vault/list")
+	listFailureMeter  = metrics.NewMeter("vault/list/failures")
+	renewTimer        = metrics.NewTimer("vault/renew")
+	renewFailureMeter = metrics.NewMeter("vault/renew/failures")
+
+	transitTimer        = metrics.NewTimer("vault/transit")
+	transitFailureMeter = metrics.NewMeter("vault/transit/failures")
+)
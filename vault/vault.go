@@ -0,0 +1,329 @@
+// Package vault provides access to secrets kept in the KV engine of a
+// Hashicorp Vault installation. Clients authenticate using the AWS IAM
+// method by default, so the node is expected to be running on an EC2 instance
+// whose instance profile is bound to a Vault role of the same name. Nodes
+// elsewhere log in with a token, AppRole or their Kubernetes service account.
+package vault
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/logger"
+	"github.com/ethereum/go-ethereum/logger/glog"
+	vaultAPI "github.com/hashicorp/vault/api"
+)
+
+// NotFoundError is returned when the requested secret, or the requested key
+// within it, does not exist in Vault.
+type NotFoundError struct {
+	Path string // Full path of the secret
+	Key  string // Key within the secret, empty if the secret itself is missing
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("no secret found at %v", e.Path)
+	}
+	return fmt.Sprintf("secret at %v does not contain key %q", e.Path, e.Key)
+}
+
+// Config holds the settings required to reach a Vault KV engine.
+type Config struct {
+	Addr   string // Web address of the Vault server
+	Prefix string // Mount prefix of the KV engine, no outer slashes
+
+	Auth      string // Login method, one of the Auth constants, AWS IAM if empty
+	AuthMount string // Mount path of the auth method, the method name if empty
+	Role      string // Role for the kubernetes method, overrides the instance profile name for aws
+	RoleID    string // AppRole role ID
+	SecretID  string // AppRole secret ID, may be empty if the role doesn't require one
+	JWTFile   string // Service account token for the kubernetes method, DefaultKubernetesTokenFile if empty
+}
+
+// Client is an authenticated handle on a Vault KV engine.
+type Client struct {
+	client *vaultAPI.Client
+	prefix string
+
+	lock      sync.Mutex
+	lease     time.Duration // Remaining lease of the token when logged in or last renewed, zero if it doesn't expire
+	renewable bool
+	quit      chan struct{} // Stops the renewal loop, nil if not running
+
+	after func(time.Duration) <-chan time.Time // Timer of the renewal loop, time.After outside tests
+}
+
+// minRenewRetryDelay is the shortest time failed renewals are retried after.
+const minRenewRetryDelay = time.Second
+
+// NewClient creates a Vault client for the given configuration and logs in
+// using the configured auth method.
+func NewClient(config Config) (*Client, error) {
+	vaultConfig := vaultAPI.DefaultConfig()
+	vaultConfig.Address = config.Addr
+	client, err := vaultAPI.NewClient(vaultConfig)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	auth, err := login(client, config)
+	loginTimer.UpdateSince(start)
+	if err != nil {
+		loginFailureMeter.Mark(1)
+		return nil, err
+	}
+	client.SetToken(auth.ClientToken)
+
+	return &Client{
+		client:    client,
+		prefix:    strings.Trim(config.Prefix, "/"),
+		lease:     time.Duration(auth.LeaseDuration) * time.Second,
+		renewable: auth.Renewable,
+		after:     time.After,
+	}, nil
+}
+
+// fullPath returns the path of a secret including the KV engine's mount prefix.
+func (c *Client) fullPath(path string) string {
+	return "/" + c.prefix + "/" + strings.Trim(path, "/")
+}
+
+// Read retrieves the string value stored under key in the secret at path.
+// A *NotFoundError is returned if either does not exist, allowing callers to
+// populate missing secrets.
+func (c *Client) Read(path, key string) (string, error) {
+	fullPath := c.fullPath(path)
+	start := time.Now()
+	secret, err := c.client.Logical().Read(fullPath)
+	readTimer.UpdateSince(start)
+	if err != nil {
+		readFailureMeter.Mark(1)
+		return "", err
+	}
+	if secret == nil || secret.Data == nil {
+		return "", &NotFoundError{Path: fullPath}
+	}
+	value, present := secret.Data[key]
+	if !present {
+		return "", &NotFoundError{Path: fullPath, Key: key}
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("value of key %q at %v is not a string", key, fullPath)
+	}
+	return str, nil
+}
+
+// Write stores the given key/value pairs as the secret at path. Note that the
+// KV engine replaces the whole secret, so any keys not included are dropped.
+func (c *Client) Write(path string, data map[string]interface{}) error {
+	start := time.Now()
+	_, err := c.client.Logical().Write(c.fullPath(path), data)
+	writeTimer.UpdateSince(start)
+	if err != nil {
+		writeFailureMeter.Mark(1)
+	}
+	return err
+}
+
+// List returns the names of the secrets directly under path. Names of nested
+// paths end with a slash. Nothing is returned if there are no secrets.
+func (c *Client) List(path string) ([]string, error) {
+	fullPath := c.fullPath(path)
+	start := time.Now()
+	secret, err := c.client.Logical().List(fullPath)
+	listTimer.UpdateSince(start)
+	if err != nil {
+		listFailureMeter.Mark(1)
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+	keys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("no secret names in listing of %v", fullPath)
+	}
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("secret name in listing of %v is not a string", fullPath)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Delete removes the secret at path. Deleting a missing secret isn't an error.
+func (c *Client) Delete(path string) error {
+	start := time.Now()
+	_, err := c.client.Logical().Delete(c.fullPath(path))
+	writeTimer.UpdateSince(start)
+	if err != nil {
+		writeFailureMeter.Mark(1)
+	}
+	return err
+}
+
+// Renew extends the lease of the client's token by increment seconds, or by
+// the token's default TTL if increment is zero.
+func (c *Client) Renew(increment int) error {
+	start := time.Now()
+	secret, err := c.client.Auth().Token().RenewSelf(increment)
+	renewTimer.UpdateSince(start)
+	if err == nil && (secret == nil || secret.Auth == nil) {
+		err = fmt.Errorf("token renewal returned no auth data")
+	}
+	if err != nil {
+		renewFailureMeter.Mark(1)
+		return err
+	}
+	c.lock.Lock()
+	c.lease = time.Duration(secret.Auth.LeaseDuration) * time.Second
+	c.lock.Unlock()
+	return nil
+}
+
+// StartRenewal keeps renewing the client's token before its lease runs out,
+// until the client is closed. Tokens which don't expire, or can't be renewed,
+// are left alone.
+func (c *Client) StartRenewal() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.quit != nil || c.lease == 0 || !c.renewable {
+		return
+	}
+	c.quit = make(chan struct{})
+	go c.renewLoop(c.quit, c.lease)
+}
+
+// renewLoop renews the token once two thirds of its lease have passed. Failed
+// renewals are retried while the token is still valid.
+func (c *Client) renewLoop(quit chan struct{}, lease time.Duration) {
+	expiry := time.Now().Add(lease)
+	wait := lease * 2 / 3
+	for {
+		select {
+		case <-c.after(wait):
+		case <-quit:
+			return
+		}
+		err := c.Renew(0)
+		if err == nil {
+			c.lock.Lock()
+			lease = c.lease
+			c.lock.Unlock()
+			if lease == 0 {
+				return
+			}
+			expiry, wait = time.Now().Add(lease), lease*2/3
+			glog.V(logger.Detail).Infof("Renewed Vault token for %v", lease)
+			continue
+		}
+		remaining := expiry.Sub(time.Now())
+		if remaining <= 0 {
+			glog.V(logger.Error).Infof("Vault token expired, renewal failed: %v", err)
+			return
+		}
+		glog.V(logger.Warn).Infof("Failed to renew Vault token, %v left: %v", remaining, err)
+		if wait = remaining / 3; wait < minRenewRetryDelay {
+			wait = minRenewRetryDelay
+		}
+	}
+}
+
+// Close stops renewing the client's token.
+func (c *Client) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.quit != nil {
+		close(c.quit)
+		c.quit = nil
+	}
+	return nil
+}
+
+// GenerateDataKey creates a new 256 bit data key with the named key of the
+// transit engine mounted at mount, and returns it wrapped by that key only.
+// The plaintext key never leaves Vault until it's unwrapped.
+func (c *Client) GenerateDataKey(mount, name string) (string, error) {
+	secret, err := c.transit(mount, "datakey/wrapped", name, map[string]interface{}{"bits": 256})
+	if err != nil {
+		return "", err
+	}
+	ciphertext, ok := secret["ciphertext"].(string)
+	if !ok {
+		return "", fmt.Errorf("no wrapped data key in response of transit key %q", name)
+	}
+	return ciphertext, nil
+}
+
+// UnwrapDataKey decrypts a data key wrapped by GenerateDataKey.
+func (c *Client) UnwrapDataKey(mount, name, ciphertext string) ([]byte, error) {
+	secret, err := c.transit(mount, "decrypt", name, map[string]interface{}{"ciphertext": ciphertext})
+	if err != nil {
+		return nil, err
+	}
+	plaintext, ok := secret["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("no data key in response of transit key %q", name)
+	}
+	return base64.StdEncoding.DecodeString(plaintext)
+}
+
+// transit performs an operation with a named key of a transit engine.
+func (c *Client) transit(mount, op, name string, data map[string]interface{}) (map[string]interface{}, error) {
+	path := "/" + strings.Trim(mount, "/") + "/" + op + "/" + name
+	start := time.Now()
+	secret, err := c.client.Logical().Write(path, data)
+	transitTimer.UpdateSince(start)
+	if err != nil {
+		transitFailureMeter.Mark(1)
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		transitFailureMeter.Mark(1)
+		return nil, fmt.Errorf("empty response from %v", path)
+	}
+	return secret.Data, nil
+}
+
+// IsNotFound reports whether err was caused by a missing secret or key.
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// CheckToken verifies that token grants read access to the secret at path,
+// independently of any login performed by the node itself. Tokens issued with
+// a single use are consumed by the check, making them one-time credentials.
+func CheckToken(config Config, token, path string) error {
+	vaultConfig := vaultAPI.DefaultConfig()
+	vaultConfig.Address = config.Addr
+	client, err := vaultAPI.NewClient(vaultConfig)
+	if err != nil {
+		return err
+	}
+	client.SetToken(token)
+
+	c := &Client{client: client, prefix: strings.Trim(config.Prefix, "/")}
+	fullPath := c.fullPath(path)
+	start := time.Now()
+	secret, err := client.Logical().Read(fullPath)
+	readTimer.UpdateSince(start)
+	if err != nil {
+		readFailureMeter.Mark(1)
+		return err
+	}
+	if secret == nil {
+		return &NotFoundError{Path: fullPath}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	vaultAPI "github.com/hashicorp/vault/api"
+)
+
+// renewServer is a Vault server issuing tokens with a one second lease to
+// AppRole logins, and counting renewals of them.
+type renewServer struct {
+	*httptest.Server
+	renewable bool
+	renewals  int32
+}
+
+func newRenewServer(t *testing.T, renewable bool) *renewServer {
+	s := &renewServer{renewable: renewable}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := map[string]interface{}{"client_token": "test-token", "lease_duration": 1, "renewable": s.renewable}
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+		case "/v1/auth/token/renew-self":
+			if token := r.Header.Get("X-Vault-Token"); token != "test-token" {
+				t.Errorf("renewed with token %q", token)
+			}
+			atomic.AddInt32(&s.renewals, 1)
+		case "/v1/auth/token/lookup-self":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"ttl": 1, "renewable": s.renewable}})
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": auth})
+	}))
+	return s
+}
+
+// testClock replaces the timer of the renewal loop, firing only when told to.
+type testClock struct {
+	waits chan time.Duration // Durations the loop waited for
+	fire  chan time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{waits: make(chan time.Duration, 16), fire: make(chan time.Time)}
+}
+
+func (c *testClock) after(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.fire
+}
+
+// next returns the duration the renewal loop waits for next.
+func (c *testClock) next(t *testing.T) time.Duration {
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewal loop isn't waiting")
+		return 0
+	}
+}
+
+func TestRenewal(t *testing.T) {
+	srv := newRenewServer(t, true)
+	defer srv.Close()
+
+	client, err := NewClient(Config{Addr: srv.URL, Auth: AuthAppRole, RoleID: "test-role"})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	clock := newTestClock()
+	client.after = clock.after
+
+	// The one second lease is renewed every 2/3 of a second
+	quit, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		client.renewLoop(quit, client.lease)
+		close(done)
+	}()
+	for i := 0; i < 2; i++ {
+		if wait := clock.next(t); wait != 2*time.Second/3 {
+			t.Fatalf("renewal %d: waited %v, want %v", i, wait, 2*time.Second/3)
+		}
+		clock.fire <- time.Now()
+	}
+	clock.next(t)
+	if renewals := atomic.LoadInt32(&srv.renewals); renewals != 2 {
+		t.Errorf("token renewed %d times, want 2", renewals)
+	}
+	close(quit)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("renewal loop still running after quitting")
+	}
+
+	// Closing the client stops the loop it started
+	client.StartRenewal()
+	clock.next(t)
+	client.Close()
+	if client.quit != nil {
+		t.Error("renewal loop not stopped by closing")
+	}
+}
+
+func TestRenewalNotRenewable(t *testing.T) {
+	srv := newRenewServer(t, false)
+	defer srv.Close()
+
+	defer os.Setenv(vaultAPI.EnvVaultToken, os.Getenv(vaultAPI.EnvVaultToken))
+	os.Setenv(vaultAPI.EnvVaultToken, "test-token")
+
+	for _, auth := range []string{AuthAppRole, AuthToken} {
+		client, err := NewClient(Config{Addr: srv.URL, Auth: auth, RoleID: "test-role"})
+		if err != nil {
+			t.Fatalf("%s: login failed: %v", auth, err)
+		}
+		client.after = func(time.Duration) <-chan time.Time {
+			t.Errorf("%s: renewal of a token which can't be renewed scheduled", auth)
+			return nil
+		}
+		client.StartRenewal()
+		if client.quit != nil {
+			t.Errorf("%s: renewal loop started for a token which can't be renewed", auth)
+		}
+		client.Close()
+	}
+	if renewals := atomic.LoadInt32(&srv.renewals); renewals != 0 {
+		t.Errorf("token which can't be renewed renewed %d times", renewals)
+	}
+}